@@ -4,11 +4,12 @@ import (
 	"time"
 
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 )
 
 type AuthGetReq struct {
 	KBID       string            `json:"kb_id,omitempty"  query:"kb_id"`
-	SourceType consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github"`
+	SourceType consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github saml oidc ldap"`
 }
 
 type AuthGetResp struct {
@@ -17,6 +18,17 @@ type AuthGetResp struct {
 	Proxy        string            `json:"proxy"`
 	SourceType   consts.SourceType `json:"source_type"`
 	Auths        []AuthItem        `json:"auths"`
+
+	Issuer         string                  `json:"issuer"`
+	Scopes         []string                `json:"scopes"`
+	IDPMetadataURL string                  `json:"idp_metadata_url"`
+	IDPMetadataXML string                  `json:"idp_metadata_xml"`
+	EntityID       string                  `json:"entity_id"`
+	Certificate    string                  `json:"certificate"`
+	PrivateKey     string                  `json:"private_key"`
+	ClaimMapping   domain.AuthClaimMapping `json:"claim_mapping"`
+	SPMetadataURL  string                  `json:"sp_metadata_url,omitempty"` // SAML SP 元数据地址，需配置知识库访问地址
+	LDAP           domain.AuthLDAPSetting  `json:"ldap"`
 }
 
 type AuthItem struct {
@@ -31,10 +43,26 @@ type AuthItem struct {
 
 type AuthSetReq struct {
 	KBID         string            `json:"kb_id,omitempty"`
-	SourceType   consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github"`
+	SourceType   consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github saml oidc ldap"`
 	ClientID     string            `json:"client_id"`
 	ClientSecret string            `json:"client_secret"`
 	Proxy        string            `json:"proxy"`
+
+	// OIDC
+	Issuer string   `json:"issuer"`
+	Scopes []string `json:"scopes"`
+
+	// SAML
+	IDPMetadataURL string `json:"idp_metadata_url"`
+	IDPMetadataXML string `json:"idp_metadata_xml"`
+	EntityID       string `json:"entity_id"`
+	Certificate    string `json:"certificate"`
+	PrivateKey     string `json:"private_key"`
+
+	ClaimMapping domain.AuthClaimMapping `json:"claim_mapping"`
+
+	// LDAP
+	LDAP domain.AuthLDAPSetting `json:"ldap"`
 }
 
 type AuthSetResp struct{}
@@ -46,3 +74,35 @@ type AuthDeleteReq struct {
 
 type AuthDeleteResp struct {
 }
+
+type SCIMGetReq struct {
+	KbID string `query:"kb_id" json:"kb_id"`
+}
+
+type SCIMGetResp struct {
+	Enabled     bool   `json:"enabled"`
+	Endpoint    string `json:"endpoint"` // SCIM 基础地址，需配置知识库访问地址
+	UnionIDAttr string `json:"union_id_attr"`
+}
+
+type SCIMSetReq struct {
+	KbID        string `json:"kb_id"`
+	UnionIDAttr string `json:"union_id_attr" validate:"omitempty,oneof=userName externalId"`
+}
+
+type SCIMSetResp struct {
+	Token string `json:"token"` // 仅返回一次，重新设置会生成新 token
+}
+
+type SCIMDeleteReq struct {
+	KbID string `query:"kb_id" json:"kb_id"`
+}
+
+type LDAPGroupSyncReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+}
+
+type LDAPGroupSyncResp struct {
+	Groups  int `json:"groups"`  // 同步的分组数
+	Members int `json:"members"` // 已匹配到登录用户的成员关系数
+}
@@ -1,5 +1,7 @@
 package v1
 
+import "github.com/chaitin/panda-wiki/domain"
+
 type GetConversationDetailReq struct {
 	KbId string `query:"kb_id" json:"kb_id" validate:"required"`
 	ID   string `query:"id" json:"id" validate:"required"`
@@ -15,3 +17,59 @@ type GetMessageDetailReq struct {
 
 type GetMessageDetailResp struct {
 }
+
+type CuratedAnswerListReq struct {
+	KbID    string `json:"kb_id" query:"kb_id" validate:"required"`
+	Keyword string `json:"keyword" query:"keyword"` // 匹配问题、相似问法和答案
+	domain.Pager
+}
+
+type CuratedAnswerListItem struct {
+	domain.CuratedAnswer
+	Nodes []CuratedAnswerNode `json:"nodes"`
+}
+
+type CuratedAnswerNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreateCuratedAnswerReq struct {
+	KbID       string   `json:"kb_id" validate:"required"`
+	Question   string   `json:"question" validate:"required,max=500"`
+	Alternates []string `json:"alternates" validate:"max=20,dive,max=500"`
+	Answer     string   `json:"answer" validate:"required,max=10000"`
+	NodeIDs    []string `json:"node_ids" validate:"max=10"`
+}
+
+type UpdateCuratedAnswerReq struct {
+	ID      string `json:"id" validate:"required"`
+	Enabled bool   `json:"enabled"`
+	CreateCuratedAnswerReq
+}
+
+type DeleteCuratedAnswerReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+// PromoteCuratedAnswerReq 把对话中的回答添加为标准答案，问题取对应的用户提问，关联回答引用的文档
+type PromoteCuratedAnswerReq struct {
+	KbID      string `json:"kb_id" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"`
+}
+
+type CuratedAnswerHitListReq struct {
+	KbID     string `json:"kb_id" query:"kb_id" validate:"required"`
+	AnswerID string `json:"answer_id" query:"answer_id"`
+	domain.Pager
+}
+
+type CuratedAnswerSettingReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type UpdateCuratedAnswerSettingReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	domain.CuratedAnswerSetting
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 )
 
 type KBUserListReq struct {
@@ -40,3 +41,36 @@ type KBUserDeleteReq struct {
 
 type KBUserDeleteResp struct {
 }
+
+type KBStorageReq struct {
+	KBId string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type KBStorageResp struct {
+	Quota int64 `json:"quota"` // 存储配额，单位字节，0 表示不限制
+	domain.AttachmentUsage
+}
+
+type KBStorageQuotaReq struct {
+	KBId  string `json:"kb_id" validate:"required"`
+	Quota int64  `json:"quota" validate:"min=0"`
+}
+
+type KBReleaseExportReq struct {
+	KBId      string `json:"kb_id" validate:"required"`
+	ReleaseId string `json:"release_id" validate:"required"`
+}
+
+type KBReleaseExportListReq struct {
+	KBId string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type KBReleaseExportItemReq struct {
+	KBId string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type KBReleaseExportListItem struct {
+	domain.KBReleaseExport
+	ReleaseTag string `json:"release_tag"`
+}
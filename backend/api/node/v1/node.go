@@ -32,6 +32,7 @@ type NodeDetailResp struct {
 	EditorAccount    string                 `json:"editor_account"`
 	PublisherAccount string                 `json:"publisher_account" gorm:"-"`
 	PV               int64                  `json:"pv" gorm:"-"`
+	Revision         int64                  `json:"revision"`
 }
 
 type NodePermissionReq struct {
@@ -81,6 +82,7 @@ type NodeMoveNavReq struct {
 	IDs   []string `json:"ids" query:"[]ids" validate:"required,min=1"`
 	KbID  string   `json:"kb_id" validate:"required"`
 	NavID string   `json:"nav_id" validate:"required"`
+	Force bool     `json:"force"` // 文档被其他文档链接时仍然移动
 }
 
 type NodeListGroupNavReq struct {
@@ -98,3 +100,344 @@ type NodeListGroupNavResp struct {
 	IsReleased bool                      `json:"is_released"`
 	List       []domain.NodeListItemResp `json:"list"`
 }
+
+type NodeDeadLetterListReq struct {
+	KbID string `query:"kb_id" json:"kb_id" validate:"required"`
+	domain.Pager
+}
+
+type NodeDeadLetterRedriveReq struct {
+	KbID string   `json:"kb_id" validate:"required"`
+	IDs  []string `json:"ids" validate:"required,min=1"`
+}
+
+type NodeRecycleListReq struct {
+	KbID string `query:"kb_id" json:"kb_id" validate:"required"`
+	domain.Pager
+}
+
+type NodeRecycleListItem struct {
+	ID               string          `json:"id"`
+	NodeID           string          `json:"node_id"`
+	NavId            string          `json:"nav_id"`
+	NavName          string          `json:"nav_name"`
+	ParentID         string          `json:"parent_id"`
+	Type             domain.NodeType `json:"type"`
+	Name             string          `json:"name"`
+	NodeCount        int             `json:"node_count"` // 子树内的节点数，包括自身
+	DeletedBy        string          `json:"deleted_by"`
+	DeletedByAccount string          `json:"deleted_by_account"`
+	DeletedAt        time.Time       `json:"deleted_at"`
+	ExpiredAt        time.Time       `json:"expired_at" gorm:"-"` // 超过该时间后彻底删除
+}
+
+type NodeRecycleRestoreReq struct {
+	KbID     string  `json:"kb_id" validate:"required"`
+	ID       string  `json:"id" validate:"required"`
+	ParentID *string `json:"parent_id"` // 不传时恢复到原位置，空字符串表示栏目根目录
+	NavID    string  `json:"nav_id"`    // 恢复到根目录时指定栏目，不传时使用原栏目
+	MaxNode  int     `json:"-"`
+}
+
+type NodeRecyclePurgeReq struct {
+	KbID string   `json:"kb_id" validate:"required"`
+	IDs  []string `json:"ids" validate:"required,min=1"`
+}
+
+type NodeRecycleRetentionReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	Days int    `json:"days" validate:"required,min=1,max=3650"`
+}
+
+type NodeMetaSchemaReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type UpdateNodeMetaSchemaReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	domain.NodeMetaSchema
+}
+
+type UpdateNodeResp struct {
+	Revision int64 `json:"revision"`
+}
+
+// NodeConflictResp 保存冲突时返回当前版本和三路合并的建议结果
+type NodeConflictResp struct {
+	ID              string             `json:"id"`
+	BaseRevision    int64              `json:"base_revision"`
+	CurrentRevision int64              `json:"current_revision"`
+	EditorId        string             `json:"editor_id"`
+	EditorAccount   string             `json:"editor_account"`
+	EditTime        time.Time          `json:"edit_time"`
+	Current         NodeConflictFields `json:"current"`
+	Merged          NodeConflictMerged `json:"merged"`
+}
+
+type NodeConflictFields struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type NodeConflictMerged struct {
+	NodeConflictFields
+	Conflicts   int  `json:"conflicts"`    // 冲突块数，为 0 时可直接以当前修订号保存
+	BaseMissing bool `json:"base_missing"` // 共同祖先已被清理，合并结果可能包含更多冲突
+}
+
+type NodePresenceReq struct {
+	KbID    string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID      string `json:"id" query:"id" validate:"required"`
+	Editing bool   `json:"editing"` // 为 true 时尝试获取编辑锁，为 false 时释放自己持有的锁
+}
+
+type NodeCollabTicketReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
+
+type NodeCollabTicketResp struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"` // 秒
+}
+
+type NodeCollabReq struct {
+	KbID   string `query:"kb_id" validate:"required"`
+	ID     string `query:"id" validate:"required"`
+	Ticket string `query:"ticket" validate:"required"` // 浏览器无法为 WebSocket 设置请求头，使用一次性凭证代替登录凭证
+}
+
+type NodeExportReq struct {
+	KbID   string `query:"kb_id" validate:"required"`
+	ID     string `query:"id" validate:"required"` // 文档或文件夹，文件夹导出其下所有节点
+	Format string `query:"format" validate:"required,oneof=md docx pdf epub"`
+}
+
+type NodePresenceResp struct {
+	Users      []*domain.NodePresence `json:"users"`       // 包括自己在内的在线用户
+	LockHolder *domain.NodePresence   `json:"lock_holder"` // 编辑锁持有人，无人编辑时为 null
+	Locked     bool                   `json:"locked"`      // 自己是否持有编辑锁
+}
+
+type NodeTemplateListReq struct {
+	KbID string `query:"kb_id" validate:"required"`
+}
+
+type CreateNodeTemplateReq struct {
+	KbID        string                   `json:"kb_id" validate:"required"`
+	Name        string                   `json:"name" validate:"required,max=100"`
+	Description string                   `json:"description" validate:"max=500"`
+	Type        domain.NodeType          `json:"type" validate:"required,oneof=1 2"`
+	Content     string                   `json:"content"`
+	ContentType string                   `json:"content_type" validate:"omitempty,oneof=md html"`
+	Emoji       string                   `json:"emoji"`
+	Permissions domain.NodePermissions   `json:"permissions"` // 为空的项默认完全开放，不支持部分开放
+	Children    domain.NodeTemplateNodes `json:"children" validate:"omitempty,dive"`
+}
+
+type UpdateNodeTemplateReq struct {
+	ID string `json:"id" validate:"required"`
+	CreateNodeTemplateReq
+}
+
+type DeleteNodeTemplateReq struct {
+	KbID string `query:"kb_id" validate:"required"`
+	ID   string `query:"id" validate:"required"`
+}
+
+// CreateNodeFromTemplateReq 名称和内容中的 {{date}}、{{time}}、{{author}}、{{parent_name}}、{{kb_name}}、{{name}} 由服务端替换
+type CreateNodeFromTemplateReq struct {
+	KbID       string   `json:"kb_id" validate:"required"`
+	NavId      string   `json:"nav_id" validate:"required"`
+	ParentID   string   `json:"parent_id"`
+	TemplateID string   `json:"template_id" validate:"required"`
+	Name       string   `json:"name" validate:"required"`
+	Position   *float64 `json:"position"`
+}
+
+type CreateNodeFromTemplateResp struct {
+	ID      string   `json:"id"`
+	NodeIDs []string `json:"node_ids"` // 包括子节点，按创建顺序排列
+}
+
+type NodeBacklinksReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+// NodeBacklinkItem 链接到该文档的文档
+type NodeBacklinkItem struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Type      domain.NodeType `json:"type"`
+	Emoji     string          `json:"emoji"`
+	URL       string          `json:"url"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type NodeLinkGraphReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type NodeLinkGraphResp struct {
+	Nodes []*NodeLinkGraphNode `json:"nodes"`
+	Edges []*NodeLinkGraphEdge `json:"edges"`
+}
+
+type NodeLinkGraphNode struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Type     domain.NodeType `json:"type"`
+	ParentID string          `json:"parent_id"`
+}
+
+type NodeLinkGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type BrokenLinkListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+// BrokenLinkItem 失效的链接，站内链接指向的文档已删除，外部链接由定时任务检查
+type BrokenLinkItem struct {
+	NodeID     string     `json:"node_id"`
+	NodeName   string     `json:"node_name"`
+	URL        string     `json:"url"`
+	Internal   bool       `json:"internal"`
+	StatusCode int        `json:"status_code"`
+	Error      string     `json:"error"`
+	CheckedAt  *time.Time `json:"checked_at"`
+}
+
+type NodeReviewReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+// NodeReviewResp 节点自身的设置和继承后生效的设置
+type NodeReviewResp struct {
+	OwnerID        string                  `json:"owner_id"`
+	IntervalDays   int                     `json:"interval_days"`
+	Effective      domain.NodeReviewPolicy `json:"effective"`
+	OwnerAccount   string                  `json:"owner_account"`
+	ReviewedAt     *time.Time              `json:"reviewed_at"`
+	LastReviewedAt time.Time               `json:"last_reviewed_at"` // 最近一次编辑或确认复查的时间
+	DueAt          *time.Time              `json:"due_at"`
+	Overdue        bool                    `json:"overdue"`
+}
+
+// UpdateNodeReviewReq 设置负责人和复查周期，空负责人和 0 天表示继承上级
+type UpdateNodeReviewReq struct {
+	KbID         string `json:"kb_id" validate:"required"`
+	ID           string `json:"id" validate:"required"`
+	OwnerID      string `json:"owner_id"`
+	IntervalDays int    `json:"interval_days" validate:"min=0,max=3650"`
+}
+
+type MarkNodeReviewedReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
+
+type NodeReviewSettingReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type UpdateNodeReviewSettingReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	domain.NodeReviewSetting
+}
+
+type NodeReviewReportReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+// NodeReviewReportResp 过期、发布后无人访问和评价较差的文档
+type NodeReviewReportResp struct {
+	Stale       []*StaleNodeItem    `json:"stale"`
+	NeverViewed []*NodeReportItem   `json:"never_viewed"`
+	LowRated    []*LowRatedNodeItem `json:"low_rated"`
+}
+
+type NodeReportItem struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	OwnerID      string    `json:"owner_id"`
+	OwnerAccount string    `json:"owner_account"`
+	EditTime     time.Time `json:"edit_time"`
+}
+
+type StaleNodeItem struct {
+	NodeReportItem
+	IntervalDays   int        `json:"interval_days"`
+	LastReviewedAt time.Time  `json:"last_reviewed_at"`
+	DueAt          time.Time  `json:"due_at"`
+	OverdueDays    int        `json:"overdue_days"`
+	RemindedAt     *time.Time `json:"reminded_at"`
+}
+
+type LowRatedNodeItem struct {
+	NodeReportItem
+	HelpfulCount   int64 `json:"helpful_count"`
+	UnhelpfulCount int64 `json:"unhelpful_count"`
+}
+
+type NodeFAQListReq struct {
+	KbID   string               `json:"kb_id" query:"kb_id" validate:"required"`
+	NodeID string               `json:"node_id" query:"node_id"`
+	Status domain.NodeFAQStatus `json:"status" query:"status" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+type NodeFAQItem struct {
+	domain.NodeFAQ
+	NodeName string `json:"node_name"`
+}
+
+type GenerateNodeFAQReq struct {
+	KbID string   `json:"kb_id" validate:"required"`
+	IDs  []string `json:"ids" validate:"required,min=1"`
+}
+
+type UpdateNodeFAQReq struct {
+	KbID     string `json:"kb_id" validate:"required"`
+	ID       string `json:"id" validate:"required"`
+	Question string `json:"question" validate:"required,max=500"`
+	Answer   string `json:"answer" validate:"required,max=5000"`
+}
+
+// UpdateNodeFAQStatusReq 通过的问答对写入检索记录，改为其他状态时删除检索记录
+type UpdateNodeFAQStatusReq struct {
+	KbID   string               `json:"kb_id" validate:"required"`
+	IDs    []string             `json:"ids" validate:"required,min=1"`
+	Status domain.NodeFAQStatus `json:"status" validate:"required,oneof=pending approved rejected"`
+}
+
+type DeleteNodeFAQReq struct {
+	KbID string   `json:"kb_id" query:"kb_id" validate:"required"`
+	IDs  []string `json:"ids" query:"ids" validate:"required,min=1"`
+}
+
+// PromoteNodeFAQReq 把问答对整理成常见问题文档，或加入首页的常见问题模块
+type PromoteNodeFAQReq struct {
+	KbID     string   `json:"kb_id" validate:"required"`
+	IDs      []string `json:"ids" validate:"required,min=1"`
+	Target   string   `json:"target" validate:"required,oneof=node landing"`
+	NavID    string   `json:"nav_id" validate:"required_if=Target node"`
+	ParentID string   `json:"parent_id"`
+	Name     string   `json:"name"` // 文档名称，默认为“常见问题”
+}
+
+type PromoteNodeFAQResp struct {
+	NodeID string `json:"node_id,omitempty"`
+}
+
+type NodeFAQSettingReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type UpdateNodeFAQSettingReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	domain.NodeFAQSetting
+}
@@ -33,3 +33,31 @@ type GitHubCallbackReq struct {
 
 type GitHubCallbackResp struct {
 }
+
+type AuthOIDCReq struct {
+	KbID        string `json:"kb_id"`
+	RedirectUrl string `json:"redirect_url"`
+}
+
+type AuthOIDCResp struct {
+	Url string `json:"url"`
+}
+
+type OIDCCallbackReq struct {
+	Code  string `json:"code" query:"code"`
+	State string `json:"state" query:"state"`
+}
+
+type AuthSAMLReq struct {
+	KbID        string `json:"kb_id"`
+	RedirectUrl string `json:"redirect_url"`
+}
+
+type AuthSAMLResp struct {
+	Url string `json:"url"`
+}
+
+type SAMLACSReq struct {
+	KbID       string `param:"kb_id"`
+	RelayState string `form:"RelayState"`
+}
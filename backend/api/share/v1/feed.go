@@ -0,0 +1,6 @@
+package v1
+
+type ShareFeedReq struct {
+	NavID string `json:"nav_id" query:"nav_id"`
+	Limit int    `json:"limit" query:"limit" validate:"omitempty,min=1,max=100"` // 默认 20 条
+}
@@ -54,3 +54,83 @@ type StatConversationDistributionResp struct {
 	AppType domain.AppType `json:"app_type"`
 	Count   int64          `json:"count"`
 }
+
+type StatReportListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type StatReportCreateReq struct {
+	KbID     string                  `json:"kb_id" validate:"required"`
+	Name     string                  `json:"name" validate:"required"`
+	Period   consts.StatReportPeriod `json:"period" validate:"required,oneof=daily weekly"`
+	Enabled  bool                    `json:"enabled"`
+	Channels []domain.NotifyChannel  `json:"channels" validate:"required,min=1,dive"`
+}
+
+type StatReportUpdateReq struct {
+	ID       string                  `json:"id" validate:"required"`
+	KbID     string                  `json:"kb_id" validate:"required"`
+	Name     string                  `json:"name" validate:"required"`
+	Period   consts.StatReportPeriod `json:"period" validate:"required,oneof=daily weekly"`
+	Enabled  bool                    `json:"enabled"`
+	Channels []domain.NotifyChannel  `json:"channels" validate:"required,min=1,dive"`
+}
+
+type StatReportDeleteReq struct {
+	ID   string `json:"id" query:"id" validate:"required"`
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type StatReportSendReq struct {
+	ID   string `json:"id" validate:"required"`
+	KbID string `json:"kb_id" validate:"required"`
+}
+
+type StatReportPreviewReq struct {
+	KbID   string                  `json:"kb_id" query:"kb_id" validate:"required"`
+	Period consts.StatReportPeriod `json:"period" query:"period" validate:"required,oneof=daily weekly"`
+}
+
+type StatNodeEngagementReq struct {
+	KbID string         `json:"kb_id" query:"kb_id" validate:"required"`
+	Day  consts.StatDay `json:"day" query:"day" validate:"omitempty,oneof=1 7 30 90"`
+}
+
+type StatNodeAnalyticsReq struct {
+	KbID   string         `json:"kb_id" query:"kb_id" validate:"required"`
+	NodeID string         `json:"node_id" query:"node_id" validate:"required"`
+	Day    consts.StatDay `json:"day" query:"day" validate:"omitempty,oneof=1 7 30 90"`
+}
+
+type StatNodeAnalyticsResp struct {
+	Summary  *domain.NodeEngagement            `json:"summary"`
+	Trend    []*domain.NodeEngagementTrendItem `json:"trend"`
+	TopLinks []*domain.NodeLinkClick           `json:"top_links"`
+}
+
+type StatTrendReq struct {
+	KbID        string                 `json:"kb_id" query:"kb_id" validate:"required"`
+	Start       string                 `json:"start" query:"start" validate:"required"` // 2006-01-02
+	End         string                 `json:"end" query:"end" validate:"required"`     // 2006-01-02，包含当天
+	Granularity consts.StatGranularity `json:"granularity" query:"granularity" validate:"omitempty,oneof=day month"`
+}
+
+type StatTrendResp struct {
+	Granularity consts.StatGranularity  `json:"granularity"`
+	Items       []*domain.StatTrendItem `json:"items"`
+}
+
+type StatRangeReq struct {
+	KbID  string `json:"kb_id" query:"kb_id" validate:"required"`
+	Start string `json:"start" query:"start" validate:"required"` // 2006-01-02
+	End   string `json:"end" query:"end" validate:"required"`     // 2006-01-02，包含当天
+}
+
+type StatRangeResp struct {
+	StatCountResp
+	HotPages                 []*domain.HotPage                  `json:"hot_pages"`
+	HotRefererHosts          []*domain.HotRefererHost           `json:"hot_referer_hosts"`
+	HotBrowser               *domain.HotBrowser                 `json:"hot_browser"`
+	GeoCount                 map[string]int64                   `json:"geo_count"`
+	ConversationDistribution []StatConversationDistributionResp `json:"conversation_distribution"`
+}
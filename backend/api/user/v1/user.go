@@ -23,6 +23,9 @@ type UserInfoResp struct {
 	IsToken    bool            `json:"is_token"`
 	LastAccess *time.Time      `json:"last_access,omitempty"`
 	CreatedAt  time.Time       `json:"created_at"`
+
+	SourceType  consts.SourceType `json:"source_type"` // 为空表示本地账号
+	TOTPEnabled bool              `json:"totp_enabled"`
 }
 
 type UserListReq struct {
@@ -39,10 +42,12 @@ type UserListItemResp struct {
 type LoginReq struct {
 	Account  string `json:"account" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	TOTPCode string `json:"totp_code"` // 开启两步验证后必填，也可填写恢复码
 }
 
 type LoginResp struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	TOTPRequired bool   `json:"totp_required,omitempty"` // 密码正确但需要两步验证码
 }
 
 type UserListResp struct {
@@ -57,3 +62,48 @@ type ResetPasswordReq struct {
 type DeleteUserReq struct {
 	UserID string `json:"user_id" query:"user_id" validate:"required"`
 }
+
+type TOTPSetupResp struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"` // otpauth:// 链接，用于生成二维码
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TOTPRecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 仅返回一次，请妥善保存
+}
+
+type TOTPResetReq struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type SSOInfoResp struct {
+	Enabled    bool              `json:"enabled"`
+	SourceType consts.SourceType `json:"source_type"`
+}
+
+type SSOLoginReq struct {
+	RedirectUrl string `json:"redirect_url" validate:"required"`
+}
+
+type SSOLoginResp struct {
+	Url string `json:"url"`
+}
+
+type SSOCallbackReq struct {
+	State  string `query:"state"`
+	Code   string `query:"code"`   // oidc/oauth
+	Ticket string `query:"ticket"` // cas
+}
+
+type SSOExchangeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type LDAPLoginReq struct {
+	Account  string `json:"account" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/chaitin/panda-wiki/metrics"
 	"github.com/chaitin/panda-wiki/setup"
 )
 
@@ -22,6 +23,9 @@ func main() {
 	if err := setup.CheckInitCert(); err != nil {
 		panic(err)
 	}
+	if app.Config.Metrics.Enabled {
+		metrics.Serve(app.Logger, app.Config.Metrics.Port)
+	}
 	port := app.Config.HTTP.Port
 	app.Logger.Info(fmt.Sprintf("Starting server on port %d", port))
 	app.HTTPServer.Echo.Logger.Fatal(app.HTTPServer.Echo.Start(fmt.Sprintf(":%d", port)))
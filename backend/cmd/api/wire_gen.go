@@ -74,14 +74,24 @@ func createApp() (*App, error) {
 	ragRepository := mq2.NewRAGRepository(mqProducer)
 	userRepository := pg2.NewUserRepository(db, logger)
 	kbRepo := cache2.NewKBRepo(cacheCache)
-	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, navRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig)
+	nodeLinkRepository := pg2.NewNodeLinkRepository(db, logger)
+	nodeLinkUsecase := usecase.NewNodeLinkUsecase(nodeLinkRepository, nodeRepository, logger)
+	nodeCollabTicketRepo := cache2.NewNodeCollabTicketRepo(cacheCache)
+	nodeCollabUsecase := usecase.NewNodeCollabUsecase(nodeRepository, nodeCollabTicketRepo, logger)
+	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, navRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig, nodeLinkUsecase, nodeCollabUsecase)
 	if err != nil {
 		return nil, err
 	}
-	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, knowledgeBaseUsecase)
+	authRepo := pg2.NewAuthRepo(db, logger, cacheCache)
+	authUsecase, err := usecase.NewAuthUsecase(authRepo, logger, knowledgeBaseRepository, cacheCache)
+	if err != nil {
+		return nil, err
+	}
+	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, knowledgeBaseUsecase, authUsecase)
 	captchaCaptcha := captcha.NewCaptcha()
 	baseHandler := handler.NewBaseHandler(echo, logger, configConfig, authMiddleware, shareAuthMiddleware, captchaCaptcha)
-	userUsecase, err := usecase.NewUserUsecase(userRepository, logger, configConfig)
+	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
+	userUsecase, err := usecase.NewUserUsecase(userRepository, systemSettingRepo, logger, configConfig, cacheCache)
 	if err != nil {
 		return nil, err
 	}
@@ -89,18 +99,39 @@ func createApp() (*App, error) {
 	conversationRepository := pg2.NewConversationRepository(db, logger)
 	modelRepository := pg2.NewModelRepository(db, logger)
 	promptRepo := pg2.NewPromptRepo(db, logger)
-	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, logger)
-	knowledgeBaseHandler := v1.NewKnowledgeBaseHandler(baseHandler, echo, knowledgeBaseUsecase, llmUsecase, authMiddleware, logger)
-	appRepository := pg2.NewAppRepository(db, logger)
-	minioClient, err := s3.NewMinioClient(configConfig)
+	attachmentRepository := pg2.NewAttachmentRepository(db, logger)
+	nodeFAQRepository := pg2.NewNodeFAQRepository(db, logger)
+	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, attachmentRepository, nodeFAQRepository, logger)
+	objectStore, err := s3.NewObjectStore(configConfig)
 	if err != nil {
 		return nil, err
 	}
-	authRepo := pg2.NewAuthRepo(db, logger, cacheCache)
-	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
+	attachmentUsecase := usecase.NewAttachmentUsecase(attachmentRepository, knowledgeBaseRepository, objectStore, logger)
+	kbExportRepository := pg2.NewKBExportRepository(db, logger)
+	kbExportTaskRepository := mq2.NewKBExportTaskRepository(mqProducer)
+	appRepository := pg2.NewAppRepository(db, logger)
+	kbExportUsecase := usecase.NewKBExportUsecase(kbExportRepository, kbExportTaskRepository, knowledgeBaseRepository, nodeRepository, navRepository, appRepository, objectStore, logger)
+	knowledgeBaseHandler := v1.NewKnowledgeBaseHandler(baseHandler, echo, knowledgeBaseUsecase, llmUsecase, attachmentUsecase, kbExportUsecase, authMiddleware, logger)
 	modelUsecase := usecase.NewModelUsecase(modelRepository, nodeRepository, ragRepository, ragService, logger, configConfig, knowledgeBaseRepository, systemSettingRepo)
-	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase)
-	nodeHandler := v1.NewNodeHandler(baseHandler, echo, nodeUsecase, authMiddleware, logger)
+	mqDeadLetterRepository := pg2.NewMQDeadLetterRepository(db)
+	nodePresenceRepo := cache2.NewNodePresenceRepo(cacheCache)
+	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, objectStore, modelRepository, authRepo, modelUsecase, mqDeadLetterRepository, nodePresenceRepo, nodeLinkRepository)
+	nodeExportUsecase := usecase.NewNodeExportUsecase(nodeRepository, objectStore, configConfig, logger)
+	nodeHandler := v1.NewNodeHandler(baseHandler, echo, nodeUsecase, nodeExportUsecase, authMiddleware, logger)
+	nodeCollabHandler := v1.NewNodeCollabHandler(baseHandler, echo, nodeCollabUsecase, authMiddleware, configConfig, logger)
+	nodeTemplateRepository := pg2.NewNodeTemplateRepository(db, logger)
+	nodeTemplateUsecase := usecase.NewNodeTemplateUsecase(nodeTemplateRepository, nodeRepository, knowledgeBaseRepository, userRepository, logger)
+	nodeTemplateHandler := v1.NewNodeTemplateHandler(baseHandler, echo, nodeTemplateUsecase, authMiddleware, logger)
+	nodeLinkHandler := v1.NewNodeLinkHandler(baseHandler, echo, nodeLinkUsecase, authMiddleware, logger)
+	nodeReviewRepository := pg2.NewNodeReviewRepository(db, logger)
+	statRepository := pg2.NewStatRepository(db, cacheCache)
+	nodeReviewUsecase := usecase.NewNodeReviewUsecase(nodeReviewRepository, nodeRepository, knowledgeBaseRepository, userRepository, statRepository, systemSettingRepo, logger)
+	nodeReviewHandler := v1.NewNodeReviewHandler(baseHandler, echo, nodeReviewUsecase, authMiddleware, logger)
+	nodeFAQUsecase := usecase.NewNodeFAQUsecase(nodeFAQRepository, nodeRepository, knowledgeBaseRepository, appRepository, ragRepository, ragService, llmUsecase, modelUsecase, logger)
+	nodeFAQHandler := v1.NewNodeFAQHandler(baseHandler, echo, nodeFAQUsecase, authMiddleware, logger)
+	curatedAnswerRepository := pg2.NewCuratedAnswerRepository(db, logger)
+	curatedAnswerUsecase := usecase.NewCuratedAnswerUsecase(curatedAnswerRepository, knowledgeBaseRepository, nodeRepository, conversationRepository, ragService, logger)
+	curatedAnswerHandler := v1.NewCuratedAnswerHandler(baseHandler, echo, curatedAnswerUsecase, authMiddleware, logger)
 	geoRepo := cache2.NewGeoCache(cacheCache, db, logger)
 	ipdbIPDB, err := ipdb.NewIPDB(configConfig, logger)
 	if err != nil {
@@ -109,44 +140,49 @@ func createApp() (*App, error) {
 	ipAddressRepo := ipdb2.NewIPAddressRepo(ipdbIPDB, logger)
 	conversationUsecase := usecase.NewConversationUsecase(conversationRepository, nodeRepository, geoRepo, logger, ipAddressRepo, authRepo)
 	blockWordRepo := pg2.NewBlockWordRepo(db, logger)
-	chatUsecase, err := usecase.NewChatUsecase(llmUsecase, knowledgeBaseRepository, conversationUsecase, modelUsecase, appRepository, blockWordRepo, nodeRepository, authRepo, logger)
+	chatUsecase, err := usecase.NewChatUsecase(llmUsecase, knowledgeBaseRepository, conversationUsecase, modelUsecase, appRepository, blockWordRepo, nodeRepository, authRepo, curatedAnswerUsecase, logger)
 	if err != nil {
 		return nil, err
 	}
 	appUsecase := usecase.NewAppUsecase(appRepository, authRepo, navRepository, nodeRepository, knowledgeBaseRepository, nodeUsecase, logger, configConfig, chatUsecase, cacheCache)
 	appHandler := v1.NewAppHandler(echo, baseHandler, logger, authMiddleware, appUsecase, modelUsecase, conversationUsecase, configConfig)
-	fileUsecase := usecase.NewFileUsecase(logger, minioClient, configConfig, systemSettingRepo)
-	fileHandler := v1.NewFileHandler(echo, baseHandler, logger, authMiddleware, minioClient, configConfig, fileUsecase)
+	fileUsecase := usecase.NewFileUsecase(logger, objectStore, configConfig, systemSettingRepo, attachmentRepository, knowledgeBaseRepository)
+	fileHandler := v1.NewFileHandler(echo, baseHandler, logger, authMiddleware, objectStore, configConfig, fileUsecase)
 	modelHandler := v1.NewModelHandler(echo, baseHandler, logger, authMiddleware, modelUsecase, llmUsecase)
 	conversationHandler := v1.NewConversationHandler(echo, baseHandler, logger, authMiddleware, conversationUsecase)
 	mqConsumer, err := mq.NewMQConsumer(configConfig, logger)
 	if err != nil {
 		return nil, err
 	}
-	crawlerUsecase, err := usecase.NewCrawlerUsecase(logger, mqConsumer, cacheCache)
+	crawlerUsecase, err := usecase.NewCrawlerUsecase(logger, mqConsumer, cacheCache, objectStore)
 	if err != nil {
 		return nil, err
 	}
 	crawlerHandler := v1.NewCrawlerHandler(echo, baseHandler, authMiddleware, logger, configConfig, crawlerUsecase, fileUsecase)
 	creationUsecase := usecase.NewCreationUsecase(logger, llmUsecase, modelUsecase)
 	creationHandler := v1.NewCreationHandler(echo, baseHandler, logger, creationUsecase)
-	statRepository := pg2.NewStatRepository(db, cacheCache)
 	statUseCase := usecase.NewStatUseCase(statRepository, nodeRepository, conversationRepository, appRepository, ipAddressRepo, geoRepo, authRepo, knowledgeBaseRepository, logger)
 	statHandler := v1.NewStatHandler(baseHandler, echo, statUseCase, logger, authMiddleware)
+	statReportUsecase := usecase.NewStatReportUsecase(statRepository, nodeRepository, knowledgeBaseRepository, systemSettingRepo, logger)
+	statReportHandler := v1.NewStatReportHandler(baseHandler, echo, statReportUsecase, logger, authMiddleware)
 	commentRepository := pg2.NewCommentRepository(db, logger)
 	commentUsecase := usecase.NewCommentUsecase(commentRepository, logger, nodeRepository, ipAddressRepo, authRepo)
 	commentHandler := v1.NewCommentHandler(echo, baseHandler, logger, authMiddleware, commentUsecase)
-	authUsecase, err := usecase.NewAuthUsecase(authRepo, logger, knowledgeBaseRepository, cacheCache)
-	if err != nil {
-		return nil, err
-	}
-	authV1Handler := v1.NewAuthV1Handler(echo, baseHandler, logger, authUsecase)
+	scimUsecase := usecase.NewSCIMUsecase(authRepo, knowledgeBaseRepository, logger)
+	ldapSyncUsecase := usecase.NewLDAPSyncUsecase(authRepo, logger)
+	authV1Handler := v1.NewAuthV1Handler(echo, baseHandler, logger, authUsecase, scimUsecase, ldapSyncUsecase)
 	navUsecase := usecase.NewNavUsecase(navRepository, nodeRepository, ragRepository, logger)
 	navHandler := v1.NewNavHandler(baseHandler, echo, navUsecase, authMiddleware, logger)
 	apiHandlers := &v1.APIHandlers{
 		UserHandler:          userHandler,
 		KnowledgeBaseHandler: knowledgeBaseHandler,
 		NodeHandler:          nodeHandler,
+		NodeCollabHandler:    nodeCollabHandler,
+		NodeTemplateHandler:  nodeTemplateHandler,
+		NodeLinkHandler:      nodeLinkHandler,
+		NodeReviewHandler:    nodeReviewHandler,
+		NodeFAQHandler:       nodeFAQHandler,
+		CuratedAnswerHandler: curatedAnswerHandler,
 		AppHandler:           appHandler,
 		FileHandler:          fileHandler,
 		ModelHandler:         modelHandler,
@@ -154,6 +190,7 @@ func createApp() (*App, error) {
 		CrawlerHandler:       crawlerHandler,
 		CreationHandler:      creationHandler,
 		StatHandler:          statHandler,
+		StatReportHandler:    statReportHandler,
 		CommentHandler:       commentHandler,
 		AuthV1Handler:        authV1Handler,
 		NavHandler:           navHandler,
@@ -162,20 +199,23 @@ func createApp() (*App, error) {
 	shareNavHandler := share.NewShareNavHandler(baseHandler, echo, navUsecase, logger)
 	shareAppHandler := share.NewShareAppHandler(echo, baseHandler, logger, appUsecase)
 	shareChatHandler := share.NewShareChatHandler(echo, baseHandler, logger, appUsecase, chatUsecase, authUsecase, conversationUsecase, modelUsecase)
-	sitemapUsecase := usecase.NewSitemapUsecase(nodeRepository, knowledgeBaseRepository, logger)
+	sitemapUsecase := usecase.NewSitemapUsecase(nodeRepository, knowledgeBaseRepository, navRepository, appRepository, logger)
 	shareSitemapHandler := share.NewShareSitemapHandler(echo, baseHandler, sitemapUsecase, appUsecase, logger)
 	shareStatHandler := share.NewShareStatHandler(baseHandler, echo, statUseCase, logger)
 	shareCommentHandler := share.NewShareCommentHandler(echo, baseHandler, logger, commentUsecase, appUsecase)
 	shareAuthHandler := share.NewShareAuthHandler(echo, baseHandler, logger, knowledgeBaseUsecase, authUsecase)
 	shareConversationHandler := share.NewShareConversationHandler(baseHandler, echo, conversationUsecase, logger)
 	wechatRepository := pg2.NewWechatRepository(db, logger)
-	wechatServiceUsecase := usecase.NewWechatUsecase(logger, appUsecase, chatUsecase, wechatRepository, authRepo)
+	wechatServiceUsecase := usecase.NewWechatUsecase(logger, appUsecase, chatUsecase, wechatRepository, authRepo, objectStore)
 	wecomUsecase := usecase.NewWecomUsecase(logger, cacheCache, appUsecase, chatUsecase, authRepo)
 	wechatAppUsecase := usecase.NewWechatAppUsecase(logger, appUsecase, chatUsecase, wechatRepository, authRepo, appRepository)
 	shareWechatHandler := share.NewShareWechatHandler(echo, baseHandler, logger, appUsecase, conversationUsecase, wechatServiceUsecase, wecomUsecase, wechatAppUsecase)
 	shareCaptchaHandler := share.NewShareCaptchaHandler(baseHandler, echo, logger)
 	openapiV1Handler := share.NewOpenapiV1Handler(echo, baseHandler, logger, authUsecase, appUsecase)
 	shareCommonHandler := share.NewShareCommonHandler(echo, baseHandler, logger, fileUsecase)
+	shareSCIMHandler := share.NewShareSCIMHandler(echo, baseHandler, logger, scimUsecase)
+	feedUsecase := usecase.NewFeedUsecase(nodeRepository, navRepository, knowledgeBaseRepository, nodeUsecase, logger)
+	shareFeedHandler := share.NewShareFeedHandler(baseHandler, echo, feedUsecase, logger)
 	shareHandler := &share.ShareHandler{
 		ShareNodeHandler:         shareNodeHandler,
 		ShareNavHandler:          shareNavHandler,
@@ -190,6 +230,8 @@ func createApp() (*App, error) {
 		ShareCaptchaHandler:      shareCaptchaHandler,
 		OpenapiV1Handler:         openapiV1Handler,
 		ShareCommonHandler:       shareCommonHandler,
+		ShareSCIMHandler:         shareSCIMHandler,
+		ShareFeedHandler:         shareFeedHandler,
 	}
 	mcpRepository := pg2.NewMCPRepository(db, logger)
 	client, err := telemetry.NewClient(logger, knowledgeBaseRepository, modelUsecase, userUsecase, nodeRepository, conversationRepository, mcpRepository, configConfig)
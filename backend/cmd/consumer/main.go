@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+
+	"github.com/chaitin/panda-wiki/metrics"
 )
 
 func main() {
@@ -9,6 +11,9 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if app.Config.Metrics.Enabled {
+		metrics.Serve(app.Logger, app.Config.Metrics.Port)
+	}
 	if err := app.MQConsumer.StartConsumerHandlers(context.Background()); err != nil {
 		panic(err)
 	}
@@ -26,6 +26,7 @@ func createApp() (*App, error) {
 type App struct {
 	MQConsumer      mq.MQConsumer
 	Config          *config.Config
+	Logger          *log.Logger
 	MQHandlers      *handler.MQHandlers
 	StatCronHandler *handler.CronHandler
 }
@@ -48,7 +48,9 @@ func createApp() (*App, error) {
 	conversationRepository := pg2.NewConversationRepository(db, logger)
 	modelRepository := pg2.NewModelRepository(db, logger)
 	promptRepo := pg2.NewPromptRepo(db, logger)
-	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, logger)
+	attachmentRepository := pg2.NewAttachmentRepository(db, logger)
+	nodeFAQRepository := pg2.NewNodeFAQRepository(db, logger)
+	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, attachmentRepository, nodeFAQRepository, logger)
 	mqProducer, err := mq.NewMQProducer(configConfig, logger)
 	if err != nil {
 		return nil, err
@@ -56,7 +58,16 @@ func createApp() (*App, error) {
 	ragRepository := mq2.NewRAGRepository(mqProducer)
 	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
 	modelUsecase := usecase.NewModelUsecase(modelRepository, nodeRepository, ragRepository, ragService, logger, configConfig, knowledgeBaseRepository, systemSettingRepo)
-	ragmqHandler, err := mq3.NewRAGMQHandler(mqConsumer, logger, ragService, nodeRepository, knowledgeBaseRepository, llmUsecase, modelUsecase)
+	objectStore, err := s3.NewObjectStore(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	attachmentIndexUsecase := usecase.NewAttachmentIndexUsecase(attachmentRepository, objectStore, ragService, llmUsecase, modelUsecase, logger)
+	appRepository := pg2.NewAppRepository(db, logger)
+	nodeFAQUsecase := usecase.NewNodeFAQUsecase(nodeFAQRepository, nodeRepository, knowledgeBaseRepository, appRepository, ragRepository, ragService, llmUsecase, modelUsecase, logger)
+	curatedAnswerRepository := pg2.NewCuratedAnswerRepository(db, logger)
+	curatedAnswerUsecase := usecase.NewCuratedAnswerUsecase(curatedAnswerRepository, knowledgeBaseRepository, nodeRepository, conversationRepository, ragService, logger)
+	ragmqHandler, err := mq3.NewRAGMQHandler(mqConsumer, logger, ragService, nodeRepository, knowledgeBaseRepository, llmUsecase, modelUsecase, attachmentIndexUsecase, nodeFAQUsecase, curatedAnswerUsecase)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +80,6 @@ func createApp() (*App, error) {
 		return nil, err
 	}
 	statRepository := pg2.NewStatRepository(db, cacheCache)
-	appRepository := pg2.NewAppRepository(db, logger)
 	ipdbIPDB, err := ipdb.NewIPDB(configConfig, logger)
 	if err != nil {
 		return nil, err
@@ -80,12 +90,28 @@ func createApp() (*App, error) {
 	statUseCase := usecase.NewStatUseCase(statRepository, nodeRepository, conversationRepository, appRepository, ipAddressRepo, geoRepo, authRepo, knowledgeBaseRepository, logger)
 	navRepository := pg2.NewNavRepository(db, logger)
 	userRepository := pg2.NewUserRepository(db, logger)
-	minioClient, err := s3.NewMinioClient(configConfig)
+	mqDeadLetterRepository := pg2.NewMQDeadLetterRepository(db)
+	nodePresenceRepo := cache2.NewNodePresenceRepo(cacheCache)
+	nodeLinkRepository := pg2.NewNodeLinkRepository(db, logger)
+	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, objectStore, modelRepository, authRepo, modelUsecase, mqDeadLetterRepository, nodePresenceRepo, nodeLinkRepository)
+	statReportUsecase := usecase.NewStatReportUsecase(statRepository, nodeRepository, knowledgeBaseRepository, systemSettingRepo, logger)
+	ldapSyncUsecase := usecase.NewLDAPSyncUsecase(authRepo, logger)
+	attachmentUsecase := usecase.NewAttachmentUsecase(attachmentRepository, knowledgeBaseRepository, objectStore, logger)
+	nodeLinkUsecase := usecase.NewNodeLinkUsecase(nodeLinkRepository, nodeRepository, logger)
+	nodeReviewRepository := pg2.NewNodeReviewRepository(db, logger)
+	nodeReviewUsecase := usecase.NewNodeReviewUsecase(nodeReviewRepository, nodeRepository, knowledgeBaseRepository, userRepository, statRepository, systemSettingRepo, logger)
+	cronHandler, err := mq3.NewCronHandler(logger, statRepository, nodeRepository, statUseCase, nodeUsecase, statReportUsecase, ldapSyncUsecase, attachmentUsecase, nodeLinkUsecase, nodeReviewUsecase, mqConsumer, configConfig)
+	if err != nil {
+		return nil, err
+	}
+	deadLetterHandler, err := mq3.NewDeadLetterHandler(mqConsumer, logger, nodeRepository, mqDeadLetterRepository)
 	if err != nil {
 		return nil, err
 	}
-	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase)
-	cronHandler, err := mq3.NewCronHandler(logger, statRepository, nodeRepository, statUseCase, nodeUsecase)
+	kbExportRepository := pg2.NewKBExportRepository(db, logger)
+	kbExportTaskRepository := mq2.NewKBExportTaskRepository(mqProducer)
+	kbExportUsecase := usecase.NewKBExportUsecase(kbExportRepository, kbExportTaskRepository, knowledgeBaseRepository, nodeRepository, navRepository, appRepository, objectStore, logger)
+	kbExportMQHandler, err := mq3.NewKBExportMQHandler(mqConsumer, logger, kbExportUsecase)
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +119,13 @@ func createApp() (*App, error) {
 		RAGMQHandler:        ragmqHandler,
 		RagDocUpdateHandler: ragDocUpdateHandler,
 		StatCronHandler:     cronHandler,
+		DeadLetterHandler:   deadLetterHandler,
+		KBExportMQHandler:   kbExportMQHandler,
 	}
 	app := &App{
 		MQConsumer:      mqConsumer,
 		Config:          configConfig,
+		Logger:          logger,
 		MQHandlers:      mqHandlers,
 		StatCronHandler: cronHandler,
 	}
@@ -108,6 +137,7 @@ func createApp() (*App, error) {
 type App struct {
 	MQConsumer      mq.MQConsumer
 	Config          *config.Config
+	Logger          *log.Logger
 	MQHandlers      *mq3.MQHandlers
 	StatCronHandler *mq3.CronHandler
 }
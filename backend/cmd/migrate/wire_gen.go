@@ -51,8 +51,10 @@ func createApp() (*App, error) {
 	conversationRepository := pg2.NewConversationRepository(db, logger)
 	modelRepository := pg2.NewModelRepository(db, logger)
 	promptRepo := pg2.NewPromptRepo(db, logger)
-	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, logger)
-	minioClient, err := s3.NewMinioClient(configConfig)
+	attachmentRepository := pg2.NewAttachmentRepository(db, logger)
+	nodeFAQRepository := pg2.NewNodeFAQRepository(db, logger)
+	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, attachmentRepository, nodeFAQRepository, logger)
+	objectStore, err := s3.NewObjectStore(configConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -63,9 +65,15 @@ func createApp() (*App, error) {
 	authRepo := pg2.NewAuthRepo(db, logger, cacheCache)
 	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
 	modelUsecase := usecase.NewModelUsecase(modelRepository, nodeRepository, ragRepository, ragService, logger, configConfig, knowledgeBaseRepository, systemSettingRepo)
-	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase)
+	mqDeadLetterRepository := pg2.NewMQDeadLetterRepository(db)
+	nodePresenceRepo := cache2.NewNodePresenceRepo(cacheCache)
+	nodeLinkRepository := pg2.NewNodeLinkRepository(db, logger)
+	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, navRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, objectStore, modelRepository, authRepo, modelUsecase, mqDeadLetterRepository, nodePresenceRepo, nodeLinkRepository)
 	kbRepo := cache2.NewKBRepo(cacheCache)
-	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, navRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig)
+	nodeLinkUsecase := usecase.NewNodeLinkUsecase(nodeLinkRepository, nodeRepository, logger)
+	nodeCollabTicketRepo := cache2.NewNodeCollabTicketRepo(cacheCache)
+	nodeCollabUsecase := usecase.NewNodeCollabUsecase(nodeRepository, nodeCollabTicketRepo, logger)
+	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, navRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig, nodeLinkUsecase, nodeCollabUsecase)
 	if err != nil {
 		return nil, err
 	}
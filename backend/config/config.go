@@ -9,18 +9,20 @@ import (
 )
 
 type Config struct {
-	Log           LogConfig    `mapstructure:"log"`
-	HTTP          HTTPConfig   `mapstructure:"http"`
-	AdminPassword string       `mapstructure:"admin_password"`
-	PG            PGConfig     `mapstructure:"pg"`
-	MQ            MQConfig     `mapstructure:"mq"`
-	RAG           RAGConfig    `mapstructure:"rag"`
-	Redis         RedisConfig  `mapstructure:"redis"`
-	Auth          AuthConfig   `mapstructure:"auth"`
-	S3            S3Config     `mapstructure:"s3"`
-	Sentry        SentryConfig `mapstructure:"sentry"`
-	CaddyAPI      string       `mapstructure:"caddy_api"`
-	SubnetPrefix  string       `mapstructure:"subnet_prefix"`
+	Log           LogConfig     `mapstructure:"log"`
+	HTTP          HTTPConfig    `mapstructure:"http"`
+	AdminPassword string        `mapstructure:"admin_password"`
+	PG            PGConfig      `mapstructure:"pg"`
+	MQ            MQConfig      `mapstructure:"mq"`
+	RAG           RAGConfig     `mapstructure:"rag"`
+	Redis         RedisConfig   `mapstructure:"redis"`
+	Auth          AuthConfig    `mapstructure:"auth"`
+	S3            S3Config      `mapstructure:"s3"`
+	Sentry        SentryConfig  `mapstructure:"sentry"`
+	Metrics       MetricsConfig `mapstructure:"metrics"`
+	Export        ExportConfig  `mapstructure:"export"`
+	CaddyAPI      string        `mapstructure:"caddy_api"`
+	SubnetPrefix  string        `mapstructure:"subnet_prefix"`
 }
 
 type LogConfig struct {
@@ -29,6 +31,9 @@ type LogConfig struct {
 
 type HTTPConfig struct {
 	Port int `mapstructure:"port"`
+	// AdminOrigins 管理后台的访问地址，如 https://wiki.example.com:2443，
+	// 用于校验 WebSocket 请求的 Origin，为空时要求与请求的 Host 一致
+	AdminOrigins []string `mapstructure:"admin_origins"`
 }
 
 type PGConfig struct {
@@ -71,9 +76,11 @@ type JWTConfig struct {
 }
 
 type S3Config struct {
+	Type      string `mapstructure:"type"` // minio 或 local，local 将文件保存在本地磁盘
 	Endpoint  string `mapstructure:"endpoint"`
 	AccessKey string `mapstructure:"access_key"`
 	SecretKey string `mapstructure:"secret_key"`
+	LocalDir  string `mapstructure:"local_dir"`
 }
 
 type SentryConfig struct {
@@ -81,6 +88,15 @@ type SentryConfig struct {
 	DSN     string `mapstructure:"dsn"`
 }
 
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Port    int  `mapstructure:"port"`
+}
+
+type ExportConfig struct {
+	PDFFont string `mapstructure:"pdf_font"` // 导出 PDF 使用的 TrueType 字体，需包含中文字符
+}
+
 func NewConfig() (*Config, error) {
 	// set default config
 	SUBNET_PREFIX := os.Getenv("SUBNET_PREFIX")
@@ -122,14 +138,23 @@ func NewConfig() (*Config, error) {
 			JWT:  JWTConfig{Secret: ""},
 		},
 		S3: S3Config{
+			Type:      "minio",
 			Endpoint:  "panda-wiki-minio:9000",
 			AccessKey: "s3panda-wiki",
 			SecretKey: "",
+			LocalDir:  "/app/data/objects",
 		},
 		Sentry: SentryConfig{
 			Enabled: true,
 			DSN:     "https://2a4cff1ae04b624ffc72663f523024ff@sentry.baizhi.cloud/4",
 		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Port:    2112,
+		},
+		Export: ExportConfig{
+			PDFFont: "/usr/share/fonts/droid-nonlatin/DroidSansFallbackFull.ttf",
+		},
 		CaddyAPI:     "/app/run/caddy-admin.sock",
 		SubnetPrefix: "169.254.15",
 	}
@@ -201,6 +226,12 @@ func overrideWithEnv(c *Config) {
 	if env := os.Getenv("S3_ENDPOINT"); env != "" {
 		c.S3.Endpoint = env
 	}
+	if env := os.Getenv("S3_TYPE"); env != "" {
+		c.S3.Type = env
+	}
+	if env := os.Getenv("S3_LOCAL_DIR"); env != "" {
+		c.S3.LocalDir = env
+	}
 	// sentry
 	if env := os.Getenv("SENTRY_ENABLED"); env != "" {
 		c.Sentry.Enabled = env == "true"
@@ -208,6 +239,10 @@ func overrideWithEnv(c *Config) {
 	if env := os.Getenv("SENTRY_DSN"); env != "" {
 		c.Sentry.DSN = env
 	}
+	// export
+	if env := os.Getenv("EXPORT_PDF_FONT"); env != "" {
+		c.Export.PDFFont = env
+	}
 	// caddy api
 	if env := os.Getenv("CADDY_API"); env != "" {
 		c.CaddyAPI = env
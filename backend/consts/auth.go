@@ -14,6 +14,9 @@ const (
 	SourceTypeGitHub                SourceType = "github"
 	SourceTypeCAS                   SourceType = "cas"
 	SourceTypeLDAP                  SourceType = "ldap"
+	SourceTypeSAML                  SourceType = "saml"
+	SourceTypeOIDC                  SourceType = "oidc"
+	SourceTypeSCIM                  SourceType = "scim"
 	SourceTypeWidget                SourceType = "widget"
 	SourceTypeDingtalkBot           SourceType = "dingtalk_bot"
 	SourceTypeFeishuBot             SourceType = "feishu_bot"
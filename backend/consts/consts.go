@@ -8,3 +8,11 @@ const (
 	StatDay30 StatDay = 30
 	StatDay90 StatDay = 90
 )
+
+// StatGranularity 长期统计的聚合粒度
+type StatGranularity string
+
+const (
+	StatGranularityDay   StatGranularity = "day"
+	StatGranularityMonth StatGranularity = "month"
+)
@@ -0,0 +1,31 @@
+package consts
+
+type NotifyChannelType string
+
+const (
+	NotifyChannelEmail    NotifyChannelType = "email"    // 邮件
+	NotifyChannelDingTalk NotifyChannelType = "dingtalk" // 钉钉群机器人
+	NotifyChannelFeishu   NotifyChannelType = "feishu"   // 飞书群机器人
+	NotifyChannelWeCom    NotifyChannelType = "wecom"    // 企业微信群机器人
+)
+
+type StatReportPeriod string
+
+const (
+	StatReportPeriodDaily  StatReportPeriod = "daily"  // 日报
+	StatReportPeriodWeekly StatReportPeriod = "weekly" // 周报
+)
+
+func (p StatReportPeriod) Days() int {
+	if p == StatReportPeriodWeekly {
+		return 7
+	}
+	return 1
+}
+
+func (p StatReportPeriod) Name() string {
+	if p == StatReportPeriodWeekly {
+		return "周报"
+	}
+	return "日报"
+}
@@ -5,4 +5,6 @@ type SystemSettingKey string
 const (
 	SystemSettingModelMode SystemSettingKey = "model_setting_mode"
 	SystemSettingUpload    SystemSettingKey = "upload"
+	SystemSettingSMTP      SystemSettingKey = "smtp"
+	SystemSettingAdminSSO  SystemSettingKey = "admin_sso"
 )
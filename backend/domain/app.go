@@ -154,6 +154,8 @@ type AppSettings struct {
 	DocumentFeedBackIsEnabled *bool `json:"document_feedback_is_enabled,omitempty"`
 	// AI feedback
 	AIFeedbackSettings AIFeedbackSettings `json:"ai_feedback_settings"`
+	// 网页和挂件回答结束后推荐追问，未设置时开启
+	FollowUpQuestionsIsEnabled *bool `json:"follow_up_questions_is_enabled,omitempty"`
 	// WebAppCustomStyle
 	WebAppCustomSettings WebAppCustomSettings `json:"web_app_custom_style"`
 	// OpenAI API Bot settings
@@ -264,14 +266,15 @@ type CarouselConfig struct {
 	} `json:"list"`
 }
 type FaqConfig struct {
-	Title      string `json:"title"`
-	TitleColor string `json:"title_color"`
-	BgColor    string `json:"bg_color"`
-	List       []struct {
-		ID       string `json:"id"`
-		Question string `json:"question"`
-		Link     string `json:"link"`
-	} `json:"list"`
+	Title      string          `json:"title"`
+	TitleColor string          `json:"title_color"`
+	BgColor    string          `json:"bg_color"`
+	List       []FaqConfigItem `json:"list"`
+}
+type FaqConfigItem struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	Link     string `json:"link"`
 }
 type TextConfig struct {
 	Type  string `json:"type"`
@@ -467,6 +470,10 @@ type Link struct {
 	URL  string `json:"url,omitempty"`
 }
 
+func (s *AppSettings) FollowUpQuestionsEnabled() bool {
+	return s.FollowUpQuestionsIsEnabled == nil || *s.FollowUpQuestionsIsEnabled
+}
+
 func (s *AppSettings) Scan(value any) error {
 	bytes, ok := value.([]byte)
 	if !ok {
@@ -563,6 +570,8 @@ type AppSettingsResp struct {
 	DocumentFeedBackIsEnabled *bool `json:"document_feedback_is_enabled,omitempty"`
 	// AI feedback
 	AIFeedbackSettings AIFeedbackSettings `json:"ai_feedback_settings"`
+	// 网页和挂件回答结束后推荐追问，未设置时开启
+	FollowUpQuestionsIsEnabled *bool `json:"follow_up_questions_is_enabled,omitempty"`
 	// WebAppCustomStyle
 	WebAppCustomSettings WebAppCustomSettings `json:"web_app_custom_style"`
 
@@ -28,6 +28,11 @@ type Auth struct {
 	CreatedAt     time.Time         `gorm:"column:created_at;not null;default:now()" json:"created_at"`       // Timestamp when the record was created
 	UpdatedAt     time.Time         `gorm:"column:updated_at;not null;default:now()" json:"updated_at"`       // Timestamp when the record was last updated
 	UserInfo      AuthUserInfo      `json:"user_info" gorm:"type:jsonb"`
+
+	// SCIM 下发的属性
+	Active     bool   `gorm:"column:active;not null;default:true" json:"active"`
+	ExternalID string `gorm:"column:external_id;not null;default:''" json:"external_id,omitempty"`
+	UserName   string `gorm:"column:user_name;not null;default:''" json:"user_name,omitempty"`
 }
 
 func (Auth) TableName() string {
@@ -85,6 +90,59 @@ type AuthSetting struct {
 	ClientID     string `json:"client_id,omitempty"`
 	ClientSecret string `json:"client_secret,omitempty"`
 	Proxy        string `json:"proxy,omitempty"`
+
+	// OIDC
+	Issuer string   `json:"issuer,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+
+	// OAuth
+	AuthorizeURL string `json:"authorize_url,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	UserInfoURL  string `json:"user_info_url,omitempty"`
+
+	// SAML
+	IDPMetadataURL string `json:"idp_metadata_url,omitempty"`
+	IDPMetadataXML string `json:"idp_metadata_xml,omitempty"`
+	EntityID       string `json:"entity_id,omitempty"`
+	Certificate    string `json:"certificate,omitempty"`
+	PrivateKey     string `json:"private_key,omitempty"`
+
+	ClaimMapping AuthClaimMapping `json:"claim_mapping"`
+
+	// SCIM
+	TokenHash   string `json:"token_hash,omitempty"`
+	UnionIDAttr string `json:"union_id_attr,omitempty"` // userName 或 externalId，需与SSO登录返回的用户标识一致
+
+	LDAP AuthLDAPSetting `json:"ldap"`
+}
+
+// AuthLDAPSetting LDAP/AD 连接及分组同步配置，用户标识取 UserIDAttr
+type AuthLDAPSetting struct {
+	ServerURL     string `json:"server_url,omitempty"`
+	BindDN        string `json:"bind_dn,omitempty"`
+	BindPassword  string `json:"bind_password,omitempty"`
+	UserBaseDN    string `json:"user_base_dn,omitempty"`
+	UserFilter    string `json:"user_filter,omitempty"`
+	UserIDAttr    string `json:"user_id_attr,omitempty"`
+	UserNameAttr  string `json:"user_name_attr,omitempty"`
+	UserEmailAttr string `json:"user_email_attr,omitempty"`
+
+	GroupSyncEnabled bool   `json:"group_sync_enabled"` // 开启后每小时同步一次分组
+	GroupBaseDN      string `json:"group_base_dn,omitempty"`
+	GroupFilter      string `json:"group_filter,omitempty"`
+	GroupNameAttr    string `json:"group_name_attr,omitempty"`
+	GroupMemberAttr  string `json:"group_member_attr,omitempty"`
+	// 目录中读不到分组或用户时仍然同步，会移除全部已同步的分组
+	GroupSyncAllowEmpty bool `json:"group_sync_allow_empty"`
+}
+
+// AuthClaimMapping OIDC声明/SAML属性到用户信息的映射，为空时使用默认字段
+type AuthClaimMapping struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Groups string `json:"groups,omitempty"`
 }
 
 type AuthInfo struct {
@@ -15,6 +15,8 @@ type ChatRequest struct {
 	AppType        AppType  `json:"app_type" validate:"required,oneof=1 2"`
 	CaptchaToken   string   `json:"captcha_token"`
 
+	Filter *NodeMetaFilter `json:"filter"` // 只检索符合标签和字段条件的文档
+
 	KBID  string `json:"-" validate:"required"`
 	AppID string `json:"-"`
 
@@ -32,6 +34,8 @@ type ChatRagOnlyRequest struct {
 
 	UserInfo UserInfo `json:"user_info"`
 	AppType  AppType  `json:"app_type" validate:"required,oneof=1 2"`
+
+	Filter *NodeMetaFilter `json:"filter"`
 }
 
 type ConversationInfo struct {
@@ -79,8 +83,9 @@ func (m MessageFrom) String() string {
 }
 
 type ChatSearchReq struct {
-	Message      string `json:"message" validate:"required"`
-	CaptchaToken string `json:"captcha_token"`
+	Message      string          `json:"message" validate:"required"`
+	CaptchaToken string          `json:"captcha_token"`
+	Filter       *NodeMetaFilter `json:"filter"`
 
 	KBID string `json:"-" validate:"required"`
 
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CuratedAnswerMode string
+
+const (
+	CuratedAnswerModeDirect CuratedAnswerMode = "direct" // 直接返回标准答案，不调用模型
+	CuratedAnswerModeInject CuratedAnswerMode = "inject" // 注入到提示词，由模型结合文档回答
+)
+
+// 问题之间的相似度阈值，低于阈值的不认为命中
+const DefaultCuratedAnswerThreshold = 0.85
+
+// CuratedAnswer 标准答案，问题和每个相似问法各写入一条检索记录，用于匹配用户问题
+type CuratedAnswer struct {
+	ID              string         `json:"id" gorm:"primaryKey"`
+	KBID            string         `json:"kb_id"`
+	Question        string         `json:"question"`
+	Alternates      pq.StringArray `json:"alternates" gorm:"type:text[]"` // 相似问法
+	Answer          string         `json:"answer"`
+	NodeIDs         pq.StringArray `json:"node_ids" gorm:"type:text[]"` // 关联文档，命中时作为引用返回
+	Enabled         bool           `json:"enabled"`
+	DocIDs          pq.StringArray `json:"-" gorm:"type:text[]"`
+	HitCount        int            `json:"hit_count"`
+	LastHitAt       *time.Time     `json:"last_hit_at"`
+	SourceMessageID string         `json:"source_message_id"` // 从对话记录添加时的回答消息 ID
+	CreatorID       string         `json:"creator_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+func (CuratedAnswer) TableName() string {
+	return "curated_answers"
+}
+
+// Questions 标准问题和全部相似问法
+func (a *CuratedAnswer) Questions() []string {
+	return append([]string{a.Question}, a.Alternates...)
+}
+
+// CuratedAnswerHit 标准答案命中记录
+type CuratedAnswerHit struct {
+	ID             string            `json:"id" gorm:"primaryKey"`
+	KBID           string            `json:"kb_id"`
+	AnswerID       string            `json:"answer_id"`
+	ConversationID string            `json:"conversation_id"`
+	MessageID      string            `json:"message_id"`
+	Question       string            `json:"question"` // 用户的原始问题
+	Mode           CuratedAnswerMode `json:"mode"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+func (CuratedAnswerHit) TableName() string {
+	return "curated_answer_hits"
+}
+
+// CuratedAnswerSetting 标准答案匹配设置
+type CuratedAnswerSetting struct {
+	Mode      CuratedAnswerMode `json:"mode" validate:"omitempty,oneof=direct inject"` // 默认直接返回
+	Threshold float64           `json:"threshold" validate:"min=0,max=1"`              // 0 表示默认 0.85
+}
+
+func (s *CuratedAnswerSetting) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *CuratedAnswerSetting) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid curated answer setting type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s *CuratedAnswerSetting) GetMode() CuratedAnswerMode {
+	if s.Mode == "" {
+		return CuratedAnswerModeDirect
+	}
+	return s.Mode
+}
+
+func (s *CuratedAnswerSetting) GetThreshold() float64 {
+	if s.Threshold <= 0 {
+		return DefaultCuratedAnswerThreshold
+	}
+	return s.Threshold
+}
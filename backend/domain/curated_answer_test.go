@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCuratedAnswerSetting(t *testing.T) {
+	tests := []struct {
+		name      string
+		setting   CuratedAnswerSetting
+		mode      CuratedAnswerMode
+		threshold float64
+	}{
+		{"defaults", CuratedAnswerSetting{}, CuratedAnswerModeDirect, DefaultCuratedAnswerThreshold},
+		{"inject", CuratedAnswerSetting{Mode: CuratedAnswerModeInject}, CuratedAnswerModeInject, DefaultCuratedAnswerThreshold},
+		{"custom threshold", CuratedAnswerSetting{Mode: CuratedAnswerModeDirect, Threshold: 0.6}, CuratedAnswerModeDirect, 0.6},
+		{"strict threshold", CuratedAnswerSetting{Threshold: 1}, CuratedAnswerModeDirect, 1},
+		{"negative threshold falls back", CuratedAnswerSetting{Threshold: -0.1}, CuratedAnswerModeDirect, DefaultCuratedAnswerThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.mode, tt.setting.GetMode())
+			assert.Equal(t, tt.threshold, tt.setting.GetThreshold())
+		})
+	}
+}
+
+func TestCuratedAnswerSetting_Scan(t *testing.T) {
+	// 历史知识库的设置列为空对象，读取后应使用默认值
+	var setting CuratedAnswerSetting
+	require.NoError(t, setting.Scan([]byte(`{}`)))
+	assert.Equal(t, CuratedAnswerModeDirect, setting.GetMode())
+	assert.Equal(t, DefaultCuratedAnswerThreshold, setting.GetThreshold())
+
+	value, err := (&CuratedAnswerSetting{Mode: CuratedAnswerModeInject, Threshold: 0.7}).Value()
+	require.NoError(t, err)
+	require.NoError(t, setting.Scan(value))
+	assert.Equal(t, CuratedAnswerSetting{Mode: CuratedAnswerModeInject, Threshold: 0.7}, setting)
+
+	assert.Error(t, setting.Scan("{}"))
+}
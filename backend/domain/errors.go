@@ -15,3 +15,9 @@ var ErrPermissionDenied = errors.New("permission denied")
 var ErrInternalServerError = errors.New("internal server error")
 
 var ErrMaxNodeLimitReached = errors.New("max node limit reached")
+
+var ErrTOTPRequired = errors.New("totp code required")
+
+var ErrInvalidTOTPCode = errors.New("invalid totp code")
+
+var ErrNodeRevisionRequired = errors.New("revision is required when updating name or content")
@@ -0,0 +1,22 @@
+package domain
+
+type FeedFormat string
+
+const (
+	FeedFormatRSS  FeedFormat = "rss"
+	FeedFormatAtom FeedFormat = "atom"
+	FeedFormatJSON FeedFormat = "json"
+)
+
+const FeedDefaultLimit = 20
+
+func (f FeedFormat) ContentType() string {
+	switch f {
+	case FeedFormatRSS:
+		return "application/rss+xml; charset=UTF-8"
+	case FeedFormatAtom:
+		return "application/atom+xml; charset=UTF-8"
+	default:
+		return "application/feed+json; charset=UTF-8"
+	}
+}
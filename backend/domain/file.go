@@ -1,9 +1,36 @@
 package domain
 
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/samber/lo"
+)
+
 const (
 	Bucket = "static-file"
 )
 
+// AttachmentKeyPattern 匹配内容中 /static-file/<key> 形式的文件引用，同时用于 postgres 正则
+const AttachmentKeyPattern = `/` + Bucket + `/([^\s"'()<>?#&\\]+)`
+
+var attachmentKeyRegexp = regexp.MustCompile(AttachmentKeyPattern)
+
+// ExtractAttachmentKeys 提取内容中引用的文件 key，按出现顺序去重
+func ExtractAttachmentKeys(content string) []string {
+	keys := make([]string, 0)
+	for _, match := range attachmentKeyRegexp.FindAllStringSubmatch(content, -1) {
+		key, err := url.PathUnescape(match[1])
+		if err != nil {
+			key = match[1]
+		}
+		keys = append(keys, key)
+	}
+	return lo.Uniq(keys)
+}
+
 type ObjectUploadResp struct {
 	Key      string `json:"key"`
 	Filename string `json:"filename"`
@@ -19,3 +46,98 @@ type AnydocUploadResp struct {
 	Err  string `json:"err"`
 	Data string `json:"data"`
 }
+
+// table: attachments
+type Attachment struct {
+	Key         string    `json:"key" gorm:"primaryKey"`
+	KBID        string    `json:"kb_id"`
+	Hash        string    `json:"hash"` // sha256
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	Filename    string    `json:"filename"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at" gorm:"default:now()"` // 上传或去重复用的时间
+
+	IndexStatus AttachmentIndexStatus `json:"index_status"` // 最近一次写入 RAG 的结果，未被发布的文档引用时为空
+}
+
+type AttachmentIndexStatus string
+
+const (
+	AttachmentIndexStatusIndexed  AttachmentIndexStatus = "indexed"
+	AttachmentIndexStatusEmpty    AttachmentIndexStatus = "empty"     // 没有可提取的文本
+	AttachmentIndexStatusNeedsOCR AttachmentIndexStatus = "needs_ocr" // 有页面没有文本层，这些页未写入
+	AttachmentIndexStatusFailed   AttachmentIndexStatus = "failed"
+)
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+type AttachmentRefType string
+
+const (
+	AttachmentRefTypeNode              AttachmentRefType = "node"
+	AttachmentRefTypeNodeRelease       AttachmentRefType = "node_release"
+	AttachmentRefTypeComment           AttachmentRefType = "comment"
+	AttachmentRefTypeContribute        AttachmentRefType = "contribute"
+	AttachmentRefTypeConversation      AttachmentRefType = "conversation"
+	AttachmentRefTypeApp               AttachmentRefType = "app"
+	AttachmentRefTypeNodeReleaseBackup AttachmentRefType = "node_release_backup"
+	AttachmentRefTypeNodeRecycle       AttachmentRefType = "node_recycle"
+	AttachmentRefTypeNodeRevision      AttachmentRefType = "node_revision"
+	AttachmentRefTypeNodeTemplate      AttachmentRefType = "node_template"
+	AttachmentRefTypeCuratedAnswer     AttachmentRefType = "curated_answer"
+)
+
+// table: attachment_refs
+type AttachmentRef struct {
+	Key     string            `json:"key" gorm:"primaryKey"`
+	KBID    string            `json:"kb_id"`
+	RefType AttachmentRefType `json:"ref_type" gorm:"primaryKey"`
+	RefID   string            `json:"ref_id" gorm:"primaryKey"`
+}
+
+func (AttachmentRef) TableName() string {
+	return "attachment_refs"
+}
+
+// AttachmentUsage 知识库存储用量
+type AttachmentUsage struct {
+	Count       int64 `json:"count"`
+	Size        int64 `json:"size"`
+	OrphanCount int64 `json:"orphan_count"`
+	OrphanSize  int64 `json:"orphan_size"`
+}
+
+// table: node_attachment_docs
+// 文档引用的附件按页写入 RAG，作为文档的子记录
+type NodeAttachmentDoc struct {
+	ID          int64  `json:"id" gorm:"primaryKey"`
+	KBID        string `json:"kb_id"`
+	NodeID      string `json:"node_id"`
+	ParentDocID string `json:"parent_doc_id"` // 文档当前发布版本的 doc_id
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	Page        int    `json:"page"` // 从 1 开始，0 表示不分页
+	PageName    string `json:"page_name"`
+	DocID       string `json:"doc_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (NodeAttachmentDoc) TableName() string {
+	return "node_attachment_docs"
+}
+
+// Source 用于回答中引用的来源描述
+func (d *NodeAttachmentDoc) Source() string {
+	switch {
+	case d.PageName != "":
+		return fmt.Sprintf("%s 工作表 %s", d.Filename, d.PageName)
+	case d.Page > 0:
+		return fmt.Sprintf("%s 第 %d 页", d.Filename, d.Page)
+	default:
+		return d.Filename
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractAttachmentKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"empty", "", []string{}},
+		{"no reference", "plain text https://example.com/a.png", []string{}},
+		{"markdown image", "![img](/static-file/kb1/a.png)", []string{"kb1/a.png"}},
+		{"html attribute", `<img src="/static-file/kb1/a.png" alt="a">`, []string{"kb1/a.png"}},
+		{"single quote attribute", `<img src='/static-file/kb1/a.png'>`, []string{"kb1/a.png"}},
+		{"absolute url", "https://wiki.example.com/static-file/kb1/a.png", []string{"kb1/a.png"}},
+		{"stop at query", "/static-file/kb1/a.png?x-oss-process=resize", []string{"kb1/a.png"}},
+		{"stop at fragment", "/static-file/kb1/a.pdf#page=2", []string{"kb1/a.pdf"}},
+		{"stop at whitespace", "/static-file/kb1/a.png next", []string{"kb1/a.png"}},
+		{"escaped path", "/static-file/kb1/%E5%9B%BE%E7%89%87.png", []string{"kb1/图片.png"}},
+		{"invalid escape kept", "/static-file/kb1/a%zz.png", []string{"kb1/a%zz.png"}},
+		{"json string", `{"url":"/static-file/kb1/a.png"}`, []string{"kb1/a.png"}},
+		{
+			"dedupe in order",
+			"/static-file/kb1/b.png /static-file/kb1/a.png /static-file/kb1/b.png",
+			[]string{"kb1/b.png", "kb1/a.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractAttachmentKeys(tt.content))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+const KBExportTaskTopic = "apps.panda-wiki.export.task"
+
+type KBExportStatus string
+
+const (
+	KBExportStatusPending   KBExportStatus = "pending"
+	KBExportStatusRunning   KBExportStatus = "running"
+	KBExportStatusSucceeded KBExportStatus = "succeeded"
+	KBExportStatusFailed    KBExportStatus = "failed"
+)
+
+// table: kb_release_exports
+type KBReleaseExport struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	KBID      string         `json:"kb_id" gorm:"index"`
+	ReleaseID string         `json:"release_id"`
+	Status    KBExportStatus `json:"status"`
+	Message   string         `json:"message"`
+	ObjectKey string         `json:"-"`
+	Size      int64          `json:"size"`
+	CreatorID string         `json:"creator_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (KBReleaseExport) TableName() string {
+	return "kb_release_exports"
+}
+
+type KBExportTaskRequest struct {
+	KBID     string `json:"kb_id"`
+	ExportID string `json:"export_id"`
+}
+
+// KBExportNode 导出版本中的一个节点，权限取自节点当前的设置
+type KBExportNode struct {
+	ID            string          `json:"id"`
+	NodeReleaseID string          `json:"node_release_id"`
+	Name          string          `json:"name"`
+	Type          NodeType        `json:"type"`
+	ParentID      string          `json:"parent_id"`
+	NavId         string          `json:"nav_id"`
+	Position      float64         `json:"position"`
+	Meta          NodeMeta        `json:"meta"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Permissions   NodePermissions `json:"permissions" gorm:"type:jsonb"`
+}
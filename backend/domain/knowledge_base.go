@@ -21,6 +21,20 @@ type KnowledgeBase struct {
 	// public info for public access
 	AccessSettings AccessSettings `json:"access_settings" gorm:"type:jsonb"`
 
+	StorageQuota int64 `json:"storage_quota"` // 存储配额，单位字节，0 表示不限制
+
+	RecycleRetentionDays int `json:"recycle_retention_days" gorm:"default:30"` // 回收站保留天数
+
+	NodeMetaSchema NodeMetaSchema `json:"node_meta_schema" gorm:"type:jsonb"` // 标签词表和自定义字段
+
+	ReviewSetting NodeReviewSetting `json:"review_setting" gorm:"type:jsonb"` // 过期文档提醒
+
+	FAQSetting NodeFAQSetting `json:"faq_setting" gorm:"type:jsonb"` // 问答对生成
+
+	AnswerDatasetID string `json:"answer_dataset_id"` // 标准答案检索库，首次添加标准答案时创建
+
+	CuratedAnswerSetting CuratedAnswerSetting `json:"curated_answer_setting" gorm:"type:jsonb"` // 标准答案匹配
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -70,6 +84,11 @@ func (s *AccessSettings) Value() (driver.Value, error) {
 	return json.Marshal(s)
 }
 
+// IsPublic 未禁止访问且未开启任何认证，搜索引擎和爬虫可以直接访问
+func (s *AccessSettings) IsPublic() bool {
+	return !s.IsForbidden && !s.SimpleAuth.Enabled && !s.EnterpriseAuth.Enabled
+}
+
 func (s *AccessSettings) GetBaseUrl() string {
 	if strings.TrimSpace(s.BaseURL) != "" {
 		return s.BaseURL
@@ -56,6 +56,7 @@ URL: {文档URL}
 	- 句号前放置引用标记
 	- 引用使用格式 [[文档序号](URL)]
 	- 如果多个不同文档支持同一观点，使用组合引用：[[文档序号](URL1)],[[文档序号](URL2)],[[文档序号](URLN)]
+	- 如果引用的内容前有"来源"标记，说明内容来自文档的附件，在引用列表中的文档标题后注明来源，例如：[文档标题1](URL1)（installation.pdf 第 12 页）
   回答结束后，如果有引用列表则按照序号输出，格式如下，没有则不输出
 	---
 	### 引用列表
@@ -70,6 +71,35 @@ URL: {文档URL}
 2. 若现有的文档不足以回答用户问题，请直接回答"抱歉，我当前的知识不足以回答这个问题"。
 `
 
+var ImageTextRecognitionPrompt = `识别图片中的全部文字，按原有的阅读顺序输出纯文本，表格的单元格用制表符分隔。不要解释、总结或补充图片中没有的内容，图片中没有文字时输出空内容。`
+
+// NodeFAQGeneratePrompt 根据文档内容生成问答对，%d 为最多生成的数量
+var NodeFAQGeneratePrompt = `你是知识库问答对整理助手，请根据文档内容整理读者最可能提出的问题和对应的答案，最多 %d 个。
+要求：
+1. 问题使用读者的口吻，简短明确，不要出现“本文”“该文档”等指代。
+2. 答案只能依据文档内容，简洁完整，不超过 200 个字，不要编造文档中没有的信息。
+3. 不同问题之间不要重复。
+4. 只输出 JSON 数组，格式为 [{"question": "问题", "answer": "答案"}]，不要输出其他内容。`
+
+// FollowUpQuestionsPrompt 根据问题和回答推荐追问，%d 为推荐的数量
+var FollowUpQuestionsPrompt = `你是知识库问答助手，请根据用户的问题、助手的回答和参考文档标题，推荐用户接下来可能追问的 %d 个问题。
+要求：
+1. 问题与当前话题相关，并且可以通过参考文档回答。
+2. 问题使用用户的口吻，简短明确，不要与用户已经提出的问题重复。
+3. 只输出 JSON 字符串数组，例如 ["问题1", "问题2"]，不要输出其他内容。`
+
+// CuratedAnswerPrompt 命中标准答案时注入的提示，%s 依次为标准问题和标准答案
+var CuratedAnswerPrompt = `以下是管理员审核过的标准答案，与用户当前的问题高度相关。
+请优先依据标准答案回答，与参考文档冲突时以标准答案为准，可以结合参考文档补充细节。
+
+<curated_question>
+%s
+</curated_question>
+
+<curated_answer>
+%s
+</curated_answer>`
+
 var UserQuestionFormatter = `
 当前日期为：{{.CurrentDate}}。
 
@@ -136,6 +166,9 @@ func FormatNodeChunks(nodeChunks []*RankedNodeChunks, baseURL string) string {
 		for _, chunk := range result.Chunks {
 			// Process content to add baseURL prefix to static-file URLs
 			processedContent := processContentWithBaseURL(chunk.Content, baseURL)
+			if chunk.Source != "" {
+				document.WriteString(fmt.Sprintf("来源: %s\n", chunk.Source))
+			}
 			document.WriteString(fmt.Sprintf("%s\n", processedContent))
 		}
 		document.WriteString("</document>")
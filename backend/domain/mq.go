@@ -4,12 +4,15 @@ const (
 	VectorTaskTopic       = "apps.panda-wiki.vector.task"
 	AnydocTaskExportTopic = "anydoc.persistence.doc.task.export"
 	RagDocUpdateTopic     = "raglite.events.doc.update"
+	DeadLetterTopic       = "apps.panda-wiki.dead_letter"
 )
 
 var TopicConsumerName = map[string]string{
 	VectorTaskTopic:       "panda-wiki-vector-consumer",
 	AnydocTaskExportTopic: "anydoc-task-export-consumer",
 	RagDocUpdateTopic:     "raglite-doc-update-consumer",
+	DeadLetterTopic:       "panda-wiki-dead-letter-consumer",
+	KBExportTaskTopic:     "panda-wiki-export-consumer",
 }
 
 type NodeReleaseVectorRequest struct {
@@ -17,7 +20,7 @@ type NodeReleaseVectorRequest struct {
 	NodeReleaseID string `json:"node_release_id"`
 	NodeID        string `json:"node_id"`
 	DocID         string `json:"doc_id"` // for delete
-	Action        string `json:"action"` // upsert, delete, summary
+	Action        string `json:"action"` // upsert, delete, summary, faq, reindex_curated_answers
 	GroupIds      []int  `json:"group_ids"`
 }
 
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// MQDeadLetter 多次重试后仍处理失败的消息
+type MQDeadLetter struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Topic     string    `json:"topic"`
+	KBID      string    `json:"kb_id" gorm:"index"`
+	NodeID    string    `json:"node_id"`
+	Payload   string    `json:"payload"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	FailedAt  time.Time `json:"failed_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (MQDeadLetter) TableName() string {
+	return "mq_dead_letters"
+}
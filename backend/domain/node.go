@@ -55,6 +55,7 @@ type Node struct {
 	EditorId    string          `json:"editor_id"`
 	EditTime    time.Time       `json:"edit_time"`
 	Permissions NodePermissions `json:"permissions" gorm:"type:jsonb"`
+	Revision    int64           `json:"revision" gorm:"default:1"` // 名称或内容每次保存后递增
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -124,6 +125,9 @@ type NodeMeta struct {
 	Summary     string `json:"summary"`
 	Emoji       string `json:"emoji"`
 	ContentType string `json:"content_type"`
+
+	Tags   []string          `json:"tags,omitempty"`   // 标签，取自知识库标签词表
+	Fields map[string]string `json:"fields,omitempty"` // 自定义字段
 }
 
 func (d *NodeMeta) Value() (driver.Value, error) {
@@ -150,12 +154,17 @@ type CreateNodeReq struct {
 	ContentType *string  `json:"content_type"`
 	MaxNode     int      `json:"-"`
 	Position    *float64 `json:"position"`
+
+	ID          string           `json:"-"` // 为空时自动生成
+	Permissions *NodePermissions `json:"-"` // 为空时完全开放
 }
 
 type GetNodeListReq struct {
 	KBID   string `json:"kb_id" query:"kb_id" validate:"required"`
 	NavId  string `query:"nav_id" json:"nav_id"`
 	Search string `json:"search" query:"search"`
+
+	NodeMetaFilter
 }
 
 type NodeListItemResp struct {
@@ -178,6 +187,10 @@ type NodeListItemResp struct {
 	Editor      string          `json:"editor"`
 	PublisherId string          `json:"publisher_id" gorm:"-"`
 	Permissions NodePermissions `json:"permissions" gorm:"type:jsonb"`
+
+	Tags   []string          `json:"tags" gorm:"-"`
+	Fields map[string]string `json:"fields" gorm:"-"`
+	Meta   NodeMeta          `json:"-" gorm:"type:jsonb"`
 }
 
 type NodeContentChunk struct {
@@ -188,6 +201,7 @@ type NodeContentChunk struct {
 	Seq     uint   `json:"seq"`
 	Name    string `json:"name"`
 	Content string `json:"content"`
+	Source  string `json:"source,omitempty"` // 来自附件时为附件名及页码
 }
 
 type RankedNodeChunks struct {
@@ -236,6 +250,35 @@ type NodeActionReq struct {
 	IDs    []string `json:"ids" validate:"required"`
 	KBID   string   `json:"kb_id" validate:"required"`
 	Action string   `json:"action" validate:"required,oneof=delete"`
+	Force  bool     `json:"force"` // 文档被其他文档链接时仍然删除
+}
+
+// NodeRevisionKeep 每个文档保留的最近修订数
+const NodeRevisionKeep = 20
+
+// table: node_revisions
+type NodeRevision struct {
+	NodeID    string    `json:"node_id" gorm:"primaryKey"`
+	Revision  int64     `json:"revision" gorm:"primaryKey"`
+	KBID      string    `json:"kb_id"`
+	Name      string    `json:"name"`
+	Content   string    `json:"content"`
+	EditorId  string    `json:"editor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (NodeRevision) TableName() string {
+	return "node_revisions"
+}
+
+// NodeConflictError 保存时传入的修订号已过期，Current 为当前的文档
+type NodeConflictError struct {
+	BaseRevision int64
+	Current      *Node
+}
+
+func (e *NodeConflictError) Error() string {
+	return fmt.Sprintf("node revision conflict: base %d, current %d", e.BaseRevision, e.Current.Revision)
 }
 
 type UpdateNodeReq struct {
@@ -248,6 +291,10 @@ type UpdateNodeReq struct {
 	Position    *float64 `json:"position"`
 	ContentType *string  `json:"content_type"`
 	NavId       *string  `json:"nav_id"`
+	Revision    *int64   `json:"revision"` // 编辑时获取的修订号，修改名称或内容时必须传入
+
+	Tags   *[]string          `json:"tags"`   // 整体替换标签
+	Fields *map[string]string `json:"fields"` // 合并更新字段，值为空时删除该字段
 }
 
 type ShareNodeListItemResp struct {
@@ -280,6 +327,22 @@ func (n *ShareNodeListItemResp) GetURL(baseURL string) string {
 	return fmt.Sprintf("%s/node/%s", baseURL, n.ID)
 }
 
+// NodeReleaseFeedItem 订阅源中的一篇已发布文档
+type NodeReleaseFeedItem struct {
+	ID               string          `json:"id"`
+	NodeReleaseID    string          `json:"node_release_id"`
+	Name             string          `json:"name"`
+	Summary          string          `json:"summary"`
+	NavId            string          `json:"nav_id"`
+	Permissions      NodePermissions `json:"permissions" gorm:"type:jsonb"`
+	FirstPublishedAt time.Time       `json:"first_published_at"` // 文档首次发布时间
+	PublishedAt      time.Time       `json:"published_at"`       // 当前版本发布时间
+}
+
+func (n *NodeReleaseFeedItem) GetURL(baseURL string) string {
+	return fmt.Sprintf("%s/node/%s", baseURL, n.ID)
+}
+
 type MoveNodeReq struct {
 	ID       string `json:"id" validate:"required"`
 	KbID     string `json:"kb_id" validate:"required"`
@@ -358,6 +421,7 @@ type BatchMoveReq struct {
 	IDs      []string `json:"ids" validate:"required"`
 	KBID     string   `json:"kb_id" validate:"required"`
 	ParentID string   `json:"parent_id"`
+	Force    bool     `json:"force"` // 文档被其他文档链接时仍然移动
 }
 
 type NodeCreateInfo struct {
@@ -371,3 +435,31 @@ type NodeReleaseWithPublisher struct {
 	PublisherId      string `json:"publisher_id"`
 	PublisherAccount string `json:"publisher_account"`
 }
+
+// NodePresence 正在查看或编辑文档的用户
+type NodePresence struct {
+	UserID    string    `json:"user_id"`
+	Account   string    `json:"account"`
+	Editing   bool      `json:"editing"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NodeCollabTicket 建立协同编辑连接的一次性凭证
+type NodeCollabTicket struct {
+	UserID string `json:"user_id"`
+	KBID   string `json:"kb_id"`
+	NodeID string `json:"node_id"`
+}
+
+// table: node_collab_states
+type NodeCollabState struct {
+	NodeID    string    `json:"node_id" gorm:"primaryKey"`
+	KBID      string    `json:"kb_id"`
+	State     []byte    `json:"-"`
+	Revision  int64     `json:"revision"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (NodeCollabState) TableName() string {
+	return "node_collab_states"
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type NodeFAQStatus string
+
+const (
+	NodeFAQStatusPending  NodeFAQStatus = "pending"  // 待审核
+	NodeFAQStatusApproved NodeFAQStatus = "approved" // 已通过，写入检索记录
+	NodeFAQStatusRejected NodeFAQStatus = "rejected" // 已拒绝，重新生成时不再出现相同问题
+)
+
+// 每篇文档默认生成 5 个问答对
+const DefaultNodeFAQMaxPerNode = 5
+
+// NodeFAQ 根据文档内容生成的问答对，通过后作为附加检索记录，检索命中时归属到来源文档
+type NodeFAQ struct {
+	ID          string        `json:"id" gorm:"primaryKey"`
+	KBID        string        `json:"kb_id"`
+	NodeID      string        `json:"node_id"`
+	Question    string        `json:"question"`
+	Answer      string        `json:"answer"`
+	Status      NodeFAQStatus `json:"status"`
+	DocID       string        `json:"doc_id"`        // 检索记录 ID，未写入时为空
+	ParentDocID string        `json:"parent_doc_id"` // 写入时来源文档发布版本的检索记录 ID
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+func (NodeFAQ) TableName() string {
+	return "node_faqs"
+}
+
+// Source 检索结果中标明内容来自问答对
+func (f *NodeFAQ) Source() string {
+	return "常见问题：" + f.Question
+}
+
+// RetrievalContent 写入检索记录的内容
+func (f *NodeFAQ) RetrievalContent() string {
+	return fmt.Sprintf("问题：%s\n答案：%s", f.Question, f.Answer)
+}
+
+// NodeFAQSetting 问答对生成设置
+type NodeFAQSetting struct {
+	AutoGenerate bool `json:"auto_generate"`                        // 发布后自动为文档生成待审核的问答对
+	MaxPerNode   int  `json:"max_per_node" validate:"min=0,max=20"` // 0 表示默认 5 个
+}
+
+func (s *NodeFAQSetting) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *NodeFAQSetting) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid node faq setting type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s *NodeFAQSetting) GetMaxPerNode() int {
+	if s.MaxPerNode <= 0 {
+		return DefaultNodeFAQMaxPerNode
+	}
+	return s.MaxPerNode
+}
+
+// FAQPair 模型生成的问答对
+type FAQPair struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// ParseFAQPairs 解析模型输出的 JSON 数组，忽略数组前后的说明文字和代码块标记
+func ParseFAQPairs(output string) ([]FAQPair, error) {
+	var pairs []FAQPair
+	if err := json.Unmarshal([]byte(extractJSONArray(output)), &pairs); err != nil {
+		return nil, fmt.Errorf("parse faq pairs failed: %w", err)
+	}
+	valid := make([]FAQPair, 0, len(pairs))
+	for _, pair := range pairs {
+		pair.Question = strings.TrimSpace(pair.Question)
+		pair.Answer = strings.TrimSpace(pair.Answer)
+		if pair.Question != "" && pair.Answer != "" {
+			valid = append(valid, pair)
+		}
+	}
+	return valid, nil
+}
+
+// ParseQuestions 解析模型输出的问题列表
+func ParseQuestions(output string) ([]string, error) {
+	var questions []string
+	if err := json.Unmarshal([]byte(extractJSONArray(output)), &questions); err != nil {
+		return nil, fmt.Errorf("parse questions failed: %w", err)
+	}
+	valid := make([]string, 0, len(questions))
+	for _, question := range questions {
+		if question = strings.TrimSpace(question); question != "" {
+			valid = append(valid, question)
+		}
+	}
+	return valid, nil
+}
+
+func extractJSONArray(output string) string {
+	start := strings.Index(output, "[")
+	end := strings.LastIndex(output, "]")
+	if start == -1 || end < start {
+		return output
+	}
+	return output[start : end+1]
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFAQPairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []FAQPair
+		err      string
+	}{
+		{
+			name:     "plain json",
+			output:   `[{"question":"如何安装？","answer":"运行 install.sh"}]`,
+			expected: []FAQPair{{Question: "如何安装？", Answer: "运行 install.sh"}},
+		},
+		{
+			name:     "code fence and prose",
+			output:   "以下是问答对：\n```json\n[{\"question\":\"q1\",\"answer\":\"a1\"}]\n```\n希望有帮助",
+			expected: []FAQPair{{Question: "q1", Answer: "a1"}},
+		},
+		{
+			name:     "trim and drop incomplete pairs",
+			output:   `[{"question":"  q1 ","answer":" a1\n"},{"question":"q2","answer":"  "},{"question":"","answer":"a3"}]`,
+			expected: []FAQPair{{Question: "q1", Answer: "a1"}},
+		},
+		{
+			name:     "empty array",
+			output:   `[]`,
+			expected: []FAQPair{},
+		},
+		{
+			name:   "no array",
+			output: "抱歉，无法生成",
+			err:    "parse faq pairs failed",
+		},
+		{
+			name:   "wrong element type",
+			output: `["q1","q2"]`,
+			err:    "parse faq pairs failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := ParseFAQPairs(tt.output)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pairs)
+		})
+	}
+}
+
+func TestParseQuestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+		err      string
+	}{
+		{"plain json", `["q1","q2"]`, []string{"q1", "q2"}, ""},
+		{"code fence", "```json\n[\" q1 \", \"\", \"q2\"]\n```", []string{"q1", "q2"}, ""},
+		{"invalid", "没有问题", nil, "parse questions failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, err := ParseQuestions(tt.output)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, questions)
+		})
+	}
+}
+
+func TestNodeFAQSetting(t *testing.T) {
+	tests := []struct {
+		name     string
+		setting  NodeFAQSetting
+		expected int
+	}{
+		{"default", NodeFAQSetting{}, DefaultNodeFAQMaxPerNode},
+		{"negative", NodeFAQSetting{MaxPerNode: -1}, DefaultNodeFAQMaxPerNode},
+		{"custom", NodeFAQSetting{MaxPerNode: 10}, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.setting.GetMaxPerNode())
+		})
+	}
+
+	setting := &NodeFAQSetting{AutoGenerate: true, MaxPerNode: 8}
+	value, err := setting.Value()
+	require.NoError(t, err)
+	var scanned NodeFAQSetting
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, *setting, scanned)
+	assert.Error(t, scanned.Scan("{}"))
+}
+
+func TestNodeFAQ_RetrievalContent(t *testing.T) {
+	faq := &NodeFAQ{Question: "如何安装？", Answer: "运行 install.sh"}
+	assert.Equal(t, "问题：如何安装？\n答案：运行 install.sh", faq.RetrievalContent())
+	assert.Equal(t, "常见问题：如何安装？", faq.Source())
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 文档中的链接，HTML 的 href 和 Markdown 的 [text](url)
+var (
+	htmlLinkRegexp     = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	markdownLinkRegexp = regexp.MustCompile(`\]\(\s*<?([^\s)>]+)`)
+	// 站内文档地址，可能带有站点地址
+	nodeURLRegexp = regexp.MustCompile(`^(?:https?://[^\s"'()<>/]+)?/node/([0-9a-fA-F-]{36})(?:[/?#].*)?$`)
+)
+
+const nodeLinkMaxURLLength = 2048
+
+// NodeLink 发布版本中的链接，站内链接记录指向的文档
+type NodeLink struct {
+	KBID         string    `json:"kb_id"`
+	SourceNodeID string    `json:"source_node_id" gorm:"primaryKey"`
+	URL          string    `json:"url" gorm:"primaryKey"`
+	TargetNodeID string    `json:"target_node_id"` // 外部链接为空
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (NodeLink) TableName() string {
+	return "node_links"
+}
+
+type LinkCheckStatus string
+
+const (
+	LinkCheckStatusOK      LinkCheckStatus = "ok"
+	LinkCheckStatusBroken  LinkCheckStatus = "broken"
+	LinkCheckStatusSkipped LinkCheckStatus = "skipped" // 内网地址不检查
+)
+
+// LinkCheck 外部链接的检查结果，按地址在知识库之间共享
+type LinkCheck struct {
+	URL        string          `json:"url" gorm:"primaryKey"`
+	Status     LinkCheckStatus `json:"status"`
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error"`
+	CheckedAt  time.Time       `json:"checked_at"`
+}
+
+func (LinkCheck) TableName() string {
+	return "link_checks"
+}
+
+// ExtractLinks 提取内容中的 http(s) 链接和站内文档链接，按出现顺序去重
+func ExtractLinks(content string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, re := range []*regexp.Regexp{htmlLinkRegexp, markdownLinkRegexp} {
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			link := strings.TrimSpace(html.UnescapeString(match[1]))
+			if len(link) > nodeLinkMaxURLLength || seen[link] {
+				continue
+			}
+			if _, ok := ParseNodeURL(link); !ok && !isExternalLink(link) {
+				continue
+			}
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ParseNodeURL 返回站内文档地址中的文档 ID
+func ParseNodeURL(link string) (string, bool) {
+	match := nodeURLRegexp.FindStringSubmatch(link)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+func isExternalLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// NodeReference 其他文档对待删除或移动文档的引用
+type NodeReference struct {
+	SourceID   string `json:"source_id"`
+	SourceName string `json:"source_name"`
+	TargetID   string `json:"target_id"`
+	TargetName string `json:"target_name"`
+	URL        string `json:"url"`
+}
+
+// NodeReferencedError 文档被其他文档链接，需确认后才能删除或移动
+type NodeReferencedError struct {
+	References []*NodeReference
+}
+
+func (e *NodeReferencedError) Error() string {
+	return fmt.Sprintf("node is referenced by %d links", len(e.References))
+}
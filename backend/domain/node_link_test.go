@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNodeURL(t *testing.T) {
+	const id = "0192f3c4-5d6e-7f80-91a2-b3c4d5e6f708"
+
+	tests := []struct {
+		name     string
+		link     string
+		expected string
+		ok       bool
+	}{
+		{"relative", "/node/" + id, id, true},
+		{"with host", "https://wiki.example.com/node/" + id, id, true},
+		{"with port", "http://127.0.0.1:8080/node/" + id, id, true},
+		{"with anchor", "/node/" + id + "#heading", id, true},
+		{"with query", "/node/" + id + "?from=share", id, true},
+		{"with sub path", "/node/" + id + "/edit", id, true},
+		{"uppercase id", "/node/" + strings.ToUpper(id), strings.ToUpper(id), true},
+		{"short id", "/node/0192f3c4", "", false},
+		{"id with suffix", "/node/" + id + "x", "", false},
+		{"nested path", "/share/node/" + id, "", false},
+		{"other path", "/welcome", "", false},
+		{"relative without slash", "node/" + id, "", false},
+		{"other scheme", "ftp://example.com/node/" + id, "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeID, ok := ParseNodeURL(tt.link)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, nodeID)
+		})
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	const id = "0192f3c4-5d6e-7f80-91a2-b3c4d5e6f708"
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"html links", `<a href="https://a.com">a</a><a HREF = 'http://b.com/x?y=1'>b</a>`, []string{"https://a.com", "http://b.com/x?y=1"}},
+		{"html escaped", `<a href="https://a.com/?x=1&amp;y=2">a</a>`, []string{"https://a.com/?x=1&y=2"}},
+		{"markdown links", "[a](https://a.com) ![img](https://b.com/x.png \"title\")", []string{"https://a.com", "https://b.com/x.png"}},
+		{"markdown angle brackets", "[a](<https://a.com/x>)", []string{"https://a.com/x"}},
+		{"node links", `<a href="/node/` + id + `">doc</a>[doc](/node/` + id + `#h1)`, []string{"/node/" + id, "/node/" + id + "#h1"}},
+		{"deduplicated", `<a href="https://a.com">a</a>[a](https://a.com)<a href=" https://a.com ">a</a>`, []string{"https://a.com"}},
+		{"relative links ignored", `<a href="/welcome">a</a>[b](./b.md)[c](#anchor)`, nil},
+		{"other schemes ignored", `<a href="mailto:a@b.com">a</a><a href="javascript:void(0)">b</a>[c](ftp://c.com)`, nil},
+		{"missing host ignored", `<a href="https:///path">a</a>`, nil},
+		{"too long ignored", `<a href="https://a.com/` + strings.Repeat("x", nodeLinkMaxURLLength) + `">a</a>`, nil},
+		{"plain text ignored", "visit https://a.com today", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractLinks(tt.content))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type NodeFieldType string
+
+const (
+	NodeFieldTypeText   NodeFieldType = "text"
+	NodeFieldTypeNumber NodeFieldType = "number"
+	NodeFieldTypeDate   NodeFieldType = "date"   // YYYY-MM-DD
+	NodeFieldTypeSelect NodeFieldType = "select" // 取值为 Options 之一
+	NodeFieldTypeUser   NodeFieldType = "user"   // 取值为用户 ID
+)
+
+// nodeFieldKeyRegexp 字段标识同时作为检索时的元数据键
+var nodeFieldKeyRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// nodeFieldReservedKeys 检索元数据中已使用的键
+var nodeFieldReservedKeys = []string{"group_ids", "tags"}
+
+// NodeMetaSchema 知识库的标签词表和自定义字段定义，例如负责人、产品版本、读者和过期日期
+type NodeMetaSchema struct {
+	Tags   []NodeTagDef   `json:"tags" validate:"omitempty,dive"`
+	Fields []NodeFieldDef `json:"fields" validate:"omitempty,dive"`
+}
+
+type NodeTagDef struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color"`
+}
+
+type NodeFieldDef struct {
+	Key     string        `json:"key" validate:"required"`
+	Name    string        `json:"name" validate:"required,max=50"`
+	Type    NodeFieldType `json:"type" validate:"required,oneof=text number date select user"`
+	Options []string      `json:"options,omitempty"`
+}
+
+func (s *NodeMetaSchema) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *NodeMetaSchema) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid node meta schema type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// Validate 检查标签和字段标识不重复，下拉字段需要可选值
+func (s *NodeMetaSchema) Validate() error {
+	tags := make(map[string]bool, len(s.Tags))
+	for _, tag := range s.Tags {
+		if tags[tag.Name] {
+			return fmt.Errorf("duplicate tag: %s", tag.Name)
+		}
+		tags[tag.Name] = true
+	}
+	keys := make(map[string]bool, len(s.Fields))
+	for _, field := range s.Fields {
+		if !nodeFieldKeyRegexp.MatchString(field.Key) || slices.Contains(nodeFieldReservedKeys, field.Key) {
+			return fmt.Errorf("invalid field key: %s", field.Key)
+		}
+		if keys[field.Key] {
+			return fmt.Errorf("duplicate field key: %s", field.Key)
+		}
+		keys[field.Key] = true
+		if field.Type == NodeFieldTypeSelect && len(field.Options) == 0 {
+			return fmt.Errorf("select field %s requires options", field.Key)
+		}
+	}
+	return nil
+}
+
+func (s *NodeMetaSchema) Field(key string) (NodeFieldDef, bool) {
+	for _, field := range s.Fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return NodeFieldDef{}, false
+}
+
+// ValidateTags 标签必须来自词表
+func (s *NodeMetaSchema) ValidateTags(tags []string) error {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(s.Tags, func(def NodeTagDef) bool { return def.Name == tag }) {
+			return fmt.Errorf("unknown tag: %s", tag)
+		}
+	}
+	return nil
+}
+
+// ValidateFields 字段必须已定义且取值符合类型，用户字段的取值需由调用方另行校验
+func (s *NodeMetaSchema) ValidateFields(fields map[string]string) error {
+	for key, value := range fields {
+		field, ok := s.Field(key)
+		if !ok {
+			return fmt.Errorf("unknown field: %s", key)
+		}
+		if err := field.validateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f NodeFieldDef) validateValue(value string) error {
+	switch f.Type {
+	case NodeFieldTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("field %s requires a number", f.Key)
+		}
+	case NodeFieldTypeDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return fmt.Errorf("field %s requires a date in YYYY-MM-DD", f.Key)
+		}
+	case NodeFieldTypeSelect:
+		if !slices.Contains(f.Options, value) {
+			return fmt.Errorf("field %s must be one of %s", f.Key, strings.Join(f.Options, ", "))
+		}
+	}
+	return nil
+}
+
+// NodeMetaFilter 按标签和自定义字段过滤节点，所有条件需同时满足
+type NodeMetaFilter struct {
+	Tags   []string `json:"tags" query:"tags"`
+	Fields []string `json:"fields" query:"fields"` // key:value
+}
+
+func (f *NodeMetaFilter) IsEmpty() bool {
+	return f == nil || (len(f.Tags) == 0 && len(f.Fields) == 0)
+}
+
+// FieldMap 解析 key:value 形式的字段条件，忽略格式不正确的条件
+func (f *NodeMetaFilter) FieldMap() map[string]string {
+	if f == nil || len(f.Fields) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(f.Fields))
+	for _, cond := range f.Fields {
+		if key, value, ok := strings.Cut(cond, ":"); ok && key != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func (f *NodeMetaFilter) Match(meta NodeMeta) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(meta.Tags, tag) {
+			return false
+		}
+	}
+	for key, value := range f.FieldMap() {
+		if meta.Fields[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Containment 用于 meta @> ? 查询的 JSON
+func (f *NodeMetaFilter) Containment() string {
+	cond := make(map[string]any)
+	if len(f.Tags) > 0 {
+		cond["tags"] = f.Tags
+	}
+	if fields := f.FieldMap(); len(fields) > 0 {
+		cond["fields"] = fields
+	}
+	b, _ := json.Marshal(cond)
+	return string(b)
+}
+
+// RAGMetadata 检索时按字段过滤的元数据
+func (f *NodeMetaFilter) RAGMetadata() map[string]any {
+	metadata := make(map[string]any)
+	for key, value := range f.FieldMap() {
+		metadata[key] = value
+	}
+	return metadata
+}
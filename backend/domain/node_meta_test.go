@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeMetaSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema NodeMetaSchema
+		err    string
+	}{
+		{"empty", NodeMetaSchema{}, ""},
+		{
+			"valid",
+			NodeMetaSchema{
+				Tags: []NodeTagDef{{Name: "API"}, {Name: "FAQ"}},
+				Fields: []NodeFieldDef{
+					{Key: "owner", Type: NodeFieldTypeUser},
+					{Key: "version_2", Type: NodeFieldTypeSelect, Options: []string{"v1", "v2"}},
+					{Key: "expires_at", Type: NodeFieldTypeDate},
+				},
+			},
+			"",
+		},
+		{"duplicate tag", NodeMetaSchema{Tags: []NodeTagDef{{Name: "API"}, {Name: "API"}}}, "duplicate tag: API"},
+		{"duplicate field", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "owner", Type: NodeFieldTypeText}, {Key: "owner", Type: NodeFieldTypeUser}}}, "duplicate field key: owner"},
+		{"upper case key", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "Owner", Type: NodeFieldTypeText}}}, "invalid field key: Owner"},
+		{"key starts with digit", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "1st", Type: NodeFieldTypeText}}}, "invalid field key: 1st"},
+		{"key too long", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "a" + strings.Repeat("b", 50), Type: NodeFieldTypeText}}}, "invalid field key"},
+		{"reserved key", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "group_ids", Type: NodeFieldTypeText}}}, "invalid field key: group_ids"},
+		{"select without options", NodeMetaSchema{Fields: []NodeFieldDef{{Key: "level", Type: NodeFieldTypeSelect}}}, "select field level requires options"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestNodeMetaSchema_ValidateFields(t *testing.T) {
+	schema := NodeMetaSchema{
+		Tags: []NodeTagDef{{Name: "API"}},
+		Fields: []NodeFieldDef{
+			{Key: "owner", Type: NodeFieldTypeUser},
+			{Key: "score", Type: NodeFieldTypeNumber},
+			{Key: "expires_at", Type: NodeFieldTypeDate},
+			{Key: "level", Type: NodeFieldTypeSelect, Options: []string{"low", "high"}},
+			{Key: "note", Type: NodeFieldTypeText},
+		},
+	}
+	tests := []struct {
+		name   string
+		fields map[string]string
+		err    string
+	}{
+		{"empty", nil, ""},
+		{"valid", map[string]string{"owner": "u1", "score": "3.5", "expires_at": "2026-01-31", "level": "high", "note": "任意"}, ""},
+		{"unknown field", map[string]string{"team": "a"}, "unknown field: team"},
+		{"invalid number", map[string]string{"score": "high"}, "field score requires a number"},
+		{"invalid date", map[string]string{"expires_at": "2026/01/31"}, "field expires_at requires a date"},
+		{"invalid option", map[string]string{"level": "mid"}, "field level must be one of low, high"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateFields(tt.fields)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	assert.NoError(t, schema.ValidateTags([]string{"API"}))
+	assert.ErrorContains(t, schema.ValidateTags([]string{"API", "FAQ"}), "unknown tag: FAQ")
+}
+
+func TestNodeMetaFilter_FieldMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   *NodeMetaFilter
+		expected map[string]string
+	}{
+		{"nil", nil, nil},
+		{"no fields", &NodeMetaFilter{Tags: []string{"API"}}, nil},
+		{"key value", &NodeMetaFilter{Fields: []string{"owner:u1", "level:high"}}, map[string]string{"owner": "u1", "level": "high"}},
+		{"value with colon", &NodeMetaFilter{Fields: []string{"time:10:30"}}, map[string]string{"time": "10:30"}},
+		{"empty value", &NodeMetaFilter{Fields: []string{"note:"}}, map[string]string{"note": ""}},
+		{"malformed conditions are ignored", &NodeMetaFilter{Fields: []string{"owner", ":u1", "level:low"}}, map[string]string{"level": "low"}},
+		{"last condition wins", &NodeMetaFilter{Fields: []string{"level:low", "level:high"}}, map[string]string{"level": "high"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.FieldMap())
+		})
+	}
+}
+
+func TestNodeMetaFilter_Match(t *testing.T) {
+	meta := NodeMeta{Tags: []string{"API", "FAQ"}, Fields: map[string]string{"owner": "u1", "level": "high"}}
+	tests := []struct {
+		name     string
+		filter   *NodeMetaFilter
+		expected bool
+	}{
+		{"nil filter", nil, true},
+		{"empty filter", &NodeMetaFilter{}, true},
+		{"all tags", &NodeMetaFilter{Tags: []string{"API", "FAQ"}}, true},
+		{"missing tag", &NodeMetaFilter{Tags: []string{"API", "SDK"}}, false},
+		{"field", &NodeMetaFilter{Fields: []string{"owner:u1"}}, true},
+		{"field mismatch", &NodeMetaFilter{Fields: []string{"owner:u2"}}, false},
+		{"tag and field", &NodeMetaFilter{Tags: []string{"FAQ"}, Fields: []string{"level:high"}}, true},
+		{"missing field", &NodeMetaFilter{Fields: []string{"team:a"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Match(meta))
+		})
+	}
+}
+
+func TestNodeMetaFilter_Containment(t *testing.T) {
+	f := &NodeMetaFilter{Tags: []string{"API"}, Fields: []string{"owner:u1", "bad"}}
+	assert.JSONEq(t, `{"tags":["API"],"fields":{"owner":"u1"}}`, f.Containment())
+	assert.Equal(t, map[string]any{"owner": "u1"}, f.RAGMetadata())
+	assert.JSONEq(t, `{}`, (&NodeMetaFilter{}).Containment())
+}
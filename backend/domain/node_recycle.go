@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultRecycleRetentionDays 知识库未配置时回收站的保留天数
+const DefaultRecycleRetentionDays = 30
+
+// RecycleExpiredAt 回收站记录被彻底删除的时间，保留天数未配置时使用默认值
+func RecycleExpiredAt(deletedAt time.Time, retentionDays int) time.Time {
+	if retentionDays <= 0 {
+		retentionDays = DefaultRecycleRetentionDays
+	}
+	return deletedAt.AddDate(0, 0, retentionDays)
+}
+
+// table: node_recycles
+type NodeRecycle struct {
+	ID        string              `json:"id" gorm:"primaryKey"`
+	KBID      string              `json:"kb_id" gorm:"index"`
+	NodeID    string              `json:"node_id"` // 被删除子树的根节点
+	NavId     string              `json:"nav_id"`
+	ParentID  string              `json:"parent_id"`
+	Type      NodeType            `json:"type"`
+	Name      string              `json:"name"`
+	NodeCount int                 `json:"node_count"`
+	Snapshot  NodeRecycleSnapshot `json:"-" gorm:"type:jsonb"`
+	DeletedBy string              `json:"deleted_by"`
+	DeletedAt time.Time           `json:"deleted_at"`
+}
+
+func (NodeRecycle) TableName() string {
+	return "node_recycles"
+}
+
+// NodeRecycleSnapshot 删除时子树内所有节点（含草稿）和发布版本的原始数据
+type NodeRecycleSnapshot struct {
+	Nodes    []*Node        `json:"nodes"`
+	Releases []*NodeRelease `json:"releases"`
+}
+
+func (s NodeRecycleSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *NodeRecycleSnapshot) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid node recycle snapshot type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecycleExpiredAt(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	deletedAt := time.Date(2026, 1, 31, 23, 30, 0, 0, shanghai)
+	tests := []struct {
+		name          string
+		deletedAt     time.Time
+		retentionDays int
+		expected      time.Time
+	}{
+		{"configured", deletedAt, 7, time.Date(2026, 2, 7, 23, 30, 0, 0, shanghai)},
+		{"one day", deletedAt, 1, time.Date(2026, 2, 1, 23, 30, 0, 0, shanghai)},
+		{"default when unset", deletedAt, 0, time.Date(2026, 3, 2, 23, 30, 0, 0, shanghai)},
+		{"default when negative", deletedAt, -1, time.Date(2026, 3, 2, 23, 30, 0, 0, shanghai)},
+		{"across leap day", time.Date(2028, 2, 28, 0, 0, 0, 0, time.UTC), 1, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"max retention", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 3650, time.Date(2035, 12, 30, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(RecycleExpiredAt(tt.deletedAt, tt.retentionDays)))
+		})
+	}
+}
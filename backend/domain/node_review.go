@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 同一文档默认每 7 天重复提醒一次
+const DefaultReviewRemindIntervalDays = 7
+
+// NodeReview 文档或文件夹的负责人和复查周期，空值表示继承上级
+type NodeReview struct {
+	NodeID       string     `json:"node_id" gorm:"primaryKey"`
+	KBID         string     `json:"kb_id"`
+	OwnerID      string     `json:"owner_id"`
+	IntervalDays int        `json:"interval_days"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	RemindedAt   *time.Time `json:"reminded_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (NodeReview) TableName() string {
+	return "node_reviews"
+}
+
+// NodeReviewSetting 过期文档提醒，发送到通知渠道，负责人账号为邮箱时可单独发送邮件
+type NodeReviewSetting struct {
+	Enabled            bool            `json:"enabled"`
+	Channels           []NotifyChannel `json:"channels" validate:"omitempty,dive"`
+	NotifyOwner        bool            `json:"notify_owner"`
+	RemindIntervalDays int             `json:"remind_interval_days" validate:"min=0,max=365"` // 0 表示默认 7 天
+}
+
+func (s *NodeReviewSetting) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *NodeReviewSetting) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid node review setting type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s *NodeReviewSetting) GetRemindInterval() time.Duration {
+	days := s.RemindIntervalDays
+	if days <= 0 {
+		days = DefaultReviewRemindIntervalDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// NodeReviewNode 计算复查状态所需的节点信息
+type NodeReviewNode struct {
+	ID        string     `json:"id"`
+	ParentID  string     `json:"parent_id"`
+	Name      string     `json:"name"`
+	Type      NodeType   `json:"type"`
+	Status    NodeStatus `json:"status"`
+	EditTime  time.Time  `json:"edit_time"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NodeReviewPolicy 节点生效的负责人和复查周期，From 为设置该值的节点
+type NodeReviewPolicy struct {
+	OwnerID      string `json:"owner_id"`
+	OwnerFrom    string `json:"owner_from"`
+	IntervalDays int    `json:"interval_days"`
+	IntervalFrom string `json:"interval_from"`
+}
+
+// ResolveNodeReviewPolicies 负责人和复查周期分别沿目录树向下继承，未设置的节点使用最近上级的设置
+func ResolveNodeReviewPolicies(nodes []*NodeReviewNode, reviews map[string]*NodeReview) map[string]*NodeReviewPolicy {
+	parents := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		parents[node.ID] = node.ParentID
+	}
+	policies := make(map[string]*NodeReviewPolicy, len(nodes))
+	var resolve func(id string, depth int) *NodeReviewPolicy
+	resolve = func(id string, depth int) *NodeReviewPolicy {
+		if policy, ok := policies[id]; ok {
+			return policy
+		}
+		policy := &NodeReviewPolicy{}
+		// 目录树异常出现环时不再继续向上查找
+		if parentID, ok := parents[id]; ok && parentID != "" && depth < len(nodes) {
+			*policy = *resolve(parentID, depth+1)
+		}
+		if review, ok := reviews[id]; ok {
+			if review.OwnerID != "" {
+				policy.OwnerID, policy.OwnerFrom = review.OwnerID, id
+			}
+			if review.IntervalDays > 0 {
+				policy.IntervalDays, policy.IntervalFrom = review.IntervalDays, id
+			}
+		}
+		policies[id] = policy
+		return policy
+	}
+	for _, node := range nodes {
+		resolve(node.ID, 0)
+	}
+	return policies
+}
+
+// ReviewDueAt 最近一次编辑或确认复查后经过复查周期即到期，未设置周期时不会到期
+func ReviewDueAt(node *NodeReviewNode, review *NodeReview, intervalDays int) (lastReviewedAt time.Time, dueAt *time.Time) {
+	lastReviewedAt = node.EditTime
+	if review != nil && review.ReviewedAt != nil && review.ReviewedAt.After(lastReviewedAt) {
+		lastReviewedAt = *review.ReviewedAt
+	}
+	if intervalDays <= 0 {
+		return lastReviewedAt, nil
+	}
+	due := lastReviewedAt.AddDate(0, 0, intervalDays)
+	return lastReviewedAt, &due
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SystemNodeTemplateIDPrefix 内置模板的 ID 前缀，内置模板不存储在数据库中，不可修改
+const SystemNodeTemplateIDPrefix = "system-"
+
+// table: node_templates
+type NodeTemplate struct {
+	ID          string            `json:"id" gorm:"primaryKey"`
+	KBID        string            `json:"kb_id" gorm:"index"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Type        NodeType          `json:"type"`
+	Content     string            `json:"content"`
+	ContentType string            `json:"content_type"`
+	Emoji       string            `json:"emoji"`
+	Permissions NodePermissions   `json:"permissions" gorm:"type:jsonb"` // 从模板创建的节点的默认权限
+	Children    NodeTemplateNodes `json:"children" gorm:"type:jsonb"`    // 文件夹模板的子节点
+	CreatorID   string            `json:"creator_id"`
+	System      bool              `json:"system" gorm:"-"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+func (NodeTemplate) TableName() string {
+	return "node_templates"
+}
+
+// NodeTemplateNode 文件夹模板中的子节点
+type NodeTemplateNode struct {
+	Type        NodeType          `json:"type" validate:"required,oneof=1 2"`
+	Name        string            `json:"name" validate:"required"`
+	Content     string            `json:"content"`
+	ContentType string            `json:"content_type" validate:"omitempty,oneof=md html"`
+	Emoji       string            `json:"emoji"`
+	Children    NodeTemplateNodes `json:"children,omitempty" validate:"omitempty,dive"`
+}
+
+type NodeTemplateNodes []*NodeTemplateNode
+
+func (n NodeTemplateNodes) Value() (driver.Value, error) {
+	if n == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(n)
+}
+
+func (n *NodeTemplateNodes) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid node template children type:", value))
+	}
+	return json.Unmarshal(bytes, n)
+}
+
+// Count 子节点总数，包括各级子孙节点
+func (n NodeTemplateNodes) Count() int {
+	count := len(n)
+	for _, node := range n {
+		count += node.Children.Count()
+	}
+	return count
+}
+
+// Depth 子节点的最大层数
+func (n NodeTemplateNodes) Depth() int {
+	depth := 0
+	for _, node := range n {
+		depth = max(depth, node.Children.Depth()+1)
+	}
+	return depth
+}
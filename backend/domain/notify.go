@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+// NotifyChannel 通知渠道，邮件使用 Emails，群机器人使用 WebhookURL/Secret
+type NotifyChannel struct {
+	Type       consts.NotifyChannelType `json:"type" validate:"required,oneof=email dingtalk feishu wecom"`
+	Emails     []string                 `json:"emails,omitempty" validate:"omitempty,dive,email"`
+	WebhookURL string                   `json:"webhook_url,omitempty" validate:"omitempty,url"`
+	Secret     string                   `json:"secret,omitempty"` // 钉钉/飞书加签密钥
+}
+
+type NotifyChannels []NotifyChannel
+
+func (c NotifyChannels) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *NotifyChannels) Scan(value any) error {
+	if value == nil {
+		*c = NotifyChannels{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid notify channels value type")
+	}
+	return json.Unmarshal(bytes, c)
+}
@@ -14,4 +14,6 @@ var (
 	ErrCodePermissionDenied = PWResponseErrCode{"Permission Denied", false, nil, 40003}
 	ErrCodeNotFound         = PWResponseErrCode{"Not Found", false, nil, 40004}
 	ErrCodeInternalError    = PWResponseErrCode{"Internal Error", false, nil, 50001}
+	ErrCodeNodeConflict     = PWResponseErrCode{"Node Conflict", false, nil, 40009}
+	ErrCodeNodeReferenced   = PWResponseErrCode{"Node Referenced", false, nil, 40010}
 )
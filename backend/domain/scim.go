@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	SCIMTypeInvalidFilter = "invalidFilter"
+	SCIMTypeUniqueness    = "uniqueness"
+	SCIMTypeInvalidValue  = "invalidValue"
+	SCIMTypeInvalidPath   = "invalidPath"
+)
+
+// SCIMError SCIM协议错误，按 RFC 7644 3.12 返回
+type SCIMError struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *SCIMError) Error() string {
+	return fmt.Sprintf("scim error %d %s: %s", e.Status, e.ScimType, e.Detail)
+}
+
+func NewSCIMError(status int, scimType, detail string) *SCIMError {
+	return &SCIMError{Status: status, ScimType: scimType, Detail: detail}
+}
+
+func NewSCIMNotFound(resource, id string) *SCIMError {
+	return NewSCIMError(http.StatusNotFound, "", fmt.Sprintf("%s %s not found", resource, id))
+}
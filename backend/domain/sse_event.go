@@ -5,4 +5,5 @@ type SSEEvent struct {
 	Content     string               `json:"content"`
 	ChunkResult *NodeContentChunkSSE `json:"chunk_result,omitempty"`
 	Error       string               `json:"error,omitempty"`
+	Suggestions []string             `json:"suggestions,omitempty"` // 回答结束后推荐的追问
 }
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
 )
 
 type StatPageScene int
@@ -106,6 +108,79 @@ func (StatPageHour) TableName() string {
 	return "stat_page_hours"
 }
 
+// StatPageRollup 按天/按月汇总的长期统计数据，不做清理
+// UV(ip_count/session_count) 由小时数据累加而来，跨小时的重复访客会被重复计数
+type StatPageRollup struct {
+	ID                       int64                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	KbID                     string                 `json:"kb_id"`
+	Granularity              consts.StatGranularity `json:"granularity"`
+	Period                   time.Time              `json:"period"` // 按天/月截断的时间
+	IPCount                  int64                  `json:"ip_count"`
+	SessionCount             int64                  `json:"session_count"`
+	PageVisitCount           int64                  `json:"page_visit_count"`
+	ConversationCount        int64                  `json:"conversation_count"`
+	GeoCount                 MapStrInt64            `json:"geo_count" gorm:"type:jsonb"`
+	ConversationDistribution MapStrInt64            `json:"conversation_distribution" gorm:"type:jsonb"`
+	HotRefererHost           MapStrInt64            `json:"hot_referer_host" gorm:"type:jsonb"`
+	HotPage                  MapStrInt64            `json:"hot_page" gorm:"type:jsonb"`
+	HotBrowser               MapStrInt64            `json:"hot_browser" gorm:"type:jsonb"`
+	HotOS                    MapStrInt64            `json:"hot_os" gorm:"type:jsonb"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (StatPageRollup) TableName() string {
+	return "stat_page_rollups"
+}
+
+func NewStatPageRollup(kbID string, granularity consts.StatGranularity, period time.Time) *StatPageRollup {
+	return &StatPageRollup{
+		KbID:                     kbID,
+		Granularity:              granularity,
+		Period:                   period,
+		GeoCount:                 make(MapStrInt64),
+		ConversationDistribution: make(MapStrInt64),
+		HotRefererHost:           make(MapStrInt64),
+		HotPage:                  make(MapStrInt64),
+		HotBrowser:               make(MapStrInt64),
+		HotOS:                    make(MapStrInt64),
+	}
+}
+
+// AddHour 累加一条小时统计数据
+func (r *StatPageRollup) AddHour(h *StatPageHour) {
+	r.add(h.IPCount, h.SessionCount, h.PageVisitCount, h.ConversationCount,
+		h.GeoCount, h.ConversationDistribution, h.HotRefererHost, h.HotPage, h.HotBrowser, h.HotOS)
+}
+
+// AddRollup 累加一条更细粒度的汇总数据
+func (r *StatPageRollup) AddRollup(o *StatPageRollup) {
+	r.add(o.IPCount, o.SessionCount, o.PageVisitCount, o.ConversationCount,
+		o.GeoCount, o.ConversationDistribution, o.HotRefererHost, o.HotPage, o.HotBrowser, o.HotOS)
+}
+
+func (r *StatPageRollup) add(ip, session, pv, conversation int64, geo, dist, referer, page, browser, os MapStrInt64) {
+	r.IPCount += ip
+	r.SessionCount += session
+	r.PageVisitCount += pv
+	r.ConversationCount += conversation
+	for dst, src := range map[*MapStrInt64]MapStrInt64{
+		&r.GeoCount:                 geo,
+		&r.ConversationDistribution: dist,
+		&r.HotRefererHost:           referer,
+		&r.HotPage:                  page,
+		&r.HotBrowser:               browser,
+		&r.HotOS:                    os,
+	} {
+		if *dst == nil {
+			*dst = make(MapStrInt64)
+		}
+		for k, v := range src {
+			(*dst)[k] += v
+		}
+	}
+}
+
 // NodeStats node表统计数据
 type NodeStats struct {
 	ID     int64  `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -116,3 +191,65 @@ type NodeStats struct {
 func (NodeStats) TableName() string {
 	return "node_stats"
 }
+
+type StatNodeEventType string
+
+const (
+	StatNodeEventRead      StatNodeEventType = "read"       // 离开页面时上报阅读时长和滚动深度
+	StatNodeEventLinkClick StatNodeEventType = "link_click" // 点击外部链接
+	StatNodeEventFeedback  StatNodeEventType = "feedback"   // 文档有用/没用
+)
+
+// StatNodeEvent 文档阅读行为事件
+type StatNodeEvent struct {
+	ID          int64             `json:"id" gorm:"primaryKey;autoIncrement"`
+	KBID        string            `json:"kb_id"`
+	NodeID      string            `json:"node_id"`
+	UserID      uint              `json:"user_id"`
+	SessionID   string            `json:"session_id"`
+	IP          string            `json:"ip"`
+	Type        StatNodeEventType `json:"type"`
+	ReadSeconds int64             `json:"read_seconds"`
+	ScrollDepth int               `json:"scroll_depth"` // 0-100
+	LinkURL     string            `json:"link_url"`
+	Score       ScoreType         `json:"score"` // 1 有用, -1 没用
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+func (StatNodeEvent) TableName() string {
+	return "stat_node_events"
+}
+
+type StatNodeEventReq struct {
+	NodeID      string            `json:"node_id" validate:"required"`
+	Type        StatNodeEventType `json:"type" validate:"required,oneof=read link_click feedback"`
+	ReadSeconds int64             `json:"read_seconds" validate:"min=0,max=86400"`
+	ScrollDepth int               `json:"scroll_depth" validate:"min=0,max=100"`
+	LinkURL     string            `json:"link_url" validate:"omitempty,url"`
+	Score       ScoreType         `json:"score" validate:"omitempty,oneof=-1 1"`
+}
+
+// NodeEngagement 文档阅读行为汇总
+type NodeEngagement struct {
+	NodeID         string  `json:"node_id"`
+	NodeName       string  `json:"node_name" gorm:"-"`
+	PV             int64   `json:"pv" gorm:"-"`
+	ReadCount      int64   `json:"read_count"`
+	AvgReadSeconds float64 `json:"avg_read_seconds"`
+	AvgScrollDepth float64 `json:"avg_scroll_depth"`
+	FinishCount    int64   `json:"finish_count"` // 滚动深度达到 90% 的阅读次数
+	BounceCount    int64   `json:"bounce_count"` // 阅读不足 10 秒的次数
+	LinkClickCount int64   `json:"link_click_count"`
+	HelpfulCount   int64   `json:"helpful_count"`
+	UnhelpfulCount int64   `json:"unhelpful_count"`
+}
+
+type NodeEngagementTrendItem struct {
+	Date time.Time `json:"date"`
+	NodeEngagement
+}
+
+type NodeLinkClick struct {
+	LinkURL string `json:"link_url"`
+	Count   int64  `json:"count"`
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+// table: stat_reports
+type StatReport struct {
+	ID         string                  `json:"id" gorm:"primaryKey"`
+	KBID       string                  `json:"kb_id" gorm:"index"`
+	Name       string                  `json:"name"`
+	Period     consts.StatReportPeriod `json:"period"`
+	Enabled    bool                    `json:"enabled"`
+	Channels   NotifyChannels          `json:"channels" gorm:"type:jsonb"`
+	LastSentAt *time.Time              `json:"last_sent_at"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+}
+
+func (StatReport) TableName() string {
+	return "stat_reports"
+}
+
+// StatReportDigest 统计报表内容
+type StatReportDigest struct {
+	KBID   string                  `json:"kb_id"`
+	KBName string                  `json:"kb_name"`
+	Period consts.StatReportPeriod `json:"period"`
+	Start  time.Time               `json:"start"`
+	End    time.Time               `json:"end"`
+
+	PageVisitCount        int64 `json:"page_visit_count"`
+	IPCount               int64 `json:"ip_count"`
+	ConversationCount     int64 `json:"conversation_count"`
+	NegativeFeedbackCount int64 `json:"negative_feedback_count"`
+	TotalTokens           int64 `json:"total_tokens"`
+
+	Trend               []*StatTrendItem       `json:"trend"`
+	HotPages            []*HotPage             `json:"hot_pages"`
+	UnansweredQuestions []*QuestionCount       `json:"unanswered_questions"`
+	TokenUsage          []*ModelTokenUsageStat `json:"token_usage"`
+}
+
+// StatTrendItem 按天/按月聚合的访问趋势，UV 以独立 IP 计
+type StatTrendItem struct {
+	Date              time.Time `json:"date"`
+	PageVisitCount    int64     `json:"page_visit_count"`
+	IPCount           int64     `json:"ip_count"`
+	SessionCount      int64     `json:"session_count"`
+	ConversationCount int64     `json:"conversation_count"`
+}
+
+type QuestionCount struct {
+	Question string `json:"question"`
+	Count    int64  `json:"count"`
+}
+
+type ModelTokenUsageStat struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+}
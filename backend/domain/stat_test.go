@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+func TestStatNodeEventReq_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   StatNodeEventReq
+		valid bool
+	}{
+		{"read", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventRead, ReadSeconds: 120, ScrollDepth: 100}, true},
+		{"link click", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventLinkClick, LinkURL: "https://example.com/a"}, true},
+		{"helpful", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventFeedback, Score: Like}, true},
+		{"unhelpful", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventFeedback, Score: DisLike}, true},
+		{"missing node", StatNodeEventReq{Type: StatNodeEventRead}, false},
+		{"unknown type", StatNodeEventReq{NodeID: "n1", Type: "share"}, false},
+		{"negative read seconds", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventRead, ReadSeconds: -1}, false},
+		{"read seconds over a day", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventRead, ReadSeconds: 86401}, false},
+		{"scroll depth over 100", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventRead, ScrollDepth: 101}, false},
+		{"invalid link", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventLinkClick, LinkURL: "not a url"}, false},
+		{"invalid score", StatNodeEventReq{NodeID: "n1", Type: StatNodeEventFeedback, Score: 5}, false},
+	}
+
+	v := validator.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.req)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStatPageRollup_Add(t *testing.T) {
+	day := NewStatPageRollup("kb", consts.StatGranularityDay, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	day.AddHour(&StatPageHour{
+		IPCount: 2, SessionCount: 3, PageVisitCount: 10, ConversationCount: 1,
+		GeoCount:                 MapStrInt64{"北京": 2},
+		ConversationDistribution: MapStrInt64{"1": 1},
+		HotPage:                  MapStrInt64{"n1": 8, "n2": 2},
+		HotBrowser:               MapStrInt64{"Chrome": 3},
+	})
+	// 小时数据中缺失的 map 不影响累加
+	day.AddHour(&StatPageHour{
+		IPCount: 1, SessionCount: 1, PageVisitCount: 4,
+		HotPage:        MapStrInt64{"n1": 4},
+		HotRefererHost: MapStrInt64{"google.com": 1},
+		HotOS:          MapStrInt64{"macOS": 1},
+	})
+	assert.Equal(t, int64(3), day.IPCount)
+	assert.Equal(t, int64(4), day.SessionCount)
+	assert.Equal(t, int64(14), day.PageVisitCount)
+	assert.Equal(t, int64(1), day.ConversationCount)
+	assert.Equal(t, MapStrInt64{"n1": 12, "n2": 2}, day.HotPage)
+	assert.Equal(t, MapStrInt64{"google.com": 1}, day.HotRefererHost)
+	assert.Equal(t, MapStrInt64{"macOS": 1}, day.HotOS)
+
+	month := &StatPageRollup{KbID: "kb", Granularity: consts.StatGranularityMonth}
+	month.AddRollup(day)
+	month.AddRollup(day)
+	assert.Equal(t, int64(28), month.PageVisitCount)
+	assert.Equal(t, MapStrInt64{"北京": 4}, month.GeoCount)
+	assert.Equal(t, MapStrInt64{"1": 2}, month.ConversationDistribution)
+	assert.Equal(t, MapStrInt64{"n1": 24, "n2": 4}, month.HotPage)
+	assert.Equal(t, MapStrInt64{"Chrome": 6}, month.HotBrowser)
+	// 累加不修改来源数据
+	assert.Equal(t, MapStrInt64{"n1": 12, "n2": 2}, day.HotPage)
+}
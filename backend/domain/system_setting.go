@@ -33,3 +33,48 @@ type ModelModeSetting struct {
 type UploadDeniedExtensionsSetting struct {
 	DeniedExtensions []string `json:"denied_extensions"` // 禁止上传的文件扩展名列表，不带点，如 ["jsp", "php", "exe"]
 }
+
+// SMTPSetting 邮件发送配置，用于统计报表等通知
+// INSERT INTO "public"."system_settings" ("key", "value") VALUES ('smtp', '{"host": "smtp.example.com", "port": 465, "username": "wiki@example.com", "password": "xxx", "from": "wiki@example.com", "tls": true}')
+type SMTPSetting struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	TLS      bool   `json:"tls"` // 是否使用 SMTPS 直连，否则尝试 STARTTLS
+}
+
+// AdminSSOSetting 管理后台单点登录配置，首次登录时自动创建用户
+type AdminSSOSetting struct {
+	Enabled        bool              `json:"enabled"`
+	SourceType     consts.SourceType `json:"source_type" validate:"omitempty,oneof=ldap oidc oauth cas"`
+	BaseURL        string            `json:"base_url"`         // 管理后台访问地址，用于拼接回调地址
+	DefaultRole    consts.UserRole   `json:"default_role"`     // 自动创建用户的角色，默认 user
+	DefaultKBPerms []AdminSSOKBPerm  `json:"default_kb_perms"` // 自动创建用户默认授予的知识库权限
+
+	AuthSetting AuthSetting         `json:"auth_setting"` // oidc/oauth
+	LDAP        AdminSSOLDAPSetting `json:"ldap"`
+	CAS         AdminSSOCASSetting  `json:"cas"`
+}
+
+type AdminSSOKBPerm struct {
+	KBID string                  `json:"kb_id"`
+	Perm consts.UserKBPermission `json:"perm"`
+}
+
+type AdminSSOLDAPSetting struct {
+	ServerURL     string `json:"server_url"`
+	BindDN        string `json:"bind_dn"`
+	BindPassword  string `json:"bind_password"`
+	UserBaseDN    string `json:"user_base_dn"`
+	UserFilter    string `json:"user_filter"`
+	UserIDAttr    string `json:"user_id_attr"`
+	UserNameAttr  string `json:"user_name_attr"`
+	UserEmailAttr string `json:"user_email_attr"`
+}
+
+type AdminSSOCASSetting struct {
+	ServerURL string `json:"server_url"`
+	Version   string `json:"version"`
+}
@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/chaitin/panda-wiki/consts"
 )
 
@@ -13,6 +15,18 @@ type User struct {
 	Role       consts.UserRole `json:"role" gorm:"default:'user'"`
 	CreatedAt  time.Time       `json:"created_at"`
 	LastAccess time.Time       `json:"last_access" gorm:"default:null"`
+
+	// SSO登录自动创建的用户，本地账号为空
+	SourceType consts.SourceType `json:"source_type"`
+	UnionID    string            `json:"-"`
+
+	TOTPSecret    string         `json:"-" gorm:"column:totp_secret"`
+	TOTPEnabled   bool           `json:"totp_enabled" gorm:"column:totp_enabled"`
+	RecoveryCodes pq.StringArray `json:"-" gorm:"type:text[]"` // sha256后的恢复码，使用后移除
+}
+
+func (u *User) IsLocal() bool {
+	return u.SourceType == ""
 }
 
 // KBUsers 知识库用户关联表（多对多关系）
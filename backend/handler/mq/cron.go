@@ -6,26 +6,42 @@ import (
 
 	"github.com/robfig/cron/v3"
 
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/metrics"
+	"github.com/chaitin/panda-wiki/mq"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/usecase"
 )
 
 type CronHandler struct {
-	logger      *log.Logger
-	statRepo    *pg.StatRepository
-	nodeRepo    *pg.NodeRepository
-	statUseCase *usecase.StatUseCase
-	nodeUseCase *usecase.NodeUsecase
+	logger        *log.Logger
+	statRepo      *pg.StatRepository
+	nodeRepo      *pg.NodeRepository
+	statUseCase   *usecase.StatUseCase
+	nodeUseCase   *usecase.NodeUsecase
+	reportUsecase *usecase.StatReportUsecase
+	ldapSync      *usecase.LDAPSyncUsecase
+	attachment    *usecase.AttachmentUsecase
+	nodeLink      *usecase.NodeLinkUsecase
+	nodeReview    *usecase.NodeReviewUsecase
+	consumer      mq.MQConsumer
 }
 
-func NewCronHandler(logger *log.Logger, statRepo *pg.StatRepository, nodeRepo *pg.NodeRepository, statUseCase *usecase.StatUseCase, nodeUseCase *usecase.NodeUsecase) (*CronHandler, error) {
+func NewCronHandler(logger *log.Logger, statRepo *pg.StatRepository, nodeRepo *pg.NodeRepository, statUseCase *usecase.StatUseCase, nodeUseCase *usecase.NodeUsecase, reportUsecase *usecase.StatReportUsecase, ldapSync *usecase.LDAPSyncUsecase, attachment *usecase.AttachmentUsecase, nodeLink *usecase.NodeLinkUsecase, nodeReview *usecase.NodeReviewUsecase, consumer mq.MQConsumer, config *config.Config) (*CronHandler, error) {
 	h := &CronHandler{
-		statRepo:    statRepo,
-		nodeRepo:    nodeRepo,
-		statUseCase: statUseCase,
-		nodeUseCase: nodeUseCase,
-		logger:      logger.WithModule("handler.mq.cron"),
+		statRepo:      statRepo,
+		nodeRepo:      nodeRepo,
+		statUseCase:   statUseCase,
+		nodeUseCase:   nodeUseCase,
+		reportUsecase: reportUsecase,
+		ldapSync:      ldapSync,
+		attachment:    attachment,
+		nodeLink:      nodeLink,
+		nodeReview:    nodeReview,
+		consumer:      consumer,
+		logger:        logger.WithModule("handler.mq.cron"),
 	}
 	cron := cron.New()
 
@@ -50,6 +66,13 @@ func NewCronHandler(logger *log.Logger, statRepo *pg.StatRepository, nodeRepo *p
 	}
 	h.logger.Info("add cron job", log.String("cron_id", "cleanup_old_hourly_stats"))
 
+	// 每天0点15分将前一天的小时统计汇总为按天/按月数据，长期保留
+	if _, err := cron.AddFunc("15 0 * * *", h.AggregateRollupStats); err != nil {
+		h.logger.Error("failed to add cron job for aggregating rollup stats", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "aggregate_rollup_stats"))
+
 	// 启动时先异步跑一次
 	go func() {
 		if err := h.nodeUseCase.SyncRagNodeStatus(context.Background()); err != nil {
@@ -69,6 +92,64 @@ func NewCronHandler(logger *log.Logger, statRepo *pg.StatRepository, nodeRepo *p
 	}
 	h.logger.Info("add cron job", log.String("cron_id", "cleanup_old_node_release_backups"))
 
+	// 每天2点30分彻底删除超过保留天数的回收站记录
+	if _, err := cron.AddFunc("30 2 * * *", h.CleanupExpiredRecycles); err != nil {
+		h.logger.Error("failed to add cron job for cleaning up expired recycles", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "cleanup_expired_recycles"))
+
+	// 每天9点发送统计日报
+	if _, err := cron.AddFunc("0 9 * * *", h.SendDailyStatReports); err != nil {
+		h.logger.Error("failed to add cron job for sending daily stat reports", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "send_daily_stat_reports"))
+
+	// 每天10点提醒超过复查期限的文档
+	if _, err := cron.AddFunc("0 10 * * *", h.RemindStaleNodes); err != nil {
+		h.logger.Error("failed to add cron job for reminding stale nodes", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "remind_stale_nodes"))
+
+	// 每周一9点发送统计周报
+	if _, err := cron.AddFunc("0 9 * * 1", h.SendWeeklyStatReports); err != nil {
+		h.logger.Error("failed to add cron job for sending weekly stat reports", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "send_weekly_stat_reports"))
+
+	// 开启指标时每分钟更新消息积压和向量化状态指标
+	if config.Metrics.Enabled {
+		if _, err := cron.AddFunc("* * * * *", h.UpdateMetrics); err != nil {
+			h.logger.Error("failed to add cron job for updating metrics", log.Error(err))
+			return nil, err
+		}
+		h.logger.Info("add cron job", log.String("cron_id", "update_metrics"))
+	}
+
+	// 每小时40分同步LDAP分组
+	if _, err := cron.AddFunc("40 * * * *", h.SyncLDAPGroups); err != nil {
+		h.logger.Error("failed to add cron job for syncing ldap groups", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "sync_ldap_groups"))
+
+	// 每天4点回收无引用的上传文件
+	if _, err := cron.AddFunc("0 4 * * *", h.CollectOrphanAttachments); err != nil {
+		h.logger.Error("failed to add cron job for collecting orphan attachments", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "collect_orphan_attachments"))
+
+	// 每天3点30分检查外部链接
+	if _, err := cron.AddFunc("30 3 * * *", h.CheckExternalLinks); err != nil {
+		h.logger.Error("failed to add cron job for checking external links", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "check_external_links"))
+
 	cron.Start()
 	h.logger.Info("start cron jobs")
 	return h, nil
@@ -113,6 +194,16 @@ func (h *CronHandler) CleanupOldHourlyStats() {
 	h.logger.Info("cleanup old hourly stats successful")
 }
 
+func (h *CronHandler) AggregateRollupStats() {
+	h.logger.Info("aggregate rollup stats start")
+	err := h.statUseCase.AggregateRollupStats(context.Background())
+	if err != nil {
+		h.logger.Error("aggregate rollup stats failed", log.Error(err))
+		return
+	}
+	h.logger.Info("aggregate rollup stats successful")
+}
+
 func (h *CronHandler) SyncRagNodeStatus() {
 	h.logger.Info("sync rag node status")
 	err := h.nodeUseCase.SyncRagNodeStatus(context.Background())
@@ -132,3 +223,79 @@ func (h *CronHandler) CleanupOldNodeReleaseBackups() {
 	}
 	h.logger.Info("cleanup old node release backups successful")
 }
+
+func (h *CronHandler) CleanupExpiredRecycles() {
+	h.logger.Info("cleanup expired recycles start")
+	if err := h.nodeUseCase.CleanupExpiredRecycles(context.Background()); err != nil {
+		h.logger.Error("cleanup expired recycles failed", log.Error(err))
+		return
+	}
+	h.logger.Info("cleanup expired recycles successful")
+}
+
+func (h *CronHandler) SendDailyStatReports() {
+	h.logger.Info("send daily stat reports start")
+	if err := h.reportUsecase.SendScheduledReports(context.Background(), consts.StatReportPeriodDaily); err != nil {
+		h.logger.Error("send daily stat reports failed", log.Error(err))
+		return
+	}
+	h.logger.Info("send daily stat reports successful")
+}
+
+func (h *CronHandler) SendWeeklyStatReports() {
+	h.logger.Info("send weekly stat reports start")
+	if err := h.reportUsecase.SendScheduledReports(context.Background(), consts.StatReportPeriodWeekly); err != nil {
+		h.logger.Error("send weekly stat reports failed", log.Error(err))
+		return
+	}
+	h.logger.Info("send weekly stat reports successful")
+}
+
+func (h *CronHandler) SyncLDAPGroups() {
+	h.logger.Info("sync ldap groups start")
+	if err := h.ldapSync.SyncAll(context.Background()); err != nil {
+		h.logger.Error("sync ldap groups failed", log.Error(err))
+		return
+	}
+	h.logger.Info("sync ldap groups successful")
+}
+
+func (h *CronHandler) CollectOrphanAttachments() {
+	h.logger.Info("collect orphan attachments start")
+	if err := h.attachment.CollectGarbage(context.Background()); err != nil {
+		h.logger.Error("collect orphan attachments failed", log.Error(err))
+		return
+	}
+	h.logger.Info("collect orphan attachments successful")
+}
+
+func (h *CronHandler) CheckExternalLinks() {
+	h.logger.Info("check external links start")
+	if err := h.nodeLink.CheckExternalLinks(context.Background()); err != nil {
+		h.logger.Error("check external links failed", log.Error(err))
+		return
+	}
+	h.logger.Info("check external links successful")
+}
+
+func (h *CronHandler) RemindStaleNodes() {
+	h.logger.Info("remind stale nodes start")
+	if err := h.nodeReview.RemindStaleNodes(context.Background()); err != nil {
+		h.logger.Error("remind stale nodes failed", log.Error(err))
+		return
+	}
+	h.logger.Info("remind stale nodes successful")
+}
+
+func (h *CronHandler) UpdateMetrics() {
+	backlog, err := h.consumer.Backlog()
+	if err != nil {
+		h.logger.Error("get mq backlog failed", log.Error(err))
+	}
+	for topic, b := range backlog {
+		metrics.SetMQPendingMessages(topic, b.Pending, b.AckPending)
+	}
+	if err := h.nodeUseCase.UpdateRagStatusMetrics(context.Background()); err != nil {
+		h.logger.Error("update rag status metrics failed", log.Error(err))
+	}
+}
@@ -0,0 +1,83 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/mq"
+	"github.com/chaitin/panda-wiki/mq/types"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+type DeadLetterHandler struct {
+	consumer       mq.MQConsumer
+	logger         *log.Logger
+	nodeRepo       *pg.NodeRepository
+	deadLetterRepo *pg.MQDeadLetterRepository
+}
+
+func NewDeadLetterHandler(consumer mq.MQConsumer, logger *log.Logger, nodeRepo *pg.NodeRepository, deadLetterRepo *pg.MQDeadLetterRepository) (*DeadLetterHandler, error) {
+	h := &DeadLetterHandler{
+		consumer:       consumer,
+		logger:         logger.WithModule("mq.dead_letter"),
+		nodeRepo:       nodeRepo,
+		deadLetterRepo: deadLetterRepo,
+	}
+	if err := consumer.RegisterHandler(domain.DeadLetterTopic, h.HandleDeadLetter); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// HandleDeadLetter 持久化死信以便后台查看和重新投递，向量化任务同时标记文档学习失败
+func (h *DeadLetterHandler) HandleDeadLetter(ctx context.Context, msg types.Message) error {
+	var deadLetter types.DeadLetter
+	if err := json.Unmarshal(msg.GetData(), &deadLetter); err != nil {
+		h.logger.Error("unmarshal dead letter failed", log.Error(err))
+		return nil
+	}
+
+	record := &domain.MQDeadLetter{
+		ID:       uuid.New().String(),
+		Topic:    deadLetter.Topic,
+		Payload:  deadLetter.Payload,
+		Error:    deadLetter.Error,
+		Attempts: deadLetter.Attempts,
+		FailedAt: deadLetter.FailedAt,
+	}
+
+	var request domain.NodeReleaseVectorRequest
+	if deadLetter.Topic == domain.VectorTaskTopic && json.Unmarshal([]byte(deadLetter.Payload), &request) == nil {
+		record.KBID = request.KBID
+		record.NodeID = request.NodeID
+		if record.NodeID == "" && request.NodeReleaseID != "" {
+			if nodeRelease, err := h.nodeRepo.GetNodeReleaseByID(ctx, request.NodeReleaseID); err == nil {
+				record.NodeID = nodeRelease.NodeID
+			}
+		}
+		// 仅向量化写入失败影响文档的学习状态
+		if request.Action == "upsert" && record.NodeID != "" {
+			if err := h.nodeRepo.Update(ctx, record.NodeID, map[string]interface{}{
+				"rag_info": domain.RagInfo{
+					Status:   consts.NodeRagStatusFailed,
+					Message:  deadLetter.Error,
+					SyncedAt: time.Now(),
+				},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := h.deadLetterRepo.Create(ctx, record); err != nil {
+		return err
+	}
+	h.logger.Info("dead letter saved", log.String("topic", record.Topic), log.String("node_id", record.NodeID))
+	return nil
+}
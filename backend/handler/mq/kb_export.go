@@ -0,0 +1,40 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/mq"
+	"github.com/chaitin/panda-wiki/mq/types"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type KBExportMQHandler struct {
+	consumer      mq.MQConsumer
+	logger        *log.Logger
+	exportUsecase *usecase.KBExportUsecase
+}
+
+func NewKBExportMQHandler(consumer mq.MQConsumer, logger *log.Logger, exportUsecase *usecase.KBExportUsecase) (*KBExportMQHandler, error) {
+	h := &KBExportMQHandler{
+		consumer:      consumer,
+		logger:        logger.WithModule("mq.kb_export"),
+		exportUsecase: exportUsecase,
+	}
+	if err := consumer.RegisterHandler(domain.KBExportTaskTopic, h.HandleKBExportRequest); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *KBExportMQHandler) HandleKBExportRequest(ctx context.Context, msg types.Message) error {
+	var request domain.KBExportTaskRequest
+	if err := json.Unmarshal(msg.GetData(), &request); err != nil {
+		h.logger.Error("unmarshal kb export request failed", log.Error(err))
+		return nil
+	}
+	h.logger.Info("handle kb export request", log.String("kb_id", request.KBID), log.String("id", request.ExportID))
+	return ignoreNotFound(h.exportUsecase.Export(ctx, &request))
+}
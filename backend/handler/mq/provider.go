@@ -15,6 +15,8 @@ type MQHandlers struct {
 	RAGMQHandler        *RAGMQHandler
 	RagDocUpdateHandler *RagDocUpdateHandler
 	StatCronHandler     *CronHandler
+	DeadLetterHandler   *DeadLetterHandler
+	KBExportMQHandler   *KBExportMQHandler
 }
 
 var ProviderSet = wire.NewSet(
@@ -26,12 +28,23 @@ var ProviderSet = wire.NewSet(
 
 	usecase.NewLLMUsecase,
 	usecase.NewStatUseCase,
+	usecase.NewStatReportUsecase,
 	usecase.NewNodeUsecase,
 	usecase.NewModelUsecase,
+	usecase.NewLDAPSyncUsecase,
+	usecase.NewAttachmentUsecase,
+	usecase.NewAttachmentIndexUsecase,
+	usecase.NewKBExportUsecase,
+	usecase.NewNodeLinkUsecase,
+	usecase.NewNodeReviewUsecase,
+	usecase.NewNodeFAQUsecase,
+	usecase.NewCuratedAnswerUsecase,
 
 	NewRAGMQHandler,
 	NewRagDocUpdateHandler,
 	NewCronHandler,
+	NewDeadLetterHandler,
+	NewKBExportMQHandler,
 
 	wire.Struct(new(MQHandlers), "*"),
 )
@@ -3,6 +3,10 @@ package mq
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
 
 	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/domain"
@@ -22,9 +26,12 @@ type RAGMQHandler struct {
 	kbRepo       *pg.KnowledgeBaseRepository
 	llmUsecase   *usecase.LLMUsecase
 	modelUsecase *usecase.ModelUsecase
+	attachment   *usecase.AttachmentIndexUsecase
+	faq          *usecase.NodeFAQUsecase
+	answer       *usecase.CuratedAnswerUsecase
 }
 
-func NewRAGMQHandler(consumer mq.MQConsumer, logger *log.Logger, rag rag.RAGService, nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, llmUsecase *usecase.LLMUsecase, modelUsecase *usecase.ModelUsecase) (*RAGMQHandler, error) {
+func NewRAGMQHandler(consumer mq.MQConsumer, logger *log.Logger, rag rag.RAGService, nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, llmUsecase *usecase.LLMUsecase, modelUsecase *usecase.ModelUsecase, attachment *usecase.AttachmentIndexUsecase, faq *usecase.NodeFAQUsecase, answer *usecase.CuratedAnswerUsecase) (*RAGMQHandler, error) {
 	h := &RAGMQHandler{
 		consumer:     consumer,
 		logger:       logger.WithModule("mq.rag"),
@@ -33,6 +40,9 @@ func NewRAGMQHandler(consumer mq.MQConsumer, logger *log.Logger, rag rag.RAGServ
 		kbRepo:       kbRepo,
 		llmUsecase:   llmUsecase,
 		modelUsecase: modelUsecase,
+		attachment:   attachment,
+		faq:          faq,
+		answer:       answer,
 	}
 	if err := consumer.RegisterHandler(domain.VectorTaskTopic, h.HandleNodeContentVectorRequest); err != nil {
 		return nil, err
@@ -40,6 +50,7 @@ func NewRAGMQHandler(consumer mq.MQConsumer, logger *log.Logger, rag rag.RAGServ
 	return h, nil
 }
 
+// HandleNodeContentVectorRequest 处理向量化任务，返回错误的消息会按退避策略重试，多次失败后转入死信
 func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg types.Message) error {
 	var request domain.NodeReleaseVectorRequest
 	err := json.Unmarshal(msg.GetData(), &request)
@@ -53,11 +64,19 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		kb, err := h.kbRepo.GetKnowledgeBaseByID(ctx, request.KBID)
 		if err != nil {
 			h.logger.Error("get kb failed", log.Error(err))
-			return nil
+			return ignoreNotFound(fmt.Errorf("get kb failed: %w", err))
 		}
 		if err := h.rag.UpdateDocumentGroupIDs(ctx, kb.DatasetID, request.DocID, request.GroupIds); err != nil {
 			h.logger.Error("update node group failed", log.Error(err))
-			return nil
+			return fmt.Errorf("update document group ids failed: %w", err)
+		}
+		if err := h.attachment.UpdateNodeAttachmentGroupIDs(ctx, kb.DatasetID, request.DocID, request.GroupIds); err != nil {
+			h.logger.Error("update node attachment group failed", log.Error(err))
+			return err
+		}
+		if err := h.faq.UpdateNodeFAQGroupIDs(ctx, kb.DatasetID, request.DocID, request.GroupIds); err != nil {
+			h.logger.Error("update node faq group failed", log.Error(err))
+			return err
 		}
 		h.logger.Info("update node group success", log.Any("doc_id", request.DocID), log.Any("group_ids", request.GroupIds))
 
@@ -66,7 +85,7 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		nodeRelease, err := h.nodeRepo.GetNodeReleaseWithDirPathByID(ctx, request.NodeReleaseID)
 		if err != nil {
 			h.logger.Error("get node content by ids failed", log.Error(err))
-			return nil
+			return ignoreNotFound(fmt.Errorf("get node release failed: %w", err))
 		}
 		if nodeRelease.Type == domain.NodeTypeFolder {
 			h.logger.Info("node is folder, skip upsert", log.Any("node_release_id", request.NodeReleaseID))
@@ -75,13 +94,13 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		kb, err := h.kbRepo.GetKnowledgeBaseByID(ctx, request.KBID)
 		if err != nil {
 			h.logger.Error("get kb failed", log.Error(err), log.String("kb_id", request.KBID))
-			return nil
+			return ignoreNotFound(fmt.Errorf("get kb failed: %w", err))
 		}
 
 		groupIds, err := h.nodeRepo.GetNodeAuthGroupIdsByNodeId(ctx, nodeRelease.NodeID, consts.NodePermNameAnswerable)
 		if err != nil {
 			h.logger.Error("get groupIds failed", log.Error(err), log.String("kb_id", request.KBID))
-			return nil
+			return fmt.Errorf("get node auth group ids failed: %w", err)
 		}
 
 		// upsert node content chunks
@@ -92,28 +111,40 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 			DocID:     nodeRelease.DocID,
 			Content:   nodeRelease.Content,
 			GroupIDs:  groupIds,
+			Tags:      nodeRelease.Meta.Tags,
+			Metadata:  nodeFieldsMetadata(nodeRelease.Meta.Fields),
 		})
 		if err != nil {
 			h.logger.Error("upsert node content vector failed", log.Error(err))
-			return nil
+			return fmt.Errorf("upsert records failed: %w", err)
 		}
 		// update node doc_id
 		if err := h.nodeRepo.UpdateNodeReleaseDocID(ctx, request.NodeReleaseID, docID); err != nil {
 			h.logger.Error("update node doc_id failed", log.String("node_id", request.NodeReleaseID), log.Error(err))
-			return nil
+			return fmt.Errorf("update node release doc_id failed: %w", err)
+		}
+		// upsert attachment chunks as child records of the node
+		nodeRelease.DocID = docID
+		if err := h.attachment.IndexNodeAttachments(ctx, kb.DatasetID, nodeRelease.NodeRelease, groupIds); err != nil {
+			h.logger.Error("index node attachments failed", log.String("node_id", nodeRelease.NodeID), log.Error(err))
+			return err
+		}
+		if err := h.faq.IndexNodeFAQs(ctx, kb.DatasetID, nodeRelease.NodeRelease, groupIds); err != nil {
+			h.logger.Error("index node faqs failed", log.String("node_id", nodeRelease.NodeID), log.Error(err))
+			return err
 		}
 		// delete old RAG records
 		// get old doc_ids by node_id
 		oldDocIDs, err := h.nodeRepo.GetOldNodeDocIDsByNodeID(ctx, nodeRelease.ID, nodeRelease.NodeID)
 		if err != nil {
 			h.logger.Error("get old doc_ids by node_id failed", log.String("node_id", nodeRelease.NodeID), log.Error(err))
-			return nil
+			return fmt.Errorf("get old doc_ids failed: %w", err)
 		}
 		if len(oldDocIDs) > 0 {
 			// delete old RAG records
 			if err := h.rag.DeleteRecords(ctx, kb.DatasetID, oldDocIDs); err != nil {
 				h.logger.Error("delete old RAG records failed", log.String("kb_id", kb.ID), log.Error(err))
-				return nil
+				return fmt.Errorf("delete old records failed: %w", err)
 			}
 		}
 
@@ -123,11 +154,19 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		kb, err := h.kbRepo.GetKnowledgeBaseByID(ctx, request.KBID)
 		if err != nil {
 			h.logger.Error("get kb failed", log.Error(err))
-			return nil
+			return ignoreNotFound(fmt.Errorf("get kb failed: %w", err))
 		}
 		if err := h.rag.DeleteRecords(ctx, kb.DatasetID, []string{request.DocID}); err != nil {
 			h.logger.Error("delete node content vector failed", log.Error(err))
-			return nil
+			return fmt.Errorf("delete records failed: %w", err)
+		}
+		if err := h.attachment.DeleteNodeAttachmentDocs(ctx, kb.DatasetID, request.DocID); err != nil {
+			h.logger.Error("delete node attachment vector failed", log.Error(err))
+			return err
+		}
+		if err := h.faq.DeleteNodeFAQDocs(ctx, kb.DatasetID, request.DocID); err != nil {
+			h.logger.Error("delete node faq vector failed", log.Error(err))
+			return err
 		}
 		h.logger.Info("delete node content vector success", log.Any("deleted_id", request.NodeReleaseID), log.Any("deleted_doc_id", request.DocID))
 	case "summary":
@@ -135,7 +174,7 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		node, err := h.nodeRepo.GetNodeByID(ctx, request.NodeID)
 		if err != nil {
 			h.logger.Error("get node by id failed", log.Error(err))
-			return nil
+			return ignoreNotFound(fmt.Errorf("get node failed: %w", err))
 		}
 		if node.Type == domain.NodeTypeFolder {
 			h.logger.Info("node is folder, skip summary", log.Any("node_id", request.NodeID))
@@ -151,21 +190,64 @@ func (h *RAGMQHandler) HandleNodeContentVectorRequest(ctx context.Context, msg t
 		summary, err := h.llmUsecase.SummaryNode(ctx, request.KBID, model, node.Name, node.Content)
 		if err != nil {
 			h.logger.Error("summary node content failed", log.Error(err))
-			return nil
+			return fmt.Errorf("summary node failed: %w", err)
 		}
 		if err := h.nodeRepo.UpdateNodeSummary(ctx, request.KBID, request.NodeID, summary); err != nil {
 			h.logger.Error("update node summary failed", log.Error(err))
-			return nil
+			return fmt.Errorf("update node summary failed: %w", err)
 		}
 		if node.Status == domain.NodeStatusPublished {
 			if err := h.nodeRepo.UpdateNodeStatus(ctx, request.KBID, request.NodeID, domain.NodeStatusDraft); err != nil {
 				h.logger.Error("update node status failed", log.Error(err))
-				return nil
+				return fmt.Errorf("update node status failed: %w", err)
 			}
 		}
 
 		h.logger.Info("summary node content vector success", log.Any("summary_id", request.NodeReleaseID), log.Any("summary", summary))
+	case "faq":
+		h.logger.Info("generate node faq request", log.Any("request", request))
+		var release *domain.NodeRelease
+		if request.NodeReleaseID != "" {
+			release, err = h.nodeRepo.GetNodeReleaseByID(ctx, request.NodeReleaseID)
+		} else {
+			release, err = h.nodeRepo.GetLatestNodeReleaseByNodeID(ctx, request.NodeID)
+		}
+		if err != nil {
+			h.logger.Error("get node release failed", log.Error(err))
+			return ignoreNotFound(fmt.Errorf("get node release failed: %w", err))
+		}
+		if _, err := h.modelUsecase.GetChatModel(ctx); err != nil {
+			h.logger.Error("get chat model failed", log.Error(err))
+			return nil
+		}
+		if err := h.faq.GenerateFromRelease(ctx, release); err != nil {
+			h.logger.Error("generate node faq failed", log.String("node_id", release.NodeID), log.Error(err))
+			return fmt.Errorf("generate node faq failed: %w", err)
+		}
+	case "reindex_curated_answers":
+		h.logger.Info("reindex curated answers request", log.Any("request", request))
+		if err := h.answer.Reindex(ctx, request.KBID); err != nil {
+			h.logger.Error("reindex curated answers failed", log.String("kb_id", request.KBID), log.Error(err))
+			return ignoreNotFound(fmt.Errorf("reindex curated answers failed: %w", err))
+		}
 	}
 
 	return nil
 }
+
+// ignoreNotFound 数据已被删除时重试没有意义，直接确认消息
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// nodeFieldsMetadata 自定义字段作为文档元数据，检索时可按字段过滤
+func nodeFieldsMetadata(fields map[string]string) map[string]any {
+	metadata := make(map[string]any, len(fields))
+	for key, value := range fields {
+		metadata[key] = value
+	}
+	return metadata
+}
@@ -36,12 +36,14 @@ func NewShareAuthHandler(
 		authUsecase: authUsecase,
 	}
 
-	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, kbUsecase)
+	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, kbUsecase, authUsecase)
 
 	share := e.Group("share/v1/auth", shareAuthMiddleware.CheckForbidden)
 	share.GET("/get", h.AuthGet)
 	share.POST("/login/simple", h.AuthLoginSimple)
 	share.POST("/github", h.AuthGitHub)
+	share.POST("/oidc", h.AuthOIDC)
+	share.POST("/saml", h.AuthSAML)
 	return h
 }
 
@@ -181,3 +183,85 @@ func (h *ShareAuthHandler) AuthGitHub(c echo.Context) error {
 		Url: url,
 	})
 }
+
+// AuthOIDC OIDC登录
+//
+//	@Tags			ShareAuth
+//	@Summary		OIDC登录
+//	@Description	OIDC登录
+//	@ID				v1-AuthOIDC
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header		string				true	"kb id"
+//	@Param			param	body		v1.AuthOIDCReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.AuthOIDCResp}
+//	@Router			/share/v1/auth/oidc [post]
+func (h *ShareAuthHandler) AuthOIDC(c echo.Context) error {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+
+	var req v1.AuthOIDCReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+	req.KbID = kbID
+
+	valid, err := h.authUsecase.ValidateRedirectUrl(ctx, req.KbID, req.RedirectUrl)
+	if err != nil || !valid {
+		return h.NewResponseWithError(c, "invalid redirect url", err)
+	}
+
+	url, err := h.authUsecase.GenerateOIDCAuthUrl(ctx, req)
+	if err != nil {
+		return h.NewResponseWithError(c, "GenerateOIDCAuthUrl failed", err)
+	}
+
+	return h.NewResponseWithData(c, v1.AuthOIDCResp{
+		Url: url,
+	})
+}
+
+// AuthSAML SAML登录
+//
+//	@Tags			ShareAuth
+//	@Summary		SAML登录
+//	@Description	SAML登录
+//	@ID				v1-AuthSAML
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header		string				true	"kb id"
+//	@Param			param	body		v1.AuthSAMLReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.AuthSAMLResp}
+//	@Router			/share/v1/auth/saml [post]
+func (h *ShareAuthHandler) AuthSAML(c echo.Context) error {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+
+	var req v1.AuthSAMLReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+	req.KbID = kbID
+
+	valid, err := h.authUsecase.ValidateRedirectUrl(ctx, req.KbID, req.RedirectUrl)
+	if err != nil || !valid {
+		return h.NewResponseWithError(c, "invalid redirect url", err)
+	}
+
+	url, err := h.authUsecase.GenerateSAMLAuthUrl(ctx, req)
+	if err != nil {
+		return h.NewResponseWithError(c, "GenerateSAMLAuthUrl failed", err)
+	}
+
+	return h.NewResponseWithData(c, v1.AuthSAMLResp{
+		Url: url,
+	})
+}
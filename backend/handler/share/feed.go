@@ -0,0 +1,107 @@
+package share
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type ShareFeedHandler struct {
+	*handler.BaseHandler
+	logger      *log.Logger
+	feedUsecase *usecase.FeedUsecase
+}
+
+func NewShareFeedHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	feedUsecase *usecase.FeedUsecase,
+	logger *log.Logger,
+) *ShareFeedHandler {
+	h := &ShareFeedHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.share.feed"),
+		feedUsecase: feedUsecase,
+	}
+
+	group := echo.Group("share/v1/feed",
+		h.ShareAuthMiddleware.Authorize,
+	)
+	group.GET("/rss", h.GetRSSFeed)
+	group.GET("/atom", h.GetAtomFeed)
+	group.GET("/json", h.GetJSONFeed)
+
+	return h
+}
+
+// GetRSSFeed
+//
+//	@Summary		最近发布文档的 RSS 订阅源
+//	@Description	GetRSSFeed
+//	@Tags			share_feed
+//	@Produce		xml
+//	@Param			X-KB-ID	header		string				true	"kb id"
+//	@Param			param	query		v1.ShareFeedReq		false	"para"
+//	@Success		200		{string}	string
+//	@Router			/share/v1/feed/rss [get]
+func (h *ShareFeedHandler) GetRSSFeed(c echo.Context) error {
+	return h.getFeed(c, domain.FeedFormatRSS)
+}
+
+// GetAtomFeed
+//
+//	@Summary		最近发布文档的 Atom 订阅源
+//	@Description	GetAtomFeed
+//	@Tags			share_feed
+//	@Produce		xml
+//	@Param			X-KB-ID	header		string				true	"kb id"
+//	@Param			param	query		v1.ShareFeedReq		false	"para"
+//	@Success		200		{string}	string
+//	@Router			/share/v1/feed/atom [get]
+func (h *ShareFeedHandler) GetAtomFeed(c echo.Context) error {
+	return h.getFeed(c, domain.FeedFormatAtom)
+}
+
+// GetJSONFeed
+//
+//	@Summary		最近发布文档的 JSON Feed 订阅源
+//	@Description	GetJSONFeed
+//	@Tags			share_feed
+//	@Produce		json
+//	@Param			X-KB-ID	header		string				true	"kb id"
+//	@Param			param	query		v1.ShareFeedReq		false	"para"
+//	@Success		200		{string}	string
+//	@Router			/share/v1/feed/json [get]
+func (h *ShareFeedHandler) GetJSONFeed(c echo.Context) error {
+	return h.getFeed(c, domain.FeedFormatJSON)
+}
+
+func (h *ShareFeedHandler) getFeed(c echo.Context, format domain.FeedFormat) error {
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	var req v1.ShareFeedReq
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("parse request failed", log.Error(err))
+		return h.NewResponseWithError(c, "parse request failed", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Error("validate request failed", log.Error(err))
+		return h.NewResponseWithError(c, "validate request failed", err)
+	}
+
+	feed, err := h.feedUsecase.GetFeed(c.Request().Context(), kbID, domain.GetAuthID(c), format, &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to generate feed", err)
+	}
+
+	return c.Blob(http.StatusOK, format.ContentType(), feed)
+}
@@ -43,7 +43,8 @@ func NewShareNodeHandler(
 //	@Tags			share_node
 //	@Accept			json
 //	@Produce		json
-//	@Param			X-KB-ID	header		string	true	"kb id"
+//	@Param			X-KB-ID	header		string					true	"kb id"
+//	@Param			param	query		domain.NodeMetaFilter	false	"按标签和字段过滤"
 //	@Success		200		{object}	domain.Response
 //	@Router			/share/v1/node/list [get]
 func (h *ShareNodeHandler) ShareNodeList(c echo.Context) error {
@@ -53,7 +54,12 @@ func (h *ShareNodeHandler) ShareNodeList(c echo.Context) error {
 		return h.NewResponseWithError(c, "kb_id is required", nil)
 	}
 
-	nodes, err := h.usecase.GetShareNodeList(c.Request().Context(), kbId, domain.GetAuthID(c))
+	var filter domain.NodeMetaFilter
+	if err := c.Bind(&filter); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	nodes, err := h.usecase.GetShareNodeList(c.Request().Context(), kbId, domain.GetAuthID(c), &filter)
 	if err != nil {
 		return h.NewResponseWithError(c, "failed to get node list", err)
 	}
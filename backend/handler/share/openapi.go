@@ -42,6 +42,9 @@ func NewOpenapiV1Handler(
 	OpenapiGroup := e.Group("/share/v1/openapi")
 
 	OpenapiGroup.Any("/github/callback", h.GitHubCallback)
+	OpenapiGroup.Any("/oidc/callback", h.OIDCCallback)
+	OpenapiGroup.GET("/saml/:kb_id/metadata", h.SAMLMetadata)
+	OpenapiGroup.POST("/saml/:kb_id/acs", h.SAMLACS)
 
 	// lark机器人
 	OpenapiGroup.POST("/lark/bot/:kb_id", h.LarkBot)
@@ -83,6 +86,99 @@ func (h *OpenapiV1Handler) GitHubCallback(c echo.Context) error {
 	return c.Redirect(http.StatusFound, redirectUrl)
 }
 
+// OIDCCallback OIDC回调
+//
+//	@Tags			ShareOpenapi
+//	@Summary		OIDC回调
+//	@Description	OIDC回调
+//	@ID				v1-OIDCCallback
+//	@Accept			json
+//	@Produce		json
+//	@Param			param	query		v1.OIDCCallbackReq	true	"para"
+//	@Success		302
+//	@Router			/share/v1/openapi/oidc/callback [get]
+func (h *OpenapiV1Handler) OIDCCallback(c echo.Context) error {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+
+	var req v1.OIDCCallbackReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if req.Code == "" {
+		return h.NewResponseWithError(c, "code is required", nil)
+	}
+
+	auth, redirectUrl, err := h.authUseCase.OIDCCallback(ctx, req)
+	if err != nil {
+		return h.NewResponseWithError(c, "handle callback failed", err)
+	}
+
+	if err := h.authUseCase.SaveNewSession(c, auth); err != nil {
+		return h.NewResponseWithError(c, "save session failed", err)
+	}
+
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
+
+// SAMLMetadata SAML SP元数据
+//
+//	@Tags			ShareOpenapi
+//	@Summary		SAML SP元数据
+//	@Description	SAML SP元数据，供IdP导入
+//	@ID				v1-SAMLMetadata
+//	@Produce		xml
+//	@Param			kb_id	path	string	true	"知识库ID"
+//	@Success		200		{string}	string
+//	@Router			/share/v1/openapi/saml/{kb_id}/metadata [get]
+func (h *OpenapiV1Handler) SAMLMetadata(c echo.Context) error {
+	kbID := c.Param("kb_id")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	metadata, err := h.authUseCase.GetSAMLMetadata(c.Request().Context(), kbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get saml metadata failed", err)
+	}
+
+	return c.Blob(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// SAMLACS SAML断言消费服务
+//
+//	@Tags			ShareOpenapi
+//	@Summary		SAML断言消费服务
+//	@Description	接收IdP以HTTP-POST绑定提交的SAMLResponse
+//	@ID				v1-SAMLACS
+//	@Accept			x-www-form-urlencoded
+//	@Param			kb_id		path		string	true	"知识库ID"
+//	@Param			SAMLResponse	formData	string	true	"SAMLResponse"
+//	@Param			RelayState	formData	string	true	"RelayState"
+//	@Success		302
+//	@Router			/share/v1/openapi/saml/{kb_id}/acs [post]
+func (h *OpenapiV1Handler) SAMLACS(c echo.Context) error {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+
+	var req v1.SAMLACSReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if req.RelayState == "" {
+		return h.NewResponseWithError(c, "RelayState is required", nil)
+	}
+
+	auth, redirectUrl, err := h.authUseCase.SAMLCallback(ctx, c.Request(), req)
+	if err != nil {
+		return h.NewResponseWithError(c, "handle saml response failed", err)
+	}
+
+	if err := h.authUseCase.SaveNewSession(c, auth); err != nil {
+		return h.NewResponseWithError(c, "save session failed", err)
+	}
+
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
+
 // LarkBot Lark机器人请求
 //
 //	@Tags			ShareOpenapi
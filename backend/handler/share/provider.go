@@ -20,6 +20,8 @@ type ShareHandler struct {
 	ShareCaptchaHandler      *ShareCaptchaHandler
 	OpenapiV1Handler         *OpenapiV1Handler
 	ShareCommonHandler       *ShareCommonHandler
+	ShareSCIMHandler         *ShareSCIMHandler
+	ShareFeedHandler         *ShareFeedHandler
 }
 
 var ProviderSet = wire.NewSet(
@@ -38,6 +40,8 @@ var ProviderSet = wire.NewSet(
 	NewShareCaptchaHandler,
 	NewShareCommonHandler,
 	NewOpenapiV1Handler,
+	NewShareSCIMHandler,
+	NewShareFeedHandler,
 
 	wire.Struct(new(ShareHandler), "*"),
 )
@@ -0,0 +1,445 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/scim/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+const (
+	scimContentType = "application/scim+json"
+	scimContextKey  = "scim_context"
+)
+
+type ShareSCIMHandler struct {
+	*handler.BaseHandler
+	logger      *log.Logger
+	scimUsecase *usecase.SCIMUsecase
+}
+
+func NewShareSCIMHandler(
+	e *echo.Echo,
+	baseHandler *handler.BaseHandler,
+	logger *log.Logger,
+	scimUsecase *usecase.SCIMUsecase,
+) *ShareSCIMHandler {
+	h := &ShareSCIMHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.share.scim"),
+		scimUsecase: scimUsecase,
+	}
+
+	// SCIM 2.0，IdP 使用知识库的 bearer token 调用
+	group := e.Group("/share/v1/openapi/scim/:kb_id", h.authorize)
+
+	group.GET("/ServiceProviderConfig", h.ServiceProviderConfig)
+
+	group.GET("/Users", h.ListUsers)
+	group.POST("/Users", h.CreateUser)
+	group.GET("/Users/:id", h.GetUser)
+	group.PUT("/Users/:id", h.ReplaceUser)
+	group.PATCH("/Users/:id", h.PatchUser)
+	group.DELETE("/Users/:id", h.DeleteUser)
+
+	group.GET("/Groups", h.ListGroups)
+	group.POST("/Groups", h.CreateGroup)
+	group.GET("/Groups/:id", h.GetGroup)
+	group.PUT("/Groups/:id", h.ReplaceGroup)
+	group.PATCH("/Groups/:id", h.PatchGroup)
+	group.DELETE("/Groups/:id", h.DeleteGroup)
+
+	return h
+}
+
+func (h *ShareSCIMHandler) authorize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			return h.scimError(c, domain.NewSCIMError(http.StatusUnauthorized, "", "bearer token is required"))
+		}
+		sc, err := h.scimUsecase.Authenticate(c.Request().Context(), c.Param("kb_id"), strings.TrimSpace(token))
+		if err != nil {
+			return h.scimError(c, err)
+		}
+		c.Set(scimContextKey, sc)
+		return next(c)
+	}
+}
+
+func (h *ShareSCIMHandler) context(c echo.Context) (context.Context, *usecase.SCIMContext) {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+	return ctx, c.Get(scimContextKey).(*usecase.SCIMContext)
+}
+
+func (h *ShareSCIMHandler) scimResponse(c echo.Context, status int, data any) error {
+	c.Response().Header().Set(echo.HeaderContentType, scimContentType)
+	return c.JSON(status, data)
+}
+
+// scimError 按 RFC 7644 返回错误，非协议错误统一返回 500
+func (h *ShareSCIMHandler) scimError(c echo.Context, err error) error {
+	var scimErr *domain.SCIMError
+	if !errors.As(err, &scimErr) {
+		h.logger.Error("scim request failed", log.String("path", c.Path()), log.Error(err))
+		scimErr = domain.NewSCIMError(http.StatusInternalServerError, "", "internal server error")
+	}
+	return h.scimResponse(c, scimErr.Status, &v1.Error{
+		Schemas:  []string{v1.SchemaError},
+		Status:   strconv.Itoa(scimErr.Status),
+		ScimType: scimErr.ScimType,
+		Detail:   scimErr.Detail,
+	})
+}
+
+func (h *ShareSCIMHandler) location(c echo.Context, resource, id string) string {
+	return c.Scheme() + "://" + c.Request().Host + "/share/v1/openapi/scim/" + c.Param("kb_id") + "/" + resource + "/" + id
+}
+
+// bindSCIM IdP 使用 application/scim+json 提交，echo 默认 binder 不识别
+func (h *ShareSCIMHandler) bindSCIM(c echo.Context, v any) error {
+	if err := json.NewDecoder(c.Request().Body).Decode(v); err != nil {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error())
+	}
+	return nil
+}
+
+func (h *ShareSCIMHandler) userResponse(c echo.Context, status int, user *v1.User) error {
+	user.Meta.Location = h.location(c, "Users", user.ID)
+	return h.scimResponse(c, status, user)
+}
+
+func (h *ShareSCIMHandler) groupResponse(c echo.Context, status int, group *v1.Group) error {
+	group.Meta.Location = h.location(c, "Groups", group.ID)
+	return h.scimResponse(c, status, group)
+}
+
+// ServiceProviderConfig SCIM服务配置
+//
+//	@Tags			ShareSCIM
+//	@Summary		SCIM服务配置
+//	@Description	SCIM服务配置
+//	@ID				v1-SCIMServiceProviderConfig
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Success		200		{object}	v1.ServiceProviderConfig
+//	@Router			/share/v1/openapi/scim/{kb_id}/ServiceProviderConfig [get]
+func (h *ShareSCIMHandler) ServiceProviderConfig(c echo.Context) error {
+	return h.scimResponse(c, http.StatusOK, &v1.ServiceProviderConfig{
+		Schemas: []string{v1.SchemaServiceProviderConfig},
+		Patch:   v1.Supported{Supported: true},
+		Filter:  v1.FilterSupported{Supported: true, MaxResults: 200},
+		AuthenticationSchemes: []v1.AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "Authentication scheme using the token generated in knowledge base settings",
+		}},
+	})
+}
+
+// ListUsers SCIM用户列表
+//
+//	@Tags			ShareSCIM
+//	@Summary		SCIM用户列表
+//	@Description	SCIM用户列表，filter 仅支持 userName/externalId eq
+//	@ID				v1-SCIMListUsers
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			param	query		v1.ListReq	true	"para"
+//	@Success		200		{object}	v1.UserListResponse
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users [get]
+func (h *ShareSCIMHandler) ListUsers(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.ListReq
+	if err := c.Bind(&req); err != nil {
+		return h.scimError(c, domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error()))
+	}
+	resp, err := h.scimUsecase.ListUsers(ctx, sc, req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	for _, user := range resp.Resources {
+		user.Meta.Location = h.location(c, "Users", user.ID)
+	}
+	return h.scimResponse(c, http.StatusOK, resp)
+}
+
+// GetUser 获取SCIM用户
+//
+//	@Tags			ShareSCIM
+//	@Summary		获取SCIM用户
+//	@Description	获取SCIM用户
+//	@ID				v1-SCIMGetUser
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Param			id		path		string	true	"用户ID"
+//	@Success		200		{object}	v1.User
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users/{id} [get]
+func (h *ShareSCIMHandler) GetUser(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	user, err := h.scimUsecase.GetUser(ctx, sc, c.Param("id"))
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.userResponse(c, http.StatusOK, user)
+}
+
+// CreateUser 创建SCIM用户
+//
+//	@Tags			ShareSCIM
+//	@Summary		创建SCIM用户
+//	@Description	创建SCIM用户
+//	@ID				v1-SCIMCreateUser
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Param			body	body		v1.User	true	"用户"
+//	@Success		201		{object}	v1.User
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users [post]
+func (h *ShareSCIMHandler) CreateUser(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.User
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	user, err := h.scimUsecase.CreateUser(ctx, sc, &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.userResponse(c, http.StatusCreated, user)
+}
+
+// ReplaceUser 替换SCIM用户
+//
+//	@Tags			ShareSCIM
+//	@Summary		替换SCIM用户
+//	@Description	替换SCIM用户
+//	@ID				v1-SCIMReplaceUser
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Param			id		path		string	true	"用户ID"
+//	@Param			body	body		v1.User	true	"用户"
+//	@Success		200		{object}	v1.User
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users/{id} [put]
+func (h *ShareSCIMHandler) ReplaceUser(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.User
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	user, err := h.scimUsecase.ReplaceUser(ctx, sc, c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.userResponse(c, http.StatusOK, user)
+}
+
+// PatchUser 修改SCIM用户
+//
+//	@Tags			ShareSCIM
+//	@Summary		修改SCIM用户
+//	@Description	修改SCIM用户，停用用户后其会话立即失效
+//	@ID				v1-SCIMPatchUser
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			id		path		string		true	"用户ID"
+//	@Param			body	body		v1.PatchOp	true	"PatchOp"
+//	@Success		200		{object}	v1.User
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users/{id} [patch]
+func (h *ShareSCIMHandler) PatchUser(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.PatchOp
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	user, err := h.scimUsecase.PatchUser(ctx, sc, c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.userResponse(c, http.StatusOK, user)
+}
+
+// DeleteUser 删除SCIM用户
+//
+//	@Tags			ShareSCIM
+//	@Summary		删除SCIM用户
+//	@Description	删除SCIM用户
+//	@ID				v1-SCIMDeleteUser
+//	@Param			kb_id	path	string	true	"知识库ID"
+//	@Param			id		path	string	true	"用户ID"
+//	@Success		204
+//	@Router			/share/v1/openapi/scim/{kb_id}/Users/{id} [delete]
+func (h *ShareSCIMHandler) DeleteUser(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	if err := h.scimUsecase.DeleteUser(ctx, sc, c.Param("id")); err != nil {
+		return h.scimError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListGroups SCIM分组列表
+//
+//	@Tags			ShareSCIM
+//	@Summary		SCIM分组列表
+//	@Description	SCIM分组列表，filter 仅支持 displayName/externalId eq
+//	@ID				v1-SCIMListGroups
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			param	query		v1.ListReq	true	"para"
+//	@Success		200		{object}	v1.GroupListResponse
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups [get]
+func (h *ShareSCIMHandler) ListGroups(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.ListReq
+	if err := c.Bind(&req); err != nil {
+		return h.scimError(c, domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error()))
+	}
+	resp, err := h.scimUsecase.ListGroups(ctx, sc, req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	for _, group := range resp.Resources {
+		group.Meta.Location = h.location(c, "Groups", group.ID)
+	}
+	return h.scimResponse(c, http.StatusOK, resp)
+}
+
+// GetGroup 获取SCIM分组
+//
+//	@Tags			ShareSCIM
+//	@Summary		获取SCIM分组
+//	@Description	获取SCIM分组
+//	@ID				v1-SCIMGetGroup
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Param			id		path		string	true	"分组ID"
+//	@Success		200		{object}	v1.Group
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups/{id} [get]
+func (h *ShareSCIMHandler) GetGroup(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	group, err := h.scimUsecase.GetGroup(ctx, sc, c.Param("id"))
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.groupResponse(c, http.StatusOK, group)
+}
+
+// CreateGroup 创建SCIM分组
+//
+//	@Tags			ShareSCIM
+//	@Summary		创建SCIM分组
+//	@Description	创建SCIM分组，type 为 Group 的成员作为子分组
+//	@ID				v1-SCIMCreateGroup
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			body	body		v1.Group	true	"分组"
+//	@Success		201		{object}	v1.Group
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups [post]
+func (h *ShareSCIMHandler) CreateGroup(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.Group
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.scimUsecase.CreateGroup(ctx, sc, &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.groupResponse(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup 替换SCIM分组
+//
+//	@Tags			ShareSCIM
+//	@Summary		替换SCIM分组
+//	@Description	替换SCIM分组
+//	@ID				v1-SCIMReplaceGroup
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			id		path		string		true	"分组ID"
+//	@Param			body	body		v1.Group	true	"分组"
+//	@Success		200		{object}	v1.Group
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups/{id} [put]
+func (h *ShareSCIMHandler) ReplaceGroup(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.Group
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.scimUsecase.ReplaceGroup(ctx, sc, c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.groupResponse(c, http.StatusOK, group)
+}
+
+// PatchGroup 修改SCIM分组
+//
+//	@Tags			ShareSCIM
+//	@Summary		修改SCIM分组
+//	@Description	修改SCIM分组名称及成员
+//	@ID				v1-SCIMPatchGroup
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string		true	"知识库ID"
+//	@Param			id		path		string		true	"分组ID"
+//	@Param			body	body		v1.PatchOp	true	"PatchOp"
+//	@Success		200		{object}	v1.Group
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups/{id} [patch]
+func (h *ShareSCIMHandler) PatchGroup(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	var req v1.PatchOp
+	if err := h.bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.scimUsecase.PatchGroup(ctx, sc, c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.groupResponse(c, http.StatusOK, group)
+}
+
+// DeleteGroup 删除SCIM分组
+//
+//	@Tags			ShareSCIM
+//	@Summary		删除SCIM分组
+//	@Description	删除SCIM分组，子分组提升为顶级分组
+//	@ID				v1-SCIMDeleteGroup
+//	@Param			kb_id	path	string	true	"知识库ID"
+//	@Param			id		path	string	true	"分组ID"
+//	@Success		204
+//	@Router			/share/v1/openapi/scim/{kb_id}/Groups/{id} [delete]
+func (h *ShareSCIMHandler) DeleteGroup(c echo.Context) error {
+	ctx, sc := h.context(c)
+
+	if err := h.scimUsecase.DeleteGroup(ctx, sc, c.Param("id")); err != nil {
+		return h.scimError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
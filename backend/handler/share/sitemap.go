@@ -2,6 +2,7 @@ package share
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 
@@ -28,19 +29,76 @@ func NewShareSitemapHandler(echo *echo.Echo, baseHandler *handler.BaseHandler, s
 	group := echo.Group("/sitemap.xml")
 	group.GET("", h.GetSitemap)
 
+	echo.GET("/robots.txt", h.GetRobots)
+	echo.GET("/llms.txt", h.GetLLMsText)
+	echo.GET("/llms-full.txt", h.GetLLMsFullText)
+
 	return h
 }
 
+// GetSitemap 不带 page 参数时返回 sitemap 索引
 func (h *ShareSitemapHandler) GetSitemap(c echo.Context) error {
 	kbID := c.Request().Header.Get("X-KB-ID")
 	if kbID == "" {
 		return h.NewResponseWithError(c, "kb_id is required", nil)
 	}
 
-	xml, err := h.sitemapUsecase.GetSitemap(c.Request().Context(), kbID)
+	page := 0
+	if p := c.QueryParam("page"); p != "" {
+		var err error
+		if page, err = strconv.Atoi(p); err != nil || page < 1 {
+			return h.NewResponseWithError(c, "invalid page", err)
+		}
+	}
+
+	xml, err := h.sitemapUsecase.GetSitemap(c.Request().Context(), kbID, page)
 	if err != nil {
 		return h.NewResponseWithError(c, "failed to generate sitemap", err)
 	}
 
-	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, []byte(xml))
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, xml)
+}
+
+func (h *ShareSitemapHandler) GetRobots(c echo.Context) error {
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	robots, err := h.sitemapUsecase.GetRobots(c.Request().Context(), kbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to generate robots.txt", err)
+	}
+
+	return c.String(http.StatusOK, robots)
+}
+
+func (h *ShareSitemapHandler) GetLLMsText(c echo.Context) error {
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	text, err := h.sitemapUsecase.GetLLMsText(c.Request().Context(), kbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to generate llms.txt", err)
+	}
+
+	return c.Blob(http.StatusOK, "text/markdown; charset=UTF-8", []byte(text))
+}
+
+// GetLLMsFullText 文档较多时内容很大，边查询边输出
+func (h *ShareSitemapHandler) GetLLMsFullText(c echo.Context) error {
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/markdown; charset=UTF-8")
+	c.Response().WriteHeader(http.StatusOK)
+	if err := h.sitemapUsecase.WriteLLMsFullText(c.Request().Context(), kbID, c.Response()); err != nil {
+		// 响应头已发出，只能记录错误
+		h.logger.Error("write llms-full.txt failed", log.String("kb_id", kbID), log.Error(err))
+	}
+	return nil
 }
@@ -28,6 +28,7 @@ func NewShareStatHandler(baseHandler *handler.BaseHandler, echo *echo.Echo, useC
 
 	group := echo.Group("/share/v1/stat")
 	group.POST("/page", h.RecordPage, h.ShareAuthMiddleware.Authorize)
+	group.POST("/node_event", h.RecordNodeEvent, h.ShareAuthMiddleware.Authorize)
 	return h
 }
 
@@ -100,3 +101,56 @@ func (h *ShareStatHandler) RecordPage(c echo.Context) error {
 	}
 	return h.NewResponseWithData(c, nil)
 }
+
+// RecordNodeEvent record node engagement event
+//
+//	@Summary		RecordNodeEvent
+//	@Description	RecordNodeEvent，上报文档阅读时长、滚动深度、外链点击和有用/没用反馈
+//	@Tags			share_stat
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		domain.StatNodeEventReq	true	"request"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/share/v1/stat/node_event [post]
+func (h *ShareStatHandler) RecordNodeEvent(c echo.Context) error {
+	req := &domain.StatNodeEventReq{}
+	if err := c.Bind(req); err != nil {
+		return h.NewResponseWithError(c, "bind request body failed", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	var userIDValue uint
+	if userID := c.Get("user_id"); userID != nil {
+		userIDValue = userID.(uint)
+	}
+	sessionID := ""
+	sessionIDCookie, err := c.Request().Cookie("x-pw-session-id")
+	if err != nil {
+		sessionID = c.Request().Header.Get("x-pw-session-id")
+	} else {
+		sessionID = sessionIDCookie.Value
+	}
+	if sessionID == "" {
+		return h.NewResponseWithError(c, "session id not found", err)
+	}
+
+	event := &domain.StatNodeEvent{
+		KBID:        c.Request().Header.Get("X-KB-ID"),
+		NodeID:      req.NodeID,
+		UserID:      userIDValue,
+		SessionID:   sessionID,
+		IP:          c.RealIP(),
+		Type:        req.Type,
+		ReadSeconds: req.ReadSeconds,
+		ScrollDepth: req.ScrollDepth,
+		LinkURL:     req.LinkURL,
+		Score:       req.Score,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.useCase.RecordNodeEvent(c.Request().Context(), event); err != nil {
+		return h.NewResponseWithError(c, "record node event failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
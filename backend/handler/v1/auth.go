@@ -14,6 +14,8 @@ type AuthV1Handler struct {
 	*handler.BaseHandler
 	logger      *log.Logger
 	authUseCase *usecase.AuthUsecase
+	scimUsecase *usecase.SCIMUsecase
+	ldapSync    *usecase.LDAPSyncUsecase
 }
 
 func NewAuthV1Handler(
@@ -21,11 +23,15 @@ func NewAuthV1Handler(
 	baseHandler *handler.BaseHandler,
 	logger *log.Logger,
 	authUseCase *usecase.AuthUsecase,
+	scimUsecase *usecase.SCIMUsecase,
+	ldapSync *usecase.LDAPSyncUsecase,
 ) *AuthV1Handler {
 	h := &AuthV1Handler{
 		BaseHandler: baseHandler,
 		logger:      logger,
 		authUseCase: authUseCase,
+		scimUsecase: scimUsecase,
+		ldapSync:    ldapSync,
 	}
 
 	AuthGroup := e.Group(
@@ -36,6 +42,10 @@ func NewAuthV1Handler(
 	AuthGroup.GET("/get", h.OpenAuthGet)
 	AuthGroup.POST("/set", h.OpenAuthSet)
 	AuthGroup.DELETE("/delete", h.OpenAuthDelete)
+	AuthGroup.GET("/scim", h.SCIMGet)
+	AuthGroup.POST("/scim", h.SCIMSet)
+	AuthGroup.DELETE("/scim", h.SCIMDelete)
+	AuthGroup.POST("/ldap/sync", h.LDAPGroupSync)
 
 	return h
 }
@@ -130,3 +140,118 @@ func (h *AuthV1Handler) OpenAuthDelete(c echo.Context) error {
 
 	return h.NewResponseWithData(c, nil)
 }
+
+// SCIMGet 获取SCIM配置
+//
+//	@Tags			Auth
+//	@Summary		获取SCIM配置
+//	@Description	获取SCIM配置
+//	@ID				v1-SCIMGet
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.SCIMGetReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.SCIMGetResp}
+//	@Router			/api/v1/auth/scim [get]
+func (h *AuthV1Handler) SCIMGet(c echo.Context) error {
+
+	var req v1.SCIMGetReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	resp, err := h.scimUsecase.GetSCIMSetting(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get scim setting", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// SCIMSet 开启SCIM并重新生成token
+//
+//	@Tags			Auth
+//	@Summary		开启SCIM并重新生成token
+//	@Description	开启SCIM并重新生成token，token 仅返回一次
+//	@ID				v1-SCIMSet
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.SCIMSetReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.SCIMSetResp}
+//	@Router			/api/v1/auth/scim [post]
+func (h *AuthV1Handler) SCIMSet(c echo.Context) error {
+
+	var req v1.SCIMSetReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	token, err := h.scimUsecase.SetSCIMSetting(c.Request().Context(), req)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to set scim setting", err)
+	}
+
+	return h.NewResponseWithData(c, v1.SCIMSetResp{Token: token})
+}
+
+// SCIMDelete 关闭SCIM
+//
+//	@Tags			Auth
+//	@Summary		关闭SCIM
+//	@Description	关闭SCIM，已下发的用户和分组保留
+//	@ID				v1-SCIMDelete
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.SCIMDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/auth/scim [delete]
+func (h *AuthV1Handler) SCIMDelete(c echo.Context) error {
+
+	var req v1.SCIMDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := h.scimUsecase.DeleteSCIMSetting(c.Request().Context(), req.KbID); err != nil {
+		return h.NewResponseWithError(c, "failed to delete scim setting", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// LDAPGroupSync 立即同步LDAP分组
+//
+//	@Tags			Auth
+//	@Summary		立即同步LDAP分组
+//	@Description	立即同步LDAP分组
+//	@ID				v1-LDAPGroupSync
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.LDAPGroupSyncReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.LDAPGroupSyncResp}
+//	@Router			/api/v1/auth/ldap/sync [post]
+func (h *AuthV1Handler) LDAPGroupSync(c echo.Context) error {
+
+	var req v1.LDAPGroupSyncReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.ldapSync.SyncKB(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to sync ldap groups", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
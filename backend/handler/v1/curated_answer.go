@@ -0,0 +1,287 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type CuratedAnswerHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.CuratedAnswerUsecase
+	auth    middleware.AuthMiddleware
+}
+
+func NewCuratedAnswerHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.CuratedAnswerUsecase,
+	auth middleware.AuthMiddleware,
+	logger *log.Logger,
+) *CuratedAnswerHandler {
+	h := &CuratedAnswerHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.curated_answer"),
+		usecase:     usecase,
+		auth:        auth,
+	}
+
+	group := echo.Group("/api/v1/conversation/answer", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.GET("/list", h.GetCuratedAnswerList)
+	group.POST("", h.CreateCuratedAnswer)
+	group.PUT("", h.UpdateCuratedAnswer)
+	group.DELETE("", h.DeleteCuratedAnswer)
+	group.POST("/promote", h.PromoteCuratedAnswer)
+	group.GET("/hit/list", h.GetCuratedAnswerHitList)
+	group.GET("/setting", h.GetCuratedAnswerSetting)
+	group.PUT("/setting", h.UpdateCuratedAnswerSetting, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+
+	return h
+}
+
+// GetCuratedAnswerList 标准答案列表
+//
+//	@Tags			conversation
+//	@Summary		标准答案列表
+//	@ID				v1-GetCuratedAnswerList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.CuratedAnswerListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.PaginatedResult[[]v1.CuratedAnswerListItem]}
+//	@Router			/api/v1/conversation/answer/list [get]
+func (h *CuratedAnswerHandler) GetCuratedAnswerList(c echo.Context) error {
+	var req v1.CuratedAnswerListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	list, err := h.usecase.GetList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get curated answer list failed", err)
+	}
+
+	return h.NewResponseWithData(c, list)
+}
+
+// CreateCuratedAnswer 添加标准答案
+//
+//	@Tags			conversation
+//	@Summary		添加标准答案
+//	@Description	用户问题与标准问题或相似问法匹配时，优先使用标准答案回答
+//	@ID				v1-CreateCuratedAnswer
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.CreateCuratedAnswerReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=map[string]string}
+//	@Router			/api/v1/conversation/answer [post]
+func (h *CuratedAnswerHandler) CreateCuratedAnswer(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.CreateCuratedAnswerReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	id, err := h.usecase.Create(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "create curated answer failed", err)
+	}
+
+	return h.NewResponseWithData(c, map[string]string{
+		"id": id,
+	})
+}
+
+// UpdateCuratedAnswer 编辑标准答案
+//
+//	@Tags			conversation
+//	@Summary		编辑标准答案
+//	@ID				v1-UpdateCuratedAnswer
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateCuratedAnswerReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/conversation/answer [put]
+func (h *CuratedAnswerHandler) UpdateCuratedAnswer(c echo.Context) error {
+	var req v1.UpdateCuratedAnswerReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update curated answer failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// DeleteCuratedAnswer 删除标准答案
+//
+//	@Tags			conversation
+//	@Summary		删除标准答案
+//	@ID				v1-DeleteCuratedAnswer
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.DeleteCuratedAnswerReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/conversation/answer [delete]
+func (h *CuratedAnswerHandler) DeleteCuratedAnswer(c echo.Context) error {
+	var req v1.DeleteCuratedAnswerReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.usecase.Delete(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "delete curated answer failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// PromoteCuratedAnswer 将对话回答添加为标准答案
+//
+//	@Tags			conversation
+//	@Summary		将对话回答添加为标准答案
+//	@Description	问题取对应的用户提问，回答引用的文档作为关联文档
+//	@ID				v1-PromoteCuratedAnswer
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.PromoteCuratedAnswerReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=map[string]string}
+//	@Router			/api/v1/conversation/answer/promote [post]
+func (h *CuratedAnswerHandler) PromoteCuratedAnswer(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.PromoteCuratedAnswerReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	id, err := h.usecase.Promote(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "promote curated answer failed", err)
+	}
+
+	return h.NewResponseWithData(c, map[string]string{
+		"id": id,
+	})
+}
+
+// GetCuratedAnswerHitList 标准答案命中记录
+//
+//	@Tags			conversation
+//	@Summary		标准答案命中记录
+//	@ID				v1-GetCuratedAnswerHitList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.CuratedAnswerHitListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.PaginatedResult[[]domain.CuratedAnswerHit]}
+//	@Router			/api/v1/conversation/answer/hit/list [get]
+func (h *CuratedAnswerHandler) GetCuratedAnswerHitList(c echo.Context) error {
+	var req v1.CuratedAnswerHitListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	list, err := h.usecase.GetHitList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get curated answer hit list failed", err)
+	}
+
+	return h.NewResponseWithData(c, list)
+}
+
+// GetCuratedAnswerSetting 标准答案匹配设置
+//
+//	@Tags			conversation
+//	@Summary		标准答案匹配设置
+//	@ID				v1-GetCuratedAnswerSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.CuratedAnswerSettingReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.CuratedAnswerSetting}
+//	@Router			/api/v1/conversation/answer/setting [get]
+func (h *CuratedAnswerHandler) GetCuratedAnswerSetting(c echo.Context) error {
+	var req v1.CuratedAnswerSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	setting, err := h.usecase.GetSetting(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get curated answer setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, setting)
+}
+
+// UpdateCuratedAnswerSetting 更新标准答案匹配设置
+//
+//	@Tags			conversation
+//	@Summary		更新标准答案匹配设置
+//	@Description	命中后直接返回标准答案，或注入到提示词由模型结合文档回答
+//	@ID				v1-UpdateCuratedAnswerSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateCuratedAnswerSettingReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/conversation/answer/setting [put]
+func (h *CuratedAnswerHandler) UpdateCuratedAnswerSetting(c echo.Context) error {
+	var req v1.UpdateCuratedAnswerSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateSetting(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update curated answer setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
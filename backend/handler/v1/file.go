@@ -1,8 +1,10 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/google/uuid"
@@ -24,9 +26,10 @@ type FileHandler struct {
 	auth        middleware.AuthMiddleware
 	config      *config.Config
 	fileUsecase *usecase.FileUsecase
+	localStore  *s3.LocalStore
 }
 
-func NewFileHandler(echo *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware, minioClient *s3.MinioClient, config *config.Config, fileUsecase *usecase.FileUsecase) *FileHandler {
+func NewFileHandler(echo *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware, objectStore s3.ObjectStore, config *config.Config, fileUsecase *usecase.FileUsecase) *FileHandler {
 	h := &FileHandler{
 		BaseHandler: baseHandler,
 		logger:      logger.WithModule("handler.v1.file"),
@@ -38,6 +41,13 @@ func NewFileHandler(echo *echo.Echo, baseHandler *handler.BaseHandler, logger *l
 	group.POST("/upload", h.Upload, h.auth.Authorize)
 	group.POST("/upload/url", h.UploadByUrl, h.auth.Authorize)
 	group.POST("/upload/anydoc", h.UploadAnydoc)
+
+	// 本地存储时由 api 提供静态文件下载
+	if localStore, ok := objectStore.(*s3.LocalStore); ok {
+		h.localStore = localStore
+		echo.GET("/"+domain.Bucket+"/*", h.StaticFile)
+		echo.HEAD("/"+domain.Bucket+"/*", h.StaticFile)
+	}
 	return h
 }
 
@@ -157,3 +167,33 @@ func (h *FileHandler) UploadAnydoc(c echo.Context) error {
 		Data: url,
 	})
 }
+
+// StaticFile 本地存储的文件下载，支持 Range 请求
+//
+//	@Summary		Static File
+//	@Description	Download object from local storage, signature is verified when present
+//	@Tags			file
+//	@Param			object		path	string	true	"Object Key"
+//	@Param			expires		query	string	false	"Expire Unix Time"
+//	@Param			signature	query	string	false	"Signature"
+//	@Success		200
+//	@Router			/static-file/{object} [get]
+func (h *FileHandler) StaticFile(c echo.Context) error {
+	object, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+
+	// 与 MinIO 的 bucket 策略一致允许匿名读取，携带签名时校验签名及有效期
+	if err := h.localStore.ServeObject(c.Response(), c.Request(), domain.Bucket, object); err != nil {
+		if errors.Is(err, s3.ErrObjectNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		if errors.Is(err, s3.ErrInvalidSignature) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		h.logger.Error("serve static file failed", log.String("object", object), log.Error(err))
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// CreateKBReleaseExport
+//
+//	@Summary		CreateKBReleaseExport
+//	@Description	Export a knowledge base release as a static HTML site
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBReleaseExportReq	true	"Request Body"
+//	@Success		200		{object}	domain.PWResponse{data=map[string]string}
+//	@Router			/api/v1/knowledge_base/release/export [post]
+func (h *KnowledgeBaseHandler) CreateKBReleaseExport(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.KBReleaseExportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	id, err := h.exportUsecase.CreateExport(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "create kb release export failed", err)
+	}
+
+	return h.NewResponseWithData(c, map[string]string{
+		"id": id,
+	})
+}
+
+// GetKBReleaseExportList
+//
+//	@Summary		GetKBReleaseExportList
+//	@Description	Get knowledge base release export list
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			kb_id	query		string	true	"Knowledge Base ID"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.KBReleaseExportListItem}
+//	@Router			/api/v1/knowledge_base/release/export/list [get]
+func (h *KnowledgeBaseHandler) GetKBReleaseExportList(c echo.Context) error {
+	var req v1.KBReleaseExportListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	exports, err := h.exportUsecase.GetExportList(c.Request().Context(), req.KBId)
+	if err != nil {
+		return h.NewResponseWithError(c, "get kb release export list failed", err)
+	}
+
+	return h.NewResponseWithData(c, exports)
+}
+
+// DownloadKBReleaseExport
+//
+//	@Summary		DownloadKBReleaseExport
+//	@Description	Download the zip file of a finished export
+//	@Tags			knowledge_base
+//	@Produce		application/zip
+//	@Security		bearerAuth
+//	@Param			kb_id	query	string	true	"Knowledge Base ID"
+//	@Param			id		query	string	true	"Export ID"
+//	@Success		200		{file}	file
+//	@Router			/api/v1/knowledge_base/release/export/download [get]
+func (h *KnowledgeBaseHandler) DownloadKBReleaseExport(c echo.Context) error {
+	var req v1.KBReleaseExportItemReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	reader, info, filename, err := h.exportUsecase.GetExportFile(c.Request().Context(), req.KBId, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get kb release export failed", err)
+	}
+	defer reader.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(info.Size, 10))
+	return c.Stream(http.StatusOK, "application/zip", reader)
+}
+
+// DeleteKBReleaseExport
+//
+//	@Summary		DeleteKBReleaseExport
+//	@Description	Delete a knowledge base release export and its file
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			kb_id	query		string	true	"Knowledge Base ID"
+//	@Param			id		query		string	true	"Export ID"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/knowledge_base/release/export [delete]
+func (h *KnowledgeBaseHandler) DeleteKBReleaseExport(c echo.Context) error {
+	var req v1.KBReleaseExportItemReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.exportUsecase.DeleteExport(c.Request().Context(), req.KBId, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete kb release export failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+)
+
+// GetKBStorage
+//
+//	@Summary		GetKBStorage
+//	@Description	Get knowledge base storage usage and quota
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			kb_id	query		string	true	"Knowledge Base ID"
+//	@Success		200		{object}	domain.PWResponse{data=v1.KBStorageResp}
+//	@Router			/api/v1/knowledge_base/storage [get]
+func (h *KnowledgeBaseHandler) GetKBStorage(c echo.Context) error {
+	var req v1.KBStorageReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.attachmentUsecase.GetStorageUsage(c.Request().Context(), req.KBId)
+	if err != nil {
+		return h.NewResponseWithError(c, "get kb storage failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// UpdateKBStorageQuota
+//
+//	@Summary		UpdateKBStorageQuota
+//	@Description	Update knowledge base storage quota, 0 means unlimited
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBStorageQuotaReq	true	"Request Body"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/knowledge_base/storage/quota [put]
+func (h *KnowledgeBaseHandler) UpdateKBStorageQuota(c echo.Context) error {
+	var req v1.KBStorageQuotaReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.attachmentUsecase.UpdateStorageQuota(c.Request().Context(), req); err != nil {
+		return h.NewResponseWithError(c, "update kb storage quota failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
@@ -16,10 +16,12 @@ import (
 
 type KnowledgeBaseHandler struct {
 	*handler.BaseHandler
-	usecase    *usecase.KnowledgeBaseUsecase
-	llmUsecase *usecase.LLMUsecase
-	logger     *log.Logger
-	auth       middleware.AuthMiddleware
+	usecase           *usecase.KnowledgeBaseUsecase
+	llmUsecase        *usecase.LLMUsecase
+	attachmentUsecase *usecase.AttachmentUsecase
+	exportUsecase     *usecase.KBExportUsecase
+	logger            *log.Logger
+	auth              middleware.AuthMiddleware
 }
 
 func NewKnowledgeBaseHandler(
@@ -27,15 +29,19 @@ func NewKnowledgeBaseHandler(
 	echo *echo.Echo,
 	usecase *usecase.KnowledgeBaseUsecase,
 	llmUsecase *usecase.LLMUsecase,
+	attachmentUsecase *usecase.AttachmentUsecase,
+	exportUsecase *usecase.KBExportUsecase,
 	auth middleware.AuthMiddleware,
 	logger *log.Logger,
 ) *KnowledgeBaseHandler {
 	h := &KnowledgeBaseHandler{
-		BaseHandler: baseHandler,
-		logger:      logger.WithModule("handler.v1.knowledge_base"),
-		usecase:     usecase,
-		llmUsecase:  llmUsecase,
-		auth:        auth,
+		BaseHandler:       baseHandler,
+		logger:            logger.WithModule("handler.v1.knowledge_base"),
+		usecase:           usecase,
+		llmUsecase:        llmUsecase,
+		attachmentUsecase: attachmentUsecase,
+		exportUsecase:     exportUsecase,
+		auth:              auth,
 	}
 
 	group := echo.Group("/api/v1/knowledge_base", h.auth.Authorize)
@@ -56,6 +62,14 @@ func NewKnowledgeBaseHandler(
 	releaseGroup := group.Group("/release", h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
 	releaseGroup.POST("", h.CreateKBRelease)
 	releaseGroup.GET("/list", h.GetKBReleaseList)
+	releaseGroup.POST("/export", h.CreateKBReleaseExport)
+	releaseGroup.GET("/export/list", h.GetKBReleaseExportList)
+	releaseGroup.GET("/export/download", h.DownloadKBReleaseExport)
+	releaseGroup.DELETE("/export", h.DeleteKBReleaseExport)
+
+	// storage
+	group.GET("/storage", h.GetKBStorage, h.auth.ValidateKBUserPerm(consts.UserKBPermissionNotNull))
+	group.PUT("/storage/quota", h.UpdateKBStorageQuota, h.auth.ValidateUserRole(consts.UserRoleAdmin))
 
 	return h
 }
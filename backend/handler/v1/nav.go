@@ -5,6 +5,7 @@ import (
 
 	v1 "github.com/chaitin/panda-wiki/api/nav/v1"
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/handler"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/middleware"
@@ -112,6 +113,10 @@ func (h *NavHandler) NavAdd(c echo.Context) error {
 //	@Router			/api/v1/nav/delete [delete]
 func (h *NavHandler) NavDelete(c echo.Context) error {
 	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
 
 	var req v1.NavDeleteReq
 	if err := c.Bind(&req); err != nil {
@@ -121,7 +126,7 @@ func (h *NavHandler) NavDelete(c echo.Context) error {
 		return h.NewResponseWithError(c, "validate request params failed", err)
 	}
 
-	if err := h.usecase.Delete(ctx, &req); err != nil {
+	if err := h.usecase.Delete(ctx, &req, authInfo.UserId); err != nil {
 		return h.NewResponseWithError(c, "delete nav failed", err)
 	}
 	return h.NewResponseWithData(c, nil)
@@ -19,23 +19,26 @@ import (
 
 type NodeHandler struct {
 	*handler.BaseHandler
-	logger  *log.Logger
-	usecase *usecase.NodeUsecase
-	auth    middleware.AuthMiddleware
+	logger        *log.Logger
+	usecase       *usecase.NodeUsecase
+	exportUsecase *usecase.NodeExportUsecase
+	auth          middleware.AuthMiddleware
 }
 
 func NewNodeHandler(
 	baseHandler *handler.BaseHandler,
 	echo *echo.Echo,
 	usecase *usecase.NodeUsecase,
+	exportUsecase *usecase.NodeExportUsecase,
 	auth middleware.AuthMiddleware,
 	logger *log.Logger,
 ) *NodeHandler {
 	h := &NodeHandler{
-		BaseHandler: baseHandler,
-		logger:      logger.WithModule("handler.v1.node"),
-		usecase:     usecase,
-		auth:        auth,
+		BaseHandler:   baseHandler,
+		logger:        logger.WithModule("handler.v1.node"),
+		usecase:       usecase,
+		exportUsecase: exportUsecase,
+		auth:          auth,
 	}
 
 	group := echo.Group("/api/v1/node", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
@@ -46,8 +49,12 @@ func NewNodeHandler(
 	group.POST("", h.CreateNode)
 	group.GET("/detail", h.GetNodeDetail)
 	group.PUT("/detail", h.UpdateNodeDetail)
+	group.GET("/presence", h.GetNodePresence)
+	group.POST("/presence", h.TouchNodePresence)
+	group.DELETE("/presence", h.LeaveNodePresence)
 	group.POST("/summary", h.SummaryNode)
 	group.POST("/summary/stream", h.SummaryNodeStream)
+	group.GET("/export", h.ExportNode)
 
 	group.POST("/action", h.NodeAction)
 	group.POST("/move", h.MoveNode)
@@ -56,6 +63,18 @@ func NewNodeHandler(
 
 	group.GET("/recommend_nodes", h.RecommendNodes)
 	group.POST("/restudy", h.NodeRestudy)
+	group.GET("/rag/dead_letter/list", h.NodeDeadLetterList)
+	group.POST("/rag/dead_letter/redrive", h.NodeDeadLetterRedrive)
+
+	// recycle bin
+	group.GET("/recycle/list", h.NodeRecycleList)
+	group.POST("/recycle/restore", h.NodeRecycleRestore)
+	group.DELETE("/recycle", h.NodeRecyclePurge)
+	group.PUT("/recycle/retention", h.NodeRecycleRetention, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+
+	// tags and custom fields
+	group.GET("/meta/schema", h.GetNodeMetaSchema)
+	group.PUT("/meta/schema", h.UpdateNodeMetaSchema, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
 
 	// node permission
 	group.GET("/permission", h.NodePermission)
@@ -225,9 +244,15 @@ func (h *NodeHandler) GetNodeDetail(c echo.Context) error {
 //	@Produce		json
 //	@Security		bearerAuth
 //	@Param			action	body		domain.NodeActionReq	true	"Action"
-//	@Success		200		{object}	domain.PWResponse{data=map[string]string}
+//	@Success		200		{object}	domain.PWResponse{data=map[string]string}	"文档被链接且未传 force 时 code 为 40010，data 为 []domain.NodeReference"
 //	@Router			/api/v1/node/action [post]
 func (h *NodeHandler) NodeAction(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
 	req := &domain.NodeActionReq{}
 	if err := c.Bind(req); err != nil {
 		return h.NewResponseWithError(c, "request body is invalid", err)
@@ -235,9 +260,8 @@ func (h *NodeHandler) NodeAction(c echo.Context) error {
 	if err := c.Validate(req); err != nil {
 		return h.NewResponseWithError(c, "validate request body failed", err)
 	}
-	ctx := c.Request().Context()
-	if err := h.usecase.NodeAction(ctx, req); err != nil {
-		return h.NewResponseWithError(c, "node action failed", err)
+	if err := h.usecase.NodeAction(ctx, req, authInfo.UserId); err != nil {
+		return h.nodeReferencedOrError(c, "node action failed", err)
 	}
 	return h.NewResponseWithData(c, nil)
 }
@@ -251,7 +275,7 @@ func (h *NodeHandler) NodeAction(c echo.Context) error {
 //	@Produce		json
 //	@Security		bearerAuth
 //	@Param			body	body		domain.UpdateNodeReq	true	"Node"
-//	@Success		200		{object}	domain.Response
+//	@Success		200		{object}	domain.PWResponse{data=v1.UpdateNodeResp}	"修订号过期时 code 为 40009，data 为 v1.NodeConflictResp"
 //	@Router			/api/v1/node/detail [put]
 func (h *NodeHandler) UpdateNodeDetail(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -268,10 +292,25 @@ func (h *NodeHandler) UpdateNodeDetail(c echo.Context) error {
 		return h.NewResponseWithError(c, "validate request body failed", err)
 	}
 
-	if err := h.usecase.Update(ctx, req, authInfo.UserId); err != nil {
+	if (req.Name != nil || req.Content != nil) && req.Revision == nil {
+		return h.NewResponseWithError(c, "validate request body failed", domain.ErrNodeRevisionRequired)
+	}
+
+	resp, err := h.usecase.Update(ctx, req, authInfo.UserId)
+	if err != nil {
+		var conflict *domain.NodeConflictError
+		if errors.As(err, &conflict) {
+			conflictResp, err := h.usecase.BuildNodeConflict(ctx, req, conflict)
+			if err != nil {
+				return h.NewResponseWithError(c, "build node conflict failed", err)
+			}
+			errCode := domain.ErrCodeNodeConflict
+			errCode.Data = conflictResp
+			return h.NewResponseWithErrCode(c, errCode)
+		}
 		return h.NewResponseWithError(c, "update node detail failed", err)
 	}
-	return h.NewResponseWithData(c, nil)
+	return h.NewResponseWithData(c, resp)
 }
 
 // MoveNode
@@ -309,7 +348,7 @@ func (h *NodeHandler) MoveNode(c echo.Context) error {
 //	@Produce		json
 //	@Security		bearerAuth
 //	@Param			body	body		v1.NodeMoveNavReq	true	"Move Node Nav"
-//	@Success		200		{object}	domain.Response
+//	@Success		200		{object}	domain.Response	"文档被链接且未传 force 时 code 为 40010，data 为 []domain.NodeReference"
 //	@Router			/api/v1/node/move/nav [post]
 func (h *NodeHandler) NodeMoveNav(c echo.Context) error {
 	req := &v1.NodeMoveNavReq{}
@@ -321,7 +360,7 @@ func (h *NodeHandler) NodeMoveNav(c echo.Context) error {
 	}
 	ctx := c.Request().Context()
 	if err := h.usecase.MoveNodeNav(ctx, req); err != nil {
-		return h.NewResponseWithError(c, "move node nav failed", err)
+		return h.nodeReferencedOrError(c, "move node nav failed", err)
 	}
 	return h.NewResponseWithData(c, nil)
 }
@@ -454,7 +493,7 @@ func (h *NodeHandler) RecommendNodes(c echo.Context) error {
 //	@Produce		json
 //	@Security		bearerAuth
 //	@Param			body	body		domain.BatchMoveReq	true	"Batch Move Node"
-//	@Success		200		{object}	domain.Response
+//	@Success		200		{object}	domain.Response	"文档被链接且未传 force 时 code 为 40010，data 为 []domain.NodeReference"
 //	@Router			/api/v1/node/batch_move [post]
 func (h *NodeHandler) BatchMoveNode(c echo.Context) error {
 	req := &domain.BatchMoveReq{}
@@ -466,7 +505,7 @@ func (h *NodeHandler) BatchMoveNode(c echo.Context) error {
 	}
 	ctx := c.Request().Context()
 	if err := h.usecase.BatchMoveNode(ctx, req); err != nil {
-		return h.NewResponseWithError(c, "batch move node failed", err)
+		return h.nodeReferencedOrError(c, "batch move node failed", err)
 	}
 	return h.NewResponseWithData(c, nil)
 }
@@ -563,3 +602,350 @@ func (h *NodeHandler) NodeRestudy(c echo.Context) error {
 
 	return h.NewResponseWithData(c, nil)
 }
+
+// NodeDeadLetterList 学习失败任务列表
+//
+//	@Tags			Node
+//	@Summary		学习失败任务列表
+//	@Description	多次重试后仍失败的向量化任务
+//	@ID				v1-NodeDeadLetterList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeDeadLetterListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.PaginatedResult[[]domain.MQDeadLetter]}
+//	@Router			/api/v1/node/rag/dead_letter/list [get]
+func (h *NodeHandler) NodeDeadLetterList(c echo.Context) error {
+	var req v1.NodeDeadLetterListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetDeadLetterList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get dead letter list failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// NodeDeadLetterRedrive 重新投递学习失败任务
+//
+//	@Tags			Node
+//	@Summary		重新投递学习失败任务
+//	@Description	重新投递学习失败任务
+//	@ID				v1-NodeDeadLetterRedrive
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodeDeadLetterRedriveReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/api/v1/node/rag/dead_letter/redrive [post]
+func (h *NodeHandler) NodeDeadLetterRedrive(c echo.Context) error {
+	var req v1.NodeDeadLetterRedriveReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.usecase.RedriveDeadLetters(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, err.Error(), err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// NodeRecycleList 回收站列表
+//
+//	@Tags			Node
+//	@Summary		回收站列表
+//	@Description	已删除的文档和文件夹，超过保留天数后彻底删除
+//	@ID				v1-NodeRecycleList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeRecycleListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.PaginatedResult[[]v1.NodeRecycleListItem]}
+//	@Router			/api/v1/node/recycle/list [get]
+func (h *NodeHandler) NodeRecycleList(c echo.Context) error {
+	var req v1.NodeRecycleListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetRecycleList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get recycle list failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// NodeRecycleRestore 从回收站恢复
+//
+//	@Tags			Node
+//	@Summary		从回收站恢复
+//	@Description	恢复到原位置或指定的父节点下，已发布的版本会重新学习
+//	@ID				v1-NodeRecycleRestore
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodeRecycleRestoreReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/recycle/restore [post]
+func (h *NodeHandler) NodeRecycleRestore(c echo.Context) error {
+	var req v1.NodeRecycleRestoreReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	ctx := c.Request().Context()
+	req.MaxNode = domain.GetBaseEditionLimitation(ctx).MaxNode
+
+	if err := h.usecase.RestoreRecycle(ctx, &req); err != nil {
+		if errors.Is(err, domain.ErrMaxNodeLimitReached) {
+			return h.NewResponseWithError(c, "已达到最大文档数量限制，请升级到更高版本", nil)
+		}
+		return h.NewResponseWithError(c, "restore recycle failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// NodeRecyclePurge 彻底删除
+//
+//	@Tags			Node
+//	@Summary		彻底删除
+//	@Description	从回收站彻底删除，无法恢复
+//	@ID				v1-NodeRecyclePurge
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodeRecyclePurgeReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/recycle [delete]
+func (h *NodeHandler) NodeRecyclePurge(c echo.Context) error {
+	var req v1.NodeRecyclePurgeReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.PurgeRecycles(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "purge recycle failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// NodeRecycleRetention 设置回收站保留天数
+//
+//	@Tags			Node
+//	@Summary		设置回收站保留天数
+//	@Description	设置回收站保留天数
+//	@ID				v1-NodeRecycleRetention
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodeRecycleRetentionReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/recycle/retention [put]
+func (h *NodeHandler) NodeRecycleRetention(c echo.Context) error {
+	var req v1.NodeRecycleRetentionReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateRecycleRetention(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update recycle retention failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// GetNodeMetaSchema 标签和自定义字段定义
+//
+//	@Tags			Node
+//	@Summary		标签和自定义字段定义
+//	@Description	获取知识库的标签词表和自定义字段定义
+//	@ID				v1-GetNodeMetaSchema
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeMetaSchemaReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.NodeMetaSchema}
+//	@Router			/api/v1/node/meta/schema [get]
+func (h *NodeHandler) GetNodeMetaSchema(c echo.Context) error {
+	var req v1.NodeMetaSchemaReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	schema, err := h.usecase.GetNodeMetaSchema(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node meta schema failed", err)
+	}
+
+	return h.NewResponseWithData(c, schema)
+}
+
+// UpdateNodeMetaSchema 更新标签和自定义字段定义
+//
+//	@Tags			Node
+//	@Summary		更新标签和自定义字段定义
+//	@Description	整体替换知识库的标签词表和自定义字段定义，字段类型支持 text、number、date、select、user
+//	@ID				v1-UpdateNodeMetaSchema
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeMetaSchemaReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/meta/schema [put]
+func (h *NodeHandler) UpdateNodeMetaSchema(c echo.Context) error {
+	var req v1.UpdateNodeMetaSchemaReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateNodeMetaSchema(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node meta schema failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// GetNodePresence 文档在线用户
+//
+//	@Tags			Node
+//	@Summary		文档在线用户
+//	@Description	正在查看或编辑文档的用户和编辑锁持有人
+//	@ID				v1-GetNodePresence
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodePresenceReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodePresenceResp}
+//	@Router			/api/v1/node/presence [get]
+func (h *NodeHandler) GetNodePresence(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.NodePresenceReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetPresence(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node presence failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// TouchNodePresence 上报文档心跳
+//
+//	@Tags			Node
+//	@Summary		上报文档心跳
+//	@Description	打开文档后定期上报，editing 为 true 时尝试获取编辑锁，超过 30 秒未上报视为离开
+//	@ID				v1-TouchNodePresence
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodePresenceReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodePresenceResp}
+//	@Router			/api/v1/node/presence [post]
+func (h *NodeHandler) TouchNodePresence(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.NodePresenceReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	resp, err := h.usecase.TouchPresence(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "touch node presence failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// LeaveNodePresence 离开文档
+//
+//	@Tags			Node
+//	@Summary		离开文档
+//	@Description	关闭文档时调用，释放自己持有的编辑锁
+//	@ID				v1-LeaveNodePresence
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodePresenceReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/presence [delete]
+func (h *NodeHandler) LeaveNodePresence(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.NodePresenceReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.LeavePresence(ctx, &req, authInfo.UserId); err != nil {
+		return h.NewResponseWithError(c, "leave node presence failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// nodeReferencedOrError 文档被其他文档链接时返回 40010，data 为引用列表，确认后带 force 重新提交
+func (h *NodeHandler) nodeReferencedOrError(c echo.Context, msg string, err error) error {
+	var referenced *domain.NodeReferencedError
+	if errors.As(err, &referenced) {
+		errCode := domain.ErrCodeNodeReferenced
+		errCode.Data = referenced.References
+		return h.NewResponseWithErrCode(c, errCode)
+	}
+	return h.NewResponseWithError(c, msg, err)
+}
@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/repo/cache"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+// collabReadLimit 单条消息的大小上限，编辑器首次同步时会发送完整文档
+const collabReadLimit = 32 << 20
+
+type NodeCollabHandler struct {
+	*handler.BaseHandler
+	logger   *log.Logger
+	usecase  *usecase.NodeCollabUsecase
+	auth     middleware.AuthMiddleware
+	upgrader websocket.Upgrader
+}
+
+func NewNodeCollabHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.NodeCollabUsecase,
+	auth middleware.AuthMiddleware,
+	config *config.Config,
+	logger *log.Logger,
+) *NodeCollabHandler {
+	h := &NodeCollabHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.node_collab"),
+		usecase:     usecase,
+		auth:        auth,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: checkAdminOrigin(config.HTTP.AdminOrigins),
+		},
+	}
+
+	echo.POST("/api/v1/node/collab/ticket", h.CreateNodeCollabTicket,
+		h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	// 浏览器无法为 WebSocket 设置请求头，连接时使用一次性凭证认证
+	echo.GET("/api/v1/node/collab", h.NodeCollab)
+
+	return h
+}
+
+// checkAdminOrigin 只允许管理后台发起 WebSocket 连接，未配置管理后台地址时要求同源
+func checkAdminOrigin(adminOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// 非浏览器客户端不会携带 Origin，也不受跨站请求影响
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if len(adminOrigins) == 0 {
+			return strings.EqualFold(u.Host, r.Host)
+		}
+		return lo.ContainsBy(adminOrigins, func(adminOrigin string) bool {
+			return strings.EqualFold(strings.TrimRight(adminOrigin, "/"), u.Scheme+"://"+u.Host)
+		})
+	}
+}
+
+// CreateNodeCollabTicket 获取协同编辑凭证
+//
+//	@Tags			Node
+//	@Summary		获取协同编辑凭证
+//	@Description	凭证 30 秒内有效，只能用于建立一次协同编辑连接
+//	@ID				v1-CreateNodeCollabTicket
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.NodeCollabTicketReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodeCollabTicketResp}
+//	@Router			/api/v1/node/collab/ticket [post]
+func (h *NodeCollabHandler) CreateNodeCollabTicket(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.NodeCollabTicketReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	ticket, err := h.usecase.CreateTicket(ctx, req.KbID, req.ID, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "create collab ticket failed", err)
+	}
+	return h.NewResponseWithData(c, v1.NodeCollabTicketResp{
+		Ticket:    ticket,
+		ExpiresIn: int(cache.NodeCollabTicketTTL.Seconds()),
+	})
+}
+
+// NodeCollab 文档协同编辑
+//
+//	@Tags			Node
+//	@Summary		文档协同编辑
+//	@Description	WebSocket 接口，使用 y-websocket 的二进制协议同步 Yjs 文档和光标。
+//	@Description	服务端保存的文档为空时，编辑器应使用文档详情中的 content 初始化。
+//	@Description	编辑器需定期发送类型为 64 的消息上报渲染后的文档内容（varString），用于保存到文档。
+//	@Description	文档在协作之外被修改时，服务端发送类型为 65 的消息并断开连接，编辑器应重新获取文档详情后再连接。
+//	@Description	连接使用 /api/v1/node/collab/ticket 获取的一次性凭证认证，每次连接需重新获取。
+//	@ID				v1-NodeCollab
+//	@Param			param	query	v1.NodeCollabReq	true	"para"
+//	@Success		101
+//	@Router			/api/v1/node/collab [get]
+func (h *NodeCollabHandler) NodeCollab(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req v1.NodeCollabReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	userID, err := h.usecase.TakeTicket(ctx, req.KbID, req.ID, req.Ticket)
+	if err != nil {
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			return c.JSON(http.StatusUnauthorized, domain.PWResponse{
+				Success: false,
+				Message: "Unauthorized",
+			})
+		}
+		return h.NewResponseWithError(c, "check collab ticket failed", err)
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		h.logger.Error("upgrade collab connection failed", log.Error(err))
+		return nil
+	}
+	conn.SetReadLimit(collabReadLimit)
+
+	if err := h.usecase.Serve(ctx, req.KbID, req.ID, userID, conn); err != nil {
+		h.logger.Error("serve collab connection failed", log.String("node_id", req.ID), log.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/pkg/docexport"
+)
+
+// ExportNode 导出文档
+//
+//	@Tags			Node
+//	@Summary		导出文档
+//	@Description	导出文档或文件夹及其子节点为 Markdown（含图片的 zip）、DOCX、PDF 或 EPUB，按目录树生成标题层级和目录
+//	@ID				v1-ExportNode
+//	@Produce		application/octet-stream
+//	@Security		bearerAuth
+//	@Param			param	query	v1.NodeExportReq	true	"para"
+//	@Success		200		{file}	file
+//	@Router			/api/v1/node/export [get]
+func (h *NodeHandler) ExportNode(c echo.Context) error {
+	var req v1.NodeExportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	reader, size, filename, err := h.exportUsecase.Export(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "export node failed", err)
+	}
+	defer reader.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(size, 10))
+	return c.Stream(http.StatusOK, docexport.Format(req.Format).ContentType(), reader)
+}
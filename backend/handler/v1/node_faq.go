@@ -0,0 +1,282 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type NodeFAQHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.NodeFAQUsecase
+	auth    middleware.AuthMiddleware
+}
+
+func NewNodeFAQHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.NodeFAQUsecase,
+	auth middleware.AuthMiddleware,
+	logger *log.Logger,
+) *NodeFAQHandler {
+	h := &NodeFAQHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.node_faq"),
+		usecase:     usecase,
+		auth:        auth,
+	}
+
+	group := echo.Group("/api/v1/node/faq", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	group.GET("/list", h.GetNodeFAQList)
+	group.POST("/generate", h.GenerateNodeFAQ)
+	group.PUT("", h.UpdateNodeFAQ)
+	group.PUT("/status", h.UpdateNodeFAQStatus)
+	group.DELETE("", h.DeleteNodeFAQ)
+	group.POST("/promote", h.PromoteNodeFAQ)
+	group.GET("/setting", h.GetNodeFAQSetting)
+	group.PUT("/setting", h.UpdateNodeFAQSetting, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+
+	return h
+}
+
+// GetNodeFAQList 问答对列表
+//
+//	@Tags			Node
+//	@Summary		问答对列表
+//	@ID				v1-GetNodeFAQList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeFAQListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.NodeFAQItem}
+//	@Router			/api/v1/node/faq/list [get]
+func (h *NodeFAQHandler) GetNodeFAQList(c echo.Context) error {
+	var req v1.NodeFAQListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	list, err := h.usecase.GetList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node faq list failed", err)
+	}
+
+	return h.NewResponseWithData(c, list)
+}
+
+// GenerateNodeFAQ 生成问答对
+//
+//	@Tags			Node
+//	@Summary		生成问答对
+//	@Description	根据文档最新发布的内容异步生成待审核的问答对，替换之前待审核的问答对
+//	@ID				v1-GenerateNodeFAQ
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.GenerateNodeFAQReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/faq/generate [post]
+func (h *NodeFAQHandler) GenerateNodeFAQ(c echo.Context) error {
+	var req v1.GenerateNodeFAQReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.Generate(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "generate node faq failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// UpdateNodeFAQ 编辑问答对
+//
+//	@Tags			Node
+//	@Summary		编辑问答对
+//	@Description	已通过的问答对同时更新检索记录
+//	@ID				v1-UpdateNodeFAQ
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeFAQReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/faq [put]
+func (h *NodeFAQHandler) UpdateNodeFAQ(c echo.Context) error {
+	var req v1.UpdateNodeFAQReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node faq failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// UpdateNodeFAQStatus 审核问答对
+//
+//	@Tags			Node
+//	@Summary		审核问答对
+//	@Description	通过后写入检索记录，检索命中时归属到来源文档
+//	@ID				v1-UpdateNodeFAQStatus
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeFAQStatusReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/faq/status [put]
+func (h *NodeFAQHandler) UpdateNodeFAQStatus(c echo.Context) error {
+	var req v1.UpdateNodeFAQStatusReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateStatus(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node faq status failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// DeleteNodeFAQ 删除问答对
+//
+//	@Tags			Node
+//	@Summary		删除问答对
+//	@ID				v1-DeleteNodeFAQ
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.DeleteNodeFAQReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/faq [delete]
+func (h *NodeFAQHandler) DeleteNodeFAQ(c echo.Context) error {
+	var req v1.DeleteNodeFAQReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.usecase.Delete(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "delete node faq failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// PromoteNodeFAQ 整理问答对
+//
+//	@Tags			Node
+//	@Summary		整理问答对
+//	@Description	把选中的问答对生成常见问题文档，或加入首页的常见问题模块
+//	@ID				v1-PromoteNodeFAQ
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.PromoteNodeFAQReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.PromoteNodeFAQResp}
+//	@Router			/api/v1/node/faq/promote [post]
+func (h *NodeFAQHandler) PromoteNodeFAQ(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.PromoteNodeFAQReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	resp, err := h.usecase.Promote(ctx, &req, authInfo.UserId, domain.GetBaseEditionLimitation(ctx).MaxNode)
+	if err != nil {
+		if errors.Is(err, domain.ErrMaxNodeLimitReached) {
+			return h.NewResponseWithError(c, "已达到最大文档数量限制，请升级到更高版本", nil)
+		}
+		return h.NewResponseWithError(c, "promote node faq failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// GetNodeFAQSetting 问答对生成设置
+//
+//	@Tags			Node
+//	@Summary		问答对生成设置
+//	@ID				v1-GetNodeFAQSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeFAQSettingReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.NodeFAQSetting}
+//	@Router			/api/v1/node/faq/setting [get]
+func (h *NodeFAQHandler) GetNodeFAQSetting(c echo.Context) error {
+	var req v1.NodeFAQSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	setting, err := h.usecase.GetSetting(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node faq setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, setting)
+}
+
+// UpdateNodeFAQSetting 更新问答对生成设置
+//
+//	@Tags			Node
+//	@Summary		更新问答对生成设置
+//	@Description	开启后文档发布时自动生成待审核的问答对
+//	@ID				v1-UpdateNodeFAQSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeFAQSettingReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/faq/setting [put]
+func (h *NodeFAQHandler) UpdateNodeFAQSetting(c echo.Context) error {
+	var req v1.UpdateNodeFAQSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateSetting(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node faq setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type NodeLinkHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.NodeLinkUsecase
+	auth    middleware.AuthMiddleware
+}
+
+func NewNodeLinkHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.NodeLinkUsecase,
+	auth middleware.AuthMiddleware,
+	logger *log.Logger,
+) *NodeLinkHandler {
+	h := &NodeLinkHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.node_link"),
+		usecase:     usecase,
+		auth:        auth,
+	}
+
+	group := echo.Group("/api/v1/node/link", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	group.GET("/backlinks", h.GetNodeBacklinks)
+	group.GET("/graph", h.GetNodeLinkGraph)
+	group.GET("/broken", h.GetBrokenLinks)
+
+	return h
+}
+
+// GetNodeBacklinks 反向链接
+//
+//	@Tags			Node
+//	@Summary		反向链接
+//	@Description	已发布内容中链接到该文档的文档
+//	@ID				v1-GetNodeBacklinks
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeBacklinksReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.NodeBacklinkItem}
+//	@Router			/api/v1/node/link/backlinks [get]
+func (h *NodeLinkHandler) GetNodeBacklinks(c echo.Context) error {
+	var req v1.NodeBacklinksReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	items, err := h.usecase.GetBacklinks(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node backlinks failed", err)
+	}
+
+	return h.NewResponseWithData(c, items)
+}
+
+// GetNodeLinkGraph 文档链接图
+//
+//	@Tags			Node
+//	@Summary		文档链接图
+//	@Description	知识库中的文档和文档之间的站内链接
+//	@ID				v1-GetNodeLinkGraph
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeLinkGraphReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodeLinkGraphResp}
+//	@Router			/api/v1/node/link/graph [get]
+func (h *NodeLinkHandler) GetNodeLinkGraph(c echo.Context) error {
+	var req v1.NodeLinkGraphReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	graph, err := h.usecase.GetLinkGraph(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node link graph failed", err)
+	}
+
+	return h.NewResponseWithData(c, graph)
+}
+
+// GetBrokenLinks 失效链接
+//
+//	@Tags			Node
+//	@Summary		失效链接
+//	@Description	指向已删除文档的站内链接，以及定时检查失败的外部链接
+//	@ID				v1-GetBrokenLinks
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.BrokenLinkListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.BrokenLinkItem}
+//	@Router			/api/v1/node/link/broken [get]
+func (h *NodeLinkHandler) GetBrokenLinks(c echo.Context) error {
+	var req v1.BrokenLinkListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	items, err := h.usecase.GetBrokenLinks(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get broken links failed", err)
+	}
+
+	return h.NewResponseWithData(c, items)
+}
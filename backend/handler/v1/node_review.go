@@ -0,0 +1,214 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type NodeReviewHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.NodeReviewUsecase
+	auth    middleware.AuthMiddleware
+}
+
+func NewNodeReviewHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.NodeReviewUsecase,
+	auth middleware.AuthMiddleware,
+	logger *log.Logger,
+) *NodeReviewHandler {
+	h := &NodeReviewHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.node_review"),
+		usecase:     usecase,
+		auth:        auth,
+	}
+
+	group := echo.Group("/api/v1/node/review", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	group.GET("", h.GetNodeReview)
+	group.PUT("", h.UpdateNodeReview)
+	group.POST("/done", h.MarkNodeReviewed)
+	group.GET("/report", h.GetNodeReviewReport)
+	group.GET("/setting", h.GetNodeReviewSetting)
+	group.PUT("/setting", h.UpdateNodeReviewSetting, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+
+	return h
+}
+
+// GetNodeReview 文档复查设置
+//
+//	@Tags			Node
+//	@Summary		文档复查设置
+//	@Description	节点自身的负责人和复查周期，以及继承上级后生效的设置和复查期限
+//	@ID				v1-GetNodeReview
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeReviewReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodeReviewResp}
+//	@Router			/api/v1/node/review [get]
+func (h *NodeReviewHandler) GetNodeReview(c echo.Context) error {
+	var req v1.NodeReviewReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetNodeReview(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node review failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// UpdateNodeReview 设置负责人和复查周期
+//
+//	@Tags			Node
+//	@Summary		设置负责人和复查周期
+//	@Description	文件夹的设置由下级节点继承，空负责人和 0 天表示继承上级
+//	@ID				v1-UpdateNodeReview
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeReviewReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/review [put]
+func (h *NodeReviewHandler) UpdateNodeReview(c echo.Context) error {
+	var req v1.UpdateNodeReviewReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateNodeReview(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node review failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// MarkNodeReviewed 标记已复查
+//
+//	@Tags			Node
+//	@Summary		标记已复查
+//	@Description	确认文档内容仍然有效，从当前时间重新计算复查期限
+//	@ID				v1-MarkNodeReviewed
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.MarkNodeReviewedReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/review/done [post]
+func (h *NodeReviewHandler) MarkNodeReviewed(c echo.Context) error {
+	var req v1.MarkNodeReviewedReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.MarkReviewed(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "mark node reviewed failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
+// GetNodeReviewReport 文档健康报告
+//
+//	@Tags			Node
+//	@Summary		文档健康报告
+//	@Description	超过复查期限、发布 30 天以上无人访问、近 90 天差评多于好评的文档
+//	@ID				v1-GetNodeReviewReport
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeReviewReportReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.NodeReviewReportResp}
+//	@Router			/api/v1/node/review/report [get]
+func (h *NodeReviewHandler) GetNodeReviewReport(c echo.Context) error {
+	var req v1.NodeReviewReportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	report, err := h.usecase.GetReport(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node review report failed", err)
+	}
+
+	return h.NewResponseWithData(c, report)
+}
+
+// GetNodeReviewSetting 过期文档提醒设置
+//
+//	@Tags			Node
+//	@Summary		过期文档提醒设置
+//	@ID				v1-GetNodeReviewSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeReviewSettingReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.NodeReviewSetting}
+//	@Router			/api/v1/node/review/setting [get]
+func (h *NodeReviewHandler) GetNodeReviewSetting(c echo.Context) error {
+	var req v1.NodeReviewSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	setting, err := h.usecase.GetSetting(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node review setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, setting)
+}
+
+// UpdateNodeReviewSetting 更新过期文档提醒设置
+//
+//	@Tags			Node
+//	@Summary		更新过期文档提醒设置
+//	@Description	每天 10 点把过期文档按负责人分组发送到通知渠道，负责人账号为邮箱时可单独发送邮件
+//	@ID				v1-UpdateNodeReviewSetting
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.UpdateNodeReviewSettingReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/review/setting [put]
+func (h *NodeReviewHandler) UpdateNodeReviewSetting(c echo.Context) error {
+	var req v1.UpdateNodeReviewSettingReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.UpdateSetting(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node review setting failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
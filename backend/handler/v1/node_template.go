@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type NodeTemplateHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.NodeTemplateUsecase
+	auth    middleware.AuthMiddleware
+}
+
+func NewNodeTemplateHandler(
+	baseHandler *handler.BaseHandler,
+	echo *echo.Echo,
+	usecase *usecase.NodeTemplateUsecase,
+	auth middleware.AuthMiddleware,
+	logger *log.Logger,
+) *NodeTemplateHandler {
+	h := &NodeTemplateHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.node_template"),
+		usecase:     usecase,
+		auth:        auth,
+	}
+
+	group := echo.Group("/api/v1/node/template", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	group.GET("/list", h.GetNodeTemplateList)
+	group.POST("", h.CreateNodeTemplate)
+	group.PUT("", h.UpdateNodeTemplate)
+	group.DELETE("", h.DeleteNodeTemplate)
+	group.POST("/create_node", h.CreateNodeFromTemplate)
+
+	return h
+}
+
+// GetNodeTemplateList 文档模板列表
+//
+//	@Tags			Node
+//	@Summary		文档模板列表
+//	@Description	内置模板和知识库自定义模板
+//	@ID				v1-GetNodeTemplateList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeTemplateListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]domain.NodeTemplate}
+//	@Router			/api/v1/node/template/list [get]
+func (h *NodeTemplateHandler) GetNodeTemplateList(c echo.Context) error {
+	var req v1.NodeTemplateListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	templates, err := h.usecase.GetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node template list failed", err)
+	}
+	return h.NewResponseWithData(c, templates)
+}
+
+// CreateNodeTemplate 创建文档模板
+//
+//	@Tags			Node
+//	@Summary		创建文档模板
+//	@Description	文件夹模板可包含子节点，从模板创建时一并创建
+//	@ID				v1-CreateNodeTemplate
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.CreateNodeTemplateReq	true	"template"
+//	@Success		200		{object}	domain.PWResponse{data=map[string]string}
+//	@Router			/api/v1/node/template [post]
+func (h *NodeTemplateHandler) CreateNodeTemplate(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.CreateNodeTemplateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	id, err := h.usecase.Create(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "create node template failed", err)
+	}
+	return h.NewResponseWithData(c, map[string]string{
+		"id": id,
+	})
+}
+
+// UpdateNodeTemplate 更新文档模板
+//
+//	@Tags			Node
+//	@Summary		更新文档模板
+//	@Description	内置模板不可修改
+//	@ID				v1-UpdateNodeTemplate
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.UpdateNodeTemplateReq	true	"template"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/template [put]
+func (h *NodeTemplateHandler) UpdateNodeTemplate(c echo.Context) error {
+	var req v1.UpdateNodeTemplateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	if err := h.usecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update node template failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// DeleteNodeTemplate 删除文档模板
+//
+//	@Tags			Node
+//	@Summary		删除文档模板
+//	@Description	内置模板不可删除，已创建的文档不受影响
+//	@ID				v1-DeleteNodeTemplate
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.DeleteNodeTemplateReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/node/template [delete]
+func (h *NodeTemplateHandler) DeleteNodeTemplate(c echo.Context) error {
+	var req v1.DeleteNodeTemplateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.usecase.Delete(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete node template failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// CreateNodeFromTemplate 从模板创建文档
+//
+//	@Tags			Node
+//	@Summary		从模板创建文档
+//	@Description	创建文档或文件夹骨架，名称和内容中的 {{date}}、{{time}}、{{author}}、{{parent_name}}、{{kb_name}}、{{name}} 由服务端替换
+//	@ID				v1-CreateNodeFromTemplate
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.CreateNodeFromTemplateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.CreateNodeFromTemplateResp}
+//	@Router			/api/v1/node/template/create_node [post]
+func (h *NodeTemplateHandler) CreateNodeFromTemplate(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.CreateNodeFromTemplateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	resp, err := h.usecase.CreateNode(ctx, &req, authInfo.UserId, domain.GetBaseEditionLimitation(ctx).MaxNode)
+	if err != nil {
+		if errors.Is(err, domain.ErrMaxNodeLimitReached) {
+			return h.NewResponseWithError(c, "已达到最大文档数量限制，请升级到更高版本", nil)
+		}
+		return h.NewResponseWithError(c, "create node from template failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
@@ -12,6 +12,12 @@ type APIHandlers struct {
 	UserHandler          *UserHandler
 	KnowledgeBaseHandler *KnowledgeBaseHandler
 	NodeHandler          *NodeHandler
+	NodeCollabHandler    *NodeCollabHandler
+	NodeTemplateHandler  *NodeTemplateHandler
+	NodeLinkHandler      *NodeLinkHandler
+	NodeReviewHandler    *NodeReviewHandler
+	NodeFAQHandler       *NodeFAQHandler
+	CuratedAnswerHandler *CuratedAnswerHandler
 	AppHandler           *AppHandler
 	FileHandler          *FileHandler
 	ModelHandler         *ModelHandler
@@ -19,6 +25,7 @@ type APIHandlers struct {
 	CrawlerHandler       *CrawlerHandler
 	CreationHandler      *CreationHandler
 	StatHandler          *StatHandler
+	StatReportHandler    *StatReportHandler
 	CommentHandler       *CommentHandler
 	AuthV1Handler        *AuthV1Handler
 	NavHandler           *NavHandler
@@ -30,6 +37,12 @@ var ProviderSet = wire.NewSet(
 
 	handler.NewBaseHandler,
 	NewNodeHandler,
+	NewNodeCollabHandler,
+	NewNodeTemplateHandler,
+	NewNodeLinkHandler,
+	NewNodeReviewHandler,
+	NewNodeFAQHandler,
+	NewCuratedAnswerHandler,
 	NewAppHandler,
 	NewConversationHandler,
 	NewUserHandler,
@@ -39,6 +52,7 @@ var ProviderSet = wire.NewSet(
 	NewCrawlerHandler,
 	NewCreationHandler,
 	NewStatHandler,
+	NewStatReportHandler,
 	NewCommentHandler,
 	NewAuthV1Handler,
 	NewNavHandler,
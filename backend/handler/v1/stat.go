@@ -40,6 +40,14 @@ func NewStatHandler(baseHandler *handler.BaseHandler, echo *echo.Echo, usecase *
 	group.GET("/hot_pages", h.StatHotPages)
 	group.GET("/referer_hosts", h.StatRefererHosts)
 	group.GET("/browsers", h.StatBrowsers)
+
+	// 文档阅读行为
+	group.GET("/node_engagement", h.StatNodeEngagement)
+	group.GET("/node_analytics", h.StatNodeAnalytics)
+
+	// 长期统计
+	group.GET("/trend", h.StatTrend)
+	group.GET("/range", h.StatRange)
 	return h
 }
 
@@ -293,3 +301,148 @@ func (h *StatHandler) GetInstantPages(c echo.Context) error {
 	}
 	return h.NewResponseWithData(c, pages)
 }
+
+// StatNodeEngagement 文档阅读行为列表
+//
+//	@Summary		文档阅读行为列表
+//	@Description	各文档的访问量、阅读时长、滚动深度、外链点击和有用/没用反馈
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatNodeEngagementReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]domain.NodeEngagement}
+//	@Router			/api/v1/stat/node_engagement [get]
+func (h *StatHandler) StatNodeEngagement(c echo.Context) error {
+	var req v1.StatNodeEngagementReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	if err := h.usecase.ValidateStatDay(req.Day, consts.GetLicenseEdition(c)); err != nil {
+		return h.NewResponseWithErrCode(c, domain.ErrCodePermissionDenied)
+	}
+
+	list, err := h.usecase.GetNodeEngagementList(c.Request().Context(), req.KbID, req.Day)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node engagement failed", err)
+	}
+	return h.NewResponseWithData(c, list)
+}
+
+// StatNodeAnalytics 单个文档阅读分析
+//
+//	@Summary		单个文档阅读分析
+//	@Description	单个文档的阅读行为汇总、按天趋势和外链点击排行
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatNodeAnalyticsReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.StatNodeAnalyticsResp}
+//	@Router			/api/v1/stat/node_analytics [get]
+func (h *StatHandler) StatNodeAnalytics(c echo.Context) error {
+	var req v1.StatNodeAnalyticsReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	if err := h.usecase.ValidateStatDay(req.Day, consts.GetLicenseEdition(c)); err != nil {
+		return h.NewResponseWithErrCode(c, domain.ErrCodePermissionDenied)
+	}
+
+	analytics, err := h.usecase.GetNodeAnalytics(c.Request().Context(), req.KbID, req.NodeID, req.Day)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node analytics failed", err)
+	}
+	return h.NewResponseWithData(c, analytics)
+}
+
+// StatTrend 任意时间段访问趋势
+//
+//	@Summary		任意时间段访问趋势
+//	@Description	按天或按月返回任意时间段内的访问趋势
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatTrendReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.StatTrendResp}
+//	@Router			/api/v1/stat/trend [get]
+func (h *StatHandler) StatTrend(c echo.Context) error {
+	var req v1.StatTrendReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	start, end, err := h.usecase.ParseStatDateRange(req.Start, req.End)
+	if err != nil {
+		return h.NewResponseWithError(c, "invalid date range", err)
+	}
+
+	if err := h.usecase.ValidateStatRange(start, end, consts.GetLicenseEdition(c)); err != nil {
+		return h.NewResponseWithErrCode(c, domain.ErrCodePermissionDenied)
+	}
+
+	if req.Granularity == "" {
+		req.Granularity = consts.StatGranularityDay
+	}
+
+	items, err := h.usecase.GetStatTrend(c.Request().Context(), req.KbID, start, end, req.Granularity)
+	if err != nil {
+		return h.NewResponseWithError(c, "get stat trend failed", err)
+	}
+	return h.NewResponseWithData(c, v1.StatTrendResp{
+		Granularity: req.Granularity,
+		Items:       items,
+	})
+}
+
+// StatRange 任意时间段汇总统计
+//
+//	@Summary		任意时间段汇总统计
+//	@Description	返回任意时间段内的访问总量、热门文档、来源、浏览器、地域和问答分布
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatRangeReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.StatRangeResp}
+//	@Router			/api/v1/stat/range [get]
+func (h *StatHandler) StatRange(c echo.Context) error {
+	var req v1.StatRangeReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	start, end, err := h.usecase.ParseStatDateRange(req.Start, req.End)
+	if err != nil {
+		return h.NewResponseWithError(c, "invalid date range", err)
+	}
+
+	if err := h.usecase.ValidateStatRange(start, end, consts.GetLicenseEdition(c)); err != nil {
+		return h.NewResponseWithErrCode(c, domain.ErrCodePermissionDenied)
+	}
+
+	resp, err := h.usecase.GetStatRange(c.Request().Context(), req.KbID, start, end)
+	if err != nil {
+		return h.NewResponseWithError(c, "get stat range failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
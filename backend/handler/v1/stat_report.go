@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/stat/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type StatReportHandler struct {
+	*handler.BaseHandler
+	usecase *usecase.StatReportUsecase
+	auth    middleware.AuthMiddleware
+	logger  *log.Logger
+}
+
+func NewStatReportHandler(baseHandler *handler.BaseHandler, echo *echo.Echo, usecase *usecase.StatReportUsecase, logger *log.Logger, auth middleware.AuthMiddleware) *StatReportHandler {
+	h := &StatReportHandler{
+		BaseHandler: baseHandler,
+		usecase:     usecase,
+		auth:        auth,
+		logger:      logger.WithModule("handler.v1.stat_report"),
+	}
+
+	group := echo.Group("/api/v1/stat/report", h.auth.Authorize, auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.GET("/list", h.StatReportList)
+	group.POST("/create", h.StatReportCreate)
+	group.PATCH("/update", h.StatReportUpdate)
+	group.DELETE("/delete", h.StatReportDelete)
+	group.POST("/send", h.StatReportSend)
+	group.GET("/preview", h.StatReportPreview)
+	return h
+}
+
+// StatReportList 统计报表订阅列表
+//
+//	@Summary		统计报表订阅列表
+//	@Description	统计报表订阅列表
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatReportListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]domain.StatReport}
+//	@Router			/api/v1/stat/report/list [get]
+func (h *StatReportHandler) StatReportList(c echo.Context) error {
+	var req v1.StatReportListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	reports, err := h.usecase.GetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get stat report list failed", err)
+	}
+	return h.NewResponseWithData(c, reports)
+}
+
+// StatReportCreate 创建统计报表订阅
+//
+//	@Summary		创建统计报表订阅
+//	@Description	创建统计报表订阅
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.StatReportCreateReq	true	"body"
+//	@Success		200		{object}	domain.PWResponse{data=string}
+//	@Router			/api/v1/stat/report/create [post]
+func (h *StatReportHandler) StatReportCreate(c echo.Context) error {
+	var req v1.StatReportCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	id, err := h.usecase.Create(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "create stat report failed", err)
+	}
+	return h.NewResponseWithData(c, id)
+}
+
+// StatReportUpdate 更新统计报表订阅
+//
+//	@Summary		更新统计报表订阅
+//	@Description	更新统计报表订阅
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.StatReportUpdateReq	true	"body"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/api/v1/stat/report/update [patch]
+func (h *StatReportHandler) StatReportUpdate(c echo.Context) error {
+	var req v1.StatReportUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	if err := h.usecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update stat report failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// StatReportDelete 删除统计报表订阅
+//
+//	@Summary		删除统计报表订阅
+//	@Description	删除统计报表订阅
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatReportDeleteReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/api/v1/stat/report/delete [delete]
+func (h *StatReportHandler) StatReportDelete(c echo.Context) error {
+	var req v1.StatReportDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	if err := h.usecase.Delete(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete stat report failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// StatReportSend 立即发送统计报表
+//
+//	@Summary		立即发送统计报表
+//	@Description	立即发送统计报表
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.StatReportSendReq	true	"body"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/api/v1/stat/report/send [post]
+func (h *StatReportHandler) StatReportSend(c echo.Context) error {
+	var req v1.StatReportSendReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	if err := h.usecase.Send(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "send stat report failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// StatReportPreview 预览统计报表
+//
+//	@Summary		预览统计报表
+//	@Description	预览统计报表
+//	@Tags			stat
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			para	query		v1.StatReportPreviewReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.StatReportDigest}
+//	@Router			/api/v1/stat/report/preview [get]
+func (h *StatReportHandler) StatReportPreview(c echo.Context) error {
+	var req v1.StatReportPreviewReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request parameters", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validation failed", err)
+	}
+
+	digest, err := h.usecase.BuildDigest(c.Request().Context(), req.KbID, req.Period, time.Now())
+	if err != nil {
+		return h.NewResponseWithError(c, "build stat report failed", err)
+	}
+	return h.NewResponseWithData(c, digest)
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -40,6 +41,11 @@ func NewUserHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.
 	}
 	group := e.Group("/api/v1/user")
 	group.POST("/login", h.Login)
+	group.GET("/login/sso", h.SSOInfo)
+	group.POST("/login/sso", h.SSOLogin)
+	group.GET("/login/sso/callback", h.SSOCallback)
+	group.POST("/login/sso/exchange", h.SSOExchange)
+	group.POST("/login/ldap", h.LDAPLogin)
 
 	group.GET("", h.GetUserInfo, h.auth.Authorize)
 	group.GET("/list", h.ListUsers, h.auth.Authorize)
@@ -47,6 +53,15 @@ func NewUserHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.
 	group.PUT("/reset_password", h.ResetPassword, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
 	group.DELETE("/delete", h.DeleteUser, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
 
+	group.GET("/sso/setting", h.GetSSOSetting, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+	group.PUT("/sso/setting", h.UpdateSSOSetting, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+
+	group.POST("/totp/setup", h.TOTPSetup, h.auth.Authorize)
+	group.POST("/totp/enable", h.TOTPEnable, h.auth.Authorize)
+	group.POST("/totp/disable", h.TOTPDisable, h.auth.Authorize)
+	group.POST("/totp/recovery_codes", h.TOTPRecoveryCodes, h.auth.Authorize)
+	group.PUT("/totp/reset", h.TOTPReset, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+
 	return h
 }
 
@@ -113,6 +128,9 @@ func (h *UserHandler) Login(c echo.Context) error {
 	}
 
 	token, err := h.usecase.VerifyUserAndGenerateToken(ctx, req)
+	if errors.Is(err, domain.ErrTOTPRequired) {
+		return h.NewResponseWithData(c, v1.LoginResp{TOTPRequired: true})
+	}
 	if err != nil {
 		h.rateLimiter.LockAttempt(ctx, ip)
 		return h.NewResponseWithError(c, "用户名或密码错误", err)
@@ -154,6 +172,9 @@ func (h *UserHandler) GetUserInfo(c echo.Context) error {
 		IsToken:    authInfo.IsToken,
 		LastAccess: &user.LastAccess,
 		CreatedAt:  user.CreatedAt,
+
+		SourceType:  user.SourceType,
+		TOTPEnabled: user.TOTPEnabled,
 	}
 
 	return h.NewResponseWithData(c, userInfo)
@@ -0,0 +1,188 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/user/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// SSOInfo
+//
+//	@Summary		SSOInfo
+//	@Description	获取管理后台单点登录方式，用于登录页展示
+//	@Tags			user
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=v1.SSOInfoResp}
+//	@Router			/api/v1/user/login/sso [get]
+func (h *UserHandler) SSOInfo(c echo.Context) error {
+	setting, err := h.usecase.GetAdminSSOSetting(c.Request().Context())
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get sso setting", err)
+	}
+	return h.NewResponseWithData(c, v1.SSOInfoResp{
+		Enabled:    setting.Enabled,
+		SourceType: setting.SourceType,
+	})
+}
+
+// SSOLogin
+//
+//	@Summary		SSOLogin
+//	@Description	获取OIDC/OAuth/CAS登录跳转地址
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.SSOLoginReq	true	"SSOLogin Request"
+//	@Success		200		{object}	domain.PWResponse{data=v1.SSOLoginResp}
+//	@Router			/api/v1/user/login/sso [post]
+func (h *UserHandler) SSOLogin(c echo.Context) error {
+	var req v1.SSOLoginReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	url, err := h.usecase.GenerateSSOLoginUrl(c.Request().Context(), req)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to generate sso login url", err)
+	}
+	return h.NewResponseWithData(c, v1.SSOLoginResp{Url: url})
+}
+
+// SSOCallback
+//
+//	@Summary		SSOCallback
+//	@Description	身份源登录回调，成功后携带一次性登录码跳转回管理后台
+//	@Tags			user
+//	@Param			params	query	v1.SSOCallbackReq	true	"SSOCallback Request"
+//	@Success		302
+//	@Router			/api/v1/user/login/sso/callback [get]
+func (h *UserHandler) SSOCallback(c echo.Context) error {
+	var req v1.SSOCallbackReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if req.State == "" || (req.Code == "" && req.Ticket == "") {
+		return h.NewResponseWithError(c, "state and code are required", nil)
+	}
+
+	redirectUrl, err := h.usecase.SSOCallback(c.Request().Context(), req)
+	if err != nil {
+		return h.NewResponseWithError(c, "sso login failed", err)
+	}
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
+
+// SSOExchange
+//
+//	@Summary		SSOExchange
+//	@Description	使用一次性登录码换取 token
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.SSOExchangeReq	true	"SSOExchange Request"
+//	@Success		200		{object}	domain.PWResponse{data=v1.LoginResp}
+//	@Router			/api/v1/user/login/sso/exchange [post]
+func (h *UserHandler) SSOExchange(c echo.Context) error {
+	var req v1.SSOExchangeReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	token, err := h.usecase.ExchangeSSOCode(c.Request().Context(), req.Code)
+	if err != nil {
+		return h.NewResponseWithError(c, "sso login failed", err)
+	}
+	return h.NewResponseWithData(c, v1.LoginResp{Token: token})
+}
+
+// LDAPLogin
+//
+//	@Summary		LDAPLogin
+//	@Description	使用LDAP账号登录管理后台
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.LDAPLoginReq	true	"LDAPLogin Request"
+//	@Success		200		{object}	domain.PWResponse{data=v1.LoginResp}
+//	@Router			/api/v1/user/login/ldap [post]
+func (h *UserHandler) LDAPLogin(c echo.Context) error {
+	var req v1.LDAPLoginReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	ctx := c.Request().Context()
+	ip := c.RealIP()
+	if locked, remaining := h.rateLimiter.CheckIPLocked(ctx, ip); locked {
+		h.logger.Warn("IP is locked", "ip", ip, "remaining", remaining)
+		return h.NewResponseWithError(c, "账号已被锁定，请 "+remaining.String()+" 后重试", nil)
+	}
+
+	token, err := h.usecase.LDAPLogin(ctx, req)
+	if err != nil {
+		h.rateLimiter.LockAttempt(ctx, ip)
+		return h.NewResponseWithError(c, "用户名或密码错误", err)
+	}
+
+	go func() {
+		if err := h.rateLimiter.ResetLoginAttempts(context.Background(), ip); err != nil {
+			h.logger.Error("failed to reset login attempts", "error", err, "ip", ip)
+		}
+	}()
+
+	return h.NewResponseWithData(c, v1.LoginResp{Token: token})
+}
+
+// GetSSOSetting
+//
+//	@Summary		GetSSOSetting
+//	@Description	获取管理后台单点登录配置
+//	@Tags			user
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=domain.AdminSSOSetting}
+//	@Router			/api/v1/user/sso/setting [get]
+func (h *UserHandler) GetSSOSetting(c echo.Context) error {
+	setting, err := h.usecase.GetAdminSSOSetting(c.Request().Context())
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get sso setting", err)
+	}
+	return h.NewResponseWithData(c, setting)
+}
+
+// UpdateSSOSetting
+//
+//	@Summary		UpdateSSOSetting
+//	@Description	更新管理后台单点登录配置
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		domain.AdminSSOSetting	true	"SSO Setting"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/user/sso/setting [put]
+func (h *UserHandler) UpdateSSOSetting(c echo.Context) error {
+	var req domain.AdminSSOSetting
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	if err := h.usecase.UpdateAdminSSOSetting(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "failed to update sso setting", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/user/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// TOTPSetup
+//
+//	@Summary		TOTPSetup
+//	@Description	生成两步验证密钥，需调用 enable 校验验证码后生效
+//	@Tags			user
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=v1.TOTPSetupResp}
+//	@Router			/api/v1/user/totp/setup [post]
+func (h *UserHandler) TOTPSetup(c echo.Context) error {
+	authInfo := domain.GetAuthInfoFromCtx(c.Request().Context())
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	if authInfo.IsToken {
+		return h.NewResponseWithError(c, "this api not support token call", nil)
+	}
+
+	resp, err := h.usecase.SetupTOTP(c.Request().Context(), authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to setup totp", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// TOTPEnable
+//
+//	@Summary		TOTPEnable
+//	@Description	校验验证码并开启两步验证，返回恢复码
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.TOTPCodeReq	true	"TOTP Code"
+//	@Success		200		{object}	domain.PWResponse{data=v1.TOTPRecoveryCodesResp}
+//	@Router			/api/v1/user/totp/enable [post]
+func (h *UserHandler) TOTPEnable(c echo.Context) error {
+	return h.handleTOTPCode(c, func(userID, code string) (any, error) {
+		codes, err := h.usecase.EnableTOTP(c.Request().Context(), userID, code)
+		if err != nil {
+			return nil, err
+		}
+		return v1.TOTPRecoveryCodesResp{RecoveryCodes: codes}, nil
+	})
+}
+
+// TOTPDisable
+//
+//	@Summary		TOTPDisable
+//	@Description	关闭两步验证，需提供验证码或恢复码
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.TOTPCodeReq	true	"TOTP Code"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/user/totp/disable [post]
+func (h *UserHandler) TOTPDisable(c echo.Context) error {
+	return h.handleTOTPCode(c, func(userID, code string) (any, error) {
+		return nil, h.usecase.DisableTOTP(c.Request().Context(), userID, code)
+	})
+}
+
+// TOTPRecoveryCodes
+//
+//	@Summary		TOTPRecoveryCodes
+//	@Description	重新生成恢复码，旧恢复码失效
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.TOTPCodeReq	true	"TOTP Code"
+//	@Success		200		{object}	domain.PWResponse{data=v1.TOTPRecoveryCodesResp}
+//	@Router			/api/v1/user/totp/recovery_codes [post]
+func (h *UserHandler) TOTPRecoveryCodes(c echo.Context) error {
+	return h.handleTOTPCode(c, func(userID, code string) (any, error) {
+		codes, err := h.usecase.RegenerateRecoveryCodes(c.Request().Context(), userID, code)
+		if err != nil {
+			return nil, err
+		}
+		return v1.TOTPRecoveryCodesResp{RecoveryCodes: codes}, nil
+	})
+}
+
+func (h *UserHandler) handleTOTPCode(c echo.Context, fn func(userID, code string) (any, error)) error {
+	var req v1.TOTPCodeReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	authInfo := domain.GetAuthInfoFromCtx(c.Request().Context())
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	if authInfo.IsToken {
+		return h.NewResponseWithError(c, "this api not support token call", nil)
+	}
+
+	resp, err := fn(authInfo.UserId, req.Code)
+	if err != nil {
+		return h.NewResponseWithError(c, "totp verify failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// TOTPReset
+//
+//	@Summary		TOTPReset
+//	@Description	管理员为丢失设备的用户关闭两步验证
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.TOTPResetReq	true	"TOTPReset Request"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/user/totp/reset [put]
+func (h *UserHandler) TOTPReset(c echo.Context) error {
+	var req v1.TOTPResetReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	if err := h.usecase.ResetTOTP(c.Request().Context(), req.ID); err != nil {
+		return h.NewResponseWithError(c, "failed to reset totp", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const namespace = "panda_wiki"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	chatFirstTokenDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "chat",
+		Name:      "first_token_seconds",
+		Help:      "Time from chat request to the first answer token.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 3, 5, 8, 13, 20, 30, 60},
+	}, []string{"model"})
+
+	llmTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "tokens_total",
+		Help:      "LLM tokens consumed by model and type (prompt/completion).",
+	}, []string{"model", "type"})
+
+	ragRetrievalDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "rag",
+		Name:      "retrieval_duration_seconds",
+		Help:      "RAG retrieval latency.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	}, []string{"status"})
+
+	mqPendingMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mq",
+		Name:      "pending_messages",
+		Help:      "Messages not yet delivered to the consumer by topic.",
+	}, []string{"topic"})
+
+	mqAckPendingMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mq",
+		Name:      "ack_pending_messages",
+		Help:      "Messages delivered but not yet acknowledged by topic.",
+	}, []string{"topic"})
+
+	nodeRagStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "rag_status",
+		Help:      "Number of document nodes by vectorization status.",
+	}, []string{"kb_id", "status"})
+)
+
+var nodeRagStatuses = []consts.NodeRagInfoStatus{
+	consts.NodeRagStatusPending,
+	consts.NodeRagStatusRunning,
+	consts.NodeRagStatusFailed,
+	consts.NodeRagStatusSucceeded,
+	consts.NodeRagStatusReindexing,
+}
+
+func ObserveHTTPRequest(method, route string, status int, d time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+func ObserveChatFirstToken(model string, d time.Duration) {
+	chatFirstTokenDuration.WithLabelValues(model).Observe(d.Seconds())
+}
+
+func AddLLMTokens(model string, promptTokens, completionTokens int) {
+	llmTokens.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	llmTokens.WithLabelValues(model, "completion").Add(float64(completionTokens))
+}
+
+func ObserveRAGRetrieval(d time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	ragRetrievalDuration.WithLabelValues(status).Observe(d.Seconds())
+}
+
+func SetMQPendingMessages(topic string, pending, ackPending uint64) {
+	mqPendingMessages.WithLabelValues(topic).Set(float64(pending))
+	mqAckPendingMessages.WithLabelValues(topic).Set(float64(ackPending))
+}
+
+// SetNodeRagStatus 更新知识库各向量化状态的文档数，未出现的状态置0
+func SetNodeRagStatus(kbID string, counts map[consts.NodeRagInfoStatus]int64) {
+	for _, status := range nodeRagStatuses {
+		nodeRagStatus.WithLabelValues(kbID, string(status)).Set(float64(counts[status]))
+	}
+}
+
+// Serve 在独立端口暴露 /metrics，避免经由网关对外暴露
+func Serve(logger *log.Logger, port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		addr := ":" + strconv.Itoa(port)
+		logger.Info("start metrics server", log.String("addr", addr))
+		if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics server exited", log.Error(err))
+		}
+	}()
+}
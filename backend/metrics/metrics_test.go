@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, g.Write(m))
+	return m.GetGauge().GetValue()
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, c.Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func histogramCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, o.(prometheus.Metric).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestEchoMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware())
+	e.GET("/api/v1/node/:id", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/api/v1/fail", func(c echo.Context) error { return errors.New("boom") })
+	e.GET("/api/v1/denied", func(c echo.Context) error { return echo.NewHTTPError(http.StatusForbidden) })
+
+	tests := []struct {
+		name   string
+		path   string
+		route  string
+		status string
+	}{
+		{"route template instead of path", "/api/v1/node/abc", "/api/v1/node/:id", "204"},
+		{"plain error", "/api/v1/fail", "/api/v1/fail", "500"},
+		{"http error", "/api/v1/denied", "/api/v1/denied", "403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, uint64(1), histogramCount(t, httpRequestDuration.WithLabelValues(http.MethodGet, tt.route, tt.status)))
+		})
+	}
+}
+
+func TestSetNodeRagStatus(t *testing.T) {
+	SetNodeRagStatus("kb", map[consts.NodeRagInfoStatus]int64{
+		consts.NodeRagStatusSucceeded: 8,
+		consts.NodeRagStatusFailed:    2,
+	})
+	assert.Equal(t, float64(8), gaugeValue(t, nodeRagStatus.WithLabelValues("kb", string(consts.NodeRagStatusSucceeded))))
+	assert.Equal(t, float64(2), gaugeValue(t, nodeRagStatus.WithLabelValues("kb", string(consts.NodeRagStatusFailed))))
+
+	// 再次上报时未出现的状态归零
+	SetNodeRagStatus("kb", map[consts.NodeRagInfoStatus]int64{consts.NodeRagStatusSucceeded: 10})
+	assert.Equal(t, float64(10), gaugeValue(t, nodeRagStatus.WithLabelValues("kb", string(consts.NodeRagStatusSucceeded))))
+	assert.Equal(t, float64(0), gaugeValue(t, nodeRagStatus.WithLabelValues("kb", string(consts.NodeRagStatusFailed))))
+	assert.Equal(t, float64(0), gaugeValue(t, nodeRagStatus.WithLabelValues("kb", string(consts.NodeRagStatusPending))))
+}
+
+func TestRAGAndTokenMetrics(t *testing.T) {
+	AddLLMTokens("gpt", 100, 20)
+	AddLLMTokens("gpt", 50, 10)
+	assert.Equal(t, float64(150), counterValue(t, llmTokens.WithLabelValues("gpt", "prompt")))
+	assert.Equal(t, float64(30), counterValue(t, llmTokens.WithLabelValues("gpt", "completion")))
+
+	ObserveRAGRetrieval(0, nil)
+	ObserveRAGRetrieval(0, errors.New("timeout"))
+	assert.Equal(t, uint64(1), histogramCount(t, ragRetrievalDuration.WithLabelValues("success")))
+	assert.Equal(t, uint64(1), histogramCount(t, ragRetrievalDuration.WithLabelValues("error")))
+}
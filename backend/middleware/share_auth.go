@@ -13,14 +13,16 @@ import (
 )
 
 type ShareAuthMiddleware struct {
-	logger    *log.Logger
-	kbUsecase *usecase.KnowledgeBaseUsecase
+	logger      *log.Logger
+	kbUsecase   *usecase.KnowledgeBaseUsecase
+	authUsecase *usecase.AuthUsecase
 }
 
-func NewShareAuthMiddleware(logger *log.Logger, kbUsecase *usecase.KnowledgeBaseUsecase) *ShareAuthMiddleware {
+func NewShareAuthMiddleware(logger *log.Logger, kbUsecase *usecase.KnowledgeBaseUsecase, authUsecase *usecase.AuthUsecase) *ShareAuthMiddleware {
 	return &ShareAuthMiddleware{
-		logger:    logger.WithModule("middleware.share_auth"),
-		kbUsecase: kbUsecase,
+		logger:      logger.WithModule("middleware.share_auth"),
+		kbUsecase:   kbUsecase,
+		authUsecase: authUsecase,
 	}
 }
 
@@ -118,6 +120,15 @@ func (h *ShareAuthMiddleware) Authorize(next echo.HandlerFunc) echo.HandlerFunc
 					Message: "Unauthorized",
 				})
 			}
+			// 用户被删除或被SCIM停用后会话立即失效
+			auth, err := h.authUsecase.GetAuthInfo(c.Request().Context(), kbID, userId)
+			if err != nil || !auth.Active {
+				h.logger.Warn("session user is deleted or deactivated", log.Any("user_id", userId), log.Error(err))
+				return c.JSON(http.StatusUnauthorized, domain.PWResponse{
+					Success: false,
+					Message: "Unauthorized",
+				})
+			}
 			c.Set("user_id", userId)
 			return next(c)
 		}
@@ -21,6 +21,7 @@ type Message interface {
 type MQConsumer interface {
 	StartConsumerHandlers(ctx context.Context) error
 	RegisterHandler(topic string, handler func(ctx context.Context, msg types.Message) error) error
+	Backlog() (map[string]types.Backlog, error)
 	Close() error
 }
 
@@ -2,7 +2,10 @@ package nats
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 
@@ -12,6 +15,12 @@ import (
 	"github.com/chaitin/panda-wiki/mq/types"
 )
 
+const (
+	maxDeliver     = 5
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = 5 * time.Minute
+)
+
 type MQConsumer struct {
 	conn     *nats.Conn
 	js       nats.JetStreamContext
@@ -113,6 +122,7 @@ func (c *MQConsumer) registerJetStreamHandler(topic string, handler func(ctx con
 			c.logger.Error("handle message failed",
 				log.String("topic", topic),
 				log.Error(err))
+			c.retryOrDeadLetter(topic, msg, err)
 			return
 		}
 
@@ -134,6 +144,79 @@ func (c *MQConsumer) registerJetStreamHandler(topic string, handler func(ctx con
 	return nil
 }
 
+// retryOrDeadLetter 处理失败的消息按指数退避重新投递，超过最大次数后转入死信主题
+func (c *MQConsumer) retryOrDeadLetter(topic string, msg *nats.Msg, handleErr error) {
+	attempts := 1
+	if meta, err := msg.Metadata(); err == nil {
+		attempts = int(meta.NumDelivered)
+	}
+
+	if attempts < maxDeliver || topic == domain.DeadLetterTopic {
+		if err := msg.NakWithDelay(retryBackoff(attempts)); err != nil {
+			c.logger.Error("failed to nak message", log.String("topic", topic), log.Error(err))
+		}
+		return
+	}
+
+	data, err := json.Marshal(types.DeadLetter{
+		Topic:    topic,
+		Payload:  string(msg.Data),
+		Error:    handleErr.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	})
+	if err != nil {
+		c.logger.Error("failed to marshal dead letter", log.String("topic", topic), log.Error(err))
+		return
+	}
+	if _, err := c.js.Publish(domain.DeadLetterTopic, data); err != nil {
+		// 死信发布失败时继续重试，避免消息丢失
+		c.logger.Error("failed to publish dead letter", log.String("topic", topic), log.Error(err))
+		if err := msg.NakWithDelay(retryBackoff(attempts)); err != nil {
+			c.logger.Error("failed to nak message", log.String("topic", topic), log.Error(err))
+		}
+		return
+	}
+	c.logger.Warn("message moved to dead letter",
+		log.String("topic", topic),
+		log.Int("attempts", attempts),
+		log.Error(handleErr))
+	if err := msg.Ack(); err != nil {
+		c.logger.Error("failed to ack message", log.String("topic", topic), log.Error(err))
+	}
+}
+
+// retryBackoff 第 n 次失败后的重试间隔：5s, 10s, 20s ... 最长 5 分钟
+func retryBackoff(attempts int) time.Duration {
+	delay := retryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// Backlog 获取各 JetStream 订阅的消息积压情况，Core NATS 订阅不做持久化故不统计
+func (c *MQConsumer) Backlog() (map[string]types.Backlog, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	backlog := make(map[string]types.Backlog)
+	for topic, sub := range c.handlers {
+		info, err := sub.ConsumerInfo()
+		if errors.Is(err, nats.ErrTypeSubscription) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		backlog[topic] = types.Backlog{
+			Pending:    info.NumPending,
+			AckPending: uint64(info.NumAckPending),
+		}
+	}
+	return backlog, nil
+}
+
 func (c *MQConsumer) StartConsumerHandlers(ctx context.Context) error {
 	<-ctx.Done()
 	return nil
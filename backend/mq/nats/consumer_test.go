@@ -0,0 +1,35 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{7, 5 * time.Minute},
+		{10, 5 * time.Minute},
+		// 位移溢出时仍取最大间隔
+		{70, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, retryBackoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+
+	// 转入死信前共重试 maxDeliver-1 次
+	var total time.Duration
+	for attempts := 1; attempts < maxDeliver; attempts++ {
+		total += retryBackoff(attempts)
+	}
+	assert.Equal(t, 75*time.Second, total)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/nats-io/nats.go"
 
 	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 )
 
@@ -30,6 +31,14 @@ func (p *MQProducer) EnsureStreams() error {
 			name:     "scraper",
 			subjects: []string{"apps.panda-wiki.scraper.>"},
 		},
+		{
+			name:     "export",
+			subjects: []string{domain.KBExportTaskTopic},
+		},
+		{
+			name:     "dead_letter",
+			subjects: []string{domain.DeadLetterTopic},
+		},
 	}
 
 	for _, stream := range streams {
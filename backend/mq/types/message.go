@@ -1,7 +1,24 @@
 package types
 
+import "time"
+
 // Message represents a generic message that can be from either Kafka or NATS
 type Message interface {
 	GetData() []byte
 	GetTopic() string
 }
+
+// Backlog 主题上尚未处理完的消息数
+type Backlog struct {
+	Pending    uint64 // 尚未投递
+	AckPending uint64 // 已投递未确认
+}
+
+// DeadLetter 投递到死信主题的消息，保留原始消息和失败原因
+type DeadLetter struct {
+	Topic    string    `json:"topic"`
+	Payload  string    `json:"payload"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
@@ -20,6 +20,8 @@ type WechatServiceConfig struct {
 	containKeywords []string
 	equalKeywords   []string
 	logoUrl         string
+	// 内部访问静态文件的地址，由对象存储提供
+	staticFileEndpoint string
 	// db
 	WeRepo *pg.WechatRepository
 }
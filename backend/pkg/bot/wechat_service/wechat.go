@@ -23,18 +23,19 @@ import (
 	"github.com/chaitin/panda-wiki/pkg/bot"
 )
 
-func NewWechatServiceConfig(ctx context.Context, logger *log.Logger, KbId, CorpID, Token, EncodingAESKey, secret, logo string, containKeywords, equalKeywords []string) (*WechatServiceConfig, error) {
+func NewWechatServiceConfig(ctx context.Context, logger *log.Logger, KbId, CorpID, Token, EncodingAESKey, secret, logo string, containKeywords, equalKeywords []string, staticFileEndpoint string) (*WechatServiceConfig, error) {
 	return &WechatServiceConfig{
-		Ctx:             ctx,
-		kbID:            KbId,
-		CorpID:          CorpID,
-		Token:           Token,
-		EncodingAESKey:  EncodingAESKey,
-		Secret:          secret,
-		logger:          logger,
-		containKeywords: containKeywords,
-		equalKeywords:   equalKeywords,
-		logoUrl:         logo,
+		Ctx:                ctx,
+		kbID:               KbId,
+		CorpID:             CorpID,
+		Token:              Token,
+		EncodingAESKey:     EncodingAESKey,
+		Secret:             secret,
+		logger:             logger,
+		containKeywords:    containKeywords,
+		equalKeywords:      equalKeywords,
+		logoUrl:            logo,
+		staticFileEndpoint: staticFileEndpoint,
 	}, nil
 }
 
@@ -187,8 +188,6 @@ func (cfg *WechatServiceConfig) Processmessage(msgRet *MsgRet, Kfmsg *WeixinUser
 }
 
 func (cfg *WechatServiceConfig) getImageID(token, image string) (string, error) {
-	const minioPrefix = "http://panda-wiki-minio:9000"
-
 	// 优先使用配置的logoUrl
 	if cfg.logoUrl != "" {
 		image = cfg.logoUrl
@@ -202,7 +201,7 @@ func (cfg *WechatServiceConfig) getImageID(token, image string) (string, error)
 	case strings.HasPrefix(image, "data:image/"):
 		imageId, err = GetDefaultImageID(token, image)
 	default:
-		imageId, err = GetUserImageID(token, fmt.Sprintf("%s%s", minioPrefix, image))
+		imageId, err = GetUserImageID(token, fmt.Sprintf("%s%s", cfg.staticFileEndpoint, image))
 	}
 
 	if imageId != "" && err == nil {
@@ -27,6 +27,7 @@ type Config struct {
 	ValidatePath string `json:"validate_path"` // 验证路径，默认根据版本自动选择
 	Version      string `json:"version"`       // CAS协议版本: "2" 或 "3"
 	CASUrl       string `json:"cas_url"`
+	CallbackPath string `json:"callback_path,omitempty"` // 回调路径，默认为前台CAS回调
 }
 
 type UserInfo struct {
@@ -106,6 +107,9 @@ func NewClient(ctx context.Context, logger *log.Logger, config Config) (*Client,
 			return nil, fmt.Errorf("invalid service URL: %w", err)
 		}
 		serviceURL.Path = callbackPath
+		if config.CallbackPath != "" {
+			serviceURL.Path = config.CallbackPath
+		}
 		config.ServiceURL = serviceURL.String()
 	}
 
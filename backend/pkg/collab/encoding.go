@@ -0,0 +1,99 @@
+package collab
+
+import (
+	"errors"
+)
+
+// 与 lib0 编码一致：无符号整数按 7 位分组小端存储，字节数组和字符串前置长度
+
+var errUnexpectedEOF = errors.New("collab: unexpected end of message")
+
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) readVarUint() (uint64, error) {
+	var num uint64
+	var shift uint
+	for {
+		if d.pos >= len(d.buf) {
+			return 0, errUnexpectedEOF
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		num |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return num, nil
+		}
+		shift += 7
+		if shift > 63 {
+			return 0, errors.New("collab: varuint overflow")
+		}
+	}
+}
+
+func (d *decoder) readVarBytes() ([]byte, error) {
+	n, err := d.readVarUint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)-d.pos) < n {
+		return nil, errUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *decoder) readVarString() (string, error) {
+	b, err := d.readVarBytes()
+	return string(b), err
+}
+
+func appendVarUint(buf []byte, num uint64) []byte {
+	for num >= 0x80 {
+		buf = append(buf, byte(num)|0x80)
+		num >>= 7
+	}
+	return append(buf, byte(num))
+}
+
+func appendVarBytes(buf, b []byte) []byte {
+	buf = appendVarUint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendVarString(buf []byte, s string) []byte {
+	buf = appendVarUint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// EncodeUpdates 将 Yjs 更新列表编码为一段字节，用于持久化
+func EncodeUpdates(updates [][]byte) []byte {
+	buf := appendVarUint(nil, uint64(len(updates)))
+	for _, update := range updates {
+		buf = appendVarBytes(buf, update)
+	}
+	return buf
+}
+
+func DecodeUpdates(data []byte) ([][]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	d := &decoder{buf: data}
+	n, err := d.readVarUint()
+	if err != nil {
+		return nil, err
+	}
+	updates := make([][]byte, 0, min(n, 1024))
+	for i := uint64(0); i < n; i++ {
+		update, err := d.readVarBytes()
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
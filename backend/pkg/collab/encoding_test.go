@@ -0,0 +1,241 @@
+package collab
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarUint(t *testing.T) {
+	tests := []struct {
+		name    string
+		num     uint64
+		encoded []byte
+	}{
+		{"zero", 0, []byte{0x00}},
+		{"one byte max", 127, []byte{0x7f}},
+		{"two bytes min", 128, []byte{0x80, 0x01}},
+		{"300", 300, []byte{0xac, 0x02}},
+		{"two bytes max", 16383, []byte{0xff, 0x7f}},
+		{"three bytes min", 16384, []byte{0x80, 0x80, 0x01}},
+		{"max uint32", math.MaxUint32, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.encoded, appendVarUint(nil, tt.num))
+
+			d := &decoder{buf: tt.encoded}
+			num, err := d.readVarUint()
+			require.NoError(t, err)
+			assert.Equal(t, tt.num, num)
+			assert.Equal(t, len(tt.encoded), d.pos)
+		})
+	}
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		read func(d *decoder) error
+	}{
+		{"empty varuint", nil, func(d *decoder) error { _, err := d.readVarUint(); return err }},
+		{"truncated varuint", []byte{0x80}, func(d *decoder) error { _, err := d.readVarUint(); return err }},
+		{
+			"varuint overflow",
+			[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+			func(d *decoder) error { _, err := d.readVarUint(); return err },
+		},
+		{"bytes longer than buffer", []byte{0x03, 'a', 'b'}, func(d *decoder) error { _, err := d.readVarBytes(); return err }},
+		{"huge length", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, func(d *decoder) error { _, err := d.readVarString(); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.read(&decoder{buf: tt.buf}))
+		})
+	}
+}
+
+func TestVarString(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		encoded []byte
+	}{
+		{"empty", "", []byte{0x00}},
+		{"ascii", "null", []byte{0x04, 'n', 'u', 'l', 'l'}},
+		{"utf-8 counts bytes", "文", []byte{0x03, 0xe6, 0x96, 0x87}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.encoded, appendVarString(nil, tt.s))
+			assert.Equal(t, tt.encoded, appendVarBytes(nil, []byte(tt.s)))
+
+			s, err := (&decoder{buf: tt.encoded}).readVarString()
+			require.NoError(t, err)
+			assert.Equal(t, tt.s, s)
+		})
+	}
+}
+
+func TestEncodeUpdates(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates [][]byte
+		encoded []byte
+	}{
+		{"no update", [][]byte{}, []byte{0x00}},
+		{"single update", [][]byte{{0x00, 0x00}}, []byte{0x01, 0x02, 0x00, 0x00}},
+		{"multiple updates", [][]byte{{0x01}, {}, {0x02, 0x03}}, []byte{0x03, 0x01, 0x01, 0x00, 0x02, 0x02, 0x03}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := EncodeUpdates(tt.updates)
+			assert.Equal(t, tt.encoded, encoded)
+
+			decoded, err := DecodeUpdates(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.updates, decoded)
+		})
+	}
+}
+
+func TestDecodeUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected [][]byte
+		wantErr  bool
+	}{
+		{"empty state", nil, nil, false},
+		{"missing update", []byte{0x02, 0x01, 0xaa}, nil, true},
+		{"truncated update", []byte{0x01, 0x05, 0xaa}, nil, true},
+		// 条数不可信，不能按条数预分配
+		{"huge count", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates, err := DecodeUpdates(tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, updates)
+		})
+	}
+}
+
+func awarenessUpdate(entries ...any) []byte {
+	buf := appendVarUint(nil, uint64(len(entries)/3))
+	for i := 0; i < len(entries); i += 3 {
+		buf = appendVarUint(buf, entries[i].(uint64))
+		buf = appendVarUint(buf, entries[i+1].(uint64))
+		buf = appendVarString(buf, entries[i+2].(string))
+	}
+	return buf
+}
+
+func TestTrackAwareness(t *testing.T) {
+	tests := []struct {
+		name     string
+		clocks   map[uint64]uint64
+		update   []byte
+		expected map[uint64]uint64
+		wantErr  bool
+	}{
+		{
+			"add clients",
+			map[uint64]uint64{},
+			awarenessUpdate(uint64(1), uint64(3), `{"user":"a"}`, uint64(2), uint64(0), `{}`),
+			map[uint64]uint64{1: 3, 2: 0},
+			false,
+		},
+		{
+			"update clock",
+			map[uint64]uint64{1: 3},
+			awarenessUpdate(uint64(1), uint64(4), `{"cursor":null}`),
+			map[uint64]uint64{1: 4},
+			false,
+		},
+		{
+			"null state removes client",
+			map[uint64]uint64{1: 3, 2: 1},
+			awarenessUpdate(uint64(1), uint64(4), "null"),
+			map[uint64]uint64{2: 1},
+			false,
+		},
+		{"empty update", map[uint64]uint64{}, nil, map[uint64]uint64{}, true},
+		{"truncated entry", map[uint64]uint64{}, []byte{0x01, 0x01}, map[uint64]uint64{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := trackAwareness(tt.clocks, tt.update)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, tt.clocks)
+		})
+	}
+}
+
+func TestEncodeAwarenessRemoval(t *testing.T) {
+	msg := encodeAwarenessRemoval(map[uint64]uint64{7: 3, 300: 0})
+
+	d := &decoder{buf: msg}
+	messageType, err := d.readVarUint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(messageAwareness), messageType)
+	update, err := d.readVarBytes()
+	require.NoError(t, err)
+	assert.Equal(t, len(msg), d.pos)
+
+	// 移除消息的时钟需大于已知时钟，其他编辑器才会应用
+	clocks := map[uint64]uint64{7: 3, 300: 0, 9: 1}
+	ud := &decoder{buf: update}
+	n, err := ud.readVarUint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+	for i := uint64(0); i < n; i++ {
+		clientID, err := ud.readVarUint()
+		require.NoError(t, err)
+		clock, err := ud.readVarUint()
+		require.NoError(t, err)
+		assert.Equal(t, clocks[clientID]+1, clock)
+		state, err := ud.readVarString()
+		require.NoError(t, err)
+		assert.Equal(t, "null", state)
+	}
+
+	require.NoError(t, trackAwareness(clocks, update))
+	assert.Equal(t, map[uint64]uint64{9: 1}, clocks)
+}
+
+func TestEncodeSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		syncType uint64
+		payload  []byte
+		expected []byte
+	}{
+		{"empty step2", syncStep2, emptyUpdate, []byte{messageSync, syncStep2, 0x02, 0x00, 0x00}},
+		{"state request", syncStep1, []byte{0}, []byte{messageSync, syncStep1, 0x01, 0x00}},
+		{"update", syncUpdate, []byte{0xaa, 0xbb}, []byte{messageSync, syncUpdate, 0x02, 0xaa, 0xbb}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, encodeSync(tt.syncType, tt.payload))
+		})
+	}
+}
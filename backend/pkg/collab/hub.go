@@ -0,0 +1,517 @@
+package collab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// 消息格式与 y-websocket 一致，服务端不解析 CRDT，只转发和保存更新
+const (
+	messageSync           = 0
+	messageAwareness      = 1
+	messageQueryAwareness = 3
+	// MessageContent 扩展消息，编辑器定期上报渲染后的文档内容，负载为 varString
+	MessageContent = 64
+	// MessageReload 扩展消息，文档在协作之外被修改，编辑器需要重新加载文档内容后再连接
+	MessageReload = 65
+
+	syncStep1  = 0
+	syncStep2  = 1
+	syncUpdate = 2
+
+	// BinaryMessage WebSocket 二进制帧类型
+	BinaryMessage = 2
+
+	// compactThreshold 更新条数超过该值时向编辑器请求完整状态，替换之前的更新
+	compactThreshold = 500
+	// maxPendingBytes 单个连接待发送的数据上限，超过后断开慢连接
+	maxPendingBytes = 64 << 20
+)
+
+// emptyUpdate 不包含任何内容的 Yjs 更新
+var emptyUpdate = []byte{0, 0}
+
+// ErrConflict 文档在协作之外被修改，房间内的更新已过期
+var ErrConflict = errors.New("collab: document modified outside collaboration")
+
+// Conn WebSocket 连接，方法与 gorilla/websocket 的 Conn 一致
+type Conn interface {
+	ReadMessage() (messageType int, data []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// Content 编辑器上报的文档内容
+type Content struct {
+	Text   string
+	UserID string
+}
+
+// Persister 加载和保存房间的文档状态
+type Persister interface {
+	Load(ctx context.Context, room string) ([][]byte, error)
+	// Save 保存全部更新，content 为 nil 表示上次保存后没有编辑器上报内容；
+	// 返回 ErrConflict 时房间丢弃更新并通知编辑器重新加载
+	Save(ctx context.Context, room string, updates [][]byte, content *Content) error
+}
+
+// Hub 管理当前进程内的协作房间，同一文档的编辑器需要连接到同一个实例
+type Hub struct {
+	persister Persister
+	interval  time.Duration
+	logger    *log.Logger
+
+	mu    sync.Mutex
+	rooms map[string]*room
+}
+
+func NewHub(persister Persister, interval time.Duration, logger *log.Logger) *Hub {
+	return &Hub{
+		persister: persister,
+		interval:  interval,
+		logger:    logger,
+		rooms:     make(map[string]*room),
+	}
+}
+
+// Serve 处理一个编辑器连接，阻塞到连接断开
+func (h *Hub) Serve(ctx context.Context, roomID, userID string, conn Conn) error {
+	defer conn.Close()
+	c := &client{
+		userID:      userID,
+		conn:        conn,
+		notify:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		clocks:      make(map[uint64]uint64),
+		compactFrom: -1,
+	}
+	r, err := h.join(ctx, roomID, c)
+	if err != nil {
+		return err
+	}
+	go c.writeLoop()
+	defer func() {
+		r.leave(c)
+		close(c.done)
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		if err := r.handle(c, data); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *Hub) join(ctx context.Context, roomID string, c *client) (*room, error) {
+	for {
+		h.mu.Lock()
+		r, ok := h.rooms[roomID]
+		if !ok {
+			r = &room{
+				id:       roomID,
+				hub:      h,
+				ready:    make(chan struct{}),
+				stop:     make(chan struct{}),
+				finished: make(chan struct{}),
+				clients:  make(map[*client]struct{}),
+			}
+			h.rooms[roomID] = r
+			go r.load()
+		}
+		h.mu.Unlock()
+
+		select {
+		case <-r.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if r.loadErr != nil {
+			return nil, r.loadErr
+		}
+		if r.add(c) {
+			return r, nil
+		}
+		// 房间正在关闭，等最后一次保存完成后重新加载
+		select {
+		case <-r.finished:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Reload 文档在协作之外被修改时，丢弃房间中的更新并让编辑器重新加载，房间不存在时忽略
+func (h *Hub) Reload(roomID string) {
+	h.mu.Lock()
+	r, ok := h.rooms[roomID]
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case <-r.ready:
+	default:
+		// 仍在加载的房间会在保存时发现修订号冲突
+		return
+	}
+	if r.loadErr == nil {
+		r.reload()
+	}
+}
+
+func (h *Hub) remove(r *room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rooms[r.id] == r {
+		delete(h.rooms, r.id)
+	}
+}
+
+type client struct {
+	userID string
+	conn   Conn
+
+	outMu    sync.Mutex
+	out      [][]byte
+	outBytes int
+	closed   bool
+	closing  bool // 待发送的消息写完后断开
+	notify   chan struct{}
+	done     chan struct{}
+
+	// 以下字段由 room.mu 保护
+	synced      bool              // 已回复过该连接的 syncStep1
+	awareness   []byte            // 最近一次的 awareness 消息，发给后加入的编辑器
+	clocks      map[uint64]uint64 // 该连接上报的 Yjs clientID 及时钟
+	compactFrom int               // 请求完整状态时的更新条数
+}
+
+func (c *client) deliver(msg []byte) {
+	c.outMu.Lock()
+	if c.closed || c.closing {
+		c.outMu.Unlock()
+		return
+	}
+	if c.outBytes+len(msg) > maxPendingBytes {
+		c.closed = true
+		c.outMu.Unlock()
+		c.conn.Close()
+		return
+	}
+	c.out = append(c.out, msg)
+	c.outBytes += len(msg)
+	c.outMu.Unlock()
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliverAndClose 发送最后一条消息后断开连接
+func (c *client) deliverAndClose(msg []byte) {
+	c.deliver(msg)
+	c.outMu.Lock()
+	c.closing = true
+	c.outMu.Unlock()
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (c *client) writeLoop() {
+	for {
+		select {
+		case <-c.notify:
+		case <-c.done:
+			return
+		}
+		c.outMu.Lock()
+		msgs, closing := c.out, c.closing
+		c.out, c.outBytes = nil, 0
+		c.outMu.Unlock()
+		for _, msg := range msgs {
+			if err := c.conn.WriteMessage(BinaryMessage, msg); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+		if closing {
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+type room struct {
+	id       string
+	hub      *Hub
+	ready    chan struct{}
+	loadErr  error
+	stop     chan struct{}
+	finished chan struct{}
+
+	mu         sync.Mutex
+	clients    map[*client]struct{}
+	updates    [][]byte
+	dirty      bool
+	content    *Content
+	compacting *client
+	closed     bool
+	stale      bool // 更新已过期，等待编辑器全部断开后重新加载
+}
+
+func (r *room) load() {
+	r.updates, r.loadErr = r.hub.persister.Load(context.Background(), r.id)
+	close(r.ready)
+	if r.loadErr != nil {
+		r.hub.logger.Error("load collab room failed", log.String("room", r.id), log.Error(r.loadErr))
+		r.hub.remove(r)
+		close(r.finished)
+		return
+	}
+	go r.run()
+}
+
+func (r *room) run() {
+	ticker := time.NewTicker(r.hub.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.save()
+		case <-r.stop:
+			r.save()
+			r.hub.remove(r)
+			close(r.finished)
+			return
+		}
+	}
+}
+
+func (r *room) save() {
+	r.mu.Lock()
+	if r.stale || (!r.dirty && r.content == nil) {
+		r.mu.Unlock()
+		return
+	}
+	updates := append([][]byte(nil), r.updates...)
+	content := r.content
+	r.dirty, r.content = false, nil
+	r.mu.Unlock()
+
+	if err := r.hub.persister.Save(context.Background(), r.id, updates, content); err != nil {
+		if errors.Is(err, ErrConflict) {
+			r.reload()
+			return
+		}
+		r.hub.logger.Error("save collab room failed", log.String("room", r.id), log.Error(err))
+		r.mu.Lock()
+		r.dirty = true
+		if r.content == nil {
+			r.content = content
+		}
+		r.mu.Unlock()
+	}
+}
+
+// reload 丢弃过期的更新并断开全部编辑器，重新连接时按文档当前内容加载
+func (r *room) reload() {
+	r.hub.logger.Warn("collab room is stale, reload editors", log.String("room", r.id))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stale = true
+	r.updates, r.dirty, r.content, r.compacting = nil, false, nil, nil
+	msg := appendVarUint(nil, MessageReload)
+	for c := range r.clients {
+		c.deliverAndClose(msg)
+	}
+}
+
+func (r *room) add(c *client) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.stale {
+		return false
+	}
+	r.clients[c] = struct{}{}
+	for other := range r.clients {
+		if other != c && other.awareness != nil {
+			c.deliver(other.awareness)
+		}
+	}
+	return true
+}
+
+// leave 最后一个编辑器离开时保存并关闭房间
+func (r *room) leave(c *client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, c)
+	if r.compacting == c {
+		r.compacting = nil
+	}
+	if len(c.clocks) > 0 {
+		r.broadcast(c, encodeAwarenessRemoval(c.clocks))
+	}
+	if len(r.clients) == 0 {
+		r.closed = true
+		close(r.stop)
+	}
+}
+
+func (r *room) broadcast(from *client, msg []byte) {
+	for c := range r.clients {
+		if c != from {
+			c.deliver(msg)
+		}
+	}
+}
+
+func (r *room) handle(c *client, data []byte) error {
+	d := &decoder{buf: data}
+	messageType, err := d.readVarUint()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	stale := r.stale
+	r.mu.Unlock()
+	if stale {
+		// 断开前收到的消息基于过期的内容，直接丢弃
+		return nil
+	}
+	switch messageType {
+	case messageSync:
+		syncType, err := d.readVarUint()
+		if err != nil {
+			return err
+		}
+		payload, err := d.readVarBytes()
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		switch syncType {
+		case syncStep1:
+			// 无法按状态向量计算差异，直接发送全部更新，Yjs 会忽略重复的部分
+			if len(r.updates) == 0 {
+				c.deliver(encodeSync(syncStep2, emptyUpdate))
+			}
+			for _, update := range r.updates {
+				c.deliver(encodeSync(syncStep2, update))
+			}
+			c.synced = true
+			// 反向请求编辑器的完整状态，补齐其连接前的修改，同时用来压缩更新
+			r.requestState(c)
+		case syncStep2, syncUpdate:
+			if syncType == syncStep2 && r.compacting == c {
+				// 编辑器在收到请求前已应用 compactFrom 之前的全部更新
+				r.updates = append([][]byte{bytes.Clone(payload)}, r.updates[c.compactFrom:]...)
+				r.compacting = nil
+			} else {
+				r.updates = append(r.updates, bytes.Clone(payload))
+			}
+			r.dirty = true
+			r.broadcast(c, encodeSync(syncUpdate, payload))
+			if r.compacting == nil && c.synced && len(r.updates) > compactThreshold {
+				r.requestState(c)
+			}
+		}
+	case messageAwareness:
+		payload, err := d.readVarBytes()
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := trackAwareness(c.clocks, payload); err != nil {
+			return err
+		}
+		c.awareness = bytes.Clone(data)
+		r.broadcast(c, c.awareness)
+	case messageQueryAwareness:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for other := range r.clients {
+			if other != c && other.awareness != nil {
+				c.deliver(other.awareness)
+			}
+		}
+	case MessageContent:
+		text, err := d.readVarString()
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.content = &Content{Text: text, UserID: c.userID}
+	}
+	return nil
+}
+
+// requestState 发送空状态向量的 syncStep1，编辑器会回复包含全部内容的 syncStep2
+func (r *room) requestState(c *client) {
+	if r.compacting == nil {
+		r.compacting = c
+		c.compactFrom = len(r.updates)
+	}
+	c.deliver(encodeSync(syncStep1, []byte{0}))
+}
+
+func encodeSync(syncType uint64, payload []byte) []byte {
+	buf := appendVarUint(nil, messageSync)
+	buf = appendVarUint(buf, syncType)
+	return appendVarBytes(buf, payload)
+}
+
+// trackAwareness 记录连接上报的 clientID 和时钟，状态为 null 表示已离开
+func trackAwareness(clocks map[uint64]uint64, update []byte) error {
+	d := &decoder{buf: update}
+	n, err := d.readVarUint()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		clientID, err := d.readVarUint()
+		if err != nil {
+			return err
+		}
+		clock, err := d.readVarUint()
+		if err != nil {
+			return err
+		}
+		state, err := d.readVarString()
+		if err != nil {
+			return err
+		}
+		if state == "null" {
+			delete(clocks, clientID)
+		} else {
+			clocks[clientID] = clock
+		}
+	}
+	return nil
+}
+
+// encodeAwarenessRemoval 连接断开时通知其他编辑器移除对应的光标
+func encodeAwarenessRemoval(clocks map[uint64]uint64) []byte {
+	update := appendVarUint(nil, uint64(len(clocks)))
+	for clientID, clock := range clocks {
+		update = appendVarUint(update, clientID)
+		update = appendVarUint(update, clock+1)
+		update = appendVarString(update, "null")
+	}
+	buf := appendVarUint(nil, messageAwareness)
+	return appendVarBytes(buf, update)
+}
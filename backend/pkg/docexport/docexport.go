@@ -0,0 +1,258 @@
+// Package docexport 将文档树导出为 Markdown、DOCX、PDF 和 EPUB，不依赖外部浏览器或转换服务
+package docexport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+	"golang.org/x/net/html"
+)
+
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatDOCX     Format = "docx"
+	FormatPDF      Format = "pdf"
+	FormatEPUB     Format = "epub"
+)
+
+// tocMaxLevel 目录中包含的最大标题级别
+const tocMaxLevel = 3
+
+var ErrFontRequired = errors.New("docexport: pdf export requires a font")
+
+// Ext 导出文件的扩展名，Markdown 和图片一起打包为 zip
+func (f Format) Ext() string {
+	if f == FormatMarkdown {
+		return ".zip"
+	}
+	return "." + string(f)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "application/zip"
+	case FormatDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatEPUB:
+		return "application/epub+zip"
+	}
+	return "application/octet-stream"
+}
+
+// Document 待导出的文档，Sections 按目录树先序排列
+type Document struct {
+	Title    string
+	Sections []*Section
+}
+
+// Section 目录树中的一个节点，Depth 从 1 开始，决定标题级别；正文中的标题按层级顺延
+type Section struct {
+	Title   string
+	Depth   int
+	Content string // HTML，文件夹为空
+}
+
+// Image 正文引用的图片
+type Image struct {
+	ContentType string
+	Data        []byte
+
+	name          string
+	format        string // image.DecodeConfig 识别的格式，无法识别时为空
+	width, height int
+}
+
+// ImageLoader 按 img 的 src 加载图片，返回 nil 时保留原地址
+type ImageLoader func(src string) (*Image, error)
+
+type Options struct {
+	Images ImageLoader
+	// Font PDF 使用的 TrueType 字体，需包含文档中的字符
+	Font []byte
+}
+
+// Write 按格式导出文档
+func Write(w io.Writer, format Format, doc *Document, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	d, err := build(doc, opts.Images)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, d)
+	case FormatDOCX:
+		return writeDOCX(w, d)
+	case FormatPDF:
+		if len(opts.Font) == 0 {
+			return ErrFontRequired
+		}
+		return writePDF(w, d, opts.Font)
+	case FormatEPUB:
+		return writeEPUB(w, d)
+	}
+	return fmt.Errorf("docexport: unsupported format %q", format)
+}
+
+// document 解析后的文档，标题已按目录树层级编号
+type document struct {
+	title    string
+	blocks   []*block
+	toc      []*block
+	images   []*Image
+	sections int
+}
+
+func build(doc *Document, loader ImageLoader) (*document, error) {
+	d := &document{title: doc.Title, sections: len(doc.Sections)}
+	p := &parser{doc: d, loader: loader, loaded: make(map[string]*Image)}
+	for i, section := range doc.Sections {
+		depth := min(max(section.Depth, 1), 6)
+		p.section = i
+		p.shift = depth
+		heading := &block{
+			kind:    blockHeading,
+			level:   depth,
+			id:      p.nextID(),
+			section: i,
+			inlines: []inline{{text: section.Title}},
+		}
+		d.blocks = append(d.blocks, heading)
+		if strings.TrimSpace(section.Content) == "" {
+			continue
+		}
+		root, err := html.Parse(strings.NewReader(section.Content))
+		if err != nil {
+			return nil, err
+		}
+		blocks := p.blocks(root)
+		if p.err != nil {
+			return nil, p.err
+		}
+		d.blocks = append(d.blocks, blocks...)
+	}
+	walkBlocks(d.blocks, func(b *block) {
+		if b.kind == blockHeading && b.level <= tocMaxLevel {
+			d.toc = append(d.toc, b)
+		}
+	})
+	return d, nil
+}
+
+func walkBlocks(blocks []*block, fn func(*block)) {
+	for _, b := range blocks {
+		fn(b)
+		walkBlocks(b.children, fn)
+		for _, item := range b.items {
+			walkBlocks(item, fn)
+		}
+	}
+}
+
+// loadImage 同一地址只加载一次，并识别尺寸和格式
+func (p *parser) loadImage(src string) *Image {
+	if p.loader == nil || src == "" {
+		return nil
+	}
+	if img, ok := p.loaded[src]; ok {
+		return img
+	}
+	img, err := p.loader(src)
+	if err != nil {
+		p.err = fmt.Errorf("load image %s failed: %w", src, err)
+		return nil
+	}
+	p.loaded[src] = img
+	if img == nil {
+		return nil
+	}
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(img.Data)); err == nil {
+		img.format, img.width, img.height = format, cfg.Width, cfg.Height
+	}
+	ext := ""
+	if img.format != "" {
+		ext = "." + img.format
+	} else if exts, _ := mime.ExtensionsByType(img.ContentType); len(exts) > 0 {
+		ext = exts[0]
+	} else {
+		ext = path.Ext(strings.SplitN(src, "?", 2)[0])
+	}
+	if ext == ".jpeg" {
+		ext = ".jpg"
+	}
+	p.doc.images = append(p.doc.images, img)
+	img.name = fmt.Sprintf("image-%d%s", len(p.doc.images), ext)
+	if img.ContentType == "" {
+		img.ContentType = mime.TypeByExtension(ext)
+	}
+	return img
+}
+
+// portable 返回 DOCX 和 PDF 都能嵌入的 PNG、JPEG 或 GIF，其他格式转为 PNG，无法解码时返回 false
+func (img *Image) portable() (data []byte, format string, ok bool) {
+	switch img.format {
+	case "png", "jpeg", "gif":
+		return img.Data, img.format, true
+	case "":
+		return nil, "", false
+	}
+	data, err := img.toPNG()
+	if err != nil {
+		return nil, "", false
+	}
+	return data, "png", true
+}
+
+// toPNG 重新编码为 8 位 NRGBA 的 PNG
+func (img *Image) toPNG() ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewNRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tocTree 将扁平的标题列表按级别组成树，跳级的标题挂在最近的上级下
+type tocNode struct {
+	heading  *block
+	children []*tocNode
+}
+
+func tocTree(headings []*block) []*tocNode {
+	root := &tocNode{}
+	stack := []*tocNode{root}
+	for _, h := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].heading.level >= h.level {
+			stack = stack[:len(stack)-1]
+		}
+		node := &tocNode{heading: h}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+	return root.children
+}
@@ -0,0 +1,349 @@
+package docexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func exportMarkdown(t *testing.T, doc *Document, opts *Options) map[string]string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatMarkdown, doc, opts))
+	return readZip(t, buf.Bytes())
+}
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 1))))
+	return buf.Bytes()
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format      Format
+		ext         string
+		contentType string
+	}{
+		{FormatMarkdown, ".zip", "application/zip"},
+		{FormatDOCX, ".docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{FormatPDF, ".pdf", "application/pdf"},
+		{FormatEPUB, ".epub", "application/epub+zip"},
+		{Format("txt"), ".txt", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			assert.Equal(t, tt.ext, tt.format.Ext())
+			assert.Equal(t, tt.contentType, tt.format.ContentType())
+		})
+	}
+}
+
+func TestWrite_Errors(t *testing.T) {
+	doc := &Document{Title: "doc", Sections: []*Section{{Title: "a", Depth: 1, Content: `<img src="/a.png">`}}}
+
+	err := Write(io.Discard, Format("txt"), doc, nil)
+	assert.ErrorContains(t, err, "unsupported format")
+
+	err = Write(io.Discard, FormatPDF, doc, nil)
+	assert.ErrorIs(t, err, ErrFontRequired)
+
+	loadErr := errors.New("boom")
+	err = Write(io.Discard, FormatMarkdown, doc, &Options{Images: func(string) (*Image, error) { return nil, loadErr }})
+	assert.ErrorIs(t, err, loadErr)
+}
+
+func TestWrite_MarkdownBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"inline styles", `<p>a <strong>b</strong> <em>c</em> <code>d</code> <s>e</s></p>`, "a **b** *c* `d` ~~e~~\n"},
+		{"nested styles", `<p><strong><em>a</em></strong></p>`, "***a***\n"},
+		{"emphasis trims inner spaces", `<p>a<strong> b </strong>c</p>`, "a **b** c\n"},
+		{"code containing backtick", "<p><code>a`b</code></p>", "``a`b``\n"},
+		{"link", `<p><a href="https://example.com/a">link</a></p>`, "[link](https://example.com/a)\n"},
+		{"link with space", `<p><a href="/a b.html">link</a></p>`, "[link](</a b.html>)\n"},
+		{"javascript link dropped", `<p><a href="JavaScript:alert(1)">x</a></p>`, "x\n"},
+		{"escape markdown", `<p># not *heading* [x]</p>`, "\\# not \\*heading\\* \\[x\\]\n"},
+		{"collapse whitespace", "<p>  a\n\n\tb  </p>", "a b\n"},
+		{"line break", `<p>a<br>b</p>`, "a\\\nb\n"},
+		{"paragraphs", `<p>a</p><div>b</div>`, "a\n\nb\n"},
+		{"ignored elements", `<p>a</p><script>x()</script><style>p{}</style>`, "a\n"},
+		{"unordered list", `<ul><li>a</li><li>b</li></ul>`, "- a\n\n- b\n"},
+		{"ordered list with start", `<ol start="3"><li>a</li><li>b</li></ol>`, "3. a\n\n4. b\n"},
+		{"nested list", `<ul><li><p>a</p><ul><li>b</li></ul></li></ul>`, "- a\n\n  - b\n"},
+		{"task list", `<ul><li><input type="checkbox" checked>done</li><li><input type="checkbox">todo</li></ul>`, "- ☑ done\n\n- ☐ todo\n"},
+		{"blockquote", `<blockquote><p>a</p><p>b</p></blockquote>`, "> a\n>\n> b\n"},
+		{"code block", "<pre><code class=\"language-go\">x := 1\n</code></pre>", "```go\nx := 1\n```\n"},
+		{"code block with fence", "<pre>a\n```\nb</pre>", "````\na\n```\nb\n````\n"},
+		{"code block in list", "<ul><li><pre>a\nb</pre></li></ul>", "- ```\n  a\n  b\n  ```\n"},
+		{"rule", `<p>a</p><hr><p>b</p>`, "a\n\n---\n\nb\n"},
+		{"image keeps src without loader", `<img src="/a b.png" alt="x]">`, "![x\\]](</a b.png>)\n"},
+		{
+			"table",
+			`<table><thead><tr><th>h1</th><th>h2</th></tr></thead><tbody><tr><td>a<br>b</td></tr></tbody></table>`,
+			"| h1 | h2 |\n| --- | --- |\n| a<br>b |  |\n",
+		},
+		{"empty content", "  ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := exportMarkdown(t, &Document{Title: "doc", Sections: []*Section{{Title: "T", Depth: 1, Content: tt.content}}}, nil)
+			md, ok := files["doc.md"]
+			require.True(t, ok)
+			_, body, ok := strings.Cut(md, "# T\n")
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, strings.TrimPrefix(body, "\n"))
+		})
+	}
+}
+
+func TestWrite_MarkdownHeadings(t *testing.T) {
+	doc := &Document{
+		Title: "doc",
+		Sections: []*Section{
+			{Title: "A", Depth: 1, Content: "<h1>A1</h1><p>x</p><h3>A2</h3>"},
+			{Title: "B", Depth: 2},
+			{Title: "C", Depth: 9},
+		},
+	}
+	files := exportMarkdown(t, doc, nil)
+	// 正文标题按章节层级顺延，超过 3 级的不进入目录
+	assert.Equal(t, "**目录**\n\n"+
+		"- [A](#h1)\n"+
+		"  - [A1](#h2)\n"+
+		"  - [B](#h4)\n"+
+		"\n"+
+		"<a id=\"h1\"></a>\n# A\n\n"+
+		"<a id=\"h2\"></a>\n## A1\n\n"+
+		"x\n\n"+
+		"<a id=\"h3\"></a>\n#### A2\n\n"+
+		"<a id=\"h4\"></a>\n## B\n\n"+
+		"<a id=\"h5\"></a>\n###### C\n", files["doc.md"])
+}
+
+func TestWrite_MarkdownImages(t *testing.T) {
+	data := pngImage(t)
+	calls := 0
+	loader := func(src string) (*Image, error) {
+		calls++
+		if src == "/missing.png" {
+			return nil, nil
+		}
+		return &Image{Data: data}, nil
+	}
+	doc := &Document{
+		Title: "a/b",
+		Sections: []*Section{
+			{Title: "T", Depth: 1, Content: `<img src="/a.png" alt="a"><img src="/a.png"><img src="/missing.png">`},
+		},
+	}
+	files := exportMarkdown(t, doc, &Options{Images: loader})
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, string(data), files["assets/image-1.png"])
+	assert.Len(t, files, 2)
+	assert.Contains(t, files["a_b.md"], "![a](assets/image-1.png)\n\n![](assets/image-1.png)\n\n![](/missing.png)\n")
+}
+
+func TestWrite_DOCX(t *testing.T) {
+	var buf bytes.Buffer
+	doc := &Document{Title: "doc", Sections: []*Section{{Title: "T & T", Depth: 1, Content: `<p>hello <strong>world</strong></p>`}}}
+	require.NoError(t, Write(&buf, FormatDOCX, doc, &Options{}))
+
+	files := readZip(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/_rels/document.xml.rels"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, files["word/document.xml"], "T &amp; T")
+	assert.Contains(t, files["word/document.xml"], "world")
+}
+
+func TestWrite_EPUB(t *testing.T) {
+	var buf bytes.Buffer
+	doc := &Document{
+		Title: "doc",
+		Sections: []*Section{
+			{Title: "A", Depth: 1, Content: `<p>first</p>`},
+			{Title: "B", Depth: 1, Content: `<p>second</p>`},
+		},
+	}
+	require.NoError(t, Write(&buf, FormatEPUB, doc, nil))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	// mimetype 必须是第一个且不压缩的文件
+	require.NotEmpty(t, zr.File)
+	assert.Equal(t, "mimetype", zr.File[0].Name)
+	assert.Equal(t, zip.Store, zr.File[0].Method)
+
+	files := readZip(t, buf.Bytes())
+	assert.Equal(t, "application/epub+zip", files["mimetype"])
+	assert.Contains(t, files, "OEBPS/content.opf")
+	assert.Contains(t, files, "OEBPS/nav.xhtml")
+	assert.Contains(t, files[epubPathFor(0)], "first")
+	assert.NotContains(t, files[epubPathFor(0)], "second")
+	assert.Contains(t, files[epubPathFor(1)], "second")
+}
+
+func epubPathFor(i int) string {
+	return "OEBPS/" + epubSectionPath(i)
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"文档", "文档"},
+		{" a/b\\c:d ", "a_b_c_d"},
+		{`a*b?c"d<e>f|g`, "a_b_c_d_e_f_g"},
+		{"a\tb", "a_b"},
+		{"  ", "export"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fileName(tt.name))
+		})
+	}
+}
+
+func TestMarkdownURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/a", "https://example.com/a"},
+		{"/a b.png", "</a b.png>"},
+		{"/a(1).png", "</a(1).png>"},
+		{"/a<b>.png", "</a%3Cb%3E.png>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, markdownURL(tt.url))
+		})
+	}
+}
+
+func TestCollapseSpace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{"  a  ", " a "},
+		{"a \t\r\n\f b", "a b"},
+		{"中\n文", "中 文"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, collapseSpace(tt.input))
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"hello world", []string{"hello", " ", "world"}},
+		{"中文ab", []string{"中", "文", "ab"}},
+		{"a，b", []string{"a", "，", "b"}},
+		{"かなカナ", []string{"か", "な", "カ", "ナ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tokenize(tt.input))
+		})
+	}
+}
+
+func TestEscapeXML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a<b>&c", "a&lt;b&gt;&amp;c"},
+		{`"quote"`, "&#34;quote&#34;"},
+		{"a\x00b\x1fc", "abc"},
+		{"a\tb", "a&#x9;b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, escapeXML(tt.input))
+		})
+	}
+}
+
+func TestTOCTree(t *testing.T) {
+	h := func(id string, level int) *block {
+		return &block{kind: blockHeading, id: id, level: level}
+	}
+	var flatten func(nodes []*tocNode) string
+	flatten = func(nodes []*tocNode) string {
+		var sb strings.Builder
+		for _, node := range nodes {
+			sb.WriteString(node.heading.id)
+			if len(node.children) > 0 {
+				sb.WriteString("(" + flatten(node.children) + ")")
+			}
+			sb.WriteString(" ")
+		}
+		return strings.TrimSpace(sb.String())
+	}
+
+	tests := []struct {
+		name     string
+		headings []*block
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"flat", []*block{h("a", 1), h("b", 1)}, "a b"},
+		{"nested", []*block{h("a", 1), h("b", 2), h("c", 3), h("d", 2), h("e", 1)}, "a(b(c) d) e"},
+		{"skipped level", []*block{h("a", 1), h("b", 3), h("c", 2)}, "a(b c)"},
+		{"starts deep", []*block{h("a", 3), h("b", 1)}, "a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, flatten(tocTree(tt.headings)))
+		})
+	}
+}
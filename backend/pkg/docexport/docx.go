@@ -0,0 +1,380 @@
+package docexport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// A4 纸张去掉页边距后的正文宽度，单位 EMU
+	docxContentWidth = 5760720
+	docxEMUPerPixel  = 9525
+	docxIndentStep   = 420 // 每级缩进，单位 twip
+)
+
+type docxWriter struct {
+	body   strings.Builder
+	rels   []string // 除样式和设置外的关系
+	media  map[*Image]*docxMedia
+	nextID int
+}
+
+type docxMedia struct {
+	name  string
+	relID string
+	data  []byte
+}
+
+// writeDOCX 输出 Word 文档，目录使用 TOC 域，在 Word 中更新域后显示页码
+func writeDOCX(w io.Writer, d *document) error {
+	x := &docxWriter{media: make(map[*Image]*docxMedia)}
+
+	x.paragraph("Title", 0, false, "", x.runs([]inline{{text: d.title}}))
+	x.toc(d.toc)
+	x.blocks(d.blocks, 0, "")
+	x.body.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1247" w:bottom="1440" w:left="1247" w:header="851" w:footer="992" w:gutter="0"/></w:sectPr>`)
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCore, escapeXML(d.title), time.Now().UTC().Format(time.RFC3339))},
+		{"word/styles.xml", docxStyles},
+		{"word/settings.xml", docxSettings},
+		{"word/_rels/document.xml.rels", docxDocumentRels(x.rels)},
+		{"word/document.xml", docxDocumentHeader + x.body.String() + docxDocumentFooter},
+	}
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	for _, img := range d.images {
+		media, ok := x.media[img]
+		if !ok {
+			continue
+		}
+		f, err := zw.Create("word/" + media.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(media.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (x *docxWriter) id() int {
+	x.nextID++
+	return x.nextID
+}
+
+func (x *docxWriter) rel(typ, target string, external bool) string {
+	id := "rId" + strconv.Itoa(len(x.rels)+10)
+	mode := ""
+	if external {
+		mode = ` TargetMode="External"`
+	}
+	x.rels = append(x.rels, fmt.Sprintf(`<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/%s" Target="%s"%s/>`, id, typ, escapeXML(target), mode))
+	return id
+}
+
+// toc 目录域，缓存的结果中每项链接到标题书签
+func (x *docxWriter) toc(headings []*block) {
+	if len(headings) == 0 {
+		return
+	}
+	x.paragraph("TOCHeading", 0, false, "", x.runs([]inline{{text: "目录"}}))
+	for i, h := range headings {
+		var sb strings.Builder
+		if i == 0 {
+			sb.WriteString(`<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> TOC \o "1-` + strconv.Itoa(tocMaxLevel) + `" \h \z \u </w:instrText></w:r><w:r><w:fldChar w:fldCharType="separate"/></w:r>`)
+		}
+		sb.WriteString(`<w:hyperlink w:anchor="` + h.id + `" w:history="1">`)
+		sb.WriteString(x.runs([]inline{{text: plainText(h.inlines)}}))
+		sb.WriteString(`</w:hyperlink>`)
+		if i == len(headings)-1 {
+			sb.WriteString(`<w:r><w:fldChar w:fldCharType="end"/></w:r>`)
+		}
+		x.paragraph("TOC"+strconv.Itoa(h.level), 0, false, "", sb.String())
+	}
+}
+
+// blocks indent 为列表和引用的缩进级别，style 为段落使用的样式
+func (x *docxWriter) blocks(blocks []*block, indent int, style string) {
+	for _, b := range blocks {
+		x.block(b, indent, style, "")
+	}
+}
+
+// block marker 为列表项的项目符号，加在第一个段落前
+func (x *docxWriter) block(b *block, indent int, style, marker string) {
+	markerRun := ""
+	if marker != "" {
+		markerRun = x.runs([]inline{{text: marker + "\t"}})
+	}
+	switch b.kind {
+	case blockHeading:
+		id := x.id()
+		x.paragraph("Heading"+strconv.Itoa(b.level), indent, markerRun != "", "",
+			fmt.Sprintf(`<w:bookmarkStart w:id="%d" w:name="%s"/>%s<w:bookmarkEnd w:id="%d"/>`, id, b.id, markerRun+x.runs(b.inlines), id))
+	case blockParagraph:
+		x.paragraph(style, indent, markerRun != "", "", markerRun+x.runs(b.inlines))
+	case blockCode:
+		var sb strings.Builder
+		for i, line := range strings.Split(b.text, "\n") {
+			if i > 0 {
+				sb.WriteString(`<w:r><w:br/></w:r>`)
+			}
+			sb.WriteString(x.runs([]inline{{text: line}}))
+		}
+		if markerRun != "" {
+			x.paragraph(style, indent, markerRun != "", "", markerRun)
+		}
+		x.paragraph("Code", indent, false, "", sb.String())
+	case blockImage:
+		x.paragraph(style, indent, markerRun != "", "", markerRun+x.image(b))
+	case blockRule:
+		x.paragraph(style, indent, markerRun != "", `<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="BFBFBF"/></w:pBdr>`, markerRun)
+	case blockQuote:
+		if markerRun != "" {
+			x.paragraph(style, indent, markerRun != "", "", markerRun)
+		}
+		x.blocks(b.children, indent+1, "Quote")
+	case blockList:
+		if markerRun != "" {
+			x.paragraph(style, indent, markerRun != "", "", markerRun)
+		}
+		for i, item := range b.items {
+			itemMarker := "•"
+			if b.ordered {
+				itemMarker = strconv.Itoa(b.start+i) + "."
+			}
+			for j, child := range item {
+				if j == 0 {
+					x.block(child, indent+1, style, itemMarker)
+				} else {
+					x.block(child, indent+1, style, "")
+				}
+			}
+		}
+	case blockTable:
+		if markerRun != "" {
+			x.paragraph(style, indent, markerRun != "", "", markerRun)
+		}
+		x.table(b, indent)
+	}
+}
+
+// paragraph hanging 为 true 时首行悬挂缩进，用于列表项目符号
+func (x *docxWriter) paragraph(style string, indent int, hanging bool, pPr, content string) {
+	x.body.WriteString("<w:p><w:pPr>")
+	if style != "" {
+		x.body.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+	}
+	x.body.WriteString(pPr)
+	if indent > 0 {
+		left := indent * docxIndentStep
+		if hanging {
+			fmt.Fprintf(&x.body, `<w:tabs><w:tab w:val="left" w:pos="%d"/></w:tabs><w:ind w:left="%d" w:hanging="%d"/>`, left, left, docxIndentStep)
+		} else {
+			fmt.Fprintf(&x.body, `<w:ind w:left="%d"/>`, left)
+		}
+	}
+	x.body.WriteString("</w:pPr>")
+	x.body.WriteString(content)
+	x.body.WriteString("</w:p>")
+}
+
+func (x *docxWriter) runs(inlines []inline) string {
+	var sb strings.Builder
+	for i := 0; i < len(inlines); {
+		link := inlines[i].link
+		j := i
+		for j < len(inlines) && inlines[j].link == link {
+			j++
+		}
+		if link != "" {
+			if anchor, ok := strings.CutPrefix(link, "#"); ok {
+				sb.WriteString(`<w:hyperlink w:anchor="` + escapeXML(anchor) + `">`)
+			} else {
+				sb.WriteString(`<w:hyperlink r:id="` + x.rel("hyperlink", link, true) + `">`)
+			}
+		}
+		for _, in := range inlines[i:j] {
+			if in.br {
+				sb.WriteString(`<w:r><w:br/></w:r>`)
+				continue
+			}
+			sb.WriteString("<w:r><w:rPr>")
+			if link != "" {
+				sb.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+			}
+			if in.code {
+				sb.WriteString(`<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/>`)
+			}
+			if in.bold {
+				sb.WriteString("<w:b/><w:bCs/>")
+			}
+			if in.italic {
+				sb.WriteString("<w:i/><w:iCs/>")
+			}
+			if in.strike {
+				sb.WriteString("<w:strike/>")
+			}
+			sb.WriteString("</w:rPr>")
+			for k, part := range strings.Split(in.text, "\t") {
+				if k > 0 {
+					sb.WriteString("<w:tab/>")
+				}
+				if part != "" {
+					sb.WriteString(`<w:t xml:space="preserve">` + escapeXML(part) + `</w:t>`)
+				}
+			}
+			sb.WriteString("</w:r>")
+		}
+		if link != "" {
+			sb.WriteString("</w:hyperlink>")
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+// image 无法嵌入的图片输出说明文字和原地址
+func (x *docxWriter) image(b *block) string {
+	if b.image == nil || b.image.width == 0 {
+		return x.runs([]inline{{text: b.alt}, {text: " " + b.src, link: b.src}})
+	}
+	media, ok := x.media[b.image]
+	if !ok {
+		data, format, ok := b.image.portable()
+		if !ok {
+			return x.runs([]inline{{text: b.alt}, {text: " " + b.src, link: b.src}})
+		}
+		ext := map[string]string{"png": "png", "jpeg": "jpg", "gif": "gif"}[format]
+		media = &docxMedia{name: fmt.Sprintf("media/image%d.%s", len(x.media)+1, ext), data: data}
+		media.relID = x.rel("image", media.name, false)
+		x.media[b.image] = media
+	}
+
+	cx, cy := b.image.width*docxEMUPerPixel, b.image.height*docxEMUPerPixel
+	if cx > docxContentWidth {
+		cy = cy * docxContentWidth / cx
+		cx = docxContentWidth
+	}
+	id := x.id()
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/><wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr><pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill><pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, id, id, escapeXML(b.alt), id, escapeXML(b.image.name), media.relID, cx, cy)
+}
+
+func (x *docxWriter) table(b *block, indent int) {
+	cols := 0
+	for _, row := range b.rows {
+		cols = max(cols, len(row))
+	}
+	x.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/>`)
+	if indent > 0 {
+		fmt.Fprintf(&x.body, `<w:tblInd w:w="%d" w:type="dxa"/>`, indent*docxIndentStep)
+	}
+	x.body.WriteString(`</w:tblPr><w:tblGrid>`)
+	for i := 0; i < cols; i++ {
+		fmt.Fprintf(&x.body, `<w:gridCol w:w="%d"/>`, 9412/cols)
+	}
+	x.body.WriteString(`</w:tblGrid>`)
+	for _, row := range b.rows {
+		x.body.WriteString("<w:tr>")
+		for i := 0; i < cols; i++ {
+			x.body.WriteString(`<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/>`)
+			var inlines []inline
+			if i < len(row) {
+				inlines = row[i].inlines
+				if row[i].header {
+					x.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/>`)
+					inlines = make([]inline, len(row[i].inlines))
+					for k, in := range row[i].inlines {
+						in.bold = true
+						inlines[k] = in
+					}
+				}
+			}
+			x.body.WriteString(`</w:tcPr><w:p>`)
+			x.body.WriteString(x.runs(inlines))
+			x.body.WriteString(`</w:p></w:tc>`)
+		}
+		x.body.WriteString("</w:tr>")
+	}
+	x.body.WriteString("</w:tbl>")
+	// 表格后需要段落，避免相邻表格合并
+	x.paragraph("", indent, false, "", "")
+}
+
+func escapeXML(s string) string {
+	var sb strings.Builder
+	// 去掉 XML 1.0 不允许的控制字符
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+func docxDocumentRels(rels []string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>` +
+		strings.Join(rels, "") + `</Relationships>`
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Default Extension="png" ContentType="image/png"/><Default Extension="jpg" ContentType="image/jpeg"/><Default Extension="gif" ContentType="image/gif"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/><Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/><Override PartName="/word/settings.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"/><Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/></Types>`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/></Relationships>`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><dc:title>%s</dc:title><dc:creator>PandaWiki</dc:creator><dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created></cp:coreProperties>`
+
+const docxSettings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:defaultTabStop w:val="420"/><w:compat><w:compatSetting w:name="compatibilityMode" w:uri="http://schemas.microsoft.com/office/word" w:val="15"/></w:compat></w:settings>`
+
+const docxDocumentHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><w:body>`
+
+const docxDocumentFooter = `</w:body></w:document>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="微软雅黑" w:cs="Calibri"/><w:sz w:val="21"/><w:szCs w:val="21"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="312" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:before="240" w:after="360"/><w:jc w:val="center"/></w:pPr><w:rPr><w:b/><w:sz w:val="44"/><w:szCs w:val="44"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="200"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/><w:szCs w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="320" w:after="160"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="280" w:after="140"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/><w:szCs w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="100"/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="100"/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:sz w:val="21"/><w:szCs w:val="21"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="TOCHeading"><w:name w:val="TOC Heading"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:before="240" w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="TOC1"><w:name w:val="toc 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="60"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="TOC2"><w:name w:val="toc 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="420"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="TOC3"><w:name w:val="toc 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="840"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F5F5F5"/><w:spacing w:after="120" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="19"/><w:szCs w:val="19"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="D9D9D9"/></w:pBdr></w:pPr><w:rPr><w:color w:val="595959"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/></w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
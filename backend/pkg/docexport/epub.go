@@ -0,0 +1,275 @@
+package docexport
+
+import (
+	"archive/zip"
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// writeEPUB 输出 EPUB 3，每个章节一个 XHTML 文件，同时提供 EPUB 2 的 toc.ncx
+func writeEPUB(w io.Writer, d *document) error {
+	zw := zip.NewWriter(w)
+	// mimetype 必须是第一个文件且不压缩
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	sections := make([][]*block, max(d.sections, 1))
+	current := 0
+	for _, b := range d.blocks {
+		if b.kind == blockHeading {
+			current = b.section
+		}
+		sections[current] = append(sections[current], b)
+	}
+	uid := "urn:uuid:" + uuid.NewString()
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", epubPackage(d, uid, len(sections))},
+		{"OEBPS/nav.xhtml", epubNav(d)},
+		{"OEBPS/toc.ncx", epubNCX(d, uid)},
+		{"OEBPS/style.css", epubStyle},
+	}
+	for _, file := range files {
+		if err := writeZipFile(zw, file.name, []byte(file.content)); err != nil {
+			return err
+		}
+	}
+	for i, blocks := range sections {
+		e := &epubWriter{}
+		e.blocks(blocks)
+		title := d.title
+		if len(blocks) > 0 && blocks[0].kind == blockHeading {
+			title = plainText(blocks[0].inlines)
+		}
+		if err := writeZipFile(zw, "OEBPS/"+epubSectionPath(i), []byte(epubPage(title, "../style.css", e.sb.String()))); err != nil {
+			return err
+		}
+	}
+	for _, img := range d.images {
+		if err := writeZipFile(zw, "OEBPS/images/"+img.name, img.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func epubSectionPath(i int) string {
+	return fmt.Sprintf("text/section-%d.xhtml", i+1)
+}
+
+func epubPage(title, css, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="zh-CN" lang="zh-CN">
+<head><meta charset="UTF-8"/><title>` + escapeXML(title) + `</title><link rel="stylesheet" type="text/css" href="` + css + `"/></head>
+<body>
+` + body + `</body>
+</html>
+`
+}
+
+func epubNav(d *document) string {
+	var sb strings.Builder
+	sb.WriteString(`<nav epub:type="toc" id="toc"><h1>目录</h1>`)
+	var write func(nodes []*tocNode)
+	write = func(nodes []*tocNode) {
+		sb.WriteString("<ol>")
+		for _, node := range nodes {
+			href := epubSectionPath(node.heading.section)
+			if node.heading.id != "" {
+				href += "#" + node.heading.id
+			}
+			sb.WriteString(`<li><a href="` + href + `">` + escapeXML(plainText(node.heading.inlines)) + "</a>")
+			if len(node.children) > 0 {
+				write(node.children)
+			}
+			sb.WriteString("</li>")
+		}
+		sb.WriteString("</ol>")
+	}
+	nodes := tocTree(d.toc)
+	if len(nodes) == 0 {
+		nodes = []*tocNode{{heading: &block{inlines: []inline{{text: d.title}}}}}
+	}
+	write(nodes)
+	sb.WriteString("</nav>\n")
+	return epubPage(d.title, "style.css", sb.String())
+}
+
+func epubNCX(d *document, uid string) string {
+	var sb strings.Builder
+	order := 0
+	var write func(nodes []*tocNode)
+	write = func(nodes []*tocNode) {
+		for _, node := range nodes {
+			order++
+			fmt.Fprintf(&sb, `<navPoint id="nav-%d" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="%s#%s"/>`,
+				order, order, escapeXML(plainText(node.heading.inlines)), epubSectionPath(node.heading.section), node.heading.id)
+			write(node.children)
+			sb.WriteString("</navPoint>")
+		}
+	}
+	write(tocTree(d.toc))
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><head><meta name="dtb:uid" content="` + uid + `"/></head><docTitle><text>` + escapeXML(d.title) + `</text></docTitle><navMap>` + sb.String() + `</navMap></ncx>
+`
+}
+
+func epubPackage(d *document, uid string, sections int) string {
+	var manifest, spine strings.Builder
+	manifest.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/><item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/><item id="css" href="style.css" media-type="text/css"/>`)
+	spine.WriteString(`<itemref idref="nav"/>`)
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&manifest, `<item id="section-%d" href="%s" media-type="application/xhtml+xml"/>`, i+1, epubSectionPath(i))
+		fmt.Fprintf(&spine, `<itemref idref="section-%d"/>`, i+1)
+	}
+	for i, img := range d.images {
+		contentType := img.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&manifest, `<item id="image-%d" href="images/%s" media-type="%s"/>`, i+1, escapeXML(img.name), escapeXML(contentType))
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid" xml:lang="zh-CN">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:identifier id="uid">` + uid + `</dc:identifier><dc:title>` + escapeXML(d.title) + `</dc:title><dc:language>zh-CN</dc:language><meta property="dcterms:modified">` + time.Now().UTC().Format("2006-01-02T15:04:05Z") + `</meta></metadata>
+<manifest>` + manifest.String() + `</manifest>
+<spine toc="ncx">` + spine.String() + `</spine>
+</package>
+`
+}
+
+type epubWriter struct {
+	sb strings.Builder
+}
+
+func (e *epubWriter) blocks(blocks []*block) {
+	for _, b := range blocks {
+		e.block(b)
+	}
+}
+
+func (e *epubWriter) block(b *block) {
+	switch b.kind {
+	case blockHeading:
+		tag := "h" + strconv.Itoa(b.level)
+		e.sb.WriteString("<" + tag + ` id="` + b.id + `">` + e.inlines(b.inlines) + "</" + tag + ">\n")
+	case blockParagraph:
+		e.sb.WriteString("<p>" + e.inlines(b.inlines) + "</p>\n")
+	case blockCode:
+		e.sb.WriteString("<pre><code>" + escapeXML(b.text) + "</code></pre>\n")
+	case blockImage:
+		if b.image == nil {
+			e.sb.WriteString(`<p><a href="` + escapeXML(b.src) + `">` + escapeXML(cmp.Or(b.alt, b.src)) + "</a></p>\n")
+			return
+		}
+		e.sb.WriteString(`<figure><img src="../images/` + escapeXML(b.image.name) + `" alt="` + escapeXML(b.alt) + `"/></figure>` + "\n")
+	case blockRule:
+		e.sb.WriteString("<hr/>\n")
+	case blockQuote:
+		e.sb.WriteString("<blockquote>\n")
+		e.blocks(b.children)
+		e.sb.WriteString("</blockquote>\n")
+	case blockList:
+		if b.ordered {
+			if b.start != 1 {
+				e.sb.WriteString(`<ol start="` + strconv.Itoa(b.start) + `">` + "\n")
+			} else {
+				e.sb.WriteString("<ol>\n")
+			}
+		} else {
+			e.sb.WriteString("<ul>\n")
+		}
+		for _, item := range b.items {
+			e.sb.WriteString("<li>")
+			e.blocks(item)
+			e.sb.WriteString("</li>\n")
+		}
+		if b.ordered {
+			e.sb.WriteString("</ol>\n")
+		} else {
+			e.sb.WriteString("</ul>\n")
+		}
+	case blockTable:
+		e.sb.WriteString("<table>\n")
+		for _, row := range b.rows {
+			e.sb.WriteString("<tr>")
+			for _, c := range row {
+				tag := "td"
+				if c.header {
+					tag = "th"
+				}
+				e.sb.WriteString("<" + tag + ">" + e.inlines(c.inlines) + "</" + tag + ">")
+			}
+			e.sb.WriteString("</tr>\n")
+		}
+		e.sb.WriteString("</table>\n")
+	}
+}
+
+func (e *epubWriter) inlines(inlines []inline) string {
+	var sb strings.Builder
+	for _, in := range inlines {
+		if in.br {
+			sb.WriteString("<br/>")
+			continue
+		}
+		text := escapeXML(in.text)
+		if in.code {
+			text = "<code>" + text + "</code>"
+		}
+		if in.strike {
+			text = "<del>" + text + "</del>"
+		}
+		if in.italic {
+			text = "<em>" + text + "</em>"
+		}
+		if in.bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if in.link != "" {
+			text = `<a href="` + escapeXML(in.link) + `">` + text + "</a>"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>
+`
+
+const epubStyle = `body { font-family: sans-serif; line-height: 1.6; }
+pre { background: #f5f5f5; padding: 0.6em; white-space: pre-wrap; word-wrap: break-word; }
+code { font-family: monospace; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #ddd; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+th { background: #f2f2f2; }
+img { max-width: 100%; }
+figure { margin: 1em 0; }
+nav ol { list-style: none; }
+`
@@ -0,0 +1,228 @@
+package docexport
+
+import (
+	"archive/zip"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, "|", `\|`,
+	)
+	// 行首会被当作块级语法的字符
+	markdownLineStart = regexp.MustCompile(`(?m)^(\s*)([#>+\-=]|\d+\.)`)
+)
+
+// writeMarkdown 输出单个 Markdown 文件，图片放在 assets 目录，一起打包为 zip
+func writeMarkdown(w io.Writer, d *document) error {
+	zw := zip.NewWriter(w)
+	f, err := zw.Create(fileName(d.title) + ".md")
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	if len(d.toc) > 0 {
+		sb.WriteString("**目录**\n\n")
+		var writeTOC func(nodes []*tocNode, indent string)
+		writeTOC = func(nodes []*tocNode, indent string) {
+			for _, node := range nodes {
+				sb.WriteString(indent + "- [" + escapeMarkdown(plainText(node.heading.inlines)) + "](#" + node.heading.id + ")\n")
+				writeTOC(node.children, indent+"  ")
+			}
+		}
+		writeTOC(tocTree(d.toc), "")
+		sb.WriteString("\n")
+	}
+	m := &markdownWriter{sb: &sb}
+	m.blocks(d.blocks, "")
+	if _, err := io.WriteString(f, sb.String()); err != nil {
+		return err
+	}
+
+	for _, img := range d.images {
+		f, err := zw.Create("assets/" + img.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(img.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+type markdownWriter struct {
+	sb *strings.Builder
+}
+
+// blocks 输出块，prefix 为列表缩进或引用标记，续行同样带上
+func (m *markdownWriter) blocks(blocks []*block, prefix string) {
+	for i, b := range blocks {
+		if i > 0 {
+			m.sb.WriteString(strings.TrimRight(prefix, " ") + "\n")
+		}
+		m.block(b, prefix, prefix)
+	}
+}
+
+// block first 为首行前缀，列表项的首行带有项目符号
+func (m *markdownWriter) block(b *block, first, prefix string) {
+	var text string
+	switch b.kind {
+	case blockHeading:
+		// 标题的级别会随目录层级变化，使用 HTML 锚点保证目录链接可用
+		m.sb.WriteString(first + `<a id="` + b.id + `"></a>` + "\n")
+		first = prefix
+		text = strings.Repeat("#", b.level) + " " + strings.ReplaceAll(m.inlines(b.inlines), "\n", " ")
+	case blockParagraph:
+		text = m.inlines(b.inlines)
+	case blockCode:
+		fence := "```"
+		for strings.Contains(b.text, fence) {
+			fence += "`"
+		}
+		text = fence + b.lang + "\n" + b.text + "\n" + fence
+	case blockImage:
+		src := b.src
+		if b.image != nil {
+			src = "assets/" + b.image.name
+		}
+		text = "![" + escapeMarkdown(b.alt) + "](" + markdownURL(src) + ")"
+	case blockRule:
+		text = "---"
+	case blockQuote:
+		m.blocks(b.children, prefix+"> ")
+		return
+	case blockList:
+		for i, item := range b.items {
+			if i > 0 {
+				m.sb.WriteString(strings.TrimRight(prefix, " ") + "\n")
+			}
+			marker := "- "
+			if b.ordered {
+				marker = strconv.Itoa(b.start+i) + ". "
+			}
+			indent := prefix + strings.Repeat(" ", len(marker))
+			for j, child := range item {
+				if j == 0 {
+					m.block(child, prefix+marker, indent)
+					continue
+				}
+				m.sb.WriteString(strings.TrimRight(indent, " ") + "\n")
+				m.block(child, indent, indent)
+			}
+		}
+		return
+	case blockTable:
+		text = m.table(b)
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			m.sb.WriteString(first)
+		} else {
+			m.sb.WriteString(prefix)
+		}
+		m.sb.WriteString(line)
+		m.sb.WriteString("\n")
+	}
+}
+
+func (m *markdownWriter) table(b *block) string {
+	cols := 0
+	for _, row := range b.rows {
+		cols = max(cols, len(row))
+	}
+	var sb strings.Builder
+	writeRow := func(row []*cell) {
+		sb.WriteString("|")
+		for i := 0; i < cols; i++ {
+			text := ""
+			if i < len(row) {
+				// 单元格内不能换行，硬换行改用 <br>
+				text = strings.NewReplacer("\\\n", "<br>", "\n", "<br>").Replace(m.inlines(row[i].inlines))
+			}
+			sb.WriteString(" " + text + " |")
+		}
+		sb.WriteString("\n")
+	}
+	writeRow(b.rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, row := range b.rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (m *markdownWriter) inlines(inlines []inline) string {
+	var sb strings.Builder
+	for _, in := range inlines {
+		if in.br {
+			sb.WriteString("\\\n")
+			continue
+		}
+		text := in.text
+		// 强调标记内侧不能有空格
+		lead := text[:len(text)-len(strings.TrimLeft(text, " "))]
+		trail := text[len(strings.TrimRight(text, " ")):]
+		text = strings.TrimSpace(text)
+		if text == "" {
+			sb.WriteString(in.text)
+			continue
+		}
+		if in.code {
+			fence := "`"
+			for strings.Contains(text, fence) {
+				fence += "`"
+			}
+			if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+				text = " " + text + " "
+			}
+			text = fence + text + fence
+		} else {
+			text = escapeMarkdown(text)
+		}
+		if in.strike {
+			text = "~~" + text + "~~"
+		}
+		if in.italic {
+			text = "*" + text + "*"
+		}
+		if in.bold {
+			text = "**" + text + "**"
+		}
+		if in.link != "" {
+			text = "[" + text + "](" + markdownURL(in.link) + ")"
+		}
+		sb.WriteString(lead + text + trail)
+	}
+	return sb.String()
+}
+
+func escapeMarkdown(s string) string {
+	s = markdownEscaper.Replace(s)
+	return markdownLineStart.ReplaceAllString(s, `$1\$2`)
+}
+
+func markdownURL(u string) string {
+	if strings.ContainsAny(u, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(u) + ">"
+	}
+	return u
+}
+
+// fileName 去掉文件名中不允许的字符
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "export"
+	}
+	return name
+}
@@ -0,0 +1,402 @@
+package docexport
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type blockKind int
+
+const (
+	blockHeading blockKind = iota
+	blockParagraph
+	blockList
+	blockCode
+	blockQuote
+	blockTable
+	blockImage
+	blockRule
+)
+
+// block 各格式共用的块级结构，由 HTML 解析得到
+type block struct {
+	kind    blockKind
+	level   int    // 标题级别
+	id      string // 标题锚点
+	section int    // 所属章节，EPUB 按章节拆分文件
+	inlines []inline
+
+	text string // 代码块
+	lang string
+
+	image *Image // 为 nil 时使用原地址
+	src   string
+	alt   string
+
+	ordered  bool
+	start    int
+	items    [][]*block // 列表项
+	children []*block   // 引用
+
+	rows [][]*cell
+}
+
+type cell struct {
+	header  bool
+	inlines []inline
+}
+
+type inline struct {
+	text   string
+	bold   bool
+	italic bool
+	code   bool
+	strike bool
+	link   string
+	br     bool
+}
+
+func (in inline) sameStyle(other inline) bool {
+	return !in.br && !other.br &&
+		in.bold == other.bold && in.italic == other.italic && in.code == other.code &&
+		in.strike == other.strike && in.link == other.link
+}
+
+// mergeInlines 合并样式相同的相邻片段
+func mergeInlines(inlines []inline) []inline {
+	merged := make([]inline, 0, len(inlines))
+	for _, in := range inlines {
+		if n := len(merged); n > 0 && merged[n-1].sameStyle(in) {
+			merged[n-1].text += in.text
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+func plainText(inlines []inline) string {
+	var sb strings.Builder
+	for _, in := range inlines {
+		if in.br {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(in.text)
+		}
+	}
+	return sb.String()
+}
+
+type parser struct {
+	doc     *document
+	loader  ImageLoader
+	loaded  map[string]*Image
+	err     error
+	section int
+	shift   int
+	seq     int
+}
+
+func (p *parser) nextID() string {
+	p.seq++
+	return "h" + strconv.Itoa(p.seq)
+}
+
+// collector 收集一个容器内的块，连续的行内内容组成段落
+type collector struct {
+	p      *parser
+	blocks []*block
+	para   []inline
+}
+
+func (p *parser) blocks(n *html.Node) []*block {
+	c := &collector{p: p}
+	c.children(n, inline{}, false)
+	c.flush()
+	return c.blocks
+}
+
+// inlines 提取节点内的行内内容，块级内容之间换行，用于标题和表格单元格
+func (p *parser) inlines(n *html.Node) []inline {
+	var inlines []inline
+	for _, b := range p.blocks(n) {
+		var content []inline
+		switch b.kind {
+		case blockHeading, blockParagraph:
+			content = b.inlines
+		case blockCode:
+			content = []inline{{text: b.text, code: true}}
+		case blockImage:
+			content = []inline{{text: b.alt}}
+		case blockList:
+			for _, item := range b.items {
+				for _, child := range item {
+					content = append(content, child.inlines...)
+				}
+			}
+		}
+		if len(content) == 0 {
+			continue
+		}
+		if len(inlines) > 0 {
+			inlines = append(inlines, inline{br: true})
+		}
+		inlines = append(inlines, content...)
+	}
+	return mergeInlines(inlines)
+}
+
+func (c *collector) flush() {
+	para := trimInlines(c.para)
+	c.para = nil
+	if len(para) > 0 {
+		c.blocks = append(c.blocks, &block{kind: blockParagraph, inlines: mergeInlines(para)})
+	}
+}
+
+func (c *collector) add(b *block) {
+	c.flush()
+	c.blocks = append(c.blocks, b)
+}
+
+func (c *collector) children(n *html.Node, style inline, pre bool) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child, style, pre)
+	}
+}
+
+func (c *collector) node(n *html.Node, style inline, pre bool) {
+	p := c.p
+	switch n.Type {
+	case html.TextNode:
+		text := n.Data
+		if !pre {
+			text = collapseSpace(text)
+			if n := len(c.para); n > 0 && strings.HasSuffix(c.para[n-1].text, " ") {
+				text = strings.TrimLeft(text, " ")
+			}
+		}
+		if text != "" {
+			in := style
+			in.text = text
+			c.para = append(c.para, in)
+		}
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		c.children(n, style, pre)
+		return
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript, atom.Template, atom.Iframe, atom.Svg, atom.Object, atom.Button, atom.Select:
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		inlines := p.inlines(n)
+		if len(inlines) == 0 {
+			return
+		}
+		c.add(&block{
+			kind:    blockHeading,
+			level:   min(int(n.Data[1]-'0')+p.shift, 6),
+			id:      p.nextID(),
+			section: p.section,
+			inlines: inlines,
+		})
+	case atom.Ul, atom.Ol:
+		list := &block{kind: blockList, ordered: n.DataAtom == atom.Ol, start: 1}
+		if v, err := strconv.Atoi(attr(n, "start")); err == nil {
+			list.start = v
+		}
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type != html.ElementNode {
+				continue
+			}
+			if item := p.blocks(li); len(item) > 0 {
+				list.items = append(list.items, item)
+			}
+		}
+		if len(list.items) > 0 {
+			c.add(list)
+		}
+	case atom.Pre:
+		code := &block{kind: blockCode, text: strings.TrimSuffix(textContent(n), "\n")}
+		for _, node := range []*html.Node{n, n.FirstChild} {
+			if node == nil || node.Type != html.ElementNode {
+				continue
+			}
+			for _, class := range strings.Fields(attr(node, "class")) {
+				if lang, ok := strings.CutPrefix(class, "language-"); ok {
+					code.lang = lang
+				}
+			}
+		}
+		c.add(code)
+	case atom.Blockquote:
+		if children := p.blocks(n); len(children) > 0 {
+			c.add(&block{kind: blockQuote, children: children})
+		}
+	case atom.Table:
+		table := &block{kind: blockTable}
+		c.rows(n, table)
+		if len(table.rows) > 0 {
+			c.add(table)
+		}
+	case atom.Img:
+		src := attr(n, "src")
+		if src == "" {
+			return
+		}
+		c.add(&block{kind: blockImage, src: src, alt: attr(n, "alt"), image: p.loadImage(src)})
+	case atom.Hr:
+		c.add(&block{kind: blockRule})
+	case atom.Br:
+		c.para = append(c.para, inline{br: true})
+	case atom.Input:
+		if attr(n, "type") == "checkbox" {
+			text := "☐ "
+			if hasAttr(n, "checked") {
+				text = "☑ "
+			}
+			in := style
+			in.text = text
+			c.para = append(c.para, in)
+		}
+	case atom.Strong, atom.B:
+		style.bold = true
+		c.children(n, style, pre)
+	case atom.Em, atom.I, atom.Cite:
+		style.italic = true
+		c.children(n, style, pre)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		style.code = true
+		c.children(n, style, pre)
+	case atom.S, atom.Del, atom.Strike:
+		style.strike = true
+		c.children(n, style, pre)
+	case atom.A:
+		if href := attr(n, "href"); href != "" && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			style.link = href
+		}
+		c.children(n, style, pre)
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Aside, atom.Nav,
+		atom.Figure, atom.Figcaption, atom.Details, atom.Summary, atom.Dl, atom.Dt, atom.Dd, atom.Address,
+		atom.Center, atom.Html, atom.Body, atom.Li, atom.Caption:
+		c.flush()
+		c.children(n, inline{}, false)
+		c.flush()
+	default:
+		c.children(n, style, pre)
+	}
+}
+
+func (c *collector) rows(n *html.Node, table *block) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			c.rows(child, table)
+		case atom.Tr:
+			var row []*cell
+			for td := child.FirstChild; td != nil; td = td.NextSibling {
+				if td.Type == html.ElementNode && (td.DataAtom == atom.Td || td.DataAtom == atom.Th) {
+					row = append(row, &cell{header: td.DataAtom == atom.Th, inlines: c.p.inlines(td)})
+				}
+			}
+			if len(row) > 0 {
+				table.rows = append(table.rows, row)
+			}
+		}
+	}
+}
+
+// trimInlines 去掉段落首尾的空白和换行，只有空白时返回 nil
+func trimInlines(inlines []inline) []inline {
+	for len(inlines) > 0 {
+		first := &inlines[0]
+		if first.br {
+			inlines = inlines[1:]
+			continue
+		}
+		if first.text = strings.TrimLeft(first.text, " "); first.text == "" {
+			inlines = inlines[1:]
+			continue
+		}
+		break
+	}
+	for len(inlines) > 0 {
+		last := &inlines[len(inlines)-1]
+		if last.br {
+			inlines = inlines[:len(inlines)-1]
+			continue
+		}
+		if last.text = strings.TrimRight(last.text, " "); last.text == "" {
+			inlines = inlines[:len(inlines)-1]
+			continue
+		}
+		break
+	}
+	return inlines
+}
+
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	if space {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			sb.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,497 @@
+package docexport
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfFont       = "doc"
+	pdfMargin     = 20.0 // 页边距，单位 mm
+	pdfFontSize   = 10.5
+	pdfLineHeight = 1.7 // 行高与字号的比例
+	pdfIndent     = 7.0 // 列表和引用每级缩进
+	pdfPxToMM     = 25.4 / 96
+)
+
+var pdfHeadingSizes = [6]float64{20, 17, 15, 13, 12, 11}
+
+type rgb struct{ r, g, b int }
+
+var (
+	pdfTextColor  = rgb{33, 33, 33}
+	pdfQuoteColor = rgb{102, 102, 102}
+	pdfLinkColor  = rgb{0, 99, 193}
+	pdfCodeColor  = rgb{199, 37, 78}
+	pdfMutedColor = rgb{150, 150, 150}
+)
+
+// writePDF 渲染两遍，第一遍记录各标题所在页，第二遍在目录中填入页码
+func writePDF(w io.Writer, d *document, font []byte) error {
+	first, err := renderPDF(d, font, nil)
+	if err != nil {
+		return err
+	}
+	second, err := renderPDF(d, font, first.pages)
+	if err != nil {
+		return err
+	}
+	return second.pdf.Output(w)
+}
+
+type pdfWriter struct {
+	pdf       *fpdf.Fpdf
+	doc       *document
+	tocPages  map[string]int // 上一遍渲染得到的标题页码
+	pages     map[string]int
+	links     map[string]int
+	images    map[*Image]fpdf.ImageOptions // 已注册的图片，无法嵌入时 ImageType 为空
+	lastLevel int                          // 上一个书签的级别，书签不能跳级
+	left      float64
+	color     rgb
+}
+
+func renderPDF(d *document, font []byte, tocPages map[string]int) (*pdfWriter, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	r := &pdfWriter{
+		pdf:       pdf,
+		doc:       d,
+		tocPages:  tocPages,
+		pages:     make(map[string]int),
+		links:     make(map[string]int),
+		images:    make(map[*Image]fpdf.ImageOptions),
+		lastLevel: -1,
+		left:      pdfMargin,
+		color:     pdfTextColor,
+	}
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetCellMargin(0)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", font)
+	pdf.SetTitle(d.title, true)
+	pdf.SetCreator("PandaWiki", true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin / 2)
+		pdf.SetFont(pdfFont, "", 9)
+		r.setColor(pdfMutedColor)
+		pdf.CellFormat(0, 4, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+
+	pdf.AddPage()
+	r.setColor(pdfTextColor)
+	pdf.SetFont(pdfFont, "", 22)
+	r.setBold(true, 22)
+	pdf.MultiCell(0, lineHeight(22), d.title, "", "C", false)
+	r.setBold(false, 22)
+	pdf.Ln(lineHeight(pdfFontSize))
+	if len(d.toc) > 0 {
+		r.toc()
+		pdf.AddPage()
+	}
+	r.blocks(d.blocks)
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+	return r, nil
+}
+
+// lineHeight 字号单位为 pt，返回 mm
+func lineHeight(size float64) float64 {
+	return size * pdfLineHeight * 25.4 / 72
+}
+
+func (r *pdfWriter) right() float64 {
+	w, _ := r.pdf.GetPageSize()
+	return w - pdfMargin
+}
+
+func (r *pdfWriter) bottom() float64 {
+	_, h := r.pdf.GetPageSize()
+	return h - pdfMargin
+}
+
+// ensureSpace 当前页剩余高度不足时换页
+func (r *pdfWriter) ensureSpace(h float64) {
+	if r.pdf.GetY()+h > r.bottom() {
+		r.pdf.AddPage()
+	}
+}
+
+func (r *pdfWriter) setLeft(left float64) {
+	r.left = left
+	r.pdf.SetLeftMargin(left)
+	r.pdf.SetX(left)
+}
+
+func (r *pdfWriter) setColor(c rgb) {
+	r.pdf.SetTextColor(c.r, c.g, c.b)
+}
+
+// setBold 只有一种字重的字体，用描边模拟粗体
+func (r *pdfWriter) setBold(bold bool, size float64) {
+	if bold {
+		r.pdf.SetLineWidth(size * 0.004)
+		r.pdf.SetDrawColor(r.color.r, r.color.g, r.color.b)
+		r.pdf.SetTextRenderingMode(2)
+	} else {
+		r.pdf.SetTextRenderingMode(0)
+	}
+}
+
+func (r *pdfWriter) link(id string) int {
+	if link, ok := r.links[id]; ok {
+		return link
+	}
+	link := r.pdf.AddLink()
+	r.links[id] = link
+	return link
+}
+
+func (r *pdfWriter) toc() {
+	pdf := r.pdf
+	pdf.SetFont(pdfFont, "", 16)
+	r.setBold(true, 16)
+	pdf.CellFormat(0, lineHeight(16), "目录", "", 1, "L", false, 0, "")
+	r.setBold(false, 16)
+
+	size := pdfFontSize
+	lineH := lineHeight(size)
+	pdf.SetFont(pdfFont, "", size)
+	numW := pdf.GetStringWidth("00000")
+	dotW := pdf.GetStringWidth(".")
+	for _, h := range r.doc.toc {
+		r.ensureSpace(lineH)
+		indent := float64(h.level-1) * pdfIndent
+		link := r.link(h.id)
+		page := ""
+		if r.tocPages != nil {
+			page = strconv.Itoa(r.tocPages[h.id])
+		}
+		title := r.truncate(plainText(h.inlines), r.right()-pdfMargin-indent-numW-dotW*4)
+		titleW := pdf.GetStringWidth(title)
+		pdf.SetX(pdfMargin + indent)
+		r.setColor(pdfTextColor)
+		pdf.CellFormat(titleW, lineH, title, "", 0, "L", false, link, "")
+		dotsW := r.right() - numW - pdf.GetX()
+		r.setColor(pdfMutedColor)
+		pdf.CellFormat(dotsW, lineH, strings.Repeat(".", max(int(dotsW/dotW)-2, 0)), "", 0, "R", false, link, "")
+		r.setColor(pdfTextColor)
+		pdf.CellFormat(numW, lineH, page, "", 1, "R", false, link, "")
+	}
+}
+
+// truncate 截断到指定宽度，超出时以省略号结尾
+func (r *pdfWriter) truncate(s string, width float64) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r.pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && r.pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func (r *pdfWriter) blocks(blocks []*block) {
+	for _, b := range blocks {
+		r.block(b, "")
+	}
+}
+
+// block marker 为列表项的项目符号，画在缩进左侧
+func (r *pdfWriter) block(b *block, marker string) {
+	pdf := r.pdf
+	if marker != "" {
+		lineH := lineHeight(pdfFontSize)
+		r.ensureSpace(lineH)
+		pdf.SetFont(pdfFont, "", pdfFontSize)
+		r.setColor(r.color)
+		pdf.SetX(r.left - pdfIndent)
+		pdf.CellFormat(pdfIndent-1.5, lineH, marker, "", 0, "R", false, 0, "")
+		pdf.SetX(r.left)
+		if b.kind != blockParagraph {
+			pdf.Ln(lineH)
+		}
+	}
+	switch b.kind {
+	case blockHeading:
+		r.heading(b)
+	case blockParagraph:
+		r.inlines(b.inlines, pdfFontSize, false)
+		pdf.Ln(lineHeight(pdfFontSize) + 1.5)
+	case blockCode:
+		r.code(b)
+	case blockImage:
+		r.image(b)
+	case blockRule:
+		r.ensureSpace(6)
+		y := pdf.GetY() + 3
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.SetLineWidth(0.3)
+		pdf.Line(r.left, y, r.right(), y)
+		pdf.SetY(y + 3)
+	case blockQuote:
+		left, color := r.left, r.color
+		startPage, startY := pdf.PageNo(), pdf.GetY()
+		r.color = pdfQuoteColor
+		r.setLeft(left + pdfIndent)
+		r.blocks(b.children)
+		r.setLeft(left)
+		r.color = color
+		if pdf.PageNo() == startPage {
+			pdf.SetDrawColor(217, 217, 217)
+			pdf.SetLineWidth(0.8)
+			pdf.Line(left+2, startY, left+2, pdf.GetY()-1.5)
+		}
+	case blockList:
+		left := r.left
+		r.setLeft(left + pdfIndent)
+		for i, item := range b.items {
+			itemMarker := "•"
+			if b.ordered {
+				itemMarker = strconv.Itoa(b.start+i) + "."
+			}
+			for j, child := range item {
+				if j == 0 {
+					r.block(child, itemMarker)
+				} else {
+					r.block(child, "")
+				}
+			}
+		}
+		r.setLeft(left)
+	case blockTable:
+		r.table(b)
+	}
+}
+
+func (r *pdfWriter) heading(b *block) {
+	pdf := r.pdf
+	size := pdfHeadingSizes[b.level-1]
+	lineH := lineHeight(size)
+	// 标题和至少两行正文在同一页
+	r.ensureSpace(lineH + lineHeight(pdfFontSize)*2 + size*0.3)
+	if pdf.GetY() > pdfMargin+1 {
+		pdf.SetY(pdf.GetY() + size*0.3)
+	}
+	pdf.SetX(r.left)
+	pdf.SetLink(r.link(b.id), -1, -1)
+	r.pages[b.id] = pdf.PageNo()
+	pdf.SetFont(pdfFont, "", size)
+	level := min(b.level-1, r.lastLevel+1)
+	r.lastLevel = level
+	pdf.Bookmark(plainText(b.inlines), level, -1)
+	r.inlines(b.inlines, size, true)
+	pdf.Ln(lineH + 1)
+}
+
+// inlines 按词排版行内内容，中日韩文字可在任意字符间换行
+func (r *pdfWriter) inlines(inlines []inline, size float64, bold bool) {
+	pdf := r.pdf
+	lineH := lineHeight(size)
+	right := r.right()
+	for _, in := range inlines {
+		if in.br {
+			pdf.Ln(lineH)
+			continue
+		}
+		style, color := "", r.color
+		linkID, linkStr := 0, ""
+		if in.link != "" {
+			style, color = "U", pdfLinkColor
+			if id, ok := strings.CutPrefix(in.link, "#"); ok {
+				if _, exists := r.links[id]; exists {
+					linkID = r.links[id]
+				}
+			} else {
+				linkStr = in.link
+			}
+		} else if in.code {
+			color = pdfCodeColor
+		}
+		pdf.SetFont(pdfFont, style, size)
+		r.setColor(color)
+		r.setBold(bold || in.bold, size)
+
+		var run strings.Builder
+		runW := 0.0
+		flush := func() {
+			if run.Len() > 0 {
+				pdf.CellFormat(runW, lineH, run.String(), "", 0, "L", false, linkID, linkStr)
+				run.Reset()
+				runW = 0
+			}
+		}
+		var place func(tok string)
+		place = func(tok string) {
+			w := pdf.GetStringWidth(tok)
+			if x := pdf.GetX() + runW; x+w > right+0.01 && x > r.left+0.01 {
+				flush()
+				pdf.Ln(lineH)
+				if tok == " " {
+					return
+				}
+			}
+			if w > right-r.left && len([]rune(tok)) > 1 {
+				// 超过一行的单词按字符拆开
+				for _, c := range tok {
+					place(string(c))
+				}
+				return
+			}
+			run.WriteString(tok)
+			runW += w
+		}
+		for _, tok := range tokenize(in.text) {
+			place(tok)
+		}
+		flush()
+	}
+	r.setBold(false, size)
+	r.setColor(r.color)
+}
+
+// tokenize 切分为可换行的片段：连续的非空白字符、单个空格、单个中日韩字符
+func tokenize(s string) []string {
+	var tokens []string
+	start := -1
+	for i, c := range s {
+		if c == ' ' || isWideRune(c) {
+			if start >= 0 {
+				tokens = append(tokens, s[start:i])
+				start = -1
+			}
+			tokens = append(tokens, string(c))
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+func isWideRune(c rune) bool {
+	return unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(c >= 0x3000 && c <= 0x303f) || (c >= 0xff00 && c <= 0xffef)
+}
+
+func (r *pdfWriter) code(b *block) {
+	pdf := r.pdf
+	size := pdfFontSize * 0.9
+	pdf.SetFont(pdfFont, "", size)
+	r.setColor(pdfTextColor)
+	pdf.SetFillColor(245, 245, 245)
+	pdf.SetCellMargin(2)
+	pdf.SetX(r.left)
+	pdf.MultiCell(r.right()-r.left, lineHeight(size)*0.85, strings.ReplaceAll(b.text, "\t", "    "), "", "L", true)
+	pdf.SetCellMargin(0)
+	pdf.Ln(2)
+}
+
+func (r *pdfWriter) table(b *block) {
+	pdf := r.pdf
+	cols := 0
+	for _, row := range b.rows {
+		cols = max(cols, len(row))
+	}
+	const pad = 1.5
+	size := pdfFontSize * 0.9
+	lineH := lineHeight(size) * 0.85
+	colW := (r.right() - r.left) / float64(cols)
+	maxLines := int((r.bottom() - pdfMargin - 2*pad) / lineH)
+	pdf.SetFont(pdfFont, "", size)
+	pdf.SetLineWidth(0.2)
+	for _, row := range b.rows {
+		lines := make([][]string, cols)
+		rowLines := 1
+		for i := range cols {
+			if i < len(row) {
+				for _, part := range strings.Split(plainText(row[i].inlines), "\n") {
+					lines[i] = append(lines[i], pdf.SplitText(part, colW-2*pad)...)
+				}
+			}
+			lines[i] = lines[i][:min(len(lines[i]), maxLines)]
+			rowLines = max(rowLines, len(lines[i]))
+		}
+		rowH := float64(rowLines)*lineH + 2*pad
+		r.ensureSpace(rowH)
+		y := pdf.GetY()
+		for i := range cols {
+			x := r.left + float64(i)*colW
+			header := i < len(row) && row[i].header
+			pdf.SetDrawColor(191, 191, 191)
+			if header {
+				pdf.SetFillColor(242, 242, 242)
+				pdf.Rect(x, y, colW, rowH, "DF")
+			} else {
+				pdf.Rect(x, y, colW, rowH, "D")
+			}
+			r.setColor(r.color)
+			r.setBold(header, size)
+			for k, line := range lines[i] {
+				pdf.SetXY(x+pad, y+pad+float64(k)*lineH)
+				pdf.CellFormat(colW-2*pad, lineH, line, "", 0, "L", false, 0, "")
+			}
+			r.setBold(false, size)
+		}
+		pdf.SetXY(r.left, y+rowH)
+	}
+	pdf.Ln(3)
+}
+
+func (r *pdfWriter) image(b *block) {
+	pdf := r.pdf
+	name, opts, ok := r.registerImage(b.image)
+	if !ok {
+		// 无法嵌入的图片输出说明文字和原地址
+		r.inlines([]inline{{text: b.alt + " "}, {text: b.src, link: b.src}}, pdfFontSize, false)
+		pdf.Ln(lineHeight(pdfFontSize) + 1.5)
+		return
+	}
+	w, h := float64(b.image.width)*pdfPxToMM, float64(b.image.height)*pdfPxToMM
+	if maxW := r.right() - r.left; w > maxW {
+		w, h = maxW, h*maxW/w
+	}
+	if maxH := r.bottom() - pdfMargin; h > maxH {
+		w, h = w*maxH/h, maxH
+	}
+	r.ensureSpace(h)
+	y := pdf.GetY()
+	pdf.ImageOptions(name, r.left, y, w, h, false, opts, 0, "")
+	pdf.SetXY(r.left, y+h+2)
+}
+
+// registerImage JPEG 直接嵌入，其他格式统一转为 8 位 PNG，避免 fpdf 不支持的 PNG 变体
+func (r *pdfWriter) registerImage(img *Image) (string, fpdf.ImageOptions, bool) {
+	if img == nil || img.width == 0 {
+		return "", fpdf.ImageOptions{}, false
+	}
+	if opts, ok := r.images[img]; ok {
+		return img.name, opts, opts.ImageType != ""
+	}
+	data, opts := img.Data, fpdf.ImageOptions{ImageType: "JPG"}
+	if img.format != "jpeg" {
+		var err error
+		if data, err = img.toPNG(); err != nil {
+			r.images[img] = fpdf.ImageOptions{}
+			return "", fpdf.ImageOptions{}, false
+		}
+		opts.ImageType = "PNG"
+	}
+	r.pdf.RegisterImageOptionsReader(img.name, opts, bytes.NewReader(data))
+	r.images[img] = opts
+	return img.name, opts, true
+}
@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxPages 单个文件最多提取的页数
+	MaxPages = 1000
+	// MaxTextSize 单个文件最多提取的文本字节数
+	MaxTextSize = 4 << 20
+)
+
+var ErrUnsupported = errors.New("unsupported file type")
+
+// Page 文件中的一页文本，Number 从 1 开始，为 0 时表示整个文件不分页；
+// Name 为工作表名等页的名称；Scanned 表示该页没有文本层（如扫描件），需要 OCR 才能提取
+type Page struct {
+	Number  int
+	Name    string
+	Text    string
+	Scanned bool
+}
+
+var textExts = map[string]bool{
+	".txt":  true,
+	".md":   true,
+	".csv":  true,
+	".tsv":  true,
+	".json": true,
+	".log":  true,
+	".xml":  true,
+	".yaml": true,
+	".yml":  true,
+}
+
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".webp": true,
+	".bmp":  true,
+	".gif":  true,
+}
+
+// IsSupported 是否支持直接提取文本
+func IsSupported(filename string) bool {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".pdf", ".docx", ".xlsx", ".pptx":
+		return true
+	default:
+		return textExts[ext]
+	}
+}
+
+// IsImage 图片需要通过视觉模型识别文字
+func IsImage(filename string) bool {
+	return imageExts[strings.ToLower(filepath.Ext(filename))]
+}
+
+// Extract 按扩展名提取文件文本，返回非空的页和需要 OCR 的页
+func Extract(filename string, data []byte) (pages []Page, err error) {
+	// 第三方解析库遇到损坏的文件可能 panic
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("extract %s failed: %v", filename, r)
+		}
+	}()
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".pdf":
+		pages, err = extractPDF(data)
+	case ".docx":
+		pages, err = extractDOCX(data)
+	case ".xlsx":
+		pages, err = extractXLSX(data)
+	case ".pptx":
+		pages, err = extractPPTX(data)
+	default:
+		if !textExts[ext] {
+			return nil, ErrUnsupported
+		}
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%s is not utf-8 text", filename)
+		}
+		pages = []Page{{Text: string(data)}}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limitPages(pages), nil
+}
+
+// limitPages 去掉空白页并限制页数和总文本大小，保留没有文本层的页
+func limitPages(pages []Page) []Page {
+	result := make([]Page, 0, len(pages))
+	size := 0
+	for _, page := range pages {
+		page.Text = strings.TrimSpace(page.Text)
+		if page.Text == "" && !page.Scanned {
+			continue
+		}
+		if len(result) >= MaxPages || size+len(page.Text) > MaxTextSize {
+			break
+		}
+		size += len(page.Text)
+		result = append(result, page)
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupported(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"a.pdf", true},
+		{"a.DOCX", true},
+		{"a.xlsx", true},
+		{"a.pptx", true},
+		{"a.md", true},
+		{"a.Csv", true},
+		{"a.doc", false},
+		{"a.png", false},
+		{"noext", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsSupported(tt.filename))
+		})
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected bool
+	}{
+		{"a.png", true},
+		{"a.JPG", true},
+		{"a.webp", true},
+		{"a.svg", false},
+		{"a.pdf", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsImage(tt.filename))
+		})
+	}
+}
+
+func TestExtract_Text(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		expected []Page
+		wantErr  bool
+	}{
+		{"markdown", "a.md", []byte("# title\n\nbody\n"), []Page{{Text: "# title\n\nbody"}}, false},
+		{"upper case ext", "a.TXT", []byte("hello"), []Page{{Text: "hello"}}, false},
+		{"blank file", "a.txt", []byte(" \n\t"), []Page{}, false},
+		{"invalid utf-8", "a.txt", []byte{0xff, 0xfe, 0x00}, nil, true},
+		{"unsupported", "a.exe", []byte("MZ"), nil, true},
+		{"broken pdf", "a.pdf", []byte("not a pdf"), nil, true},
+		{"broken docx", "a.docx", []byte("not a zip"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := Extract(tt.filename, tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pages)
+		})
+	}
+}
+
+func TestExtract_Unsupported(t *testing.T) {
+	_, err := Extract("a.exe", nil)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestLimitPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    []Page
+		expected []Page
+	}{
+		{"empty", nil, []Page{}},
+		{
+			"trim and skip blank pages",
+			[]Page{{Number: 1, Text: " a "}, {Number: 2, Text: "\n"}, {Number: 3, Text: "b"}},
+			[]Page{{Number: 1, Text: "a"}, {Number: 3, Text: "b"}},
+		},
+		{
+			"keep scanned pages",
+			[]Page{{Number: 1, Text: "a"}, {Number: 2, Scanned: true}, {Number: 3, Text: " ", Scanned: true}},
+			[]Page{{Number: 1, Text: "a"}, {Number: 2, Scanned: true}, {Number: 3, Scanned: true}},
+		},
+		{
+			"stop at text size limit",
+			[]Page{{Number: 1, Text: "a"}, {Number: 2, Text: strings.Repeat("b", MaxTextSize)}, {Number: 3, Text: "c"}},
+			[]Page{{Number: 1, Text: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, limitPages(tt.pages))
+		})
+	}
+}
+
+func TestLimitPages_MaxPages(t *testing.T) {
+	pages := make([]Page, MaxPages+10)
+	for i := range pages {
+		pages[i] = Page{Number: i + 1, Text: "x"}
+	}
+	result := limitPages(pages)
+	assert.Len(t, result, MaxPages)
+	assert.Equal(t, MaxPages, result[len(result)-1].Number)
+}
@@ -0,0 +1,403 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 单个 xml 部件的大小上限，防止压缩炸弹
+const maxPartSize = 64 << 20
+
+func openZip(data []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open office file failed: %w", err)
+	}
+	return zr, nil
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf, err := io.ReadAll(io.LimitReader(f, maxPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > maxPartSize {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return buf, nil
+}
+
+// relTargets 解析 _rels 中 Id 到部件路径的映射
+func relTargets(zr *zip.Reader, relsName, baseDir string) (map[string]string, error) {
+	buf, err := readZipFile(zr, relsName)
+	if err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(buf, &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join(baseDir, rel.Target)
+		}
+	}
+	return targets, nil
+}
+
+func relID(attrs []xml.Attr) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == "id" && strings.Contains(attr.Name.Space, "relationships") {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func attrValue(attrs []xml.Attr, local string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// walkXML 遍历 xml，跳过 mc:Fallback 中重复的兼容内容
+func walkXML(data []byte, fn func(tok xml.Token)) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	fallback := 0
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Fallback" {
+				fallback++
+			}
+		case xml.EndElement:
+			if t.Name.Local == "Fallback" {
+				fallback--
+				continue
+			}
+		}
+		if fallback == 0 {
+			fn(xml.CopyToken(tok))
+		}
+	}
+}
+
+// paragraphText 提取 <a:t>/<w:t> 文本，段落之间换行
+func paragraphText(data []byte, textElem string) (string, error) {
+	var sb strings.Builder
+	inText := false
+	err := walkXML(data, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case textElem:
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case textElem:
+				inText = false
+			case "p":
+				sb.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	})
+	return sb.String(), err
+}
+
+// extractDOCX Word 不保存分页结果，优先使用上次排版记录的分页符，
+// 没有时使用手动分页符，都没有时不分页
+func extractDOCX(data []byte) ([]Page, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := readZipFile(zr, "word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("read docx document failed: %w", err)
+	}
+
+	var (
+		rendered, manual []Page
+		renderedSB       strings.Builder
+		manualSB         strings.Builder
+		inText           bool
+	)
+	write := func(s string) {
+		renderedSB.WriteString(s)
+		manualSB.WriteString(s)
+	}
+	err = walkXML(buf, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				write("\t")
+			case "br", "cr":
+				if attrValue(t.Attr, "type") == "page" {
+					manual = append(manual, Page{Text: manualSB.String()})
+					manualSB.Reset()
+				} else {
+					write("\n")
+				}
+			case "lastRenderedPageBreak":
+				rendered = append(rendered, Page{Text: renderedSB.String()})
+				renderedSB.Reset()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				write("\n")
+			}
+		case xml.CharData:
+			if inText {
+				write(string(t))
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse docx document failed: %w", err)
+	}
+
+	pages := manual
+	last := manualSB.String()
+	if len(rendered) > 0 {
+		pages, last = rendered, renderedSB.String()
+	}
+	if len(pages) == 0 {
+		return []Page{{Text: last}}, nil
+	}
+	pages = append(pages, Page{Text: last})
+	for i := range pages {
+		pages[i].Number = i + 1
+	}
+	return pages, nil
+}
+
+// extractPPTX 每张幻灯片作为一页
+func extractPPTX(data []byte) ([]Page, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := relTargets(zr, "ppt/_rels/presentation.xml.rels", "ppt")
+	if err != nil {
+		return nil, fmt.Errorf("read pptx relationships failed: %w", err)
+	}
+	buf, err := readZipFile(zr, "ppt/presentation.xml")
+	if err != nil {
+		return nil, fmt.Errorf("read pptx presentation failed: %w", err)
+	}
+
+	// 按 sldIdLst 中的顺序读取幻灯片
+	var slides []string
+	if err := walkXML(buf, func(tok xml.Token) {
+		if t, ok := tok.(xml.StartElement); ok && t.Name.Local == "sldId" {
+			if target, ok := targets[relID(t.Attr)]; ok {
+				slides = append(slides, target)
+			}
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("parse pptx presentation failed: %w", err)
+	}
+
+	pages := make([]Page, 0, len(slides))
+	for i, slide := range slides {
+		if i >= MaxPages {
+			break
+		}
+		buf, err := readZipFile(zr, slide)
+		if err != nil {
+			return nil, fmt.Errorf("read pptx slide failed: %w", err)
+		}
+		text, err := paragraphText(buf, "t")
+		if err != nil {
+			return nil, fmt.Errorf("parse pptx slide failed: %w", err)
+		}
+		pages = append(pages, Page{Number: i + 1, Text: text})
+	}
+	return pages, nil
+}
+
+// extractXLSX 每个工作表作为一页，单元格以制表符分隔
+func extractXLSX(data []byte) ([]Page, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := relTargets(zr, "xl/_rels/workbook.xml.rels", "xl")
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx relationships failed: %w", err)
+	}
+	sharedStrings, err := readSharedStrings(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx shared strings failed: %w", err)
+	}
+	buf, err := readZipFile(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx workbook failed: %w", err)
+	}
+
+	type sheet struct {
+		name   string
+		target string
+	}
+	var sheets []sheet
+	if err := walkXML(buf, func(tok xml.Token) {
+		if t, ok := tok.(xml.StartElement); ok && t.Name.Local == "sheet" {
+			if target, ok := targets[relID(t.Attr)]; ok {
+				sheets = append(sheets, sheet{name: attrValue(t.Attr, "name"), target: target})
+			}
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("parse xlsx workbook failed: %w", err)
+	}
+
+	pages := make([]Page, 0, len(sheets))
+	for i, s := range sheets {
+		buf, err := readZipFile(zr, s.target)
+		if err != nil {
+			return nil, fmt.Errorf("read xlsx sheet failed: %w", err)
+		}
+		text, err := sheetText(buf, sharedStrings)
+		if err != nil {
+			return nil, fmt.Errorf("parse xlsx sheet failed: %w", err)
+		}
+		pages = append(pages, Page{Number: i + 1, Name: s.name, Text: text})
+	}
+	return pages, nil
+}
+
+func readSharedStrings(zr *zip.Reader) ([]string, error) {
+	buf, err := readZipFile(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		// 没有文本单元格时不存在该文件
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var (
+		result []string
+		sb     strings.Builder
+		inText bool
+		inPh   bool
+	)
+	err = walkXML(buf, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "rPh": // 注音
+				inPh = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "rPh":
+				inPh = false
+			case "si":
+				result = append(result, sb.String())
+				sb.Reset()
+			}
+		case xml.CharData:
+			if inText && !inPh {
+				sb.Write(t)
+			}
+		}
+	})
+	return result, err
+}
+
+func sheetText(data []byte, sharedStrings []string) (string, error) {
+	var (
+		sb       strings.Builder
+		row      []string
+		cellType string
+		value    strings.Builder
+		inValue  bool
+	)
+	err := walkXML(data, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "c":
+				cellType = attrValue(t.Attr, "t")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				if cellType == "s" {
+					if idx, err := strconv.Atoi(v); err == nil && idx >= 0 && idx < len(sharedStrings) {
+						v = sharedStrings[idx]
+					}
+				}
+				if v = strings.TrimSpace(v); v != "" {
+					row = append(row, v)
+				}
+			case "row":
+				if len(row) > 0 {
+					sb.WriteString(strings.Join(row, "\t"))
+					sb.WriteString("\n")
+				}
+				row = row[:0]
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	})
+	return sb.String(), err
+}
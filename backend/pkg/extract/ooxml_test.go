@@ -0,0 +1,148 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nsW = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	nsA = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+	nsR = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	nsS = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func docx(t *testing.T, body string) []byte {
+	return buildZip(t, map[string]string{
+		"word/document.xml": `<w:document ` + nsW + `><w:body>` + body + `</w:body></w:document>`,
+	})
+}
+
+func TestExtractDOCX(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []Page
+	}{
+		{
+			"no page break",
+			`<w:p><w:r><w:t>hello</w:t><w:tab/><w:t>world</w:t></w:r></w:p><w:p><w:r><w:t>second</w:t></w:r></w:p>`,
+			[]Page{{Text: "hello\tworld\nsecond\n"}},
+		},
+		{
+			"manual page break",
+			`<w:p><w:r><w:t>one</w:t><w:br w:type="page"/><w:t>two</w:t></w:r></w:p>`,
+			[]Page{{Number: 1, Text: "one"}, {Number: 2, Text: "two\n"}},
+		},
+		{
+			"line break",
+			`<w:p><w:r><w:t>a</w:t><w:br/><w:t>b</w:t></w:r></w:p>`,
+			[]Page{{Text: "a\nb\n"}},
+		},
+		{
+			"rendered page break wins over manual",
+			`<w:p><w:r><w:t>one</w:t></w:r><w:r><w:lastRenderedPageBreak/><w:t>two</w:t><w:br w:type="page"/><w:t>three</w:t></w:r></w:p>`,
+			[]Page{{Number: 1, Text: "one"}, {Number: 2, Text: "twothree\n"}},
+		},
+		{
+			"skip compatibility fallback",
+			`<w:p><mc:AlternateContent xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"><mc:Choice><w:r><w:t>new</w:t></w:r></mc:Choice><mc:Fallback><w:r><w:t>old</w:t></w:r></mc:Fallback></mc:AlternateContent></w:p>`,
+			[]Page{{Text: "new\n"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := extractDOCX(docx(t, tt.body))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pages)
+		})
+	}
+}
+
+func TestExtractDOCX_MissingDocument(t *testing.T) {
+	_, err := extractDOCX(buildZip(t, map[string]string{"word/other.xml": "<a/>"}))
+	assert.Error(t, err)
+}
+
+func TestExtractPPTX(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"ppt/_rels/presentation.xml.rels": `<Relationships>
+			<Relationship Id="rId2" Target="slides/slide1.xml"/>
+			<Relationship Id="rId3" Target="/ppt/slides/slide2.xml"/>
+		</Relationships>`,
+		// 按 sldIdLst 顺序而不是文件名顺序
+		"ppt/presentation.xml": `<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` + nsR + `>
+			<p:sldIdLst><p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId2"/><p:sldId id="258" r:id="rId9"/></p:sldIdLst>
+		</p:presentation>`,
+		"ppt/slides/slide1.xml": `<p:sld xmlns:p="p" ` + nsA + `><a:p><a:r><a:t>first</a:t></a:r></a:p></p:sld>`,
+		"ppt/slides/slide2.xml": `<p:sld xmlns:p="p" ` + nsA + `><a:p><a:r><a:t>title</a:t></a:r></a:p><a:p><a:r><a:t>body</a:t></a:r></a:p></p:sld>`,
+	})
+
+	pages, err := extractPPTX(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Page{
+		{Number: 1, Text: "title\nbody\n"},
+		{Number: 2, Text: "first\n"},
+	}, pages)
+}
+
+func TestExtractXLSX(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+			<Relationship Id="rId2" Target="worksheets/sheet2.xml"/>
+		</Relationships>`,
+		"xl/workbook.xml": `<workbook ` + nsS + ` ` + nsR + `><sheets>
+			<sheet name="数据" sheetId="1" r:id="rId1"/>
+			<sheet name="Empty" sheetId="2" r:id="rId2"/>
+		</sheets></workbook>`,
+		"xl/sharedStrings.xml": `<sst ` + nsS + `>
+			<si><t>name</t></si>
+			<si><r><t>漢字</t></r><rPh><t>かんじ</t></rPh></si>
+		</sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet ` + nsS + `><sheetData>
+			<row><c t="s"><v>0</v></c><c><v>42</v></c></row>
+			<row><c t="s"><v>1</v></c><c t="inlineStr"><is><t>inline</t></is></c><c t="s"><v>99</v></c></row>
+			<row><c><v> </v></c></row>
+		</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet ` + nsS + `><sheetData/></worksheet>`,
+	})
+
+	pages, err := extractXLSX(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Page{
+		{Number: 1, Name: "数据", Text: "name\t42\n漢字\tinline\t99\n"},
+		{Number: 2, Name: "Empty", Text: ""},
+	}, pages)
+}
+
+func TestExtractXLSX_NoSharedStrings(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="/xl/worksheets/sheet1.xml"/></Relationships>`,
+		"xl/workbook.xml":            `<workbook ` + nsS + ` ` + nsR + `><sheets><sheet name="S" r:id="rId1"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet ` + nsS + `><sheetData><row><c><v>1</v></c><c><v>2</v></c></row></sheetData></worksheet>`,
+	})
+
+	pages, err := extractXLSX(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Page{{Number: 1, Name: "S", Text: "1\t2\n"}}, pages)
+}
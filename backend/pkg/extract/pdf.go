@@ -0,0 +1,32 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+func extractPDF(data []byte) ([]Page, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open pdf failed: %w", err)
+	}
+
+	numPage := min(reader.NumPage(), MaxPages)
+	pages := make([]Page, 0, numPage)
+	for i := 1; i <= numPage; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			continue
+		}
+		// 扫描件的页面没有文本层，得到空文本
+		pages = append(pages, Page{Number: i, Text: text, Scanned: strings.TrimSpace(text) == ""})
+	}
+	return pages, nil
+}
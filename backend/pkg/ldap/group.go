@@ -0,0 +1,160 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const (
+	pagingSize   = 500
+	memberOfAttr = "memberOf"
+)
+
+type Group struct {
+	DN        string   `json:"dn"`
+	Name      string   `json:"name"`
+	MemberDNs []string `json:"member_dns"` // 成员DN，可能是用户也可能是子分组
+	MemberIDs []string `json:"member_ids"` // posixGroup memberUid 直接记录用户ID
+	ParentDNs []string `json:"parent_dns"` // AD 等目录在分组上维护的 memberOf
+}
+
+type Member struct {
+	DN       string   `json:"dn"`
+	ID       string   `json:"id"`
+	Username string   `json:"username"`
+	GroupDNs []string `json:"group_dns"` // memberOf
+}
+
+// Directory 一次同步读取到的分组和用户
+type Directory struct {
+	Groups  []Group  `json:"groups"`
+	Members []Member `json:"members"`
+}
+
+// NormalizeDN 统一DN格式，便于比较 member/memberOf 中的DN
+func NormalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+	rdns := make([]string, 0, len(parsed.RDNs))
+	for _, rdn := range parsed.RDNs {
+		attrs := make([]string, 0, len(rdn.Attributes))
+		for _, attr := range rdn.Attributes {
+			attrs = append(attrs, strings.ToLower(attr.Type)+"="+strings.ToLower(attr.Value))
+		}
+		rdns = append(rdns, strings.Join(attrs, "+"))
+	}
+	return strings.Join(rdns, ",")
+}
+
+func (c *Client) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(c.config.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to bind with admin credentials: %w", err)
+	}
+	return conn, nil
+}
+
+// ReadDirectory 读取分组基础DN下的全部分组及用户基础DN下的全部用户
+func (c *Client) ReadDirectory() (*Directory, error) {
+	if c.config.GroupBaseDN == "" {
+		return nil, fmt.Errorf("group base DN is required")
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		c.logger.Error("ldap connect failed", log.Error(err))
+		return nil, err
+	}
+	defer conn.Close()
+
+	groupResult, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		c.config.GroupBaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		c.config.GroupFilter,
+		[]string{c.config.GroupNameAttr, c.config.GroupMemberAttr, "uniqueMember", memberOfAttr},
+		nil,
+	), pagingSize)
+	if err != nil {
+		return nil, fmt.Errorf("group search failed: %w", err)
+	}
+	// 不跟随引用，存在引用时读到的分组不完整
+	if len(groupResult.Referrals) > 0 {
+		return nil, fmt.Errorf("group search returned referrals, directory is incomplete: %v", groupResult.Referrals)
+	}
+
+	dir := &Directory{}
+	for _, entry := range groupResult.Entries {
+		group := Group{
+			DN:        entry.DN,
+			Name:      c.getAttributeValue(entry, c.config.GroupNameAttr),
+			ParentDNs: entry.GetAttributeValues(memberOfAttr),
+		}
+		if group.Name == "" {
+			group.Name = entry.DN
+		}
+		members := append(entry.GetAttributeValues(c.config.GroupMemberAttr), entry.GetAttributeValues("uniqueMember")...)
+		for _, member := range members {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			// memberUid 的值不是DN
+			if strings.Contains(member, "=") {
+				group.MemberDNs = append(group.MemberDNs, member)
+			} else {
+				group.MemberIDs = append(group.MemberIDs, member)
+			}
+		}
+		dir.Groups = append(dir.Groups, group)
+	}
+
+	// 用户过滤器中的 %s 替换为通配符以列出全部用户
+	userResult, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		c.config.UserBaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		fmt.Sprintf(c.config.UserFilter, "*"),
+		[]string{c.config.UserIDAttr, c.config.UserNameAttr, memberOfAttr},
+		nil,
+	), pagingSize)
+	if err != nil {
+		return nil, fmt.Errorf("user search failed: %w", err)
+	}
+	if len(userResult.Referrals) > 0 {
+		return nil, fmt.Errorf("user search returned referrals, directory is incomplete: %v", userResult.Referrals)
+	}
+	for _, entry := range userResult.Entries {
+		member := Member{
+			DN:       entry.DN,
+			ID:       c.getAttributeValue(entry, c.config.UserIDAttr),
+			Username: c.getAttributeValue(entry, c.config.UserNameAttr),
+			GroupDNs: entry.GetAttributeValues(memberOfAttr),
+		}
+		if member.ID == "" {
+			continue
+		}
+		dir.Members = append(dir.Members, member)
+	}
+
+	c.logger.Info("ldap directory read",
+		log.Int("groups", len(dir.Groups)),
+		log.Int("members", len(dir.Members)))
+	return dir, nil
+}
@@ -0,0 +1,28 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		dn       string
+		expected string
+	}{
+		{"lower case", "cn=dev,ou=groups,dc=example,dc=com", "cn=dev,ou=groups,dc=example,dc=com"},
+		{"mixed case and spaces", "CN=Dev, OU=Groups , DC=Example,DC=com", "cn=dev,ou=groups,dc=example,dc=com"},
+		{"multi-valued rdn", "CN=a+UID=b,DC=x", "cn=a+uid=b,dc=x"},
+		{"escaped comma", `CN=Smith\, John,DC=x`, "cn=smith, john,dc=x"},
+		{"invalid dn", "  Not A DN  ", "not a dn"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeDN(tt.dn))
+		})
+	}
+}
@@ -25,6 +25,12 @@ type Config struct {
 	UserIDAttr    string `json:"user_id_attr"`    // 用户ID属性，默认 uid
 	UserNameAttr  string `json:"user_name_attr"`  // 用户名属性，默认 cn
 	UserEmailAttr string `json:"user_email_attr"` // 用户邮箱属性，默认 mail
+
+	// 分组同步
+	GroupBaseDN     string `json:"group_base_dn"`     // 分组基础DN，如 ou=Groups,dc=company,dc=com
+	GroupFilter     string `json:"group_filter"`      // 分组查询过滤器
+	GroupNameAttr   string `json:"group_name_attr"`   // 分组名属性，默认 cn
+	GroupMemberAttr string `json:"group_member_attr"` // 成员属性，默认 member，posixGroup 可使用 memberUid
 }
 
 type UserInfo struct {
@@ -35,10 +41,13 @@ type UserInfo struct {
 }
 
 const (
-	defaultUserIDAttr    = "uid"
-	defaultUserNameAttr  = "cn"
-	defaultUserEmailAttr = "mail"
-	defaultUserFilter    = "(&(objectClass=person)(uid=%s))"
+	defaultUserIDAttr      = "uid"
+	defaultUserNameAttr    = "cn"
+	defaultUserEmailAttr   = "mail"
+	defaultUserFilter      = "(&(objectClass=person)(uid=%s))"
+	defaultGroupNameAttr   = "cn"
+	defaultGroupMemberAttr = "member"
+	defaultGroupFilter     = "(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=group)(objectClass=posixGroup))"
 )
 
 // NewClient 创建LDAP客户端
@@ -56,6 +65,15 @@ func NewClient(ctx context.Context, logger *log.Logger, config Config) (*Client,
 	if config.UserFilter == "" {
 		config.UserFilter = defaultUserFilter
 	}
+	if config.GroupNameAttr == "" {
+		config.GroupNameAttr = defaultGroupNameAttr
+	}
+	if config.GroupMemberAttr == "" {
+		config.GroupMemberAttr = defaultGroupMemberAttr
+	}
+	if config.GroupFilter == "" {
+		config.GroupFilter = defaultGroupFilter
+	}
 
 	// 验证必需的配置
 	if config.ServerURL == "" {
@@ -118,7 +136,7 @@ func (c *Client) Authenticate(username, password string) (*UserInfo, error) {
 // searchUser 搜索用户信息
 func (c *Client) searchUser(conn *ldap.Conn, username string) (*UserInfo, error) {
 	// 构建搜索过滤器
-	filter := fmt.Sprintf(c.config.UserFilter, username)
+	filter := fmt.Sprintf(c.config.UserFilter, ldap.EscapeFilter(username))
 
 	// 构建搜索请求
 	searchRequest := ldap.NewSearchRequest(
@@ -0,0 +1,202 @@
+package merge
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxLCSCells 去掉首尾相同部分后，超过该规模的差异不再逐行比较，整体视为修改
+	maxLCSCells = 4_000_000
+
+	MarkerOurs   = "<<<<<<< 你的修改"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> 最新版本"
+)
+
+// Result 三路合并的结果，Conflicts 为 0 时 Text 可以直接保存
+type Result struct {
+	Text      string `json:"text"`
+	Conflicts int    `json:"conflicts"`
+}
+
+// SplitLines 按行切分，每段保留行尾换行符，拼接后与原文一致
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.SplitAfter(s, "\n")
+	if parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+var htmlBlockEnd = regexp.MustCompile(`(?i)(</(p|h[1-6]|li|ul|ol|pre|blockquote|table|tr|div|section|figure)>|<br\s*/?>|<hr\s*/?>|\n)`)
+
+// SplitHTML 按块级标签切分 HTML，编辑器保存的 HTML 常常只有一行
+func SplitHTML(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var parts []string
+	start := 0
+	for _, loc := range htmlBlockEnd.FindAllStringIndex(s, -1) {
+		parts = append(parts, s[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// Merge 以 base 为共同祖先合并 ours 和 theirs，双方都修改的部分用冲突标记包裹
+func Merge(base, ours, theirs string, split func(string) []string) Result {
+	if ours == theirs {
+		return Result{Text: ours}
+	}
+	if ours == base {
+		return Result{Text: theirs}
+	}
+	if theirs == base {
+		return Result{Text: ours}
+	}
+
+	o, a, b := split(base), split(ours), split(theirs)
+	sb := strings.Builder{}
+	conflicts := 0
+	writeRange := func(lines []string) {
+		for _, line := range lines {
+			sb.WriteString(line)
+		}
+	}
+	writeMarker := func(marker string) {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(marker)
+		sb.WriteString("\n")
+	}
+
+	iz, ia, ib := 0, 0, 0
+	for _, r := range syncRegions(o, a, b) {
+		if r.aStart > ia || r.bStart > ib {
+			aChunk, bChunk, oChunk := a[ia:r.aStart], b[ib:r.bStart], o[iz:r.oStart]
+			equalA := slices.Equal(aChunk, oChunk)
+			equalB := slices.Equal(bChunk, oChunk)
+			switch {
+			case slices.Equal(aChunk, bChunk):
+				writeRange(aChunk)
+			case equalA:
+				writeRange(bChunk)
+			case equalB:
+				writeRange(aChunk)
+			default:
+				conflicts++
+				writeMarker(MarkerOurs)
+				writeRange(aChunk)
+				writeMarker(MarkerSep)
+				writeRange(bChunk)
+				writeMarker(MarkerTheirs)
+			}
+		}
+		writeRange(o[r.oStart:r.oEnd])
+		iz, ia, ib = r.oEnd, r.aStart+(r.oEnd-r.oStart), r.bStart+(r.oEnd-r.oStart)
+	}
+	return Result{Text: sb.String(), Conflicts: conflicts}
+}
+
+// region 三方都相同的一段，o/a/b 分别为在 base、ours、theirs 中的起始位置
+type region struct {
+	oStart, oEnd int
+	aStart       int
+	bStart       int
+}
+
+// syncRegions 取 base 与双方匹配块的交集，最后追加一个空的结束块
+func syncRegions(o, a, b []string) []region {
+	am, bm := matchingBlocks(o, a), matchingBlocks(o, b)
+	regions := make([]region, 0)
+	ia, ib := 0, 0
+	for ia < len(am) && ib < len(bm) {
+		x, y := am[ia], bm[ib]
+		start, end := max(x.o, y.o), min(x.o+x.n, y.o+y.n)
+		if start < end {
+			regions = append(regions, region{
+				oStart: start,
+				oEnd:   end,
+				aStart: x.other + start - x.o,
+				bStart: y.other + start - y.o,
+			})
+		}
+		if x.o+x.n < y.o+y.n {
+			ia++
+		} else {
+			ib++
+		}
+	}
+	return append(regions, region{oStart: len(o), oEnd: len(o), aStart: len(a), bStart: len(b)})
+}
+
+type block struct {
+	o, other, n int
+}
+
+// matchingBlocks 基于最长公共子序列返回连续相同的块
+func matchingBlocks(o, x []string) []block {
+	prefix := 0
+	for prefix < len(o) && prefix < len(x) && o[prefix] == x[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(o)-prefix && suffix < len(x)-prefix && o[len(o)-1-suffix] == x[len(x)-1-suffix] {
+		suffix++
+	}
+
+	blocks := make([]block, 0)
+	add := func(oi, xi int) {
+		if n := len(blocks); n > 0 && blocks[n-1].o+blocks[n-1].n == oi && blocks[n-1].other+blocks[n-1].n == xi {
+			blocks[n-1].n++
+			return
+		}
+		blocks = append(blocks, block{o: oi, other: xi, n: 1})
+	}
+	for i := 0; i < prefix; i++ {
+		add(i, i)
+	}
+
+	mo, mx := o[prefix:len(o)-suffix], x[prefix:len(x)-suffix]
+	if len(mo) > 0 && len(mx) > 0 && len(mo)*len(mx) <= maxLCSCells {
+		// lcs[i][j] 为 mo[i:] 与 mx[j:] 的最长公共子序列长度
+		cols := len(mx) + 1
+		lcs := make([]int32, (len(mo)+1)*cols)
+		for i := len(mo) - 1; i >= 0; i-- {
+			for j := len(mx) - 1; j >= 0; j-- {
+				if mo[i] == mx[j] {
+					lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+				} else {
+					lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < len(mo) && j < len(mx); {
+			switch {
+			case mo[i] == mx[j]:
+				add(prefix+i, prefix+j)
+				i++
+				j++
+			case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		add(len(o)-suffix+i, len(x)-suffix+i)
+	}
+	return blocks
+}
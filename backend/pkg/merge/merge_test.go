@@ -0,0 +1,198 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"single line without newline", "a", []string{"a"}},
+		{"trailing newline", "a\nb\n", []string{"a\n", "b\n"}},
+		{"no trailing newline", "a\nb", []string{"a\n", "b"}},
+		{"blank lines", "\n\n", []string{"\n", "\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := SplitLines(tt.input)
+			assert.Equal(t, tt.expected, lines)
+			assert.Equal(t, tt.input, strings.Join(lines, ""))
+		})
+	}
+}
+
+func TestSplitHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"plain text", "hello", []string{"hello"}},
+		{"paragraphs", "<p>a</p><p>b</p>", []string{"<p>a</p>", "<p>b</p>"}},
+		{"case insensitive", "<P>a</P><H2>b</H2>", []string{"<P>a</P>", "<H2>b</H2>"}},
+		{"line breaks", "a<br>b<br/>c<hr />d", []string{"a<br>", "b<br/>", "c<hr />", "d"}},
+		{"inline tags kept", "<p><strong>a</strong> b</p>", []string{"<p><strong>a</strong> b</p>"}},
+		{"list items", "<ul><li>a</li><li>b</li></ul>", []string{"<ul><li>a</li>", "<li>b</li>", "</ul>"}},
+		{"newline", "<p>a</p>\n<p>b</p>", []string{"<p>a</p>", "\n", "<p>b</p>"}},
+		{"trailing text", "<p>a</p>tail", []string{"<p>a</p>", "tail"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := SplitHTML(tt.input)
+			assert.Equal(t, tt.expected, parts)
+			assert.Equal(t, tt.input, strings.Join(parts, ""))
+		})
+	}
+}
+
+func conflict(ours, theirs string) string {
+	return MarkerOurs + "\n" + ours + MarkerSep + "\n" + theirs + MarkerTheirs + "\n"
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		expected  string
+		conflicts int
+	}{
+		{"no change", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"only ours changed", "a\nb\n", "a\nB\n", "a\nb\n", "a\nB\n", 0},
+		{"only theirs changed", "a\nb\n", "a\nb\n", "A\nb\n", "A\nb\n", 0},
+		{"same change on both sides", "a\nb\n", "a\nX\n", "a\nX\n", "a\nX\n", 0},
+		{
+			"different lines changed",
+			"1\n2\n3\n4\n5\n",
+			"1\nTWO\n3\n4\n5\n",
+			"1\n2\n3\nFOUR\n5\n",
+			"1\nTWO\n3\nFOUR\n5\n",
+			0,
+		},
+		{
+			"insert and delete",
+			"a\nb\nc\n",
+			"a\nb\nb2\nc\n",
+			"b\nc\n",
+			"b\nb2\nc\n",
+			0,
+		},
+		{
+			"same line changed differently",
+			"a\nb\nc\n",
+			"a\nours\nc\n",
+			"a\ntheirs\nc\n",
+			"a\n" + conflict("ours\n", "theirs\n") + "c\n",
+			1,
+		},
+		{
+			"two conflicts",
+			"1\n2\n3\n4\n5\n",
+			"1\nA\n3\nC\n5\n",
+			"1\nB\n3\nD\n5\n",
+			"1\n" + conflict("A\n", "B\n") + "3\n" + conflict("C\n", "D\n") + "5\n",
+			2,
+		},
+		{
+			"both append at end",
+			"a\n",
+			"a\nours\n",
+			"a\ntheirs\n",
+			"a\n" + conflict("ours\n", "theirs\n"),
+			1,
+		},
+		{
+			"conflict without trailing newline",
+			"a\nb",
+			"a\nours",
+			"a\ntheirs",
+			"a\n" + conflict("ours\n", "theirs\n"),
+			1,
+		},
+		{
+			"empty base",
+			"",
+			"ours\n",
+			"theirs\n",
+			conflict("ours\n", "theirs\n"),
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge(tt.base, tt.ours, tt.theirs, SplitLines)
+			assert.Equal(t, tt.expected, result.Text)
+			assert.Equal(t, tt.conflicts, result.Conflicts)
+		})
+	}
+}
+
+func TestMerge_HTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		expected  string
+		conflicts int
+	}{
+		{
+			"different paragraphs",
+			"<p>a</p><p>b</p><p>c</p>",
+			"<p>A</p><p>b</p><p>c</p>",
+			"<p>a</p><p>b</p><p>C</p>",
+			"<p>A</p><p>b</p><p>C</p>",
+			0,
+		},
+		{
+			"same paragraph",
+			"<p>a</p><p>b</p>",
+			"<p>a</p><p>ours</p>",
+			"<p>a</p><p>theirs</p>",
+			"<p>a</p>\n" + conflict("<p>ours</p>\n", "<p>theirs</p>\n"),
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge(tt.base, tt.ours, tt.theirs, SplitHTML)
+			assert.Equal(t, tt.expected, result.Text)
+			assert.Equal(t, tt.conflicts, result.Conflicts)
+		})
+	}
+}
+
+func TestMatchingBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		o        []string
+		x        []string
+		expected []block
+	}{
+		{"both empty", nil, nil, []block{}},
+		{"identical", []string{"a", "b"}, []string{"a", "b"}, []block{{0, 0, 2}}},
+		{"nothing common", []string{"a"}, []string{"b"}, []block{}},
+		{"insert in middle", []string{"a", "c"}, []string{"a", "b", "c"}, []block{{0, 0, 1}, {1, 2, 1}}},
+		{"delete at start", []string{"a", "b", "c"}, []string{"b", "c"}, []block{{1, 0, 2}}},
+		{"lcs in middle", []string{"a", "x", "y", "b"}, []string{"a", "y", "z", "b"}, []block{{0, 0, 1}, {2, 1, 1}, {3, 3, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchingBlocks(tt.o, tt.x))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	body := strings.Repeat("<p>统计</p>", 20)
+	msg := string(buildMessage("wiki@example.com", []string{"a@example.com", "b@example.com"}, "知识库周报", body))
+
+	header, encoded, ok := strings.Cut(msg, "\r\n\r\n")
+	require.True(t, ok)
+	assert.Contains(t, header, "From: wiki@example.com\r\n")
+	assert.Contains(t, header, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, header, "Subject: =?UTF-8?b?")
+	assert.Contains(t, header, "Content-Transfer-Encoding: base64")
+
+	lines := strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n")
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// WebhookType 群机器人类型
+type WebhookType string
+
+const (
+	WebhookTypeDingTalk WebhookType = "dingtalk"
+	WebhookTypeFeishu   WebhookType = "feishu"
+	WebhookTypeWeCom    WebhookType = "wecom"
+)
+
+// Webhook 群机器人 webhook，Secret 为钉钉/飞书的加签密钥，可为空
+type Webhook struct {
+	Type   WebhookType
+	URL    string
+	Secret string
+}
+
+type webhookResp struct {
+	// 钉钉、企业微信
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	// 飞书
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// SendMarkdown 通过群机器人发送 markdown 消息
+func SendMarkdown(ctx context.Context, hook Webhook, title, content string) error {
+	var (
+		body    any
+		hookURL = hook.URL
+	)
+	switch hook.Type {
+	case WebhookTypeDingTalk:
+		// https://open.dingtalk.com/document/robots/custom-robot-access
+		if hook.Secret != "" {
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			sign := hmacSign(hook.Secret, timestamp+"\n"+hook.Secret)
+			u, err := url.Parse(hook.URL)
+			if err != nil {
+				return fmt.Errorf("parse webhook url failed: %w", err)
+			}
+			q := u.Query()
+			q.Set("timestamp", timestamp)
+			q.Set("sign", sign)
+			u.RawQuery = q.Encode()
+			hookURL = u.String()
+		}
+		body = map[string]any{
+			"msgtype": "markdown",
+			"markdown": map[string]string{
+				"title": title,
+				"text":  content,
+			},
+		}
+	case WebhookTypeFeishu:
+		// https://open.feishu.cn/document/client-docs/bot-v3/add-custom-bot
+		msg := map[string]any{
+			"msg_type": "interactive",
+			"card": map[string]any{
+				"header": map[string]any{
+					"title": map[string]string{"tag": "plain_text", "content": title},
+				},
+				"elements": []map[string]string{
+					{"tag": "markdown", "content": content},
+				},
+			},
+		}
+		if hook.Secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			msg["timestamp"] = timestamp
+			msg["sign"] = hmacSign(timestamp+"\n"+hook.Secret, "")
+		}
+		body = msg
+	case WebhookTypeWeCom:
+		// https://developer.work.weixin.qq.com/document/path/91770
+		body = map[string]any{
+			"msgtype": "markdown",
+			"markdown": map[string]string{
+				"content": content,
+			},
+		}
+	default:
+		return fmt.Errorf("unsupported webhook type: %s", hook.Type)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook response status %d: %s", resp.StatusCode, string(respBody))
+	}
+	var result webhookResp
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("unmarshal webhook response failed: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("webhook error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("webhook error %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+func hmacSign(key, data string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
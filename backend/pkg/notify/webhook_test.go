@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRequest struct {
+	query url.Values
+	body  map[string]any
+}
+
+func newWebhookServer(t *testing.T, status int, resp string) (*httptest.Server, *webhookRequest) {
+	t.Helper()
+	got := &webhookRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.query = r.URL.Query()
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got.body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestSendMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		hookType WebhookType
+		secret   string
+		check    func(t *testing.T, got *webhookRequest)
+	}{
+		{
+			name:     "dingtalk",
+			hookType: WebhookTypeDingTalk,
+			check: func(t *testing.T, got *webhookRequest) {
+				assert.Equal(t, "markdown", got.body["msgtype"])
+				assert.Equal(t, map[string]any{"title": "周报", "text": "**内容**"}, got.body["markdown"])
+				assert.Empty(t, got.query.Get("sign"))
+			},
+		},
+		{
+			name:     "dingtalk signed",
+			hookType: WebhookTypeDingTalk,
+			secret:   "SEC123",
+			check: func(t *testing.T, got *webhookRequest) {
+				timestamp := got.query.Get("timestamp")
+				require.NotEmpty(t, timestamp)
+				assert.Equal(t, hmacSign("SEC123", timestamp+"\nSEC123"), got.query.Get("sign"))
+				assert.Equal(t, "keep", got.query.Get("access_token"))
+			},
+		},
+		{
+			name:     "feishu signed",
+			hookType: WebhookTypeFeishu,
+			secret:   "SEC123",
+			check: func(t *testing.T, got *webhookRequest) {
+				assert.Equal(t, "interactive", got.body["msg_type"])
+				timestamp, _ := got.body["timestamp"].(string)
+				require.NotEmpty(t, timestamp)
+				assert.Equal(t, hmacSign(timestamp+"\nSEC123", ""), got.body["sign"])
+				card := got.body["card"].(map[string]any)
+				elements := card["elements"].([]any)
+				assert.Equal(t, map[string]any{"tag": "markdown", "content": "**内容**"}, elements[0])
+			},
+		},
+		{
+			name:     "wecom",
+			hookType: WebhookTypeWeCom,
+			check: func(t *testing.T, got *webhookRequest) {
+				assert.Equal(t, "markdown", got.body["msgtype"])
+				assert.Equal(t, map[string]any{"content": "**内容**"}, got.body["markdown"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := newWebhookServer(t, http.StatusOK, `{"errcode":0,"code":0}`)
+			hook := Webhook{Type: tt.hookType, URL: srv.URL + "?access_token=keep", Secret: tt.secret}
+			require.NoError(t, SendMarkdown(context.Background(), hook, "周报", "**内容**"))
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestSendMarkdown_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		hookType WebhookType
+		status   int
+		resp     string
+		err      string
+	}{
+		{"unsupported type", "slack", http.StatusOK, `{}`, "unsupported webhook type: slack"},
+		{"http status", WebhookTypeWeCom, http.StatusBadGateway, `bad gateway`, "webhook response status 502"},
+		{"dingtalk errcode", WebhookTypeDingTalk, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`, "webhook error 310000: sign not match"},
+		{"feishu code", WebhookTypeFeishu, http.StatusOK, `{"code":19021,"msg":"sign match fail"}`, "webhook error 19021: sign match fail"},
+		{"invalid response", WebhookTypeWeCom, http.StatusOK, `ok`, "unmarshal webhook response failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newWebhookServer(t, tt.status, tt.resp)
+			err := SendMarkdown(context.Background(), Webhook{Type: tt.hookType, URL: srv.URL}, "t", "c")
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -46,9 +46,13 @@ type UserInfo struct {
 
 // NewClient 创建OAuth客户端
 func NewClient(ctx context.Context, logger *log.Logger, baseUrl string, config Config) (*Client, error) {
-	redirectURI, err := url.JoinPath(baseUrl, callbackPath)
-	if err != nil {
-		return nil, err
+	redirectURI := config.RedirectURI
+	if redirectURI == "" {
+		var err error
+		redirectURI, err = url.JoinPath(baseUrl, callbackPath)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &Client{
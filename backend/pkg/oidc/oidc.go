@@ -0,0 +1,221 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const (
+	callbackPath = "/share/v1/openapi/oidc/callback"
+
+	// providerTTL 发现文档缓存时间，JWKS 在遇到未知 kid 时由 go-oidc 自动刷新
+	providerTTL = time.Hour
+)
+
+var defaultScopes = []string{gooidc.ScopeOpenID, "profile", "email"}
+
+type Client struct {
+	logger   *log.Logger
+	ctx      context.Context
+	config   *Config
+	oauth    *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+	provider *gooidc.Provider
+}
+
+type Config struct {
+	Issuer       string   `json:"issuer"` // 用于获取 /.well-known/openid-configuration
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+	RedirectURI  string   `json:"redirect_uri,omitempty"` // 默认为前台OIDC回调
+	IDField      string   `json:"id_field,omitempty"`
+	NameField    string   `json:"name_field,omitempty"`
+	AvatarField  string   `json:"avatar_field,omitempty"`
+	EmailField   string   `json:"email_field,omitempty"`
+	GroupsField  string   `json:"groups_field,omitempty"`
+}
+
+type UserInfo struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	AvatarUrl string   `json:"avatar_url"`
+	Groups    []string `json:"groups"`
+}
+
+type cachedProvider struct {
+	provider  *gooidc.Provider
+	expiresAt time.Time
+}
+
+var providers sync.Map
+
+// getProvider 按 issuer 缓存 provider，复用其 JWKS 缓存
+func getProvider(ctx context.Context, issuer string) (*gooidc.Provider, error) {
+	if v, ok := providers.Load(issuer); ok {
+		cached := v.(*cachedProvider)
+		if time.Now().Before(cached.expiresAt) {
+			return cached.provider, nil
+		}
+	}
+
+	// provider 内部的 JWKS 请求会沿用该 ctx，不能使用请求级别的 ctx
+	provider, err := gooidc.NewProvider(context.WithoutCancel(ctx), issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover oidc provider failed: %w", err)
+	}
+	providers.Store(issuer, &cachedProvider{provider: provider, expiresAt: time.Now().Add(providerTTL)})
+	return provider, nil
+}
+
+// NewClient 创建OIDC客户端
+func NewClient(ctx context.Context, logger *log.Logger, baseUrl string, config Config) (*Client, error) {
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("oidc issuer and client id are required")
+	}
+
+	redirectURI := config.RedirectURI
+	if redirectURI == "" {
+		var err error
+		redirectURI, err = url.JoinPath(baseUrl, callbackPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	provider, err := getProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	return &Client{
+		ctx:    ctx,
+		logger: logger.WithModule("pkg.oidc"),
+		oauth: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  redirectURI,
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&gooidc.Config{ClientID: config.ClientID}),
+		provider: provider,
+		config:   &config,
+	}, nil
+}
+
+func (c *Client) GetAuthorizeURL(state, nonce, verifier string) string {
+	return c.oauth.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// GetUserInfo 用授权码换取并校验 ID Token，合并 userinfo 接口返回的声明后按字段映射
+func (c *Client) GetUserInfo(code, nonce, verifier string) (*UserInfo, error) {
+	token, err := c.oauth.Exchange(c.ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("id_token not found in token response")
+	}
+
+	idToken, err := c.verifier.Verify(c.ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token failed: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	claims := make(map[string]any)
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if c.provider.UserInfoEndpoint() != "" {
+		userInfo, err := c.provider.UserInfo(c.ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			c.logger.Warn("oidc get userinfo failed", log.Error(err))
+		} else if userInfo.Subject == idToken.Subject {
+			extra := make(map[string]any)
+			if err := userInfo.Claims(&extra); err != nil {
+				return nil, err
+			}
+			for k, v := range extra {
+				if _, ok := claims[k]; !ok {
+					claims[k] = v
+				}
+			}
+		}
+	}
+
+	buf, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info("oidc GetUserInfo:", log.Any("claims", string(buf)))
+
+	jsonString := string(buf)
+	info := &UserInfo{
+		ID:        idToken.Subject,
+		Name:      gjson.Get(jsonString, fieldOr(c.config.NameField, "name")).String(),
+		Email:     gjson.Get(jsonString, fieldOr(c.config.EmailField, "email")).String(),
+		AvatarUrl: gjson.Get(jsonString, fieldOr(c.config.AvatarField, "picture")).String(),
+		Groups:    stringList(gjson.Get(jsonString, fieldOr(c.config.GroupsField, "groups"))),
+	}
+	if c.config.IDField != "" {
+		info.ID = gjson.Get(jsonString, c.config.IDField).String()
+	}
+	if info.Name == "" {
+		info.Name = gjson.Get(jsonString, "preferred_username").String()
+	}
+	if info.ID == "" {
+		return nil, errors.New("oidc user id is empty")
+	}
+
+	return info, nil
+}
+
+func fieldOr(field, def string) string {
+	if field == "" {
+		return def
+	}
+	return field
+}
+
+func stringList(r gjson.Result) []string {
+	if !r.Exists() {
+		return nil
+	}
+	if !r.IsArray() {
+		if r.String() == "" {
+			return nil
+		}
+		return []string{r.String()}
+	}
+	list := make([]string, 0)
+	for _, item := range r.Array() {
+		if item.String() != "" {
+			list = append(list, item.String())
+		}
+	}
+	return list
+}
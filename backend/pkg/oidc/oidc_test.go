@@ -0,0 +1,219 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// testIDP 模拟 OIDC 提供方，签发 RS256 的 ID Token
+type testIDP struct {
+	*httptest.Server
+	key        *rsa.PrivateKey
+	discovery  atomic.Int32
+	idClaims   map[string]any
+	userClaims map[string]any
+}
+
+func newTestIDP(t *testing.T) *testIDP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &testIDP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		idp.discovery.Add(1)
+		writeJSON(w, map[string]any{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"userinfo_endpoint":      idp.URL + "/userinfo",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		claims := map[string]any{
+			"iss": idp.URL,
+			"aud": "client-1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"iat": time.Now().Unix(),
+		}
+		for k, v := range idp.idClaims {
+			claims[k] = v
+		}
+		writeJSON(w, map[string]any{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, claims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, idp.userClaims)
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(func() {
+		idp.Close()
+		providers.Delete(idp.URL)
+	})
+	return idp
+}
+
+func (idp *testIDP) sign(t *testing.T, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestLogger() *log.Logger {
+	return log.NewLogger(&config.Config{})
+}
+
+func TestNewClient(t *testing.T) {
+	idp := newTestIDP(t)
+
+	_, err := NewClient(context.Background(), newTestLogger(), "https://wiki.example.com", Config{Issuer: idp.URL})
+	assert.EqualError(t, err, "oidc issuer and client id are required")
+
+	client, err := NewClient(context.Background(), newTestLogger(), "https://wiki.example.com", Config{Issuer: idp.URL, ClientID: "client-1"})
+	require.NoError(t, err)
+	_, err = NewClient(context.Background(), newTestLogger(), "https://wiki.example.com", Config{Issuer: idp.URL, ClientID: "client-2"})
+	require.NoError(t, err)
+	// 同一 issuer 的发现文档只获取一次
+	assert.Equal(t, int32(1), idp.discovery.Load())
+
+	u, err := url.Parse(client.GetAuthorizeURL("state-1", "nonce-1", "verifier-1"))
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, idp.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "client-1", q.Get("client_id"))
+	assert.Equal(t, "https://wiki.example.com/share/v1/openapi/oidc/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "openid profile email", q.Get("scope"))
+	assert.Equal(t, "state-1", q.Get("state"))
+	assert.Equal(t, "nonce-1", q.Get("nonce"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.NotEmpty(t, q.Get("code_challenge"))
+}
+
+func TestClient_GetUserInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		idClaims   map[string]any
+		userClaims map[string]any
+		expected   *UserInfo
+		err        string
+	}{
+		{
+			name:       "default fields merged with userinfo",
+			idClaims:   map[string]any{"sub": "u1", "nonce": "n", "name": "Alice"},
+			userClaims: map[string]any{"sub": "u1", "name": "ignored", "email": "alice@example.com", "picture": "https://a/p.png", "groups": []string{"dev", "", "ops"}},
+			expected:   &UserInfo{ID: "u1", Name: "Alice", Email: "alice@example.com", AvatarUrl: "https://a/p.png", Groups: []string{"dev", "ops"}},
+		},
+		{
+			name:       "userinfo of another subject is ignored",
+			idClaims:   map[string]any{"sub": "u1", "nonce": "n", "preferred_username": "alice"},
+			userClaims: map[string]any{"sub": "u2", "email": "bob@example.com"},
+			expected:   &UserInfo{ID: "u1", Name: "alice"},
+		},
+		{
+			name:     "custom fields",
+			config:   Config{IDField: "employee.id", NameField: "cn", GroupsField: "roles"},
+			idClaims: map[string]any{"sub": "u1", "nonce": "n", "cn": "张三", "employee": map[string]any{"id": "E001"}, "roles": "admin"},
+			expected: &UserInfo{ID: "E001", Name: "张三", Groups: []string{"admin"}},
+		},
+		{
+			name:     "nonce mismatch",
+			idClaims: map[string]any{"sub": "u1", "nonce": "other"},
+			err:      "id_token nonce mismatch",
+		},
+		{
+			name:     "empty id",
+			config:   Config{IDField: "employee_id"},
+			idClaims: map[string]any{"sub": "u1", "nonce": "n"},
+			err:      "oidc user id is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newTestIDP(t)
+			idp.idClaims = tt.idClaims
+			idp.userClaims = tt.userClaims
+			if idp.userClaims == nil {
+				idp.userClaims = map[string]any{"sub": tt.idClaims["sub"]}
+			}
+			cfg := tt.config
+			cfg.Issuer, cfg.ClientID = idp.URL, "client-1"
+			client, err := NewClient(context.Background(), newTestLogger(), "https://wiki.example.com", cfg)
+			require.NoError(t, err)
+
+			info, err := client.GetUserInfo("code", "n", "verifier")
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, info)
+		})
+	}
+}
+
+func TestStringList(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []string
+	}{
+		{"missing", `{}`, nil},
+		{"empty string", `{"v":""}`, nil},
+		{"string", `{"v":"dev"}`, []string{"dev"}},
+		{"array", `{"v":["dev","","ops"]}`, []string{"dev", "ops"}},
+		{"empty array", `{"v":[]}`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, stringList(gjson.Get(tt.json, "v")))
+		})
+	}
+}
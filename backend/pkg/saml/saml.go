@@ -0,0 +1,248 @@
+package saml
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const (
+	metadataPath = "/share/v1/openapi/saml/%s/metadata"
+	acsPath      = "/share/v1/openapi/saml/%s/acs"
+
+	// metadataTTL IdP 元数据缓存时间
+	metadataTTL = time.Hour
+)
+
+type Client struct {
+	logger *log.Logger
+	ctx    context.Context
+	config *Config
+	sp     *saml.ServiceProvider
+}
+
+type Config struct {
+	IDPMetadataURL string `json:"idp_metadata_url,omitempty"` // IdP 元数据地址，与 IDPMetadataXML 二选一
+	IDPMetadataXML string `json:"idp_metadata_xml,omitempty"`
+	EntityID       string `json:"entity_id,omitempty"`   // 默认为 SP 元数据地址
+	Certificate    string `json:"certificate,omitempty"` // SP 证书 PEM
+	PrivateKey     string `json:"private_key,omitempty"` // SP 私钥 PEM
+	IDField        string `json:"id_field,omitempty"`    // 默认使用 NameID
+	NameField      string `json:"name_field,omitempty"`
+	AvatarField    string `json:"avatar_field,omitempty"`
+	EmailField     string `json:"email_field,omitempty"`
+	GroupsField    string `json:"groups_field,omitempty"`
+}
+
+type UserInfo struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	AvatarUrl string   `json:"avatar_url"`
+	Groups    []string `json:"groups"`
+}
+
+type cachedMetadata struct {
+	metadata  *saml.EntityDescriptor
+	expiresAt time.Time
+}
+
+var idpMetadatas sync.Map
+
+// fetchIDPMetadata 按地址缓存 IdP 元数据，避免每次登录都请求 IdP
+func fetchIDPMetadata(ctx context.Context, metadataURL string) (*saml.EntityDescriptor, error) {
+	if v, ok := idpMetadatas.Load(metadataURL); ok {
+		cached := v.(*cachedMetadata)
+		if time.Now().Before(cached.expiresAt) {
+			return cached.metadata, nil
+		}
+	}
+
+	idpURL, err := url.Parse(metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := samlsp.FetchMetadata(ctx, http.DefaultClient, *idpURL)
+	if err != nil {
+		return nil, err
+	}
+	idpMetadatas.Store(metadataURL, &cachedMetadata{metadata: metadata, expiresAt: time.Now().Add(metadataTTL)})
+	return metadata, nil
+}
+
+// NewClient 创建SAML SP，baseUrl 为知识库访问地址
+func NewClient(ctx context.Context, logger *log.Logger, baseUrl, kbID string, config Config) (*Client, error) {
+	if config.Certificate == "" || config.PrivateKey == "" {
+		return nil, errors.New("saml sp certificate and private key are required")
+	}
+
+	cert, key, err := parseKeyPair(config.Certificate, config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	metadataURL := base.JoinPath(fmt.Sprintf(metadataPath, kbID))
+	acsURL := base.JoinPath(fmt.Sprintf(acsPath, kbID))
+
+	var idpMetadata *saml.EntityDescriptor
+	switch {
+	case config.IDPMetadataXML != "":
+		idpMetadata, err = samlsp.ParseMetadata([]byte(config.IDPMetadataXML))
+	case config.IDPMetadataURL != "":
+		idpMetadata, err = fetchIDPMetadata(ctx, config.IDPMetadataURL)
+	default:
+		err = errors.New("saml idp metadata is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load idp metadata failed: %w", err)
+	}
+
+	entityID := config.EntityID
+	if entityID == "" {
+		entityID = metadataURL.String()
+	}
+
+	return &Client{
+		ctx:    ctx,
+		logger: logger.WithModule("pkg.saml"),
+		config: &config,
+		sp: &saml.ServiceProvider{
+			EntityID:          entityID,
+			Key:               key,
+			Certificate:       cert,
+			MetadataURL:       *metadataURL,
+			AcsURL:            *acsURL,
+			IDPMetadata:       idpMetadata,
+			AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+		},
+	}, nil
+}
+
+// MetadataURL SP 元数据地址，同时作为默认 EntityID
+func MetadataURL(baseUrl, kbID string) (string, error) {
+	return url.JoinPath(baseUrl, fmt.Sprintf(metadataPath, kbID))
+}
+
+func parseKeyPair(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse sp key pair failed: %w", err)
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("sp private key must be rsa")
+	}
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid sp certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// Metadata 生成 SP 元数据，供 IdP 导入
+func (c *Client) Metadata() ([]byte, error) {
+	return xml.MarshalIndent(c.sp.Metadata(), "", "  ")
+}
+
+// GetAuthorizeURL 生成 HTTP-Redirect 绑定的认证请求，返回跳转地址和请求ID
+func (c *Client) GetAuthorizeURL(relayState string) (string, string, error) {
+	req, err := c.sp.MakeAuthenticationRequest(
+		c.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding,
+		saml.HTTPPostBinding,
+	)
+	if err != nil {
+		return "", "", err
+	}
+	redirectURL, err := req.Redirect(relayState, c.sp)
+	if err != nil {
+		return "", "", err
+	}
+	return redirectURL.String(), req.ID, nil
+}
+
+// GetUserInfo 校验 ACS 收到的 SAMLResponse，并按属性映射用户信息
+func (c *Client) GetUserInfo(r *http.Request, requestID string) (*UserInfo, error) {
+	assertion, err := c.sp.ParseResponse(r, []string{requestID})
+	if err != nil {
+		var ire *saml.InvalidResponseError
+		if errors.As(err, &ire) {
+			c.logger.Warn("saml invalid response", log.Error(ire.PrivateErr))
+		}
+		return nil, err
+	}
+
+	attrs := make(map[string][]string)
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			for _, v := range attr.Values {
+				attrs[attr.Name] = append(attrs[attr.Name], v.Value)
+				if attr.FriendlyName != "" {
+					attrs[attr.FriendlyName] = append(attrs[attr.FriendlyName], v.Value)
+				}
+			}
+		}
+	}
+	c.logger.Info("saml GetUserInfo:", log.Any("attributes", attrs))
+
+	first := func(field string, defaults ...string) string {
+		for _, name := range append([]string{field}, defaults...) {
+			if values := attrs[name]; name != "" && len(values) > 0 {
+				return values[0]
+			}
+		}
+		return ""
+	}
+
+	info := &UserInfo{
+		Name:      first(c.config.NameField, "displayName", "http://schemas.microsoft.com/identity/claims/displayname", "name"),
+		Email:     first(c.config.EmailField, "email", "mail", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress"),
+		AvatarUrl: first(c.config.AvatarField),
+		Groups:    attrs[fieldOr(c.config.GroupsField, "groups")],
+	}
+	if len(info.Groups) == 0 && c.config.GroupsField == "" {
+		info.Groups = attrs["http://schemas.microsoft.com/ws/2008/06/identity/claims/groups"]
+	}
+	if c.config.IDField != "" {
+		info.ID = first(c.config.IDField)
+	} else if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		info.ID = assertion.Subject.NameID.Value
+	}
+	if info.ID == "" {
+		return nil, errors.New("saml user id is empty")
+	}
+	if info.Name == "" {
+		info.Name = info.ID
+	}
+
+	return info, nil
+}
+
+func fieldOr(field, def string) string {
+	if field == "" {
+		return def
+	}
+	return field
+}
@@ -0,0 +1,143 @@
+package saml
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const testIDPMetadata = `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com/metadata">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
+
+// newKeyPair 生成自签名证书，key 为 nil 时使用 rsa 私钥
+func newKeyPair(t *testing.T, key crypto.Signer) (string, string) {
+	t.Helper()
+	if key == nil {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		key = rsaKey
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "panda-wiki"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func newTestLogger() *log.Logger {
+	return log.NewLogger(&config.Config{})
+}
+
+func TestNewClient(t *testing.T) {
+	cert, key := newKeyPair(t, nil)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecCert, ecKeyPEM := newKeyPair(t, ecKey)
+
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{"valid", Config{Certificate: cert, PrivateKey: key, IDPMetadataXML: testIDPMetadata}, ""},
+		{"missing key pair", Config{IDPMetadataXML: testIDPMetadata}, "saml sp certificate and private key are required"},
+		{"mismatched key pair", Config{Certificate: cert, PrivateKey: ecKeyPEM, IDPMetadataXML: testIDPMetadata}, "parse sp key pair failed"},
+		{"non rsa key", Config{Certificate: ecCert, PrivateKey: ecKeyPEM, IDPMetadataXML: testIDPMetadata}, "sp private key must be rsa"},
+		{"missing idp metadata", Config{Certificate: cert, PrivateKey: key}, "saml idp metadata is required"},
+		{"invalid idp metadata", Config{Certificate: cert, PrivateKey: key, IDPMetadataXML: "<xml"}, "load idp metadata failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(context.Background(), newTestLogger(), "https://wiki.example.com", "kb1", tt.config)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestClient_AuthorizeURLAndMetadata(t *testing.T) {
+	cert, key := newKeyPair(t, nil)
+	client, err := NewClient(context.Background(), newTestLogger(), "https://wiki.example.com/", "kb1", Config{
+		Certificate:    cert,
+		PrivateKey:     key,
+		IDPMetadataXML: testIDPMetadata,
+	})
+	require.NoError(t, err)
+
+	authURL, requestID, err := client.GetAuthorizeURL("state-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, requestID)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "idp.example.com", u.Host)
+	assert.Equal(t, "/sso", u.Path)
+	assert.NotEmpty(t, u.Query().Get("SAMLRequest"))
+	assert.Equal(t, "state-1", u.Query().Get("RelayState"))
+
+	metadata, err := client.Metadata()
+	require.NoError(t, err)
+	// 未配置 EntityID 时使用 SP 元数据地址
+	assert.Contains(t, string(metadata), `entityID="https://wiki.example.com/share/v1/openapi/saml/kb1/metadata"`)
+	assert.Contains(t, string(metadata), `Location="https://wiki.example.com/share/v1/openapi/saml/kb1/acs"`)
+
+	metadataURL, err := MetadataURL("https://wiki.example.com/", "kb1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://wiki.example.com/share/v1/openapi/saml/kb1/metadata", metadataURL)
+}
+
+func TestFetchIDPMetadata(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(testIDPMetadata))
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { idpMetadatas.Delete(srv.URL) })
+
+	for range 3 {
+		metadata, err := fetchIDPMetadata(context.Background(), srv.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/metadata", metadata.EntityID)
+	}
+	assert.Equal(t, int32(1), hits.Load())
+
+	// 缓存过期后重新获取
+	v, _ := idpMetadatas.Load(srv.URL)
+	v.(*cachedMetadata).expiresAt = time.Now().Add(-time.Second)
+	_, err := fetchIDPMetadata(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), hits.Load())
+}
@@ -0,0 +1,217 @@
+// Package sitegen 将知识库发布版本渲染为可离线浏览的静态 HTML 站点
+package sitegen
+
+import (
+	"archive/zip"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+//go:embed static/*
+var staticFS embed.FS
+
+var pageTemplate = template.Must(template.New("page.html").Funcs(template.FuncMap{
+	"tree": func(root string, nodes []*TreeNode) treeData {
+		return treeData{Root: root, Nodes: nodes}
+	},
+	"navPath": navPath,
+}).ParseFS(staticFS, "static/page.html"))
+
+// 搜索索引中每篇文档保留的正文长度
+const searchTextLimit = 20000
+
+// TreeNode 目录树中的节点，Page 为空时只显示名称
+type TreeNode struct {
+	Name     string
+	Page     string
+	Children []*TreeNode
+}
+
+type Nav struct {
+	ID   string
+	Name string
+	Tree []*TreeNode
+}
+
+// Page 一篇文档，Content 为已处理过资源地址的 HTML
+type Page struct {
+	ID        string
+	Title     string
+	NavID     string
+	Summary   string
+	Content   string
+	Text      string
+	UpdatedAt time.Time
+}
+
+type Site struct {
+	Title       string
+	Description string
+	Version     string
+	Navs        []*Nav
+	Pages       []*Page
+}
+
+// PagePath 文档在站点中的路径
+func PagePath(id string) string {
+	return "node/" + id + ".html"
+}
+
+func navPath(id string) string {
+	return "nav/" + id + ".html"
+}
+
+type pageData struct {
+	Root    string
+	Site    *Site
+	Nav     *Nav
+	Title   string
+	Summary string
+	Updated string
+	Content template.HTML
+	Pages   []*Page
+}
+
+type treeData struct {
+	Root  string
+	Nodes []*TreeNode
+}
+
+type searchEntry struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+	Text    string `json:"text"`
+}
+
+// Write 将站点写入 zip，文件资源由调用方另行写入
+func Write(zw *zip.Writer, site *Site) error {
+	navPages := make(map[string][]*Page, len(site.Navs))
+	for _, page := range site.Pages {
+		navPages[page.NavID] = append(navPages[page.NavID], page)
+	}
+	navByID := make(map[string]*Nav, len(site.Navs))
+	for _, nav := range site.Navs {
+		navByID[nav.ID] = nav
+	}
+
+	index := &pageData{Root: "", Site: site, Title: site.Title, Summary: site.Description}
+	if len(site.Navs) > 0 {
+		index.Nav = site.Navs[0]
+		index.Pages = navPages[site.Navs[0].ID]
+	} else {
+		index.Pages = site.Pages
+	}
+	if err := writePage(zw, "index.html", index); err != nil {
+		return err
+	}
+
+	for _, nav := range site.Navs {
+		if err := writePage(zw, navPath(nav.ID), &pageData{
+			Root:  "../",
+			Site:  site,
+			Nav:   nav,
+			Title: nav.Name,
+			Pages: navPages[nav.ID],
+		}); err != nil {
+			return err
+		}
+	}
+
+	entries := make([]searchEntry, 0, len(site.Pages))
+	for _, page := range site.Pages {
+		if err := writePage(zw, PagePath(page.ID), &pageData{
+			Root:    "../",
+			Site:    site,
+			Nav:     navByID[page.NavID],
+			Title:   page.Title,
+			Summary: page.Summary,
+			Updated: page.UpdatedAt.Format(time.DateTime),
+			Content: template.HTML(page.Content),
+		}); err != nil {
+			return err
+		}
+		text := []rune(page.Text)
+		if len(text) > searchTextLimit {
+			text = text[:searchTextLimit]
+		}
+		entries = append(entries, searchEntry{
+			Title:   page.Title,
+			URL:     PagePath(page.ID),
+			Summary: page.Summary,
+			Text:    string(text),
+		})
+	}
+
+	// 以脚本形式加载索引，本地打开文件时浏览器不允许 fetch
+	searchIndex, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(zw, "assets/search-index.js", []byte(fmt.Sprintf("window.SEARCH_INDEX = %s;\n", searchIndex))); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"style.css", "search.js"} {
+		data, err := fs.ReadFile(staticFS, path.Join("static", name))
+		if err != nil {
+			return err
+		}
+		if err := writeFile(zw, path.Join("assets", name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePage(zw *zip.Writer, name string, data *pageData) error {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, data); err != nil {
+		return fmt.Errorf("render %s failed: %w", name, err)
+	}
+	return writeFile(zw, name, buf.Bytes())
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// PlainText 提取 HTML 中的文本，用于生成搜索索引
+func PlainText(content string) string {
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	skip := 0
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.StartTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "script" || string(name) == "style" {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); (string(name) == "script" || string(name) == "style") && skip > 0 {
+				skip--
+			}
+			sb.WriteString(" ")
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(tokenizer.Text())
+			}
+		}
+	}
+}
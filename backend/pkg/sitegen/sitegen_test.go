@@ -0,0 +1,118 @@
+package sitegen
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"plain", "hello", "hello"},
+		{"block elements are separated", "<h1>标题</h1><p>第一段</p><p>第二段</p>", "标题 第一段 第二段"},
+		{"inline elements are joined", "<p>Panda<b>Wiki</b></p>", "PandaWiki"},
+		{"script and style are skipped", "<style>p{color:red}</style><p>正文</p><script>alert(1)</script>", "正文"},
+		{"whitespace collapsed", "<p>  a \n\t b  </p>", "a b"},
+		{"entities decoded", "<p>a &amp; b &lt;c&gt;</p>", "a & b <c>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PlainText(tt.content))
+		})
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	site := &Site{
+		Title:       "产品文档",
+		Description: "离线版",
+		Version:     "v1.2",
+		Navs: []*Nav{
+			{ID: "guide", Name: "指南", Tree: []*TreeNode{
+				{Name: "入门", Children: []*TreeNode{{Name: "安装", Page: PagePath("p1")}}},
+			}},
+			{ID: "api", Name: "API"},
+		},
+		Pages: []*Page{
+			{ID: "p1", Title: "安装", NavID: "guide", Summary: "如何安装", Content: `<p>运行 <code>install.sh</code></p>`, Text: "运行 install.sh", UpdatedAt: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)},
+			{ID: "p2", Title: "接口<列表>", NavID: "api", Text: strings.Repeat("字", searchTextLimit+10)},
+		},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	require.NoError(t, Write(zw, site))
+	require.NoError(t, zw.Close())
+	files := readZip(t, buf.Bytes())
+
+	for _, name := range []string{"index.html", "nav/guide.html", "nav/api.html", "node/p1.html", "node/p2.html", "assets/style.css", "assets/search.js", "assets/search-index.js"} {
+		assert.Contains(t, files, name)
+	}
+
+	// 首页展示第一个栏目的文档
+	index := files["index.html"]
+	assert.Contains(t, index, `href="node/p1.html"`)
+	assert.NotContains(t, index, `href="node/p2.html"`)
+	assert.Contains(t, index, `href="assets/style.css"`)
+
+	// 子目录中的页面使用相对根目录的地址
+	page := files["node/p1.html"]
+	assert.Contains(t, page, `<title>安装 - 产品文档</title>`)
+	assert.Contains(t, page, `href="../assets/style.css"`)
+	assert.Contains(t, page, `<a href="../node/p1.html">安装</a>`)
+	assert.Contains(t, page, `class="tab active" href="../nav/guide.html"`)
+	assert.Contains(t, page, "更新于 2026-03-01 08:00:00")
+	// 正文已由调用方处理，原样输出
+	assert.Contains(t, page, `<p>运行 <code>install.sh</code></p>`)
+	assert.Contains(t, page, "产品文档 · v1.2")
+	// 标题等文本需要转义
+	assert.Contains(t, files["node/p2.html"], "<h1>接口&lt;列表&gt;</h1>")
+
+	searchIndex := strings.TrimSuffix(strings.TrimPrefix(files["assets/search-index.js"], "window.SEARCH_INDEX = "), ";\n")
+	var entries []searchEntry
+	require.NoError(t, json.Unmarshal([]byte(searchIndex), &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, searchEntry{Title: "安装", URL: "node/p1.html", Summary: "如何安装", Text: "运行 install.sh"}, entries[0])
+	assert.Len(t, []rune(entries[1].Text), searchTextLimit)
+}
+
+func TestWrite_WithoutNavs(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	require.NoError(t, Write(zw, &Site{Title: "kb", Pages: []*Page{{ID: "p1", Title: "doc"}}}))
+	require.NoError(t, zw.Close())
+	files := readZip(t, buf.Bytes())
+
+	// 没有栏目时首页列出全部文档
+	assert.Contains(t, files["index.html"], `href="node/p1.html"`)
+	assert.Contains(t, files["assets/search-index.js"], `"url":"node/p1.html"`)
+}
@@ -0,0 +1,58 @@
+(function () {
+  var input = document.getElementById('search-input');
+  var results = document.getElementById('search-results');
+  var index = window.SEARCH_INDEX || [];
+  var root = window.SITE_ROOT || '';
+
+  function escapeHTML(s) {
+    return s.replace(/[&<>"']/g, function (c) {
+      return { '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' }[c];
+    });
+  }
+
+  function snippet(text, keyword) {
+    var pos = text.toLowerCase().indexOf(keyword);
+    if (pos < 0) return '';
+    var start = Math.max(0, pos - 40);
+    return (start > 0 ? '…' : '') + text.slice(start, pos + keyword.length + 80) + '…';
+  }
+
+  function search(query) {
+    var keywords = query.toLowerCase().split(/\s+/).filter(Boolean);
+    if (!keywords.length) return [];
+    var matched = [];
+    index.forEach(function (doc) {
+      var title = doc.title.toLowerCase();
+      var body = (doc.summary + ' ' + doc.text).toLowerCase();
+      var score = 0;
+      for (var i = 0; i < keywords.length; i++) {
+        var inTitle = title.indexOf(keywords[i]) >= 0;
+        var inBody = body.indexOf(keywords[i]) >= 0;
+        if (!inTitle && !inBody) return;
+        score += (inTitle ? 10 : 0) + (inBody ? 1 : 0);
+      }
+      matched.push({ doc: doc, score: score });
+    });
+    matched.sort(function (a, b) { return b.score - a.score; });
+    return matched.slice(0, 20).map(function (m) {
+      return { doc: m.doc, snippet: m.doc.summary || snippet(m.doc.text, keywords[0]) };
+    });
+  }
+
+  input.addEventListener('input', function () {
+    var items = search(input.value);
+    if (!input.value.trim()) {
+      results.hidden = true;
+      return;
+    }
+    results.innerHTML = items.length ? items.map(function (item) {
+      return '<li><a href="' + root + item.doc.url + '">' + escapeHTML(item.doc.title) + '</a>' +
+        (item.snippet ? '<p>' + escapeHTML(item.snippet) + '</p>' : '') + '</li>';
+    }).join('') : '<li>没有找到相关文档</li>';
+    results.hidden = false;
+  });
+
+  document.addEventListener('click', function (e) {
+    if (!e.target.closest('.search')) results.hidden = true;
+  });
+})();
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/store/cache"
+)
+
+// NodeCollabTicketTTL 协同编辑凭证的有效期，凭证只能使用一次
+const NodeCollabTicketTTL = 30 * time.Second
+
+type NodeCollabTicketRepo struct {
+	cache *cache.Cache
+}
+
+func NewNodeCollabTicketRepo(cache *cache.Cache) *NodeCollabTicketRepo {
+	return &NodeCollabTicketRepo{cache: cache}
+}
+
+func nodeCollabTicketKey(ticket string) string {
+	return "node_collab_ticket:" + ticket
+}
+
+func (r *NodeCollabTicketRepo) Create(ctx context.Context, ticket string, value *domain.NodeCollabTicket) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.cache.Set(ctx, nodeCollabTicketKey(ticket), data, NodeCollabTicketTTL).Err()
+}
+
+// Take 取出并删除凭证，不存在或已使用时返回 nil
+func (r *NodeCollabTicketRepo) Take(ctx context.Context, ticket string) (*domain.NodeCollabTicket, error) {
+	data, err := r.cache.GetDel(ctx, nodeCollabTicketKey(ticket)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var value domain.NodeCollabTicket
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/store/cache"
+)
+
+// nodePresenceTTL 超过该时间未上报心跳视为已离开
+const nodePresenceTTL = 30 * time.Second
+
+type NodePresenceRepo struct {
+	cache *cache.Cache
+}
+
+func NewNodePresenceRepo(cache *cache.Cache) *NodePresenceRepo {
+	return &NodePresenceRepo{cache: cache}
+}
+
+func nodePresenceKey(nodeID string) string {
+	return "node_presence:" + nodeID
+}
+
+func nodeEditLockKey(nodeID string) string {
+	return "node_edit_lock:" + nodeID
+}
+
+// Touch 刷新用户在文档上的心跳
+func (r *NodePresenceRepo) Touch(ctx context.Context, nodeID string, presence *domain.NodePresence) error {
+	data, err := json.Marshal(presence)
+	if err != nil {
+		return err
+	}
+	key := nodePresenceKey(nodeID)
+	pipe := r.cache.TxPipeline()
+	pipe.HSet(ctx, key, presence.UserID, data)
+	pipe.Expire(ctx, key, 2*nodePresenceTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *NodePresenceRepo) Leave(ctx context.Context, nodeID, userID string) error {
+	return r.cache.HDel(ctx, nodePresenceKey(nodeID), userID).Err()
+}
+
+// List 返回仍在线的用户，顺带清理过期的心跳
+func (r *NodePresenceRepo) List(ctx context.Context, nodeID string) ([]*domain.NodePresence, error) {
+	key := nodePresenceKey(nodeID)
+	values, err := r.cache.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(-nodePresenceTTL)
+	presences := make([]*domain.NodePresence, 0, len(values))
+	expired := make([]string, 0)
+	for userID, value := range values {
+		var presence domain.NodePresence
+		if err := json.Unmarshal([]byte(value), &presence); err != nil || presence.UpdatedAt.Before(deadline) {
+			expired = append(expired, userID)
+			continue
+		}
+		presences = append(presences, &presence)
+	}
+	if len(expired) > 0 {
+		if err := r.cache.HDel(ctx, key, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return presences, nil
+}
+
+// AcquireEditLock 获取或续期编辑锁，已被其他用户持有时返回 false
+func (r *NodePresenceRepo) AcquireEditLock(ctx context.Context, nodeID, userID string) (bool, error) {
+	key := nodeEditLockKey(nodeID)
+	ok, err := r.cache.SetNX(ctx, key, userID, nodePresenceTTL).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+	holder, err := r.GetEditLockHolder(ctx, nodeID)
+	if err != nil || holder != userID {
+		return false, err
+	}
+	return true, r.cache.Expire(ctx, key, nodePresenceTTL).Err()
+}
+
+// ReleaseEditLock 释放自己持有的编辑锁
+func (r *NodePresenceRepo) ReleaseEditLock(ctx context.Context, nodeID, userID string) error {
+	holder, err := r.GetEditLockHolder(ctx, nodeID)
+	if err != nil || holder != userID {
+		return err
+	}
+	return r.cache.Del(ctx, nodeEditLockKey(nodeID)).Err()
+}
+
+// GetEditLockHolder 返回持有编辑锁的用户，无人持有时为空
+func (r *NodePresenceRepo) GetEditLockHolder(ctx context.Context, nodeID string) (string, error) {
+	holder, err := r.cache.Get(ctx, nodeEditLockKey(nodeID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return holder, err
+}
@@ -0,0 +1,25 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/mq"
+)
+
+type KBExportTaskRepository struct {
+	producer mq.MQProducer
+}
+
+func NewKBExportTaskRepository(producer mq.MQProducer) *KBExportTaskRepository {
+	return &KBExportTaskRepository{producer: producer}
+}
+
+func (r *KBExportTaskRepository) AsyncExport(ctx context.Context, request *domain.KBExportTaskRequest) error {
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return r.producer.Produce(ctx, domain.KBExportTaskTopic, "", requestBytes)
+}
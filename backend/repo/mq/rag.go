@@ -28,3 +28,8 @@ func (r *RAGRepository) AsyncUpdateNodeReleaseVector(ctx context.Context, reques
 	}
 	return nil
 }
+
+// Republish 将死信中的原始消息重新投递到原主题
+func (r *RAGRepository) Republish(ctx context.Context, topic string, payload []byte) error {
+	return r.producer.Produce(ctx, topic, "", payload)
+}
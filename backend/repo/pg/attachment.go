@@ -0,0 +1,218 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+// attachmentRefSources 引用文件的数据来源，已删除知识库的数据不计入引用
+var attachmentRefSources = []struct {
+	refType domain.AttachmentRefType
+	query   string
+}{
+	{domain.AttachmentRefTypeNode, `SELECT t.kb_id, t.id, t.content || ' ' || COALESCE(t.meta::text, '') FROM nodes t`},
+	{domain.AttachmentRefTypeNodeRelease, `SELECT t.kb_id, t.id, t.content || ' ' || COALESCE(t.meta::text, '') FROM node_releases t`},
+	{domain.AttachmentRefTypeNodeReleaseBackup, `SELECT t.kb_id, t.id, t.content || ' ' || COALESCE(t.meta::text, '') FROM node_release_backup t`},
+	{domain.AttachmentRefTypeNodeRecycle, `SELECT t.kb_id, t.id, t.snapshot::text FROM node_recycles t`},
+	{domain.AttachmentRefTypeNodeRevision, `SELECT t.kb_id, t.node_id || ':' || t.revision, t.content FROM node_revisions t`},
+	{domain.AttachmentRefTypeNodeTemplate, `SELECT t.kb_id, t.id, t.content || ' ' || t.children::text FROM node_templates t`},
+	{domain.AttachmentRefTypeComment, `SELECT t.kb_id, t.id, t.content || ' ' || array_to_string(t.pic_urls, ' ') FROM comments t`},
+	{domain.AttachmentRefTypeContribute, `SELECT t.kb_id, t.id, t.content || ' ' || COALESCE(t.meta::text, '') FROM contributes t`},
+	{domain.AttachmentRefTypeConversation, `SELECT t.kb_id, t.id, array_to_string(t.image_paths, ' ') FROM conversation_messages t WHERE cardinality(t.image_paths) > 0`},
+	{domain.AttachmentRefTypeCuratedAnswer, `SELECT t.kb_id, t.id, t.answer FROM curated_answers t`},
+	{domain.AttachmentRefTypeApp, `SELECT t.kb_id, t.id, COALESCE(t.settings::text, '') FROM apps t`},
+}
+
+type AttachmentRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewAttachmentRepository(db *pg.DB, logger *log.Logger) *AttachmentRepository {
+	return &AttachmentRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.attachment"),
+	}
+}
+
+// GetAttachmentByHash 查找知识库内内容相同的文件，不存在时返回 nil
+func (r *AttachmentRepository) GetAttachmentByHash(ctx context.Context, kbID, hash string) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND hash = ?", kbID, hash).
+		First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// TouchAttachment 去重复用已有文件时刷新最后使用时间，避免刚复用的文件在引用写入前被回收
+func (r *AttachmentRepository) TouchAttachment(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Attachment{}).
+		Where("key = ?", key).
+		Update("last_used_at", gorm.Expr("NOW()")).Error
+}
+
+// UpdateAttachmentIndexStatus 记录附件写入 RAG 的结果
+func (r *AttachmentRepository) UpdateAttachmentIndexStatus(ctx context.Context, key string, status domain.AttachmentIndexStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Attachment{}).
+		Where("key = ?", key).
+		Update("index_status", status).Error
+}
+
+// CreateAttachment 并发上传相同内容时只有一条记录生效，返回是否写入成功
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *domain.Attachment) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(attachment)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *AttachmentRepository) GetAttachmentSize(ctx context.Context, kbID string) (int64, error) {
+	var size int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Attachment{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("kb_id = ?", kbID).
+		Scan(&size).Error; err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (r *AttachmentRepository) GetAttachmentUsage(ctx context.Context, kbID string) (*domain.AttachmentUsage, error) {
+	var usage domain.AttachmentUsage
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Attachment{}).
+		Select(`COUNT(*) AS count, COALESCE(SUM(size), 0) AS size,
+			COUNT(*) FILTER (WHERE NOT EXISTS (SELECT 1 FROM attachment_refs ar WHERE ar.key = attachments.key)) AS orphan_count,
+			COALESCE(SUM(size) FILTER (WHERE NOT EXISTS (SELECT 1 FROM attachment_refs ar WHERE ar.key = attachments.key)), 0) AS orphan_size`).
+		Where("kb_id = ?", kbID).
+		Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// RebuildAttachmentRefs 扫描文档、评论等内容重建文件引用索引
+func (r *AttachmentRepository) RebuildAttachmentRefs(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM attachment_refs").Error; err != nil {
+			return err
+		}
+		for _, source := range attachmentRefSources {
+			if err := tx.Exec(fmt.Sprintf(`
+				INSERT INTO attachment_refs (key, kb_id, ref_type, ref_id)
+				SELECT DISTINCT m[1], s.kb_id, ?, s.id
+				FROM (%s) AS s(kb_id, id, body)
+				JOIN knowledge_bases kb ON kb.id = s.kb_id
+				CROSS JOIN LATERAL regexp_matches(s.body, ?, 'g') AS m
+				ON CONFLICT DO NOTHING`, source.query),
+				source.refType, domain.AttachmentKeyPattern).Error; err != nil {
+				return fmt.Errorf("rebuild %s attachment refs: %w", source.refType, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetOrphanAttachments 获取在 before 之前最后使用且没有被引用的文件
+func (r *AttachmentRepository) GetOrphanAttachments(ctx context.Context, before time.Time, limit int) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	if err := r.db.WithContext(ctx).
+		Where("last_used_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM attachment_refs ar WHERE ar.key = attachments.key)").
+		Order("last_used_at ASC").
+		Limit(limit).
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&domain.Attachment{}).Error
+}
+
+func (r *AttachmentRepository) GetAttachmentsByKeys(ctx context.Context, keys []string) (map[string]*domain.Attachment, error) {
+	var attachments []*domain.Attachment
+	if err := r.db.WithContext(ctx).
+		Where("key IN ?", keys).
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(attachments, func(a *domain.Attachment) string { return a.Key }), nil
+}
+
+func (r *AttachmentRepository) GetNodeAttachmentDocs(ctx context.Context, nodeID string) ([]*domain.NodeAttachmentDoc, error) {
+	var docs []*domain.NodeAttachmentDoc
+	if err := r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		Order("id ASC").
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *AttachmentRepository) GetNodeAttachmentDocsByParentDocID(ctx context.Context, parentDocID string) ([]*domain.NodeAttachmentDoc, error) {
+	var docs []*domain.NodeAttachmentDoc
+	if err := r.db.WithContext(ctx).
+		Where("parent_doc_id = ?", parentDocID).
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *AttachmentRepository) GetNodeAttachmentDocsByDocIDs(ctx context.Context, docIDs []string) ([]*domain.NodeAttachmentDoc, error) {
+	var docs []*domain.NodeAttachmentDoc
+	if len(docIDs) == 0 {
+		return docs, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Where("doc_id IN ?", docIDs).
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *AttachmentRepository) CreateNodeAttachmentDoc(ctx context.Context, doc *domain.NodeAttachmentDoc) error {
+	return r.db.WithContext(ctx).Create(doc).Error
+}
+
+// UpdateNodeAttachmentDocsParent 文档发布新版本后将附件记录挂到新版本下
+func (r *AttachmentRepository) UpdateNodeAttachmentDocsParent(ctx context.Context, nodeID, parentDocID string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.NodeAttachmentDoc{}).
+		Where("node_id = ?", nodeID).
+		Update("parent_doc_id", parentDocID).Error
+}
+
+func (r *AttachmentRepository) DeleteNodeAttachmentDocs(ctx context.Context, docIDs []string) error {
+	if len(docIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("doc_id IN ?", docIDs).
+		Delete(&domain.NodeAttachmentDoc{}).Error
+}
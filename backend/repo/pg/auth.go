@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/samber/lo"
 	"gorm.io/gorm"
 
@@ -313,6 +314,10 @@ func (r *AuthRepo) GetOrCreateAuth(ctx context.Context, auth *domain.Auth, sourc
 			return err
 		}
 
+		if !existing.Active {
+			return errors.New("auth is deactivated")
+		}
+
 		updateMap := map[string]interface{}{
 			"last_login_time": time.Now(),
 			"user_info":       auth.UserInfo,
@@ -337,3 +342,174 @@ func (r *AuthRepo) GetOrCreateAuth(ctx context.Context, auth *domain.Auth, sourc
 
 	return auth, nil
 }
+
+// SyncAuthGroupMembers 按身份源下发的组名同步用户所属分组，不存在的分组按 sync_id 自动创建
+func (r *AuthRepo) SyncAuthGroupMembers(ctx context.Context, kbID string, sourceType consts.SourceType, authID uint, groupNames []string) error {
+	groupNames = lo.Uniq(lo.Compact(groupNames))
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 同一知识库同一身份源的同步串行执行，避免并发登录重复创建分组
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "auth_group_sync:"+kbID+":"+string(sourceType)).Error; err != nil {
+			return err
+		}
+		var syncIDs []string
+		if err := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Where("source_type = ?", sourceType).
+			Pluck("sync_id", &syncIDs).Error; err != nil {
+			return err
+		}
+
+		var maxPos float64
+		if err := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Select("COALESCE(MAX(position), 0)").
+			Scan(&maxPos).Error; err != nil {
+			return err
+		}
+		for _, name := range groupNames {
+			if lo.Contains(syncIDs, name) {
+				continue
+			}
+			maxPos += 1000
+			group := domain.AuthGroup{
+				Name:       name,
+				KbID:       kbID,
+				Position:   maxPos,
+				AuthIDs:    []int64{},
+				SyncId:     name,
+				SourceType: sourceType,
+			}
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+		}
+
+		// 成员变更在数据库中原子执行，不覆盖其他请求同时写入的成员
+		removeQuery := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Where("source_type = ?", sourceType).
+			Where("? = ANY(auth_ids)", authID)
+		if len(groupNames) > 0 {
+			removeQuery = removeQuery.Where("sync_id NOT IN ?", groupNames)
+		}
+		if err := removeQuery.Update("auth_ids", gorm.Expr("array_remove(auth_ids, ?)", authID)).Error; err != nil {
+			return err
+		}
+		if len(groupNames) == 0 {
+			return nil
+		}
+		return tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Where("source_type = ?", sourceType).
+			Where("sync_id IN ?", groupNames).
+			Where("NOT (? = ANY(COALESCE(auth_ids, '{}')))", authID).
+			Update("auth_ids", gorm.Expr("array_append(COALESCE(auth_ids, '{}'), ?)", authID)).Error
+	})
+}
+
+func (r *AuthRepo) GetAuthConfigsBySourceType(ctx context.Context, sourceType consts.SourceType) ([]domain.AuthConfig, error) {
+	configs := make([]domain.AuthConfig, 0)
+	if err := r.db.WithContext(ctx).
+		Where("source_type = ?", sourceType).
+		Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (r *AuthRepo) GetAuthsBySourceType(ctx context.Context, kbID string, sourceType consts.SourceType) ([]domain.Auth, error) {
+	auths := make([]domain.Auth, 0)
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND source_type = ?", kbID, sourceType).
+		Find(&auths).Error; err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+// SyncAuthGroups 以目录为准全量同步指定身份源的分组：按 sync_id 新增或更新，
+// 按 sync_parent_id 重建层级，目录中已不存在的分组连同其文档权限一并删除
+func (r *AuthRepo) SyncAuthGroups(ctx context.Context, kbID string, sourceType consts.SourceType, groups []domain.AuthGroup) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []domain.AuthGroup
+		if err := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Where("source_type = ?", sourceType).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		existingMap := lo.SliceToMap(existing, func(g domain.AuthGroup) (string, domain.AuthGroup) {
+			return g.SyncId, g
+		})
+
+		var maxPos float64
+		if err := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ?", kbID).
+			Select("COALESCE(MAX(position), 0)").
+			Scan(&maxPos).Error; err != nil {
+			return err
+		}
+
+		idMap := make(map[string]uint, len(groups))
+		for _, group := range groups {
+			if group.AuthIDs == nil {
+				group.AuthIDs = pq.Int64Array{}
+			}
+			if old, ok := existingMap[group.SyncId]; ok {
+				if err := tx.Model(&domain.AuthGroup{}).
+					Where("id = ?", old.ID).
+					Updates(map[string]any{
+						"name":           group.Name,
+						"auth_ids":       group.AuthIDs,
+						"sync_parent_id": group.SyncParentId,
+						"updated_at":     gorm.Expr("now()"),
+					}).Error; err != nil {
+					return err
+				}
+				idMap[group.SyncId] = old.ID
+				continue
+			}
+			maxPos += 1000
+			group.KbID = kbID
+			group.SourceType = sourceType
+			group.Position = maxPos
+			group.ParentID = nil
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			idMap[group.SyncId] = group.ID
+		}
+
+		for _, group := range groups {
+			var parentID *uint
+			if id, ok := idMap[group.SyncParentId]; ok && group.SyncParentId != "" {
+				parentID = &id
+			}
+			if err := tx.Model(&domain.AuthGroup{}).
+				Where("id = ?", idMap[group.SyncId]).
+				Update("parent_id", parentID).Error; err != nil {
+				return err
+			}
+		}
+
+		staleIDs := make([]uint, 0)
+		for _, old := range existing {
+			if _, ok := idMap[old.SyncId]; !ok {
+				staleIDs = append(staleIDs, old.ID)
+			}
+		}
+		if len(staleIDs) == 0 {
+			return nil
+		}
+		if err := tx.Where("id IN (?)", staleIDs).Delete(&domain.AuthGroup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("auth_group_id IN (?)", staleIDs).Delete(&domain.NodeAuthGroup{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ? AND parent_id IN (?)", kbID, staleIDs).
+			Update("parent_id", nil).Error
+	})
+}
@@ -0,0 +1,273 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// GetSCIMAuths 分页获取知识库下指定身份源的用户，column 为空时不过滤
+func (r *AuthRepo) GetSCIMAuths(ctx context.Context, kbID string, sourceType consts.SourceType, column, value string, offset, limit int) ([]domain.Auth, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Auth{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType)
+	if column != "" {
+		query = query.Where(fmt.Sprintf("%s = ?", column), value)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	auths := make([]domain.Auth, 0)
+	if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&auths).Error; err != nil {
+		return nil, 0, err
+	}
+	return auths, total, nil
+}
+
+// CreateSCIMAuth 创建SCIM下发的用户，受SSO用户数量限制
+func (r *AuthRepo) CreateSCIMAuth(ctx context.Context, auth *domain.Auth) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var exists int64
+		if err := tx.Model(&domain.Auth{}).
+			Where("kb_id = ?", auth.KBID).
+			Where("source_type = ?", auth.SourceType).
+			Where("union_id = ?", auth.UnionID).
+			Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists > 0 {
+			return domain.NewSCIMError(http.StatusConflict, domain.SCIMTypeUniqueness, "user already exists")
+		}
+
+		var count int64
+		if err := tx.Model(&domain.Auth{}).
+			Where("kb_id = ?", auth.KBID).
+			Where("source_type NOT IN (?)", consts.BotSourceTypes).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) >= domain.GetBaseEditionLimitation(ctx).MaxSSOUser {
+			return fmt.Errorf("exceed max auth limit for kb %s, current count: %d, max limit: %d", auth.KBID, count, domain.GetBaseEditionLimitation(ctx).MaxSSOUser)
+		}
+
+		active := auth.Active
+		if err := tx.Create(auth).Error; err != nil {
+			return err
+		}
+		// active 有数据库默认值，false 需要单独更新
+		if !active {
+			auth.Active = false
+			return tx.Model(&domain.Auth{}).Where("id = ?", auth.ID).Update("active", false).Error
+		}
+		return nil
+	})
+}
+
+func (r *AuthRepo) UpdateSCIMAuth(ctx context.Context, auth *domain.Auth) error {
+	return r.db.WithContext(ctx).Model(&domain.Auth{}).
+		Where("kb_id = ? AND id = ?", auth.KBID, auth.ID).
+		Updates(map[string]any{
+			"union_id":    auth.UnionID,
+			"user_name":   auth.UserName,
+			"external_id": auth.ExternalID,
+			"user_info":   auth.UserInfo,
+			"active":      auth.Active,
+			"updated_at":  gorm.Expr("now()"),
+		}).Error
+}
+
+// DeleteAuthWithGroups 删除用户并移出所有分组
+func (r *AuthRepo) DeleteAuthWithGroups(ctx context.Context, kbID string, authID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kb_id = ? AND id = ?", kbID, authID).Delete(&domain.Auth{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ? AND ? = ANY(auth_ids)", kbID, authID).
+			Update("auth_ids", gorm.Expr("array_remove(auth_ids, ?)", authID)).Error
+	})
+}
+
+// GetAuthGroupsBySourceType 分页获取指定身份源的分组，column 为空时不过滤
+func (r *AuthRepo) GetAuthGroupsBySourceType(ctx context.Context, kbID string, sourceType consts.SourceType, column, value string, offset, limit int) ([]domain.AuthGroup, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.AuthGroup{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType)
+	if column != "" {
+		query = query.Where(fmt.Sprintf("%s = ?", column), value)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]domain.AuthGroup, 0)
+	if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+func (r *AuthRepo) GetAuthGroup(ctx context.Context, kbID string, id uint) (*domain.AuthGroup, error) {
+	var group domain.AuthGroup
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&group).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// GetAuthsByIDs 获取知识库下指定身份源的用户，不存在的ID会被忽略
+func (r *AuthRepo) GetAuthsByIDs(ctx context.Context, kbID string, sourceType consts.SourceType, ids []uint) ([]domain.Auth, error) {
+	auths := make([]domain.Auth, 0)
+	if len(ids) == 0 {
+		return auths, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND source_type = ? AND id IN (?)", kbID, sourceType, ids).
+		Find(&auths).Error; err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+// GetAuthGroupsByIDs 获取知识库下指定身份源的分组，不存在的ID会被忽略
+func (r *AuthRepo) GetAuthGroupsByIDs(ctx context.Context, kbID string, sourceType consts.SourceType, ids []uint) ([]domain.AuthGroup, error) {
+	groups := make([]domain.AuthGroup, 0)
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND source_type = ? AND id IN (?)", kbID, sourceType, ids).
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *AuthRepo) GetChildAuthGroups(ctx context.Context, kbID string, parentID uint) ([]domain.AuthGroup, error) {
+	groups := make([]domain.AuthGroup, 0)
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND parent_id = ?", kbID, parentID).
+		Order("position ASC").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// SaveSCIMAuthGroup 创建或更新分组，并将 childIDs 设置为该分组的全部子分组
+func (r *AuthRepo) SaveSCIMAuthGroup(ctx context.Context, group *domain.AuthGroup, childIDs []uint) error {
+	if group.ID != 0 && lo.Contains(childIDs, group.ID) {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "group can not be member of itself")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if group.ID != 0 && len(childIDs) > 0 {
+			var groups []domain.AuthGroup
+			if err := tx.Model(&domain.AuthGroup{}).
+				Where("kb_id = ?", group.KbID).
+				Select("id, parent_id").
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Find(&groups).Error; err != nil {
+				return err
+			}
+			if id, ok := findAncestor(groups, group.ID, childIDs); ok {
+				return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, fmt.Sprintf("group %d is an ancestor of this group", id))
+			}
+		}
+
+		if group.ID == 0 {
+			var maxPos float64
+			if err := tx.Model(&domain.AuthGroup{}).
+				Where("kb_id = ?", group.KbID).
+				Select("COALESCE(MAX(position), 0)").
+				Scan(&maxPos).Error; err != nil {
+				return err
+			}
+			group.Position = maxPos + 1000
+			if group.AuthIDs == nil {
+				group.AuthIDs = pq.Int64Array{}
+			}
+			if err := tx.Create(group).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&domain.AuthGroup{}).
+				Where("kb_id = ? AND id = ?", group.KbID, group.ID).
+				Updates(map[string]any{
+					"name":       group.Name,
+					"sync_id":    group.SyncId,
+					"auth_ids":   group.AuthIDs,
+					"updated_at": gorm.Expr("now()"),
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ? AND parent_id = ?", group.KbID, group.ID).
+			Where("id NOT IN (?)", append(childIDs, 0)).
+			Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if len(childIDs) > 0 {
+			if err := tx.Model(&domain.AuthGroup{}).
+				Where("kb_id = ? AND id IN (?)", group.KbID, childIDs).
+				Update("parent_id", group.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findAncestor 返回 ids 中属于 groupID 祖先的分组，将其设为子分组会使分组成环
+func findAncestor(groups []domain.AuthGroup, groupID uint, ids []uint) (uint, bool) {
+	parentOf := make(map[uint]uint, len(groups))
+	for _, g := range groups {
+		if g.ParentID != nil {
+			parentOf[g.ID] = *g.ParentID
+		}
+	}
+	seen := map[uint]bool{groupID: true}
+	for id, ok := parentOf[groupID]; ok && !seen[id]; id, ok = parentOf[id] {
+		if lo.Contains(ids, id) {
+			return id, true
+		}
+		seen[id] = true
+	}
+	return 0, false
+}
+
+// DeleteAuthGroup 删除分组及其文档权限，子分组提升为顶级分组
+func (r *AuthRepo) DeleteAuthGroup(ctx context.Context, kbID string, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("kb_id = ? AND id = ?", kbID, id).Delete(&domain.AuthGroup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("auth_group_id = ?", id).Delete(&domain.NodeAuthGroup{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.AuthGroup{}).
+			Where("kb_id = ? AND parent_id = ?", kbID, id).
+			Update("parent_id", nil).Error
+	})
+}
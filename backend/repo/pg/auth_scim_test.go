@@ -0,0 +1,45 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func TestFindAncestor(t *testing.T) {
+	parent := func(id uint) *uint { return &id }
+	// 1 -> 2 -> 3，4 独立，5 -> 6 -> 5 为已有的环
+	groups := []domain.AuthGroup{
+		{ID: 1},
+		{ID: 2, ParentID: parent(1)},
+		{ID: 3, ParentID: parent(2)},
+		{ID: 4},
+		{ID: 5, ParentID: parent(6)},
+		{ID: 6, ParentID: parent(5)},
+	}
+	tests := []struct {
+		name     string
+		groupID  uint
+		ids      []uint
+		ancestor uint
+		found    bool
+	}{
+		{"direct parent", 2, []uint{1}, 1, true},
+		{"grandparent", 3, []uint{4, 1}, 1, true},
+		{"descendant", 1, []uint{2, 3}, 0, false},
+		{"unrelated", 3, []uint{4}, 0, false},
+		{"root group", 1, []uint{4}, 0, false},
+		{"existing cycle terminates", 5, []uint{4}, 0, false},
+		{"unknown group", 9, []uint{1}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ancestor, found := findAncestor(groups, tt.groupID, tt.ids)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.ancestor, ancestor)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type CuratedAnswerRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewCuratedAnswerRepository(db *pg.DB, logger *log.Logger) *CuratedAnswerRepository {
+	return &CuratedAnswerRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.curated_answer"),
+	}
+}
+
+func (r *CuratedAnswerRepository) GetList(ctx context.Context, req *v1.CuratedAnswerListReq) ([]*domain.CuratedAnswer, uint64, error) {
+	answers := make([]*domain.CuratedAnswer, 0)
+	query := r.db.WithContext(ctx).
+		Model(&domain.CuratedAnswer{}).
+		Where("kb_id = ?", req.KbID)
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("question LIKE ? OR answer LIKE ? OR array_to_string(alternates, ' ') LIKE ?", keyword, keyword, keyword)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Offset(req.Offset()).
+		Limit(req.Limit()).
+		Order("created_at DESC").
+		Find(&answers).Error; err != nil {
+		return nil, 0, err
+	}
+	return answers, uint64(count), nil
+}
+
+func (r *CuratedAnswerRepository) GetByID(ctx context.Context, kbID, id string) (*domain.CuratedAnswer, error) {
+	var answer domain.CuratedAnswer
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&answer).Error; err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+func (r *CuratedAnswerRepository) GetByKBID(ctx context.Context, kbID string) ([]*domain.CuratedAnswer, error) {
+	var answers []*domain.CuratedAnswer
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Find(&answers).Error; err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+// GetEnabledByDocID 根据命中的检索记录查询启用的标准答案
+func (r *CuratedAnswerRepository) GetEnabledByDocID(ctx context.Context, kbID, docID string) (*domain.CuratedAnswer, error) {
+	var answer domain.CuratedAnswer
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND enabled AND ? = ANY(doc_ids)", kbID, docID).
+		First(&answer).Error; err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+func (r *CuratedAnswerRepository) Create(ctx context.Context, answer *domain.CuratedAnswer) error {
+	if answer.DocIDs == nil {
+		answer.DocIDs = pq.StringArray{}
+	}
+	return r.db.WithContext(ctx).Create(answer).Error
+}
+
+func (r *CuratedAnswerRepository) Update(ctx context.Context, answer *domain.CuratedAnswer) error {
+	answer.UpdatedAt = time.Now()
+	if answer.DocIDs == nil {
+		answer.DocIDs = pq.StringArray{}
+	}
+	return r.db.WithContext(ctx).
+		Model(answer).
+		Select("question", "alternates", "answer", "node_ids", "enabled", "doc_ids", "updated_at").
+		Updates(answer).Error
+}
+
+func (r *CuratedAnswerRepository) UpdateDocIDs(ctx context.Context, id string, docIDs []string) error {
+	if docIDs == nil {
+		docIDs = []string{}
+	}
+	return r.db.WithContext(ctx).
+		Model(&domain.CuratedAnswer{}).
+		Where("id = ?", id).
+		Update("doc_ids", pq.StringArray(docIDs)).Error
+}
+
+func (r *CuratedAnswerRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kb_id = ? AND answer_id = ?", kbID, id).
+			Delete(&domain.CuratedAnswerHit{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("kb_id = ? AND id = ?", kbID, id).
+			Delete(&domain.CuratedAnswer{}).Error
+	})
+}
+
+// RecordHit 写入命中记录并累加命中次数
+func (r *CuratedAnswerRepository) RecordHit(ctx context.Context, hit *domain.CuratedAnswerHit) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hit).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.CuratedAnswer{}).
+			Where("id = ?", hit.AnswerID).
+			Updates(map[string]any{
+				"hit_count":   gorm.Expr("hit_count + 1"),
+				"last_hit_at": hit.CreatedAt,
+			}).Error
+	})
+}
+
+func (r *CuratedAnswerRepository) GetHitList(ctx context.Context, req *v1.CuratedAnswerHitListReq) ([]*domain.CuratedAnswerHit, uint64, error) {
+	hits := make([]*domain.CuratedAnswerHit, 0)
+	query := r.db.WithContext(ctx).
+		Model(&domain.CuratedAnswerHit{}).
+		Where("kb_id = ?", req.KbID)
+	if req.AnswerID != "" {
+		query = query.Where("answer_id = ?", req.AnswerID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Offset(req.Offset()).
+		Limit(req.Limit()).
+		Order("created_at DESC").
+		Find(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+	return hits, uint64(count), nil
+}
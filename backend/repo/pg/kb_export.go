@@ -0,0 +1,81 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type KBExportRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewKBExportRepository(db *pg.DB, logger *log.Logger) *KBExportRepository {
+	return &KBExportRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.kb_export"),
+	}
+}
+
+func (r *KBExportRepository) Create(ctx context.Context, export *domain.KBReleaseExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *KBExportRepository) GetByID(ctx context.Context, kbID, id string) (*domain.KBReleaseExport, error) {
+	var export domain.KBReleaseExport
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *KBExportRepository) GetList(ctx context.Context, kbID string) ([]*v1.KBReleaseExportListItem, error) {
+	var exports []*v1.KBReleaseExportListItem
+	if err := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseExport{}).
+		Joins("LEFT JOIN kb_releases ON kb_releases.id = kb_release_exports.release_id").
+		Where("kb_release_exports.kb_id = ?", kbID).
+		Select("kb_release_exports.*, kb_releases.tag as release_tag").
+		Order("kb_release_exports.created_at DESC").
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// Start 将任务标记为执行中，返回是否抢到任务；执行中的任务超过 staleBefore 未更新时视为中断，可重新执行
+func (r *KBExportRepository) Start(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseExport{}).
+		Where("id = ?", id).
+		Where("status = ? OR (status = ? AND updated_at < ?)", domain.KBExportStatusPending, domain.KBExportStatusRunning, staleBefore).
+		Updates(map[string]any{
+			"status":     domain.KBExportStatusRunning,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *KBExportRepository) Update(ctx context.Context, id string, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.KBReleaseExport{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *KBExportRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Delete(&domain.KBReleaseExport{}).Error
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/store/pg"
 	"github.com/chaitin/panda-wiki/store/rag"
+	"github.com/chaitin/panda-wiki/store/s3"
 )
 
 type KnowledgeBaseRepository struct {
@@ -102,6 +103,9 @@ func (r *KnowledgeBaseRepository) SyncKBAccessSettingsToCaddy(ctx context.Contex
 	api := fmt.Sprintf("%s.2:8000", subnetPrefix)
 	app := fmt.Sprintf("%s.112:3010", subnetPrefix)
 	staticFile := fmt.Sprintf("%s.12:9000", subnetPrefix) // minio
+	if r.config.S3.Type == s3.StoreTypeLocal {
+		staticFile = api
+	}
 	servers := make(map[string]any, 0)
 	for port, hostKBMap := range portHostKBMap {
 		trustProxies := make([]string, 0)
@@ -548,6 +552,13 @@ func (r *KnowledgeBaseRepository) UpdateDatasetID(ctx context.Context, kbID, dat
 		Update("dataset_id", datasetID).Error
 }
 
+func (r *KnowledgeBaseRepository) UpdateAnswerDatasetID(ctx context.Context, kbID, datasetID string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("answer_dataset_id", datasetID).Error
+}
+
 func (r *KnowledgeBaseRepository) UpdateKnowledgeBase(ctx context.Context, req *domain.UpdateKnowledgeBaseReq) (bool, error) {
 	var isChanged bool
 	kb, err := r.GetKnowledgeBaseByID(ctx, req.ID)
@@ -605,11 +616,85 @@ func (r *KnowledgeBaseRepository) GetKnowledgeBaseByID(ctx context.Context, kbID
 	return &kb, nil
 }
 
+func (r *KnowledgeBaseRepository) UpdateStorageQuota(ctx context.Context, kbID string, quota int64) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("storage_quota", quota).Error
+}
+
+func (r *KnowledgeBaseRepository) UpdateRecycleRetentionDays(ctx context.Context, kbID string, days int) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("recycle_retention_days", days).Error
+}
+
+func (r *KnowledgeBaseRepository) UpdateNodeMetaSchema(ctx context.Context, kbID string, schema *domain.NodeMetaSchema) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("node_meta_schema", schema).Error
+}
+
+func (r *KnowledgeBaseRepository) UpdateReviewSetting(ctx context.Context, kbID string, setting *domain.NodeReviewSetting) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("review_setting", setting).Error
+}
+
+func (r *KnowledgeBaseRepository) UpdateFAQSetting(ctx context.Context, kbID string, setting *domain.NodeFAQSetting) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("faq_setting", setting).Error
+}
+
+func (r *KnowledgeBaseRepository) UpdateCuratedAnswerSetting(ctx context.Context, kbID string, setting *domain.CuratedAnswerSetting) error {
+	return r.db.WithContext(ctx).Model(&domain.KnowledgeBase{}).
+		Where("id = ?", kbID).
+		Update("curated_answer_setting", setting).Error
+}
+
+// GetReviewRemindKnowledgeBases 开启了过期文档提醒的知识库
+func (r *KnowledgeBaseRepository) GetReviewRemindKnowledgeBases(ctx context.Context) ([]*domain.KnowledgeBase, error) {
+	var kbs []*domain.KnowledgeBase
+	if err := r.db.WithContext(ctx).
+		Where("review_setting->>'enabled' = 'true'").
+		Find(&kbs).Error; err != nil {
+		return nil, err
+	}
+	return kbs, nil
+}
+
 func (r *KnowledgeBaseRepository) DeleteKnowledgeBase(ctx context.Context, kbID string) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.Node{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeRecycle{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeCollabState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeTemplate{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.NodeFAQ{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.CuratedAnswer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.CuratedAnswerHit{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.App{}).Error; err != nil {
 			return err
 		}
@@ -750,6 +835,16 @@ func (r *KnowledgeBaseRepository) GetLatestRelease(ctx context.Context, kbID str
 	return &release, nil
 }
 
+func (r *KnowledgeBaseRepository) GetKBRelease(ctx context.Context, kbID, releaseID string) (*domain.KBRelease, error) {
+	var release domain.KBRelease
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, releaseID).
+		First(&release).Error; err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
 func (r *KnowledgeBaseRepository) GetKBUserlist(ctx context.Context, kbID string) ([]v1.KBUserListItemResp, error) {
 	var users []v1.KBUserListItemResp
 	err := r.db.WithContext(ctx).
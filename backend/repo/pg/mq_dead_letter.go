@@ -0,0 +1,48 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type MQDeadLetterRepository struct {
+	db *pg.DB
+}
+
+func NewMQDeadLetterRepository(db *pg.DB) *MQDeadLetterRepository {
+	return &MQDeadLetterRepository{db: db}
+}
+
+func (r *MQDeadLetterRepository) Create(ctx context.Context, deadLetter *domain.MQDeadLetter) error {
+	return r.db.WithContext(ctx).Create(deadLetter).Error
+}
+
+func (r *MQDeadLetterRepository) GetList(ctx context.Context, kbID string, offset, limit int) ([]*domain.MQDeadLetter, int64, error) {
+	var total int64
+	deadLetters := make([]*domain.MQDeadLetter, 0)
+	query := r.db.WithContext(ctx).Model(&domain.MQDeadLetter{}).Where("kb_id = ?", kbID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deadLetters).Error; err != nil {
+		return nil, 0, err
+	}
+	return deadLetters, total, nil
+}
+
+func (r *MQDeadLetterRepository) GetByIDs(ctx context.Context, kbID string, ids []string) ([]*domain.MQDeadLetter, error) {
+	deadLetters := make([]*domain.MQDeadLetter, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.MQDeadLetter{}).
+		Where("kb_id = ? AND id IN ?", kbID, ids).
+		Order("created_at ASC").
+		Find(&deadLetters).Error; err != nil {
+		return nil, err
+	}
+	return deadLetters, nil
+}
+
+func (r *MQDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&domain.MQDeadLetter{}).Error
+}
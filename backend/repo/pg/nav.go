@@ -193,10 +193,15 @@ func (r *NavRepository) GetReleaseList(ctx context.Context, kbId string) ([]v1.N
 		return nil, err
 	}
 
+	return r.GetReleaseListByReleaseID(ctx, kbRelease.ID)
+}
+
+// GetReleaseListByReleaseID 获取指定发布版本中的栏目
+func (r *NavRepository) GetReleaseListByReleaseID(ctx context.Context, releaseID string) ([]v1.NavListResp, error) {
 	navs := make([]v1.NavListResp, 0)
 	if err := r.db.WithContext(ctx).
 		Model(&domain.NavRelease{}).
-		Where("release_id = ?", kbRelease.ID).
+		Where("release_id = ?", releaseID).
 		Select("nav_id as id, name, position").
 		Order("position ASC").
 		Find(&navs).Error; err != nil {
@@ -2,8 +2,11 @@ package pg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -33,96 +36,131 @@ func NewNodeRepository(db *pg.DB, logger *log.Logger) *NodeRepository {
 }
 
 func (r *NodeRepository) Create(ctx context.Context, req *domain.CreateNodeReq, userId string) (string, error) {
-	nodeID, err := uuid.NewV7()
+	var nodeID string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		nodeID, err = r.createTx(tx, req, userId)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
-	nodeIDStr := nodeID.String()
-	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// check count
-		var count int64
-		if err := tx.Model(&domain.Node{}).
-			Where("kb_id = ?", req.KBID).
-			Count(&count).Error; err != nil {
-			return err
-		}
-		if count >= int64(req.MaxNode) {
-			return domain.ErrMaxNodeLimitReached
-		}
-		var maxPos float64
-		query := tx.WithContext(ctx).
-			Model(&domain.Node{}).
-			Where("kb_id = ?", req.KBID)
 
-		if req.ParentID == "" {
-			query = query.Where("parent_id IS NULL OR parent_id = ''")
-		} else {
-			query = query.Where("parent_id = ?", req.ParentID)
+	return nodeID, nil
+}
+
+// CreateNodes 在同一事务中按顺序创建节点，子节点需排在父节点之后并预先指定 ID
+func (r *NodeRepository) CreateNodes(ctx context.Context, reqs []*domain.CreateNodeReq, userId string) ([]string, error) {
+	nodeIDs := make([]string, 0, len(reqs))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, req := range reqs {
+			nodeID, err := r.createTx(tx, req, userId)
+			if err != nil {
+				return err
+			}
+			nodeIDs = append(nodeIDs, nodeID)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodeIDs, nil
+}
 
-		if err := query.
-			Select("COALESCE(MAX(position::float), 0)").
-			Scan(&maxPos).Error; err != nil {
-			return err
+func (r *NodeRepository) createTx(tx *gorm.DB, req *domain.CreateNodeReq, userId string) (string, error) {
+	nodeIDStr := req.ID
+	if nodeIDStr == "" {
+		nodeID, err := uuid.NewV7()
+		if err != nil {
+			return "", err
 		}
+		nodeIDStr = nodeID.String()
+	}
+	// check count
+	var count int64
+	if err := tx.Model(&domain.Node{}).
+		Where("kb_id = ?", req.KBID).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count >= int64(req.MaxNode) {
+		return "", domain.ErrMaxNodeLimitReached
+	}
+	var maxPos float64
+	query := tx.Model(&domain.Node{}).
+		Where("kb_id = ?", req.KBID)
 
-		var newPos float64
-		if req.Position != nil { // user specify position
-			if *req.Position > domain.MaxPosition || *req.Position < 0 {
-				return errors.New("specified position is out of range")
-			}
-			newPos = *req.Position
-		} else { // default the last
-			newPos = maxPos + (domain.MaxPosition-maxPos)/2.0
-			if newPos-maxPos < domain.MinPositionGap {
-				if err := r.reorderPositionsByParentID(tx, req.KBID, req.ParentID); err != nil {
-					return err
-				}
+	if req.ParentID == "" {
+		query = query.Where("parent_id IS NULL OR parent_id = ''")
+	} else {
+		query = query.Where("parent_id = ?", req.ParentID)
+	}
+
+	if err := query.
+		Select("COALESCE(MAX(position::float), 0)").
+		Scan(&maxPos).Error; err != nil {
+		return "", err
+	}
+
+	var newPos float64
+	if req.Position != nil { // user specify position
+		if *req.Position > domain.MaxPosition || *req.Position < 0 {
+			return "", errors.New("specified position is out of range")
+		}
+		newPos = *req.Position
+	} else { // default the last
+		newPos = maxPos + (domain.MaxPosition-maxPos)/2.0
+		if newPos-maxPos < domain.MinPositionGap {
+			if err := r.reorderPositionsByParentID(tx, req.KBID, req.ParentID); err != nil {
+				return "", err
 			}
 		}
+	}
 
-		now := time.Now()
-		meta := domain.NodeMeta{Emoji: req.Emoji}
-		if req.Summary != nil {
-			meta.Summary = *req.Summary
-		}
-		if req.ContentType != nil {
-			meta.ContentType = *req.ContentType
-		}
-
-		node := &domain.Node{
-			ID:        nodeIDStr,
-			KBID:      req.KBID,
-			NavId:     req.NavId,
-			Name:      req.Name,
-			Content:   req.Content,
-			Meta:      meta,
-			Type:      req.Type,
-			ParentID:  req.ParentID,
-			Position:  newPos,
-			Status:    domain.NodeStatusUnreleased,
-			CreatorId: userId,
-			EditorId:  userId,
-			CreatedAt: now,
-			UpdatedAt: now,
-			EditTime:  now,
-			RagInfo: domain.RagInfo{
-				Status:  consts.NodeRagStatusPending,
-				Message: "",
-			},
-			Permissions: domain.NodePermissions{
-				Answerable: consts.NodeAccessPermOpen,
-				Visitable:  consts.NodeAccessPermOpen,
-				Visible:    consts.NodeAccessPermOpen,
-			},
-		}
-
-		return tx.Create(node).Error
-	})
-	if err != nil {
+	now := time.Now()
+	meta := domain.NodeMeta{Emoji: req.Emoji}
+	if req.Summary != nil {
+		meta.Summary = *req.Summary
+	}
+	if req.ContentType != nil {
+		meta.ContentType = *req.ContentType
+	}
+	permissions := domain.NodePermissions{
+		Answerable: consts.NodeAccessPermOpen,
+		Visitable:  consts.NodeAccessPermOpen,
+		Visible:    consts.NodeAccessPermOpen,
+	}
+	if req.Permissions != nil {
+		permissions = *req.Permissions
+	}
+
+	node := &domain.Node{
+		ID:        nodeIDStr,
+		KBID:      req.KBID,
+		NavId:     req.NavId,
+		Name:      req.Name,
+		Content:   req.Content,
+		Meta:      meta,
+		Type:      req.Type,
+		ParentID:  req.ParentID,
+		Position:  newPos,
+		Status:    domain.NodeStatusUnreleased,
+		CreatorId: userId,
+		EditorId:  userId,
+		CreatedAt: now,
+		UpdatedAt: now,
+		EditTime:  now,
+		RagInfo: domain.RagInfo{
+			Status:  consts.NodeRagStatusPending,
+			Message: "",
+		},
+		Permissions: permissions,
+	}
+
+	if err := tx.Create(node).Error; err != nil {
 		return "", err
 	}
-
 	return nodeIDStr, nil
 }
 
@@ -133,7 +171,7 @@ func (r *NodeRepository) GetList(ctx context.Context, req *domain.GetNodeListReq
 		Joins("LEFT JOIN users cu ON nodes.creator_id = cu.id").
 		Joins("LEFT JOIN users eu ON nodes.editor_id = eu.id").
 		Where("nodes.kb_id = ?", req.KBID).
-		Select("cu.account AS creator, eu.account AS editor, nodes.editor_id, nodes.nav_id, nodes.rag_info, nodes.creator_id, nodes.id, nodes.permissions, nodes.type, nodes.status, nodes.name, nodes.parent_id, nodes.position, nodes.created_at, nodes.edit_time as updated_at, nodes.meta->>'summary' as summary, nodes.meta->>'emoji' as emoji, nodes.meta->>'content_type' as content_type")
+		Select("cu.account AS creator, eu.account AS editor, nodes.editor_id, nodes.nav_id, nodes.rag_info, nodes.creator_id, nodes.id, nodes.permissions, nodes.type, nodes.status, nodes.name, nodes.parent_id, nodes.position, nodes.created_at, nodes.edit_time as updated_at, nodes.meta->>'summary' as summary, nodes.meta->>'emoji' as emoji, nodes.meta->>'content_type' as content_type, nodes.meta")
 	if req.Search != "" {
 		searchPattern := "%" + req.Search + "%"
 		query = query.Where("name LIKE ? OR content LIKE ?", searchPattern, searchPattern)
@@ -141,9 +179,16 @@ func (r *NodeRepository) GetList(ctx context.Context, req *domain.GetNodeListReq
 	if req.NavId != "" {
 		query = query.Where("nodes.nav_id = ?", req.NavId)
 	}
+	if !req.NodeMetaFilter.IsEmpty() {
+		query = query.Where("nodes.meta @> ?::jsonb", req.NodeMetaFilter.Containment())
+	}
 	if err := query.Find(&nodes).Error; err != nil {
 		return nil, err
 	}
+	for _, node := range nodes {
+		node.Tags = node.Meta.Tags
+		node.Fields = node.Meta.Fields
+	}
 	return nodes, nil
 }
 
@@ -187,7 +232,10 @@ func (r *NodeRepository) GetNodeReleasePublisherMap(ctx context.Context, kbID st
 	return publisherMap, nil
 }
 
-func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.UpdateNodeReq, userId string) error {
+// UpdateNodeContent 更新文档，返回更新后的修订号；修改名称或内容时未传修订号返回 domain.ErrNodeRevisionRequired，
+// 修订号过期时返回 *domain.NodeConflictError
+func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.UpdateNodeReq, userId string) (int64, error) {
+	var revision int64
 	// Use transaction to ensure data consistency
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Get current node data with row-level lock
@@ -200,6 +248,19 @@ func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.Upda
 			First(&currentNode).Error; err != nil {
 			return err
 		}
+		revision = currentNode.Revision
+
+		// 名称或内容有修改时，修订号必须与当前一致
+		nameChanged := req.Name != nil && *req.Name != currentNode.Name
+		contentChanged := req.Content != nil && *req.Content != currentNode.Content
+		if nameChanged || contentChanged {
+			if req.Revision == nil {
+				return domain.ErrNodeRevisionRequired
+			}
+			if *req.Revision != currentNode.Revision {
+				return &domain.NodeConflictError{BaseRevision: *req.Revision, Current: &currentNode}
+			}
+		}
 
 		updateMap := make(map[string]any)
 		updateStatus := false
@@ -232,7 +293,7 @@ func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.Upda
 		}
 
 		// Handle multiple meta field updates
-		if req.Emoji != nil || req.Summary != nil || req.ContentType != nil {
+		if req.Emoji != nil || req.Summary != nil || req.ContentType != nil || req.Tags != nil || req.Fields != nil {
 			metaExpr := "meta"
 			var args []any
 			metaUpdated := false
@@ -263,6 +324,40 @@ func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.Upda
 				}
 			}
 
+			if req.Tags != nil && !slices.Equal(*req.Tags, currentNode.Meta.Tags) {
+				tags, err := json.Marshal(*req.Tags)
+				if err != nil {
+					return err
+				}
+				metaExpr = "jsonb_set(" + metaExpr + ", '{tags}', ?::jsonb)"
+				args = append(args, string(tags))
+				metaUpdated = true
+			}
+
+			// 字段按键合并，值为空时删除
+			if req.Fields != nil {
+				fields := maps.Clone(currentNode.Meta.Fields)
+				if fields == nil {
+					fields = make(map[string]string)
+				}
+				for key, value := range *req.Fields {
+					if value == "" {
+						delete(fields, key)
+					} else {
+						fields[key] = value
+					}
+				}
+				if !maps.Equal(fields, currentNode.Meta.Fields) {
+					b, err := json.Marshal(fields)
+					if err != nil {
+						return err
+					}
+					metaExpr = "jsonb_set(" + metaExpr + ", '{fields}', ?::jsonb)"
+					args = append(args, string(b))
+					metaUpdated = true
+				}
+			}
+
 			if metaUpdated {
 				updateMap["meta"] = gorm.Expr(metaExpr, args...)
 				updateStatus = true
@@ -275,19 +370,70 @@ func (r *NodeRepository) UpdateNodeContent(ctx context.Context, req *domain.Upda
 			updateMap["edit_time"] = time.Now()
 		}
 
+		if nameChanged || contentChanged {
+			revision = currentNode.Revision + 1
+			updateMap["revision"] = revision
+		}
+
 		// Perform update if there are changes
 		if len(updateMap) > 0 {
 			// Use the transaction's DB instance for the update
-			return tx.Model(&domain.Node{}).
+			if err := tx.Model(&domain.Node{}).
 				Where("id = ?", req.ID).
 				Where("kb_id = ?", req.KBID).
-				Updates(updateMap).Error
+				Updates(updateMap).Error; err != nil {
+				return err
+			}
 		}
-		return nil
+		if revision == currentNode.Revision {
+			return nil
+		}
+		next := domain.NodeRevision{
+			NodeID:   currentNode.ID,
+			Revision: revision,
+			KBID:     currentNode.KBID,
+			Name:     lo.FromPtrOr(req.Name, currentNode.Name),
+			Content:  lo.FromPtrOr(req.Content, currentNode.Content),
+			EditorId: userId,
+		}
+		return r.saveNodeRevisionTx(tx, &currentNode, &next)
 	})
 
 	// Return any error from the transaction
-	return err
+	return revision, err
+}
+
+// saveNodeRevisionTx 记录新的修订，升级前创建的文档首次保存时补录原有内容
+func (r *NodeRepository) saveNodeRevisionTx(tx *gorm.DB, current *domain.Node, next *domain.NodeRevision) error {
+	prev := domain.NodeRevision{
+		NodeID:    current.ID,
+		Revision:  current.Revision,
+		KBID:      current.KBID,
+		Name:      current.Name,
+		Content:   current.Content,
+		EditorId:  current.EditorId,
+		CreatedAt: current.UpdatedAt,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&prev).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(next).Error; err != nil {
+		return err
+	}
+	return tx.Where("node_id = ?", next.NodeID).
+		Where("revision <= ?", next.Revision-domain.NodeRevisionKeep).
+		Delete(&domain.NodeRevision{}).Error
+}
+
+func (r *NodeRepository) GetNodeRevision(ctx context.Context, nodeID string, revision int64) (*domain.NodeRevision, error) {
+	var nodeRevision domain.NodeRevision
+	if err := r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		Where("revision = ?", revision).
+		First(&nodeRevision).Error; err != nil {
+		return nil, err
+	}
+	return &nodeRevision, nil
 }
 
 func (r *NodeRepository) GetByID(ctx context.Context, id, kbId string) (*v1.NodeDetailResp, error) {
@@ -305,12 +451,17 @@ func (r *NodeRepository) GetByID(ctx context.Context, id, kbId string) (*v1.Node
 	return node, nil
 }
 
-func (r *NodeRepository) Delete(ctx context.Context, kbID string, ids []string) ([]string, error) {
+// Delete 将节点及其子节点移入回收站，返回需要从 RAG 删除的 doc_id
+func (r *NodeRepository) Delete(ctx context.Context, kbID string, ids []string, deletedBy string) ([]string, error) {
 	docIDs := make([]string, 0)
 	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// recursively collect all child node IDs
 		allIDs := r.collectAllChildNodeIDs(tx, kbID, ids)
 
+		if err := r.recycleNodesTx(tx, kbID, allIDs, deletedBy); err != nil {
+			return err
+		}
+
 		var nodes []*domain.Node
 		if err := tx.Model(&domain.Node{}).
 			Where("id IN ?", allIDs).
@@ -410,6 +561,11 @@ func (r *NodeRepository) collectAllChildNodeIDs(tx *gorm.DB, kbID string, parent
 	return lo.Uniq(allIDs)
 }
 
+// GetSubtreeNodeIDs 返回节点及其所有子孙节点的 ID
+func (r *NodeRepository) GetSubtreeNodeIDs(ctx context.Context, kbID, rootID string) []string {
+	return r.collectAllChildNodeIDs(r.db.WithContext(ctx), kbID, []string{rootID})
+}
+
 func (r *NodeRepository) GetNodeByID(ctx context.Context, id string) (*domain.Node, error) {
 	var node *domain.Node
 	if err := r.db.WithContext(ctx).
@@ -421,6 +577,18 @@ func (r *NodeRepository) GetNodeByID(ctx context.Context, id string) (*domain.No
 	return node, nil
 }
 
+// NodeExistsInKB 文档是否属于该知识库
+func (r *NodeRepository) NodeExistsInKB(ctx context.Context, kbID, nodeID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Node{}).
+		Where("kb_id = ? AND id = ?", kbID, nodeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetNodesByIDs retrieves nodes by their IDs
 func (r *NodeRepository) GetNodesByIDs(ctx context.Context, ids []string) (map[string]*domain.Node, error) {
 	if len(ids) == 0 {
@@ -581,6 +749,21 @@ func (r *NodeRepository) GetNodeReleasesByDocIDs(ctx context.Context, ids []stri
 	return nodesMap, nil
 }
 
+func (r *NodeRepository) GetNodeReleasesByIDs(ctx context.Context, ids []string) (map[string]*domain.NodeRelease, error) {
+	var nodeReleases []*domain.NodeRelease
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeRelease{}).
+		Where("id IN ?", ids).
+		Find(&nodeReleases).Error; err != nil {
+		return nil, err
+	}
+	nodesMap := make(map[string]*domain.NodeRelease, len(nodeReleases))
+	for _, nodeRelease := range nodeReleases {
+		nodesMap[nodeRelease.ID] = nodeRelease
+	}
+	return nodesMap, nil
+}
+
 // NodeReleaseWithPath represents a node release with path information
 type NodeReleaseWithPath struct {
 	*domain.NodeRelease
@@ -802,6 +985,103 @@ func (r *NodeRepository) GetNodeReleaseListByKBID(ctx context.Context, kbID stri
 	return nodes, nil
 }
 
+// GetNodeReleaseFeedItems 获取指定发布版本中的文档，按当前版本的发布时间倒序，
+// 仅返回公开的文档，以及 visibleNodeIDs、visitableNodeIDs 中对应权限部分公开的文档
+func (r *NodeRepository) GetNodeReleaseFeedItems(ctx context.Context, kbID, releaseID, navID string, visibleNodeIDs, visitableNodeIDs []string, limit int) ([]*domain.NodeReleaseFeedItem, error) {
+	var items []*domain.NodeReleaseFeedItem
+	query := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseNodeRelease{}).
+		Joins("LEFT JOIN node_releases ON node_releases.id = kb_release_node_releases.node_release_id").
+		Joins("LEFT JOIN nodes ON nodes.id = kb_release_node_releases.node_id").
+		Where("kb_release_node_releases.kb_id = ?", kbID).
+		Where("kb_release_node_releases.release_id = ?", releaseID).
+		Where("node_releases.type = ?", domain.NodeTypeDocument)
+	if navID != "" {
+		query = query.Where("kb_release_node_releases.nav_id = ?", navID)
+	}
+	query = wherePermitted(query, consts.NodePermNameVisible, visibleNodeIDs)
+	query = wherePermitted(query, consts.NodePermNameVisitable, visitableNodeIDs)
+	if err := query.
+		Select(`node_releases.node_id as id, node_releases.id as node_release_id, node_releases.name,
+			node_releases.meta->>'summary' as summary, kb_release_node_releases.nav_id, nodes.permissions,
+			(SELECT MIN(kb_releases.created_at) FROM kb_release_node_releases r
+				JOIN kb_releases ON kb_releases.id = r.release_id
+				WHERE r.kb_id = kb_release_node_releases.kb_id AND r.node_id = kb_release_node_releases.node_id) as first_published_at,
+			(SELECT MIN(kb_releases.created_at) FROM kb_release_node_releases r
+				JOIN kb_releases ON kb_releases.id = r.release_id
+				WHERE r.node_release_id = kb_release_node_releases.node_release_id) as published_at`).
+		Order("published_at DESC, node_releases.updated_at DESC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// wherePermitted 节点的 perm 权限为公开，或部分公开且在 partialNodeIDs 中
+func wherePermitted(query *gorm.DB, perm consts.NodePermName, partialNodeIDs []string) *gorm.DB {
+	column := fmt.Sprintf("nodes.permissions->>'%s'", perm)
+	if len(partialNodeIDs) == 0 {
+		return query.Where(column+" = ?", consts.NodeAccessPermOpen)
+	}
+	return query.Where(fmt.Sprintf("(%s = ? OR (%s = ? AND nodes.id IN ?))", column, column),
+		consts.NodeAccessPermOpen, consts.NodeAccessPermPartial, partialNodeIDs)
+}
+
+// publicNodeReleaseQuery 指定发布版本中导航内公开可见的节点，供搜索引擎和 AI 爬虫读取
+func (r *NodeRepository) publicNodeReleaseQuery(ctx context.Context, kbID, releaseID string) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Model(&domain.KBReleaseNodeRelease{}).
+		Joins("LEFT JOIN node_releases ON node_releases.id = kb_release_node_releases.node_release_id").
+		Joins("LEFT JOIN nodes ON nodes.id = kb_release_node_releases.node_id").
+		Where("kb_release_node_releases.kb_id = ?", kbID).
+		Where("kb_release_node_releases.release_id = ?", releaseID).
+		Where("nodes.permissions->>'visible' = ?", consts.NodeAccessPermOpen)
+}
+
+// GetPublicNodeReleaseList 获取发布版本中所有导航内公开可见的文档和文件夹，不检查上级节点的权限
+func (r *NodeRepository) GetPublicNodeReleaseList(ctx context.Context, kbID, releaseID string) ([]*domain.ShareNodeListItemResp, error) {
+	var nodes []*domain.ShareNodeListItemResp
+	if err := r.publicNodeReleaseQuery(ctx, kbID, releaseID).
+		Select("node_releases.node_id as id, node_releases.name, node_releases.type, node_releases.parent_id, nodes.position, node_releases.meta, node_releases.updated_at, nodes.permissions, kb_release_node_releases.nav_id").
+		Order("nodes.position ASC").
+		Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// GetPublicDocReleasesByNodeIDs 获取公开可访问文档的正文
+func (r *NodeRepository) GetPublicDocReleasesByNodeIDs(ctx context.Context, kbID, releaseID string, nodeIDs []string) ([]*domain.NodeRelease, error) {
+	var releases []*domain.NodeRelease
+	if err := r.publicNodeReleaseQuery(ctx, kbID, releaseID).
+		Where("nodes.permissions->>'visitable' = ?", consts.NodeAccessPermOpen).
+		Where("node_releases.type = ?", domain.NodeTypeDocument).
+		Where("node_releases.node_id IN ?", nodeIDs).
+		Select("node_releases.*").
+		Find(&releases).Error; err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// GetExportNodesByReleaseID 获取发布版本中的所有节点，位置取发布时的值，已删除的节点没有权限信息
+func (r *NodeRepository) GetExportNodesByReleaseID(ctx context.Context, kbID, releaseID string) ([]*domain.KBExportNode, error) {
+	var nodes []*domain.KBExportNode
+	if err := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseNodeRelease{}).
+		Joins("LEFT JOIN node_releases ON node_releases.id = kb_release_node_releases.node_release_id").
+		Joins("LEFT JOIN nodes ON nodes.id = kb_release_node_releases.node_id").
+		Where("kb_release_node_releases.kb_id = ?", kbID).
+		Where("kb_release_node_releases.release_id = ?", releaseID).
+		Select("node_releases.node_id as id, node_releases.id as node_release_id, node_releases.name, node_releases.type, node_releases.parent_id, kb_release_node_releases.nav_id, node_releases.position, node_releases.meta, node_releases.updated_at, nodes.permissions").
+		Order("node_releases.position ASC").
+		Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
 func (r *NodeRepository) GetNodeReleaseDetailByKBIDAndID(ctx context.Context, kbID, id string) (*shareV1.ShareNodeDetailResp, error) {
 	// get kb release
 	var kbRelease *domain.KBRelease
@@ -995,6 +1275,52 @@ func (r *NodeRepository) CreateNodeReleases(ctx context.Context, kbID, userId st
 	return releaseIDs, nil
 }
 
+// ReplaceContentLinks 将文档内容中的 from 替换为 to，每篇文档增加修订号并记录修订，
+// 已发布的文档标记为未发布，返回修改的文档 ID
+func (r *NodeRepository) ReplaceContentLinks(ctx context.Context, kbID, from, to, userID string) ([]string, error) {
+	var nodeIDs []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var nodes []*domain.Node
+		if err := tx.Model(&domain.Node{}).
+			Where("kb_id = ?", kbID).
+			Where("strpos(content, ?) > 0", from).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Find(&nodes).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, node := range nodes {
+			content := strings.ReplaceAll(node.Content, from, to)
+			updates := map[string]any{
+				"content":   content,
+				"revision":  node.Revision + 1,
+				"edit_time": now,
+			}
+			if node.Status != domain.NodeStatusUnreleased {
+				updates["status"] = domain.NodeStatusDraft
+			}
+			if err := tx.Model(&domain.Node{}).
+				Where("id = ?", node.ID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := r.saveNodeRevisionTx(tx, node, &domain.NodeRevision{
+				NodeID:   node.ID,
+				Revision: node.Revision + 1,
+				KBID:     node.KBID,
+				Name:     node.Name,
+				Content:  content,
+				EditorId: userID,
+			}); err != nil {
+				return err
+			}
+			nodeIDs = append(nodeIDs, node.ID)
+		}
+		return nil
+	})
+	return nodeIDs, err
+}
+
 func (r *NodeRepository) GetOldNodeDocIDsByNodeID(ctx context.Context, nodeReleaseID, nodeID string) ([]string, error) {
 	var docIDs []string
 	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -1278,6 +1604,32 @@ func (r *NodeRepository) GetNodeIdsWithoutStatusByKbId(ctx context.Context, kbId
 	return docIds, nil
 }
 
+// GetNodeRagStatusCount 按知识库统计文档的向量化状态
+func (r *NodeRepository) GetNodeRagStatusCount(ctx context.Context) (map[string]map[consts.NodeRagInfoStatus]int64, error) {
+	var rows []struct {
+		KBID   string
+		Status consts.NodeRagInfoStatus
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Node{}).
+		Select("kb_id, rag_info ->> 'status' as status, COUNT(*) as count").
+		Where("type = ?", domain.NodeTypeDocument).
+		Where("rag_info ->> 'status' IS NOT NULL AND rag_info ->> 'status' != ''").
+		Group("kb_id, rag_info ->> 'status'").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]map[consts.NodeRagInfoStatus]int64)
+	for _, row := range rows {
+		if _, ok := counts[row.KBID]; !ok {
+			counts[row.KBID] = make(map[consts.NodeRagInfoStatus]int64)
+		}
+		counts[row.KBID][row.Status] = row.Count
+	}
+	return counts, nil
+}
+
 // GetNodeIdsByDocIds 批量获取 doc_id 到 node_id 的映射
 func (r *NodeRepository) GetNodeIdsByDocIds(ctx context.Context, docIds []string) (map[string]string, error) {
 	if len(docIds) == 0 {
@@ -0,0 +1,40 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// GetCollabState 返回文档保存的协同编辑状态，不存在时返回 nil
+func (r *NodeRepository) GetCollabState(ctx context.Context, nodeID string) (*domain.NodeCollabState, error) {
+	var state domain.NodeCollabState
+	if err := r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *NodeRepository) SaveCollabState(ctx context.Context, state *domain.NodeCollabState) error {
+	state.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "node_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "revision", "updated_at"}),
+	}).Create(state).Error
+}
+
+func (r *NodeRepository) DeleteCollabState(ctx context.Context, nodeID string) error {
+	return r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		Delete(&domain.NodeCollabState{}).Error
+}
@@ -0,0 +1,131 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type NodeFAQRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewNodeFAQRepository(db *pg.DB, logger *log.Logger) *NodeFAQRepository {
+	return &NodeFAQRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.node_faq"),
+	}
+}
+
+func (r *NodeFAQRepository) GetList(ctx context.Context, req *v1.NodeFAQListReq) ([]*v1.NodeFAQItem, error) {
+	items := make([]*v1.NodeFAQItem, 0)
+	query := r.db.WithContext(ctx).
+		Model(&domain.NodeFAQ{}).
+		Joins("JOIN nodes ON nodes.id = node_faqs.node_id").
+		Where("node_faqs.kb_id = ?", req.KbID)
+	if req.NodeID != "" {
+		query = query.Where("node_faqs.node_id = ?", req.NodeID)
+	}
+	if req.Status != "" {
+		query = query.Where("node_faqs.status = ?", req.Status)
+	}
+	if err := query.
+		Select("node_faqs.*, nodes.name AS node_name").
+		Order("node_faqs.created_at DESC, node_faqs.id").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *NodeFAQRepository) GetByIDs(ctx context.Context, kbID string, ids []string) ([]*domain.NodeFAQ, error) {
+	var faqs []*domain.NodeFAQ
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id IN ?", kbID, ids).
+		Order("created_at, id").
+		Find(&faqs).Error; err != nil {
+		return nil, err
+	}
+	return faqs, nil
+}
+
+func (r *NodeFAQRepository) GetByNodeID(ctx context.Context, nodeID string) ([]*domain.NodeFAQ, error) {
+	var faqs []*domain.NodeFAQ
+	if err := r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		Find(&faqs).Error; err != nil {
+		return nil, err
+	}
+	return faqs, nil
+}
+
+// ReplacePending 用新生成的问答对替换文档待审核的问答对
+func (r *NodeFAQRepository) ReplacePending(ctx context.Context, nodeID string, faqs []*domain.NodeFAQ) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("node_id = ? AND status = ?", nodeID, domain.NodeFAQStatusPending).
+			Delete(&domain.NodeFAQ{}).Error; err != nil {
+			return err
+		}
+		if len(faqs) == 0 {
+			return nil
+		}
+		return tx.Create(faqs).Error
+	})
+}
+
+func (r *NodeFAQRepository) Update(ctx context.Context, faq *domain.NodeFAQ) error {
+	faq.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).
+		Model(faq).
+		Select("question", "answer", "status", "doc_id", "parent_doc_id", "updated_at").
+		Updates(faq).Error
+}
+
+func (r *NodeFAQRepository) Delete(ctx context.Context, kbID string, ids []string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ? AND id IN ?", kbID, ids).
+		Delete(&domain.NodeFAQ{}).Error
+}
+
+func (r *NodeFAQRepository) GetByDocIDs(ctx context.Context, docIDs []string) ([]*domain.NodeFAQ, error) {
+	var faqs []*domain.NodeFAQ
+	if err := r.db.WithContext(ctx).
+		Where("doc_id IN ?", docIDs).
+		Find(&faqs).Error; err != nil {
+		return nil, err
+	}
+	return faqs, nil
+}
+
+func (r *NodeFAQRepository) GetByParentDocID(ctx context.Context, parentDocID string) ([]*domain.NodeFAQ, error) {
+	var faqs []*domain.NodeFAQ
+	if err := r.db.WithContext(ctx).
+		Where("parent_doc_id = ?", parentDocID).
+		Find(&faqs).Error; err != nil {
+		return nil, err
+	}
+	return faqs, nil
+}
+
+// UpdateDocsParent 文档重新发布后，已写入的问答对改为归属新的发布版本
+func (r *NodeFAQRepository) UpdateDocsParent(ctx context.Context, nodeID, parentDocID string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.NodeFAQ{}).
+		Where("node_id = ? AND doc_id != ''", nodeID).
+		Update("parent_doc_id", parentDocID).Error
+}
+
+// ClearDocs 检索记录删除后清空记录 ID，文档再次发布时重新写入
+func (r *NodeFAQRepository) ClearDocs(ctx context.Context, ids []string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.NodeFAQ{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"doc_id": "", "parent_doc_id": "", "updated_at": time.Now()}).Error
+}
@@ -0,0 +1,51 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+func TestGetNodeReleaseFeedItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		navID     string
+		visible   []string
+		visitable []string
+		where     string
+	}{
+		{
+			name:  "anonymous reader only gets open nodes",
+			where: "nodes.permissions->>'visible' = 'open' AND nodes.permissions->>'visitable' = 'open' ORDER BY",
+		},
+		{
+			name:      "partial nodes of the reader's groups",
+			visible:   []string{"n1", "n2"},
+			visitable: []string{"n1"},
+			where: "((nodes.permissions->>'visible' = 'open' OR (nodes.permissions->>'visible' = 'partial' AND nodes.id IN ('n1','n2')))) AND " +
+				"((nodes.permissions->>'visitable' = 'open' OR (nodes.permissions->>'visitable' = 'partial' AND nodes.id IN ('n1')))) ORDER BY",
+		},
+		{
+			name:  "nav filter",
+			navID: "nav1",
+			where: "AND kb_release_node_releases.nav_id = 'nav1' AND nodes.permissions->>'visible' = 'open'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := newDryRunDB(t)
+			r := &NodeRepository{db: &pg.DB{DB: db}}
+			_, err := r.GetNodeReleaseFeedItems(context.Background(), "kb", "rel", tt.navID, tt.visible, tt.visitable, 20)
+			require.NoError(t, err)
+			require.Len(t, recorder.sqls, 1)
+			assert.Contains(t, recorder.sqls[0], "WHERE kb_release_node_releases.kb_id = 'kb' AND kb_release_node_releases.release_id = 'rel' AND node_releases.type = 2")
+			assert.Contains(t, recorder.sqls[0], tt.where)
+			assert.Contains(t, recorder.sqls[0], "LIMIT 20")
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type NodeLinkRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewNodeLinkRepository(db *pg.DB, logger *log.Logger) *NodeLinkRepository {
+	return &NodeLinkRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.node_link"),
+	}
+}
+
+// ReplaceNodeLinks 用最新发布版本中的链接替换文档原有的链接
+func (r *NodeLinkRepository) ReplaceNodeLinks(ctx context.Context, nodeID string, links []*domain.NodeLink) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_node_id = ?", nodeID).Delete(&domain.NodeLink{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(links, 100).Error
+	})
+}
+
+func (r *NodeLinkRepository) GetBacklinks(ctx context.Context, kbID, nodeID string) ([]*v1.NodeBacklinkItem, error) {
+	var items []*v1.NodeBacklinkItem
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes ON nodes.id = node_links.source_node_id").
+		Where("node_links.kb_id = ?", kbID).
+		Where("node_links.target_node_id = ?", nodeID).
+		Where("node_links.source_node_id != ?", nodeID).
+		Select("DISTINCT ON (nodes.id) nodes.id, nodes.name, nodes.type, nodes.meta->>'emoji' AS emoji, node_links.url, nodes.updated_at").
+		Order("nodes.id, node_links.url").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetReferences 子树外的文档对子树中文档的链接
+func (r *NodeLinkRepository) GetReferences(ctx context.Context, kbID string, nodeIDs []string) ([]*domain.NodeReference, error) {
+	var refs []*domain.NodeReference
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes s ON s.id = node_links.source_node_id").
+		Joins("JOIN nodes t ON t.id = node_links.target_node_id").
+		Where("node_links.kb_id = ?", kbID).
+		Where("node_links.target_node_id IN ?", nodeIDs).
+		Where("node_links.source_node_id NOT IN ?", nodeIDs).
+		Select("s.id AS source_id, s.name AS source_name, t.id AS target_id, t.name AS target_name, node_links.url").
+		Order("s.name, t.name").
+		Find(&refs).Error; err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+func (r *NodeLinkRepository) GetLinkGraph(ctx context.Context, kbID string) (*v1.NodeLinkGraphResp, error) {
+	resp := &v1.NodeLinkGraphResp{}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Node{}).
+		Where("kb_id = ?", kbID).
+		Select("id, name, type, parent_id").
+		Order("position").
+		Find(&resp.Nodes).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes s ON s.id = node_links.source_node_id").
+		Joins("JOIN nodes t ON t.id = node_links.target_node_id").
+		Where("node_links.kb_id = ?", kbID).
+		Where("node_links.source_node_id != node_links.target_node_id").
+		Distinct().
+		Select("node_links.source_node_id AS source, node_links.target_node_id AS target").
+		Find(&resp.Edges).Error; err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetBrokenLinks 指向已删除文档的站内链接和检查失败的外部链接
+func (r *NodeLinkRepository) GetBrokenLinks(ctx context.Context, kbID string) ([]*v1.BrokenLinkItem, error) {
+	var internal []*v1.BrokenLinkItem
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes s ON s.id = node_links.source_node_id").
+		Where("node_links.kb_id = ?", kbID).
+		Where("node_links.target_node_id != ''").
+		Where("NOT EXISTS (SELECT 1 FROM nodes t WHERE t.id = node_links.target_node_id AND t.kb_id = node_links.kb_id)").
+		Select("s.id AS node_id, s.name AS node_name, node_links.url, TRUE AS internal").
+		Order("s.name, node_links.url").
+		Find(&internal).Error; err != nil {
+		return nil, err
+	}
+	var external []*v1.BrokenLinkItem
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes s ON s.id = node_links.source_node_id").
+		Joins("JOIN link_checks c ON c.url = node_links.url").
+		Where("node_links.kb_id = ?", kbID).
+		Where("node_links.target_node_id = ''").
+		Where("c.status = ?", domain.LinkCheckStatusBroken).
+		Select("s.id AS node_id, s.name AS node_name, node_links.url, FALSE AS internal, c.status_code, c.error, c.checked_at").
+		Order("s.name, node_links.url").
+		Find(&external).Error; err != nil {
+		return nil, err
+	}
+	return append(internal, external...), nil
+}
+
+// GetLinkCheckURLs 未检查或上次检查早于 before 的外部链接
+func (r *NodeLinkRepository) GetLinkCheckURLs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	var urls []string
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeLink{}).
+		Joins("JOIN nodes s ON s.id = node_links.source_node_id").
+		Joins("LEFT JOIN link_checks c ON c.url = node_links.url").
+		Where("node_links.target_node_id = ''").
+		Where("(c.url IS NULL OR c.checked_at < ?)", before).
+		Distinct("node_links.url").
+		Limit(limit).
+		Pluck("node_links.url", &urls).Error; err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+func (r *NodeLinkRepository) UpsertLinkChecks(ctx context.Context, checks []*domain.LinkCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "url"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "status_code", "error", "checked_at"}),
+		}).
+		Create(checks).Error
+}
+
+// DeleteUnusedLinkChecks 删除不再被引用的链接的检查结果
+func (r *NodeLinkRepository) DeleteUnusedLinkChecks(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("NOT EXISTS (SELECT 1 FROM node_links l WHERE l.url = link_checks.url)").
+		Delete(&domain.LinkCheck{}).Error
+}
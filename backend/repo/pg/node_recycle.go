@@ -0,0 +1,305 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// recycleNodesTx 按子树将待删除的节点和发布版本写入回收站，同时删除的父子节点归入同一条记录
+func (r *NodeRepository) recycleNodesTx(tx *gorm.DB, kbID string, nodeIDs []string, deletedBy string) error {
+	var nodes []*domain.Node
+	if err := tx.Model(&domain.Node{}).
+		Where("kb_id = ?", kbID).
+		Where("id IN ?", nodeIDs).
+		Order("position ASC").
+		Find(&nodes).Error; err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	var releases []*domain.NodeRelease
+	if err := tx.Model(&domain.NodeRelease{}).
+		Where("node_id IN ?", nodeIDs).
+		Find(&releases).Error; err != nil {
+		return err
+	}
+
+	nodeMap := lo.KeyBy(nodes, func(node *domain.Node) string { return node.ID })
+	children := lo.GroupBy(nodes, func(node *domain.Node) string { return node.ParentID })
+	releaseMap := lo.GroupBy(releases, func(release *domain.NodeRelease) string { return release.NodeID })
+
+	now := time.Now()
+	recycles := make([]*domain.NodeRecycle, 0)
+	for _, node := range nodes {
+		if _, ok := nodeMap[node.ParentID]; ok {
+			continue
+		}
+		snapshot := domain.NodeRecycleSnapshot{}
+		queue := []*domain.Node{node}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			snapshot.Nodes = append(snapshot.Nodes, current)
+			snapshot.Releases = append(snapshot.Releases, releaseMap[current.ID]...)
+			queue = append(queue, children[current.ID]...)
+		}
+		recycles = append(recycles, &domain.NodeRecycle{
+			ID:        uuid.New().String(),
+			KBID:      kbID,
+			NodeID:    node.ID,
+			NavId:     node.NavId,
+			ParentID:  node.ParentID,
+			Type:      node.Type,
+			Name:      node.Name,
+			NodeCount: len(snapshot.Nodes),
+			Snapshot:  snapshot,
+			DeletedBy: deletedBy,
+			DeletedAt: now,
+		})
+	}
+	return tx.Create(&recycles).Error
+}
+
+func (r *NodeRepository) GetRecycleList(ctx context.Context, kbID string, offset, limit int) ([]*v1.NodeRecycleListItem, int64, error) {
+	var total int64
+	items := make([]*v1.NodeRecycleListItem, 0)
+	query := r.db.WithContext(ctx).Model(&domain.NodeRecycle{}).Where("node_recycles.kb_id = ?", kbID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Joins("LEFT JOIN navs ON navs.id = node_recycles.nav_id").
+		Joins("LEFT JOIN users ON users.id = node_recycles.deleted_by").
+		Select("node_recycles.id, node_recycles.node_id, node_recycles.nav_id, navs.name AS nav_name, node_recycles.parent_id, node_recycles.type, node_recycles.name, node_recycles.node_count, node_recycles.deleted_by, users.account AS deleted_by_account, node_recycles.deleted_at").
+		Order("node_recycles.deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// RestoreRecycle 将回收站中的子树恢复到原位置或指定的父节点下，返回恢复后的节点和发布版本
+func (r *NodeRepository) RestoreRecycle(ctx context.Context, req *v1.NodeRecycleRestoreReq) (*domain.NodeRecycleSnapshot, error) {
+	var recycle domain.NodeRecycle
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kb_id = ?", req.KbID).
+			Where("id = ?", req.ID).
+			First(&recycle).Error; err != nil {
+			return err
+		}
+		nodes, releases := recycle.Snapshot.Nodes, recycle.Snapshot.Releases
+		root, ok := lo.Find(nodes, func(node *domain.Node) bool { return node.ID == recycle.NodeID })
+		if !ok {
+			return errors.New("recycle snapshot is broken")
+		}
+
+		var count int64
+		if err := tx.Model(&domain.Node{}).
+			Where("kb_id = ?", req.KbID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count+int64(len(nodes)) > int64(req.MaxNode) {
+			return domain.ErrMaxNodeLimitReached
+		}
+
+		parentID, navID := root.ParentID, root.NavId
+		if req.ParentID != nil {
+			parentID = *req.ParentID
+			if parentID == "" && req.NavID != "" {
+				navID = req.NavID
+			}
+		}
+		if parentID != "" {
+			var parent domain.Node
+			if err := tx.Model(&domain.Node{}).
+				Where("kb_id = ?", req.KbID).
+				Where("id = ?", parentID).
+				Select("id, type, nav_id").
+				First(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("parent node not found, please choose a new parent")
+				}
+				return err
+			}
+			if parent.Type != domain.NodeTypeFolder {
+				return errors.New("parent node is not a folder")
+			}
+			navID = parent.NavId
+		} else {
+			var navCount int64
+			if err := tx.Model(&domain.Nav{}).
+				Where("kb_id = ?", req.KbID).
+				Where("id = ?", navID).
+				Count(&navCount).Error; err != nil {
+				return err
+			}
+			if navCount == 0 {
+				return errors.New("nav not found, please choose a new nav")
+			}
+		}
+
+		// 恢复到新位置时放在末尾，已发布的节点变为待发布，与移动节点一致
+		if parentID != root.ParentID || navID != root.NavId {
+			position, err := r.nextPositionTx(tx, req.KbID, parentID)
+			if err != nil {
+				return err
+			}
+			root.ParentID = parentID
+			root.Position = position
+			for _, node := range nodes {
+				if node.ID != root.ID && node.NavId == navID {
+					continue
+				}
+				node.NavId = navID
+				if node.Status == domain.NodeStatusPublished {
+					node.Status = domain.NodeStatusDraft
+				}
+			}
+		}
+
+		// 删除时已清理 RAG 记录，恢复后重新学习
+		for _, node := range nodes {
+			node.DocID = ""
+		}
+		for _, release := range releases {
+			release.DocID = ""
+		}
+		if err := tx.CreateInBatches(&nodes, 100).Error; err != nil {
+			return err
+		}
+		if len(releases) > 0 {
+			if err := tx.CreateInBatches(&releases, 100).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&recycle).Error
+	}); err != nil {
+		return nil, err
+	}
+	return &recycle.Snapshot, nil
+}
+
+// nextPositionTx 返回父节点下最后一个位置
+func (r *NodeRepository) nextPositionTx(tx *gorm.DB, kbID, parentID string) (float64, error) {
+	query := tx.Model(&domain.Node{}).Where("kb_id = ?", kbID)
+	if parentID == "" {
+		query = query.Where("parent_id IS NULL OR parent_id = ''")
+	} else {
+		query = query.Where("parent_id = ?", parentID)
+	}
+	var maxPos float64
+	if err := query.
+		Select("COALESCE(MAX(position::float), 0)").
+		Scan(&maxPos).Error; err != nil {
+		return 0, err
+	}
+	newPos := maxPos + (domain.MaxPosition-maxPos)/2.0
+	if newPos-maxPos < domain.MinPositionGap {
+		if err := r.reorderPositionsByParentID(tx, kbID, parentID); err != nil {
+			return 0, err
+		}
+		return r.nextPositionTx(tx, kbID, parentID)
+	}
+	return newPos, nil
+}
+
+func (r *NodeRepository) PurgeRecycles(ctx context.Context, kbID string, ids []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var recycleIDs []string
+		if err := tx.Model(&domain.NodeRecycle{}).
+			Where("kb_id = ?", kbID).
+			Where("id IN ?", ids).
+			Pluck("id", &recycleIDs).Error; err != nil {
+			return err
+		}
+		return r.purgeRecyclesTx(tx, recycleIDs)
+	})
+}
+
+// DeleteExpiredRecycles 彻底删除超过知识库保留天数的回收站记录
+func (r *NodeRepository) DeleteExpiredRecycles(ctx context.Context) (int, error) {
+	var recycleIDs []string
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.NodeRecycle{}).
+			Joins("JOIN knowledge_bases ON knowledge_bases.id = node_recycles.kb_id").
+			Where("node_recycles.deleted_at < NOW() - make_interval(days => knowledge_bases.recycle_retention_days)").
+			Pluck("node_recycles.id", &recycleIDs).Error; err != nil {
+			return err
+		}
+		return r.purgeRecyclesTx(tx, recycleIDs)
+	}); err != nil {
+		return 0, err
+	}
+	return len(recycleIDs), nil
+}
+
+// nodeRefTable 通过 column 列引用节点的表
+type nodeRefTable struct {
+	table  string
+	column string
+}
+
+// purgeNodeTables 彻底删除节点时一并删除的数据，节点进入回收站时保留以便恢复，
+// 因此这些表不能用外键级联删除
+var purgeNodeTables = []nodeRefTable{
+	{"node_auth_groups", "node_id"},
+	{"node_revisions", "node_id"},
+	{"node_collab_states", "node_id"},
+	// 指向这些节点的链接保留，由失效链接检查报告
+	{"node_links", "source_node_id"},
+	{"node_reviews", "node_id"},
+	// 问答对和附件的检索记录已在删除文档时随发布版本一并删除
+	{"node_faqs", "node_id"},
+	{"node_attachment_docs", "node_id"},
+	{"node_stats", "node_id"},
+	// 评论图片计入文件引用，不删除会使文件一直无法回收
+	{"comments", "node_id"},
+}
+
+// purgeRecyclesTx 删除回收站记录，并清理子树节点保留的数据
+func (r *NodeRepository) purgeRecyclesTx(tx *gorm.DB, recycleIDs []string) error {
+	if len(recycleIDs) == 0 {
+		return nil
+	}
+	var nodeIDs []string
+	if err := tx.Raw("SELECT n->>'id' FROM node_recycles, jsonb_array_elements(node_recycles.snapshot->'nodes') AS n WHERE node_recycles.id IN ?", recycleIDs).
+		Scan(&nodeIDs).Error; err != nil {
+		return err
+	}
+	if err := r.purgeNodesTx(tx, nodeIDs); err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", recycleIDs).Delete(&domain.NodeRecycle{}).Error
+}
+
+// purgeNodesTx 清理已删除节点的关联数据，并从精选回答的关联文档中移除
+func (r *NodeRepository) purgeNodesTx(tx *gorm.DB, nodeIDs []string) error {
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+	for _, t := range purgeNodeTables {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN ?", t.table, t.column), nodeIDs).Error; err != nil {
+			return fmt.Errorf("purge %s failed: %w", t.table, err)
+		}
+	}
+	ids := pq.StringArray(nodeIDs)
+	return tx.Model(&domain.CuratedAnswer{}).
+		Where("node_ids && ?", ids).
+		Update("node_ids", gorm.Expr("ARRAY(SELECT id FROM unnest(node_ids) AS id WHERE id <> ALL(?))", ids)).Error
+}
@@ -0,0 +1,120 @@
+package pg
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retainedNodeTables 节点彻底删除后仍保留的数据
+var retainedNodeTables = map[string]string{
+	"nodes":                    "节点本身，删除时已写入回收站",
+	"node_releases":            "删除时已写入回收站",
+	"kb_release_node_releases": "知识库发布历史",
+	"node_release_backup":      "删除前的发布版本备份",
+	"node_recycles":            "回收站记录本身",
+	"stat_pages":               "访问统计",
+	"stat_node_events":         "访问统计",
+	"document_feedbacks":       "反馈统计",
+	"contributes":              "贡献审核记录",
+	"mq_dead_letters":          "消息处理记录",
+	"curated_answers":          "只移除关联文档",
+}
+
+var (
+	createTableRegexp = regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?(?:"public"\.)?"?(\w+)"?`)
+	alterTableRegexp  = regexp.MustCompile(`(?i)ALTER TABLE (?:IF EXISTS )?(?:"public"\.)?"?(\w+)"?`)
+	addColumnRegexp   = regexp.MustCompile(`(?i)^\s*ADD COLUMN (?:IF NOT EXISTS )?`)
+	nodeColumnRegexp  = regexp.MustCompile(`(?i)^\s*"?((?:source_)?node_ids?)"?\s+text`)
+)
+
+// migrationNodeTables 从迁移文件中找出带节点 ID 列的表
+func migrationNodeTables(t *testing.T) map[string][]string {
+	files, err := filepath.Glob("../../store/pg/migration/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	tables := make(map[string][]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		table := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := createTableRegexp.FindStringSubmatch(line); m != nil {
+				table = m[1]
+			} else if m := alterTableRegexp.FindStringSubmatch(line); m != nil {
+				table = m[1]
+				line = addColumnRegexp.ReplaceAllString(line[len(m[0]):], "")
+			}
+			if m := nodeColumnRegexp.FindStringSubmatch(line); m != nil && table != "" {
+				tables[table] = lo.Uniq(append(tables[table], strings.ToLower(m[1])))
+			}
+		}
+	}
+	return tables
+}
+
+func TestPurgeNodeTables_CoverMigrations(t *testing.T) {
+	tables := migrationNodeTables(t)
+	assert.Equal(t, []string{"node_id"}, tables["node_revisions"])
+	assert.Equal(t, []string{"node_ids"}, tables["curated_answers"])
+	assert.Equal(t, []string{"source_node_id"}, tables["node_links"])
+
+	purged := lo.SliceToMap(purgeNodeTables, func(t nodeRefTable) (string, string) {
+		return t.table, t.column
+	})
+	for table, columns := range tables {
+		if _, ok := retainedNodeTables[table]; ok {
+			continue
+		}
+		column, ok := purged[table]
+		if assert.Truef(t, ok, "table %s references nodes but is neither purged nor retained", table) {
+			assert.Contains(t, columns, column, table)
+		}
+	}
+	for _, p := range purgeNodeTables {
+		assert.Contains(t, tables, p.table)
+		assert.NotContains(t, retainedNodeTables, p.table)
+	}
+}
+
+func TestPurgeNodeTables_AttachmentRefSources(t *testing.T) {
+	tables := migrationNodeTables(t)
+	purged := lo.Map(purgeNodeTables, func(t nodeRefTable, _ int) string {
+		return t.table
+	})
+	fromRegexp := regexp.MustCompile(`FROM (\w+)`)
+	for _, source := range attachmentRefSources {
+		m := fromRegexp.FindStringSubmatch(source.query)
+		require.NotNil(t, m, source.query)
+		if _, ok := tables[m[1]]; !ok {
+			continue
+		}
+		// 与节点关联的引用来源要么随节点清理，要么有意保留
+		_, retained := retainedNodeTables[m[1]]
+		assert.Truef(t, retained || lo.Contains(purged, m[1]), "attachment ref source %s is not purged with nodes", m[1])
+	}
+}
+
+func TestPurgeNodesTx(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+
+	r := &NodeRepository{}
+	require.NoError(t, r.purgeNodesTx(db, nil))
+	assert.Empty(t, recorder.sqls)
+
+	require.NoError(t, r.purgeNodesTx(db, []string{"n1", "n2"}))
+	require.Len(t, recorder.sqls, len(purgeNodeTables)+1)
+	for i, p := range purgeNodeTables {
+		assert.Equal(t, "DELETE FROM "+p.table+" WHERE "+p.column+" IN ('n1','n2')", recorder.sqls[i])
+	}
+	update := recorder.sqls[len(purgeNodeTables)]
+	assert.True(t, strings.HasPrefix(update, `UPDATE "curated_answers" SET "node_ids"=ARRAY(SELECT id FROM unnest(node_ids) AS id WHERE id <> ALL('{"n1","n2"}'))`), update)
+	assert.Contains(t, update, `WHERE node_ids && '{"n1","n2"}'`)
+}
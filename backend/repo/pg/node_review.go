@@ -0,0 +1,136 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type NodeReviewRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewNodeReviewRepository(db *pg.DB, logger *log.Logger) *NodeReviewRepository {
+	return &NodeReviewRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.node_review"),
+	}
+}
+
+// GetReviewNodes 知识库中所有节点的目录结构和编辑时间
+func (r *NodeReviewRepository) GetReviewNodes(ctx context.Context, kbID string) ([]*domain.NodeReviewNode, error) {
+	var nodes []*domain.NodeReviewNode
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Node{}).
+		Where("kb_id = ?", kbID).
+		Select("id, parent_id, name, type, status, edit_time, created_at").
+		Order("position").
+		Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (r *NodeReviewRepository) GetReviews(ctx context.Context, kbID string) (map[string]*domain.NodeReview, error) {
+	var reviews []*domain.NodeReview
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	reviewMap := make(map[string]*domain.NodeReview, len(reviews))
+	for _, review := range reviews {
+		reviewMap[review.NodeID] = review
+	}
+	return reviewMap, nil
+}
+
+// GetReview 节点没有设置时返回 nil
+func (r *NodeReviewRepository) GetReview(ctx context.Context, nodeID string) (*domain.NodeReview, error) {
+	var review domain.NodeReview
+	if err := r.db.WithContext(ctx).
+		Where("node_id = ?", nodeID).
+		First(&review).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// UpsertPolicy 更新负责人和复查周期，清除提醒时间以便按新设置重新提醒
+func (r *NodeReviewRepository) UpsertPolicy(ctx context.Context, review *domain.NodeReview) error {
+	review.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "node_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"owner_id":      review.OwnerID,
+				"interval_days": review.IntervalDays,
+				"reminded_at":   nil,
+				"updated_at":    review.UpdatedAt,
+			}),
+		}).
+		Create(review).Error
+}
+
+func (r *NodeReviewRepository) MarkReviewed(ctx context.Context, kbID, nodeID string, reviewedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "node_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"reviewed_at": reviewedAt,
+				"reminded_at": nil,
+				"updated_at":  reviewedAt,
+			}),
+		}).
+		Create(&domain.NodeReview{NodeID: nodeID, KBID: kbID, ReviewedAt: &reviewedAt, UpdatedAt: reviewedAt}).Error
+}
+
+// UpdateRemindedAt 记录提醒时间，没有设置记录的文档同时创建记录
+func (r *NodeReviewRepository) UpdateRemindedAt(ctx context.Context, kbID string, nodeIDs []string, remindedAt time.Time) error {
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+	reviews := make([]*domain.NodeReview, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		reviews = append(reviews, &domain.NodeReview{NodeID: nodeID, KBID: kbID, RemindedAt: &remindedAt, UpdatedAt: remindedAt})
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "node_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reminded_at"}),
+		}).
+		CreateInBatches(reviews, 100).Error
+}
+
+// DeleteOrphanReviews 删除节点已不存在的设置
+func (r *NodeReviewRepository) DeleteOrphanReviews(ctx context.Context, kbID string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Where("NOT EXISTS (SELECT 1 FROM nodes n WHERE n.id = node_reviews.node_id)").
+		Delete(&domain.NodeReview{}).Error
+}
+
+// GetViewedNodeIDs 有访问记录的文档
+func (r *NodeReviewRepository) GetViewedNodeIDs(ctx context.Context, kbID string) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeStats{}).
+		Joins("JOIN nodes ON nodes.id = node_stats.node_id").
+		Where("nodes.kb_id = ?", kbID).
+		Where("node_stats.pv > 0").
+		Pluck("node_stats.node_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,60 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type NodeTemplateRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewNodeTemplateRepository(db *pg.DB, logger *log.Logger) *NodeTemplateRepository {
+	return &NodeTemplateRepository{
+		db:     db,
+		logger: logger.WithModule("repo.pg.node_template"),
+	}
+}
+
+func (r *NodeTemplateRepository) Create(ctx context.Context, template *domain.NodeTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+func (r *NodeTemplateRepository) GetByID(ctx context.Context, kbID, id string) (*domain.NodeTemplate, error) {
+	var template domain.NodeTemplate
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *NodeTemplateRepository) GetList(ctx context.Context, kbID string) ([]*domain.NodeTemplate, error) {
+	var templates []*domain.NodeTemplate
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Order("created_at ASC").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *NodeTemplateRepository) Update(ctx context.Context, template *domain.NodeTemplate) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.NodeTemplate{}).
+		Where("kb_id = ? AND id = ?", template.KBID, template.ID).
+		Select("name", "description", "type", "content", "content_type", "emoji", "permissions", "children", "updated_at").
+		Updates(template).Error
+}
+
+func (r *NodeTemplateRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Delete(&domain.NodeTemplate{}).Error
+}
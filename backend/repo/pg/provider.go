@@ -26,4 +26,12 @@ var ProviderSet = wire.NewSet(
 	NewSystemSettingRepo,
 	NewMCPRepository,
 	NewNavRepository,
+	NewMQDeadLetterRepository,
+	NewAttachmentRepository,
+	NewKBExportRepository,
+	NewNodeTemplateRepository,
+	NewNodeLinkRepository,
+	NewNodeReviewRepository,
+	NewNodeFAQRepository,
+	NewCuratedAnswerRepository,
 )
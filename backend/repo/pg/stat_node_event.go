@@ -0,0 +1,122 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+const nodeEngagementSelect = `
+	COUNT(*) FILTER (WHERE type = 'read') as read_count,
+	COALESCE(AVG(read_seconds) FILTER (WHERE type = 'read'), 0) as avg_read_seconds,
+	COALESCE(AVG(scroll_depth) FILTER (WHERE type = 'read'), 0) as avg_scroll_depth,
+	COUNT(*) FILTER (WHERE type = 'read' AND scroll_depth >= 90) as finish_count,
+	COUNT(*) FILTER (WHERE type = 'read' AND read_seconds < 10) as bounce_count,
+	COUNT(*) FILTER (WHERE type = 'link_click') as link_click_count,
+	COUNT(*) FILTER (WHERE type = 'feedback' AND score > 0) as helpful_count,
+	COUNT(*) FILTER (WHERE type = 'feedback' AND score < 0) as unhelpful_count`
+
+func (r *StatRepository) CreateStatNodeEvent(ctx context.Context, event *domain.StatNodeEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// UpsertStatNodeFeedback 同一会话对同一文档只保留最后一次反馈
+func (r *StatRepository) UpsertStatNodeFeedback(ctx context.Context, event *domain.StatNodeEvent) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "kb_id"}, {Name: "node_id"}, {Name: "session_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "type = 'feedback'"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"user_id", "ip", "score", "created_at"}),
+		}).
+		Create(event).Error
+}
+
+// GetNodeEngagementList 按文档汇总 start 之后的阅读行为
+func (r *StatRepository) GetNodeEngagementList(ctx context.Context, kbID string, start time.Time) ([]*domain.NodeEngagement, error) {
+	list := make([]*domain.NodeEngagement, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatNodeEvent{}).
+		Select("node_id, "+nodeEngagementSelect).
+		Where("kb_id = ?", kbID).
+		Where("created_at >= ?", start).
+		Group("node_id").
+		Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *StatRepository) GetNodeEngagement(ctx context.Context, kbID, nodeID string, start time.Time) (*domain.NodeEngagement, error) {
+	engagement := &domain.NodeEngagement{NodeID: nodeID}
+	if err := r.db.WithContext(ctx).Model(&domain.StatNodeEvent{}).
+		Select(nodeEngagementSelect).
+		Where("kb_id = ? AND node_id = ?", kbID, nodeID).
+		Where("created_at >= ?", start).
+		Scan(engagement).Error; err != nil {
+		return nil, err
+	}
+	return engagement, nil
+}
+
+// GetNodeEngagementTrend 按天汇总单个文档的阅读行为
+func (r *StatRepository) GetNodeEngagementTrend(ctx context.Context, kbID, nodeID string, start time.Time) ([]*domain.NodeEngagementTrendItem, error) {
+	trend := make([]*domain.NodeEngagementTrendItem, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatNodeEvent{}).
+		Select("date_trunc('day', created_at) as date, node_id, "+nodeEngagementSelect).
+		Where("kb_id = ? AND node_id = ?", kbID, nodeID).
+		Where("created_at >= ?", start).
+		Group("date, node_id").
+		Order("date ASC").
+		Scan(&trend).Error; err != nil {
+		return nil, err
+	}
+	return trend, nil
+}
+
+// GetNodePVTrend 基于小时统计表按天汇总单个文档的访问量
+func (r *StatRepository) GetNodePVTrend(ctx context.Context, kbID, nodeID string, start time.Time) (map[string]int64, error) {
+	type row struct {
+		Date  time.Time
+		Count int64
+	}
+	var rows []row
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
+		Select("date_trunc('day', hour) as date, SUM((hot_page->>?)::bigint) as count", nodeID).
+		Where("kb_id = ?", kbID).
+		Where("hot_page ->> ? IS NOT NULL", nodeID).
+		Where("hour >= ?", start).
+		Group("date").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	pv := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		pv[row.Date.Format(time.DateOnly)] = row.Count
+	}
+	return pv, nil
+}
+
+func (r *StatRepository) GetNodeLinkClicks(ctx context.Context, kbID, nodeID string, start time.Time, limit int) ([]*domain.NodeLinkClick, error) {
+	clicks := make([]*domain.NodeLinkClick, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatNodeEvent{}).
+		Select("link_url, COUNT(*) as count").
+		Where("kb_id = ? AND node_id = ?", kbID, nodeID).
+		Where("type = ?", domain.StatNodeEventLinkClick).
+		Where("created_at >= ?", start).
+		Group("link_url").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&clicks).Error; err != nil {
+		return nil, err
+	}
+	return clicks, nil
+}
+
+// CleanupOldNodeEvents 清理90天前的文档阅读行为数据
+func (r *StatRepository) CleanupOldNodeEvents(ctx context.Context) error {
+	return r.db.WithContext(ctx).Model(&domain.StatNodeEvent{}).
+		Where("created_at < NOW() - INTERVAL '90 days'").
+		Delete(&domain.StatNodeEvent{}).Error
+}
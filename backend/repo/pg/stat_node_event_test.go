@@ -0,0 +1,33 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+func TestUpsertStatNodeFeedback(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	r := &StatRepository{db: &pg.DB{DB: db}}
+
+	require.NoError(t, r.UpsertStatNodeFeedback(context.Background(), &domain.StatNodeEvent{
+		KBID:      "kb",
+		NodeID:    "n1",
+		SessionID: "s1",
+		Type:      domain.StatNodeEventFeedback,
+		Score:     domain.Like,
+	}))
+	require.Len(t, recorder.sqls, 1)
+	// 冲突目标需与 uniq_stat_node_events_feedback 部分唯一索引一致，否则 postgres 拒绝执行
+	assert.Contains(t, recorder.sqls[0], `ON CONFLICT ("kb_id","node_id","session_id")  WHERE type = 'feedback' DO UPDATE SET "user_id"="excluded"."user_id","ip"="excluded"."ip","score"="excluded"."score","created_at"="excluded"."created_at"`)
+
+	migration, err := os.ReadFile("../../store/pg/migration/000057_dedupe_stat_node_feedback.up.sql")
+	require.NoError(t, err)
+	assert.Contains(t, string(migration), "ON stat_node_events(kb_id, node_id, session_id) WHERE type = 'feedback'")
+}
@@ -0,0 +1,150 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func (r *StatRepository) GetStatReportList(ctx context.Context, kbID string) ([]*domain.StatReport, error) {
+	reports := make([]*domain.StatReport, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatReport{}).
+		Where("kb_id = ?", kbID).
+		Order("created_at ASC").
+		Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r *StatRepository) GetStatReport(ctx context.Context, kbID, id string) (*domain.StatReport, error) {
+	var report domain.StatReport
+	if err := r.db.WithContext(ctx).Model(&domain.StatReport{}).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *StatRepository) GetEnabledStatReports(ctx context.Context, period consts.StatReportPeriod) ([]*domain.StatReport, error) {
+	reports := make([]*domain.StatReport, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatReport{}).
+		Where("enabled = ? AND period = ?", true, period).
+		Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r *StatRepository) CreateStatReport(ctx context.Context, report *domain.StatReport) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *StatRepository) UpdateStatReport(ctx context.Context, report *domain.StatReport) error {
+	return r.db.WithContext(ctx).Model(&domain.StatReport{}).
+		Where("kb_id = ? AND id = ?", report.KBID, report.ID).
+		Updates(map[string]any{
+			"name":       report.Name,
+			"period":     report.Period,
+			"enabled":    report.Enabled,
+			"channels":   report.Channels,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *StatRepository) UpdateStatReportSentAt(ctx context.Context, id string, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.StatReport{}).
+		Where("id = ?", id).
+		Update("last_sent_at", sentAt).Error
+}
+
+func (r *StatRepository) DeleteStatReport(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Delete(&domain.StatReport{}).Error
+}
+
+// GetStatTrendByDay 基于小时统计表按天聚合 [start, end) 内的访问数据
+func (r *StatRepository) GetStatTrendByDay(ctx context.Context, kbID string, start, end time.Time) ([]*domain.StatTrendItem, error) {
+	trend := make([]*domain.StatTrendItem, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
+		Select("date_trunc('day', hour) as date, SUM(page_visit_count) as page_visit_count, SUM(ip_count) as ip_count, SUM(session_count) as session_count, SUM(conversation_count) as conversation_count").
+		Where("kb_id = ?", kbID).
+		Where("hour >= ? AND hour < ?", start, end).
+		Group("date").
+		Order("date ASC").
+		Scan(&trend).Error; err != nil {
+		return nil, err
+	}
+	return trend, nil
+}
+
+// GetHotPagesByRange 基于小时统计表汇总 [start, end) 内的文档访问量
+func (r *StatRepository) GetHotPagesByRange(ctx context.Context, kbID string, start, end time.Time) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	hotPageMaps := make([]domain.MapStrInt64, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
+		Where("kb_id = ?", kbID).
+		Where("hot_page != '{}'").
+		Where("hour >= ? AND hour < ?", start, end).
+		Pluck("hot_page", &hotPageMaps).Error; err != nil {
+		return nil, err
+	}
+	for i := range hotPageMaps {
+		for k, v := range hotPageMaps[i] {
+			counts[k] += v
+		}
+	}
+	return counts, nil
+}
+
+// GetUnansweredQuestions 没有引用到任何文档的提问，按出现次数排序
+func (r *StatRepository) GetUnansweredQuestions(ctx context.Context, kbID string, start, end time.Time, limit int) ([]*domain.QuestionCount, error) {
+	questions := make([]*domain.QuestionCount, 0)
+	if err := r.db.WithContext(ctx).Table("conversation_messages as cm").
+		Select("cm.content as question, COUNT(*) as count").
+		Where("cm.kb_id = ?", kbID).
+		Where("cm.role = ?", schema.User).
+		Where("cm.created_at >= ? AND cm.created_at < ?", start, end).
+		Where("NOT EXISTS (SELECT 1 FROM conversation_references cr WHERE cr.conversation_id = cm.conversation_id)").
+		Group("cm.content").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&questions).Error; err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *StatRepository) GetNegativeFeedbackCount(ctx context.Context, kbID string, start, end time.Time) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.ConversationMessage{}).
+		Where("kb_id = ?", kbID).
+		Where("role = ?", schema.Assistant).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Where("info->>'score' = ?", "-1").
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *StatRepository) GetTokenUsageByModel(ctx context.Context, kbID string, start, end time.Time) ([]*domain.ModelTokenUsageStat, error) {
+	usage := make([]*domain.ModelTokenUsageStat, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.ConversationMessage{}).
+		Select("model, SUM(prompt_tokens) as prompt_tokens, SUM(completion_tokens) as completion_tokens, SUM(total_tokens) as total_tokens").
+		Where("kb_id = ?", kbID).
+		Where("role = ?", schema.Assistant).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("model").
+		Order("total_tokens DESC").
+		Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
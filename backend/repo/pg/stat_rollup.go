@@ -0,0 +1,73 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// GetUnrolledDays 获取小时统计表中已结束但尚未按天汇总的日期
+func (r *StatRepository) GetUnrolledDays(ctx context.Context, kbID string) ([]time.Time, error) {
+	days := make([]time.Time, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
+		Select("DISTINCT date_trunc('day', hour) as day").
+		Where("kb_id = ?", kbID).
+		Where("hour < date_trunc('day', NOW())").
+		Where("NOT EXISTS (SELECT 1 FROM stat_page_rollups r WHERE r.kb_id = stat_page_hours.kb_id AND r.granularity = ? AND r.period = date_trunc('day', stat_page_hours.hour))", consts.StatGranularityDay).
+		Order("day ASC").
+		Scan(&days).Error; err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// GetUnrolledMonths 获取按天汇总表中已结束但尚未按月汇总的月份
+func (r *StatRepository) GetUnrolledMonths(ctx context.Context, kbID string) ([]time.Time, error) {
+	months := make([]time.Time, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageRollup{}).
+		Select("DISTINCT date_trunc('month', period) as month").
+		Where("kb_id = ? AND granularity = ?", kbID, consts.StatGranularityDay).
+		Where("period < date_trunc('month', NOW())").
+		Where("NOT EXISTS (SELECT 1 FROM stat_page_rollups m WHERE m.kb_id = stat_page_rollups.kb_id AND m.granularity = ? AND m.period = date_trunc('month', stat_page_rollups.period))", consts.StatGranularityMonth).
+		Order("month ASC").
+		Scan(&months).Error; err != nil {
+		return nil, err
+	}
+	return months, nil
+}
+
+// GetStatPageHoursByRange 获取 [start, end) 内的小时统计数据
+func (r *StatRepository) GetStatPageHoursByRange(ctx context.Context, kbID string, start, end time.Time) ([]*domain.StatPageHour, error) {
+	hours := make([]*domain.StatPageHour, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
+		Where("kb_id = ?", kbID).
+		Where("hour >= ? AND hour < ?", start, end).
+		Order("hour ASC").
+		Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+// GetStatPageRollups 获取 [start, end) 内指定粒度的汇总数据
+func (r *StatRepository) GetStatPageRollups(ctx context.Context, kbID string, granularity consts.StatGranularity, start, end time.Time) ([]*domain.StatPageRollup, error) {
+	rollups := make([]*domain.StatPageRollup, 0)
+	if err := r.db.WithContext(ctx).Model(&domain.StatPageRollup{}).
+		Where("kb_id = ? AND granularity = ?", kbID, granularity).
+		Where("period >= ? AND period < ?", start, end).
+		Order("period ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+func (r *StatRepository) CreateStatPageRollup(ctx context.Context, rollup *domain.StatPageRollup) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(rollup).Error
+}
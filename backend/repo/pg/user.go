@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/lib/pq"
 	"github.com/samber/lo"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -144,3 +145,74 @@ func (r *UserRepository) DeleteUser(ctx context.Context, userID string) error {
 	}
 	return nil
 }
+
+func (r *UserRepository) GetUserBySource(ctx context.Context, sourceType consts.SourceType, unionID string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).
+		Where("source_type = ? AND union_id = ?", sourceType, unionID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateSSOUser 创建SSO登录的用户并授予默认知识库权限，账号重名时追加后缀
+func (r *UserRepository) CreateSSOUser(ctx context.Context, user *domain.User, perms []domain.AdminSSOKBPerm) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = string(hashedPassword)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= domain.GetBaseEditionLimitation(ctx).MaxAdmin {
+			return fmt.Errorf("exceed max admin limit, current count: %d, max limit: %d", count, domain.GetBaseEditionLimitation(ctx).MaxAdmin)
+		}
+
+		var exists int64
+		if err := tx.Model(&domain.User{}).Where("account = ?", user.Account).Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists > 0 {
+			user.Account = fmt.Sprintf("%s_%s", user.Account, user.ID[:8])
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		for _, perm := range perms {
+			if err := tx.Create(&domain.KBUsers{
+				KBId:   perm.KBID,
+				UserId: user.ID,
+				Perm:   perm.Perm,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *UserRepository) UpdateUserTOTP(ctx context.Context, userID string, secret string, enabled bool, recoveryCodes []string) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"totp_secret":    secret,
+		"totp_enabled":   enabled,
+		"recovery_codes": pq.StringArray(recoveryCodes),
+	}).Error
+}
+
+// ConsumeRecoveryCode 原子地移除一个恢复码，返回是否命中
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, userID string, hashedCode string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ? AND ? = ANY(recovery_codes)", userID, hashedCode).
+		Update("recovery_codes", gorm.Expr("array_remove(recovery_codes, ?)", hashedCode))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/chaitin/panda-wiki/config"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/metrics"
 	PWMiddleware "github.com/chaitin/panda-wiki/middleware"
 )
 
@@ -84,6 +85,10 @@ func NewEcho(
 		e.Use(middlewareOtel.Middleware(config.GetString("apm.service_name")))
 	}
 
+	if config.Metrics.Enabled {
+		e.Use(metrics.EchoMiddleware())
+	}
+
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogStatus:   true,
 		LogURI:      true,
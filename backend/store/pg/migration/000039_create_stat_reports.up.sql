@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stat_reports (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    period TEXT NOT NULL DEFAULT 'weekly',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    channels JSONB NOT NULL DEFAULT '[]',
+    last_sent_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stat_reports_kb_id ON stat_reports(kb_id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS stat_node_events (
+    id BIGSERIAL PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    session_id TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL,
+    read_seconds BIGINT NOT NULL DEFAULT 0,
+    scroll_depth INT NOT NULL DEFAULT 0,
+    link_url TEXT NOT NULL DEFAULT '',
+    score INT NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stat_node_events_kb_id_created_at ON stat_node_events(kb_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stat_node_events_node_id_created_at ON stat_node_events(node_id, created_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mq_dead_letters;
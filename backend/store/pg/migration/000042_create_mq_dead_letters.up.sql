@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS mq_dead_letters (
+    id TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    kb_id TEXT NOT NULL DEFAULT '',
+    node_id TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    failed_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mq_dead_letters_kb_id ON mq_dead_letters (kb_id, created_at);
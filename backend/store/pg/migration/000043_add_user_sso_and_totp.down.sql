@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_uniq_users_source_type_union_id;
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "source_type";
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "union_id";
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "totp_secret";
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "totp_enabled";
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "recovery_codes";
@@ -0,0 +1,9 @@
+-- 管理后台SSO登录用户的身份源
+ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "source_type" text NOT NULL DEFAULT '';
+ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "union_id" text NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uniq_users_source_type_union_id ON users(source_type, union_id) WHERE source_type <> '';
+
+-- 本地账号TOTP两步验证
+ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "totp_secret" text NOT NULL DEFAULT '';
+ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "totp_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "recovery_codes" text[] NOT NULL DEFAULT '{}';
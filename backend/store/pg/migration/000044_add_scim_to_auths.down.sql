@@ -0,0 +1,3 @@
+ALTER TABLE auths DROP COLUMN IF EXISTS active;
+ALTER TABLE auths DROP COLUMN IF EXISTS external_id;
+ALTER TABLE auths DROP COLUMN IF EXISTS user_name;
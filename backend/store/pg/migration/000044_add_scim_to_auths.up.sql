@@ -0,0 +1,4 @@
+-- SCIM 下发的用户属性，停用的用户不能登录
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS active boolean NOT NULL DEFAULT true;
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS external_id text NOT NULL DEFAULT '';
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS user_name text NOT NULL DEFAULT '';
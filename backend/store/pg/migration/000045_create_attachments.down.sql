@@ -0,0 +1,3 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS storage_quota;
+DROP TABLE IF EXISTS attachment_refs;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,24 @@
+-- 上传文件索引，同一知识库内相同内容的文件只保存一份
+CREATE TABLE IF NOT EXISTS attachments (
+    key TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    filename TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    UNIQUE(kb_id, hash)
+);
+
+-- 文档、评论等对文件的引用，由定时任务重建
+CREATE TABLE IF NOT EXISTS attachment_refs (
+    key TEXT NOT NULL,
+    kb_id TEXT NOT NULL,
+    ref_type TEXT NOT NULL,
+    ref_id TEXT NOT NULL,
+    PRIMARY KEY (key, ref_type, ref_id)
+);
+CREATE INDEX IF NOT EXISTS idx_attachment_refs_kb_id ON attachment_refs(kb_id);
+
+-- 知识库存储配额，单位字节，0 表示不限制
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS storage_quota BIGINT NOT NULL DEFAULT 0;
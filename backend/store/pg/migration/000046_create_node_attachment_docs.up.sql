@@ -0,0 +1,16 @@
+-- 文档引用附件的 RAG 子记录，每页一条
+CREATE TABLE IF NOT EXISTS node_attachment_docs (
+    id BIGSERIAL PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    parent_doc_id TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    filename TEXT NOT NULL DEFAULT '',
+    page INT NOT NULL DEFAULT 0,
+    page_name TEXT NOT NULL DEFAULT '',
+    doc_id TEXT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_node_attachment_docs_node_id ON node_attachment_docs(node_id);
+CREATE INDEX IF NOT EXISTS idx_node_attachment_docs_parent_doc_id ON node_attachment_docs(parent_doc_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_node_attachment_docs_doc_id ON node_attachment_docs(doc_id);
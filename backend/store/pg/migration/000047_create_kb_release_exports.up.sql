@@ -0,0 +1,14 @@
+-- 发布版本导出的静态站点
+CREATE TABLE IF NOT EXISTS kb_release_exports (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    release_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    message TEXT NOT NULL DEFAULT '',
+    object_key TEXT NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    creator_id TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_kb_release_exports_kb_id ON kb_release_exports(kb_id);
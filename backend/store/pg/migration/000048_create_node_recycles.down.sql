@@ -0,0 +1,2 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS recycle_retention_days;
+DROP TABLE IF EXISTS node_recycles;
@@ -0,0 +1,19 @@
+-- 回收站，每条记录对应一次删除的子树，子树内的文档和发布版本保存在 snapshot 中
+CREATE TABLE IF NOT EXISTS node_recycles (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    nav_id TEXT NOT NULL DEFAULT '',
+    parent_id TEXT NOT NULL DEFAULT '',
+    type SMALLINT NOT NULL DEFAULT 0,
+    name TEXT NOT NULL DEFAULT '',
+    node_count INT NOT NULL DEFAULT 0,
+    snapshot JSONB NOT NULL DEFAULT '{}',
+    deleted_by TEXT NOT NULL DEFAULT '',
+    deleted_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_node_recycles_kb_id ON node_recycles(kb_id);
+CREATE INDEX IF NOT EXISTS idx_node_recycles_deleted_at ON node_recycles(deleted_at);
+
+-- 回收站保留天数，过期后彻底删除
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS recycle_retention_days INT NOT NULL DEFAULT 30;
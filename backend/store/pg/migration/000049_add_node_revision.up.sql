@@ -0,0 +1,14 @@
+-- 文档修订号，每次保存名称或内容时递增，用于检测编辑冲突
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT 1;
+
+-- 最近的修订内容，作为冲突时三路合并的共同祖先
+CREATE TABLE IF NOT EXISTS node_revisions (
+    node_id TEXT NOT NULL,
+    revision BIGINT NOT NULL,
+    kb_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    editor_id TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (node_id, revision)
+);
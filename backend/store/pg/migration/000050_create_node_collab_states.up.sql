@@ -0,0 +1,9 @@
+-- 协同编辑的 Yjs 更新，revision 为保存时文档的修订号，文档被其他方式修改后作废
+CREATE TABLE IF NOT EXISTS node_collab_states (
+    node_id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    state BYTEA NOT NULL,
+    revision BIGINT NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_node_collab_states_kb_id ON node_collab_states(kb_id);
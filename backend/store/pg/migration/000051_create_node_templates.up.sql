@@ -0,0 +1,17 @@
+-- 知识库的文档模板，children 为从模板创建文件夹骨架时一并创建的子节点
+CREATE TABLE IF NOT EXISTS node_templates (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    type SMALLINT NOT NULL DEFAULT 2,
+    content TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL DEFAULT '',
+    emoji TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '{}',
+    children JSONB NOT NULL DEFAULT '[]',
+    creator_id TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_node_templates_kb_id ON node_templates(kb_id);
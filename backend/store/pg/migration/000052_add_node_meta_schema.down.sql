@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_nodes_meta;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS node_meta_schema;
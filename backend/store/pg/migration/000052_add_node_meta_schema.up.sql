@@ -0,0 +1,5 @@
+-- 知识库的标签词表和自定义字段定义
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS node_meta_schema JSONB NOT NULL DEFAULT '{}';
+
+-- 按标签和自定义字段过滤文档
+CREATE INDEX IF NOT EXISTS idx_nodes_meta ON nodes USING GIN (meta jsonb_path_ops);
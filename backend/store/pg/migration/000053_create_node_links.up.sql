@@ -0,0 +1,21 @@
+-- 发布版本中的链接，站内链接记录指向的文档
+CREATE TABLE IF NOT EXISTS node_links (
+    kb_id TEXT NOT NULL,
+    source_node_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    target_node_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source_node_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_links_kb_id ON node_links(kb_id);
+CREATE INDEX IF NOT EXISTS idx_node_links_target_node_id ON node_links(target_node_id) WHERE target_node_id != '';
+
+-- 外部链接的检查结果
+CREATE TABLE IF NOT EXISTS link_checks (
+    url TEXT PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT '',
+    status_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
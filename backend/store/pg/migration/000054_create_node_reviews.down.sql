@@ -0,0 +1,2 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS review_setting;
+DROP TABLE IF EXISTS node_reviews;
@@ -0,0 +1,15 @@
+-- 文档和文件夹的负责人与复查周期，未设置时继承上级
+CREATE TABLE IF NOT EXISTS node_reviews (
+    node_id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL DEFAULT '',
+    interval_days INT NOT NULL DEFAULT 0,
+    reviewed_at TIMESTAMPTZ,
+    reminded_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_reviews_kb_id ON node_reviews(kb_id);
+
+-- 过期文档提醒设置
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS review_setting JSONB NOT NULL DEFAULT '{}';
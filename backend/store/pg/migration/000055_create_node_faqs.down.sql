@@ -0,0 +1,2 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS faq_setting;
+DROP TABLE IF EXISTS node_faqs;
@@ -0,0 +1,21 @@
+-- 根据文档内容生成的问答对，审核通过后作为附加检索记录指向来源文档
+CREATE TABLE IF NOT EXISTS node_faqs (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    doc_id TEXT NOT NULL DEFAULT '',
+    parent_doc_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_faqs_kb_id_status ON node_faqs(kb_id, status);
+CREATE INDEX IF NOT EXISTS idx_node_faqs_node_id ON node_faqs(node_id);
+CREATE INDEX IF NOT EXISTS idx_node_faqs_doc_id ON node_faqs(doc_id) WHERE doc_id != '';
+CREATE INDEX IF NOT EXISTS idx_node_faqs_parent_doc_id ON node_faqs(parent_doc_id) WHERE parent_doc_id != '';
+
+-- 问答对生成设置
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS faq_setting JSONB NOT NULL DEFAULT '{}';
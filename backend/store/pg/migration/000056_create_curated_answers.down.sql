@@ -0,0 +1,4 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS curated_answer_setting;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS answer_dataset_id;
+DROP TABLE IF EXISTS curated_answer_hits;
+DROP TABLE IF EXISTS curated_answers;
@@ -0,0 +1,39 @@
+-- 标准答案：问题命中时优先于文档检索，直接回答或注入到提示词
+CREATE TABLE IF NOT EXISTS curated_answers (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    alternates TEXT[] NOT NULL DEFAULT '{}',
+    answer TEXT NOT NULL,
+    node_ids TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    doc_ids TEXT[] NOT NULL DEFAULT '{}',
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMPTZ,
+    source_message_id TEXT NOT NULL DEFAULT '',
+    creator_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_answers_kb_id ON curated_answers(kb_id);
+CREATE INDEX IF NOT EXISTS idx_curated_answers_doc_ids ON curated_answers USING GIN(doc_ids);
+
+-- 标准答案命中记录
+CREATE TABLE IF NOT EXISTS curated_answer_hits (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    answer_id TEXT NOT NULL,
+    conversation_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_curated_answer_hits_answer_id_created_at ON curated_answer_hits(answer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_curated_answer_hits_kb_id ON curated_answer_hits(kb_id);
+
+-- 标准答案单独使用一个检索库，只用于匹配问题
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS answer_dataset_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS curated_answer_setting JSONB NOT NULL DEFAULT '{}';
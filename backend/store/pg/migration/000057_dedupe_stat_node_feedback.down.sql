@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uniq_stat_node_events_feedback;
@@ -0,0 +1,11 @@
+-- 同一会话对同一文档只保留最后一次有用/没用反馈
+DELETE FROM stat_node_events a
+USING stat_node_events b
+WHERE a.type = 'feedback'
+  AND b.type = 'feedback'
+  AND a.kb_id = b.kb_id
+  AND a.node_id = b.node_id
+  AND a.session_id = b.session_id
+  AND a.id < b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_stat_node_events_feedback ON stat_node_events(kb_id, node_id, session_id) WHERE type = 'feedback';
@@ -0,0 +1,3 @@
+-- 去重命中时刷新，回收文件时以最后使用时间计算宽限期
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS last_used_at timestamptz NOT NULL DEFAULT NOW();
+UPDATE attachments SET last_used_at = created_at;
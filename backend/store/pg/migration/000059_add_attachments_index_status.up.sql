@@ -0,0 +1,2 @@
+-- 附件写入 RAG 的结果，扫描件等无法提取文本的附件记为 needs_ocr
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS index_status text NOT NULL DEFAULT '';
@@ -66,13 +66,16 @@ func (s *CTRAG) QueryRecords(ctx context.Context, req *QueryRecordsRequest) (str
 		}
 	}
 	s.logger.Debug("retrieving by history msgs", log.Any("history_msgs", req.HistoryMsgs), log.Any("chat_msgs", chatMsgs))
+	metadata := map[string]interface{}{}
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+	metadata["group_ids"] = req.GroupIDs
 	data := &raglite.RetrieveRequest{
-		DatasetID: req.DatasetID,
-		Query:     req.Query,
-		TopK:      10,
-		Metadata: map[string]interface{}{
-			"group_ids": req.GroupIDs,
-		},
+		DatasetID:           req.DatasetID,
+		Query:               req.Query,
+		TopK:                10,
+		Metadata:            metadata,
 		Tags:                req.Tags,
 		SimilarityThreshold: req.SimilarityThreshold,
 		ChatHistory:         chatMsgs,
@@ -112,6 +115,9 @@ func (s *CTRAG) UpsertRecords(ctx context.Context, req *UpsertRecordsRequest) (s
 		Filename:   fmt.Sprintf("%s.md", req.ID),
 		Metadata:   make(map[string]interface{}),
 	}
+	for key, value := range req.Metadata {
+		data.Metadata[key] = value
+	}
 	if req.GroupIDs != nil {
 		data.Metadata["group_ids"] = req.GroupIDs
 	}
@@ -17,6 +17,7 @@ type QueryRecordsRequest struct {
 	Query               string
 	GroupIDs            []int
 	Tags                []string
+	Metadata            map[string]any // 按文档元数据过滤，例如自定义字段
 	SimilarityThreshold float64
 	HistoryMsgs         []*schema.Message
 	MaxChunksPerDoc     int
@@ -30,6 +31,7 @@ type UpsertRecordsRequest struct {
 	Content   string
 	GroupIDs  []int
 	Tags      []string
+	Metadata  map[string]any // 文档元数据，例如自定义字段
 }
 
 type DocumentMetadata struct {
@@ -0,0 +1,249 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const localMetaDir = ".meta"
+
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStore 本地磁盘对象存储，对象保存在 <root>/<bucket>/<object>，
+// 元数据保存在 <root>/.meta/<bucket>/<object>.json
+type LocalStore struct {
+	root     string
+	signKey  []byte
+	endpoint string
+}
+
+type localMeta struct {
+	ContentType  string            `json:"content_type"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+func NewLocalStore(root string, signKey []byte, endpoint string) (*LocalStore, error) {
+	if root == "" {
+		return nil, errors.New("local store dir is required")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create local store dir: %w", err)
+	}
+	return &LocalStore{root: root, signKey: signKey, endpoint: endpoint}, nil
+}
+
+// objectPath 校验对象名，防止越出存储目录
+func (s *LocalStore) objectPath(bucket, object string) (string, string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || strings.HasPrefix(bucket, ".") {
+		return "", "", fmt.Errorf("invalid bucket: %s", bucket)
+	}
+	cleaned := path.Clean("/" + object)
+	if object == "" || cleaned == "/" || cleaned != "/"+strings.TrimPrefix(object, "/") || strings.Contains(object, `\`) {
+		return "", "", fmt.Errorf("invalid object name: %s", object)
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(cleaned, "/"))
+	return filepath.Join(s.root, bucket, rel), filepath.Join(s.root, localMetaDir, bucket, rel+".json"), nil
+}
+
+func (s *LocalStore) PutObject(ctx context.Context, bucket, object string, reader io.Reader, size int64, opts PutObjectOptions) error {
+	objPath, metaPath, err := s.objectPath(bucket, object)
+	if err != nil {
+		return err
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = mime.TypeByExtension(path.Ext(object))
+	}
+	if err := writeFileAtomic(objPath, func(f *os.File) error {
+		n, err := io.Copy(f, reader)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && n != size {
+			return fmt.Errorf("object size mismatch: expected %d, got %d", size, n)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(localMeta{ContentType: opts.ContentType, UserMetadata: opts.UserMetadata})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(metaPath, func(f *os.File) error {
+		_, err := f.Write(meta)
+		return err
+	})
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免读到写了一半的对象
+func writeFileAtomic(name string, write func(f *os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+func (s *LocalStore) GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, *ObjectInfo, error) {
+	f, info, err := s.open(bucket, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+func (s *LocalStore) open(bucket, object string) (*os.File, *ObjectInfo, error) {
+	objPath, _, err := s.objectPath(bucket, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(objPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrObjectNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := s.StatObject(context.Background(), bucket, object)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+func (s *LocalStore) StatObject(ctx context.Context, bucket, object string) (*ObjectInfo, error) {
+	objPath, metaPath, err := s.objectPath(bucket, object)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(objPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, ErrObjectNotFound
+	}
+
+	var meta localMeta
+	if buf, err := os.ReadFile(metaPath); err == nil {
+		_ = json.Unmarshal(buf, &meta)
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(path.Ext(object))
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Key:          object,
+		Size:         stat.Size(),
+		ContentType:  meta.ContentType,
+		LastModified: stat.ModTime(),
+		UserMetadata: meta.UserMetadata,
+	}, nil
+}
+
+func (s *LocalStore) RemoveObject(ctx context.Context, bucket, object string) error {
+	objPath, metaPath, err := s.objectPath(bucket, object)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(objPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(metaPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) sign(bucket, object string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	fmt.Fprintf(mac, "%s/%s\n%d", bucket, object, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignURL 生成 /<bucket>/<object>?expires=&signature= 形式的相对地址
+func (s *LocalStore) SignURL(ctx context.Context, bucket, object string, expires time.Duration) (string, error) {
+	object = strings.TrimPrefix(object, "/")
+	if _, _, err := s.objectPath(bucket, object); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(expires).Unix()
+	u := url.URL{
+		Path: "/" + bucket + "/" + object,
+		RawQuery: url.Values{
+			"expires":   {strconv.FormatInt(exp, 10)},
+			"signature": {s.sign(bucket, object, exp)},
+		}.Encode(),
+	}
+	return u.String(), nil
+}
+
+// VerifySignature 校验 SignURL 生成的签名
+func (s *LocalStore) VerifySignature(bucket, object, expires, signature string) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(s.sign(bucket, object, exp)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *LocalStore) InternalEndpoint() string {
+	return s.endpoint
+}
+
+// ServeObject 输出对象内容，支持 Range 和条件请求，携带签名时校验签名及有效期
+func (s *LocalStore) ServeObject(w http.ResponseWriter, r *http.Request, bucket, object string) error {
+	query := r.URL.Query()
+	if signature := query.Get("signature"); signature != "" {
+		if err := s.VerifySignature(bucket, object, query.Get("expires"), signature); err != nil {
+			return err
+		}
+	}
+
+	f, info, err := s.open(bucket, object)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("ETag", strconv.Quote(fmt.Sprintf("%x-%x", info.LastModified.UnixNano(), info.Size)))
+	http.ServeContent(w, r, path.Base(object), info.LastModified, f)
+	return nil
+}
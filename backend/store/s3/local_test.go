@@ -0,0 +1,262 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBucket = "static-file"
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir(), []byte("secret"), "http://127.0.0.1:8000")
+	require.NoError(t, err)
+	return store
+}
+
+func putTestObject(t *testing.T, store *LocalStore, object, content string, opts PutObjectOptions) {
+	t.Helper()
+	require.NoError(t, store.PutObject(context.Background(), testBucket, object, strings.NewReader(content), int64(len(content)), opts))
+}
+
+func TestLocalStore_Object(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+	putTestObject(t, store, "kb/a.txt", "hello", PutObjectOptions{UserMetadata: map[string]string{"sha256": "x"}})
+
+	info, err := store.StatObject(ctx, testBucket, "kb/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "kb/a.txt", info.Key)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "text/plain; charset=utf-8", info.ContentType)
+	assert.Equal(t, map[string]string{"sha256": "x"}, info.UserMetadata)
+
+	reader, info, err := store.GetObject(ctx, testBucket, "/kb/a.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, int64(5), info.Size)
+
+	// 覆盖写入
+	putTestObject(t, store, "kb/a.txt", "hello world", PutObjectOptions{ContentType: "application/x-test"})
+	info, err = store.StatObject(ctx, testBucket, "kb/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+	assert.Equal(t, "application/x-test", info.ContentType)
+	assert.Empty(t, info.UserMetadata)
+
+	require.NoError(t, store.RemoveObject(ctx, testBucket, "kb/a.txt"))
+	_, err = store.StatObject(ctx, testBucket, "kb/a.txt")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	_, _, err = store.GetObject(ctx, testBucket, "kb/a.txt")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	// 删除不存在的对象不报错
+	assert.NoError(t, store.RemoveObject(ctx, testBucket, "kb/a.txt"))
+	// 目录不是对象
+	putTestObject(t, store, "kb/b/c.txt", "c", PutObjectOptions{})
+	_, err = store.StatObject(ctx, testBucket, "kb/b")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestLocalStore_PutObjectSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	err := store.PutObject(ctx, testBucket, "a.txt", strings.NewReader("hello"), 3, PutObjectOptions{})
+	assert.ErrorContains(t, err, "size mismatch")
+	_, err = store.StatObject(ctx, testBucket, "a.txt")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+
+	// 大小未知时按实际内容写入
+	require.NoError(t, store.PutObject(ctx, testBucket, "a.txt", strings.NewReader("hello"), -1, PutObjectOptions{}))
+	info, err := store.StatObject(ctx, testBucket, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+}
+
+func TestLocalStore_ObjectPath(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	tests := []struct {
+		name    string
+		bucket  string
+		object  string
+		wantErr bool
+	}{
+		{"plain", testBucket, "a.png", false},
+		{"nested", testBucket, "kb/2024/a.png", false},
+		{"leading slash", testBucket, "/kb/a.png", false},
+		{"empty", testBucket, "", true},
+		{"root", testBucket, "/", true},
+		{"parent", testBucket, "../a.png", true},
+		{"nested parent", testBucket, "kb/../../a.png", true},
+		{"parent inside", testBucket, "kb/../a.png", true},
+		{"dot segment", testBucket, "kb/./a.png", true},
+		{"double slash", testBucket, "kb//a.png", true},
+		{"trailing slash", testBucket, "kb/", true},
+		{"backslash", testBucket, `kb\..\a.png`, true},
+		{"empty bucket", "", "a.png", true},
+		{"bucket with slash", "a/b", "a.png", true},
+		{"meta bucket", localMetaDir, "a.png", true},
+		{"parent bucket", "..", "a.png", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objPath, metaPath, err := store.objectPath(tt.bucket, tt.object)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(objPath, filepath.Join(store.root, tt.bucket)+string(os.PathSeparator)))
+			assert.True(t, strings.HasPrefix(metaPath, filepath.Join(store.root, localMetaDir, tt.bucket)+string(os.PathSeparator)))
+		})
+	}
+}
+
+func TestLocalStore_ServeObject(t *testing.T) {
+	store := newTestLocalStore(t)
+	putTestObject(t, store, "a.txt", "0123456789", PutObjectOptions{})
+
+	tests := []struct {
+		name          string
+		method        string
+		header        map[string]string
+		status        int
+		body          string
+		contentRange  string
+		contentLength string
+	}{
+		{"full", http.MethodGet, nil, http.StatusOK, "0123456789", "", "10"},
+		{"head", http.MethodHead, nil, http.StatusOK, "", "", "10"},
+		{"range", http.MethodGet, map[string]string{"Range": "bytes=2-5"}, http.StatusPartialContent, "2345", "bytes 2-5/10", "4"},
+		{"open range", http.MethodGet, map[string]string{"Range": "bytes=7-"}, http.StatusPartialContent, "789", "bytes 7-9/10", "3"},
+		{"suffix range", http.MethodGet, map[string]string{"Range": "bytes=-2"}, http.StatusPartialContent, "89", "bytes 8-9/10", "2"},
+		{"unsatisfiable range", http.MethodGet, map[string]string{"Range": "bytes=20-"}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/static-file/a.txt", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			require.NoError(t, store.ServeObject(rec, req, testBucket, "a.txt"))
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+			assert.Equal(t, tt.contentRange, rec.Header().Get("Content-Range"))
+			if tt.contentLength != "" {
+				assert.Equal(t, tt.contentLength, rec.Header().Get("Content-Length"))
+			}
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+				assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestLocalStore_ServeObjectConditional(t *testing.T) {
+	store := newTestLocalStore(t)
+	putTestObject(t, store, "a.txt", "hello", PutObjectOptions{})
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, store.ServeObject(rec, httptest.NewRequest(http.MethodGet, "/static-file/a.txt", nil), testBucket, "a.txt"))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/static-file/a.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	require.NoError(t, store.ServeObject(rec, req, testBucket, "a.txt"))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	err := store.ServeObject(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static-file/b.txt", nil), testBucket, "b.txt")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestLocalStore_SignURL(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+	putTestObject(t, store, "kb/a b.txt", "hello", PutObjectOptions{})
+
+	signed, err := store.SignURL(ctx, testBucket, "/kb/a b.txt", time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/static-file/kb/a b.txt", u.Path)
+	query := u.Query()
+
+	_, err = store.SignURL(ctx, testBucket, "../a.txt", time.Hour)
+	assert.Error(t, err)
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	tests := []struct {
+		name      string
+		object    string
+		expires   string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", "kb/a b.txt", query.Get("expires"), query.Get("signature"), false},
+		{"other object", "kb/a.txt", query.Get("expires"), query.Get("signature"), true},
+		{"extended expiry", "kb/a b.txt", strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10), query.Get("signature"), true},
+		{"expired", "kb/a b.txt", expired, store.sign(testBucket, "kb/a b.txt", mustParseInt(t, expired)), true},
+		{"invalid expiry", "kb/a b.txt", "never", query.Get("signature"), true},
+		{"bad signature", "kb/a b.txt", query.Get("expires"), "00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.VerifySignature(testBucket, tt.object, tt.expires, tt.signature)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSignature)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// 其他密钥签发的地址无效
+	other, err := NewLocalStore(t.TempDir(), []byte("other"), "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.VerifySignature(testBucket, "kb/a b.txt", query.Get("expires"), query.Get("signature")), ErrInvalidSignature)
+
+	// 输出对象时校验签名，未携带签名时允许匿名读取
+	rec := httptest.NewRecorder()
+	require.NoError(t, store.ServeObject(rec, httptest.NewRequest(http.MethodGet, signed, nil), testBucket, "kb/a b.txt"))
+	assert.Equal(t, "hello", rec.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/static-file/kb/a%20b.txt?expires="+expired+"&signature="+query.Get("signature"), nil)
+	assert.ErrorIs(t, store.ServeObject(httptest.NewRecorder(), req, testBucket, "kb/a b.txt"), ErrInvalidSignature)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, store.ServeObject(rec, httptest.NewRequest(http.MethodGet, "/static-file/kb/a%20b.txt", nil), testBucket, "kb/a b.txt"))
+	assert.Equal(t, "hello", rec.Body.String())
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 64)
+	require.NoError(t, err)
+	return n
+}
@@ -3,6 +3,8 @@ package s3
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -13,7 +15,7 @@ import (
 )
 
 type MinioClient struct {
-	*minio.Client
+	client *minio.Client
 	config *config.Config
 }
 
@@ -58,14 +60,68 @@ func NewMinioClient(config *config.Config) (*MinioClient, error) {
 			return nil, fmt.Errorf("set bucket policy: %w", err)
 		}
 	}
-	return &MinioClient{Client: minioClient, config: config}, nil
+	return &MinioClient{client: minioClient, config: config}, nil
+}
+
+func (c *MinioClient) PutObject(ctx context.Context, bucket, object string, reader io.Reader, size int64, opts PutObjectOptions) error {
+	_, err := c.client.PutObject(ctx, bucket, object, reader, size, minio.PutObjectOptions{
+		ContentType:  opts.ContentType,
+		UserMetadata: opts.UserMetadata,
+	})
+	return err
+}
+
+func (c *MinioClient) GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, *ObjectInfo, error) {
+	obj, err := c.client.GetObject(ctx, bucket, object, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, convertMinioError(err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, convertMinioError(err)
+	}
+	return obj, toObjectInfo(stat), nil
+}
+
+func (c *MinioClient) StatObject(ctx context.Context, bucket, object string) (*ObjectInfo, error) {
+	stat, err := c.client.StatObject(ctx, bucket, object, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, convertMinioError(err)
+	}
+	return toObjectInfo(stat), nil
+}
+
+func (c *MinioClient) RemoveObject(ctx context.Context, bucket, object string) error {
+	return c.client.RemoveObject(ctx, bucket, object, minio.RemoveObjectOptions{})
 }
 
 // sign url
 func (c *MinioClient) SignURL(ctx context.Context, bucket, object string, expires time.Duration) (string, error) {
-	url, err := c.PresignedGetObject(ctx, bucket, object, expires, nil)
+	url, err := c.client.PresignedGetObject(ctx, bucket, object, expires, nil)
 	if err != nil {
 		return "", err
 	}
 	return url.String(), nil
 }
+
+func (c *MinioClient) InternalEndpoint() string {
+	return "http://" + c.config.S3.Endpoint
+}
+
+func toObjectInfo(stat minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          stat.Key,
+		Size:         stat.Size,
+		ContentType:  stat.ContentType,
+		LastModified: stat.LastModified,
+		UserMetadata: stat.UserMetadata,
+	}
+}
+
+func convertMinioError(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrObjectNotFound
+	}
+	return err
+}
@@ -2,4 +2,4 @@ package s3
 
 import "github.com/google/wire"
 
-var ProviderSet = wire.NewSet(NewMinioClient)
+var ProviderSet = wire.NewSet(NewObjectStore)
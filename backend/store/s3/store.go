@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chaitin/panda-wiki/config"
+)
+
+const (
+	StoreTypeMinio = "minio"
+	StoreTypeLocal = "local"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore 对象存储，默认使用 MinIO，单机部署可使用本地磁盘
+type ObjectStore interface {
+	PutObject(ctx context.Context, bucket, object string, reader io.Reader, size int64, opts PutObjectOptions) error
+	GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, *ObjectInfo, error)
+	StatObject(ctx context.Context, bucket, object string) (*ObjectInfo, error)
+	RemoveObject(ctx context.Context, bucket, object string) error
+	// SignURL 生成带有效期的下载地址
+	SignURL(ctx context.Context, bucket, object string, expires time.Duration) (string, error)
+	// InternalEndpoint 容器网络内访问 /<bucket>/<object> 的地址，供 anydoc 等内部服务下载文件
+	InternalEndpoint() string
+}
+
+type PutObjectOptions struct {
+	ContentType  string
+	UserMetadata map[string]string
+}
+
+type ObjectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	LastModified time.Time         `json:"last_modified"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+func NewObjectStore(config *config.Config) (ObjectStore, error) {
+	switch config.S3.Type {
+	case StoreTypeLocal:
+		signKey := []byte(config.Auth.JWT.Secret)
+		if len(signKey) == 0 {
+			// 未配置密钥时签名地址在重启后失效
+			signKey = make([]byte, 32)
+			if _, err := rand.Read(signKey); err != nil {
+				return nil, err
+			}
+		}
+		subnetPrefix := config.SubnetPrefix
+		if subnetPrefix == "" {
+			subnetPrefix = "169.254.15"
+		}
+		return NewLocalStore(config.S3.LocalDir, signKey, fmt.Sprintf("http://%s.2:%d", subnetPrefix, config.HTTP.Port))
+	case StoreTypeMinio, "":
+		return NewMinioClient(config)
+	default:
+		return nil, fmt.Errorf("unsupported object store type: %s", config.S3.Type)
+	}
+}
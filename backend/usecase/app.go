@@ -537,6 +537,8 @@ func (u *AppUsecase) GetAppDetailByKBIDAndAppType(ctx context.Context, kbID stri
 		DocumentFeedBackIsEnabled: app.Settings.DocumentFeedBackIsEnabled,
 		// AI Feedback
 		AIFeedbackSettings: app.Settings.AIFeedbackSettings,
+		// follow-up questions
+		FollowUpQuestionsIsEnabled: app.Settings.FollowUpQuestionsIsEnabled,
 		// WebApp Custom Settings
 		WebAppCustomSettings: app.Settings.WebAppCustomSettings,
 		// openai api settings
@@ -708,6 +710,8 @@ func (u *AppUsecase) ShareGetWebAppInfo(ctx context.Context, kbID string, authId
 			DocumentFeedBackIsEnabled: app.Settings.DocumentFeedBackIsEnabled,
 			// AI Feedback
 			AIFeedbackSettings: app.Settings.AIFeedbackSettings,
+			// follow-up questions
+			FollowUpQuestionsIsEnabled: app.Settings.FollowUpQuestionsIsEnabled,
 			// WebApp Custom Settings
 			WebAppCustomSettings: app.Settings.WebAppCustomSettings,
 			// Disclaimer Settings
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
+)
+
+const (
+	// 上传或复用后一段时间内未被引用的文件不回收，避免编辑中的文档图片被删除
+	attachmentGCGracePeriod = 24 * time.Hour
+	attachmentGCBatchSize   = 500
+)
+
+type AttachmentUsecase struct {
+	attachmentRepo *pg.AttachmentRepository
+	kbRepo         *pg.KnowledgeBaseRepository
+	objectStore    s3.ObjectStore
+	logger         *log.Logger
+}
+
+func NewAttachmentUsecase(attachmentRepo *pg.AttachmentRepository, kbRepo *pg.KnowledgeBaseRepository, objectStore s3.ObjectStore, logger *log.Logger) *AttachmentUsecase {
+	return &AttachmentUsecase{
+		attachmentRepo: attachmentRepo,
+		kbRepo:         kbRepo,
+		objectStore:    objectStore,
+		logger:         logger.WithModule("usecase.attachment"),
+	}
+}
+
+func (u *AttachmentUsecase) GetStorageUsage(ctx context.Context, kbID string) (*v1.KBStorageResp, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := u.attachmentRepo.GetAttachmentUsage(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.KBStorageResp{
+		Quota:           kb.StorageQuota,
+		AttachmentUsage: *usage,
+	}, nil
+}
+
+func (u *AttachmentUsecase) UpdateStorageQuota(ctx context.Context, req v1.KBStorageQuotaReq) error {
+	if _, err := u.kbRepo.GetKnowledgeBaseByID(ctx, req.KBId); err != nil {
+		return err
+	}
+	return u.kbRepo.UpdateStorageQuota(ctx, req.KBId, req.Quota)
+}
+
+// CollectGarbage 重建引用索引后删除无引用的文件，已删除知识库的文件同样会被回收
+func (u *AttachmentUsecase) CollectGarbage(ctx context.Context) error {
+	if err := u.attachmentRepo.RebuildAttachmentRefs(ctx); err != nil {
+		return fmt.Errorf("rebuild attachment refs failed: %w", err)
+	}
+
+	before := time.Now().Add(-attachmentGCGracePeriod)
+	var removed, freed int64
+	for {
+		attachments, err := u.attachmentRepo.GetOrphanAttachments(ctx, before, attachmentGCBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(attachments) == 0 {
+			break
+		}
+		for _, attachment := range attachments {
+			if err := u.objectStore.RemoveObject(ctx, domain.Bucket, attachment.Key); err != nil && !errors.Is(err, s3.ErrObjectNotFound) {
+				// 对象删除失败时保留记录，下次继续回收
+				return fmt.Errorf("remove object %s failed: %w", attachment.Key, err)
+			}
+			if err := u.attachmentRepo.DeleteAttachment(ctx, attachment.Key); err != nil {
+				return err
+			}
+			removed++
+			freed += attachment.Size
+		}
+	}
+	u.logger.Info("collect orphan attachments", log.Int64("removed", removed), log.Int64("freed", freed))
+	return nil
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/extract"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+	"github.com/chaitin/panda-wiki/store/s3"
+)
+
+const (
+	attachmentIndexMaxFiles = 50
+	attachmentIndexMaxSize  = 50 << 20
+)
+
+var errNoVLModel = errors.New("analysis-vl model is not configured")
+
+type AttachmentIndexUsecase struct {
+	attachmentRepo *pg.AttachmentRepository
+	objectStore    s3.ObjectStore
+	rag            rag.RAGService
+	llmUsecase     *LLMUsecase
+	modelUsecase   *ModelUsecase
+	logger         *log.Logger
+}
+
+func NewAttachmentIndexUsecase(attachmentRepo *pg.AttachmentRepository, objectStore s3.ObjectStore, rag rag.RAGService, llmUsecase *LLMUsecase, modelUsecase *ModelUsecase, logger *log.Logger) *AttachmentIndexUsecase {
+	return &AttachmentIndexUsecase{
+		attachmentRepo: attachmentRepo,
+		objectStore:    objectStore,
+		rag:            rag,
+		llmUsecase:     llmUsecase,
+		modelUsecase:   modelUsecase,
+		logger:         logger.WithModule("usecase.attachment_index"),
+	}
+}
+
+// IndexNodeAttachments 将发布版本引用的附件按页写入 RAG，已写入的附件只同步权限分组，
+// 不再引用的附件从 RAG 中删除
+func (u *AttachmentIndexUsecase) IndexNodeAttachments(ctx context.Context, datasetID string, release *domain.NodeRelease, groupIDs []int) error {
+	keys := lo.Filter(domain.ExtractAttachmentKeys(release.Content), func(key string, _ int) bool {
+		return extract.IsSupported(key) || extract.IsImage(key)
+	})
+	if len(keys) > attachmentIndexMaxFiles {
+		keys = keys[:attachmentIndexMaxFiles]
+	}
+
+	existing, err := u.attachmentRepo.GetNodeAttachmentDocs(ctx, release.NodeID)
+	if err != nil {
+		return err
+	}
+	staleDocs, keptDocs := lo.FilterReject(existing, func(doc *domain.NodeAttachmentDoc, _ int) bool {
+		return !lo.Contains(keys, doc.Key)
+	})
+	if err := u.deleteDocs(ctx, datasetID, staleDocs); err != nil {
+		return err
+	}
+	for _, doc := range keptDocs {
+		if err := u.rag.UpdateDocumentGroupIDs(ctx, datasetID, doc.DocID, groupIDs); err != nil {
+			return fmt.Errorf("update attachment doc group ids failed: %w", err)
+		}
+	}
+	if err := u.attachmentRepo.UpdateNodeAttachmentDocsParent(ctx, release.NodeID, release.DocID); err != nil {
+		return err
+	}
+
+	indexedKeys := lo.SliceToMap(keptDocs, func(doc *domain.NodeAttachmentDoc) (string, bool) {
+		return doc.Key, true
+	})
+	newKeys := lo.Reject(keys, func(key string, _ int) bool { return indexedKeys[key] })
+	if len(newKeys) == 0 {
+		return nil
+	}
+	attachments, err := u.attachmentRepo.GetAttachmentsByKeys(ctx, newKeys)
+	if err != nil {
+		return err
+	}
+
+	var vlModel *domain.Model
+	for _, key := range newKeys {
+		filename, pages, err := u.extractAttachment(ctx, key, attachments[key], &vlModel)
+		if err != nil {
+			// 附件无法解析时不影响文档本身的索引
+			u.logger.Warn("extract attachment text failed", log.String("node_id", release.NodeID), log.String("key", key), log.Error(err))
+			u.updateIndexStatus(ctx, key, domain.AttachmentIndexStatusFailed)
+			continue
+		}
+		scanned, pages := lo.FilterReject(pages, func(page extract.Page, _ int) bool { return page.Scanned })
+		if err := u.indexPages(ctx, datasetID, release, groupIDs, key, filename, pages); err != nil {
+			u.updateIndexStatus(ctx, key, domain.AttachmentIndexStatusFailed)
+			return err
+		}
+		switch {
+		case len(scanned) > 0:
+			// 扫描页没有文本层，记录下来而不是当作成功
+			u.logger.Warn("attachment pages need ocr", log.String("node_id", release.NodeID), log.String("key", key), log.Any("pages", lo.Map(scanned, func(page extract.Page, _ int) int { return page.Number })))
+			u.updateIndexStatus(ctx, key, domain.AttachmentIndexStatusNeedsOCR)
+		case len(pages) == 0:
+			u.updateIndexStatus(ctx, key, domain.AttachmentIndexStatusEmpty)
+		default:
+			u.updateIndexStatus(ctx, key, domain.AttachmentIndexStatusIndexed)
+		}
+	}
+	return nil
+}
+
+func (u *AttachmentIndexUsecase) updateIndexStatus(ctx context.Context, key string, status domain.AttachmentIndexStatus) {
+	if err := u.attachmentRepo.UpdateAttachmentIndexStatus(ctx, key, status); err != nil {
+		u.logger.Error("update attachment index status failed", log.String("key", key), log.Error(err))
+	}
+}
+
+// indexPages 写入一个附件的全部页，失败时回滚已写入的页，重试时重新写入
+func (u *AttachmentIndexUsecase) indexPages(ctx context.Context, datasetID string, release *domain.NodeRelease, groupIDs []int, key, filename string, pages []extract.Page) error {
+	if len(pages) == 0 {
+		return nil
+	}
+	created := make([]*domain.NodeAttachmentDoc, 0, len(pages))
+	rollback := func(err error) error {
+		if delErr := u.deleteDocs(ctx, datasetID, created); delErr != nil {
+			u.logger.Error("rollback attachment docs failed", log.String("key", key), log.Error(delErr))
+		}
+		return fmt.Errorf("index attachment %s failed: %w", key, err)
+	}
+	for _, page := range pages {
+		doc := &domain.NodeAttachmentDoc{
+			KBID:        release.KBID,
+			NodeID:      release.NodeID,
+			ParentDocID: release.DocID,
+			Key:         key,
+			Filename:    filename,
+			Page:        page.Number,
+			PageName:    page.Name,
+		}
+		docID, err := u.rag.UpsertRecords(ctx, &rag.UpsertRecordsRequest{
+			ID:        uuid.New().String(),
+			DatasetID: datasetID,
+			Title:     fmt.Sprintf("%s - %s", release.Name, doc.Source()),
+			Content:   page.Text,
+			GroupIDs:  groupIDs,
+		})
+		if err != nil {
+			return rollback(err)
+		}
+		doc.DocID = docID
+		created = append(created, doc)
+		if err := u.attachmentRepo.CreateNodeAttachmentDoc(ctx, doc); err != nil {
+			return rollback(err)
+		}
+	}
+	u.logger.Info("index attachment success", log.String("node_id", release.NodeID), log.String("key", key), log.Int("pages", len(pages)))
+	return nil
+}
+
+func (u *AttachmentIndexUsecase) extractAttachment(ctx context.Context, key string, attachment *domain.Attachment, vlModel **domain.Model) (string, []extract.Page, error) {
+	reader, info, err := u.objectStore.GetObject(ctx, domain.Bucket, key)
+	if err != nil {
+		return "", nil, err
+	}
+	defer reader.Close()
+	if info.Size > attachmentIndexMaxSize {
+		return "", nil, fmt.Errorf("attachment is too large: %d bytes", info.Size)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, attachmentIndexMaxSize))
+	if err != nil {
+		return "", nil, err
+	}
+
+	filename := path.Base(key)
+	if attachment != nil && attachment.Filename != "" {
+		filename = attachment.Filename
+	} else {
+		for k, v := range info.UserMetadata {
+			if strings.EqualFold(k, "originalname") && v != "" {
+				filename = v
+			}
+		}
+	}
+
+	if !extract.IsImage(key) {
+		pages, err := extract.Extract(key, data)
+		return filename, pages, err
+	}
+
+	// 图片通过视觉模型识别文字，未配置模型时跳过
+	if *vlModel == nil {
+		model, err := u.modelUsecase.GetAnalysisVLModel(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", errNoVLModel, err)
+		}
+		*vlModel = model
+	}
+	text, err := u.llmUsecase.RecognizeImageText(ctx, *vlModel, data, info.ContentType)
+	if err != nil {
+		return "", nil, err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return filename, nil, nil
+	}
+	return filename, []extract.Page{{Text: text}}, nil
+}
+
+// DeleteNodeAttachmentDocs 删除文档发布版本下的附件记录
+func (u *AttachmentIndexUsecase) DeleteNodeAttachmentDocs(ctx context.Context, datasetID, parentDocID string) error {
+	docs, err := u.attachmentRepo.GetNodeAttachmentDocsByParentDocID(ctx, parentDocID)
+	if err != nil {
+		return err
+	}
+	return u.deleteDocs(ctx, datasetID, docs)
+}
+
+// UpdateNodeAttachmentGroupIDs 文档权限变化时同步附件记录的权限分组
+func (u *AttachmentIndexUsecase) UpdateNodeAttachmentGroupIDs(ctx context.Context, datasetID, parentDocID string, groupIDs []int) error {
+	docs, err := u.attachmentRepo.GetNodeAttachmentDocsByParentDocID(ctx, parentDocID)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := u.rag.UpdateDocumentGroupIDs(ctx, datasetID, doc.DocID, groupIDs); err != nil {
+			return fmt.Errorf("update attachment doc group ids failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (u *AttachmentIndexUsecase) deleteDocs(ctx context.Context, datasetID string, docs []*domain.NodeAttachmentDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	docIDs := lo.Map(docs, func(doc *domain.NodeAttachmentDoc, _ int) string { return doc.DocID })
+	if err := u.rag.DeleteRecords(ctx, datasetID, docIDs); err != nil {
+		return fmt.Errorf("delete attachment docs failed: %w", err)
+	}
+	return u.attachmentRepo.DeleteNodeAttachmentDocs(ctx, docIDs)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/saml"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/store/cache"
 )
@@ -43,6 +44,8 @@ type StateInfo struct {
 	KbId        string `json:"kb_id"`
 	RedirectUrl string `json:"redirect_url"`
 	Verifier    string `json:"verifier"`
+	Nonce       string `json:"nonce,omitempty"`
+	RequestID   string `json:"request_id,omitempty"` // SAML AuthnRequest ID
 }
 
 func (u *AuthUsecase) GetAuthBySourceType(ctx context.Context, sourceType consts.SourceType) (*domain.Auth, error) {
@@ -56,9 +59,18 @@ func (u *AuthUsecase) DeleteAuth(ctx context.Context, req v1.AuthDeleteReq) erro
 func (u *AuthUsecase) SetAuth(ctx context.Context, req v1.AuthSetReq) error {
 	if err := u.AuthRepo.CreateAuthConfig(ctx, &domain.AuthConfig{
 		AuthSetting: domain.AuthSetting{
-			ClientID:     req.ClientID,
-			ClientSecret: req.ClientSecret,
-			Proxy:        req.Proxy,
+			ClientID:       req.ClientID,
+			ClientSecret:   req.ClientSecret,
+			Proxy:          req.Proxy,
+			Issuer:         req.Issuer,
+			Scopes:         req.Scopes,
+			IDPMetadataURL: req.IDPMetadataURL,
+			IDPMetadataXML: req.IDPMetadataXML,
+			EntityID:       req.EntityID,
+			Certificate:    req.Certificate,
+			PrivateKey:     req.PrivateKey,
+			ClaimMapping:   req.ClaimMapping,
+			LDAP:           req.LDAP,
 		},
 		KbID:       req.KBID,
 		SourceType: req.SourceType,
@@ -111,6 +123,26 @@ func (u *AuthUsecase) GetAuth(ctx context.Context, kbID string, sourceType const
 		SourceType:   authConfig.SourceType,
 		Proxy:        authConfig.AuthSetting.Proxy,
 		Auths:        as,
+
+		Issuer:         authConfig.AuthSetting.Issuer,
+		Scopes:         authConfig.AuthSetting.Scopes,
+		IDPMetadataURL: authConfig.AuthSetting.IDPMetadataURL,
+		IDPMetadataXML: authConfig.AuthSetting.IDPMetadataXML,
+		EntityID:       authConfig.AuthSetting.EntityID,
+		Certificate:    authConfig.AuthSetting.Certificate,
+		PrivateKey:     authConfig.AuthSetting.PrivateKey,
+		ClaimMapping:   authConfig.AuthSetting.ClaimMapping,
+		LDAP:           authConfig.AuthSetting.LDAP,
+	}
+
+	if sourceType == consts.SourceTypeSAML {
+		kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			return nil, err
+		}
+		if kb.AccessSettings.BaseURL != "" {
+			resp.SPMetadataURL, _ = saml.MetadataURL(kb.AccessSettings.BaseURL, kbID)
+		}
 	}
 	return resp, nil
 
@@ -152,6 +184,15 @@ func (u *AuthUsecase) genState(ctx context.Context, stateInfo StateInfo) (string
 	return state, nil
 }
 
+// setState 覆盖已生成 state 的内容
+func (u *AuthUsecase) setState(ctx context.Context, state string, stateInfo StateInfo) error {
+	stateInfoBytes, err := json.Marshal(stateInfo)
+	if err != nil {
+		return err
+	}
+	return u.cache.Set(ctx, state, stateInfoBytes, 15*time.Minute).Err()
+}
+
 func (u *AuthUsecase) SaveNewSession(c echo.Context, auth *domain.Auth) error {
 	s := c.Get(domain.SessionCacheKey)
 	if s == nil {
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	shareV1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/oidc"
+)
+
+func (u *AuthUsecase) getOIDCClient(ctx context.Context, kbId string) (*oidc.Client, error) {
+	authConfig, err := u.AuthRepo.GetAuthConfig(ctx, kbId, consts.SourceTypeOIDC)
+	if authConfig == nil || err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := u.getKBBaseUrl(ctx, kbId)
+	if err != nil {
+		return nil, err
+	}
+
+	authSetting := authConfig.AuthSetting
+
+	return oidc.NewClient(ctx, u.logger, baseUrl, oidc.Config{
+		Issuer:       authSetting.Issuer,
+		ClientID:     authSetting.ClientID,
+		ClientSecret: authSetting.ClientSecret,
+		Scopes:       authSetting.Scopes,
+		IDField:      authSetting.ClaimMapping.ID,
+		NameField:    authSetting.ClaimMapping.Name,
+		AvatarField:  authSetting.ClaimMapping.Avatar,
+		EmailField:   authSetting.ClaimMapping.Email,
+		GroupsField:  authSetting.ClaimMapping.Groups,
+	})
+}
+
+// getKBBaseUrl SAML/OIDC 回调地址需要知识库配置访问地址
+func (u *AuthUsecase) getKBBaseUrl(ctx context.Context, kbId string) (string, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbId)
+	if err != nil {
+		return "", err
+	}
+	if kb.AccessSettings.BaseURL == "" {
+		return "", errors.New("kb base url is not configured")
+	}
+	return kb.AccessSettings.BaseURL, nil
+}
+
+func (u *AuthUsecase) GenerateOIDCAuthUrl(ctx context.Context, req shareV1.AuthOIDCReq) (string, error) {
+	oidcClient, err := u.getOIDCClient(ctx, req.KbID)
+	if err != nil {
+		return "", fmt.Errorf("get oidcClient failed: %w", err)
+	}
+
+	stateInfo := StateInfo{
+		KbId:        req.KbID,
+		RedirectUrl: req.RedirectUrl,
+		Verifier:    oauth2.GenerateVerifier(),
+		Nonce:       uuid.New().String(),
+	}
+	state, err := u.genState(ctx, stateInfo)
+	if err != nil {
+		return "", fmt.Errorf("gen state failed: %w", err)
+	}
+
+	return oidcClient.GetAuthorizeURL(state, stateInfo.Nonce, stateInfo.Verifier), nil
+}
+
+func (u *AuthUsecase) OIDCCallback(ctx context.Context, req shareV1.OIDCCallbackReq) (*domain.Auth, string, error) {
+	statInfo, err := u.getStateInfo(ctx, req.State)
+	if err != nil {
+		return nil, "", err
+	}
+	// state 只能使用一次
+	u.cache.Del(ctx, req.State)
+
+	oidcClient, err := u.getOIDCClient(ctx, statInfo.KbId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userInfo, err := oidcClient.GetUserInfo(req.Code, statInfo.Nonce, statInfo.Verifier)
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth := &domain.Auth{
+		UserInfo: domain.AuthUserInfo{
+			Username:  userInfo.Name,
+			AvatarUrl: userInfo.AvatarUrl,
+			Email:     userInfo.Email,
+		},
+		KBID:       statInfo.KbId,
+		UnionID:    userInfo.ID,
+		SourceType: consts.SourceTypeOIDC,
+	}
+
+	auth, err = u.AuthRepo.GetOrCreateAuth(ctx, auth, consts.SourceTypeOIDC)
+	if err != nil {
+		return nil, "", fmt.Errorf("create auth failed: %w", err)
+	}
+
+	if err := u.AuthRepo.SyncAuthGroupMembers(ctx, auth.KBID, consts.SourceTypeOIDC, auth.ID, userInfo.Groups); err != nil {
+		return nil, "", fmt.Errorf("sync auth groups failed: %w", err)
+	}
+
+	return auth, statInfo.RedirectUrl, nil
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	shareV1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/saml"
+)
+
+func (u *AuthUsecase) getSAMLClient(ctx context.Context, kbId string) (*saml.Client, error) {
+	authConfig, err := u.AuthRepo.GetAuthConfig(ctx, kbId, consts.SourceTypeSAML)
+	if authConfig == nil || err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := u.getKBBaseUrl(ctx, kbId)
+	if err != nil {
+		return nil, err
+	}
+
+	authSetting := authConfig.AuthSetting
+
+	return saml.NewClient(ctx, u.logger, baseUrl, kbId, saml.Config{
+		IDPMetadataURL: authSetting.IDPMetadataURL,
+		IDPMetadataXML: authSetting.IDPMetadataXML,
+		EntityID:       authSetting.EntityID,
+		Certificate:    authSetting.Certificate,
+		PrivateKey:     authSetting.PrivateKey,
+		IDField:        authSetting.ClaimMapping.ID,
+		NameField:      authSetting.ClaimMapping.Name,
+		AvatarField:    authSetting.ClaimMapping.Avatar,
+		EmailField:     authSetting.ClaimMapping.Email,
+		GroupsField:    authSetting.ClaimMapping.Groups,
+	})
+}
+
+// GetSAMLMetadata 生成SP元数据
+func (u *AuthUsecase) GetSAMLMetadata(ctx context.Context, kbId string) ([]byte, error) {
+	samlClient, err := u.getSAMLClient(ctx, kbId)
+	if err != nil {
+		return nil, err
+	}
+	return samlClient.Metadata()
+}
+
+func (u *AuthUsecase) GenerateSAMLAuthUrl(ctx context.Context, req shareV1.AuthSAMLReq) (string, error) {
+	samlClient, err := u.getSAMLClient(ctx, req.KbID)
+	if err != nil {
+		return "", fmt.Errorf("get samlClient failed: %w", err)
+	}
+
+	// RelayState 长度受限，只放 state，请求ID随 state 一起缓存
+	stateInfo := StateInfo{
+		KbId:        req.KbID,
+		RedirectUrl: req.RedirectUrl,
+	}
+	state, err := u.genState(ctx, stateInfo)
+	if err != nil {
+		return "", fmt.Errorf("gen state failed: %w", err)
+	}
+
+	url, requestID, err := samlClient.GetAuthorizeURL(state)
+	if err != nil {
+		return "", err
+	}
+	stateInfo.RequestID = requestID
+	if err := u.setState(ctx, state, stateInfo); err != nil {
+		return "", fmt.Errorf("set state failed: %w", err)
+	}
+
+	return url, nil
+}
+
+func (u *AuthUsecase) SAMLCallback(ctx context.Context, r *http.Request, req shareV1.SAMLACSReq) (*domain.Auth, string, error) {
+	statInfo, err := u.getStateInfo(ctx, req.RelayState)
+	if err != nil {
+		return nil, "", err
+	}
+	u.cache.Del(ctx, req.RelayState)
+	if statInfo.KbId != req.KbID {
+		return nil, "", fmt.Errorf("kb id mismatch")
+	}
+
+	samlClient, err := u.getSAMLClient(ctx, statInfo.KbId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userInfo, err := samlClient.GetUserInfo(r, statInfo.RequestID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth := &domain.Auth{
+		UserInfo: domain.AuthUserInfo{
+			Username:  userInfo.Name,
+			AvatarUrl: userInfo.AvatarUrl,
+			Email:     userInfo.Email,
+		},
+		KBID:       statInfo.KbId,
+		UnionID:    userInfo.ID,
+		SourceType: consts.SourceTypeSAML,
+	}
+
+	auth, err = u.AuthRepo.GetOrCreateAuth(ctx, auth, consts.SourceTypeSAML)
+	if err != nil {
+		return nil, "", fmt.Errorf("create auth failed: %w", err)
+	}
+
+	if err := u.AuthRepo.SyncAuthGroupMembers(ctx, auth.KBID, consts.SourceTypeSAML, auth.ID, userInfo.Groups); err != nil {
+		return nil, "", fmt.Errorf("sync auth groups failed: %w", err)
+	}
+
+	return auth, statInfo.RedirectUrl, nil
+}
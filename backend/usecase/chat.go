@@ -16,10 +16,16 @@ import (
 	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/metrics"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/utils"
 )
 
+const (
+	followUpQuestionsCount   = 3
+	followUpQuestionsTimeout = 15 * time.Second
+)
+
 type ChatUsecase struct {
 	llmUsecase          *LLMUsecase
 	conversationUsecase *ConversationUsecase
@@ -29,12 +35,13 @@ type ChatUsecase struct {
 	kbRepo              *pg.KnowledgeBaseRepository
 	nodeRepo            *pg.NodeRepository
 	AuthRepo            *pg.AuthRepo
+	curatedAnswer       *CuratedAnswerUsecase
 	logger              *log.Logger
 	modelkit            *modelkit.ModelKit
 }
 
 func NewChatUsecase(llmUsecase *LLMUsecase, kbRepo *pg.KnowledgeBaseRepository, conversationUsecase *ConversationUsecase, modelUsecase *ModelUsecase, appRepo *pg.AppRepository,
-	blockWordRepo *pg.BlockWordRepo, nodeRepo *pg.NodeRepository, authRepo *pg.AuthRepo, logger *log.Logger) (*ChatUsecase, error) {
+	blockWordRepo *pg.BlockWordRepo, nodeRepo *pg.NodeRepository, authRepo *pg.AuthRepo, curatedAnswer *CuratedAnswerUsecase, logger *log.Logger) (*ChatUsecase, error) {
 	modelkit := modelkit.NewModelKit(logger.Logger)
 	u := &ChatUsecase{
 		llmUsecase:          llmUsecase,
@@ -45,6 +52,7 @@ func NewChatUsecase(llmUsecase *LLMUsecase, kbRepo *pg.KnowledgeBaseRepository,
 		kbRepo:              kbRepo,
 		nodeRepo:            nodeRepo,
 		AuthRepo:            authRepo,
+		curatedAnswer:       curatedAnswer,
 		logger:              logger.WithModule("usecase.chat"),
 		modelkit:            modelkit,
 	}
@@ -80,6 +88,7 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 	eventCh := make(chan domain.SSEEvent, 100)
 	go func() {
 		defer close(eventCh)
+		start := time.Now()
 		// 1. get app detail and validate app
 		app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, req.KBID, req.AppType)
 		if err != nil {
@@ -224,12 +233,31 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 			return
 		}
 
-		messages, rankedNodes, err := u.llmUsecase.BuildConversationMessageWithRAG(ctx, req.ConversationID, req.KBID, groupIds, req.Prompt)
+		// extra2. 问题命中标准答案时优先使用标准答案，匹配失败不影响正常回答
+		curatedAnswer, curatedAnswerMode, err := u.curatedAnswer.Match(ctx, req.KBID, req.Message)
+		if err != nil {
+			u.logger.Warn("match curated answer failed", log.Error(err))
+		}
+		if curatedAnswer != nil {
+			if err := u.curatedAnswer.RecordHit(ctx, curatedAnswer, curatedAnswerMode, req.ConversationID, messageId, req.Message); err != nil {
+				u.logger.Error("failed to record curated answer hit", log.Error(err))
+			}
+			if curatedAnswerMode == domain.CuratedAnswerModeDirect {
+				u.replyCuratedAnswer(ctx, req, curatedAnswer, groupIds, messageId, userMessageId, eventCh)
+				return
+			}
+		}
+
+		messages, rankedNodes, err := u.llmUsecase.BuildConversationMessageWithRAG(ctx, req.ConversationID, req.KBID, groupIds, req.Prompt, req.Filter)
 		if err != nil {
 			u.logger.Error("build messages failed", log.Error(err))
 			eventCh <- domain.SSEEvent{Type: "error", Content: err.Error()}
 			return
 		}
+		if curatedAnswer != nil && len(messages) > 0 {
+			curatedAnswerMsg := schema.SystemMessage(fmt.Sprintf(domain.CuratedAnswerPrompt, curatedAnswer.Question, curatedAnswer.Answer))
+			messages = slices.Insert(messages, len(messages)-1, curatedAnswerMsg)
+		}
 
 		u.logger.Debug("message:", log.Any("schema", messages))
 		for _, node := range rankedNodes {
@@ -260,8 +288,16 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 		}
 		// get words
 		onChunkAC, flushBuffer := u.CreateAcOnChunk(ctx, req.KBID, &answer, eventCh, blockWords)
+		firstToken := true
+		onChunk := func(ctx context.Context, dataType, chunk string) error {
+			if firstToken {
+				firstToken = false
+				metrics.ObserveChatFirstToken(string(req.ModelInfo.Model), time.Since(start))
+			}
+			return onChunkAC(ctx, dataType, chunk)
+		}
 
-		chatErr := u.llmUsecase.ChatWithAgent(ctx, chatModel, messages, &usage, onChunkAC)
+		chatErr := u.llmUsecase.ChatWithAgent(ctx, chatModel, messages, &usage, onChunk)
 
 		// 处理缓冲区中剩余的内容
 		if flushBuffer != nil {
@@ -290,6 +326,7 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 			return
 		}
 		// update model usage
+		metrics.AddLLMTokens(string(req.ModelInfo.Model), usage.PromptTokens, usage.CompletionTokens)
 		if err := u.modelUsecase.UpdateUsage(ctx, req.ModelInfo.ID, &usage); err != nil {
 			u.logger.Error("failed to update model usage", log.Error(err))
 			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to update model usage"}
@@ -301,11 +338,57 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 			eventCh <- domain.SSEEvent{Type: "error", Content: "对话失败，请稍后再试"}
 			return
 		}
+		// 6. 网页和挂件根据回答和引用的文档推荐追问，失败时不影响本次回答
+		if (req.AppType == domain.AppTypeWeb || req.AppType == domain.AppTypeWidget) &&
+			app.Settings.FollowUpQuestionsEnabled() && answer != "" && len(rankedNodes) > 0 {
+			suggestCtx, cancel := context.WithTimeout(ctx, followUpQuestionsTimeout)
+			nodeNames := lo.Map(rankedNodes, func(node *domain.RankedNodeChunks, _ int) string { return node.NodeName })
+			suggestions, err := u.llmUsecase.SuggestFollowUpQuestions(suggestCtx, chatModel, req.Message, answer, nodeNames, followUpQuestionsCount)
+			cancel()
+			if err != nil {
+				u.logger.Warn("suggest follow-up questions failed", log.Error(err))
+			} else if len(suggestions) > 0 {
+				eventCh <- domain.SSEEvent{Type: "suggestions", Suggestions: suggestions}
+			}
+		}
 		eventCh <- domain.SSEEvent{Type: "done"}
 	}()
 	return eventCh, nil
 }
 
+// replyCuratedAnswer 直接返回标准答案，关联的已发布文档作为引用，不调用模型
+func (u *ChatUsecase) replyCuratedAnswer(ctx context.Context, req *domain.ChatRequest, answer *domain.CuratedAnswer, groupIds []int, messageID, userMessageID string, eventCh chan<- domain.SSEEvent) {
+	releases, err := u.curatedAnswer.GetReferenceNodes(ctx, answer, groupIds)
+	if err != nil {
+		u.logger.Error("failed to get curated answer reference nodes", log.Error(err))
+	}
+	for _, release := range releases {
+		eventCh <- domain.SSEEvent{Type: "chunk_result", ChunkResult: &domain.NodeContentChunkSSE{
+			NodeID:        release.NodeID,
+			Name:          release.Name,
+			Summary:       release.Meta.Summary,
+			Emoji:         release.Meta.Emoji,
+			NodePathNames: release.PathNames,
+		}}
+	}
+	eventCh <- domain.SSEEvent{Type: "data", Content: answer.Answer}
+	if err := u.conversationUsecase.CreateChatConversationMessage(ctx, req.KBID, &domain.ConversationMessage{
+		ID:             messageID,
+		ConversationID: req.ConversationID,
+		KBID:           req.KBID,
+		AppID:          req.AppID,
+		Role:           schema.Assistant,
+		Content:        answer.Answer,
+		RemoteIP:       req.RemoteIP,
+		ParentID:       userMessageID,
+	}); err != nil {
+		u.logger.Error("failed to save assistant answer to conversation message", log.Error(err))
+		eventCh <- domain.SSEEvent{Type: "error", Content: "failed to save assistant answer to conversation message"}
+		return
+	}
+	eventCh <- domain.SSEEvent{Type: "done"}
+}
+
 func (u *ChatUsecase) ChatRagOnly(ctx context.Context, req *domain.ChatRagOnlyRequest) (<-chan domain.SSEEvent, error) {
 	eventCh := make(chan domain.SSEEvent, 100)
 	go func() {
@@ -355,6 +438,7 @@ func (u *ChatUsecase) ChatRagOnly(ctx context.Context, req *domain.ChatRagOnlyRe
 			HistoryMessages:     nil,
 			SimilarityThreshold: 0,
 			MaxChunksPerDoc:     1,
+			Filter:              req.Filter,
 		})
 		if err != nil {
 			u.logger.Error("failed to get rank nodes", log.Error(err))
@@ -448,6 +532,7 @@ func (u *ChatUsecase) Search(ctx context.Context, req *domain.ChatSearchReq) (*d
 		GroupIDs:            groupIds,
 		SimilarityThreshold: 0.2,
 		HistoryMessages:     nil,
+		Filter:              req.Filter,
 	})
 	if err != nil {
 		return nil, err
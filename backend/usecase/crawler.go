@@ -11,10 +11,12 @@ import (
 
 	v1 "github.com/chaitin/panda-wiki/api/crawler/v1"
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/mq"
 	"github.com/chaitin/panda-wiki/pkg/anydoc"
 	"github.com/chaitin/panda-wiki/store/cache"
+	"github.com/chaitin/panda-wiki/store/s3"
 	"github.com/chaitin/panda-wiki/utils"
 )
 
@@ -23,9 +25,10 @@ type CrawlerUsecase struct {
 	anydocClient *anydoc.Client
 	httpClient   *http.Client
 	cache        *cache.Cache
+	objectStore  s3.ObjectStore
 }
 
-func NewCrawlerUsecase(logger *log.Logger, mqConsumer mq.MQConsumer, cache *cache.Cache) (*CrawlerUsecase, error) {
+func NewCrawlerUsecase(logger *log.Logger, mqConsumer mq.MQConsumer, cache *cache.Cache, objectStore s3.ObjectStore) (*CrawlerUsecase, error) {
 	anydocClient, err := anydoc.NewClient(logger, mqConsumer)
 	if err != nil {
 		return nil, err
@@ -34,6 +37,7 @@ func NewCrawlerUsecase(logger *log.Logger, mqConsumer mq.MQConsumer, cache *cach
 		logger:       logger,
 		anydocClient: anydocClient,
 		cache:        cache,
+		objectStore:  objectStore,
 		httpClient: &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: &tls.Config{
@@ -52,7 +56,7 @@ func (u *CrawlerUsecase) ParseUrl(ctx context.Context, req *v1.CrawlerParseReq)
 
 	// 文件类型的解析会先走上传接口
 	if req.CrawlerSource.Type() == consts.CrawlerSourceTypeFile {
-		req.Key = fmt.Sprintf("http://panda-wiki-minio:9000/static-file/%s", req.Key)
+		req.Key = fmt.Sprintf("%s/%s/%s", u.objectStore.InternalEndpoint(), domain.Bucket, req.Key)
 	}
 
 	var (
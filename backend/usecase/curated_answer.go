@@ -0,0 +1,432 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+)
+
+var (
+	answerReferencesBlockRe = regexp.MustCompile(`(?ms)((?:>|\\u003e)\s*\[\d+\]\.\s*\[.*?\]\(.*?\)\s*\n?)+$`)
+	answerReferenceNodeRe   = regexp.MustCompile(`/node/([^/?#)\s]+)`)
+)
+
+type CuratedAnswerUsecase struct {
+	repo             *pg.CuratedAnswerRepository
+	kbRepo           *pg.KnowledgeBaseRepository
+	nodeRepo         *pg.NodeRepository
+	conversationRepo *pg.ConversationRepository
+	rag              rag.RAGService
+	logger           *log.Logger
+}
+
+func NewCuratedAnswerUsecase(repo *pg.CuratedAnswerRepository, kbRepo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, conversationRepo *pg.ConversationRepository, rag rag.RAGService, logger *log.Logger) *CuratedAnswerUsecase {
+	return &CuratedAnswerUsecase{
+		repo:             repo,
+		kbRepo:           kbRepo,
+		nodeRepo:         nodeRepo,
+		conversationRepo: conversationRepo,
+		rag:              rag,
+		logger:           logger.WithModule("usecase.curated_answer"),
+	}
+}
+
+func (u *CuratedAnswerUsecase) GetList(ctx context.Context, req *v1.CuratedAnswerListReq) (*domain.PaginatedResult[[]*v1.CuratedAnswerListItem], error) {
+	answers, total, err := u.repo.GetList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs := lo.Uniq(lo.FlatMap(answers, func(answer *domain.CuratedAnswer, _ int) []string { return answer.NodeIDs }))
+	nodes, err := u.nodeRepo.GetNodesByIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*v1.CuratedAnswerListItem, 0, len(answers))
+	for _, answer := range answers {
+		item := &v1.CuratedAnswerListItem{CuratedAnswer: *answer, Nodes: make([]v1.CuratedAnswerNode, 0, len(answer.NodeIDs))}
+		for _, id := range answer.NodeIDs {
+			if node, ok := nodes[id]; ok {
+				item.Nodes = append(item.Nodes, v1.CuratedAnswerNode{ID: node.ID, Name: node.Name})
+			}
+		}
+		items = append(items, item)
+	}
+	return domain.NewPaginatedResult(items, total), nil
+}
+
+func (u *CuratedAnswerUsecase) Create(ctx context.Context, req *v1.CreateCuratedAnswerReq, userID string) (string, error) {
+	now := time.Now()
+	answer := &domain.CuratedAnswer{
+		ID:        uuid.New().String(),
+		KBID:      req.KbID,
+		Enabled:   true,
+		CreatorID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.fill(ctx, answer, req); err != nil {
+		return "", err
+	}
+	if err := u.create(ctx, answer); err != nil {
+		return "", err
+	}
+	return answer.ID, nil
+}
+
+func (u *CuratedAnswerUsecase) Update(ctx context.Context, req *v1.UpdateCuratedAnswerReq) error {
+	answer, err := u.repo.GetByID(ctx, req.KbID, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := u.fill(ctx, answer, &req.CreateCuratedAnswerReq); err != nil {
+		return err
+	}
+	answer.Enabled = req.Enabled
+	if err := u.repo.Update(ctx, answer); err != nil {
+		return err
+	}
+	return u.index(ctx, answer)
+}
+
+func (u *CuratedAnswerUsecase) Delete(ctx context.Context, req *v1.DeleteCuratedAnswerReq) error {
+	answer, err := u.repo.GetByID(ctx, req.KbID, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, req.KbID, req.ID); err != nil {
+		return err
+	}
+	// 标准答案删除后检索记录不会再被匹配到，删除失败不影响结果
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, req.KbID)
+	if err != nil {
+		return err
+	}
+	u.deleteRecords(ctx, kb.AnswerDatasetID, answer.DocIDs)
+	return nil
+}
+
+// Promote 把对话中的回答添加为标准答案，去掉回答末尾的引用列表，引用的文档作为关联文档
+func (u *CuratedAnswerUsecase) Promote(ctx context.Context, req *v1.PromoteCuratedAnswerReq, userID string) (string, error) {
+	message, err := u.conversationRepo.GetConversationMessagesDetailByKbID(ctx, req.KbID, req.MessageID)
+	if err != nil {
+		return "", err
+	}
+	if message.ParentID == "" {
+		return "", errors.New("message is not an answer")
+	}
+	question, err := u.conversationRepo.GetConversationMessagesDetailByKbID(ctx, req.KbID, message.ParentID)
+	if err != nil {
+		return "", err
+	}
+	// 引用记录按回答消息 ID 保存
+	references, err := u.conversationRepo.GetConversationReferences(ctx, message.ID)
+	if err != nil {
+		return "", err
+	}
+	nodeIDs := make([]string, 0, len(references))
+	for _, reference := range references {
+		if match := answerReferenceNodeRe.FindStringSubmatch(reference.URL); match != nil {
+			nodeIDs = append(nodeIDs, match[1])
+		}
+	}
+	now := time.Now()
+	answer := &domain.CuratedAnswer{
+		ID:              uuid.New().String(),
+		KBID:            req.KbID,
+		Enabled:         true,
+		SourceMessageID: message.ID,
+		CreatorID:       userID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := u.fill(ctx, answer, &v1.CreateCuratedAnswerReq{
+		KbID:     req.KbID,
+		Question: question.Content,
+		Answer:   answerReferencesBlockRe.ReplaceAllString(message.Content, ""),
+		NodeIDs:  lo.Uniq(nodeIDs),
+	}); err != nil {
+		return "", err
+	}
+	if answer.Answer == "" {
+		return "", errors.New("answer is empty")
+	}
+	if err := u.create(ctx, answer); err != nil {
+		return "", err
+	}
+	return answer.ID, nil
+}
+
+// create 先保存标准答案再写入检索记录，写入失败时删除刚保存的标准答案
+func (u *CuratedAnswerUsecase) create(ctx context.Context, answer *domain.CuratedAnswer) error {
+	if err := u.repo.Create(ctx, answer); err != nil {
+		return err
+	}
+	if err := u.index(ctx, answer); err != nil {
+		if delErr := u.repo.Delete(ctx, answer.KBID, answer.ID); delErr != nil {
+			u.logger.Error("rollback curated answer failed", log.String("id", answer.ID), log.Error(delErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// fill 整理问法并去重，只保留当前知识库中存在的关联文档
+func (u *CuratedAnswerUsecase) fill(ctx context.Context, answer *domain.CuratedAnswer, req *v1.CreateCuratedAnswerReq) error {
+	answer.Question = strings.TrimSpace(req.Question)
+	answer.Answer = strings.TrimSpace(req.Answer)
+	answer.Alternates = lo.Filter(lo.Uniq(lo.Map(req.Alternates, func(alternate string, _ int) string {
+		return strings.TrimSpace(alternate)
+	})), func(alternate string, _ int) bool {
+		return alternate != "" && alternate != answer.Question
+	})
+	nodes, err := u.nodeRepo.GetNodesByIDs(ctx, lo.Uniq(req.NodeIDs))
+	if err != nil {
+		return err
+	}
+	answer.NodeIDs = lo.Filter(lo.Uniq(req.NodeIDs), func(id string, _ int) bool {
+		node, ok := nodes[id]
+		return ok && node.KBID == answer.KBID
+	})
+	return nil
+}
+
+// index 重新写入问题和相似问法的检索记录并保存记录 ID，之后再删除旧记录，
+// 停用的标准答案只删除旧记录；保存失败时删除本次写入的记录
+func (u *CuratedAnswerUsecase) index(ctx context.Context, answer *domain.CuratedAnswer) error {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, answer.KBID)
+	if err != nil {
+		return err
+	}
+	datasetID := kb.AnswerDatasetID
+	var docIDs []string
+	if answer.Enabled {
+		if datasetID == "" {
+			if datasetID, err = u.rag.CreateKnowledgeBase(ctx); err != nil {
+				return fmt.Errorf("create answer dataset failed: %w", err)
+			}
+			if err := u.kbRepo.UpdateAnswerDatasetID(ctx, kb.ID, datasetID); err != nil {
+				return err
+			}
+		}
+		if docIDs, err = u.upsertDocs(ctx, datasetID, answer); err != nil {
+			return err
+		}
+	}
+	if err := u.repo.UpdateDocIDs(ctx, answer.ID, docIDs); err != nil {
+		u.deleteRecords(ctx, datasetID, docIDs)
+		return err
+	}
+
+	oldDocIDs := answer.DocIDs
+	answer.DocIDs = docIDs
+	// 旧记录已不被引用，删除失败也不会被匹配到
+	u.deleteRecords(ctx, datasetID, oldDocIDs)
+	return nil
+}
+
+// upsertDocs 写入问题和相似问法的检索记录，失败时删除已写入的记录
+func (u *CuratedAnswerUsecase) upsertDocs(ctx context.Context, datasetID string, answer *domain.CuratedAnswer) ([]string, error) {
+	docIDs := make([]string, 0, len(answer.Alternates)+1)
+	for _, question := range answer.Questions() {
+		docID, err := u.rag.UpsertRecords(ctx, &rag.UpsertRecordsRequest{
+			ID:        uuid.New().String(),
+			DatasetID: datasetID,
+			Title:     question,
+			Content:   question,
+		})
+		if err != nil {
+			u.deleteRecords(ctx, datasetID, docIDs)
+			return nil, fmt.Errorf("upsert curated answer record failed: %w", err)
+		}
+		docIDs = append(docIDs, docID)
+	}
+	return docIDs, nil
+}
+
+// deleteRecords 删除检索记录，失败时只记录日志
+func (u *CuratedAnswerUsecase) deleteRecords(ctx context.Context, datasetID string, docIDs []string) {
+	if datasetID == "" || len(docIDs) == 0 {
+		return
+	}
+	if err := u.rag.DeleteRecords(ctx, datasetID, docIDs); err != nil {
+		u.logger.Error("delete curated answer records failed", log.String("dataset_id", datasetID), log.Error(err))
+	}
+}
+
+// Reindex 向量模型变更后把标准答案写入新的检索库，再删除旧检索库
+func (u *CuratedAnswerUsecase) Reindex(ctx context.Context, kbID string) error {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb.AnswerDatasetID == "" {
+		return nil
+	}
+	answers, err := u.repo.GetByKBID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	datasetID, err := u.rag.CreateKnowledgeBase(ctx)
+	if err != nil {
+		return fmt.Errorf("create answer dataset failed: %w", err)
+	}
+	for _, answer := range answers {
+		answer.DocIDs = nil
+		if answer.Enabled {
+			if answer.DocIDs, err = u.upsertDocs(ctx, datasetID, answer); err != nil {
+				return err
+			}
+		}
+		if err := u.repo.UpdateDocIDs(ctx, answer.ID, answer.DocIDs); err != nil {
+			return err
+		}
+	}
+	if err := u.kbRepo.UpdateAnswerDatasetID(ctx, kbID, datasetID); err != nil {
+		return err
+	}
+	if err := u.rag.DeleteKnowledgeBase(ctx, kb.AnswerDatasetID); err != nil {
+		u.logger.Error("delete old answer dataset failed", log.String("kb_id", kbID), log.Error(err))
+	}
+	return nil
+}
+
+// Match 用问题的向量相似度匹配启用的标准答案，未命中时返回 nil
+func (u *CuratedAnswerUsecase) Match(ctx context.Context, kbID, question string) (*domain.CuratedAnswer, domain.CuratedAnswerMode, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, "", err
+	}
+	if kb.AnswerDatasetID == "" {
+		return nil, "", nil
+	}
+	_, records, err := u.rag.QueryRecords(ctx, &rag.QueryRecordsRequest{
+		DatasetID:           kb.AnswerDatasetID,
+		Query:               question,
+		SimilarityThreshold: kb.CuratedAnswerSetting.GetThreshold(),
+		MaxChunksPerDoc:     1,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("query curated answer records failed: %w", err)
+	}
+	for _, record := range records {
+		answer, err := u.repo.GetEnabledByDocID(ctx, kbID, record.DocID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, "", err
+		}
+		return answer, kb.CuratedAnswerSetting.GetMode(), nil
+	}
+	return nil, "", nil
+}
+
+func (u *CuratedAnswerUsecase) RecordHit(ctx context.Context, answer *domain.CuratedAnswer, mode domain.CuratedAnswerMode, conversationID, messageID, question string) error {
+	return u.repo.RecordHit(ctx, &domain.CuratedAnswerHit{
+		ID:             uuid.New().String(),
+		KBID:           answer.KBID,
+		AnswerID:       answer.ID,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		Question:       question,
+		Mode:           mode,
+		CreatedAt:      time.Now(),
+	})
+}
+
+// GetReferenceNodes 标准答案关联的已发布文档，作为直接回答时的引用，
+// 与检索一样排除用户所在分组不可问答或不可访问的文档
+func (u *CuratedAnswerUsecase) GetReferenceNodes(ctx context.Context, answer *domain.CuratedAnswer, groupIDs []int) ([]*pg.NodeReleaseWithPath, error) {
+	if len(answer.NodeIDs) == 0 {
+		return nil, nil
+	}
+	nodes, err := u.nodeRepo.GetNodesByIDs(ctx, answer.NodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	userGroupIDs := lo.Map(groupIDs, func(id int, _ int) uint {
+		return uint(id)
+	})
+	permNodeIDs := make(map[consts.NodePermName]map[string]bool)
+	for _, perm := range []consts.NodePermName{consts.NodePermNameAnswerable, consts.NodePermNameVisitable} {
+		nodeGroups, err := u.nodeRepo.GetNodeGroupsByGroupIdsPerm(ctx, userGroupIDs, perm)
+		if err != nil {
+			return nil, err
+		}
+		permNodeIDs[perm] = lo.SliceToMap(nodeGroups, func(v domain.NodeAuthGroup) (string, bool) {
+			return v.NodeID, true
+		})
+	}
+	permitted := func(nodeID string, access consts.NodeAccessPerm, perm consts.NodePermName) bool {
+		switch access {
+		case consts.NodeAccessPermClosed:
+			return false
+		case consts.NodeAccessPermPartial:
+			return permNodeIDs[perm][nodeID]
+		}
+		return true
+	}
+	nodeIDs := lo.Filter(answer.NodeIDs, func(id string, _ int) bool {
+		node, ok := nodes[id]
+		return ok &&
+			permitted(id, node.Permissions.Answerable, consts.NodePermNameAnswerable) &&
+			permitted(id, node.Permissions.Visitable, consts.NodePermNameVisitable)
+	})
+	if len(nodeIDs) == 0 {
+		return nil, nil
+	}
+
+	releases, err := u.nodeRepo.GetLatestNodeReleaseByNodeIDs(ctx, answer.KBID, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	docIDs := lo.FilterMap(releases, func(release *domain.NodeRelease, _ int) (string, bool) {
+		return release.DocID, release.DocID != ""
+	})
+	docNodes, err := u.nodeRepo.GetNodeReleasesWithPathsByDocIDs(ctx, docIDs)
+	if err != nil {
+		return nil, err
+	}
+	nodeReleases := lo.KeyBy(lo.Values(docNodes), func(release *pg.NodeReleaseWithPath) string {
+		return release.NodeID
+	})
+	// 保持标准答案中关联文档的顺序
+	return lo.FilterMap(nodeIDs, func(id string, _ int) (*pg.NodeReleaseWithPath, bool) {
+		release, ok := nodeReleases[id]
+		return release, ok
+	}), nil
+}
+
+func (u *CuratedAnswerUsecase) GetHitList(ctx context.Context, req *v1.CuratedAnswerHitListReq) (*domain.PaginatedResult[[]*domain.CuratedAnswerHit], error) {
+	hits, total, err := u.repo.GetHitList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(hits, total), nil
+}
+
+func (u *CuratedAnswerUsecase) GetSetting(ctx context.Context, kbID string) (*domain.CuratedAnswerSetting, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &kb.CuratedAnswerSetting, nil
+}
+
+func (u *CuratedAnswerUsecase) UpdateSetting(ctx context.Context, req *v1.UpdateCuratedAnswerSettingReq) error {
+	return u.kbRepo.UpdateCuratedAnswerSetting(ctx, req.KbID, &req.CuratedAnswerSetting)
+}
@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+type FeedUsecase struct {
+	nodeRepo    *pg.NodeRepository
+	navRepo     *pg.NavRepository
+	kbRepo      *pg.KnowledgeBaseRepository
+	nodeUsecase *NodeUsecase
+	logger      *log.Logger
+}
+
+func NewFeedUsecase(nodeRepo *pg.NodeRepository, navRepo *pg.NavRepository, kbRepo *pg.KnowledgeBaseRepository, nodeUsecase *NodeUsecase, logger *log.Logger) *FeedUsecase {
+	return &FeedUsecase{
+		nodeRepo:    nodeRepo,
+		navRepo:     navRepo,
+		kbRepo:      kbRepo,
+		nodeUsecase: nodeUsecase,
+		logger:      logger.WithModule("usecase.feed"),
+	}
+}
+
+type feedEntry struct {
+	id               string
+	title            string
+	link             string
+	summary          string
+	category         string
+	firstPublishedAt time.Time
+	publishedAt      time.Time
+}
+
+type releaseFeed struct {
+	title     string
+	link      string
+	selfLink  string
+	updatedAt time.Time
+	entries   []feedEntry
+}
+
+// GetFeed 生成最近发布文档的订阅源，指定栏目时只包含该栏目下的文档
+func (u *FeedUsecase) GetFeed(ctx context.Context, kbID string, authID uint, format domain.FeedFormat, req *v1.ShareFeedReq) ([]byte, error) {
+	feed, err := u.getReleaseFeed(ctx, kbID, authID, format, req)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case domain.FeedFormatRSS:
+		return feed.rss()
+	case domain.FeedFormatAtom:
+		return feed.atom()
+	case domain.FeedFormatJSON:
+		return feed.json()
+	default:
+		return nil, fmt.Errorf("unsupported feed format: %s", format)
+	}
+}
+
+func (u *FeedUsecase) getReleaseFeed(ctx context.Context, kbID string, authID uint, format domain.FeedFormat, req *v1.ShareFeedReq) (*releaseFeed, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge base: %w", err)
+	}
+	baseURL := kb.AccessSettings.GetBaseUrl()
+
+	query := url.Values{}
+	if req.NavID != "" {
+		query.Set("nav_id", req.NavID)
+	}
+	if req.Limit > 0 {
+		query.Set("limit", fmt.Sprint(req.Limit))
+	}
+	selfLink := fmt.Sprintf("%s/share/v1/feed/%s", baseURL, format)
+	if len(query) > 0 {
+		selfLink += "?" + query.Encode()
+	}
+	feed := &releaseFeed{
+		title:     kb.Name,
+		link:      baseURL,
+		selfLink:  selfLink,
+		updatedAt: kb.CreatedAt,
+		entries:   []feedEntry{},
+	}
+
+	release, err := u.kbRepo.GetLatestRelease(ctx, kbID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return feed, nil
+		}
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	feed.updatedAt = release.CreatedAt
+
+	navs, err := u.navRepo.GetReleaseList(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nav list: %w", err)
+	}
+	navNames := make(map[string]string, len(navs))
+	for _, nav := range navs {
+		navNames[nav.ID] = nav.Name
+	}
+	if req.NavID != "" {
+		navName, ok := navNames[req.NavID]
+		if !ok {
+			return nil, fmt.Errorf("nav %s not found", req.NavID)
+		}
+		feed.title = fmt.Sprintf("%s - %s", kb.Name, navName)
+	}
+
+	visibleNodeIDs, err := u.nodeUsecase.GetNodeIdsByAuthId(ctx, authID, consts.NodePermNameVisible)
+	if err != nil {
+		return nil, err
+	}
+	visitableNodeIDs, err := u.nodeUsecase.GetNodeIdsByAuthId(ctx, authID, consts.NodePermNameVisitable)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = domain.FeedDefaultLimit
+	}
+	items, err := u.nodeRepo.GetNodeReleaseFeedItems(ctx, kbID, release.ID, req.NavID, visibleNodeIDs, visitableNodeIDs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get released nodes: %w", err)
+	}
+	for _, item := range items {
+		feed.entries = append(feed.entries, feedEntry{
+			// 文档每次重新发布都作为新的条目
+			id:               item.NodeReleaseID,
+			title:            item.Name,
+			link:             item.GetURL(baseURL),
+			summary:          item.Summary,
+			category:         navNames[item.NavId],
+			firstPublishedAt: item.FirstPublishedAt,
+			publishedAt:      item.PublishedAt,
+		})
+	}
+	return feed, nil
+}
+
+func (f *releaseFeed) rss() ([]byte, error) {
+	type rssLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	}
+	type rssGUID struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	type rssItem struct {
+		Title       string  `xml:"title"`
+		Link        string  `xml:"link"`
+		Description string  `xml:"description,omitempty"`
+		Category    string  `xml:"category,omitempty"`
+		GUID        rssGUID `xml:"guid"`
+		PubDate     string  `xml:"pubDate"`
+	}
+	type rssChannel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		AtomLink      rssLink   `xml:"atom:link"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name   `xml:"rss"`
+		Version string     `xml:"version,attr"`
+		Atom    string     `xml:"xmlns:atom,attr"`
+		Channel rssChannel `xml:"channel"`
+	}
+
+	doc := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.title,
+			Link:          f.link,
+			Description:   f.title,
+			AtomLink:      rssLink{Href: f.selfLink, Rel: "self", Type: domain.FeedFormatRSS.ContentType()},
+			LastBuildDate: f.updatedAt.Format(time.RFC1123Z),
+		},
+	}
+	for _, entry := range f.entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       entry.title,
+			Link:        entry.link,
+			Description: entry.summary,
+			Category:    entry.category,
+			GUID:        rssGUID{Value: entry.id},
+			PubDate:     entry.publishedAt.Format(time.RFC1123Z),
+		})
+	}
+	return marshalFeedXML(doc)
+}
+
+func (f *releaseFeed) atom() ([]byte, error) {
+	type atomLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+	}
+	type atomCategory struct {
+		Term string `xml:"term,attr"`
+	}
+	type atomEntry struct {
+		ID        string        `xml:"id"`
+		Title     string        `xml:"title"`
+		Link      atomLink      `xml:"link"`
+		Summary   string        `xml:"summary,omitempty"`
+		Category  *atomCategory `xml:"category,omitempty"`
+		Published string        `xml:"published"`
+		Updated   string        `xml:"updated"`
+	}
+	type atomFeed struct {
+		XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string      `xml:"id"`
+		Title   string      `xml:"title"`
+		Links   []atomLink  `xml:"link"`
+		Updated string      `xml:"updated"`
+		Author  string      `xml:"author>name"`
+		Entries []atomEntry `xml:"entry"`
+	}
+
+	doc := atomFeed{
+		ID:      f.selfLink,
+		Title:   f.title,
+		Links:   []atomLink{{Href: f.link}, {Href: f.selfLink, Rel: "self"}},
+		Updated: f.updatedAt.Format(time.RFC3339),
+		Author:  f.title,
+	}
+	for _, entry := range f.entries {
+		e := atomEntry{
+			ID:        "urn:uuid:" + entry.id,
+			Title:     entry.title,
+			Link:      atomLink{Href: entry.link},
+			Summary:   entry.summary,
+			Published: entry.firstPublishedAt.Format(time.RFC3339),
+			Updated:   entry.publishedAt.Format(time.RFC3339),
+		}
+		if entry.category != "" {
+			e.Category = &atomCategory{Term: entry.category}
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return marshalFeedXML(doc)
+}
+
+func (f *releaseFeed) json() ([]byte, error) {
+	type jsonItem struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url"`
+		Title         string   `json:"title"`
+		Summary       string   `json:"summary,omitempty"`
+		ContentText   string   `json:"content_text"`
+		DatePublished string   `json:"date_published"`
+		DateModified  string   `json:"date_modified"`
+		Tags          []string `json:"tags,omitempty"`
+	}
+	type jsonFeed struct {
+		Version     string     `json:"version"`
+		Title       string     `json:"title"`
+		HomePageURL string     `json:"home_page_url"`
+		FeedURL     string     `json:"feed_url"`
+		Items       []jsonItem `json:"items"`
+	}
+
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.title,
+		HomePageURL: f.link,
+		FeedURL:     f.selfLink,
+		Items:       make([]jsonItem, 0, len(f.entries)),
+	}
+	for _, entry := range f.entries {
+		item := jsonItem{
+			ID:            entry.id,
+			URL:           entry.link,
+			Title:         entry.title,
+			Summary:       entry.summary,
+			ContentText:   entry.summary,
+			DatePublished: entry.firstPublishedAt.Format(time.RFC3339),
+			DateModified:  entry.publishedAt.Format(time.RFC3339),
+		}
+		if entry.category != "" {
+			item.Tags = []string{entry.category}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return json.Marshal(doc)
+}
+
+func marshalFeedXML(v any) ([]byte, error) {
+	buf, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReleaseFeed() *releaseFeed {
+	cst := time.FixedZone("CST", 8*3600)
+	return &releaseFeed{
+		title:     "产品文档 - 指南",
+		link:      "https://wiki.example.com",
+		selfLink:  "https://wiki.example.com/share/v1/feed/rss?nav_id=nav1",
+		updatedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, cst),
+		entries: []feedEntry{
+			{
+				id:               "r2",
+				title:            "A & B <升级>",
+				link:             "https://wiki.example.com/node/n1",
+				summary:          "新版本说明",
+				category:         "指南",
+				firstPublishedAt: time.Date(2026, 1, 1, 9, 0, 0, 0, cst),
+				publishedAt:      time.Date(2026, 3, 2, 10, 0, 0, 0, cst),
+			},
+			{
+				id:               "r1",
+				title:            "快速开始",
+				link:             "https://wiki.example.com/node/n2",
+				firstPublishedAt: time.Date(2026, 2, 1, 9, 0, 0, 0, cst),
+				publishedAt:      time.Date(2026, 2, 1, 9, 0, 0, 0, cst),
+			},
+		},
+	}
+}
+
+func TestReleaseFeed_RSS(t *testing.T) {
+	data, err := testReleaseFeed().rss()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), xml.Header))
+	assert.Contains(t, string(data), `<atom:link href="https://wiki.example.com/share/v1/feed/rss?nav_id=nav1" rel="self" type="application/rss+xml; charset=UTF-8"></atom:link>`)
+	assert.Contains(t, string(data), `<title>A &amp; B &lt;升级&gt;</title>`)
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				Description string `xml:"description"`
+				Category    string `xml:"category"`
+				GUID        struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "产品文档 - 指南", doc.Channel.Title)
+	assert.Equal(t, "Mon, 02 Mar 2026 10:00:00 +0800", doc.Channel.LastBuildDate)
+	require.Len(t, doc.Channel.Items, 2)
+	item := doc.Channel.Items[0]
+	assert.Equal(t, "A & B <升级>", item.Title)
+	assert.Equal(t, "https://wiki.example.com/node/n1", item.Link)
+	assert.Equal(t, "新版本说明", item.Description)
+	assert.Equal(t, "指南", item.Category)
+	assert.Equal(t, "r2", item.GUID.Value)
+	assert.Equal(t, "false", item.GUID.IsPermaLink)
+	assert.Equal(t, "Mon, 02 Mar 2026 10:00:00 +0800", item.PubDate)
+	// 没有摘要和栏目的条目不输出空元素
+	assert.NotContains(t, string(data), "<description></description>")
+	assert.NotContains(t, string(data), "<category></category>")
+}
+
+func TestReleaseFeed_Atom(t *testing.T) {
+	data, err := testReleaseFeed().atom()
+	require.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID       string `xml:"id"`
+			Title    string `xml:"title"`
+			Category *struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "https://wiki.example.com/share/v1/feed/rss?nav_id=nav1", doc.ID)
+	require.Len(t, doc.Links, 2)
+	assert.Equal(t, "self", doc.Links[1].Rel)
+	assert.Equal(t, "2026-03-02T10:00:00+08:00", doc.Updated)
+	require.Len(t, doc.Entries, 2)
+	assert.Equal(t, "urn:uuid:r2", doc.Entries[0].ID)
+	assert.Equal(t, "A & B <升级>", doc.Entries[0].Title)
+	require.NotNil(t, doc.Entries[0].Category)
+	assert.Equal(t, "指南", doc.Entries[0].Category.Term)
+	// 首次发布时间和本次发布时间分别对应 published 和 updated
+	assert.Equal(t, "2026-01-01T09:00:00+08:00", doc.Entries[0].Published)
+	assert.Equal(t, "2026-03-02T10:00:00+08:00", doc.Entries[0].Updated)
+	assert.Nil(t, doc.Entries[1].Category)
+}
+
+func TestReleaseFeed_JSON(t *testing.T) {
+	data, err := testReleaseFeed().json()
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	assert.Equal(t, "https://wiki.example.com", doc["home_page_url"])
+	assert.Equal(t, "https://wiki.example.com/share/v1/feed/rss?nav_id=nav1", doc["feed_url"])
+	items := doc["items"].([]any)
+	require.Len(t, items, 2)
+	assert.Equal(t, map[string]any{
+		"id":             "r2",
+		"url":            "https://wiki.example.com/node/n1",
+		"title":          "A & B <升级>",
+		"summary":        "新版本说明",
+		"content_text":   "新版本说明",
+		"date_published": "2026-01-01T09:00:00+08:00",
+		"date_modified":  "2026-03-02T10:00:00+08:00",
+		"tags":           []any{"指南"},
+	}, items[0])
+	assert.NotContains(t, items[1], "tags")
+
+	// 没有已发布文档时 items 为空数组而不是 null
+	empty, err := (&releaseFeed{title: "kb"}).json()
+	require.NoError(t, err)
+	assert.Contains(t, string(empty), `"items":[]`)
+}
@@ -3,7 +3,9 @@ package usecase
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,7 +17,6 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
 	"gorm.io/gorm"
 
 	"github.com/chaitin/panda-wiki/config"
@@ -29,18 +30,22 @@ import (
 
 type FileUsecase struct {
 	logger            *log.Logger
-	s3Client          *s3.MinioClient
+	s3Client          s3.ObjectStore
 	config            *config.Config
 	systemSettingRepo *pg.SystemSettingRepo
+	attachmentRepo    *pg.AttachmentRepository
+	kbRepo            *pg.KnowledgeBaseRepository
 	httpClient        *http.Client
 }
 
-func NewFileUsecase(logger *log.Logger, s3Client *s3.MinioClient, config *config.Config, systemSettingRepo *pg.SystemSettingRepo) *FileUsecase {
+func NewFileUsecase(logger *log.Logger, s3Client s3.ObjectStore, config *config.Config, systemSettingRepo *pg.SystemSettingRepo, attachmentRepo *pg.AttachmentRepository, kbRepo *pg.KnowledgeBaseRepository) *FileUsecase {
 	return &FileUsecase{
 		s3Client:          s3Client,
 		logger:            logger.WithModule("usecase.file"),
 		config:            config,
 		systemSettingRepo: systemSettingRepo,
+		attachmentRepo:    attachmentRepo,
+		kbRepo:            kbRepo,
 		httpClient: &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: &tls.Config{
@@ -60,7 +65,7 @@ func (u *FileUsecase) UploadFileGetUrl(ctx context.Context, kbID string, file *m
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("http://panda-wiki-minio:9000/static-file/%s", key), nil
+	return fmt.Sprintf("%s/%s/%s", u.s3Client.InternalEndpoint(), domain.Bucket, key), nil
 }
 
 func (u *FileUsecase) UploadFile(ctx context.Context, kbID string, file *multipart.FileHeader) (string, error) {
@@ -77,8 +82,6 @@ func (u *FileUsecase) UploadFile(ctx context.Context, kbID string, file *multipa
 		return "", err
 	}
 
-	filename := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
-
 	size := file.Size
 
 	contentType := file.Header.Get("Content-Type")
@@ -86,24 +89,12 @@ func (u *FileUsecase) UploadFile(ctx context.Context, kbID string, file *multipa
 		contentType = mime.TypeByExtension(ext)
 	}
 
-	resp, err := u.s3Client.PutObject(
-		ctx,
-		domain.Bucket,
-		filename,
-		src,
-		size,
-		minio.PutObjectOptions{
-			ContentType: contentType,
-			UserMetadata: map[string]string{
-				"originalname": file.Filename,
-			},
+	return u.putAttachment(ctx, kbID, ext, src, size, s3.PutObjectOptions{
+		ContentType: contentType,
+		UserMetadata: map[string]string{
+			"originalname": file.Filename,
 		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("upload failed: %w", err)
-	}
-
-	return resp.Key, nil
+	})
 }
 
 func (u *FileUsecase) UploadFileFromBytes(ctx context.Context, kbID string, filename string, fileBytes []byte) (string, error) {
@@ -117,8 +108,6 @@ func (u *FileUsecase) UploadFileFromBytes(ctx context.Context, kbID string, file
 		return "", err
 	}
 
-	s3Filename := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
-
 	size := int64(len(fileBytes))
 
 	contentType := mime.TypeByExtension(ext)
@@ -127,24 +116,12 @@ func (u *FileUsecase) UploadFileFromBytes(ctx context.Context, kbID string, file
 		contentType = "application/octet-stream"
 	}
 
-	resp, err := u.s3Client.PutObject(
-		ctx,
-		domain.Bucket,
-		s3Filename,
-		reader,
-		size,
-		minio.PutObjectOptions{
-			ContentType: contentType,
-			UserMetadata: map[string]string{
-				"originalname": filename,
-			},
+	return u.putAttachment(ctx, kbID, ext, reader, size, s3.PutObjectOptions{
+		ContentType: contentType,
+		UserMetadata: map[string]string{
+			"originalname": filename,
 		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("upload failed: %w", err)
-	}
-
-	return resp.Key, nil
+	})
 }
 
 func (u *FileUsecase) UploadFileFromReader(
@@ -162,8 +139,6 @@ func (u *FileUsecase) UploadFileFromReader(
 		return "", err
 	}
 
-	s3Filename := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
-
 	// 获取内容类型
 	contentType := mime.TypeByExtension(ext)
 	if contentType == "" {
@@ -171,24 +146,12 @@ func (u *FileUsecase) UploadFileFromReader(
 	}
 
 	// 上传到 S3
-	_, err := u.s3Client.PutObject(
-		ctx,
-		domain.Bucket,
-		s3Filename,
-		reader,
-		size, // 必须提供对象大小
-		minio.PutObjectOptions{
-			ContentType: contentType,
-			UserMetadata: map[string]string{
-				"originalname": filename,
-			},
+	return u.putAttachment(ctx, kbID, ext, reader, size, s3.PutObjectOptions{
+		ContentType: contentType,
+		UserMetadata: map[string]string{
+			"originalname": filename,
 		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("S3 upload failed: %w", err)
-	}
-
-	return s3Filename, nil
+	})
 }
 
 func (u *FileUsecase) AnyDocUploadFile(ctx context.Context, file *multipart.FileHeader, path string) (string, error) {
@@ -212,13 +175,13 @@ func (u *FileUsecase) AnyDocUploadFile(ctx context.Context, file *multipart.File
 		contentType = mime.TypeByExtension(ext)
 	}
 
-	resp, err := u.s3Client.PutObject(
+	err = u.s3Client.PutObject(
 		ctx,
 		domain.Bucket,
 		path,
 		src,
 		size,
-		minio.PutObjectOptions{
+		s3.PutObjectOptions{
 			ContentType: contentType,
 			UserMetadata: map[string]string{
 				"originalname": file.Filename,
@@ -229,7 +192,7 @@ func (u *FileUsecase) AnyDocUploadFile(ctx context.Context, file *multipart.File
 		return "", fmt.Errorf("upload failed: %w", err)
 	}
 
-	return resp.Key, nil
+	return path, nil
 }
 
 func (u *FileUsecase) UploadFileByUrl(ctx context.Context, kbID string, fileURL string) (string, error) {
@@ -278,8 +241,6 @@ func (u *FileUsecase) UploadFileByUrl(ctx context.Context, kbID string, fileURL
 		return "", err
 	}
 
-	s3Filename := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
-
 	// Derive content type from the actual data instead of trusting the remote header
 	contentType := http.DetectContentType(data)
 	if contentType == "" || contentType == "application/octet-stream" {
@@ -290,21 +251,104 @@ func (u *FileUsecase) UploadFileByUrl(ctx context.Context, kbID string, fileURL
 		}
 	}
 
-	putResp, err := u.s3Client.PutObject(
-		ctx,
-		domain.Bucket,
-		s3Filename,
-		bytes.NewReader(data),
-		int64(len(data)),
-		minio.PutObjectOptions{
-			ContentType: contentType,
-		},
-	)
+	return u.putAttachment(ctx, kbID, ext, bytes.NewReader(data), int64(len(data)), s3.PutObjectOptions{
+		ContentType: contentType,
+	})
+}
+
+// putAttachment 上传知识库文件，同一知识库内内容相同的文件只保存一份，
+// 未关联知识库的上传（如创建知识库前上传的图标）按原方式保存，不参与去重和回收
+func (u *FileUsecase) putAttachment(ctx context.Context, kbID, ext string, src io.Reader, size int64, opts s3.PutObjectOptions) (string, error) {
+	key := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
+
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+		if err := u.s3Client.PutObject(ctx, domain.Bucket, key, src, size, opts); err != nil {
+			return "", fmt.Errorf("upload failed: %w", err)
+		}
+		return key, nil
+	}
+
+	// 可回读的内容先计算摘要，命中时无需上传；否则边上传边计算
+	hash := sha256.New()
+	if seeker, ok := src.(io.ReadSeeker); ok {
+		if _, err := io.Copy(hash, seeker); err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		existing, err := u.attachmentRepo.GetAttachmentByHash(ctx, kbID, hex.EncodeToString(hash.Sum(nil)))
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			if err := u.attachmentRepo.TouchAttachment(ctx, existing.Key); err != nil {
+				return "", err
+			}
+			return existing.Key, nil
+		}
+	} else {
+		src = io.TeeReader(src, hash)
+	}
+
+	if err := u.checkStorageQuota(ctx, kb, size); err != nil {
+		return "", err
+	}
+	if err := u.s3Client.PutObject(ctx, domain.Bucket, key, src, size, opts); err != nil {
 		return "", fmt.Errorf("upload failed: %w", err)
 	}
 
-	return putResp.Key, nil
+	attachment := &domain.Attachment{
+		Key:         key,
+		KBID:        kbID,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		Size:        size,
+		ContentType: opts.ContentType,
+		Filename:    opts.UserMetadata["originalname"],
+	}
+	created, err := u.attachmentRepo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		u.removeObject(ctx, key)
+		return "", err
+	}
+	if created {
+		return key, nil
+	}
+	// 相同内容已被其他请求写入，复用已有文件
+	existing, err := u.attachmentRepo.GetAttachmentByHash(ctx, kbID, attachment.Hash)
+	if err != nil || existing == nil {
+		return key, err
+	}
+	u.removeObject(ctx, key)
+	if err := u.attachmentRepo.TouchAttachment(ctx, existing.Key); err != nil {
+		return "", err
+	}
+	return existing.Key, nil
+}
+
+func (u *FileUsecase) removeObject(ctx context.Context, key string) {
+	if err := u.s3Client.RemoveObject(ctx, domain.Bucket, key); err != nil {
+		u.logger.Error("failed to remove object", log.String("key", key), log.Error(err))
+	}
+}
+
+// checkStorageQuota 检查上传后是否超出知识库存储配额
+func (u *FileUsecase) checkStorageQuota(ctx context.Context, kb *domain.KnowledgeBase, size int64) error {
+	if kb.StorageQuota <= 0 {
+		return nil
+	}
+	used, err := u.attachmentRepo.GetAttachmentSize(ctx, kb.ID)
+	if err != nil {
+		return err
+	}
+	if used+size > kb.StorageQuota {
+		return fmt.Errorf("storage quota exceeded: used %d of %d bytes", used, kb.StorageQuota)
+	}
+	return nil
 }
 
 // checkDeniedExtension checks if the file extension is in the denied list
@@ -0,0 +1,364 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	navV1 "github.com/chaitin/panda-wiki/api/nav/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/sitegen"
+	"github.com/chaitin/panda-wiki/repo/mq"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	// 执行中的任务超过该时间未完成视为中断，消息重新投递时可再次执行
+	kbExportStaleTimeout = time.Hour
+	kbExportBatchSize    = 100
+)
+
+var (
+	// 文档中引用的上传文件，可能带有站点地址
+	exportFileURLRegexp = regexp.MustCompile(`(?:https?://[^\s"'()<>/]+)?` + domain.AttachmentKeyPattern)
+	// 文档之间的链接
+	exportNodeURLRegexp = regexp.MustCompile(`(?:https?://[^\s"'()<>/]+)?/node/([0-9a-fA-F-]{36})`)
+)
+
+type KBExportUsecase struct {
+	exportRepo     *pg.KBExportRepository
+	exportTaskRepo *mq.KBExportTaskRepository
+	kbRepo         *pg.KnowledgeBaseRepository
+	nodeRepo       *pg.NodeRepository
+	navRepo        *pg.NavRepository
+	appRepo        *pg.AppRepository
+	objectStore    s3.ObjectStore
+	logger         *log.Logger
+}
+
+func NewKBExportUsecase(
+	exportRepo *pg.KBExportRepository,
+	exportTaskRepo *mq.KBExportTaskRepository,
+	kbRepo *pg.KnowledgeBaseRepository,
+	nodeRepo *pg.NodeRepository,
+	navRepo *pg.NavRepository,
+	appRepo *pg.AppRepository,
+	objectStore s3.ObjectStore,
+	logger *log.Logger,
+) *KBExportUsecase {
+	return &KBExportUsecase{
+		exportRepo:     exportRepo,
+		exportTaskRepo: exportTaskRepo,
+		kbRepo:         kbRepo,
+		nodeRepo:       nodeRepo,
+		navRepo:        navRepo,
+		appRepo:        appRepo,
+		objectStore:    objectStore,
+		logger:         logger.WithModule("usecase.kb_export"),
+	}
+}
+
+// CreateExport 创建导出任务，由 consumer 异步生成静态站点
+func (u *KBExportUsecase) CreateExport(ctx context.Context, req *v1.KBReleaseExportReq, userID string) (string, error) {
+	if _, err := u.kbRepo.GetKBRelease(ctx, req.KBId, req.ReleaseId); err != nil {
+		return "", fmt.Errorf("get kb release failed: %w", err)
+	}
+	export := &domain.KBReleaseExport{
+		ID:        uuid.New().String(),
+		KBID:      req.KBId,
+		ReleaseID: req.ReleaseId,
+		Status:    domain.KBExportStatusPending,
+		CreatorID: userID,
+	}
+	if err := u.exportRepo.Create(ctx, export); err != nil {
+		return "", err
+	}
+	if err := u.exportTaskRepo.AsyncExport(ctx, &domain.KBExportTaskRequest{KBID: req.KBId, ExportID: export.ID}); err != nil {
+		if updateErr := u.exportRepo.Update(ctx, export.ID, map[string]any{
+			"status":  domain.KBExportStatusFailed,
+			"message": err.Error(),
+		}); updateErr != nil {
+			u.logger.Error("update export status failed", log.String("id", export.ID), log.Error(updateErr))
+		}
+		return "", err
+	}
+	return export.ID, nil
+}
+
+func (u *KBExportUsecase) GetExportList(ctx context.Context, kbID string) ([]*v1.KBReleaseExportListItem, error) {
+	return u.exportRepo.GetList(ctx, kbID)
+}
+
+// GetExportFile 获取导出结果，返回下载文件名
+func (u *KBExportUsecase) GetExportFile(ctx context.Context, kbID, id string) (io.ReadCloser, *s3.ObjectInfo, string, error) {
+	export, err := u.exportRepo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if export.Status != domain.KBExportStatusSucceeded {
+		return nil, nil, "", fmt.Errorf("export is %s", export.Status)
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	filename := kb.Name + ".zip"
+	if release, err := u.kbRepo.GetKBRelease(ctx, kbID, export.ReleaseID); err == nil {
+		filename = fmt.Sprintf("%s-%s.zip", kb.Name, release.Tag)
+	}
+	reader, info, err := u.objectStore.GetObject(ctx, domain.Bucket, export.ObjectKey)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return reader, info, filename, nil
+}
+
+func (u *KBExportUsecase) DeleteExport(ctx context.Context, kbID, id string) error {
+	export, err := u.exportRepo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	if export.Status == domain.KBExportStatusRunning && export.UpdatedAt.After(time.Now().Add(-kbExportStaleTimeout)) {
+		return errors.New("export is running")
+	}
+	if export.ObjectKey != "" {
+		if err := u.objectStore.RemoveObject(ctx, domain.Bucket, export.ObjectKey); err != nil && !errors.Is(err, s3.ErrObjectNotFound) {
+			return err
+		}
+	}
+	return u.exportRepo.Delete(ctx, kbID, id)
+}
+
+// Export 执行导出任务，失败时记录原因不再重试，用户可重新发起导出
+func (u *KBExportUsecase) Export(ctx context.Context, req *domain.KBExportTaskRequest) error {
+	started, err := u.exportRepo.Start(ctx, req.ExportID, time.Now().Add(-kbExportStaleTimeout))
+	if err != nil {
+		return err
+	}
+	if !started {
+		// 任务已完成或正在其他实例执行
+		return nil
+	}
+
+	updates := map[string]any{}
+	if err := u.export(ctx, req, updates); err != nil {
+		u.logger.Error("export kb release failed", log.String("kb_id", req.KBID), log.String("id", req.ExportID), log.Error(err))
+		updates = map[string]any{
+			"status":  domain.KBExportStatusFailed,
+			"message": err.Error(),
+		}
+	} else {
+		updates["status"] = domain.KBExportStatusSucceeded
+	}
+	return u.exportRepo.Update(ctx, req.ExportID, updates)
+}
+
+func (u *KBExportUsecase) export(ctx context.Context, req *domain.KBExportTaskRequest, updates map[string]any) error {
+	export, err := u.exportRepo.GetByID(ctx, req.KBID, req.ExportID)
+	if err != nil {
+		return err
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, export.KBID)
+	if err != nil {
+		return fmt.Errorf("get knowledge base failed: %w", err)
+	}
+	release, err := u.kbRepo.GetKBRelease(ctx, export.KBID, export.ReleaseID)
+	if err != nil {
+		return fmt.Errorf("get kb release failed: %w", err)
+	}
+	navs, err := u.navRepo.GetReleaseListByReleaseID(ctx, release.ID)
+	if err != nil {
+		return fmt.Errorf("get nav list failed: %w", err)
+	}
+	nodes, err := u.nodeRepo.GetExportNodesByReleaseID(ctx, export.KBID, release.ID)
+	if err != nil {
+		return fmt.Errorf("get release nodes failed: %w", err)
+	}
+
+	site := &sitegen.Site{Title: kb.Name, Version: release.Tag}
+	if app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, kb.ID, domain.AppTypeWeb); err == nil {
+		if app.Settings.Title != "" {
+			site.Title = app.Settings.Title
+		}
+		site.Description = app.Settings.Desc
+	}
+	var releaseIDs map[string]string
+	site.Navs, site.Pages, releaseIDs = buildExportSite(navs, nodes)
+
+	file, err := os.CreateTemp("", "kb-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	if err := u.writePages(ctx, zw, site, releaseIDs); err != nil {
+		return err
+	}
+	if err := sitegen.Write(zw, site); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("export/%s/%s.zip", export.KBID, export.ID)
+	if err := u.objectStore.PutObject(ctx, domain.Bucket, key, file, size, s3.PutObjectOptions{ContentType: "application/zip"}); err != nil {
+		return fmt.Errorf("upload export file failed: %w", err)
+	}
+	updates["object_key"] = key
+	updates["size"] = size
+	u.logger.Info("export kb release success", log.String("kb_id", export.KBID), log.String("release_id", release.ID), log.Int("pages", len(site.Pages)), log.Int64("size", size))
+	return nil
+}
+
+// buildExportSite 导航内可见的节点组成目录树，树中可被访问的文档生成页面，同时返回文档 ID 到发布版本 ID 的映射；
+// 静态站点无法认证，部分开放的节点按不公开处理，未公开节点下的所有节点都不再导出
+func buildExportSite(navs []navV1.NavListResp, nodes []*domain.KBExportNode) ([]*sitegen.Nav, []*sitegen.Page, map[string]string) {
+	children := make(map[string][]*domain.KBExportNode)
+	for _, node := range nodes {
+		children[node.ParentID] = append(children[node.ParentID], node)
+	}
+
+	releaseIDs := make(map[string]string)
+	var buildTree func(parentID, navID string) []*sitegen.TreeNode
+	buildTree = func(parentID, navID string) []*sitegen.TreeNode {
+		tree := make([]*sitegen.TreeNode, 0)
+		for _, node := range children[parentID] {
+			if node.Permissions.Visible != consts.NodeAccessPermOpen || (parentID == "" && node.NavId != navID) {
+				continue
+			}
+			item := &sitegen.TreeNode{Name: node.Name}
+			if node.Type == domain.NodeTypeFolder {
+				if item.Children = buildTree(node.ID, navID); len(item.Children) == 0 {
+					continue
+				}
+			} else if node.Permissions.Visitable == consts.NodeAccessPermOpen {
+				releaseIDs[node.ID] = node.NodeReleaseID
+				item.Page = sitegen.PagePath(node.ID)
+			} else {
+				continue
+			}
+			tree = append(tree, item)
+		}
+		return tree
+	}
+
+	siteNavs := lo.Map(navs, func(nav navV1.NavListResp, _ int) *sitegen.Nav {
+		return &sitegen.Nav{ID: nav.ID, Name: nav.Name, Tree: buildTree("", nav.ID)}
+	})
+
+	pages := make([]*sitegen.Page, 0, len(releaseIDs))
+	for _, node := range nodes {
+		if _, ok := releaseIDs[node.ID]; ok {
+			pages = append(pages, &sitegen.Page{
+				ID:        node.ID,
+				Title:     node.Name,
+				NavID:     node.NavId,
+				Summary:   node.Meta.Summary,
+				UpdatedAt: node.UpdatedAt,
+			})
+		}
+	}
+	return siteNavs, pages, releaseIDs
+}
+
+// writePages 读取文档正文，将引用的文件打包进站点并改为相对地址
+func (u *KBExportUsecase) writePages(ctx context.Context, zw *zip.Writer, site *sitegen.Site, releaseIDs map[string]string) error {
+	written := make(map[string]bool)
+	for _, batch := range lo.Chunk(site.Pages, kbExportBatchSize) {
+		releases, err := u.nodeRepo.GetNodeReleasesByIDs(ctx, lo.Map(batch, func(page *sitegen.Page, _ int) string {
+			return releaseIDs[page.ID]
+		}))
+		if err != nil {
+			return fmt.Errorf("get node releases failed: %w", err)
+		}
+		for _, page := range batch {
+			release, ok := releases[releaseIDs[page.ID]]
+			if !ok {
+				continue
+			}
+			content := release.Content
+			if release.Meta.ContentType == domain.ContentTypeMD || !utils.IsLikelyHTML(content) {
+				content = convertMDToHTML(content)
+			}
+
+			for _, match := range exportFileURLRegexp.FindAllStringSubmatch(content, -1) {
+				key, err := url.PathUnescape(match[1])
+				if err != nil || written[key] || strings.Contains(key, "..") {
+					continue
+				}
+				found, err := u.writeFile(ctx, zw, key)
+				if err != nil {
+					return fmt.Errorf("export file %s failed: %w", key, err)
+				}
+				written[key] = found
+			}
+			content = exportFileURLRegexp.ReplaceAllStringFunc(content, func(s string) string {
+				rawKey := exportFileURLRegexp.FindStringSubmatch(s)[1]
+				if key, err := url.PathUnescape(rawKey); err != nil || !written[key] {
+					return s
+				}
+				return "../assets/files/" + rawKey
+			})
+			page.Content = content
+			page.Text = sitegen.PlainText(content)
+		}
+	}
+
+	// 指向已导出文档的链接改为站点内的相对地址
+	for _, page := range site.Pages {
+		page.Content = exportNodeURLRegexp.ReplaceAllStringFunc(page.Content, func(s string) string {
+			nodeID := exportNodeURLRegexp.FindStringSubmatch(s)[1]
+			if _, ok := releaseIDs[nodeID]; !ok {
+				return s
+			}
+			return "../" + sitegen.PagePath(nodeID)
+		})
+	}
+	return nil
+}
+
+// writeFile 文件已被删除时返回 false，保留原地址
+func (u *KBExportUsecase) writeFile(ctx context.Context, zw *zip.Writer, key string) (bool, error) {
+	reader, _, err := u.objectStore.GetObject(ctx, domain.Bucket, key)
+	if err != nil {
+		if errors.Is(err, s3.ErrObjectNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer reader.Close()
+	w, err := zw.Create(path.Join("assets/files", key))
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+
+	navV1 "github.com/chaitin/panda-wiki/api/nav/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/sitegen"
+)
+
+func exportTestNode(id, parentID, navID string, nodeType domain.NodeType, visible, visitable consts.NodeAccessPerm) *domain.KBExportNode {
+	return &domain.KBExportNode{
+		ID:            id,
+		NodeReleaseID: "r-" + id,
+		Name:          id,
+		Type:          nodeType,
+		ParentID:      parentID,
+		NavId:         navID,
+		Meta:          domain.NodeMeta{Summary: "summary " + id},
+		Permissions:   domain.NodePermissions{Visible: visible, Visitable: visitable},
+	}
+}
+
+// treeNames 以 名称(子节点...) 的形式展示目录树，带页面的节点以 * 结尾
+func treeNames(nodes []*sitegen.TreeNode) []string {
+	return lo.Map(nodes, func(node *sitegen.TreeNode, _ int) string {
+		name := node.Name
+		if node.Page != "" {
+			name += "*"
+		}
+		if len(node.Children) > 0 {
+			name += "(" + strings.Join(treeNames(node.Children), " ") + ")"
+		}
+		return name
+	})
+}
+
+func TestBuildExportSite(t *testing.T) {
+	const (
+		open    = consts.NodeAccessPermOpen
+		partial = consts.NodeAccessPermPartial
+		closed  = consts.NodeAccessPermClosed
+		doc     = domain.NodeTypeDocument
+		folder  = domain.NodeTypeFolder
+	)
+	navs := []navV1.NavListResp{{ID: "guide", Name: "指南"}, {ID: "api", Name: "API"}, {ID: "empty", Name: "空栏目"}}
+	nodes := []*domain.KBExportNode{
+		exportTestNode("f1", "", "guide", folder, open, open),
+		exportTestNode("d1", "f1", "guide", doc, open, open),
+		// 部分开放的文档在静态站点中不可访问
+		exportTestNode("d2", "f1", "guide", doc, open, partial),
+		// 目录中隐藏的文档不导出
+		exportTestNode("d3", "f1", "guide", doc, closed, open),
+		// 子节点都不可见的目录不显示
+		exportTestNode("f2", "f1", "guide", folder, open, open),
+		exportTestNode("d4", "f2", "guide", doc, open, closed),
+		// 隐藏目录下的文档不导出
+		exportTestNode("f3", "", "guide", folder, partial, partial),
+		exportTestNode("d5", "f3", "guide", doc, open, open),
+		exportTestNode("d6", "", "api", doc, open, open),
+		// 不属于任何栏目的根节点不导出
+		exportTestNode("d7", "", "", doc, open, open),
+	}
+
+	siteNavs, pages, releaseIDs := buildExportSite(navs, nodes)
+
+	assert.Equal(t, []string{"guide", "api", "empty"}, lo.Map(siteNavs, func(nav *sitegen.Nav, _ int) string { return nav.ID }))
+	assert.Equal(t, []string{"f1(d1*)"}, treeNames(siteNavs[0].Tree))
+	assert.Equal(t, sitegen.PagePath("d1"), siteNavs[0].Tree[0].Children[0].Page)
+	assert.Equal(t, []string{"d6*"}, treeNames(siteNavs[1].Tree))
+	assert.Empty(t, siteNavs[2].Tree)
+
+	assert.Equal(t, []string{"d1", "d6"}, lo.Map(pages, func(page *sitegen.Page, _ int) string { return page.ID }))
+	assert.Equal(t, &sitegen.Page{ID: "d1", Title: "d1", NavID: "guide", Summary: "summary d1"}, pages[0])
+	assert.Equal(t, map[string]string{"d1": "r-d1", "d6": "r-d6"}, releaseIDs)
+}
+
+func TestExportURLRegexp(t *testing.T) {
+	const nodeID = "0197c5b4-1f0e-7d5a-9a51-6c9a1d3e8f00"
+	tests := []struct {
+		name    string
+		re      string
+		content string
+		matches []string
+	}{
+		{"relative file", "file", `<img src="/static-file/kb/a.png">`, []string{"kb/a.png"}},
+		{"absolute file", "file", `<img src="https://wiki.example.com/static-file/kb/a%20b.png">`, []string{"kb/a%20b.png"}},
+		{"file query is not part of key", "file", `<a href="/static-file/kb/a.pdf?x=1">`, []string{"kb/a.pdf"}},
+		{"markdown file", "file", `![img](/static-file/kb/a.png)`, []string{"kb/a.png"}},
+		{"node link", "node", `<a href="https://wiki.example.com/node/` + nodeID + `">`, []string{nodeID}},
+		{"relative node link", "node", `[doc](/node/` + nodeID + `)`, []string{nodeID}},
+		{"short node id", "node", `<a href="/node/abc">`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := exportFileURLRegexp
+			if tt.re == "node" {
+				re = exportNodeURLRegexp
+			}
+			var matches []string
+			for _, m := range re.FindAllStringSubmatch(tt.content, -1) {
+				matches = append(matches, m[1])
+			}
+			assert.Equal(t, tt.matches, matches)
+		})
+	}
+}
@@ -28,9 +28,12 @@ type KnowledgeBaseUsecase struct {
 	kbCache  *cache.KBRepo
 	logger   *log.Logger
 	config   *config.Config
+
+	linkUsecase   *NodeLinkUsecase
+	collabUsecase *NodeCollabUsecase
 }
 
-func NewKnowledgeBaseUsecase(repo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, navRepo *pg.NavRepository, ragRepo *mq.RAGRepository, userRepo *pg.UserRepository, rag rag.RAGService, kbCache *cache.KBRepo, logger *log.Logger, config *config.Config) (*KnowledgeBaseUsecase, error) {
+func NewKnowledgeBaseUsecase(repo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, navRepo *pg.NavRepository, ragRepo *mq.RAGRepository, userRepo *pg.UserRepository, rag rag.RAGService, kbCache *cache.KBRepo, logger *log.Logger, config *config.Config, linkUsecase *NodeLinkUsecase, collabUsecase *NodeCollabUsecase) (*KnowledgeBaseUsecase, error) {
 	u := &KnowledgeBaseUsecase{
 		repo:     repo,
 		nodeRepo: nodeRepo,
@@ -41,6 +44,9 @@ func NewKnowledgeBaseUsecase(repo *pg.KnowledgeBaseRepository, nodeRepo *pg.Node
 		logger:   logger.WithModule("usecase.knowledge_base"),
 		config:   config,
 		kbCache:  kbCache,
+
+		linkUsecase:   linkUsecase,
+		collabUsecase: collabUsecase,
 	}
 	return u, nil
 }
@@ -98,11 +104,28 @@ func (u *KnowledgeBaseUsecase) GetKnowledgeBaseListByUserId(ctx context.Context)
 }
 
 func (u *KnowledgeBaseUsecase) UpdateKnowledgeBase(ctx context.Context, req *domain.UpdateKnowledgeBaseReq) error {
+	old, err := u.repo.GetKnowledgeBaseByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
 	isChange, err := u.repo.UpdateKnowledgeBase(ctx, req)
 	if err != nil {
 		return err
 	}
 
+	// 站点地址变更后，文档中带旧地址的站内链接改为新地址
+	if req.AccessSettings != nil && old.AccessSettings.BaseURL != "" && req.AccessSettings.BaseURL != old.AccessSettings.BaseURL {
+		var userID string
+		if authInfo := domain.GetAuthInfoFromCtx(ctx); authInfo != nil {
+			userID = authInfo.UserId
+		}
+		nodeIDs, err := u.linkUsecase.RewriteBaseURL(ctx, req.ID, old.AccessSettings.BaseURL, req.AccessSettings.BaseURL, userID)
+		if err != nil {
+			return err
+		}
+		u.collabUsecase.ReloadNodes(req.ID, nodeIDs)
+	}
+
 	if isChange {
 		if err := u.kbCache.ClearSession(ctx); err != nil {
 			return err
@@ -145,6 +168,10 @@ func (u *KnowledgeBaseUsecase) GetKnowledgeBasePerm(ctx context.Context, kbID st
 }
 
 func (u *KnowledgeBaseUsecase) DeleteKnowledgeBase(ctx context.Context, kbID string) error {
+	kb, err := u.repo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
 	if err := u.repo.DeleteKnowledgeBase(ctx, kbID); err != nil {
 		return err
 	}
@@ -152,6 +179,11 @@ func (u *KnowledgeBaseUsecase) DeleteKnowledgeBase(ctx context.Context, kbID str
 	if err := u.rag.DeleteKnowledgeBase(ctx, kbID); err != nil {
 		return err
 	}
+	if kb.AnswerDatasetID != "" {
+		if err := u.rag.DeleteKnowledgeBase(ctx, kb.AnswerDatasetID); err != nil {
+			u.logger.Error("delete answer dataset failed", log.String("kb_id", kbID), log.Error(err))
+		}
+	}
 	if err := u.kbCache.DeleteKB(ctx, kbID); err != nil {
 		return err
 	}
@@ -175,9 +207,27 @@ func (u *KnowledgeBaseUsecase) CreateKBRelease(ctx context.Context, req *domain.
 					Action:        "upsert",
 				})
 			}
+			kb, err := u.repo.GetKnowledgeBaseByID(ctx, req.KBID)
+			if err != nil {
+				return "", err
+			}
+			// 开启自动生成时，发布后为文档重新生成待审核的问答对
+			if kb.FAQSetting.AutoGenerate {
+				for _, releaseID := range releaseIDs {
+					nodeContentVectorRequests = append(nodeContentVectorRequests, &domain.NodeReleaseVectorRequest{
+						KBID:          req.KBID,
+						NodeReleaseID: releaseID,
+						Action:        "faq",
+					})
+				}
+			}
 			if err := u.ragRepo.AsyncUpdateNodeReleaseVector(ctx, nodeContentVectorRequests); err != nil {
 				return "", err
 			}
+			// 链接记录只用于反向链接和检查，失败不影响发布
+			if err := u.linkUsecase.SyncReleaseLinks(ctx, req.KBID, releaseIDs); err != nil {
+				u.logger.Error("sync release links failed", log.String("kb_id", req.KBID), log.Error(err))
+			}
 		}
 	}
 
@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/auth/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/ldap"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+type LDAPSyncUsecase struct {
+	authRepo *pg.AuthRepo
+	logger   *log.Logger
+}
+
+func NewLDAPSyncUsecase(authRepo *pg.AuthRepo, logger *log.Logger) *LDAPSyncUsecase {
+	return &LDAPSyncUsecase{
+		authRepo: authRepo,
+		logger:   logger.WithModule("usecase.ldap_sync"),
+	}
+}
+
+// SyncAll 同步所有开启分组同步的知识库，单个知识库失败不影响其他知识库
+func (u *LDAPSyncUsecase) SyncAll(ctx context.Context) error {
+	configs, err := u.authRepo.GetAuthConfigsBySourceType(ctx, consts.SourceTypeLDAP)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if !config.AuthSetting.LDAP.GroupSyncEnabled {
+			continue
+		}
+		resp, err := u.sync(ctx, config)
+		if err != nil {
+			u.logger.Error("sync ldap groups failed", log.String("kb_id", config.KbID), log.Error(err))
+			continue
+		}
+		u.logger.Info("sync ldap groups successful", log.String("kb_id", config.KbID),
+			log.Int("groups", resp.Groups), log.Int("members", resp.Members))
+	}
+	return nil
+}
+
+// SyncKB 立即同步指定知识库的LDAP分组
+func (u *LDAPSyncUsecase) SyncKB(ctx context.Context, kbID string) (*v1.LDAPGroupSyncResp, error) {
+	config, err := u.authRepo.GetAuthConfig(ctx, kbID, consts.SourceTypeLDAP)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ldap is not configured")
+		}
+		return nil, err
+	}
+	return u.sync(ctx, *config)
+}
+
+func (u *LDAPSyncUsecase) sync(ctx context.Context, config domain.AuthConfig) (*v1.LDAPGroupSyncResp, error) {
+	setting := config.AuthSetting.LDAP
+	client, err := ldap.NewClient(ctx, u.logger, ldap.Config{
+		ServerURL:       setting.ServerURL,
+		BindDN:          setting.BindDN,
+		BindPassword:    setting.BindPassword,
+		UserBaseDN:      setting.UserBaseDN,
+		UserFilter:      setting.UserFilter,
+		UserIDAttr:      setting.UserIDAttr,
+		UserNameAttr:    setting.UserNameAttr,
+		UserEmailAttr:   setting.UserEmailAttr,
+		GroupBaseDN:     setting.GroupBaseDN,
+		GroupFilter:     setting.GroupFilter,
+		GroupNameAttr:   setting.GroupNameAttr,
+		GroupMemberAttr: setting.GroupMemberAttr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	dir, err := client.ReadDirectory()
+	if err != nil {
+		return nil, err
+	}
+	// 读到空目录通常是过滤器或账号权限配置错误，同步会移除全部已同步的分组
+	if !setting.GroupSyncAllowEmpty && (len(dir.Groups) == 0 || len(dir.Members) == 0) {
+		return nil, fmt.Errorf("ldap directory has %d groups and %d members, refuse to sync without group_sync_allow_empty", len(dir.Groups), len(dir.Members))
+	}
+
+	// 只有登录过的用户才有 auth 记录，未登录用户在下次同步时加入
+	auths, err := u.authRepo.GetAuthsBySourceType(ctx, config.KbID, consts.SourceTypeLDAP)
+	if err != nil {
+		return nil, err
+	}
+	authIDs := lo.SliceToMap(auths, func(a domain.Auth) (string, int64) {
+		return a.UnionID, int64(a.ID)
+	})
+
+	groups := buildLDAPAuthGroups(dir, authIDs)
+	if err := u.authRepo.SyncAuthGroups(ctx, config.KbID, consts.SourceTypeLDAP, groups); err != nil {
+		return nil, err
+	}
+
+	resp := &v1.LDAPGroupSyncResp{Groups: len(groups)}
+	for _, group := range groups {
+		resp.Members += len(group.AuthIDs)
+	}
+	return resp, nil
+}
+
+// buildLDAPAuthGroups 将目录转换为分组树。嵌套分组通过 parent_id 表示，
+// 权限会沿父分组继承；一个分组有多个父分组时只保留第一个，
+// 其余父分组直接包含该子树的全部成员，保证权限不丢失
+func buildLDAPAuthGroups(dir *ldap.Directory, authIDs map[string]int64) []domain.AuthGroup {
+	groupByDN := make(map[string]ldap.Group, len(dir.Groups))
+	dns := make([]string, 0, len(dir.Groups))
+	for _, group := range dir.Groups {
+		dn := ldap.NormalizeDN(group.DN)
+		if _, ok := groupByDN[dn]; ok {
+			continue
+		}
+		groupByDN[dn] = group
+		dns = append(dns, dn)
+	}
+	sort.Strings(dns)
+
+	memberByDN := make(map[string]ldap.Member, len(dir.Members))
+	for _, member := range dir.Members {
+		memberByDN[ldap.NormalizeDN(member.DN)] = member
+	}
+
+	// 直接成员和子分组，来源包括分组的 member/memberUid 以及用户、分组上的 memberOf
+	users := make(map[string][]int64)
+	children := make(map[string][]string)
+	addUser := func(groupDN, id string) {
+		if authID, ok := authIDs[id]; ok {
+			users[groupDN] = append(users[groupDN], authID)
+		}
+	}
+	addChild := func(parentDN, childDN string) {
+		if parentDN != childDN {
+			children[parentDN] = append(children[parentDN], childDN)
+		}
+	}
+	for _, dn := range dns {
+		group := groupByDN[dn]
+		for _, memberDN := range group.MemberDNs {
+			memberDN = ldap.NormalizeDN(memberDN)
+			if _, ok := groupByDN[memberDN]; ok {
+				addChild(dn, memberDN)
+			} else if member, ok := memberByDN[memberDN]; ok {
+				addUser(dn, member.ID)
+			}
+		}
+		for _, id := range group.MemberIDs {
+			addUser(dn, id)
+		}
+		for _, parentDN := range group.ParentDNs {
+			if parentDN = ldap.NormalizeDN(parentDN); groupByDN[parentDN].DN != "" {
+				addChild(parentDN, dn)
+			}
+		}
+	}
+	for _, member := range dir.Members {
+		for _, groupDN := range member.GroupDNs {
+			if groupDN = ldap.NormalizeDN(groupDN); groupByDN[groupDN].DN != "" {
+				addUser(groupDN, member.ID)
+			}
+		}
+	}
+
+	// 为每个分组选定唯一父分组，跳过会成环的关系
+	parentOf := make(map[string]string)
+	hasAncestor := func(dn, ancestor string) bool {
+		for seen := map[string]bool{}; dn != "" && !seen[dn]; dn = parentOf[dn] {
+			if dn == ancestor {
+				return true
+			}
+			seen[dn] = true
+		}
+		return false
+	}
+	for _, dn := range dns {
+		children[dn] = lo.Uniq(children[dn])
+		sort.Strings(children[dn])
+		for _, child := range children[dn] {
+			if _, ok := parentOf[child]; ok || hasAncestor(dn, child) {
+				continue
+			}
+			parentOf[child] = dn
+		}
+	}
+
+	// 子树全部成员，用于非树形父子关系
+	subtree := make(map[string][]int64)
+	var collect func(dn string, visiting map[string]bool) []int64
+	collect = func(dn string, visiting map[string]bool) []int64 {
+		if ids, ok := subtree[dn]; ok {
+			return ids
+		}
+		if visiting[dn] {
+			return nil
+		}
+		visiting[dn] = true
+		ids := append([]int64{}, users[dn]...)
+		for _, child := range children[dn] {
+			ids = append(ids, collect(child, visiting)...)
+		}
+		ids = lo.Uniq(ids)
+		subtree[dn] = ids
+		return ids
+	}
+
+	groups := make([]domain.AuthGroup, 0, len(dns))
+	for _, dn := range dns {
+		ids := append([]int64{}, users[dn]...)
+		for _, child := range children[dn] {
+			if parentOf[child] != dn {
+				ids = append(ids, collect(child, map[string]bool{})...)
+			}
+		}
+		ids = lo.Uniq(ids)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		groups = append(groups, domain.AuthGroup{
+			Name:         groupByDN[dn].Name,
+			AuthIDs:      ids,
+			SyncId:       groupByDN[dn].DN,
+			SyncParentId: groupByDN[parentOf[dn]].DN,
+		})
+	}
+	return groups
+}
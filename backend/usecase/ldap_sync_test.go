@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/ldap"
+)
+
+func TestBuildLDAPAuthGroups(t *testing.T) {
+	authIDs := map[string]int64{"alice": 1, "bob": 2, "carol": 3}
+	tests := []struct {
+		name     string
+		dir      *ldap.Directory
+		expected []domain.AuthGroup
+	}{
+		{
+			name:     "empty directory",
+			dir:      &ldap.Directory{},
+			expected: []domain.AuthGroup{},
+		},
+		{
+			name: "member DNs are matched case insensitively",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=dev,ou=groups,dc=x", Name: "dev", MemberDNs: []string{"UID=Alice,OU=People,DC=x", "uid=dave,ou=people,dc=x"}},
+				},
+				Members: []ldap.Member{
+					{DN: "uid=alice,ou=people,dc=x", ID: "alice"},
+					{DN: "uid=dave,ou=people,dc=x", ID: "dave"},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "dev", AuthIDs: pq.Int64Array{1}, SyncId: "cn=dev,ou=groups,dc=x"},
+			},
+		},
+		{
+			name: "posix memberUid and user memberOf",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=ops,dc=x", Name: "ops", MemberIDs: []string{"bob"}},
+				},
+				Members: []ldap.Member{
+					{DN: "uid=carol,dc=x", ID: "carol", GroupDNs: []string{"CN=ops,DC=x"}},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "ops", AuthIDs: pq.Int64Array{2, 3}, SyncId: "cn=ops,dc=x"},
+			},
+		},
+		{
+			name: "nested groups from member and memberOf",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=all,dc=x", Name: "all", MemberDNs: []string{"cn=dev,dc=x"}},
+					{DN: "cn=dev,dc=x", Name: "dev", MemberIDs: []string{"alice"}},
+					{DN: "cn=qa,dc=x", Name: "qa", MemberIDs: []string{"bob"}, ParentDNs: []string{"CN=All,DC=x"}},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "all", AuthIDs: pq.Int64Array{}, SyncId: "cn=all,dc=x"},
+				{Name: "dev", AuthIDs: pq.Int64Array{1}, SyncId: "cn=dev,dc=x", SyncParentId: "cn=all,dc=x"},
+				{Name: "qa", AuthIDs: pq.Int64Array{2}, SyncId: "cn=qa,dc=x", SyncParentId: "cn=all,dc=x"},
+			},
+		},
+		{
+			name: "second parent gets the subtree members",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=a,dc=x", Name: "a", MemberDNs: []string{"cn=c,dc=x"}},
+					{DN: "cn=b,dc=x", Name: "b", MemberDNs: []string{"cn=c,dc=x"}},
+					{DN: "cn=c,dc=x", Name: "c", MemberIDs: []string{"alice"}, MemberDNs: []string{"cn=d,dc=x"}},
+					{DN: "cn=d,dc=x", Name: "d", MemberIDs: []string{"bob"}},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "a", AuthIDs: pq.Int64Array{}, SyncId: "cn=a,dc=x"},
+				{Name: "b", AuthIDs: pq.Int64Array{1, 2}, SyncId: "cn=b,dc=x"},
+				{Name: "c", AuthIDs: pq.Int64Array{1}, SyncId: "cn=c,dc=x", SyncParentId: "cn=a,dc=x"},
+				{Name: "d", AuthIDs: pq.Int64Array{2}, SyncId: "cn=d,dc=x", SyncParentId: "cn=c,dc=x"},
+			},
+		},
+		{
+			name: "cycles and self membership are broken",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=a,dc=x", Name: "a", MemberDNs: []string{"cn=b,dc=x", "cn=a,dc=x"}, MemberIDs: []string{"alice"}},
+					{DN: "cn=b,dc=x", Name: "b", MemberDNs: []string{"cn=a,dc=x"}, MemberIDs: []string{"bob"}},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "a", AuthIDs: pq.Int64Array{1}, SyncId: "cn=a,dc=x"},
+				{Name: "b", AuthIDs: pq.Int64Array{1, 2}, SyncId: "cn=b,dc=x", SyncParentId: "cn=a,dc=x"},
+			},
+		},
+		{
+			name: "duplicate group DNs keep the first",
+			dir: &ldap.Directory{
+				Groups: []ldap.Group{
+					{DN: "cn=dev,dc=x", Name: "dev", MemberIDs: []string{"alice"}},
+					{DN: "CN=Dev,DC=x", Name: "Dev", MemberIDs: []string{"bob"}},
+				},
+			},
+			expected: []domain.AuthGroup{
+				{Name: "dev", AuthIDs: pq.Int64Array{1}, SyncId: "cn=dev,dc=x"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildLDAPAuthGroups(tt.dir, authIDs))
+		})
+	}
+}
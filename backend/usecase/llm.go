@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ import (
 	"github.com/chaitin/panda-wiki/config"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/metrics"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/store/rag"
 	"github.com/chaitin/panda-wiki/utils"
@@ -32,6 +34,8 @@ type LLMUsecase struct {
 	nodeRepo         *pg.NodeRepository
 	modelRepo        *pg.ModelRepository
 	promptRepo       *pg.PromptRepo
+	attachmentRepo   *pg.AttachmentRepository
+	faqRepo          *pg.NodeFAQRepository
 	config           *config.Config
 	logger           *log.Logger
 	modelkit         *modelkit.ModelKit
@@ -42,7 +46,7 @@ const (
 	summaryMaxChunks       = 4     // max chunks to process for summary
 )
 
-func NewLLMUsecase(config *config.Config, rag rag.RAGService, conversationRepo *pg.ConversationRepository, kbRepo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, modelRepo *pg.ModelRepository, promptRepo *pg.PromptRepo, logger *log.Logger) *LLMUsecase {
+func NewLLMUsecase(config *config.Config, rag rag.RAGService, conversationRepo *pg.ConversationRepository, kbRepo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, modelRepo *pg.ModelRepository, promptRepo *pg.PromptRepo, attachmentRepo *pg.AttachmentRepository, faqRepo *pg.NodeFAQRepository, logger *log.Logger) *LLMUsecase {
 	tiktoken.SetBpeLoader(&utils.Localloader{})
 	modelkit := modelkit.NewModelKit(logger.Logger)
 	return &LLMUsecase{
@@ -53,6 +57,8 @@ func NewLLMUsecase(config *config.Config, rag rag.RAGService, conversationRepo *
 		nodeRepo:         nodeRepo,
 		modelRepo:        modelRepo,
 		promptRepo:       promptRepo,
+		attachmentRepo:   attachmentRepo,
+		faqRepo:          faqRepo,
 		logger:           logger.WithModule("usecase.llm"),
 		modelkit:         modelkit,
 	}
@@ -64,6 +70,7 @@ func (u *LLMUsecase) BuildConversationMessageWithRAG(
 	kbID string,
 	groupIDs []int,
 	systemPrompt string,
+	filter *domain.NodeMetaFilter,
 ) ([]*schema.Message, []*domain.RankedNodeChunks, error) {
 	messages := make([]*schema.Message, 0)
 	rankedNodes := make([]*domain.RankedNodeChunks, 0)
@@ -116,6 +123,7 @@ func (u *LLMUsecase) BuildConversationMessageWithRAG(
 				GroupIDs:            groupIDs,
 				SimilarityThreshold: 0.2,
 				HistoryMessages:     historyMessages[:len(historyMessages)-1],
+				Filter:              filter,
 			})
 			if err != nil {
 				u.logger.Error("get rank nodes failed", log.Error(err))
@@ -205,6 +213,32 @@ func (u *LLMUsecase) Generate(
 	return resp.Content, nil
 }
 
+// RecognizeImageText 通过视觉模型识别图片中的文字
+func (u *LLMUsecase) RecognizeImageText(ctx context.Context, model *domain.Model, data []byte, contentType string) (string, error) {
+	modelkitModel, err := model.ToModelkitModel()
+	if err != nil {
+		return "", err
+	}
+	chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
+	if err != nil {
+		return "", err
+	}
+	imageURL := fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data))
+	resp, err := chatModel.Generate(ctx, []*schema.Message{
+		{
+			Role: schema.User,
+			MultiContent: []schema.ChatMessagePart{
+				{Type: schema.ChatMessagePartTypeText, Text: domain.ImageTextRecognitionPrompt},
+				{Type: schema.ChatMessagePartTypeImageURL, ImageURL: &schema.ChatMessageImageURL{URL: imageURL}},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("recognize image text failed: %w", err)
+	}
+	return u.trimThinking(resp.Content), nil
+}
+
 func (u *LLMUsecase) SummaryNode(ctx context.Context, kbID string, model *domain.Model, name, content string) (string, error) {
 	modelkitModel, err := model.ToModelkitModel()
 	if err != nil {
@@ -358,6 +392,69 @@ func (u *LLMUsecase) requestSummary(ctx context.Context, kbID string, chatModel
 	return strings.TrimSpace(u.trimThinking(summary)), nil
 }
 
+// GenerateFAQPairs 根据文档内容生成问答对，过长的文档只使用开头部分
+func (u *LLMUsecase) GenerateFAQPairs(ctx context.Context, model *domain.Model, name, content string, limit int) ([]domain.FAQPair, error) {
+	modelkitModel, err := model.ToModelkitModel()
+	if err != nil {
+		return nil, err
+	}
+	chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := u.SplitByTokenLimit(content, summaryChunkTokenLimit)
+	if err != nil {
+		return nil, err
+	}
+	output, err := u.Generate(ctx, chatModel, []*schema.Message{
+		{
+			Role:    schema.System,
+			Content: fmt.Sprintf(domain.NodeFAQGeneratePrompt, limit),
+		},
+		{
+			Role:    schema.User,
+			Content: fmt.Sprintf("文档名称：%s\n文档内容：%s", name, chunks[0]),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := domain.ParseFAQPairs(u.trimThinking(output))
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+	return pairs, nil
+}
+
+// SuggestFollowUpQuestions 根据问题、回答和引用的文档推荐追问
+func (u *LLMUsecase) SuggestFollowUpQuestions(ctx context.Context, chatModel model.BaseChatModel, question, answer string, nodeNames []string, limit int) ([]string, error) {
+	output, err := u.Generate(ctx, chatModel, []*schema.Message{
+		{
+			Role:    schema.System,
+			Content: fmt.Sprintf(domain.FollowUpQuestionsPrompt, limit),
+		},
+		{
+			Role:    schema.User,
+			Content: fmt.Sprintf("用户问题：%s\n助手回答：%s\n参考文档：%s", question, answer, strings.Join(nodeNames, "、")),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	questions, err := domain.ParseQuestions(u.trimThinking(output))
+	if err != nil {
+		return nil, err
+	}
+	questions = lo.Uniq(lo.Reject(questions, func(q string, _ int) bool { return q == question }))
+	if len(questions) > limit {
+		questions = questions[:limit]
+	}
+	return questions, nil
+}
+
 func (u *LLMUsecase) streamSummary(
 	ctx context.Context,
 	kbID string,
@@ -461,19 +558,27 @@ type GetRankNodesRequest struct {
 	SimilarityThreshold float64
 	HistoryMessages     []*schema.Message
 	MaxChunksPerDoc     int
+	Filter              *domain.NodeMetaFilter // 按标签和自定义字段过滤
 }
 
 func (u *LLMUsecase) GetRankNodes(ctx context.Context, req GetRankNodesRequest) (string, []*domain.RankedNodeChunks, error) {
 	var rankedNodes []*domain.RankedNodeChunks
 	// get related documents from raglite
-	rewrittenQuery, records, err := u.rag.QueryRecords(ctx, &rag.QueryRecordsRequest{
+	start := time.Now()
+	query := &rag.QueryRecordsRequest{
 		DatasetID:           req.DatasetID,
 		Query:               req.Question,
 		GroupIDs:            req.GroupIDs,
 		SimilarityThreshold: req.SimilarityThreshold,
 		HistoryMsgs:         req.HistoryMessages,
 		MaxChunksPerDoc:     req.MaxChunksPerDoc,
-	})
+	}
+	if !req.Filter.IsEmpty() {
+		query.Tags = req.Filter.Tags
+		query.Metadata = req.Filter.RAGMetadata()
+	}
+	rewrittenQuery, records, err := u.rag.QueryRecords(ctx, query)
+	metrics.ObserveRAGRetrieval(time.Since(start), err)
 	if err != nil {
 		return "", nil, fmt.Errorf("get records from raglite failed: %w", err)
 	}
@@ -485,14 +590,41 @@ func (u *LLMUsecase) GetRankNodes(ctx context.Context, req GetRankNodesRequest)
 			return item.DocID
 		}))
 		u.logger.Info("node chunk doc ids", log.Any("docIDs", docIDs))
-		docIDNode, err := u.nodeRepo.GetNodeReleasesWithPathsByDocIDs(ctx, docIDs)
+		// 附件的记录归属到引用它的文档
+		attachmentDocs, err := u.attachmentRepo.GetNodeAttachmentDocsByDocIDs(ctx, docIDs)
+		if err != nil {
+			return "", nil, fmt.Errorf("get node attachment docs failed: %w", err)
+		}
+		attachmentDocMap := lo.KeyBy(attachmentDocs, func(doc *domain.NodeAttachmentDoc) string { return doc.DocID })
+		for _, doc := range attachmentDocs {
+			docIDs = append(docIDs, doc.ParentDocID)
+		}
+		// 问答对的记录同样归属到来源文档
+		faqs, err := u.faqRepo.GetByDocIDs(ctx, docIDs)
+		if err != nil {
+			return "", nil, fmt.Errorf("get node faqs failed: %w", err)
+		}
+		faqMap := lo.KeyBy(faqs, func(faq *domain.NodeFAQ) string { return faq.DocID })
+		for _, faq := range faqs {
+			docIDs = append(docIDs, faq.ParentDocID)
+		}
+		docIDNode, err := u.nodeRepo.GetNodeReleasesWithPathsByDocIDs(ctx, lo.Uniq(docIDs))
 		if err != nil {
 			return "", nil, fmt.Errorf("get nodes by ids failed: %w", err)
 		}
 		u.logger.Info("get node release by doc ids", log.Any("docIDNode", lo.Keys(docIDNode)))
 		for _, record := range records {
-			if nodeChunk, ok := rankedNodesMap[record.DocID]; !ok {
-				if docNode, ok := docIDNode[record.DocID]; ok {
+			docID := record.DocID
+			if doc, ok := attachmentDocMap[record.DocID]; ok {
+				docID = doc.ParentDocID
+				record.Source = doc.Source()
+			}
+			if faq, ok := faqMap[record.DocID]; ok {
+				docID = faq.ParentDocID
+				record.Source = faq.Source()
+			}
+			if nodeChunk, ok := rankedNodesMap[docID]; !ok {
+				if docNode, ok := docIDNode[docID]; ok {
 					rankNodeChunk := &domain.RankedNodeChunks{
 						NodeID:        docNode.NodeID,
 						NodeName:      docNode.Name,
@@ -502,7 +634,7 @@ func (u *LLMUsecase) GetRankNodes(ctx context.Context, req GetRankNodesRequest)
 						Chunks:        []*domain.NodeContentChunk{record},
 					}
 					rankedNodes = append(rankedNodes, rankNodeChunk)
-					rankedNodesMap[record.DocID] = rankNodeChunk
+					rankedNodesMap[docID] = rankNodeChunk
 				}
 			} else {
 				nodeChunk.Chunks = append(nodeChunk.Chunks, record)
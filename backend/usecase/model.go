@@ -86,6 +86,15 @@ func (u *ModelUsecase) TriggerUpsertRecords(ctx context.Context) error {
 		if err := u.kbRepo.UpdateDatasetID(ctx, kb.ID, newDatasetID); err != nil {
 			return fmt.Errorf("update knowledge base dataset id failed: %w", err)
 		}
+		// 标准答案的检索库同样需要用新模型重新写入
+		if err := u.ragRepo.AsyncUpdateNodeReleaseVector(ctx, []*domain.NodeReleaseVectorRequest{
+			{
+				KBID:   kb.ID,
+				Action: "reindex_curated_answers",
+			},
+		}); err != nil {
+			return err
+		}
 	}
 	// traverse all nodes
 	err = u.nodeRepo.TraverseNodesByCursor(ctx, func(nodeRelease *domain.NodeRelease) error {
@@ -173,6 +182,32 @@ func (u *ModelUsecase) GetChatModel(ctx context.Context) (*domain.Model, error)
 	return model, nil
 }
 
+// GetAnalysisVLModel 获取用于识别图片文字的视觉模型
+func (u *ModelUsecase) GetAnalysisVLModel(ctx context.Context) (*domain.Model, error) {
+	modelModeSetting, err := u.GetModelModeSetting(ctx)
+	if err != nil {
+		u.logger.Error("get model mode setting failed, use manual mode", log.Error(err))
+	}
+	if err == nil && modelModeSetting.Mode == consts.ModelSettingModeAuto && modelModeSetting.AutoModeAPIKey != "" {
+		return &domain.Model{
+			Model:    string(consts.AutoModeDefaultAnalysisVLModel),
+			Type:     domain.ModelTypeAnalysisVL,
+			IsActive: true,
+			BaseURL:  consts.AutoModeBaseURL,
+			APIKey:   modelModeSetting.AutoModeAPIKey,
+			Provider: domain.ModelProviderBrandBaiZhiCloud,
+		}, nil
+	}
+	model, err := u.modelRepo.GetModelByType(ctx, domain.ModelTypeAnalysisVL)
+	if err != nil {
+		return nil, err
+	}
+	if !model.IsActive {
+		return nil, fmt.Errorf("analysis-vl model is not active")
+	}
+	return model, nil
+}
+
 func (u *ModelUsecase) GetModelByType(ctx context.Context, modelType domain.ModelType) (*domain.Model, error) {
 	return u.modelRepo.GetModelByType(ctx, modelType)
 }
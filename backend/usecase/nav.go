@@ -68,14 +68,14 @@ func (u *NavUsecase) Move(ctx context.Context, req *v1.NavMoveReq) error {
 	return u.navRepo.Move(ctx, req.KbId, req.ID, req.PrevID, req.NextID)
 }
 
-func (u *NavUsecase) Delete(ctx context.Context, req *v1.NavDeleteReq) error {
+func (u *NavUsecase) Delete(ctx context.Context, req *v1.NavDeleteReq, userId string) error {
 	nodeIDs, err := u.nodeRepo.GetNodeIDsByNavId(ctx, req.KbId, req.ID)
 	if err != nil {
 		return err
 	}
 
 	if len(nodeIDs) > 0 {
-		docIDs, err := u.nodeRepo.Delete(ctx, req.KbId, nodeIDs)
+		docIDs, err := u.nodeRepo.Delete(ctx, req.KbId, nodeIDs, userId)
 		if err != nil {
 			return err
 		}
@@ -19,6 +19,8 @@ import (
 	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/metrics"
+	"github.com/chaitin/panda-wiki/repo/cache"
 	"github.com/chaitin/panda-wiki/repo/mq"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/store/rag"
@@ -37,9 +39,13 @@ type NodeUsecase struct {
 	authRepo     *pg.AuthRepo
 	llmUsecase   *LLMUsecase
 	logger       *log.Logger
-	s3Client     *s3.MinioClient
+	s3Client     s3.ObjectStore
 	rAGService   rag.RAGService
 	modelUsecase *ModelUsecase
+
+	deadLetterRepo *pg.MQDeadLetterRepository
+	presenceRepo   *cache.NodePresenceRepo
+	linkRepo       *pg.NodeLinkRepository
 }
 
 func NewNodeUsecase(
@@ -52,10 +58,13 @@ func NewNodeUsecase(
 	llmUsecase *LLMUsecase,
 	ragService rag.RAGService,
 	logger *log.Logger,
-	s3Client *s3.MinioClient,
+	s3Client s3.ObjectStore,
 	modelRepo *pg.ModelRepository,
 	authRepo *pg.AuthRepo,
 	modelUsecase *ModelUsecase,
+	deadLetterRepo *pg.MQDeadLetterRepository,
+	presenceRepo *cache.NodePresenceRepo,
+	linkRepo *pg.NodeLinkRepository,
 ) *NodeUsecase {
 	return &NodeUsecase{
 		nodeRepo:     nodeRepo,
@@ -71,6 +80,10 @@ func NewNodeUsecase(
 		logger:       logger.WithModule("usecase.node"),
 		s3Client:     s3Client,
 		modelUsecase: modelUsecase,
+
+		deadLetterRepo: deadLetterRepo,
+		presenceRepo:   presenceRepo,
+		linkRepo:       linkRepo,
 	}
 }
 
@@ -133,16 +146,21 @@ func (u *NodeUsecase) GetNodeByKBID(ctx context.Context, id, kbId, format string
 	}
 	if format != "raw" {
 		if !utils.IsLikelyHTML(node.Content) {
-			node.Content = u.convertMDToHTML(node.Content)
+			node.Content = convertMDToHTML(node.Content)
 		}
 	}
 	return node, nil
 }
 
-func (u *NodeUsecase) NodeAction(ctx context.Context, req *domain.NodeActionReq) error {
+func (u *NodeUsecase) NodeAction(ctx context.Context, req *domain.NodeActionReq, userId string) error {
 	switch req.Action {
 	case "delete":
-		docIDs, err := u.nodeRepo.Delete(ctx, req.KBID, req.IDs)
+		if !req.Force {
+			if err := u.checkNodeReferences(ctx, req.KBID, req.IDs); err != nil {
+				return err
+			}
+		}
+		docIDs, err := u.nodeRepo.Delete(ctx, req.KBID, req.IDs, userId)
 		if err != nil {
 			return err
 		}
@@ -161,18 +179,25 @@ func (u *NodeUsecase) NodeAction(ctx context.Context, req *domain.NodeActionReq)
 	return nil
 }
 
-func (u *NodeUsecase) Update(ctx context.Context, req *domain.UpdateNodeReq, userId string) error {
+func (u *NodeUsecase) Update(ctx context.Context, req *domain.UpdateNodeReq, userId string) (*v1.UpdateNodeResp, error) {
 	if req.NavId != nil {
 		_, err := u.navRepo.GetById(ctx, *req.NavId)
 		if err != nil {
-			return errors.New("invalid nav_id")
+			return nil, errors.New("invalid nav_id")
 		}
 	}
-	err := u.nodeRepo.UpdateNodeContent(ctx, req, userId)
+	if req.Tags != nil {
+		tags := lo.Uniq(*req.Tags)
+		req.Tags = &tags
+	}
+	if err := u.validateNodeMeta(ctx, req.KBID, req.Tags, req.Fields); err != nil {
+		return nil, err
+	}
+	revision, err := u.nodeRepo.UpdateNodeContent(ctx, req, userId)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &v1.UpdateNodeResp{Revision: revision}, nil
 }
 
 func (u *NodeUsecase) ValidateNodePerm(ctx context.Context, kbID, nodeId string, authId uint) *domain.PWResponseErrCode {
@@ -257,7 +282,7 @@ func (u *NodeUsecase) GetNodeReleaseDetailByKBIDAndID(ctx context.Context, kbID,
 	// just for info
 	if format != "raw" {
 		if !utils.IsLikelyHTML(node.Content) {
-			node.Content = u.convertMDToHTML(node.Content)
+			node.Content = convertMDToHTML(node.Content)
 		}
 	}
 	return node, nil
@@ -376,6 +401,11 @@ func (u *NodeUsecase) GetRecommendNodeList(ctx context.Context, req *domain.GetR
 }
 
 func (u *NodeUsecase) BatchMoveNode(ctx context.Context, req *domain.BatchMoveReq) error {
+	if !req.Force {
+		if err := u.checkNodeReferences(ctx, req.KBID, req.IDs); err != nil {
+			return err
+		}
+	}
 	return u.nodeRepo.BatchMove(ctx, req)
 }
 
@@ -390,10 +420,15 @@ func (u *NodeUsecase) MoveNodeNav(ctx context.Context, req *v1.NodeMoveNavReq) e
 	if nav.KbID != req.KbID {
 		return fmt.Errorf("nav does not belong to kb %s", req.KbID)
 	}
+	if !req.Force {
+		if err := u.checkNodeReferences(ctx, req.KbID, req.IDs); err != nil {
+			return err
+		}
+	}
 	return u.nodeRepo.MoveNodeNav(ctx, req.KbID, req.NavID, req.IDs)
 }
 
-func (u *NodeUsecase) convertMDToHTML(mdStr string) string {
+func convertMDToHTML(mdStr string) string {
 	extensions := parser.CommonExtensions & ^parser.Autolink & ^parser.MathJax
 	p := parser.NewWithExtensions(extensions)
 	doc := p.Parse([]byte(mdStr))
@@ -408,7 +443,7 @@ func (u *NodeUsecase) convertMDToHTML(mdStr string) string {
 	return string(html)
 }
 
-func (u *NodeUsecase) GetShareNodeList(ctx context.Context, kbId string, authId uint) ([]*shareV1.NodeListGroupNavResp, error) {
+func (u *NodeUsecase) GetShareNodeList(ctx context.Context, kbId string, authId uint, filter *domain.NodeMetaFilter) ([]*shareV1.NodeListGroupNavResp, error) {
 
 	nodes, err := u.nodeRepo.GetNodeReleaseListByKBID(ctx, kbId)
 	if err != nil {
@@ -442,6 +477,7 @@ func (u *NodeUsecase) GetShareNodeList(ctx context.Context, kbId string, authId
 		return id, struct{}{}
 	})
 
+	visibleNodes := make([]*domain.ShareNodeListItemResp, 0, len(nodes))
 	for _, node := range nodes {
 		switch node.Permissions.Visible {
 		case consts.NodeAccessPermOpen:
@@ -452,6 +488,10 @@ func (u *NodeUsecase) GetShareNodeList(ctx context.Context, kbId string, authId
 		default:
 			continue
 		}
+		visibleNodes = append(visibleNodes, node)
+	}
+
+	for _, node := range filterShareNodesByMeta(visibleNodes, filter) {
 		if idx, ok := navIndexMap[node.NavId]; ok {
 			result[idx].List = append(result[idx].List, *node)
 			result[idx].Count++
@@ -498,6 +538,32 @@ func (u *NodeUsecase) GetNodeReleaseListByParentID(ctx context.Context, kbID, pa
 	return result, nil
 }
 
+// filterShareNodesByMeta 保留符合条件的文档及其上级文件夹，保证目录树完整
+func filterShareNodesByMeta(nodes []*domain.ShareNodeListItemResp, filter *domain.NodeMetaFilter) []*domain.ShareNodeListItemResp {
+	if filter.IsEmpty() {
+		return nodes
+	}
+	nodeMap := lo.SliceToMap(nodes, func(node *domain.ShareNodeListItemResp) (string, *domain.ShareNodeListItemResp) {
+		return node.ID, node
+	})
+	keep := make(map[string]struct{})
+	for _, node := range nodes {
+		if node.Type != domain.NodeTypeDocument || !filter.Match(node.Meta) {
+			continue
+		}
+		for cur := node; cur != nil; cur = nodeMap[cur.ParentID] {
+			if _, ok := keep[cur.ID]; ok {
+				break
+			}
+			keep[cur.ID] = struct{}{}
+		}
+	}
+	return lo.Filter(nodes, func(node *domain.ShareNodeListItemResp, _ int) bool {
+		_, ok := keep[node.ID]
+		return ok
+	})
+}
+
 // buildNodeTree 递归构建节点树结构
 func (u *NodeUsecase) buildNodeTree(parentID string, childrenMap map[string][]*domain.ShareNodeListItemResp) []*domain.ShareNodeDetailItem {
 	children := childrenMap[parentID]
@@ -666,6 +732,18 @@ func (u *NodeUsecase) NodePermissionsEdit(ctx context.Context, req v1.NodePermis
 	return nil
 }
 
+// UpdateRagStatusMetrics 更新文档向量化状态指标
+func (u *NodeUsecase) UpdateRagStatusMetrics(ctx context.Context) error {
+	counts, err := u.nodeRepo.GetNodeRagStatusCount(ctx)
+	if err != nil {
+		return err
+	}
+	for kbID, statusCount := range counts {
+		metrics.SetNodeRagStatus(kbID, statusCount)
+	}
+	return nil
+}
+
 func (u *NodeUsecase) SyncRagNodeStatus(ctx context.Context) error {
 	kbs, err := u.kbRepo.GetKnowledgeBaseList(ctx)
 	if err != nil {
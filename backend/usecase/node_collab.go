@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/collab"
+	"github.com/chaitin/panda-wiki/repo/cache"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+// collabSaveInterval 协同编辑中定期保存的间隔，最后一个编辑器离开时会立即保存
+const collabSaveInterval = 10 * time.Second
+
+type NodeCollabUsecase struct {
+	nodeRepo   *pg.NodeRepository
+	ticketRepo *cache.NodeCollabTicketRepo
+	logger     *log.Logger
+	hub        *collab.Hub
+
+	// revisions 房间加载或上次保存时文档的修订号
+	revisions sync.Map
+}
+
+func NewNodeCollabUsecase(nodeRepo *pg.NodeRepository, ticketRepo *cache.NodeCollabTicketRepo, logger *log.Logger) *NodeCollabUsecase {
+	u := &NodeCollabUsecase{
+		nodeRepo:   nodeRepo,
+		ticketRepo: ticketRepo,
+		logger:     logger.WithModule("usecase.node_collab"),
+	}
+	u.hub = collab.NewHub(u, collabSaveInterval, u.logger)
+	return u
+}
+
+// CreateTicket 为已通过权限校验的用户生成连接文档协作房间的一次性凭证
+func (u *NodeCollabUsecase) CreateTicket(ctx context.Context, kbID, nodeID, userID string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
+	if err := u.ticketRepo.Create(ctx, ticket, &domain.NodeCollabTicket{
+		UserID: userID,
+		KBID:   kbID,
+		NodeID: nodeID,
+	}); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// TakeTicket 使用凭证，返回凭证所属的用户，凭证无效、已使用或不属于该文档时返回 ErrPermissionDenied
+func (u *NodeCollabUsecase) TakeTicket(ctx context.Context, kbID, nodeID, ticket string) (string, error) {
+	value, err := u.ticketRepo.Take(ctx, ticket)
+	if err != nil {
+		return "", err
+	}
+	if value == nil || value.KBID != kbID || value.NodeID != nodeID {
+		return "", domain.ErrPermissionDenied
+	}
+	return value.UserID, nil
+}
+
+// Serve 将编辑器连接加入文档的协作房间，阻塞到连接断开
+func (u *NodeCollabUsecase) Serve(ctx context.Context, kbID, nodeID, userID string, conn collab.Conn) error {
+	node, err := u.nodeRepo.GetNodeByID(ctx, nodeID)
+	if err != nil {
+		conn.Close()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("node not found")
+		}
+		return err
+	}
+	if node.KBID != kbID || node.Type != domain.NodeTypeDocument {
+		conn.Close()
+		return fmt.Errorf("node not found")
+	}
+	return u.hub.Serve(ctx, kbID+":"+nodeID, userID, conn)
+}
+
+// ReloadNodes 文档内容在协作之外被批量修改后，让正在协作的编辑器重新加载
+func (u *NodeCollabUsecase) ReloadNodes(kbID string, nodeIDs []string) {
+	for _, nodeID := range nodeIDs {
+		u.hub.Reload(kbID + ":" + nodeID)
+	}
+}
+
+// Load 加载保存的更新，文档在协作之外被修改过时丢弃，由编辑器按文档内容重新初始化
+func (u *NodeCollabUsecase) Load(ctx context.Context, room string) ([][]byte, error) {
+	_, nodeID, _ := strings.Cut(room, ":")
+	node, err := u.nodeRepo.GetNodeByID(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	u.revisions.Store(room, node.Revision)
+	state, err := u.nodeRepo.GetCollabState(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Revision != node.Revision {
+		return nil, nil
+	}
+	return collab.DecodeUpdates(state.State)
+}
+
+// Save 写入编辑器上报的内容并保存更新，以房间加载或上次保存时的修订号检查冲突；
+// 文档在协作之外被修改时丢弃保存的更新，由编辑器按当前内容重新加载
+func (u *NodeCollabUsecase) Save(ctx context.Context, room string, updates [][]byte, content *collab.Content) error {
+	kbID, nodeID, _ := strings.Cut(room, ":")
+	rev, ok := u.revisions.Load(room)
+	if !ok {
+		return fmt.Errorf("collab room %s not loaded", room)
+	}
+	revision := rev.(int64)
+	if content != nil {
+		newRevision, err := u.nodeRepo.UpdateNodeContent(ctx, &domain.UpdateNodeReq{
+			ID:       nodeID,
+			KBID:     kbID,
+			Content:  &content.Text,
+			Revision: &revision,
+		}, content.UserID)
+		if err != nil {
+			var conflict *domain.NodeConflictError
+			if errors.As(err, &conflict) {
+				u.revisions.Delete(room)
+				if err := u.nodeRepo.DeleteCollabState(ctx, nodeID); err != nil {
+					return err
+				}
+				return collab.ErrConflict
+			}
+			return err
+		}
+		revision = newRevision
+		u.revisions.Store(room, revision)
+	}
+	// 没有上报内容时沿用已知的修订号，期间文档被其他方式修改的话下次加载会丢弃这些更新
+	return u.nodeRepo.SaveCollabState(ctx, &domain.NodeCollabState{
+		NodeID:   nodeID,
+		KBID:     kbID,
+		State:    collab.EncodeUpdates(updates),
+		Revision: revision,
+	})
+}
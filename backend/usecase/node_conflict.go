@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/merge"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+// BuildNodeConflict 以提交时的修订为共同祖先，合并本次修改和当前版本
+func (u *NodeUsecase) BuildNodeConflict(ctx context.Context, req *domain.UpdateNodeReq, conflict *domain.NodeConflictError) (*v1.NodeConflictResp, error) {
+	current := conflict.Current
+	resp := &v1.NodeConflictResp{
+		ID:              current.ID,
+		BaseRevision:    conflict.BaseRevision,
+		CurrentRevision: current.Revision,
+		EditorId:        current.EditorId,
+		EditTime:        current.EditTime,
+		Current: v1.NodeConflictFields{
+			Name:    current.Name,
+			Content: current.Content,
+		},
+	}
+	if editor, err := u.userRepo.GetUser(ctx, current.EditorId); err == nil {
+		resp.EditorAccount = editor.Account
+	} else {
+		u.logger.Warn("get node editor failed", log.String("user_id", current.EditorId), log.Error(err))
+	}
+
+	base, err := u.nodeRepo.GetNodeRevision(ctx, current.ID, conflict.BaseRevision)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// 祖先已被清理时只能两两比较，双方不同的部分都作为冲突
+		base = &domain.NodeRevision{}
+		resp.Merged.BaseMissing = true
+	}
+
+	ours := v1.NodeConflictFields{
+		Name:    lo.FromPtrOr(req.Name, base.Name),
+		Content: lo.FromPtrOr(req.Content, base.Content),
+	}
+	if resp.Merged.BaseMissing {
+		ours.Name = lo.FromPtrOr(req.Name, current.Name)
+		ours.Content = lo.FromPtrOr(req.Content, current.Content)
+	}
+
+	// 名称不做逐行合并，双方都修改时以当前版本为准并计为冲突
+	switch {
+	case ours.Name == base.Name || ours.Name == current.Name:
+		resp.Merged.Name = current.Name
+	case current.Name == base.Name:
+		resp.Merged.Name = ours.Name
+	default:
+		resp.Merged.Name = current.Name
+		resp.Merged.Conflicts++
+	}
+
+	split := merge.SplitLines
+	if current.Meta.ContentType != domain.ContentTypeMD && utils.IsLikelyHTML(current.Content) {
+		split = merge.SplitHTML
+	}
+	merged := merge.Merge(base.Content, ours.Content, current.Content, split)
+	resp.Merged.Content = merged.Text
+	resp.Merged.Conflicts += merged.Conflicts
+	return resp, nil
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+func (u *NodeUsecase) GetDeadLetterList(ctx context.Context, req *v1.NodeDeadLetterListReq) (*domain.PaginatedResult[[]*domain.MQDeadLetter], error) {
+	deadLetters, total, err := u.deadLetterRepo.GetList(ctx, req.KbID, req.Offset(), req.Limit())
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(deadLetters, uint64(total)), nil
+}
+
+// RedriveDeadLetters 将死信重新投递到原主题，投递成功后删除死信记录
+func (u *NodeUsecase) RedriveDeadLetters(ctx context.Context, req *v1.NodeDeadLetterRedriveReq) error {
+	deadLetters, err := u.deadLetterRepo.GetByIDs(ctx, req.KbID, req.IDs)
+	if err != nil {
+		return err
+	}
+	if len(deadLetters) == 0 {
+		return fmt.Errorf("dead letter not found")
+	}
+
+	for _, deadLetter := range deadLetters {
+		if err := u.ragRepo.Republish(ctx, deadLetter.Topic, []byte(deadLetter.Payload)); err != nil {
+			u.logger.Error("redrive dead letter failed", log.String("id", deadLetter.ID), log.Error(err))
+			return fmt.Errorf("redrive dead letter failed: %w", err)
+		}
+		if err := u.deadLetterRepo.Delete(ctx, deadLetter.ID); err != nil {
+			return err
+		}
+		if deadLetter.NodeID != "" {
+			if err := u.nodeRepo.UpdateNodeByKbID(ctx, deadLetter.NodeID, req.KbID, map[string]interface{}{
+				"rag_info": domain.RagInfo{
+					Status:   consts.NodeRagStatusPending,
+					SyncedAt: time.Now(),
+				},
+			}); err != nil {
+				u.logger.Error("reset node rag status failed", log.String("node_id", deadLetter.NodeID), log.Error(err))
+			}
+		}
+	}
+	return nil
+}
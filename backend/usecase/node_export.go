@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/docexport"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	// 单次导出的节点数上限
+	nodeExportMaxNodes = 500
+	// 超过该大小的图片不嵌入，保留原地址
+	nodeExportMaxImageSize = 20 << 20
+)
+
+type NodeExportUsecase struct {
+	nodeRepo    *pg.NodeRepository
+	objectStore s3.ObjectStore
+	config      *config.Config
+	logger      *log.Logger
+}
+
+func NewNodeExportUsecase(
+	nodeRepo *pg.NodeRepository,
+	objectStore s3.ObjectStore,
+	config *config.Config,
+	logger *log.Logger,
+) *NodeExportUsecase {
+	return &NodeExportUsecase{
+		nodeRepo:    nodeRepo,
+		objectStore: objectStore,
+		config:      config,
+		logger:      logger.WithModule("usecase.node_export"),
+	}
+}
+
+// Export 导出文档或文件夹及其子节点，结果写入临时文件，关闭后删除；返回文件、大小和文件名
+func (u *NodeExportUsecase) Export(ctx context.Context, req *v1.NodeExportReq) (io.ReadCloser, int64, string, error) {
+	format := docexport.Format(req.Format)
+	root, err := u.nodeRepo.GetNodeByID(ctx, req.ID)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("get node failed: %w", err)
+	}
+	if root.KBID != req.KbID {
+		return nil, 0, "", errors.New("node not found")
+	}
+	ids := u.nodeRepo.GetSubtreeNodeIDs(ctx, req.KbID, root.ID)
+	if len(ids) > nodeExportMaxNodes {
+		return nil, 0, "", fmt.Errorf("too many nodes to export: %d, limit %d", len(ids), nodeExportMaxNodes)
+	}
+	nodes, err := u.nodeRepo.GetNodesByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("get nodes failed: %w", err)
+	}
+
+	// 按目录树先序排列，层级决定标题级别
+	children := make(map[string][]*domain.Node)
+	for _, node := range nodes {
+		if node.ID != root.ID {
+			children[node.ParentID] = append(children[node.ParentID], node)
+		}
+	}
+	for _, list := range children {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Position < list[j].Position })
+	}
+	doc := &docexport.Document{Title: root.Name}
+	var walk func(node *domain.Node, depth int)
+	walk = func(node *domain.Node, depth int) {
+		content := node.Content
+		if node.Type == domain.NodeTypeFolder {
+			content = ""
+		} else if node.Meta.ContentType == domain.ContentTypeMD || !utils.IsLikelyHTML(content) {
+			content = convertMDToHTML(content)
+		}
+		doc.Sections = append(doc.Sections, &docexport.Section{Title: node.Name, Depth: depth, Content: content})
+		for _, child := range children[node.ID] {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 1)
+
+	opts := &docexport.Options{Images: u.imageLoader(ctx)}
+	if format == docexport.FormatPDF {
+		if opts.Font, err = os.ReadFile(u.config.Export.PDFFont); err != nil {
+			return nil, 0, "", fmt.Errorf("read pdf font failed: %w", err)
+		}
+	}
+
+	file, err := os.CreateTemp("", "node-export-*"+format.Ext())
+	if err != nil {
+		return nil, 0, "", err
+	}
+	reader := &tempFileReader{File: file}
+	if err := docexport.Write(file, format, doc, opts); err != nil {
+		reader.Close()
+		return nil, 0, "", fmt.Errorf("export node failed: %w", err)
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		reader.Close()
+		return nil, 0, "", err
+	}
+	u.logger.Info("export node success", log.String("kb_id", req.KbID), log.String("node_id", root.ID), log.String("format", req.Format), log.Int("nodes", len(doc.Sections)), log.Int64("size", size))
+	return reader, size, strings.TrimSpace(root.Name) + format.Ext(), nil
+}
+
+// imageLoader 只嵌入上传到对象存储的图片，其他地址保留原样
+func (u *NodeExportUsecase) imageLoader(ctx context.Context) docexport.ImageLoader {
+	return func(src string) (*docexport.Image, error) {
+		match := exportFileURLRegexp.FindStringSubmatch(src)
+		if match == nil {
+			return nil, nil
+		}
+		key, err := url.PathUnescape(match[1])
+		if err != nil || strings.Contains(key, "..") {
+			return nil, nil
+		}
+		reader, info, err := u.objectStore.GetObject(ctx, domain.Bucket, key)
+		if err != nil {
+			if errors.Is(err, s3.ErrObjectNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		defer reader.Close()
+		if info.Size > nodeExportMaxImageSize {
+			return nil, nil
+		}
+		data, err := io.ReadAll(io.LimitReader(reader, nodeExportMaxImageSize))
+		if err != nil {
+			return nil, err
+		}
+		return &docexport.Image{ContentType: info.ContentType, Data: data}, nil
+	}
+}
+
+// tempFileReader 关闭时删除临时文件
+type tempFileReader struct {
+	*os.File
+}
+
+func (r *tempFileReader) Close() error {
+	err := r.File.Close()
+	os.Remove(r.File.Name())
+	return err
+}
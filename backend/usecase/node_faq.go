@@ -0,0 +1,386 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/mq"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+)
+
+const nodeFAQDefaultNodeName = "常见问题"
+
+type NodeFAQUsecase struct {
+	faqRepo      *pg.NodeFAQRepository
+	nodeRepo     *pg.NodeRepository
+	kbRepo       *pg.KnowledgeBaseRepository
+	appRepo      *pg.AppRepository
+	ragRepo      *mq.RAGRepository
+	rag          rag.RAGService
+	llmUsecase   *LLMUsecase
+	modelUsecase *ModelUsecase
+	logger       *log.Logger
+}
+
+func NewNodeFAQUsecase(faqRepo *pg.NodeFAQRepository, nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, appRepo *pg.AppRepository, ragRepo *mq.RAGRepository, rag rag.RAGService, llmUsecase *LLMUsecase, modelUsecase *ModelUsecase, logger *log.Logger) *NodeFAQUsecase {
+	return &NodeFAQUsecase{
+		faqRepo:      faqRepo,
+		nodeRepo:     nodeRepo,
+		kbRepo:       kbRepo,
+		appRepo:      appRepo,
+		ragRepo:      ragRepo,
+		rag:          rag,
+		llmUsecase:   llmUsecase,
+		modelUsecase: modelUsecase,
+		logger:       logger.WithModule("usecase.node_faq"),
+	}
+}
+
+// Generate 异步为文档生成待审核的问答对
+func (u *NodeFAQUsecase) Generate(ctx context.Context, req *v1.GenerateNodeFAQReq) error {
+	if _, err := u.modelUsecase.GetChatModel(ctx); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrModelNotConfigured
+		}
+		return err
+	}
+	requests := make([]*domain.NodeReleaseVectorRequest, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		requests = append(requests, &domain.NodeReleaseVectorRequest{
+			KBID:   req.KbID,
+			NodeID: id,
+			Action: "faq",
+		})
+	}
+	return u.ragRepo.AsyncUpdateNodeReleaseVector(ctx, requests)
+}
+
+// GenerateFromRelease 根据文档的发布版本生成问答对，替换原有的待审核问答对，
+// 已通过或已拒绝的问题不再重复生成
+func (u *NodeFAQUsecase) GenerateFromRelease(ctx context.Context, release *domain.NodeRelease) error {
+	if release.Type == domain.NodeTypeFolder || strings.TrimSpace(release.Content) == "" {
+		return nil
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, release.KBID)
+	if err != nil {
+		return err
+	}
+	model, err := u.modelUsecase.GetChatModel(ctx)
+	if err != nil {
+		return err
+	}
+	pairs, err := u.llmUsecase.GenerateFAQPairs(ctx, model, release.Name, release.Content, kb.FAQSetting.GetMaxPerNode())
+	if err != nil {
+		return err
+	}
+	existing, err := u.faqRepo.GetByNodeID(ctx, release.NodeID)
+	if err != nil {
+		return err
+	}
+	reviewed := make(map[string]bool)
+	for _, faq := range existing {
+		if faq.Status != domain.NodeFAQStatusPending {
+			reviewed[faq.Question] = true
+		}
+	}
+	now := time.Now()
+	faqs := make([]*domain.NodeFAQ, 0, len(pairs))
+	for _, pair := range pairs {
+		if reviewed[pair.Question] {
+			continue
+		}
+		reviewed[pair.Question] = true
+		faqs = append(faqs, &domain.NodeFAQ{
+			ID:        uuid.New().String(),
+			KBID:      release.KBID,
+			NodeID:    release.NodeID,
+			Question:  pair.Question,
+			Answer:    pair.Answer,
+			Status:    domain.NodeFAQStatusPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	if err := u.faqRepo.ReplacePending(ctx, release.NodeID, faqs); err != nil {
+		return err
+	}
+	u.logger.Info("generate node faqs", log.String("node_id", release.NodeID), log.Int("count", len(faqs)))
+	return nil
+}
+
+func (u *NodeFAQUsecase) GetList(ctx context.Context, req *v1.NodeFAQListReq) ([]*v1.NodeFAQItem, error) {
+	return u.faqRepo.GetList(ctx, req)
+}
+
+// Update 修改问答对，已写入检索记录的同时更新检索记录
+func (u *NodeFAQUsecase) Update(ctx context.Context, req *v1.UpdateNodeFAQReq) error {
+	faqs, err := u.faqRepo.GetByIDs(ctx, req.KbID, []string{req.ID})
+	if err != nil {
+		return err
+	}
+	if len(faqs) == 0 {
+		return errors.New("faq not found")
+	}
+	faq := faqs[0]
+	faq.Question = strings.TrimSpace(req.Question)
+	faq.Answer = strings.TrimSpace(req.Answer)
+	if faq.DocID != "" {
+		if err := u.deleteDocs(ctx, faq.KBID, []*domain.NodeFAQ{faq}); err != nil {
+			return err
+		}
+		if err := u.index(ctx, faq); err != nil {
+			return err
+		}
+	}
+	return u.faqRepo.Update(ctx, faq)
+}
+
+// UpdateStatus 审核问答对，通过的写入检索记录，其他状态删除检索记录
+func (u *NodeFAQUsecase) UpdateStatus(ctx context.Context, req *v1.UpdateNodeFAQStatusReq) error {
+	faqs, err := u.faqRepo.GetByIDs(ctx, req.KbID, req.IDs)
+	if err != nil {
+		return err
+	}
+	for _, faq := range faqs {
+		if faq.Status == req.Status {
+			continue
+		}
+		faq.Status = req.Status
+		if req.Status == domain.NodeFAQStatusApproved {
+			if err := u.index(ctx, faq); err != nil {
+				return err
+			}
+		} else if err := u.deleteDocs(ctx, faq.KBID, []*domain.NodeFAQ{faq}); err != nil {
+			return err
+		}
+		if err := u.faqRepo.Update(ctx, faq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *NodeFAQUsecase) Delete(ctx context.Context, req *v1.DeleteNodeFAQReq) error {
+	faqs, err := u.faqRepo.GetByIDs(ctx, req.KbID, req.IDs)
+	if err != nil {
+		return err
+	}
+	if err := u.deleteDocs(ctx, req.KbID, faqs); err != nil {
+		return err
+	}
+	return u.faqRepo.Delete(ctx, req.KbID, req.IDs)
+}
+
+// index 把问答对写入检索记录，来源文档尚未写入检索记录时跳过，等文档发布后再写入
+func (u *NodeFAQUsecase) index(ctx context.Context, faq *domain.NodeFAQ) error {
+	releases, err := u.nodeRepo.GetLatestNodeReleaseByNodeIDs(ctx, faq.KBID, []string{faq.NodeID})
+	if err != nil {
+		return err
+	}
+	if len(releases) == 0 || releases[0].DocID == "" {
+		return nil
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, faq.KBID)
+	if err != nil {
+		return err
+	}
+	groupIDs, err := u.nodeRepo.GetNodeAuthGroupIdsByNodeId(ctx, faq.NodeID, consts.NodePermNameAnswerable)
+	if err != nil {
+		return err
+	}
+	return u.upsertDoc(ctx, kb.DatasetID, faq, releases[0].DocID, groupIDs)
+}
+
+func (u *NodeFAQUsecase) upsertDoc(ctx context.Context, datasetID string, faq *domain.NodeFAQ, parentDocID string, groupIDs []int) error {
+	docID, err := u.rag.UpsertRecords(ctx, &rag.UpsertRecordsRequest{
+		ID:        uuid.New().String(),
+		DatasetID: datasetID,
+		Title:     faq.Question,
+		Content:   faq.RetrievalContent(),
+		GroupIDs:  groupIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("upsert faq record failed: %w", err)
+	}
+	faq.DocID = docID
+	faq.ParentDocID = parentDocID
+	return nil
+}
+
+func (u *NodeFAQUsecase) deleteDocs(ctx context.Context, kbID string, faqs []*domain.NodeFAQ) error {
+	faqs = lo.Filter(faqs, func(faq *domain.NodeFAQ, _ int) bool { return faq.DocID != "" })
+	if len(faqs) == 0 {
+		return nil
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	docIDs := lo.Map(faqs, func(faq *domain.NodeFAQ, _ int) string { return faq.DocID })
+	if err := u.rag.DeleteRecords(ctx, kb.DatasetID, docIDs); err != nil {
+		return fmt.Errorf("delete faq records failed: %w", err)
+	}
+	for _, faq := range faqs {
+		faq.DocID = ""
+		faq.ParentDocID = ""
+	}
+	return nil
+}
+
+// IndexNodeFAQs 文档发布后同步已通过问答对的检索记录：已写入的改为归属新版本并同步权限分组，未写入的补充写入
+func (u *NodeFAQUsecase) IndexNodeFAQs(ctx context.Context, datasetID string, release *domain.NodeRelease, groupIDs []int) error {
+	faqs, err := u.faqRepo.GetByNodeID(ctx, release.NodeID)
+	if err != nil {
+		return err
+	}
+	if err := u.faqRepo.UpdateDocsParent(ctx, release.NodeID, release.DocID); err != nil {
+		return err
+	}
+	for _, faq := range faqs {
+		if faq.Status != domain.NodeFAQStatusApproved {
+			continue
+		}
+		if faq.DocID != "" {
+			if err := u.rag.UpdateDocumentGroupIDs(ctx, datasetID, faq.DocID, groupIDs); err != nil {
+				return fmt.Errorf("update faq doc group ids failed: %w", err)
+			}
+			continue
+		}
+		if err := u.upsertDoc(ctx, datasetID, faq, release.DocID, groupIDs); err != nil {
+			return err
+		}
+		if err := u.faqRepo.Update(ctx, faq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateNodeFAQGroupIDs 文档权限变化时同步问答对记录的权限分组
+func (u *NodeFAQUsecase) UpdateNodeFAQGroupIDs(ctx context.Context, datasetID, parentDocID string, groupIDs []int) error {
+	faqs, err := u.faqRepo.GetByParentDocID(ctx, parentDocID)
+	if err != nil {
+		return err
+	}
+	for _, faq := range faqs {
+		if err := u.rag.UpdateDocumentGroupIDs(ctx, datasetID, faq.DocID, groupIDs); err != nil {
+			return fmt.Errorf("update faq doc group ids failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteNodeFAQDocs 文档删除时删除问答对的检索记录，问答对保留，文档恢复并发布后重新写入
+func (u *NodeFAQUsecase) DeleteNodeFAQDocs(ctx context.Context, datasetID, parentDocID string) error {
+	faqs, err := u.faqRepo.GetByParentDocID(ctx, parentDocID)
+	if err != nil {
+		return err
+	}
+	if len(faqs) == 0 {
+		return nil
+	}
+	docIDs := lo.Map(faqs, func(faq *domain.NodeFAQ, _ int) string { return faq.DocID })
+	if err := u.rag.DeleteRecords(ctx, datasetID, docIDs); err != nil {
+		return fmt.Errorf("delete faq records failed: %w", err)
+	}
+	return u.faqRepo.ClearDocs(ctx, lo.Map(faqs, func(faq *domain.NodeFAQ, _ int) string { return faq.ID }))
+}
+
+// Promote 把问答对整理成常见问题文档，或加入网页首页的常见问题模块
+func (u *NodeFAQUsecase) Promote(ctx context.Context, req *v1.PromoteNodeFAQReq, userID string, maxNode int) (*v1.PromoteNodeFAQResp, error) {
+	faqs, err := u.faqRepo.GetByIDs(ctx, req.KbID, req.IDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(faqs) == 0 {
+		return nil, errors.New("faq not found")
+	}
+	if req.Target == "landing" {
+		return &v1.PromoteNodeFAQResp{}, u.promoteToLanding(ctx, req.KbID, faqs)
+	}
+
+	nodes, err := u.nodeRepo.GetNodesByIDs(ctx, lo.Uniq(lo.Map(faqs, func(faq *domain.NodeFAQ, _ int) string { return faq.NodeID })))
+	if err != nil {
+		return nil, err
+	}
+	var content strings.Builder
+	for _, faq := range faqs {
+		content.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", faq.Question, faq.Answer))
+		if node, ok := nodes[faq.NodeID]; ok {
+			content.WriteString(fmt.Sprintf("参考文档：[%s](/node/%s)\n\n", node.Name, node.ID))
+		}
+	}
+	name := req.Name
+	if name == "" {
+		name = nodeFAQDefaultNodeName
+	}
+	contentType := domain.ContentTypeMD
+	nodeID, err := u.nodeRepo.Create(ctx, &domain.CreateNodeReq{
+		KBID:        req.KbID,
+		NavId:       req.NavID,
+		ParentID:    req.ParentID,
+		Type:        domain.NodeTypeDocument,
+		Name:        name,
+		Content:     content.String(),
+		ContentType: &contentType,
+		MaxNode:     maxNode,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PromoteNodeFAQResp{NodeID: nodeID}, nil
+}
+
+// promoteToLanding 问题追加到首页第一个常见问题模块，没有时新建模块，链接指向来源文档
+func (u *NodeFAQUsecase) promoteToLanding(ctx context.Context, kbID string, faqs []*domain.NodeFAQ) error {
+	app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, kbID, domain.AppTypeWeb)
+	if err != nil {
+		return err
+	}
+	configs := app.Settings.WebAppLandingConfigs
+	_, idx, ok := lo.FindIndexOf(configs, func(config domain.WebAppLandingConfig) bool { return config.FaqConfig != nil })
+	if !ok {
+		configs = append(configs, domain.WebAppLandingConfig{
+			Type:      "faq",
+			FaqConfig: &domain.FaqConfig{Title: nodeFAQDefaultNodeName},
+		})
+		idx = len(configs) - 1
+	}
+	faqConfig := configs[idx].FaqConfig
+	for _, faq := range faqs {
+		if lo.ContainsBy(faqConfig.List, func(item domain.FaqConfigItem) bool { return item.ID == faq.ID }) {
+			continue
+		}
+		faqConfig.List = append(faqConfig.List, domain.FaqConfigItem{
+			ID:       faq.ID,
+			Question: faq.Question,
+			Link:     "/node/" + faq.NodeID,
+		})
+	}
+	app.Settings.WebAppLandingConfigs = configs
+	return u.appRepo.UpdateApp(ctx, app.ID, kbID, &domain.UpdateAppReq{Settings: &app.Settings})
+}
+
+func (u *NodeFAQUsecase) GetSetting(ctx context.Context, kbID string) (*domain.NodeFAQSetting, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &kb.FAQSetting, nil
+}
+
+func (u *NodeFAQUsecase) UpdateSetting(ctx context.Context, req *v1.UpdateNodeFAQSettingReq) error {
+	return u.kbRepo.UpdateFAQSetting(ctx, req.KbID, &req.NodeFAQSetting)
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	linkSyncBatchSize = 100
+	// 外部链接每天检查一次
+	linkCheckInterval    = 20 * time.Hour
+	linkCheckBatchSize   = 200
+	linkCheckConcurrency = 8
+	linkCheckTimeout     = 15 * time.Second
+)
+
+var errLinkCheckPrivateAddr = errors.New("private or reserved address")
+
+type NodeLinkUsecase struct {
+	linkRepo   *pg.NodeLinkRepository
+	nodeRepo   *pg.NodeRepository
+	logger     *log.Logger
+	httpClient *http.Client
+}
+
+func NewNodeLinkUsecase(linkRepo *pg.NodeLinkRepository, nodeRepo *pg.NodeRepository, logger *log.Logger) *NodeLinkUsecase {
+	// 只访问公网地址，重定向后的地址同样在建立连接时检查
+	dialer := &net.Dialer{
+		Timeout: linkCheckTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if utils.IsPrivateOrReservedIP(host) {
+				return errLinkCheckPrivateAddr
+			}
+			return nil
+		},
+	}
+	return &NodeLinkUsecase{
+		linkRepo: linkRepo,
+		nodeRepo: nodeRepo,
+		logger:   logger.WithModule("usecase.node_link"),
+		httpClient: &http.Client{
+			Timeout:   linkCheckTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+	}
+}
+
+// SyncReleaseLinks 解析发布版本中的链接，替换对应文档的链接记录
+func (u *NodeLinkUsecase) SyncReleaseLinks(ctx context.Context, kbID string, releaseIDs []string) error {
+	for _, ids := range lo.Chunk(releaseIDs, linkSyncBatchSize) {
+		releases, err := u.nodeRepo.GetNodeReleasesByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		releaseLinks := make(map[string][]string, len(releases))
+		var targetIDs []string
+		for _, release := range releases {
+			links := domain.ExtractLinks(release.Content)
+			releaseLinks[release.NodeID] = links
+			for _, link := range links {
+				if nodeID, ok := domain.ParseNodeURL(link); ok {
+					targetIDs = append(targetIDs, nodeID)
+				}
+			}
+		}
+		targets, err := u.nodeRepo.GetNodesByIDs(ctx, lo.Uniq(targetIDs))
+		if err != nil {
+			return err
+		}
+		for nodeID, links := range releaseLinks {
+			nodeLinks := make([]*domain.NodeLink, 0, len(links))
+			for _, link := range links {
+				nodeLink := &domain.NodeLink{KBID: kbID, SourceNodeID: nodeID, URL: link}
+				// 相对地址一定指向本站，带站点地址时只有本知识库的文档算作站内链接
+				if targetID, ok := domain.ParseNodeURL(link); ok {
+					if target, exists := targets[targetID]; strings.HasPrefix(link, "/") || (exists && target.KBID == kbID) {
+						nodeLink.TargetNodeID = targetID
+					}
+				}
+				nodeLinks = append(nodeLinks, nodeLink)
+			}
+			if err := u.linkRepo.ReplaceNodeLinks(ctx, nodeID, nodeLinks); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *NodeLinkUsecase) GetBacklinks(ctx context.Context, req *v1.NodeBacklinksReq) ([]*v1.NodeBacklinkItem, error) {
+	return u.linkRepo.GetBacklinks(ctx, req.KbID, req.ID)
+}
+
+func (u *NodeLinkUsecase) GetLinkGraph(ctx context.Context, req *v1.NodeLinkGraphReq) (*v1.NodeLinkGraphResp, error) {
+	return u.linkRepo.GetLinkGraph(ctx, req.KbID)
+}
+
+func (u *NodeLinkUsecase) GetBrokenLinks(ctx context.Context, req *v1.BrokenLinkListReq) ([]*v1.BrokenLinkItem, error) {
+	return u.linkRepo.GetBrokenLinks(ctx, req.KbID)
+}
+
+// RewriteBaseURL 站点地址变更后，把文档中带旧站点地址的站内链接改为新地址，返回修改的文档 ID
+func (u *NodeLinkUsecase) RewriteBaseURL(ctx context.Context, kbID, oldBaseURL, newBaseURL, userID string) ([]string, error) {
+	from := strings.TrimRight(oldBaseURL, "/") + "/node/"
+	to := strings.TrimRight(newBaseURL, "/") + "/node/"
+	nodeIDs, err := u.nodeRepo.ReplaceContentLinks(ctx, kbID, from, to, userID)
+	if err != nil {
+		return nil, err
+	}
+	u.logger.Info("rewrite node links", log.String("kb_id", kbID), log.String("from", from), log.String("to", to), log.Int("nodes", len(nodeIDs)))
+	return nodeIDs, nil
+}
+
+// CheckExternalLinks 检查所有知识库的外部链接，结果按地址记录
+func (u *NodeLinkUsecase) CheckExternalLinks(ctx context.Context) error {
+	before := time.Now().Add(-linkCheckInterval)
+	var checked, broken int
+	for {
+		urls, err := u.linkRepo.GetLinkCheckURLs(ctx, before, linkCheckBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			break
+		}
+		checks := make([]*domain.LinkCheck, len(urls))
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(linkCheckConcurrency)
+		for i, url := range urls {
+			g.Go(func() error {
+				checks[i] = u.checkLink(gctx, url)
+				return nil
+			})
+		}
+		_ = g.Wait()
+		if err := u.linkRepo.UpsertLinkChecks(ctx, checks); err != nil {
+			return err
+		}
+		checked += len(checks)
+		broken += lo.CountBy(checks, func(check *domain.LinkCheck) bool { return check.Status == domain.LinkCheckStatusBroken })
+	}
+	if err := u.linkRepo.DeleteUnusedLinkChecks(ctx); err != nil {
+		return err
+	}
+	u.logger.Info("check external links", log.Int("checked", checked), log.Int("broken", broken))
+	return nil
+}
+
+// checkLink 先用 HEAD 请求，服务端不支持时改用 GET；404、410 和 5xx 视为失效
+func (u *NodeLinkUsecase) checkLink(ctx context.Context, url string) *domain.LinkCheck {
+	check := &domain.LinkCheck{URL: url, CheckedAt: time.Now()}
+	statusCode, err := u.request(ctx, http.MethodHead, url)
+	if err == nil && (statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented || statusCode == http.StatusForbidden) {
+		statusCode, err = u.request(ctx, http.MethodGet, url)
+	}
+	check.StatusCode = statusCode
+	switch {
+	case errors.Is(err, errLinkCheckPrivateAddr):
+		check.Status = domain.LinkCheckStatusSkipped
+		check.Error = err.Error()
+	case err != nil:
+		check.Status = domain.LinkCheckStatusBroken
+		check.Error = err.Error()
+	case statusCode == http.StatusNotFound || statusCode == http.StatusGone || statusCode >= http.StatusInternalServerError:
+		check.Status = domain.LinkCheckStatusBroken
+		check.Error = http.StatusText(statusCode)
+	default:
+		check.Status = domain.LinkCheckStatusOK
+	}
+	return check
+}
+
+func (u *NodeLinkUsecase) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "PandaWiki-LinkChecker/1.0")
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// checkNodeReferences 删除或移动前检查子树外的文档是否链接到子树中的文档
+func (u *NodeUsecase) checkNodeReferences(ctx context.Context, kbID string, ids []string) error {
+	subtree := make([]string, 0, len(ids))
+	for _, id := range ids {
+		subtree = append(subtree, u.nodeRepo.GetSubtreeNodeIDs(ctx, kbID, id)...)
+	}
+	refs, err := u.linkRepo.GetReferences(ctx, kbID, lo.Uniq(subtree))
+	if err != nil {
+		return fmt.Errorf("get node references failed: %w", err)
+	}
+	if len(refs) > 0 {
+		return &domain.NodeReferencedError{References: refs}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func (u *NodeUsecase) GetNodeMetaSchema(ctx context.Context, kbID string) (*domain.NodeMetaSchema, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &kb.NodeMetaSchema, nil
+}
+
+// UpdateNodeMetaSchema 更新标签词表和字段定义，已有文档上的取值保持不变
+func (u *NodeUsecase) UpdateNodeMetaSchema(ctx context.Context, req *v1.UpdateNodeMetaSchemaReq) error {
+	if err := req.NodeMetaSchema.Validate(); err != nil {
+		return err
+	}
+	return u.kbRepo.UpdateNodeMetaSchema(ctx, req.KbID, &req.NodeMetaSchema)
+}
+
+// validateNodeMeta 按知识库的定义校验文档的标签和字段
+func (u *NodeUsecase) validateNodeMeta(ctx context.Context, kbID string, tags *[]string, fields *map[string]string) error {
+	if tags == nil && fields == nil {
+		return nil
+	}
+	schema, err := u.GetNodeMetaSchema(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if tags != nil {
+		if err := schema.ValidateTags(*tags); err != nil {
+			return err
+		}
+	}
+	if fields == nil {
+		return nil
+	}
+	values := make(map[string]string, len(*fields))
+	for key, value := range *fields {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	if err := schema.ValidateFields(values); err != nil {
+		return err
+	}
+	for key, value := range values {
+		if field, _ := schema.Field(key); field.Type == domain.NodeFieldTypeUser {
+			if _, err := u.userRepo.GetUser(ctx, value); err != nil {
+				return fmt.Errorf("field %s: user not found", key)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// TouchPresence 上报心跳，编辑中的用户尝试获取编辑锁，锁只做提示不阻止保存
+func (u *NodeUsecase) TouchPresence(ctx context.Context, req *v1.NodePresenceReq, userId string) (*v1.NodePresenceResp, error) {
+	user, err := u.userRepo.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	editing := false
+	if req.Editing {
+		if editing, err = u.presenceRepo.AcquireEditLock(ctx, req.ID, userId); err != nil {
+			return nil, err
+		}
+	} else if err := u.presenceRepo.ReleaseEditLock(ctx, req.ID, userId); err != nil {
+		return nil, err
+	}
+	if err := u.presenceRepo.Touch(ctx, req.ID, &domain.NodePresence{
+		UserID:    userId,
+		Account:   user.Account,
+		Editing:   editing,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return u.getPresence(ctx, req.ID, userId)
+}
+
+func (u *NodeUsecase) LeavePresence(ctx context.Context, req *v1.NodePresenceReq, userId string) error {
+	if err := u.presenceRepo.ReleaseEditLock(ctx, req.ID, userId); err != nil {
+		return err
+	}
+	return u.presenceRepo.Leave(ctx, req.ID, userId)
+}
+
+func (u *NodeUsecase) GetPresence(ctx context.Context, req *v1.NodePresenceReq, userId string) (*v1.NodePresenceResp, error) {
+	return u.getPresence(ctx, req.ID, userId)
+}
+
+func (u *NodeUsecase) getPresence(ctx context.Context, nodeID, userId string) (*v1.NodePresenceResp, error) {
+	users, err := u.presenceRepo.List(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	holder, err := u.presenceRepo.GetEditLockHolder(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.NodePresenceResp{
+		Users:  users,
+		Locked: holder != "" && holder == userId,
+	}
+	if holder == "" {
+		return resp, nil
+	}
+	for _, user := range users {
+		if user.UserID == holder {
+			resp.LockHolder = user
+			return resp, nil
+		}
+	}
+	resp.LockHolder = &domain.NodePresence{UserID: holder, Editing: true}
+	if user, err := u.userRepo.GetUser(ctx, holder); err == nil {
+		resp.LockHolder.Account = user.Account
+	}
+	return resp, nil
+}
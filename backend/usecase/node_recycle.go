@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+func (u *NodeUsecase) GetRecycleList(ctx context.Context, req *v1.NodeRecycleListReq) (*domain.PaginatedResult[[]*v1.NodeRecycleListItem], error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, req.KbID)
+	if err != nil {
+		return nil, err
+	}
+	items, total, err := u.nodeRepo.GetRecycleList(ctx, req.KbID, req.Offset(), req.Limit())
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.ExpiredAt = domain.RecycleExpiredAt(item.DeletedAt, kb.RecycleRetentionDays)
+	}
+	return domain.NewPaginatedResult(items, uint64(total)), nil
+}
+
+// RestoreRecycle 恢复回收站中的子树，并重新学习恢复的发布版本
+func (u *NodeUsecase) RestoreRecycle(ctx context.Context, req *v1.NodeRecycleRestoreReq) error {
+	snapshot, err := u.nodeRepo.RestoreRecycle(ctx, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("recycle item not found")
+		}
+		return err
+	}
+
+	// 每个文档只需学习最新的发布版本
+	latest := make(map[string]*domain.NodeRelease)
+	for _, release := range snapshot.Releases {
+		if release.Type != domain.NodeTypeDocument {
+			continue
+		}
+		if prev, ok := latest[release.NodeID]; !ok || release.UpdatedAt.After(prev.UpdatedAt) {
+			latest[release.NodeID] = release
+		}
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+	requests := make([]*domain.NodeReleaseVectorRequest, 0, len(latest))
+	for _, release := range latest {
+		requests = append(requests, &domain.NodeReleaseVectorRequest{
+			KBID:          req.KbID,
+			NodeReleaseID: release.ID,
+			Action:        "upsert",
+		})
+	}
+	return u.ragRepo.AsyncUpdateNodeReleaseVector(ctx, requests)
+}
+
+func (u *NodeUsecase) PurgeRecycles(ctx context.Context, req *v1.NodeRecyclePurgeReq) error {
+	return u.nodeRepo.PurgeRecycles(ctx, req.KbID, req.IDs)
+}
+
+func (u *NodeUsecase) UpdateRecycleRetention(ctx context.Context, req *v1.NodeRecycleRetentionReq) error {
+	return u.kbRepo.UpdateRecycleRetentionDays(ctx, req.KbID, req.Days)
+}
+
+// CleanupExpiredRecycles 清理超过保留天数的回收站记录
+func (u *NodeUsecase) CleanupExpiredRecycles(ctx context.Context) error {
+	count, err := u.nodeRepo.DeleteExpiredRecycles(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		u.logger.Info("purged expired recycles", log.Int("count", count))
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+const (
+	// 发布不足 30 天的文档不计入无人访问
+	nodeNeverViewedGraceDays = 30
+	// 近 90 天没用的反馈不少于 3 次且多于有用的反馈
+	nodeLowRatedDays         = 90
+	nodeLowRatedMinUnhelpful = 3
+)
+
+type NodeReviewUsecase struct {
+	reviewRepo        *pg.NodeReviewRepository
+	nodeRepo          *pg.NodeRepository
+	kbRepo            *pg.KnowledgeBaseRepository
+	userRepo          *pg.UserRepository
+	statRepo          *pg.StatRepository
+	systemSettingRepo *pg.SystemSettingRepo
+	logger            *log.Logger
+}
+
+func NewNodeReviewUsecase(reviewRepo *pg.NodeReviewRepository, nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, userRepo *pg.UserRepository, statRepo *pg.StatRepository, systemSettingRepo *pg.SystemSettingRepo, logger *log.Logger) *NodeReviewUsecase {
+	return &NodeReviewUsecase{
+		reviewRepo:        reviewRepo,
+		nodeRepo:          nodeRepo,
+		kbRepo:            kbRepo,
+		userRepo:          userRepo,
+		statRepo:          statRepo,
+		systemSettingRepo: systemSettingRepo,
+		logger:            logger.WithModule("usecase.node_review"),
+	}
+}
+
+// nodeReviewState 知识库的目录结构、复查设置和继承后的结果
+type nodeReviewState struct {
+	nodes    []*domain.NodeReviewNode
+	reviews  map[string]*domain.NodeReview
+	policies map[string]*domain.NodeReviewPolicy
+	accounts map[string]string
+}
+
+func (u *NodeReviewUsecase) loadState(ctx context.Context, kbID string) (*nodeReviewState, error) {
+	nodes, err := u.reviewRepo.GetReviewNodes(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := u.reviewRepo.GetReviews(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := u.userRepo.GetUsersAccountMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeReviewState{
+		nodes:    nodes,
+		reviews:  reviews,
+		policies: domain.ResolveNodeReviewPolicies(nodes, reviews),
+		accounts: accounts,
+	}, nil
+}
+
+func (s *nodeReviewState) reportItem(node *domain.NodeReviewNode) v1.NodeReportItem {
+	ownerID := s.policies[node.ID].OwnerID
+	return v1.NodeReportItem{
+		ID:           node.ID,
+		Name:         node.Name,
+		OwnerID:      ownerID,
+		OwnerAccount: s.accounts[ownerID],
+		EditTime:     node.EditTime,
+	}
+}
+
+// staleNodes 已发布且超过复查期限的文档，按逾期时间倒序
+func (s *nodeReviewState) staleNodes(now time.Time) []*v1.StaleNodeItem {
+	items := make([]*v1.StaleNodeItem, 0)
+	for _, node := range s.nodes {
+		if node.Type != domain.NodeTypeDocument || node.Status == domain.NodeStatusUnreleased {
+			continue
+		}
+		policy := s.policies[node.ID]
+		review := s.reviews[node.ID]
+		lastReviewedAt, dueAt := domain.ReviewDueAt(node, review, policy.IntervalDays)
+		if dueAt == nil || now.Before(*dueAt) {
+			continue
+		}
+		item := &v1.StaleNodeItem{
+			NodeReportItem: s.reportItem(node),
+			IntervalDays:   policy.IntervalDays,
+			LastReviewedAt: lastReviewedAt,
+			DueAt:          *dueAt,
+			OverdueDays:    int(now.Sub(*dueAt).Hours() / 24),
+		}
+		if review != nil {
+			item.RemindedAt = review.RemindedAt
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DueAt.Before(items[j].DueAt)
+	})
+	return items
+}
+
+func (u *NodeReviewUsecase) GetNodeReview(ctx context.Context, req *v1.NodeReviewReq) (*v1.NodeReviewResp, error) {
+	state, err := u.loadState(ctx, req.KbID)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := lo.Find(state.nodes, func(node *domain.NodeReviewNode) bool { return node.ID == req.ID })
+	if !ok {
+		return nil, errors.New("node not found")
+	}
+	policy := state.policies[node.ID]
+	review := state.reviews[node.ID]
+	resp := &v1.NodeReviewResp{
+		Effective:    *policy,
+		OwnerAccount: state.accounts[policy.OwnerID],
+	}
+	if review != nil {
+		resp.OwnerID = review.OwnerID
+		resp.IntervalDays = review.IntervalDays
+		resp.ReviewedAt = review.ReviewedAt
+	}
+	resp.LastReviewedAt, resp.DueAt = domain.ReviewDueAt(node, review, policy.IntervalDays)
+	resp.Overdue = node.Type == domain.NodeTypeDocument && resp.DueAt != nil && time.Now().After(*resp.DueAt)
+	return resp, nil
+}
+
+// UpdateNodeReview 设置节点的负责人和复查周期，文件夹的设置由下级节点继承
+func (u *NodeReviewUsecase) UpdateNodeReview(ctx context.Context, req *v1.UpdateNodeReviewReq) error {
+	if err := u.checkNode(ctx, req.KbID, req.ID); err != nil {
+		return err
+	}
+	if req.OwnerID != "" {
+		if _, err := u.userRepo.GetUser(ctx, req.OwnerID); err != nil {
+			return fmt.Errorf("owner not found: %w", err)
+		}
+	}
+	return u.reviewRepo.UpsertPolicy(ctx, &domain.NodeReview{
+		NodeID:       req.ID,
+		KBID:         req.KbID,
+		OwnerID:      req.OwnerID,
+		IntervalDays: req.IntervalDays,
+	})
+}
+
+// MarkReviewed 确认文档内容仍然有效，从当前时间重新计算复查期限
+func (u *NodeReviewUsecase) MarkReviewed(ctx context.Context, req *v1.MarkNodeReviewedReq) error {
+	if err := u.checkNode(ctx, req.KbID, req.ID); err != nil {
+		return err
+	}
+	return u.reviewRepo.MarkReviewed(ctx, req.KbID, req.ID, time.Now())
+}
+
+func (u *NodeReviewUsecase) checkNode(ctx context.Context, kbID, nodeID string) error {
+	node, err := u.nodeRepo.GetNodeByID(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if node.KBID != kbID {
+		return errors.New("node not found")
+	}
+	return nil
+}
+
+func (u *NodeReviewUsecase) GetSetting(ctx context.Context, kbID string) (*domain.NodeReviewSetting, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &kb.ReviewSetting, nil
+}
+
+func (u *NodeReviewUsecase) UpdateSetting(ctx context.Context, req *v1.UpdateNodeReviewSettingReq) error {
+	if err := validateNotifyChannels(req.Channels); err != nil {
+		return err
+	}
+	return u.kbRepo.UpdateReviewSetting(ctx, req.KbID, &req.NodeReviewSetting)
+}
+
+// GetReport 过期、发布后无人访问和评价较差的文档
+func (u *NodeReviewUsecase) GetReport(ctx context.Context, kbID string) (*v1.NodeReviewReportResp, error) {
+	state, err := u.loadState(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	resp := &v1.NodeReviewReportResp{
+		Stale:       state.staleNodes(now),
+		NeverViewed: make([]*v1.NodeReportItem, 0),
+		LowRated:    make([]*v1.LowRatedNodeItem, 0),
+	}
+
+	viewedIDs, err := u.reviewRepo.GetViewedNodeIDs(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	viewed := lo.SliceToMap(viewedIDs, func(id string) (string, bool) { return id, true })
+	graceStart := now.AddDate(0, 0, -nodeNeverViewedGraceDays)
+	docs := make(map[string]*domain.NodeReviewNode)
+	for _, node := range state.nodes {
+		if node.Type != domain.NodeTypeDocument || node.Status == domain.NodeStatusUnreleased {
+			continue
+		}
+		docs[node.ID] = node
+		if !viewed[node.ID] && node.CreatedAt.Before(graceStart) {
+			item := state.reportItem(node)
+			resp.NeverViewed = append(resp.NeverViewed, &item)
+		}
+	}
+
+	engagements, err := u.statRepo.GetNodeEngagementList(ctx, kbID, now.AddDate(0, 0, -nodeLowRatedDays))
+	if err != nil {
+		return nil, err
+	}
+	for _, engagement := range engagements {
+		node, ok := docs[engagement.NodeID]
+		if !ok || engagement.UnhelpfulCount < nodeLowRatedMinUnhelpful || engagement.UnhelpfulCount <= engagement.HelpfulCount {
+			continue
+		}
+		resp.LowRated = append(resp.LowRated, &v1.LowRatedNodeItem{
+			NodeReportItem: state.reportItem(node),
+			HelpfulCount:   engagement.HelpfulCount,
+			UnhelpfulCount: engagement.UnhelpfulCount,
+		})
+	}
+	sort.SliceStable(resp.LowRated, func(i, j int) bool {
+		return resp.LowRated[i].UnhelpfulCount-resp.LowRated[i].HelpfulCount > resp.LowRated[j].UnhelpfulCount-resp.LowRated[j].HelpfulCount
+	})
+	return resp, nil
+}
+
+// RemindStaleNodes 向开启提醒的知识库发送过期文档，同一文档按提醒间隔重复提醒，由定时任务调用
+func (u *NodeReviewUsecase) RemindStaleNodes(ctx context.Context) error {
+	kbs, err := u.kbRepo.GetReviewRemindKnowledgeBases(ctx)
+	if err != nil {
+		return err
+	}
+	for _, kb := range kbs {
+		if err := u.remindKB(ctx, kb, time.Now()); err != nil {
+			u.logger.Error("remind stale nodes failed", log.Error(err), log.String("kb_id", kb.ID))
+		}
+	}
+	return nil
+}
+
+func (u *NodeReviewUsecase) remindKB(ctx context.Context, kb *domain.KnowledgeBase, now time.Time) error {
+	if err := u.reviewRepo.DeleteOrphanReviews(ctx, kb.ID); err != nil {
+		return err
+	}
+	state, err := u.loadState(ctx, kb.ID)
+	if err != nil {
+		return err
+	}
+	// 提前一小时，避免定时任务执行时间的波动导致推迟一天
+	remindBefore := now.Add(-kb.ReviewSetting.GetRemindInterval() + time.Hour)
+	items := lo.Filter(state.staleNodes(now), func(item *v1.StaleNodeItem, _ int) bool {
+		return item.RemindedAt == nil || item.RemindedAt.Before(remindBefore)
+	})
+	if len(items) == 0 {
+		return nil
+	}
+
+	baseURL := kb.AccessSettings.GetBaseUrl()
+	title := fmt.Sprintf("%s - 待复查文档", kb.Name)
+	var errs []error
+	if len(kb.ReviewSetting.Channels) > 0 {
+		if err := sendNotify(ctx, u.systemSettingRepo, kb.ReviewSetting.Channels, title, renderStaleNodes(kb.Name, baseURL, items)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if kb.ReviewSetting.NotifyOwner {
+		for account, ownerItems := range lo.GroupBy(items, func(item *v1.StaleNodeItem) string { return item.OwnerAccount }) {
+			addr, err := mail.ParseAddress(account)
+			if err != nil || addr.Address != account {
+				continue
+			}
+			if err := sendNotifyEmail(ctx, u.systemSettingRepo, []string{account}, title, renderStaleNodes(kb.Name, baseURL, ownerItems)); err != nil {
+				errs = append(errs, fmt.Errorf("notify owner %s failed: %w", account, err))
+			}
+		}
+	}
+
+	nodeIDs := lo.Map(items, func(item *v1.StaleNodeItem, _ int) string { return item.ID })
+	if err := u.reviewRepo.UpdateRemindedAt(ctx, kb.ID, nodeIDs, now); err != nil {
+		u.logger.Warn("update node reminded at failed", log.Error(err), log.String("kb_id", kb.ID))
+	}
+	u.logger.Info("remind stale nodes", log.String("kb_id", kb.ID), log.Int("count", len(items)))
+	return errors.Join(errs...)
+}
+
+// renderStaleNodes 按负责人分组列出过期文档，邮件和群机器人共用
+func renderStaleNodes(kbName, baseURL string, items []*v1.StaleNodeItem) string {
+	groups := lo.GroupBy(items, func(item *v1.StaleNodeItem) string { return item.OwnerAccount })
+	owners := lo.Keys(groups)
+	sort.Strings(owners)
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("### %s 待复查文档\n\n", kbName))
+	sb.WriteString(fmt.Sprintf("以下 %d 篇文档已超过复查期限，请确认内容是否仍然有效，确认后在文档中标记已复查。\n\n", len(items)))
+	for _, owner := range owners {
+		if owner == "" {
+			sb.WriteString("#### 未设置负责人\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("#### 负责人：%s\n\n", owner))
+		}
+		for _, item := range groups[owner] {
+			name := item.Name
+			if baseURL != "" {
+				name = fmt.Sprintf("[%s](%s/node/%s)", name, baseURL, item.ID)
+			}
+			sb.WriteString(fmt.Sprintf("- %s：%s 到期，已逾期 %d 天\n", name, item.DueAt.Format(time.DateOnly), item.OverdueDays))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func TestNodeReviewState_StaleNodes(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	remindedAt := daysAgo(1)
+	reviewedAt := daysAgo(10)
+
+	// 目录 f 设置 30 天复查周期和负责人，子文档继承；d5 自行覆盖为 7 天
+	nodes := []*domain.NodeReviewNode{
+		{ID: "f", Type: domain.NodeTypeFolder, EditTime: daysAgo(100)},
+		{ID: "d1", ParentID: "f", Name: "overdue", Type: domain.NodeTypeDocument, Status: domain.NodeStatusPublished, EditTime: daysAgo(40)},
+		{ID: "d2", ParentID: "f", Name: "fresh", Type: domain.NodeTypeDocument, Status: domain.NodeStatusPublished, EditTime: daysAgo(5)},
+		{ID: "d3", ParentID: "f", Name: "reviewed", Type: domain.NodeTypeDocument, Status: domain.NodeStatusPublished, EditTime: daysAgo(40)},
+		{ID: "d4", ParentID: "f", Name: "unreleased", Type: domain.NodeTypeDocument, Status: domain.NodeStatusUnreleased, EditTime: daysAgo(60)},
+		{ID: "d5", ParentID: "f", Name: "short interval", Type: domain.NodeTypeDocument, Status: domain.NodeStatusPublished, EditTime: daysAgo(9)},
+		{ID: "d6", Name: "no policy", Type: domain.NodeTypeDocument, Status: domain.NodeStatusPublished, EditTime: daysAgo(365)},
+		{ID: "d7", ParentID: "f", Name: "due now", Type: domain.NodeTypeDocument, Status: domain.NodeStatusDraft, EditTime: daysAgo(30)},
+	}
+	reviews := map[string]*domain.NodeReview{
+		"f":  {NodeID: "f", OwnerID: "u1", IntervalDays: 30},
+		"d3": {NodeID: "d3", ReviewedAt: &reviewedAt},
+		"d5": {NodeID: "d5", IntervalDays: 7, RemindedAt: &remindedAt},
+	}
+	state := &nodeReviewState{
+		nodes:    nodes,
+		reviews:  reviews,
+		policies: domain.ResolveNodeReviewPolicies(nodes, reviews),
+		accounts: map[string]string{"u1": "alice@example.com"},
+	}
+
+	items := state.staleNodes(now)
+	require.Len(t, items, 3)
+	// 按到期时间升序，逾期最久的排在最前
+	assert.Equal(t, []string{"d1", "d5", "d7"}, lo.Map(items, func(item *v1.StaleNodeItem, _ int) string { return item.ID }))
+
+	assert.Equal(t, 30, items[0].IntervalDays)
+	assert.Equal(t, "u1", items[0].OwnerID)
+	assert.Equal(t, "alice@example.com", items[0].OwnerAccount)
+	assert.Equal(t, daysAgo(40), items[0].LastReviewedAt)
+	assert.Equal(t, daysAgo(10), items[0].DueAt)
+	assert.Equal(t, 10, items[0].OverdueDays)
+	assert.Nil(t, items[0].RemindedAt)
+
+	assert.Equal(t, 7, items[1].IntervalDays)
+	assert.Equal(t, 2, items[1].OverdueDays)
+	assert.Equal(t, &remindedAt, items[1].RemindedAt)
+
+	assert.Equal(t, 0, items[2].OverdueDays)
+
+	assert.Empty(t, (&nodeReviewState{}).staleNodes(now))
+}
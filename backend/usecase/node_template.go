@@ -0,0 +1,464 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/node/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+const (
+	// 文件夹模板的子节点数和层数上限
+	nodeTemplateMaxChildren = 200
+	nodeTemplateMaxDepth    = 5
+)
+
+type NodeTemplateUsecase struct {
+	templateRepo *pg.NodeTemplateRepository
+	nodeRepo     *pg.NodeRepository
+	kbRepo       *pg.KnowledgeBaseRepository
+	userRepo     *pg.UserRepository
+	logger       *log.Logger
+}
+
+func NewNodeTemplateUsecase(
+	templateRepo *pg.NodeTemplateRepository,
+	nodeRepo *pg.NodeRepository,
+	kbRepo *pg.KnowledgeBaseRepository,
+	userRepo *pg.UserRepository,
+	logger *log.Logger,
+) *NodeTemplateUsecase {
+	return &NodeTemplateUsecase{
+		templateRepo: templateRepo,
+		nodeRepo:     nodeRepo,
+		kbRepo:       kbRepo,
+		userRepo:     userRepo,
+		logger:       logger.WithModule("usecase.node_template"),
+	}
+}
+
+// GetList 内置模板在前，知识库模板按创建时间排列
+func (u *NodeTemplateUsecase) GetList(ctx context.Context, kbID string) ([]*domain.NodeTemplate, error) {
+	templates, err := u.templateRepo.GetList(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*domain.NodeTemplate, 0, len(systemNodeTemplates)+len(templates))
+	for _, t := range systemNodeTemplates {
+		system := *t
+		system.KBID = kbID
+		list = append(list, &system)
+	}
+	return append(list, templates...), nil
+}
+
+func (u *NodeTemplateUsecase) Create(ctx context.Context, req *v1.CreateNodeTemplateReq, userID string) (string, error) {
+	if err := validateNodeTemplate(req); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	template := &domain.NodeTemplate{
+		ID:        uuid.NewString(),
+		KBID:      req.KbID,
+		CreatorID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	fillNodeTemplate(template, req)
+	if err := u.templateRepo.Create(ctx, template); err != nil {
+		return "", err
+	}
+	return template.ID, nil
+}
+
+func (u *NodeTemplateUsecase) Update(ctx context.Context, req *v1.UpdateNodeTemplateReq) error {
+	if strings.HasPrefix(req.ID, domain.SystemNodeTemplateIDPrefix) {
+		return errors.New("system template can not be modified")
+	}
+	if err := validateNodeTemplate(&req.CreateNodeTemplateReq); err != nil {
+		return err
+	}
+	template, err := u.templateRepo.GetByID(ctx, req.KbID, req.ID)
+	if err != nil {
+		return fmt.Errorf("get node template failed: %w", err)
+	}
+	template.UpdatedAt = time.Now()
+	fillNodeTemplate(template, &req.CreateNodeTemplateReq)
+	return u.templateRepo.Update(ctx, template)
+}
+
+func (u *NodeTemplateUsecase) Delete(ctx context.Context, kbID, id string) error {
+	if strings.HasPrefix(id, domain.SystemNodeTemplateIDPrefix) {
+		return errors.New("system template can not be deleted")
+	}
+	return u.templateRepo.Delete(ctx, kbID, id)
+}
+
+// CreateNode 从模板创建节点，文件夹模板同时创建其下的子节点
+func (u *NodeTemplateUsecase) CreateNode(ctx context.Context, req *v1.CreateNodeFromTemplateReq, userID string, maxNode int) (*v1.CreateNodeFromTemplateResp, error) {
+	template, err := u.getTemplate(ctx, req.KbID, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, req.KbID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base failed: %w", err)
+	}
+	parentName := ""
+	if req.ParentID != "" {
+		parent, err := u.nodeRepo.GetNodeByID(ctx, req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("get parent node failed: %w", err)
+		}
+		if parent.KBID != req.KbID || parent.Type != domain.NodeTypeFolder {
+			return nil, errors.New("parent node must be a folder in the same knowledge base")
+		}
+		parentName = parent.Name
+	}
+	author := ""
+	if user, err := u.userRepo.GetUser(ctx, userID); err == nil {
+		author = user.Account
+	}
+
+	now := time.Now()
+	vars := map[string]string{
+		"date":    now.Format(time.DateOnly),
+		"time":    now.Format("15:04"),
+		"author":  author,
+		"kb_name": kb.Name,
+	}
+	permissions := template.Permissions
+	var reqs []*domain.CreateNodeReq
+	var add func(node *domain.NodeTemplateNode, parentID, parentName string, position *float64)
+	add = func(node *domain.NodeTemplateNode, parentID, parentName string, position *float64) {
+		vars["parent_name"] = parentName
+		vars["name"] = node.Name
+		name := renderNodeTemplate(node.Name, vars)
+		vars["name"] = name
+		nodeReq := &domain.CreateNodeReq{
+			ID:          uuid.Must(uuid.NewV7()).String(),
+			KBID:        req.KbID,
+			NavId:       req.NavId,
+			ParentID:    parentID,
+			Type:        node.Type,
+			Name:        name,
+			Content:     renderNodeTemplate(node.Content, vars),
+			Emoji:       node.Emoji,
+			MaxNode:     maxNode,
+			Position:    position,
+			Permissions: &permissions,
+		}
+		if node.ContentType != "" {
+			nodeReq.ContentType = lo.ToPtr(node.ContentType)
+		}
+		reqs = append(reqs, nodeReq)
+		for _, child := range node.Children {
+			add(child, nodeReq.ID, name, nil)
+		}
+	}
+	add(&domain.NodeTemplateNode{
+		Type:        template.Type,
+		Name:        req.Name,
+		Content:     template.Content,
+		ContentType: template.ContentType,
+		Emoji:       template.Emoji,
+		Children:    template.Children,
+	}, req.ParentID, parentName, req.Position)
+
+	nodeIDs, err := u.nodeRepo.CreateNodes(ctx, reqs, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.CreateNodeFromTemplateResp{ID: nodeIDs[0], NodeIDs: nodeIDs}, nil
+}
+
+func (u *NodeTemplateUsecase) getTemplate(ctx context.Context, kbID, id string) (*domain.NodeTemplate, error) {
+	if strings.HasPrefix(id, domain.SystemNodeTemplateIDPrefix) {
+		for _, t := range systemNodeTemplates {
+			if t.ID == id {
+				return t, nil
+			}
+		}
+		return nil, errors.New("node template not found")
+	}
+	template, err := u.templateRepo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return nil, fmt.Errorf("get node template failed: %w", err)
+	}
+	return template, nil
+}
+
+func validateNodeTemplate(req *v1.CreateNodeTemplateReq) error {
+	for _, perm := range []consts.NodeAccessPerm{req.Permissions.Answerable, req.Permissions.Visitable, req.Permissions.Visible} {
+		if perm != "" && perm != consts.NodeAccessPermOpen && perm != consts.NodeAccessPermClosed {
+			return fmt.Errorf("invalid template permission: %s", perm)
+		}
+	}
+	if req.Children.Count() > nodeTemplateMaxChildren || req.Children.Depth() > nodeTemplateMaxDepth {
+		return fmt.Errorf("template can contain at most %d children in %d levels", nodeTemplateMaxChildren, nodeTemplateMaxDepth)
+	}
+	// 只有文件夹可以包含子节点
+	var check func(nodeType domain.NodeType, children domain.NodeTemplateNodes) error
+	check = func(nodeType domain.NodeType, children domain.NodeTemplateNodes) error {
+		if len(children) > 0 && nodeType != domain.NodeTypeFolder {
+			return errors.New("only folder template can contain children")
+		}
+		for _, child := range children {
+			if err := check(child.Type, child.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(req.Type, req.Children)
+}
+
+func fillNodeTemplate(template *domain.NodeTemplate, req *v1.CreateNodeTemplateReq) {
+	template.Name = req.Name
+	template.Description = req.Description
+	template.Type = req.Type
+	template.Content = req.Content
+	template.ContentType = req.ContentType
+	template.Emoji = req.Emoji
+	template.Permissions = domain.NodePermissions{
+		Answerable: lo.CoalesceOrEmpty(req.Permissions.Answerable, consts.NodeAccessPermOpen),
+		Visitable:  lo.CoalesceOrEmpty(req.Permissions.Visitable, consts.NodeAccessPermOpen),
+		Visible:    lo.CoalesceOrEmpty(req.Permissions.Visible, consts.NodeAccessPermOpen),
+	}
+	template.Children = req.Children
+	if template.Children == nil {
+		template.Children = domain.NodeTemplateNodes{}
+	}
+}
+
+// renderNodeTemplate 替换 {{变量}}，未知的变量保持原样
+func renderNodeTemplate(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	pairs := make([]string, 0, len(vars)*4)
+	for k, v := range vars {
+		pairs = append(pairs, "{{"+k+"}}", v, "{{ "+k+" }}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+var systemNodeTemplatePermissions = domain.NodePermissions{
+	Answerable: consts.NodeAccessPermOpen,
+	Visitable:  consts.NodeAccessPermOpen,
+	Visible:    consts.NodeAccessPermOpen,
+}
+
+// systemNodeTemplates 内置模板，所有知识库可用
+var systemNodeTemplates = []*domain.NodeTemplate{
+	{
+		ID:          domain.SystemNodeTemplateIDPrefix + "api-reference",
+		Name:        "API 参考",
+		Description: "接口说明、请求参数、响应示例和错误码",
+		Type:        domain.NodeTypeDocument,
+		ContentType: domain.ContentTypeMD,
+		Emoji:       "🔌",
+		Content:     systemTemplateAPIReference,
+	},
+	{
+		ID:          domain.SystemNodeTemplateIDPrefix + "troubleshooting",
+		Name:        "故障排查",
+		Description: "问题现象、可能原因、排查步骤和解决方案",
+		Type:        domain.NodeTypeDocument,
+		ContentType: domain.ContentTypeMD,
+		Emoji:       "🛠️",
+		Content:     systemTemplateTroubleshooting,
+	},
+	{
+		ID:          domain.SystemNodeTemplateIDPrefix + "release-note",
+		Name:        "发布说明",
+		Description: "版本的新功能、改进、问题修复和升级注意事项",
+		Type:        domain.NodeTypeDocument,
+		ContentType: domain.ContentTypeMD,
+		Emoji:       "🚀",
+		Content:     systemTemplateReleaseNote,
+	},
+	{
+		ID:          domain.SystemNodeTemplateIDPrefix + "faq",
+		Name:        "常见问题",
+		Description: "按问答形式整理的常见问题",
+		Type:        domain.NodeTypeDocument,
+		ContentType: domain.ContentTypeMD,
+		Emoji:       "❓",
+		Content:     systemTemplateFAQ,
+	},
+	{
+		ID:          domain.SystemNodeTemplateIDPrefix + "product-docs",
+		Name:        "产品文档",
+		Description: "包含概述、快速开始、API 参考、故障排查、常见问题和发布说明的目录骨架",
+		Type:        domain.NodeTypeFolder,
+		Emoji:       "📚",
+		Children: domain.NodeTemplateNodes{
+			{Type: domain.NodeTypeDocument, Name: "概述", ContentType: domain.ContentTypeMD, Content: systemTemplateOverview},
+			{Type: domain.NodeTypeDocument, Name: "快速开始", ContentType: domain.ContentTypeMD, Content: systemTemplateQuickStart},
+			{Type: domain.NodeTypeDocument, Name: "API 参考", ContentType: domain.ContentTypeMD, Emoji: "🔌", Content: systemTemplateAPIReference},
+			{Type: domain.NodeTypeDocument, Name: "故障排查", ContentType: domain.ContentTypeMD, Emoji: "🛠️", Content: systemTemplateTroubleshooting},
+			{Type: domain.NodeTypeDocument, Name: "常见问题", ContentType: domain.ContentTypeMD, Emoji: "❓", Content: systemTemplateFAQ},
+			{Type: domain.NodeTypeFolder, Name: "发布说明", Emoji: "🚀"},
+		},
+	},
+}
+
+func init() {
+	for _, t := range systemNodeTemplates {
+		t.System = true
+		t.Permissions = systemNodeTemplatePermissions
+	}
+}
+
+const systemTemplateAPIReference = `> 维护人：{{author}}，更新于 {{date}}
+
+## 接口说明
+
+简要描述接口的用途和适用场景。
+
+## 请求
+
+` + "`POST /api/v1/example`" + `
+
+| 参数 | 位置 | 类型 | 必填 | 说明 |
+| --- | --- | --- | --- | --- |
+| id | body | string | 是 | 资源 ID |
+
+### 请求示例
+
+` + "```json" + `
+{
+  "id": "example"
+}
+` + "```" + `
+
+## 响应
+
+| 字段 | 类型 | 说明 |
+| --- | --- | --- |
+| success | bool | 是否成功 |
+| data | object | 返回数据 |
+
+### 响应示例
+
+` + "```json" + `
+{
+  "success": true,
+  "data": {}
+}
+` + "```" + `
+
+## 错误码
+
+| 错误码 | 说明 | 处理建议 |
+| --- | --- | --- |
+| 400 | 参数错误 | 检查请求参数 |
+`
+
+const systemTemplateTroubleshooting = `> 所属分类：{{parent_name}}，记录人：{{author}}，{{date}}
+
+## 问题现象
+
+描述用户看到的报错信息、异常表现和影响范围。
+
+## 适用范围
+
+- 产品版本：
+- 运行环境：
+
+## 可能原因
+
+1. 原因一
+2. 原因二
+
+## 排查步骤
+
+1. 检查……
+2. 查看日志……
+
+## 解决方案
+
+说明修复方法，如有临时规避措施也一并列出。
+
+## 相关文档
+
+-
+`
+
+const systemTemplateReleaseNote = `> 发布日期：{{date}}，发布人：{{author}}
+
+## 新功能
+
+-
+
+## 改进
+
+-
+
+## 问题修复
+
+-
+
+## 升级说明
+
+说明升级前需要注意的事项，如配置变更、数据迁移或不兼容改动。
+`
+
+const systemTemplateFAQ = `> 适用于 {{kb_name}}，更新于 {{date}}
+
+## 问题一？
+
+回答。
+
+## 问题二？
+
+回答。
+
+## 没有找到答案？
+
+请联系 {{author}} 或在本页留言。
+`
+
+const systemTemplateOverview = `## {{parent_name}} 是什么
+
+一句话介绍产品及其解决的问题。
+
+## 核心功能
+
+-
+
+## 适用场景
+
+-
+`
+
+const systemTemplateQuickStart = `## 准备工作
+
+-
+
+## 安装
+
+` + "```bash" + `
+# 安装命令
+` + "```" + `
+
+## 第一次使用
+
+1.
+2.
+
+## 下一步
+
+阅读 API 参考了解更多用法。
+`
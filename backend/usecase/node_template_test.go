@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderNodeTemplate(t *testing.T) {
+	vars := map[string]string{
+		"date":        "2024-01-02",
+		"author":      "admin",
+		"kb_name":     "知识库",
+		"parent_name": "",
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"no variables", "hello world", "hello world"},
+		{"single variable", "# {{kb_name}}", "# 知识库"},
+		{"spaced variable", "作者：{{ author }}", "作者：admin"},
+		{"repeated variables", "{{date}}/{{date}} by {{author}}", "2024-01-02/2024-01-02 by admin"},
+		{"empty value", "[{{parent_name}}]", "[]"},
+		{"unknown variable kept", "{{unknown}} {{ date }}", "{{unknown}} 2024-01-02"},
+		{"unbalanced spaces kept", "{{ date}} {{date }}", "{{ date}} {{date }}"},
+		{"unclosed braces", "{{date", "{{date"},
+		{"nested braces", "{{{date}}}", "{2024-01-02}"},
+		{"empty text", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderNodeTemplate(tt.text, vars))
+		})
+	}
+}
+
+func TestRenderNodeTemplate_NoVars(t *testing.T) {
+	assert.Equal(t, "{{date}}", renderNodeTemplate("{{date}}", nil))
+}
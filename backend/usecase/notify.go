@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gomarkdown/markdown"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/notify"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+// sendNotify 把 Markdown 内容发送到各通知渠道，邮件渠道转换为 HTML
+func sendNotify(ctx context.Context, systemSettingRepo *pg.SystemSettingRepo, channels []domain.NotifyChannel, title, content string) error {
+	var errs []error
+	for _, channel := range channels {
+		switch channel.Type {
+		case consts.NotifyChannelEmail:
+			if err := sendNotifyEmail(ctx, systemSettingRepo, channel.Emails, title, content); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			hook := notify.Webhook{
+				Type:   notify.WebhookType(channel.Type),
+				URL:    channel.WebhookURL,
+				Secret: channel.Secret,
+			}
+			if err := notify.SendMarkdown(ctx, hook, title, content); err != nil {
+				errs = append(errs, fmt.Errorf("send %s webhook failed: %w", channel.Type, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sendNotifyEmail(ctx context.Context, systemSettingRepo *pg.SystemSettingRepo, to []string, title, content string) error {
+	smtpConfig, err := getSMTPConfig(ctx, systemSettingRepo)
+	if err != nil {
+		return err
+	}
+	html := string(markdown.ToHTML([]byte(content), nil, nil))
+	if err := notify.SendEmail(*smtpConfig, to, title, html); err != nil {
+		return fmt.Errorf("send email failed: %w", err)
+	}
+	return nil
+}
+
+func getSMTPConfig(ctx context.Context, systemSettingRepo *pg.SystemSettingRepo) (*notify.SMTPConfig, error) {
+	setting, err := systemSettingRepo.GetSystemSetting(ctx, consts.SystemSettingSMTP)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("smtp is not configured")
+		}
+		return nil, err
+	}
+	var smtpSetting domain.SMTPSetting
+	if err := json.Unmarshal(setting.Value, &smtpSetting); err != nil {
+		return nil, fmt.Errorf("unmarshal smtp setting failed: %w", err)
+	}
+	smtpConfig := notify.SMTPConfig(smtpSetting)
+	return &smtpConfig, nil
+}
+
+func validateNotifyChannels(channels []domain.NotifyChannel) error {
+	for _, channel := range channels {
+		switch channel.Type {
+		case consts.NotifyChannelEmail:
+			if len(channel.Emails) == 0 {
+				return errors.New("email channel requires at least one recipient")
+			}
+		case consts.NotifyChannelDingTalk, consts.NotifyChannelFeishu, consts.NotifyChannelWeCom:
+			if channel.WebhookURL == "" {
+				return fmt.Errorf("%s channel requires webhook url", channel.Type)
+			}
+		default:
+			return fmt.Errorf("unsupported notify channel type: %s", channel.Type)
+		}
+	}
+	return nil
+}
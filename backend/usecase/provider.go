@@ -19,6 +19,13 @@ var ProviderSet = wire.NewSet(
 
 	NewLLMUsecase,
 	NewNodeUsecase,
+	NewNodeCollabUsecase,
+	NewNodeExportUsecase,
+	NewNodeTemplateUsecase,
+	NewNodeLinkUsecase,
+	NewNodeReviewUsecase,
+	NewNodeFAQUsecase,
+	NewCuratedAnswerUsecase,
 	NewAppUsecase,
 	NewConversationUsecase,
 	NewUserUsecase,
@@ -28,12 +35,18 @@ var ProviderSet = wire.NewSet(
 	NewCrawlerUsecase,
 	NewCreationUsecase,
 	NewFileUsecase,
+	NewAttachmentUsecase,
 	NewSitemapUsecase,
+	NewFeedUsecase,
+	NewKBExportUsecase,
 	NewStatUseCase,
+	NewStatReportUsecase,
 	NewCommentUsecase,
 	NewWechatUsecase,
 	NewWecomUsecase,
 	NewWechatAppUsecase,
 	NewAuthUsecase,
+	NewSCIMUsecase,
+	NewLDAPSyncUsecase,
 	NewNavUsecase,
 )
@@ -0,0 +1,831 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	authV1 "github.com/chaitin/panda-wiki/api/auth/v1"
+	v1 "github.com/chaitin/panda-wiki/api/scim/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+const (
+	scimBasePath              = "/share/v1/openapi/scim/"
+	scimMaxResults            = 200
+	scimUnionIDAttrExternalID = "externalId"
+)
+
+var scimFilterRegexp = regexp.MustCompile(`^\s*(\w+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+type SCIMUsecase struct {
+	authRepo *pg.AuthRepo
+	kbRepo   *pg.KnowledgeBaseRepository
+	logger   *log.Logger
+}
+
+func NewSCIMUsecase(authRepo *pg.AuthRepo, kbRepo *pg.KnowledgeBaseRepository, logger *log.Logger) *SCIMUsecase {
+	return &SCIMUsecase{
+		authRepo: authRepo,
+		kbRepo:   kbRepo,
+		logger:   logger.WithModule("usecase.scim"),
+	}
+}
+
+// GetSCIMSetting 获取SCIM配置，endpoint 需知识库配置访问地址
+func (u *SCIMUsecase) GetSCIMSetting(ctx context.Context, kbID string) (*authV1.SCIMGetResp, error) {
+	resp := &authV1.SCIMGetResp{UnionIDAttr: "userName"}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if kb.AccessSettings.BaseURL != "" {
+		resp.Endpoint = strings.TrimSuffix(kb.AccessSettings.BaseURL, "/") + scimBasePath + kbID
+	}
+
+	authConfig, err := u.authRepo.GetAuthConfig(ctx, kbID, consts.SourceTypeSCIM)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, nil
+		}
+		return nil, err
+	}
+	resp.Enabled = authConfig.AuthSetting.TokenHash != ""
+	if authConfig.AuthSetting.UnionIDAttr != "" {
+		resp.UnionIDAttr = authConfig.AuthSetting.UnionIDAttr
+	}
+	return resp, nil
+}
+
+// SetSCIMSetting 开启SCIM并生成新的 bearer token，旧 token 立即失效
+func (u *SCIMUsecase) SetSCIMSetting(ctx context.Context, req authV1.SCIMSetReq) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := u.authRepo.CreateAuthConfig(ctx, &domain.AuthConfig{
+		KbID:       req.KbID,
+		SourceType: consts.SourceTypeSCIM,
+		AuthSetting: domain.AuthSetting{
+			TokenHash:   hashSCIMToken(token),
+			UnionIDAttr: req.UnionIDAttr,
+		},
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (u *SCIMUsecase) DeleteSCIMSetting(ctx context.Context, kbID string) error {
+	return u.authRepo.CreateAuthConfig(ctx, &domain.AuthConfig{
+		KbID:       kbID,
+		SourceType: consts.SourceTypeSCIM,
+		AuthSetting: domain.AuthSetting{
+			TokenHash: "",
+		},
+	})
+}
+
+func hashSCIMToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SCIMContext 一次SCIM请求所需的知识库配置
+type SCIMContext struct {
+	kbID        string
+	sourceType  consts.SourceType // 用户的身份源，与知识库企业认证一致
+	unionIDAttr string
+}
+
+// Authenticate 校验 bearer token
+func (u *SCIMUsecase) Authenticate(ctx context.Context, kbID, token string) (*SCIMContext, error) {
+	authConfig, err := u.authRepo.GetAuthConfig(ctx, kbID, consts.SourceTypeSCIM)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewSCIMError(http.StatusUnauthorized, "", "scim is not enabled")
+		}
+		return nil, err
+	}
+	expected := authConfig.AuthSetting.TokenHash
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(hashSCIMToken(token))) != 1 {
+		return nil, domain.NewSCIMError(http.StatusUnauthorized, "", "invalid token")
+	}
+
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if kb.AccessSettings.SourceType == "" {
+		return nil, domain.NewSCIMError(http.StatusBadRequest, "", "enterprise auth source type is not configured")
+	}
+
+	return &SCIMContext{
+		kbID:        kbID,
+		sourceType:  kb.AccessSettings.SourceType,
+		unionIDAttr: authConfig.AuthSetting.UnionIDAttr,
+	}, nil
+}
+
+func parseSCIMFilter(filter string, attrs map[string]string) (string, string, error) {
+	if filter == "" {
+		return "", "", nil
+	}
+	m := scimFilterRegexp.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidFilter, "only 'attr eq \"value\"' filter is supported")
+	}
+	column, ok := attrs[strings.ToLower(m[1])]
+	if !ok {
+		return "", "", domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidFilter, "unsupported filter attribute "+m[1])
+	}
+	value, err := strconv.Unquote(`"` + m[2] + `"`)
+	if err != nil {
+		return "", "", domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidFilter, err.Error())
+	}
+	return column, value, nil
+}
+
+func scimPage(startIndex, count int) (int, int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count <= 0 || count > scimMaxResults {
+		count = scimMaxResults
+	}
+	return startIndex, startIndex - 1, count
+}
+
+func parseSCIMID(resource, id string) (uint, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, domain.NewSCIMNotFound(resource, id)
+	}
+	return uint(n), nil
+}
+
+// Users
+
+func (u *SCIMUsecase) toSCIMUser(ctx context.Context, auth *domain.Auth) (*v1.User, error) {
+	active := auth.Active
+	user := &v1.User{
+		Schemas:     []string{v1.SchemaUser},
+		ID:          strconv.FormatUint(uint64(auth.ID), 10),
+		ExternalID:  auth.ExternalID,
+		UserName:    auth.UserName,
+		DisplayName: auth.UserInfo.Username,
+		Active:      &active,
+		Meta: &v1.Meta{
+			ResourceType: v1.ResourceTypeUser,
+			Created:      auth.CreatedAt,
+			LastModified: auth.UpdatedAt,
+		},
+	}
+	if user.UserName == "" {
+		user.UserName = auth.UnionID
+	}
+	if auth.UserInfo.Username != "" {
+		user.Name = &v1.Name{Formatted: auth.UserInfo.Username}
+	}
+	if auth.UserInfo.Email != "" {
+		user.Emails = []v1.MultiValue{{Value: auth.UserInfo.Email, Type: "work", Primary: true}}
+	}
+	if auth.UserInfo.AvatarUrl != "" {
+		user.Photos = []v1.MultiValue{{Value: auth.UserInfo.AvatarUrl, Type: "photo", Primary: true}}
+	}
+
+	groups, err := u.authRepo.GetAuthGroupByAuthId(ctx, auth.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		user.Groups = append(user.Groups, v1.Member{
+			Value:   strconv.FormatUint(uint64(group.ID), 10),
+			Display: group.Name,
+		})
+	}
+	return user, nil
+}
+
+// applySCIMUser 将SCIM用户属性写入 auth
+func (u *SCIMUsecase) applySCIMUser(sc *SCIMContext, auth *domain.Auth, user *v1.User) error {
+	if user.UserName == "" {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "userName is required")
+	}
+	auth.UserName = user.UserName
+	auth.ExternalID = user.ExternalID
+	auth.UnionID = user.UserName
+	if sc.unionIDAttr == scimUnionIDAttrExternalID && user.ExternalID != "" {
+		auth.UnionID = user.ExternalID
+	}
+	auth.Active = user.Active == nil || *user.Active
+
+	auth.UserInfo.Username = user.DisplayName
+	if auth.UserInfo.Username == "" && user.Name != nil {
+		auth.UserInfo.Username = user.Name.Formatted
+		if auth.UserInfo.Username == "" {
+			auth.UserInfo.Username = strings.TrimSpace(user.Name.GivenName + " " + user.Name.FamilyName)
+		}
+	}
+	if auth.UserInfo.Username == "" {
+		auth.UserInfo.Username = user.UserName
+	}
+	auth.UserInfo.Email = primaryValue(user.Emails)
+	auth.UserInfo.AvatarUrl = primaryValue(user.Photos)
+	return nil
+}
+
+func primaryValue(values []v1.MultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func (u *SCIMUsecase) getAuth(ctx context.Context, sc *SCIMContext, id string) (*domain.Auth, error) {
+	authID, err := parseSCIMID("user", id)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := u.authRepo.GetAuthById(ctx, sc.kbID, authID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewSCIMNotFound("user", id)
+		}
+		return nil, err
+	}
+	if auth.SourceType != sc.sourceType {
+		return nil, domain.NewSCIMNotFound("user", id)
+	}
+	return auth, nil
+}
+
+func (u *SCIMUsecase) ListUsers(ctx context.Context, sc *SCIMContext, req v1.ListReq) (*v1.UserListResponse, error) {
+	column, value, err := parseSCIMFilter(req.Filter, map[string]string{
+		"username":   "user_name",
+		"externalid": "external_id",
+	})
+	if err != nil {
+		return nil, err
+	}
+	startIndex, offset, limit := scimPage(req.StartIndex, req.Count)
+
+	auths, total, err := u.authRepo.GetSCIMAuths(ctx, sc.kbID, sc.sourceType, column, value, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.UserListResponse{
+		Schemas:      []string{v1.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		Resources:    make([]*v1.User, 0, len(auths)),
+	}
+	for i := range auths {
+		user, err := u.toSCIMUser(ctx, &auths[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.Resources = append(resp.Resources, user)
+	}
+	resp.ItemsPerPage = len(resp.Resources)
+	return resp, nil
+}
+
+func (u *SCIMUsecase) GetUser(ctx context.Context, sc *SCIMContext, id string) (*v1.User, error) {
+	auth, err := u.getAuth(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.toSCIMUser(ctx, auth)
+}
+
+func (u *SCIMUsecase) CreateUser(ctx context.Context, sc *SCIMContext, user *v1.User) (*v1.User, error) {
+	auth := &domain.Auth{
+		KBID:       sc.kbID,
+		SourceType: sc.sourceType,
+	}
+	if err := u.applySCIMUser(sc, auth, user); err != nil {
+		return nil, err
+	}
+	if err := u.authRepo.CreateSCIMAuth(ctx, auth); err != nil {
+		return nil, err
+	}
+	u.logger.Info("scim user created", log.String("kb_id", sc.kbID), log.String("union_id", auth.UnionID))
+	return u.GetUser(ctx, sc, strconv.FormatUint(uint64(auth.ID), 10))
+}
+
+func (u *SCIMUsecase) ReplaceUser(ctx context.Context, sc *SCIMContext, id string, user *v1.User) (*v1.User, error) {
+	auth, err := u.getAuth(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.applySCIMUser(sc, auth, user); err != nil {
+		return nil, err
+	}
+	if err := u.authRepo.UpdateSCIMAuth(ctx, auth); err != nil {
+		return nil, err
+	}
+	return u.GetUser(ctx, sc, id)
+}
+
+func (u *SCIMUsecase) PatchUser(ctx context.Context, sc *SCIMContext, id string, patch *v1.PatchOp) (*v1.User, error) {
+	auth, err := u.getAuth(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.toSCIMUser(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range patch.Operations {
+		if err := patchSCIMUser(user, op); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.applySCIMUser(sc, auth, user); err != nil {
+		return nil, err
+	}
+	if err := u.authRepo.UpdateSCIMAuth(ctx, auth); err != nil {
+		return nil, err
+	}
+	return u.GetUser(ctx, sc, id)
+}
+
+func (u *SCIMUsecase) DeleteUser(ctx context.Context, sc *SCIMContext, id string) error {
+	auth, err := u.getAuth(ctx, sc, id)
+	if err != nil {
+		return err
+	}
+	return u.authRepo.DeleteAuthWithGroups(ctx, sc.kbID, auth.ID)
+}
+
+// patchSCIMUser 支持常见IdP下发的属性路径，如 active、name.givenName、emails[type eq "work"].value
+func patchSCIMUser(user *v1.User, op v1.PatchOperation) error {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "unsupported op "+op.Op)
+	}
+
+	if op.Path == "" {
+		if opName == "remove" {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidPath, "path is required for remove")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error())
+		}
+		for path, value := range values {
+			if err := patchSCIMUserAttr(user, opName, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return patchSCIMUserAttr(user, opName, op.Path, op.Value)
+}
+
+func patchSCIMUserAttr(user *v1.User, op, path string, value json.RawMessage) error {
+	remove := op == "remove"
+	path = strings.TrimPrefix(path, v1.SchemaUser+":")
+	lower := strings.ToLower(path)
+
+	switch {
+	case lower == "active":
+		if remove {
+			return nil
+		}
+		active, err := parseSCIMBool(value)
+		if err != nil {
+			return err
+		}
+		user.Active = &active
+	case lower == "username":
+		if remove {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "userName is required")
+		}
+		return unmarshalSCIMValue(value, &user.UserName)
+	case lower == "displayname":
+		user.DisplayName = ""
+		if !remove {
+			return unmarshalSCIMValue(value, &user.DisplayName)
+		}
+	case lower == "externalid":
+		user.ExternalID = ""
+		if !remove {
+			return unmarshalSCIMValue(value, &user.ExternalID)
+		}
+	case lower == "name":
+		user.Name = nil
+		if !remove {
+			return unmarshalSCIMValue(value, &user.Name)
+		}
+	case strings.HasPrefix(lower, "name."):
+		if user.Name == nil {
+			user.Name = &v1.Name{}
+		}
+		var s string
+		if !remove {
+			if err := unmarshalSCIMValue(value, &s); err != nil {
+				return err
+			}
+		}
+		switch strings.TrimPrefix(lower, "name.") {
+		case "formatted":
+			user.Name.Formatted = s
+		case "givenname":
+			user.Name.GivenName = s
+		case "familyname":
+			user.Name.FamilyName = s
+		}
+	case lower == "emails":
+		user.Emails = nil
+		if !remove {
+			return unmarshalSCIMValue(value, &user.Emails)
+		}
+	case strings.HasPrefix(lower, "emails"):
+		// emails[type eq "work"].value
+		var s string
+		if !remove {
+			if err := unmarshalSCIMValue(value, &s); err != nil {
+				return err
+			}
+		}
+		user.Emails = []v1.MultiValue{{Value: s, Type: "work", Primary: true}}
+		if s == "" {
+			user.Emails = nil
+		}
+	case lower == "photos":
+		user.Photos = nil
+		if !remove {
+			return unmarshalSCIMValue(value, &user.Photos)
+		}
+	default:
+		// 不支持的属性（如企业扩展）忽略
+	}
+	return nil
+}
+
+func unmarshalSCIMValue(value json.RawMessage, v any) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error())
+	}
+	return nil
+}
+
+// parseSCIMBool Azure AD 会以字符串 "True"/"False" 下发布尔值
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	return false, domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "invalid boolean value")
+}
+
+// Groups
+
+// scimGroupMembers 分组成员，用户写入 auth_ids，子分组通过 parent_id 维护
+type scimGroupMembers struct {
+	authIDs  []uint
+	groupIDs []uint
+}
+
+func (m *scimGroupMembers) add(members []v1.Member) error {
+	for _, member := range members {
+		id, err := strconv.ParseUint(member.Value, 10, 64)
+		if err != nil {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "invalid member "+member.Value)
+		}
+		if isSCIMGroupMember(member) {
+			m.groupIDs = append(m.groupIDs, uint(id))
+		} else {
+			m.authIDs = append(m.authIDs, uint(id))
+		}
+	}
+	m.authIDs = lo.Uniq(m.authIDs)
+	m.groupIDs = lo.Uniq(m.groupIDs)
+	return nil
+}
+
+func (m *scimGroupMembers) remove(members []v1.Member) {
+	for _, member := range members {
+		id, err := strconv.ParseUint(member.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		// 未指明类型时用户和子分组都移除
+		if member.Type == "" || isSCIMGroupMember(member) {
+			m.groupIDs = lo.Without(m.groupIDs, uint(id))
+		}
+		if !isSCIMGroupMember(member) {
+			m.authIDs = lo.Without(m.authIDs, uint(id))
+		}
+	}
+}
+
+func isSCIMGroupMember(member v1.Member) bool {
+	return strings.EqualFold(member.Type, v1.ResourceTypeGroup) || strings.Contains(member.Ref, "/Groups/")
+}
+
+func (u *SCIMUsecase) getGroup(ctx context.Context, sc *SCIMContext, id string) (*domain.AuthGroup, error) {
+	groupID, err := parseSCIMID("group", id)
+	if err != nil {
+		return nil, err
+	}
+	group, err := u.authRepo.GetAuthGroup(ctx, sc.kbID, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewSCIMNotFound("group", id)
+		}
+		return nil, err
+	}
+	if group.SourceType != consts.SourceTypeSCIM {
+		return nil, domain.NewSCIMNotFound("group", id)
+	}
+	return group, nil
+}
+
+func (u *SCIMUsecase) getGroupMembers(ctx context.Context, sc *SCIMContext, group *domain.AuthGroup) (*scimGroupMembers, error) {
+	members := &scimGroupMembers{
+		authIDs: lo.Map(group.AuthIDs, func(id int64, _ int) uint { return uint(id) }),
+	}
+	children, err := u.authRepo.GetChildAuthGroups(ctx, sc.kbID, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		members.groupIDs = append(members.groupIDs, child.ID)
+	}
+	return members, nil
+}
+
+func (u *SCIMUsecase) toSCIMGroup(ctx context.Context, sc *SCIMContext, group *domain.AuthGroup) (*v1.Group, error) {
+	resp := &v1.Group{
+		Schemas:     []string{v1.SchemaGroup},
+		ID:          strconv.FormatUint(uint64(group.ID), 10),
+		ExternalID:  group.SyncId,
+		DisplayName: group.Name,
+		Members:     make([]v1.Member, 0),
+		Meta: &v1.Meta{
+			ResourceType: v1.ResourceTypeGroup,
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+		},
+	}
+
+	authIDs := lo.Map(group.AuthIDs, func(id int64, _ int) uint { return uint(id) })
+	auths, err := u.authRepo.GetAuthsByIDs(ctx, sc.kbID, sc.sourceType, authIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, auth := range auths {
+		resp.Members = append(resp.Members, v1.Member{
+			Value:   strconv.FormatUint(uint64(auth.ID), 10),
+			Display: auth.UserInfo.Username,
+			Type:    v1.ResourceTypeUser,
+		})
+	}
+
+	children, err := u.authRepo.GetChildAuthGroups(ctx, sc.kbID, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		resp.Members = append(resp.Members, v1.Member{
+			Value:   strconv.FormatUint(uint64(child.ID), 10),
+			Display: child.Name,
+			Type:    v1.ResourceTypeGroup,
+		})
+	}
+	return resp, nil
+}
+
+// saveGroup 校验成员均属于当前知识库后保存
+func (u *SCIMUsecase) saveGroup(ctx context.Context, sc *SCIMContext, group *domain.AuthGroup, members *scimGroupMembers) error {
+	if group.Name == "" {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "displayName is required")
+	}
+
+	auths, err := u.authRepo.GetAuthsByIDs(ctx, sc.kbID, sc.sourceType, members.authIDs)
+	if err != nil {
+		return err
+	}
+	if len(auths) != len(members.authIDs) {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "member user not found")
+	}
+	children, err := u.authRepo.GetAuthGroupsByIDs(ctx, sc.kbID, consts.SourceTypeSCIM, members.groupIDs)
+	if err != nil {
+		return err
+	}
+	if len(children) != len(members.groupIDs) {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "member group not found")
+	}
+
+	group.AuthIDs = lo.Map(members.authIDs, func(id uint, _ int) int64 { return int64(id) })
+	return u.authRepo.SaveSCIMAuthGroup(ctx, group, members.groupIDs)
+}
+
+func (u *SCIMUsecase) ListGroups(ctx context.Context, sc *SCIMContext, req v1.ListReq) (*v1.GroupListResponse, error) {
+	column, value, err := parseSCIMFilter(req.Filter, map[string]string{
+		"displayname": "name",
+		"externalid":  "sync_id",
+	})
+	if err != nil {
+		return nil, err
+	}
+	startIndex, offset, limit := scimPage(req.StartIndex, req.Count)
+
+	groups, total, err := u.authRepo.GetAuthGroupsBySourceType(ctx, sc.kbID, consts.SourceTypeSCIM, column, value, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.GroupListResponse{
+		Schemas:      []string{v1.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		Resources:    make([]*v1.Group, 0, len(groups)),
+	}
+	for i := range groups {
+		group, err := u.toSCIMGroup(ctx, sc, &groups[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.Resources = append(resp.Resources, group)
+	}
+	resp.ItemsPerPage = len(resp.Resources)
+	return resp, nil
+}
+
+func (u *SCIMUsecase) GetGroup(ctx context.Context, sc *SCIMContext, id string) (*v1.Group, error) {
+	group, err := u.getGroup(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.toSCIMGroup(ctx, sc, group)
+}
+
+func (u *SCIMUsecase) CreateGroup(ctx context.Context, sc *SCIMContext, req *v1.Group) (*v1.Group, error) {
+	group := &domain.AuthGroup{
+		KbID:       sc.kbID,
+		Name:       req.DisplayName,
+		SyncId:     req.ExternalID,
+		SourceType: consts.SourceTypeSCIM,
+	}
+	members := &scimGroupMembers{}
+	if err := members.add(req.Members); err != nil {
+		return nil, err
+	}
+	if err := u.saveGroup(ctx, sc, group, members); err != nil {
+		return nil, err
+	}
+	u.logger.Info("scim group created", log.String("kb_id", sc.kbID), log.String("name", group.Name))
+	return u.toSCIMGroup(ctx, sc, group)
+}
+
+func (u *SCIMUsecase) ReplaceGroup(ctx context.Context, sc *SCIMContext, id string, req *v1.Group) (*v1.Group, error) {
+	group, err := u.getGroup(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	group.Name = req.DisplayName
+	group.SyncId = req.ExternalID
+	members := &scimGroupMembers{}
+	if err := members.add(req.Members); err != nil {
+		return nil, err
+	}
+	if err := u.saveGroup(ctx, sc, group, members); err != nil {
+		return nil, err
+	}
+	return u.GetGroup(ctx, sc, id)
+}
+
+func (u *SCIMUsecase) PatchGroup(ctx context.Context, sc *SCIMContext, id string, patch *v1.PatchOp) (*v1.Group, error) {
+	group, err := u.getGroup(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := u.getGroupMembers(ctx, sc, group)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range patch.Operations {
+		if err := patchSCIMGroup(group, members, op); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.saveGroup(ctx, sc, group, members); err != nil {
+		return nil, err
+	}
+	return u.GetGroup(ctx, sc, id)
+}
+
+func (u *SCIMUsecase) DeleteGroup(ctx context.Context, sc *SCIMContext, id string) error {
+	group, err := u.getGroup(ctx, sc, id)
+	if err != nil {
+		return err
+	}
+	return u.authRepo.DeleteAuthGroup(ctx, sc.kbID, group.ID)
+}
+
+var scimMemberPathRegexp = regexp.MustCompile(`^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// patchSCIMGroup 支持 displayName、externalId、members 及 members[value eq "id"] 路径
+func patchSCIMGroup(group *domain.AuthGroup, members *scimGroupMembers, op v1.PatchOperation) error {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "unsupported op "+op.Op)
+	}
+
+	if op.Path == "" {
+		if opName == "remove" {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidPath, "path is required for remove")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, err.Error())
+		}
+		for path, value := range values {
+			if err := patchSCIMGroupAttr(group, members, opName, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return patchSCIMGroupAttr(group, members, opName, op.Path, op.Value)
+}
+
+func patchSCIMGroupAttr(group *domain.AuthGroup, members *scimGroupMembers, op, path string, value json.RawMessage) error {
+	path = strings.TrimPrefix(path, v1.SchemaGroup+":")
+	lower := strings.ToLower(path)
+
+	if m := scimMemberPathRegexp.FindStringSubmatch(lower); m != nil {
+		if op != "remove" {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidPath, "unsupported path "+path)
+		}
+		members.remove([]v1.Member{{Value: m[1]}})
+		return nil
+	}
+
+	switch lower {
+	case "displayname":
+		if op == "remove" {
+			return domain.NewSCIMError(http.StatusBadRequest, domain.SCIMTypeInvalidValue, "displayName is required")
+		}
+		return unmarshalSCIMValue(value, &group.Name)
+	case "externalid":
+		group.SyncId = ""
+		if op != "remove" {
+			return unmarshalSCIMValue(value, &group.SyncId)
+		}
+	case "members":
+		var list []v1.Member
+		if len(value) > 0 {
+			if err := unmarshalSCIMValue(value, &list); err != nil {
+				return err
+			}
+		}
+		switch op {
+		case "add":
+			return members.add(list)
+		case "replace":
+			*members = scimGroupMembers{}
+			return members.add(list)
+		case "remove":
+			// 未指定 value 时移除全部成员
+			if len(value) == 0 {
+				*members = scimGroupMembers{}
+				return nil
+			}
+			members.remove(list)
+		}
+	default:
+		// 忽略 id 等只读或不支持的属性
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/chaitin/panda-wiki/api/scim/v1"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func assertSCIMError(t *testing.T, err error, scimType string) {
+	t.Helper()
+	var scimErr *domain.SCIMError
+	require.True(t, errors.As(err, &scimErr), "expected scim error, got %v", err)
+	assert.Equal(t, http.StatusBadRequest, scimErr.Status)
+	assert.Equal(t, scimType, scimErr.ScimType)
+}
+
+func TestParseSCIMFilter(t *testing.T) {
+	attrs := map[string]string{
+		"username":   "user_name",
+		"externalid": "external_id",
+	}
+	tests := []struct {
+		name   string
+		filter string
+		column string
+		value  string
+		err    bool
+	}{
+		{"empty", "", "", "", false},
+		{"eq", `userName eq "alice"`, "user_name", "alice", false},
+		{"attribute is case insensitive", `EXTERNALID eq "a-1"`, "external_id", "a-1", false},
+		{"surrounding spaces", `  userName   eq   "bob"  `, "user_name", "bob", false},
+		{"escaped quote", `userName eq "a\"b"`, "user_name", `a"b`, false},
+		{"unicode", `userName eq "张三"`, "user_name", "张三", false},
+		{"unsupported operator", `userName co "a"`, "", "", true},
+		{"unquoted value", `userName eq alice`, "", "", true},
+		{"compound filter", `userName eq "a" and active eq "true"`, "", "", true},
+		{"unsupported attribute", `emails eq "a@b.c"`, "", "", true},
+		{"invalid escape", `userName eq "a\x"`, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, value, err := parseSCIMFilter(tt.filter, attrs)
+			if tt.err {
+				assertSCIMError(t, err, domain.SCIMTypeInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.column, column)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
+
+func TestSCIMPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		startIndex int
+		count      int
+		start      int
+		offset     int
+		limit      int
+	}{
+		{"defaults", 0, 0, 1, 0, scimMaxResults},
+		{"second page", 11, 10, 11, 10, 10},
+		{"negative start", -5, 10, 1, 0, 10},
+		{"count over max", 1, scimMaxResults + 1, 1, 0, scimMaxResults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, offset, limit := scimPage(tt.startIndex, tt.count)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.offset, offset)
+			assert.Equal(t, tt.limit, limit)
+		})
+	}
+}
+
+func TestPatchSCIMUser(t *testing.T) {
+	active := true
+	tests := []struct {
+		name     string
+		op       v1.PatchOperation
+		expected v1.User
+		err      string
+	}{
+		{
+			"replace active with azure string",
+			v1.PatchOperation{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+			v1.User{UserName: "alice", DisplayName: "Alice", Active: new(bool)},
+			"",
+		},
+		{
+			"replace without path",
+			v1.PatchOperation{Op: "replace", Value: json.RawMessage(`{"displayName":"Bob","name.givenName":"B"}`)},
+			v1.User{UserName: "alice", DisplayName: "Bob", Name: &v1.Name{GivenName: "B"}, Active: &active},
+			"",
+		},
+		{
+			"remove display name",
+			v1.PatchOperation{Op: "remove", Path: "displayName"},
+			v1.User{UserName: "alice", Active: &active},
+			"",
+		},
+		{
+			"replace work email",
+			v1.PatchOperation{Op: "replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"a@b.c"`)},
+			v1.User{UserName: "alice", DisplayName: "Alice", Emails: []v1.MultiValue{{Value: "a@b.c", Type: "work", Primary: true}}, Active: &active},
+			"",
+		},
+		{
+			"schema prefixed path",
+			v1.PatchOperation{Op: "add", Path: v1.SchemaUser + ":externalId", Value: json.RawMessage(`"e-1"`)},
+			v1.User{UserName: "alice", DisplayName: "Alice", ExternalID: "e-1", Active: &active},
+			"",
+		},
+		{
+			"unknown attribute is ignored",
+			v1.PatchOperation{Op: "replace", Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", Value: json.RawMessage(`"R&D"`)},
+			v1.User{UserName: "alice", DisplayName: "Alice", Active: &active},
+			"",
+		},
+		{"remove user name", v1.PatchOperation{Op: "remove", Path: "userName"}, v1.User{}, domain.SCIMTypeInvalidValue},
+		{"remove without path", v1.PatchOperation{Op: "remove"}, v1.User{}, domain.SCIMTypeInvalidPath},
+		{"unsupported op", v1.PatchOperation{Op: "move", Path: "active"}, v1.User{}, domain.SCIMTypeInvalidValue},
+		{"invalid boolean", v1.PatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`"yes"`)}, v1.User{}, domain.SCIMTypeInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			on := true
+			user := v1.User{UserName: "alice", DisplayName: "Alice", Active: &on}
+			err := patchSCIMUser(&user, tt.op)
+			if tt.err != "" {
+				assertSCIMError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, user)
+		})
+	}
+}
+
+func TestSCIMGroupMembers(t *testing.T) {
+	m := &scimGroupMembers{}
+	require.NoError(t, m.add([]v1.Member{
+		{Value: "1"},
+		{Value: "2", Type: "User"},
+		{Value: "1"},
+		{Value: "3", Type: "Group"},
+		{Value: "4", Ref: "https://wiki/share/v1/openapi/scim/kb/Groups/4"},
+	}))
+	assert.Equal(t, []uint{1, 2}, m.authIDs)
+	assert.Equal(t, []uint{3, 4}, m.groupIDs)
+
+	m.remove([]v1.Member{{Value: "2", Type: "User"}, {Value: "3", Type: "User"}, {Value: "x"}})
+	assert.Equal(t, []uint{1}, m.authIDs)
+	assert.Equal(t, []uint{3, 4}, m.groupIDs)
+
+	// 未指明类型时用户和子分组都移除
+	m.remove([]v1.Member{{Value: "1"}, {Value: "4"}})
+	assert.Empty(t, m.authIDs)
+	assert.Equal(t, []uint{3}, m.groupIDs)
+
+	err := m.add([]v1.Member{{Value: "abc"}})
+	assertSCIMError(t, err, domain.SCIMTypeInvalidValue)
+}
@@ -2,51 +2,370 @@ package usecase
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/consts"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	// 单个 sitemap 文件最多 50000 个地址
+	sitemapPageSize = 50000
+	// llms-full.txt 每批读取的文档数
+	llmsFullBatchSize = 100
 )
 
 type SitemapUsecase struct {
-	nodeUsecase *pg.NodeRepository
-	appUsecase  *pg.KnowledgeBaseRepository
-	logger      *log.Logger
+	nodeRepo *pg.NodeRepository
+	kbRepo   *pg.KnowledgeBaseRepository
+	navRepo  *pg.NavRepository
+	appRepo  *pg.AppRepository
+	mdConv   *converter.Converter
+	logger   *log.Logger
 }
 
-func NewSitemapUsecase(nodeUsecase *pg.NodeRepository, appUsecase *pg.KnowledgeBaseRepository, logger *log.Logger) *SitemapUsecase {
-	return &SitemapUsecase{nodeUsecase: nodeUsecase, appUsecase: appUsecase, logger: logger.WithModule("usecase.sitemap")}
+func NewSitemapUsecase(nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, navRepo *pg.NavRepository, appRepo *pg.AppRepository, logger *log.Logger) *SitemapUsecase {
+	return &SitemapUsecase{
+		nodeRepo: nodeRepo,
+		kbRepo:   kbRepo,
+		navRepo:  navRepo,
+		appRepo:  appRepo,
+		mdConv:   rag.NewHTML2MDConverter(),
+		logger:   logger.WithModule("usecase.sitemap"),
+	}
 }
 
-func (u *SitemapUsecase) GetSitemap(ctx context.Context, kbID string) (string, error) {
-	nodes, err := u.nodeUsecase.GetNodeReleaseListByKBID(ctx, kbID)
+// 知识库只有一种语言，没有可以用 hreflang 关联的其他语言版本
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// getPublicRelease 获取公开知识库的最新发布版本，知识库未公开或未发布时 release 为 nil
+func (u *SitemapUsecase) getPublicRelease(ctx context.Context, kbID string) (*domain.KnowledgeBase, *domain.KBRelease, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get node release list: %w", err)
+		return nil, nil, fmt.Errorf("failed to get knowledge base: %w", err)
 	}
+	if !kb.AccessSettings.IsPublic() {
+		return kb, nil, nil
+	}
+	release, err := u.kbRepo.GetLatestRelease(ctx, kbID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return kb, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	return kb, release, nil
+}
 
-	kb, err := u.appUsecase.GetKnowledgeBaseByID(ctx, kbID)
+// GetSitemap page 为 0 时返回 sitemap 索引，否则返回对应分页的地址列表
+func (u *SitemapUsecase) GetSitemap(ctx context.Context, kbID string, page int) ([]byte, error) {
+	kb, release, err := u.getPublicRelease(ctx, kbID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get knowledge base: %w", err)
+		return nil, err
+	}
+	baseURL := kb.AccessSettings.GetBaseUrl()
+
+	var docs []*domain.ShareNodeListItemResp
+	if release != nil {
+		children, err := u.getPublicNodeTree(ctx, kbID, release.ID)
+		if err != nil {
+			return nil, err
+		}
+		docs = collectPublicDocs(children, children[""])
+	}
+	if page == 0 {
+		return marshalSitemap(buildSitemapIndex(baseURL, release, len(docs)))
+	}
+	urlSet, err := buildSitemapURLSet(baseURL, release, docs, page)
+	if err != nil {
+		return nil, err
+	}
+	return marshalSitemap(urlSet)
+}
+
+// sitemapPageCount 首页占第一页的一个地址
+func sitemapPageCount(docCount int) int {
+	return (docCount + sitemapPageSize) / sitemapPageSize
+}
+
+func buildSitemapIndex(baseURL string, release *domain.KBRelease, docCount int) sitemapIndex {
+	index := sitemapIndex{}
+	for i := 1; i <= sitemapPageCount(docCount); i++ {
+		loc := sitemapURL{Loc: fmt.Sprintf("%s/sitemap.xml?page=%d", baseURL, i)}
+		if release != nil {
+			loc.LastMod = release.CreatedAt.Format(time.DateOnly)
+		}
+		index.Sitemaps = append(index.Sitemaps, loc)
 	}
+	return index
+}
 
+func buildSitemapURLSet(baseURL string, release *domain.KBRelease, docs []*domain.ShareNodeListItemResp, page int) (sitemapURLSet, error) {
+	urlSet := sitemapURLSet{URLs: []sitemapURL{}}
+	if page < 1 || page > sitemapPageCount(len(docs)) {
+		return urlSet, fmt.Errorf("sitemap page %d out of range", page)
+	}
+	if release == nil {
+		return urlSet, nil
+	}
+	start, end := (page-1)*sitemapPageSize-1, page*sitemapPageSize-1
+	if page == 1 {
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     fmt.Sprintf("%s/welcome", baseURL),
+			LastMod: release.CreatedAt.Format(time.DateOnly),
+		})
+		start = 0
+	}
+	for _, doc := range docs[start:min(end, len(docs))] {
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     doc.GetURL(baseURL),
+			LastMod: doc.UpdatedAt.Format(time.DateOnly),
+		})
+	}
+	return urlSet, nil
+}
+
+func marshalSitemap(v any) ([]byte, error) {
+	buf, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
+
+// GetRobots 未公开的知识库禁止所有爬虫
+func (u *SitemapUsecase) GetRobots(ctx context.Context, kbID string) (string, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get knowledge base: %w", err)
+	}
 	sb := strings.Builder{}
-	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	sb.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	sb.WriteString("User-agent: *\n")
+	if !kb.AccessSettings.IsPublic() {
+		sb.WriteString("Disallow: /\n")
+		return sb.String(), nil
+	}
+	sb.WriteString("Allow: /\n\n")
+	sb.WriteString(fmt.Sprintf("Sitemap: %s/sitemap.xml\n", kb.AccessSettings.GetBaseUrl()))
+	return sb.String(), nil
+}
+
+type llmsSection struct {
+	name  string
+	nodes []*domain.ShareNodeListItemResp
+}
 
-	// add welcome
-	sb.WriteString(fmt.Sprintf(`<url><loc>%s/welcome</loc><lastmod>%s</lastmod></url>`, kb.AccessSettings.BaseURL, time.Now().Format(time.DateOnly)))
+// getPublicNodeTree 获取可以公开给爬虫的节点，按父节点分组
+func (u *SitemapUsecase) getPublicNodeTree(ctx context.Context, kbID, releaseID string) (map[string][]*domain.ShareNodeListItemResp, error) {
+	nodes, err := u.nodeRepo.GetPublicNodeReleaseList(ctx, kbID, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node release list: %w", err)
+	}
+	return filterPublicNodeTree(nodes), nil
+}
 
-	// add nodes
+// filterPublicNodeTree 从根节点开始保留导航内公开可见的节点，文档还需公开可访问，
+// 与导出静态站点一致，未公开节点下的所有节点都不再保留
+func filterPublicNodeTree(nodes []*domain.ShareNodeListItemResp) map[string][]*domain.ShareNodeListItemResp {
+	all := make(map[string][]*domain.ShareNodeListItemResp)
+	for _, node := range nodes {
+		all[node.ParentID] = append(all[node.ParentID], node)
+	}
+	children := make(map[string][]*domain.ShareNodeListItemResp)
+	var walk func(parentID string)
+	walk = func(parentID string) {
+		for _, node := range all[parentID] {
+			if node.Permissions.Visible != consts.NodeAccessPermOpen {
+				continue
+			}
+			if node.Type != domain.NodeTypeFolder && node.Permissions.Visitable != consts.NodeAccessPermOpen {
+				continue
+			}
+			children[parentID] = append(children[parentID], node)
+			walk(node.ID)
+		}
+	}
+	walk("")
+	return children
+}
+
+// collectPublicDocs 按目录顺序收集 nodes 及其下的所有文档
+func collectPublicDocs(children map[string][]*domain.ShareNodeListItemResp, nodes []*domain.ShareNodeListItemResp) []*domain.ShareNodeListItemResp {
+	var docs []*domain.ShareNodeListItemResp
 	for _, node := range nodes {
 		if node.Type == domain.NodeTypeDocument {
-			sb.WriteString(fmt.Sprintf(`<url><loc>%s</loc><lastmod>%s</lastmod></url>`, node.GetURL(kb.AccessSettings.BaseURL), node.UpdatedAt.Format(time.DateOnly)))
+			docs = append(docs, node)
 		}
+		docs = append(docs, collectPublicDocs(children, children[node.ID])...)
 	}
+	return docs
+}
 
-	sb.WriteString(`</urlset>`)
+// getLLMsSections 按栏目整理公开的文档树
+func (u *SitemapUsecase) getLLMsSections(ctx context.Context, kbID, releaseID string) ([]*llmsSection, map[string][]*domain.ShareNodeListItemResp, error) {
+	children, err := u.getPublicNodeTree(ctx, kbID, releaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	navs, err := u.navRepo.GetReleaseList(ctx, kbID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get nav list: %w", err)
+	}
+
+	// 没有栏目的文档放在最前面
+	sections := []*llmsSection{{}}
+	sectionIndex := make(map[string]int, len(navs))
+	for _, nav := range navs {
+		sectionIndex[nav.ID] = len(sections)
+		sections = append(sections, &llmsSection{name: nav.Name})
+	}
+	for _, node := range children[""] {
+		idx := sectionIndex[node.NavId]
+		sections[idx].nodes = append(sections[idx].nodes, node)
+	}
+	return sections, children, nil
+}
+
+// GetLLMsText 按 llms.txt 格式输出公开文档的目录和摘要
+func (u *SitemapUsecase) GetLLMsText(ctx context.Context, kbID string) (string, error) {
+	kb, release, err := u.getPublicRelease(ctx, kbID)
+	if err != nil {
+		return "", err
+	}
+	baseURL := kb.AccessSettings.GetBaseUrl()
+
+	title, desc := kb.Name, ""
+	if app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, kbID, domain.AppTypeWeb); err == nil {
+		if app.Settings.Title != "" {
+			title = app.Settings.Title
+		}
+		desc = app.Settings.Desc
+	} else {
+		u.logger.Warn("get web app failed", log.String("kb_id", kbID), log.Error(err))
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# %s\n\n", llmsLine(title)))
+	if desc != "" {
+		sb.WriteString(fmt.Sprintf("> %s\n\n", llmsLine(desc)))
+	}
+	if release == nil {
+		return sb.String(), nil
+	}
+	sb.WriteString(fmt.Sprintf("完整文档内容见 %s/llms-full.txt\n", baseURL))
 
+	sections, children, err := u.getLLMsSections(ctx, kbID, release.ID)
+	if err != nil {
+		return "", err
+	}
+	var writeNodes func(nodes []*domain.ShareNodeListItemResp, depth int)
+	writeNodes = func(nodes []*domain.ShareNodeListItemResp, depth int) {
+		for _, node := range nodes {
+			sb.WriteString(strings.Repeat("  ", depth))
+			if node.Type == domain.NodeTypeFolder {
+				sb.WriteString(fmt.Sprintf("- %s\n", llmsLine(node.Name)))
+			} else {
+				sb.WriteString(fmt.Sprintf("- [%s](%s)", llmsLine(node.Name), node.GetURL(baseURL)))
+				if summary := llmsLine(node.Meta.Summary); summary != "" {
+					sb.WriteString(": " + summary)
+				}
+				sb.WriteString("\n")
+			}
+			writeNodes(children[node.ID], depth+1)
+		}
+	}
+	for _, section := range sections {
+		if len(section.nodes) == 0 {
+			continue
+		}
+		sb.WriteString("\n")
+		if section.name != "" {
+			sb.WriteString(fmt.Sprintf("## %s\n\n", llmsLine(section.name)))
+		}
+		writeNodes(section.nodes, 0)
+	}
 	return sb.String(), nil
 }
+
+// WriteLLMsFullText 按目录顺序输出所有公开文档的 Markdown 正文
+func (u *SitemapUsecase) WriteLLMsFullText(ctx context.Context, kbID string, w io.Writer) error {
+	kb, release, err := u.getPublicRelease(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return nil
+	}
+	baseURL := kb.AccessSettings.GetBaseUrl()
+
+	sections, children, err := u.getLLMsSections(ctx, kbID, release.ID)
+	if err != nil {
+		return err
+	}
+	var docIDs []string
+	for _, section := range sections {
+		for _, doc := range collectPublicDocs(children, section.nodes) {
+			docIDs = append(docIDs, doc.ID)
+		}
+	}
+
+	for start := 0; start < len(docIDs); start += llmsFullBatchSize {
+		batch := docIDs[start:min(start+llmsFullBatchSize, len(docIDs))]
+		releases, err := u.nodeRepo.GetPublicDocReleasesByNodeIDs(ctx, kbID, release.ID, batch)
+		if err != nil {
+			return fmt.Errorf("failed to get node releases: %w", err)
+		}
+		releaseMap := make(map[string]*domain.NodeRelease, len(releases))
+		for _, r := range releases {
+			releaseMap[r.NodeID] = r
+		}
+		for _, nodeID := range batch {
+			r, ok := releaseMap[nodeID]
+			if !ok {
+				continue
+			}
+			content := r.Content
+			if utils.IsLikelyHTML(content) {
+				if md, err := u.mdConv.ConvertString(content); err == nil {
+					content = md
+				} else {
+					u.logger.Warn("convert html to markdown failed", log.String("node_id", nodeID), log.Error(err))
+				}
+			}
+			if _, err := fmt.Fprintf(w, "# %s\n\n来源: %s/node/%s\n\n%s\n\n", llmsLine(r.Name), baseURL, nodeID, strings.TrimSpace(content)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// llmsLine 去掉换行，避免破坏 Markdown 列表结构
+func llmsLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
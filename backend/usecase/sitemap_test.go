@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func sitemapTestNode(id, parentID string, nodeType domain.NodeType, visible, visitable consts.NodeAccessPerm) *domain.ShareNodeListItemResp {
+	return &domain.ShareNodeListItemResp{
+		ID:          id,
+		ParentID:    parentID,
+		Type:        nodeType,
+		Permissions: domain.NodePermissions{Visible: visible, Visitable: visitable},
+	}
+}
+
+func TestFilterPublicNodeTree(t *testing.T) {
+	const (
+		open    = consts.NodeAccessPermOpen
+		partial = consts.NodeAccessPermPartial
+		closed  = consts.NodeAccessPermClosed
+		doc     = domain.NodeTypeDocument
+		folder  = domain.NodeTypeFolder
+	)
+
+	tests := []struct {
+		name     string
+		nodes    []*domain.ShareNodeListItemResp
+		expected []string
+	}{
+		{
+			name:     "public docs",
+			nodes:    []*domain.ShareNodeListItemResp{sitemapTestNode("a", "", doc, open, open), sitemapTestNode("b", "", doc, open, open)},
+			expected: []string{"a", "b"},
+		},
+		{
+			name: "doc not visitable",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("a", "", doc, open, closed),
+				sitemapTestNode("b", "", doc, open, partial),
+				sitemapTestNode("c", "", doc, open, open),
+			},
+			expected: []string{"c"},
+		},
+		{
+			name: "doc not visible",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("a", "", doc, closed, open),
+				sitemapTestNode("b", "", doc, partial, open),
+			},
+			expected: []string{},
+		},
+		{
+			name: "public folder",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("f", "", folder, open, closed),
+				sitemapTestNode("a", "f", doc, open, open),
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "docs under hidden folder",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("f", "", folder, partial, open),
+				sitemapTestNode("a", "f", doc, open, open),
+				sitemapTestNode("g", "f", folder, open, open),
+				sitemapTestNode("b", "g", doc, open, open),
+			},
+			expected: []string{},
+		},
+		{
+			name: "docs under nested hidden folder",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("f", "", folder, open, open),
+				sitemapTestNode("a", "f", doc, open, open),
+				sitemapTestNode("g", "f", folder, closed, open),
+				sitemapTestNode("b", "g", doc, open, open),
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "docs under unpublished folder",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("a", "missing", doc, open, open),
+			},
+			expected: []string{},
+		},
+		{
+			name: "children of closed doc",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("a", "", doc, open, closed),
+				sitemapTestNode("b", "a", doc, open, open),
+			},
+			expected: []string{},
+		},
+		{
+			name: "directory order",
+			nodes: []*domain.ShareNodeListItemResp{
+				sitemapTestNode("f", "", folder, open, open),
+				sitemapTestNode("a", "", doc, open, open),
+				sitemapTestNode("b", "f", doc, open, open),
+				sitemapTestNode("g", "f", folder, open, open),
+				sitemapTestNode("c", "g", doc, open, open),
+				sitemapTestNode("d", "f", doc, open, open),
+			},
+			expected: []string{"b", "c", "d", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children := filterPublicNodeTree(tt.nodes)
+			docs := collectPublicDocs(children, children[""])
+			assert.Equal(t, tt.expected, lo.Map(docs, func(node *domain.ShareNodeListItemResp, _ int) string {
+				return node.ID
+			}))
+		})
+	}
+}
+
+func TestBuildSitemap(t *testing.T) {
+	const baseURL = "https://wiki.example.com"
+	release := &domain.KBRelease{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	updatedAt := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	docs := func(n int) []*domain.ShareNodeListItemResp {
+		docs := make([]*domain.ShareNodeListItemResp, n)
+		for i := range docs {
+			docs[i] = &domain.ShareNodeListItemResp{ID: fmt.Sprintf("doc-%d", i), UpdatedAt: updatedAt}
+		}
+		return docs
+	}
+
+	tests := []struct {
+		name     string
+		release  *domain.KBRelease
+		docs     int
+		page     int
+		wantErr  bool
+		urls     int
+		firstURL string
+		lastURL  string
+	}{
+		{name: "no release", release: nil, docs: 0, page: 1, urls: 0},
+		{name: "no docs", release: release, docs: 0, page: 1, urls: 1, firstURL: baseURL + "/welcome", lastURL: baseURL + "/welcome"},
+		{name: "single page", release: release, docs: 3, page: 1, urls: 4, firstURL: baseURL + "/welcome", lastURL: baseURL + "/node/doc-2"},
+		{name: "full first page", release: release, docs: sitemapPageSize - 1, page: 1, urls: sitemapPageSize, lastURL: fmt.Sprintf("%s/node/doc-%d", baseURL, sitemapPageSize-2)},
+		{name: "second page", release: release, docs: sitemapPageSize, page: 2, urls: 1, firstURL: fmt.Sprintf("%s/node/doc-%d", baseURL, sitemapPageSize-1)},
+		{name: "full second page", release: release, docs: 2*sitemapPageSize - 1, page: 2, urls: sitemapPageSize, firstURL: fmt.Sprintf("%s/node/doc-%d", baseURL, sitemapPageSize-1), lastURL: fmt.Sprintf("%s/node/doc-%d", baseURL, 2*sitemapPageSize-2)},
+		{name: "page out of range", release: release, docs: sitemapPageSize - 1, page: 2, wantErr: true},
+		{name: "page zero", release: release, docs: 3, page: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urlSet, err := buildSitemapURLSet(baseURL, tt.release, docs(tt.docs), tt.page)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, urlSet.URLs, tt.urls)
+			if tt.firstURL != "" {
+				assert.Equal(t, tt.firstURL, urlSet.URLs[0].Loc)
+			}
+			if tt.lastURL != "" {
+				assert.Equal(t, tt.lastURL, urlSet.URLs[len(urlSet.URLs)-1].Loc)
+			}
+		})
+	}
+
+	// 相邻分页的地址不重复也不遗漏
+	all := docs(2*sitemapPageSize + 10)
+	seen := make(map[string]bool)
+	for page := 1; page <= sitemapPageCount(len(all)); page++ {
+		urlSet, err := buildSitemapURLSet(baseURL, release, all, page)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(urlSet.URLs), sitemapPageSize)
+		for _, u := range urlSet.URLs {
+			assert.False(t, seen[u.Loc], u.Loc)
+			seen[u.Loc] = true
+		}
+	}
+	assert.Len(t, seen, len(all)+1)
+}
+
+func TestBuildSitemapIndex(t *testing.T) {
+	release := &domain.KBRelease{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	tests := []struct {
+		name     string
+		release  *domain.KBRelease
+		docs     int
+		expected []sitemapURL
+	}{
+		{"no release", nil, 0, []sitemapURL{{Loc: "https://a.com/sitemap.xml?page=1"}}},
+		{"one page", release, sitemapPageSize - 1, []sitemapURL{{Loc: "https://a.com/sitemap.xml?page=1", LastMod: "2024-01-02"}}},
+		{"two pages", release, sitemapPageSize, []sitemapURL{
+			{Loc: "https://a.com/sitemap.xml?page=1", LastMod: "2024-01-02"},
+			{Loc: "https://a.com/sitemap.xml?page=2", LastMod: "2024-01-02"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildSitemapIndex("https://a.com", tt.release, tt.docs).Sitemaps)
+		})
+	}
+}
+
+func TestLLMsLine(t *testing.T) {
+	assert.Equal(t, "a b c", llmsLine(" a\nb \t c\r\n"))
+	assert.Equal(t, "", llmsLine(" \n "))
+}
@@ -457,8 +457,11 @@ func (u *StatUseCase) AggregateHourlyStats(ctx context.Context) error {
 	return nil
 }
 
-// CleanupOldHourlyStats 清理90天前的小时统计数据
+// CleanupOldHourlyStats 清理90天前的小时统计数据和文档阅读行为数据
 func (u *StatUseCase) CleanupOldHourlyStats(ctx context.Context) error {
+	if err := u.repo.CleanupOldNodeEvents(ctx); err != nil {
+		return err
+	}
 	return u.repo.CleanupOldHourlyStats(ctx)
 }
 
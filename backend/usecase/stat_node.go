@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/stat/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func (u *StatUseCase) RecordNodeEvent(ctx context.Context, event *domain.StatNodeEvent) error {
+	exists, err := u.nodeRepo.NodeExistsInKB(ctx, event.KBID, event.NodeID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("node not found")
+	}
+	switch event.Type {
+	case domain.StatNodeEventLinkClick:
+		if event.LinkURL == "" {
+			return errors.New("link url is required")
+		}
+	case domain.StatNodeEventFeedback:
+		if event.Score != domain.Like && event.Score != domain.DisLike {
+			return errors.New("score is required")
+		}
+		app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, event.KBID, domain.AppTypeWeb)
+		if err != nil {
+			return err
+		}
+		if app.Settings.DocumentFeedBackIsEnabled == nil || !*app.Settings.DocumentFeedBackIsEnabled {
+			return errors.New("document feedback is disabled")
+		}
+		return u.repo.UpsertStatNodeFeedback(ctx, event)
+	}
+	return u.repo.CreateStatNodeEvent(ctx, event)
+}
+
+func statDayStart(day consts.StatDay) time.Time {
+	if day == 0 {
+		day = consts.StatDay1
+	}
+	return time.Now().Add(-time.Duration(day) * 24 * time.Hour)
+}
+
+// GetNodeEngagementList 各文档的阅读行为汇总，按访问量排序
+func (u *StatUseCase) GetNodeEngagementList(ctx context.Context, kbID string, day consts.StatDay) ([]*domain.NodeEngagement, error) {
+	start := statDayStart(day)
+	list, err := u.repo.GetNodeEngagementList(ctx, kbID, start)
+	if err != nil {
+		return nil, err
+	}
+	pvMap, err := u.repo.GetHotPagesByRange(ctx, kbID, start.Truncate(time.Hour), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	engagementMap := lo.SliceToMap(list, func(item *domain.NodeEngagement) (string, *domain.NodeEngagement) {
+		return item.NodeID, item
+	})
+	for nodeID, pv := range pvMap {
+		if item, ok := engagementMap[nodeID]; ok {
+			item.PV = pv
+		} else {
+			list = append(list, &domain.NodeEngagement{NodeID: nodeID, PV: pv})
+		}
+	}
+
+	nodeNames, err := u.nodeRepo.GetNodeNameByNodeIDs(ctx, lo.Map(list, func(item *domain.NodeEngagement, _ int) string {
+		return item.NodeID
+	}))
+	if err != nil {
+		return nil, err
+	}
+	// 过滤已删除的文档
+	list = lo.Filter(list, func(item *domain.NodeEngagement, _ int) bool {
+		item.NodeName = nodeNames[item.NodeID]
+		return item.NodeName != ""
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PV > list[j].PV
+	})
+	return list, nil
+}
+
+// GetNodeAnalytics 单个文档的阅读行为汇总及按天趋势
+func (u *StatUseCase) GetNodeAnalytics(ctx context.Context, kbID, nodeID string, day consts.StatDay) (*v1.StatNodeAnalyticsResp, error) {
+	start := statDayStart(day)
+	summary, err := u.repo.GetNodeEngagement(ctx, kbID, nodeID, start)
+	if err != nil {
+		return nil, err
+	}
+	trend, err := u.repo.GetNodeEngagementTrend(ctx, kbID, nodeID, start)
+	if err != nil {
+		return nil, err
+	}
+	pvTrend, err := u.repo.GetNodePVTrend(ctx, kbID, nodeID, start.Truncate(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	topLinks, err := u.repo.GetNodeLinkClicks(ctx, kbID, nodeID, start, 10)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames, err := u.nodeRepo.GetNodeNameByNodeIDs(ctx, []string{nodeID})
+	if err != nil {
+		return nil, err
+	}
+	summary.NodeName = nodeNames[nodeID]
+
+	// 补齐没有阅读事件但有访问量的日期
+	trendMap := lo.SliceToMap(trend, func(item *domain.NodeEngagementTrendItem) (string, *domain.NodeEngagementTrendItem) {
+		return item.Date.Format(time.DateOnly), item
+	})
+	for date, pv := range pvTrend {
+		summary.PV += pv
+		if item, ok := trendMap[date]; ok {
+			item.PV = pv
+			continue
+		}
+		d, err := time.ParseInLocation(time.DateOnly, date, time.Local)
+		if err != nil {
+			continue
+		}
+		item := &domain.NodeEngagementTrendItem{Date: d, NodeEngagement: domain.NodeEngagement{NodeID: nodeID, PV: pv}}
+		trendMap[date] = item
+		trend = append(trend, item)
+	}
+	sort.Slice(trend, func(i, j int) bool {
+		return trend[i].Date.Before(trend[j].Date)
+	})
+
+	return &v1.StatNodeAnalyticsResp{
+		Summary:  summary,
+		Trend:    trend,
+		TopLinks: topLinks,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/stat/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+const statReportTopN = 10
+
+type StatReportUsecase struct {
+	statRepo          *pg.StatRepository
+	nodeRepo          *pg.NodeRepository
+	kbRepo            *pg.KnowledgeBaseRepository
+	systemSettingRepo *pg.SystemSettingRepo
+	logger            *log.Logger
+}
+
+func NewStatReportUsecase(statRepo *pg.StatRepository, nodeRepo *pg.NodeRepository, kbRepo *pg.KnowledgeBaseRepository, systemSettingRepo *pg.SystemSettingRepo, logger *log.Logger) *StatReportUsecase {
+	return &StatReportUsecase{
+		statRepo:          statRepo,
+		nodeRepo:          nodeRepo,
+		kbRepo:            kbRepo,
+		systemSettingRepo: systemSettingRepo,
+		logger:            logger.WithModule("usecase.stat_report"),
+	}
+}
+
+func (u *StatReportUsecase) GetList(ctx context.Context, kbID string) ([]*domain.StatReport, error) {
+	return u.statRepo.GetStatReportList(ctx, kbID)
+}
+
+func (u *StatReportUsecase) Create(ctx context.Context, req *v1.StatReportCreateReq) (string, error) {
+	if err := validateNotifyChannels(req.Channels); err != nil {
+		return "", err
+	}
+	report := &domain.StatReport{
+		ID:       uuid.New().String(),
+		KBID:     req.KbID,
+		Name:     req.Name,
+		Period:   req.Period,
+		Enabled:  req.Enabled,
+		Channels: req.Channels,
+	}
+	if err := u.statRepo.CreateStatReport(ctx, report); err != nil {
+		return "", err
+	}
+	return report.ID, nil
+}
+
+func (u *StatReportUsecase) Update(ctx context.Context, req *v1.StatReportUpdateReq) error {
+	if err := validateNotifyChannels(req.Channels); err != nil {
+		return err
+	}
+	if _, err := u.statRepo.GetStatReport(ctx, req.KbID, req.ID); err != nil {
+		return err
+	}
+	return u.statRepo.UpdateStatReport(ctx, &domain.StatReport{
+		ID:       req.ID,
+		KBID:     req.KbID,
+		Name:     req.Name,
+		Period:   req.Period,
+		Enabled:  req.Enabled,
+		Channels: req.Channels,
+	})
+}
+
+func (u *StatReportUsecase) Delete(ctx context.Context, kbID, id string) error {
+	return u.statRepo.DeleteStatReport(ctx, kbID, id)
+}
+
+// Send 立即发送一次报表
+func (u *StatReportUsecase) Send(ctx context.Context, kbID, id string) error {
+	report, err := u.statRepo.GetStatReport(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	return u.sendReport(ctx, report, time.Now())
+}
+
+// SendScheduledReports 发送所有已启用且周期匹配的报表，由定时任务调用
+func (u *StatReportUsecase) SendScheduledReports(ctx context.Context, period consts.StatReportPeriod) error {
+	reports, err := u.statRepo.GetEnabledStatReports(ctx, period)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, report := range reports {
+		if err := u.sendReport(ctx, report, now); err != nil {
+			u.logger.Error("send stat report failed", log.Error(err), log.String("kb_id", report.KBID), log.String("report_id", report.ID))
+		}
+	}
+	return nil
+}
+
+func (u *StatReportUsecase) sendReport(ctx context.Context, report *domain.StatReport, now time.Time) error {
+	digest, err := u.BuildDigest(ctx, report.KBID, report.Period, now)
+	if err != nil {
+		return fmt.Errorf("build digest failed: %w", err)
+	}
+	title := fmt.Sprintf("%s - %s", digest.KBName, report.Period.Name())
+	content := u.RenderMarkdown(ctx, digest)
+
+	sendErr := sendNotify(ctx, u.systemSettingRepo, report.Channels, title, content)
+	if err := u.statRepo.UpdateStatReportSentAt(ctx, report.ID, now); err != nil {
+		u.logger.Warn("update stat report sent at failed", log.Error(err), log.String("report_id", report.ID))
+	}
+	return sendErr
+}
+
+// BuildDigest 统计截至 now 当天零点之前一个周期内的数据
+func (u *StatReportUsecase) BuildDigest(ctx context.Context, kbID string, period consts.StatReportPeriod, now time.Time) (*domain.StatReportDigest, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, 0, -period.Days())
+
+	digest := &domain.StatReportDigest{
+		KBID:   kbID,
+		KBName: kb.Name,
+		Period: period,
+		Start:  start,
+		End:    end,
+	}
+
+	trend, err := u.statRepo.GetStatTrendByDay(ctx, kbID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	trendMap := lo.SliceToMap(trend, func(item *domain.StatTrendItem) (string, *domain.StatTrendItem) {
+		return item.Date.In(now.Location()).Format(time.DateOnly), item
+	})
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		item, ok := trendMap[day.Format(time.DateOnly)]
+		if !ok {
+			item = &domain.StatTrendItem{}
+		}
+		item.Date = day
+		digest.Trend = append(digest.Trend, item)
+		digest.PageVisitCount += item.PageVisitCount
+		digest.IPCount += item.IPCount
+		digest.ConversationCount += item.ConversationCount
+	}
+
+	pageCounts, err := u.statRepo.GetHotPagesByRange(ctx, kbID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	hotPages := make([]*domain.HotPage, 0, len(pageCounts))
+	for nodeID, count := range pageCounts {
+		hotPages = append(hotPages, &domain.HotPage{NodeID: nodeID, Count: count})
+	}
+	sort.Slice(hotPages, func(i, j int) bool {
+		return hotPages[i].Count > hotPages[j].Count
+	})
+	if len(hotPages) > statReportTopN {
+		hotPages = hotPages[:statReportTopN]
+	}
+	nodeNames, err := u.nodeRepo.GetNodeNameByNodeIDs(ctx, lo.Map(hotPages, func(page *domain.HotPage, _ int) string {
+		return page.NodeID
+	}))
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range hotPages {
+		page.NodeName = nodeNames[page.NodeID]
+	}
+	digest.HotPages = hotPages
+
+	if digest.UnansweredQuestions, err = u.statRepo.GetUnansweredQuestions(ctx, kbID, start, end, statReportTopN); err != nil {
+		return nil, err
+	}
+	if digest.NegativeFeedbackCount, err = u.statRepo.GetNegativeFeedbackCount(ctx, kbID, start, end); err != nil {
+		return nil, err
+	}
+	if digest.TokenUsage, err = u.statRepo.GetTokenUsageByModel(ctx, kbID, start, end); err != nil {
+		return nil, err
+	}
+	for _, usage := range digest.TokenUsage {
+		digest.TotalTokens += usage.TotalTokens
+	}
+
+	return digest, nil
+}
+
+// RenderMarkdown 渲染报表内容，邮件和群机器人共用
+func (u *StatReportUsecase) RenderMarkdown(ctx context.Context, digest *domain.StatReportDigest) string {
+	baseURL := ""
+	if kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, digest.KBID); err == nil {
+		baseURL = kb.AccessSettings.GetBaseUrl()
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("### %s %s\n\n", digest.KBName, digest.Period.Name()))
+	sb.WriteString(fmt.Sprintf("统计周期：%s ~ %s\n\n", digest.Start.Format(time.DateOnly), digest.End.AddDate(0, 0, -1).Format(time.DateOnly)))
+
+	sb.WriteString("#### 概览\n\n")
+	sb.WriteString(fmt.Sprintf("- 访问量 (PV)：%d\n", digest.PageVisitCount))
+	sb.WriteString(fmt.Sprintf("- 访客数 (UV)：%d\n", digest.IPCount))
+	sb.WriteString(fmt.Sprintf("- 问答次数：%d\n", digest.ConversationCount))
+	sb.WriteString(fmt.Sprintf("- 差评数：%d\n", digest.NegativeFeedbackCount))
+	sb.WriteString(fmt.Sprintf("- Token 消耗：%d\n\n", digest.TotalTokens))
+
+	if len(digest.Trend) > 1 {
+		sb.WriteString("#### 访问趋势\n\n")
+		for _, item := range digest.Trend {
+			sb.WriteString(fmt.Sprintf("- %s：PV %d / UV %d / 问答 %d\n", item.Date.Format("01-02"), item.PageVisitCount, item.IPCount, item.ConversationCount))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(digest.HotPages) > 0 {
+		sb.WriteString("#### 热门文档\n\n")
+		for i, page := range digest.HotPages {
+			name := page.NodeName
+			if name == "" {
+				name = page.NodeID
+			}
+			if baseURL != "" {
+				name = fmt.Sprintf("[%s](%s/node/%s)", name, baseURL, page.NodeID)
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s (%d)\n", i+1, name, page.Count))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(digest.UnansweredQuestions) > 0 {
+		sb.WriteString("#### 未命中文档的问题\n\n")
+		for i, question := range digest.UnansweredQuestions {
+			sb.WriteString(fmt.Sprintf("%d. %s (%d)\n", i+1, strings.ReplaceAll(question.Question, "\n", " "), question.Count))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(digest.TokenUsage) > 0 {
+		sb.WriteString("#### Token 消耗\n\n")
+		for _, usage := range digest.TokenUsage {
+			sb.WriteString(fmt.Sprintf("- %s：输入 %d / 输出 %d / 合计 %d\n", usage.Model, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
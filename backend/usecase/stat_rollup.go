@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/stat/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const statDateLayout = "2006-01-02"
+
+// AggregateRollupStats 将已结束的天/月汇总到stat_page_rollups表，需在小时数据被清理前执行
+func (u *StatUseCase) AggregateRollupStats(ctx context.Context) error {
+	kbIds, err := u.kbRepo.GetKnowledgeBaseIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, kbId := range kbIds {
+		days, err := u.repo.GetUnrolledDays(ctx, kbId)
+		if err != nil {
+			return err
+		}
+		for _, day := range days {
+			hours, err := u.repo.GetStatPageHoursByRange(ctx, kbId, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				return err
+			}
+			rollup := domain.NewStatPageRollup(kbId, consts.StatGranularityDay, day)
+			for _, hour := range hours {
+				rollup.AddHour(hour)
+			}
+			if err := u.repo.CreateStatPageRollup(ctx, rollup); err != nil {
+				return err
+			}
+		}
+
+		months, err := u.repo.GetUnrolledMonths(ctx, kbId)
+		if err != nil {
+			return err
+		}
+		for _, month := range months {
+			dayRollups, err := u.repo.GetStatPageRollups(ctx, kbId, consts.StatGranularityDay, month, month.AddDate(0, 1, 0))
+			if err != nil {
+				return err
+			}
+			rollup := domain.NewStatPageRollup(kbId, consts.StatGranularityMonth, month)
+			for _, day := range dayRollups {
+				rollup.AddRollup(day)
+			}
+			if err := u.repo.CreateStatPageRollup(ctx, rollup); err != nil {
+				return err
+			}
+		}
+
+		if len(days) > 0 || len(months) > 0 {
+			u.logger.Info("aggregate rollup stats", log.String("kb_id", kbId), log.Int("days", len(days)), log.Int("months", len(months)))
+		}
+	}
+
+	return nil
+}
+
+// ParseStatDateRange 解析起止日期，返回 [start, end) 区间，end 当天包含在内
+func (u *StatUseCase) ParseStatDateRange(startDate, endDate string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(statDateLayout, startDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid start date")
+	}
+	end, err := time.ParseInLocation(statDateLayout, endDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid end date")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("end date is before start date")
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// ValidateStatRange 按时间跨度复用统计天数的版本限制
+func (u *StatUseCase) ValidateStatRange(start, end time.Time, edition consts.LicenseEdition) error {
+	day := consts.StatDay30
+	switch span := end.Sub(start); {
+	case span <= 24*time.Hour:
+		day = consts.StatDay1
+	case span <= 7*24*time.Hour:
+		day = consts.StatDay7
+	}
+	return u.ValidateStatDay(day, edition)
+}
+
+// GetStatTrend 获取任意时间段内按天/按月的访问趋势
+func (u *StatUseCase) GetStatTrend(ctx context.Context, kbID string, start, end time.Time, granularity consts.StatGranularity) ([]*domain.StatTrendItem, error) {
+	var (
+		rollups []*domain.StatPageRollup
+		err     error
+	)
+	switch granularity {
+	case consts.StatGranularityDay:
+		rollups, err = u.getDailyRollups(ctx, kbID, start, end)
+	case consts.StatGranularityMonth:
+		rollups, err = u.getMonthlyRollups(ctx, kbID, start, end)
+	default:
+		return nil, errors.New("invalid stat granularity")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(rollups, func(r *domain.StatPageRollup, _ int) *domain.StatTrendItem {
+		return &domain.StatTrendItem{
+			Date:              r.Period,
+			PageVisitCount:    r.PageVisitCount,
+			IPCount:           r.IPCount,
+			SessionCount:      r.SessionCount,
+			ConversationCount: r.ConversationCount,
+		}
+	}), nil
+}
+
+// GetStatRange 获取任意时间段内的汇总统计
+func (u *StatUseCase) GetStatRange(ctx context.Context, kbID string, start, end time.Time) (*v1.StatRangeResp, error) {
+	rollups, err := u.getDailyRollups(ctx, kbID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	total := domain.NewStatPageRollup(kbID, consts.StatGranularityDay, start)
+	for _, r := range rollups {
+		total.AddRollup(r)
+	}
+
+	resp := &v1.StatRangeResp{
+		StatCountResp: v1.StatCountResp{
+			IPCount:           total.IPCount,
+			SessionCount:      total.SessionCount,
+			PageVisitCount:    total.PageVisitCount,
+			ConversationCount: total.ConversationCount,
+		},
+		GeoCount: total.GeoCount,
+	}
+
+	resp.HotPages = lo.Map(topCounts(total.HotPage, 10), func(e lo.Entry[string, int64], _ int) *domain.HotPage {
+		return &domain.HotPage{Scene: domain.StatPageSceneNodeDetail, NodeID: e.Key, Count: e.Value}
+	})
+	nodeIDs := lo.Map(resp.HotPages, func(page *domain.HotPage, _ int) string {
+		return page.NodeID
+	})
+	docNames, err := u.nodeRepo.GetNodeNameByNodeIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range resp.HotPages {
+		page.NodeName = docNames[page.NodeID]
+	}
+
+	delete(total.HotRefererHost, "")
+	resp.HotRefererHosts = lo.Map(topCounts(total.HotRefererHost, 10), func(e lo.Entry[string, int64], _ int) *domain.HotRefererHost {
+		return &domain.HotRefererHost{RefererHost: e.Key, Count: e.Value}
+	})
+
+	toBrowserCount := func(e lo.Entry[string, int64], _ int) domain.BrowserCount {
+		return domain.BrowserCount{Name: e.Key, Count: e.Value}
+	}
+	delete(total.HotBrowser, "")
+	delete(total.HotOS, "")
+	resp.HotBrowser = &domain.HotBrowser{
+		Browser: lo.Map(topCounts(total.HotBrowser, 10), toBrowserCount),
+		OS:      lo.Map(topCounts(total.HotOS, 10), toBrowserCount),
+	}
+
+	resp.ConversationDistribution = make([]v1.StatConversationDistributionResp, 0, len(total.ConversationDistribution))
+	for appType, count := range total.ConversationDistribution {
+		t, err := strconv.Atoi(appType)
+		if err != nil {
+			continue
+		}
+		resp.ConversationDistribution = append(resp.ConversationDistribution, v1.StatConversationDistributionResp{
+			AppType: domain.AppType(t),
+			Count:   count,
+		})
+	}
+
+	return resp, nil
+}
+
+// getDailyRollups 获取 [start, end) 内的按天数据，尚未汇总的天(如今天)由小时数据实时计算
+func (u *StatUseCase) getDailyRollups(ctx context.Context, kbID string, start, end time.Time) ([]*domain.StatPageRollup, error) {
+	rollups, err := u.repo.GetStatPageRollups(ctx, kbID, consts.StatGranularityDay, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	from := start
+	if len(rollups) > 0 {
+		from = rollups[len(rollups)-1].Period.AddDate(0, 0, 1)
+	}
+	if !from.Before(end) {
+		return rollups, nil
+	}
+
+	hours, err := u.repo.GetStatPageHoursByRange(ctx, kbID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, hour := range hours {
+		t := hour.Hour.In(time.Local)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		if len(rollups) == 0 || !rollups[len(rollups)-1].Period.Equal(day) {
+			rollups = append(rollups, domain.NewStatPageRollup(kbID, consts.StatGranularityDay, day))
+		}
+		rollups[len(rollups)-1].AddHour(hour)
+	}
+	return rollups, nil
+}
+
+// getMonthlyRollups 获取覆盖 [start, end) 的按月数据，尚未汇总的月份(如本月)由按天数据实时计算
+func (u *StatUseCase) getMonthlyRollups(ctx context.Context, kbID string, start, end time.Time) ([]*domain.StatPageRollup, error) {
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local)
+	rollups, err := u.repo.GetStatPageRollups(ctx, kbID, consts.StatGranularityMonth, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	from := start
+	if len(rollups) > 0 {
+		from = rollups[len(rollups)-1].Period.AddDate(0, 1, 0)
+	}
+	if !from.Before(end) {
+		return rollups, nil
+	}
+
+	// 月份按整月统计
+	last := end.AddDate(0, 0, -1)
+	to := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, 1, 0)
+	days, err := u.getDailyRollups(ctx, kbID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		t := day.Period.In(time.Local)
+		month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+		if len(rollups) == 0 || !rollups[len(rollups)-1].Period.Equal(month) {
+			rollups = append(rollups, domain.NewStatPageRollup(kbID, consts.StatGranularityMonth, month))
+		}
+		rollups[len(rollups)-1].AddRollup(day)
+	}
+	return rollups, nil
+}
+
+// topCounts 按计数倒序取前 n 项
+func topCounts(m domain.MapStrInt64, n int) []lo.Entry[string, int64] {
+	entries := lo.Entries(m)
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value == entries[j].Value {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Value > entries[j].Value
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
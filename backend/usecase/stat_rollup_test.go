@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func TestParseStatDateRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		from  time.Time
+		to    time.Time
+		err   string
+	}{
+		{"single day", "2026-03-01", "2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local), ""},
+		{"across months", "2026-01-30", "2026-02-28", time.Date(2026, 1, 30, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), ""},
+		{"invalid start", "2026/03/01", "2026-03-01", time.Time{}, time.Time{}, "invalid start date"},
+		{"invalid end", "2026-03-01", "", time.Time{}, time.Time{}, "invalid end date"},
+		{"end before start", "2026-03-02", "2026-03-01", time.Time{}, time.Time{}, "end date is before start date"},
+	}
+
+	u := &StatUseCase{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := u.ParseStatDateRange(tt.start, tt.end)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+		})
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	m := domain.MapStrInt64{"c": 3, "a": 5, "b": 5, "d": 1}
+	tests := []struct {
+		name     string
+		n        int
+		expected []string
+	}{
+		{"ties sorted by key", 3, []string{"a", "b", "c"}},
+		{"n larger than map", 10, []string{"a", "b", "c", "d"}},
+		{"zero", 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := topCounts(m, tt.n)
+			assert.Equal(t, tt.expected, lo.Map(entries, func(e lo.Entry[string, int64], _ int) string { return e.Key }))
+		})
+	}
+	assert.Empty(t, topCounts(nil, 10))
+}
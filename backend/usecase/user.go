@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,15 +15,18 @@ import (
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/cache"
 )
 
 type UserUsecase struct {
-	repo   *pg.UserRepository
-	logger *log.Logger
-	config *config.Config
+	repo              *pg.UserRepository
+	systemSettingRepo *pg.SystemSettingRepo
+	logger            *log.Logger
+	config            *config.Config
+	cache             *cache.Cache
 }
 
-func NewUserUsecase(repo *pg.UserRepository, logger *log.Logger, config *config.Config) (*UserUsecase, error) {
+func NewUserUsecase(repo *pg.UserRepository, systemSettingRepo *pg.SystemSettingRepo, logger *log.Logger, config *config.Config, cache *cache.Cache) (*UserUsecase, error) {
 	if config.AdminPassword != "" {
 		if err := repo.UpsertDefaultUser(context.Background(), &domain.User{
 			ID:       uuid.New().String(),
@@ -34,9 +38,11 @@ func NewUserUsecase(repo *pg.UserRepository, logger *log.Logger, config *config.
 		}
 	}
 	return &UserUsecase{
-		repo:   repo,
-		logger: logger.WithModule("usecase.user"),
-		config: config,
+		repo:              repo,
+		systemSettingRepo: systemSettingRepo,
+		logger:            logger.WithModule("usecase.user"),
+		config:            config,
+		cache:             cache,
 	}, nil
 }
 
@@ -51,8 +57,23 @@ func (u *UserUsecase) VerifyUserAndGenerateToken(ctx context.Context, req v1.Log
 	if err != nil {
 		return "", err
 	}
+	if !user.IsLocal() {
+		return "", errors.New("sso user can not login with password")
+	}
+	if user.TOTPEnabled {
+		if req.TOTPCode == "" {
+			return "", domain.ErrTOTPRequired
+		}
+		if err := u.verifySecondFactor(ctx, user, req.TOTPCode); err != nil {
+			return "", err
+		}
+	}
+	return u.generateToken(user.ID)
+}
+
+func (u *UserUsecase) generateToken(userID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":  user.ID,
+		"id":  userID,
 		"exp": time.Now().Add(time.Hour * 24).Unix(),
 	})
 
@@ -0,0 +1,363 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/user/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/cas"
+	"github.com/chaitin/panda-wiki/pkg/ldap"
+	"github.com/chaitin/panda-wiki/pkg/oauth"
+	"github.com/chaitin/panda-wiki/pkg/oidc"
+)
+
+const (
+	adminSSOCallbackPath = "/api/v1/user/login/sso/callback"
+	adminSSOStatePrefix  = "admin_sso_state:"
+	adminSSOCodePrefix   = "admin_sso_code:"
+)
+
+// ssoUserInfo 各身份源返回的用户信息
+type ssoUserInfo struct {
+	ID    string
+	Name  string
+	Email string
+}
+
+func (u *UserUsecase) GetAdminSSOSetting(ctx context.Context) (*domain.AdminSSOSetting, error) {
+	setting, err := u.systemSettingRepo.GetSystemSetting(ctx, consts.SystemSettingAdminSSO)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.AdminSSOSetting{}, nil
+		}
+		return nil, err
+	}
+	var ssoSetting domain.AdminSSOSetting
+	if err := json.Unmarshal(setting.Value, &ssoSetting); err != nil {
+		return nil, err
+	}
+	return &ssoSetting, nil
+}
+
+func (u *UserUsecase) UpdateAdminSSOSetting(ctx context.Context, setting *domain.AdminSSOSetting) error {
+	if setting.Enabled {
+		switch setting.SourceType {
+		case consts.SourceTypeLDAP:
+		case consts.SourceTypeOIDC, consts.SourceTypeOAuth, consts.SourceTypeCAS:
+			if setting.BaseURL == "" {
+				return errors.New("base url is required")
+			}
+		default:
+			return errors.New("unsupported sso source type")
+		}
+	}
+	if setting.DefaultRole == "" {
+		setting.DefaultRole = consts.UserRoleUser
+	}
+
+	value, err := json.Marshal(setting)
+	if err != nil {
+		return err
+	}
+	return u.systemSettingRepo.UpsertSystemSetting(ctx, consts.SystemSettingAdminSSO, value, "管理后台单点登录配置")
+}
+
+func (u *UserUsecase) getEnabledSSOSetting(ctx context.Context, sourceTypes ...consts.SourceType) (*domain.AdminSSOSetting, error) {
+	setting, err := u.GetAdminSSOSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !setting.Enabled {
+		return nil, errors.New("sso is not enabled")
+	}
+	for _, sourceType := range sourceTypes {
+		if setting.SourceType == sourceType {
+			return setting, nil
+		}
+	}
+	return nil, fmt.Errorf("sso source type %s is not allowed", setting.SourceType)
+}
+
+func (u *UserUsecase) getSSOCallbackURL(setting *domain.AdminSSOSetting) (string, error) {
+	return url.JoinPath(setting.BaseURL, adminSSOCallbackPath)
+}
+
+func (u *UserUsecase) getOIDCClient(ctx context.Context, setting *domain.AdminSSOSetting) (*oidc.Client, error) {
+	redirectURI, err := u.getSSOCallbackURL(setting)
+	if err != nil {
+		return nil, err
+	}
+	authSetting := setting.AuthSetting
+	return oidc.NewClient(ctx, u.logger, setting.BaseURL, oidc.Config{
+		Issuer:       authSetting.Issuer,
+		ClientID:     authSetting.ClientID,
+		ClientSecret: authSetting.ClientSecret,
+		Scopes:       authSetting.Scopes,
+		RedirectURI:  redirectURI,
+		IDField:      authSetting.ClaimMapping.ID,
+		NameField:    authSetting.ClaimMapping.Name,
+		EmailField:   authSetting.ClaimMapping.Email,
+	})
+}
+
+func (u *UserUsecase) getOAuthClient(ctx context.Context, setting *domain.AdminSSOSetting) (*oauth.Client, error) {
+	redirectURI, err := u.getSSOCallbackURL(setting)
+	if err != nil {
+		return nil, err
+	}
+	authSetting := setting.AuthSetting
+	return oauth.NewClient(ctx, u.logger, setting.BaseURL, oauth.Config{
+		ClientID:     authSetting.ClientID,
+		ClientSecret: authSetting.ClientSecret,
+		RedirectURI:  redirectURI,
+		Scopes:       authSetting.Scopes,
+		AuthorizeURL: authSetting.AuthorizeURL,
+		TokenURL:     authSetting.TokenURL,
+		UserInfoURL:  authSetting.UserInfoURL,
+		IDField:      authSetting.ClaimMapping.ID,
+		NameField:    authSetting.ClaimMapping.Name,
+		AvatarField:  authSetting.ClaimMapping.Avatar,
+		EmailField:   authSetting.ClaimMapping.Email,
+	})
+}
+
+func (u *UserUsecase) getCASClient(ctx context.Context, setting *domain.AdminSSOSetting) (*cas.Client, error) {
+	return cas.NewClient(ctx, u.logger, cas.Config{
+		ServerURL:    setting.CAS.ServerURL,
+		ServiceURL:   setting.BaseURL,
+		Version:      setting.CAS.Version,
+		CallbackPath: adminSSOCallbackPath,
+	})
+}
+
+// GenerateSSOLoginUrl 生成跳转到身份源的登录地址
+func (u *UserUsecase) GenerateSSOLoginUrl(ctx context.Context, req v1.SSOLoginReq) (string, error) {
+	setting, err := u.getEnabledSSOSetting(ctx, consts.SourceTypeOIDC, consts.SourceTypeOAuth, consts.SourceTypeCAS)
+	if err != nil {
+		return "", err
+	}
+	if err := validateSSORedirectUrl(setting.BaseURL, req.RedirectUrl); err != nil {
+		return "", err
+	}
+
+	stateInfo := StateInfo{
+		RedirectUrl: req.RedirectUrl,
+		Verifier:    oauth2.GenerateVerifier(),
+		Nonce:       uuid.New().String(),
+	}
+	state := uuid.New().String()
+	stateInfoBytes, err := json.Marshal(stateInfo)
+	if err != nil {
+		return "", err
+	}
+	if err := u.cache.SetNX(ctx, adminSSOStatePrefix+state, stateInfoBytes, 15*time.Minute).Err(); err != nil {
+		return "", err
+	}
+
+	switch setting.SourceType {
+	case consts.SourceTypeOIDC:
+		client, err := u.getOIDCClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		return client.GetAuthorizeURL(state, stateInfo.Nonce, stateInfo.Verifier), nil
+	case consts.SourceTypeOAuth:
+		client, err := u.getOAuthClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		return client.GetAuthorizeURL(state), nil
+	default:
+		client, err := u.getCASClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		return client.GetLoginURL(state), nil
+	}
+}
+
+// SSOCallback 处理身份源回调，返回带一次性登录码的前端地址
+func (u *UserUsecase) SSOCallback(ctx context.Context, req v1.SSOCallbackReq) (string, error) {
+	stateKey := adminSSOStatePrefix + req.State
+	stateInfoStr, err := u.cache.GetDel(ctx, stateKey).Result()
+	if err != nil {
+		return "", fmt.Errorf("state info not found: %w", err)
+	}
+	var stateInfo StateInfo
+	if err := json.Unmarshal([]byte(stateInfoStr), &stateInfo); err != nil {
+		return "", err
+	}
+
+	setting, err := u.getEnabledSSOSetting(ctx, consts.SourceTypeOIDC, consts.SourceTypeOAuth, consts.SourceTypeCAS)
+	if err != nil {
+		return "", err
+	}
+
+	var info *ssoUserInfo
+	switch setting.SourceType {
+	case consts.SourceTypeOIDC:
+		client, err := u.getOIDCClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		userInfo, err := client.GetUserInfo(req.Code, stateInfo.Nonce, stateInfo.Verifier)
+		if err != nil {
+			return "", err
+		}
+		info = &ssoUserInfo{ID: userInfo.ID, Name: userInfo.Name, Email: userInfo.Email}
+	case consts.SourceTypeOAuth:
+		client, err := u.getOAuthClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		userInfo, err := client.GetUserInfo(req.Code)
+		if err != nil {
+			return "", err
+		}
+		info = &ssoUserInfo{ID: userInfo.ID, Name: userInfo.Name, Email: userInfo.Email}
+	default:
+		client, err := u.getCASClient(ctx, setting)
+		if err != nil {
+			return "", err
+		}
+		userInfo, err := client.ValidateTicket(req.Ticket, req.State)
+		if err != nil {
+			return "", err
+		}
+		info = &ssoUserInfo{ID: userInfo.Username, Name: userInfo.Username}
+	}
+
+	user, err := u.getOrCreateSSOUser(ctx, setting, info)
+	if err != nil {
+		return "", err
+	}
+
+	// 不在地址中直接携带 token，前端用一次性登录码换取
+	code := uuid.New().String()
+	if err := u.cache.Set(ctx, adminSSOCodePrefix+code, user.ID, time.Minute).Err(); err != nil {
+		return "", err
+	}
+	redirectURL, err := url.Parse(stateInfo.RedirectUrl)
+	if err != nil {
+		return "", err
+	}
+	query := redirectURL.Query()
+	query.Set("sso_code", code)
+	redirectURL.RawQuery = query.Encode()
+	return redirectURL.String(), nil
+}
+
+// ExchangeSSOCode 用一次性登录码换取 token
+func (u *UserUsecase) ExchangeSSOCode(ctx context.Context, code string) (string, error) {
+	userID, err := u.cache.GetDel(ctx, adminSSOCodePrefix+code).Result()
+	if err != nil {
+		return "", errors.New("invalid sso code")
+	}
+	return u.generateToken(userID)
+}
+
+// LDAPLogin 使用LDAP账号密码登录
+func (u *UserUsecase) LDAPLogin(ctx context.Context, req v1.LDAPLoginReq) (string, error) {
+	setting, err := u.getEnabledSSOSetting(ctx, consts.SourceTypeLDAP)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := ldap.NewClient(ctx, u.logger, ldap.Config{
+		ServerURL:     setting.LDAP.ServerURL,
+		BindDN:        setting.LDAP.BindDN,
+		BindPassword:  setting.LDAP.BindPassword,
+		UserBaseDN:    setting.LDAP.UserBaseDN,
+		UserFilter:    setting.LDAP.UserFilter,
+		UserIDAttr:    setting.LDAP.UserIDAttr,
+		UserNameAttr:  setting.LDAP.UserNameAttr,
+		UserEmailAttr: setting.LDAP.UserEmailAttr,
+	})
+	if err != nil {
+		return "", err
+	}
+	userInfo, err := client.Authenticate(req.Account, req.Password)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := u.getOrCreateSSOUser(ctx, setting, &ssoUserInfo{
+		ID:    userInfo.ID,
+		Name:  userInfo.Username,
+		Email: userInfo.Email,
+	})
+	if err != nil {
+		return "", err
+	}
+	return u.generateToken(user.ID)
+}
+
+// getOrCreateSSOUser 首次登录时创建用户并授予默认权限
+func (u *UserUsecase) getOrCreateSSOUser(ctx context.Context, setting *domain.AdminSSOSetting, info *ssoUserInfo) (*domain.User, error) {
+	if info.ID == "" {
+		return nil, errors.New("sso user id is empty")
+	}
+
+	user, err := u.repo.GetUserBySource(ctx, setting.SourceType, info.ID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account := info.Name
+	if account == "" {
+		account = info.Email
+	}
+	if account == "" {
+		account = info.ID
+	}
+	role := setting.DefaultRole
+	if role == "" {
+		role = consts.UserRoleUser
+	}
+
+	user = &domain.User{
+		ID:         uuid.New().String(),
+		Account:    account,
+		Password:   uuid.New().String(), // SSO用户不使用本地密码登录
+		Role:       role,
+		SourceType: setting.SourceType,
+		UnionID:    info.ID,
+	}
+	if err := u.repo.CreateSSOUser(ctx, user, setting.DefaultKBPerms); err != nil {
+		return nil, fmt.Errorf("create sso user failed: %w", err)
+	}
+	u.logger.Info("sso user created", log.String("user_id", user.ID), log.String("account", user.Account), log.String("source_type", string(user.SourceType)))
+	return user, nil
+}
+
+func validateSSORedirectUrl(baseUrl, redirectUrl string) error {
+	redirectURL, err := url.Parse(redirectUrl)
+	if err != nil {
+		return err
+	}
+	if baseUrl == "" {
+		return errors.New("base url is not configured")
+	}
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		return err
+	}
+	if redirectURL.Hostname() != base.Hostname() {
+		return errors.New("invalid redirect url")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSSORedirectUrl(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseUrl     string
+		redirectUrl string
+		err         string
+	}{
+		{"same host", "https://wiki.example.com", "https://wiki.example.com/dashboard", ""},
+		{"different port", "https://wiki.example.com:8443", "http://wiki.example.com/login", ""},
+		{"other host", "https://wiki.example.com", "https://evil.example.com/", "invalid redirect url"},
+		{"suffix host", "https://example.com", "https://example.com.evil.io/", "invalid redirect url"},
+		{"relative url", "https://wiki.example.com", "/dashboard", "invalid redirect url"},
+		{"base url not configured", "", "https://wiki.example.com/", "base url is not configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSSORedirectUrl(tt.baseUrl, tt.redirectUrl)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+
+	v1 "github.com/chaitin/panda-wiki/api/user/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const (
+	totpIssuer        = "PandaWiki"
+	recoveryCodeCount = 10
+)
+
+// SetupTOTP 生成新的TOTP密钥，需调用 EnableTOTP 校验验证码后才生效
+func (u *UserUsecase) SetupTOTP(ctx context.Context, userID string) (*v1.TOTPSetupResp, error) {
+	user, err := u.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsLocal() {
+		return nil, errors.New("totp is only available for local accounts")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("totp is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Account,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.UpdateUserTOTP(ctx, user.ID, key.Secret(), false, nil); err != nil {
+		return nil, err
+	}
+
+	return &v1.TOTPSetupResp{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+	}, nil
+}
+
+// EnableTOTP 校验验证码后开启两步验证并生成恢复码
+func (u *UserUsecase) EnableTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := u.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("totp is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("totp is not set up")
+	}
+	if !totp.Validate(code, user.TOTPSecret) {
+		return nil, domain.ErrInvalidTOTPCode
+	}
+
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.UpdateUserTOTP(ctx, user.ID, user.TOTPSecret, true, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证，需提供验证码或恢复码
+func (u *UserUsecase) DisableTOTP(ctx context.Context, userID, code string) error {
+	user, err := u.repo.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errors.New("totp is not enabled")
+	}
+	if err := u.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+	return u.repo.UpdateUserTOTP(ctx, user.ID, "", false, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (u *UserUsecase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := u.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("totp is not enabled")
+	}
+	if err := u.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.UpdateUserTOTP(ctx, user.ID, user.TOTPSecret, true, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTOTP 管理员为丢失设备的用户关闭两步验证
+func (u *UserUsecase) ResetTOTP(ctx context.Context, userID string) error {
+	return u.repo.UpdateUserTOTP(ctx, userID, "", false, nil)
+}
+
+// verifySecondFactor 校验TOTP验证码或恢复码，验证码在有效期内只能使用一次
+func (u *UserUsecase) verifySecondFactor(ctx context.Context, user *domain.User, code string) error {
+	code = strings.TrimSpace(code)
+	if totp.Validate(code, user.TOTPSecret) {
+		key := fmt.Sprintf("totp_used:%s:%s", user.ID, code)
+		ok, err := u.cache.SetNX(ctx, key, 1, 90*time.Second).Result()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	ok, err := u.repo.ConsumeRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidTOTPCode
+	}
+	u.logger.Info("recovery code used", log.String("user_id", user.ID))
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashed := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+		code := raw[:4] + "-" + raw[4:]
+		codes = append(codes, code)
+		hashed = append(hashed, hashRecoveryCode(code))
+	}
+	return codes, hashed, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
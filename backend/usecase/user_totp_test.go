@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashed, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashed, recoveryCodeCount)
+	assert.Len(t, lo.Uniq(codes), recoveryCodeCount)
+
+	pattern := regexp.MustCompile(`^[a-z2-7]{4}-[a-z2-7]{4}$`)
+	for i, code := range codes {
+		assert.Regexp(t, pattern, code)
+		assert.Equal(t, hashRecoveryCode(code), hashed[i])
+		assert.NotContains(t, hashed[i], code)
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	expected := hashRecoveryCode("abcd-efgh")
+	tests := []struct {
+		name  string
+		code  string
+		equal bool
+	}{
+		{"same", "abcd-efgh", true},
+		{"upper case", "ABCD-EFGH", true},
+		{"without dash", "abcdefgh", true},
+		{"surrounding spaces", "  abcd-efgh\n", true},
+		{"different", "abcd-efgi", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, hashRecoveryCode(tt.code) == expected)
+		})
+	}
+}
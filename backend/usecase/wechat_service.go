@@ -8,6 +8,7 @@ import (
 	"github.com/chaitin/panda-wiki/pkg/bot"
 	"github.com/chaitin/panda-wiki/pkg/bot/wechat_service"
 	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
 )
 
 type WechatServiceUsecase struct {
@@ -16,15 +17,17 @@ type WechatServiceUsecase struct {
 	authRepo    *pg.AuthRepo
 	chatUsecase *ChatUsecase
 	weRepo      *pg.WechatRepository
+	objectStore s3.ObjectStore
 }
 
-func NewWechatUsecase(logger *log.Logger, AppUsecase *AppUsecase, chatUsecase *ChatUsecase, weRepo *pg.WechatRepository, authRepo *pg.AuthRepo) *WechatServiceUsecase {
+func NewWechatUsecase(logger *log.Logger, AppUsecase *AppUsecase, chatUsecase *ChatUsecase, weRepo *pg.WechatRepository, authRepo *pg.AuthRepo, objectStore s3.ObjectStore) *WechatServiceUsecase {
 	return &WechatServiceUsecase{
 		logger:      logger.WithModule("usecase.wechatUsecase"),
 		AppUsecase:  AppUsecase,
 		chatUsecase: chatUsecase,
 		weRepo:      weRepo,
 		authRepo:    authRepo,
+		objectStore: objectStore,
 	}
 }
 
@@ -51,7 +54,7 @@ func (u *WechatServiceUsecase) WechatService(ctx context.Context, msg *wechat_se
 }
 
 func (u *WechatServiceUsecase) NewWechatServiceConfig(ctx context.Context, kbID string, appInfo *domain.AppDetailResp) (*wechat_service.WechatServiceConfig, error) {
-	return wechat_service.NewWechatServiceConfig(
+	conf, err := wechat_service.NewWechatServiceConfig(
 		ctx,
 		u.logger,
 		kbID,
@@ -62,7 +65,12 @@ func (u *WechatServiceUsecase) NewWechatServiceConfig(ctx context.Context, kbID
 		appInfo.Settings.WechatServiceLogo,
 		appInfo.Settings.WechatServiceContainKeywords,
 		appInfo.Settings.WechatServiceEqualKeywords,
+		u.objectStore.InternalEndpoint(),
 	)
+	if err != nil {
+		return nil, err
+	}
+	return conf, nil
 }
 
 func (u *WechatServiceUsecase) getQAFunc(kbID string, appType domain.AppType) bot.GetQAFun {
@@ -20,14 +20,13 @@ import (
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/store/s3"
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
 	"golang.org/x/sync/semaphore"
 )
 
 type EpubConverter struct {
 	logger      *log.Logger
 	mu          sync.Mutex
-	minioClient *s3.MinioClient
+	minioClient s3.ObjectStore
 	// relative path -> oss path
 	resources map[string]string
 	// id -> relative path
@@ -36,7 +35,7 @@ type EpubConverter struct {
 	relativePath map[string]string
 }
 
-func NewEpubConverter(logger *log.Logger, minio *s3.MinioClient) *EpubConverter {
+func NewEpubConverter(logger *log.Logger, minio s3.ObjectStore) *EpubConverter {
 	return &EpubConverter{
 		logger:         logger.WithModule("epubConverter"),
 		minioClient:    minio,
@@ -204,13 +203,13 @@ func (e *EpubConverter) processFile(ctx context.Context, f *zip.File, kbID strin
 	e.mu.Lock()
 	e.resources[f.Name] = fmt.Sprintf("/%s/%s", domain.Bucket, ossPath)
 	e.mu.Unlock()
-	_, err = e.minioClient.PutObject(
+	err = e.minioClient.PutObject(
 		ctx,
 		domain.Bucket,
 		ossPath,
 		file,
 		f.FileInfo().Size(),
-		minio.PutObjectOptions{
+		s3.PutObjectOptions{
 			ContentType:  e.resourcesIdMap[e.relativePath[f.Name]].MediaType,
 			UserMetadata: map[string]string{"originalname": filepath.Base(f.Name)},
 		},
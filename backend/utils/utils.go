@@ -20,7 +20,6 @@ import (
 	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
 	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
 	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
@@ -171,9 +170,9 @@ func RemoveURLParams(rawURL string) (string, error) {
 	return parsedURL.String(), nil
 }
 
-func UploadImage(ctx context.Context, minioClient *s3.MinioClient, imageURL string, kbID string) (string, error) {
+func UploadImage(ctx context.Context, minioClient s3.ObjectStore, imageURL string, kbID string) (string, error) {
 	if minioClient == nil {
-		return "", fmt.Errorf("minio client is nil")
+		return "", fmt.Errorf("object store is nil")
 	}
 	var data []byte
 	var contentType string
@@ -227,20 +226,20 @@ func UploadImage(ctx context.Context, minioClient *s3.MinioClient, imageURL stri
 	}
 	imgName := fmt.Sprintf("%s/%s%s", kbID, uuid.New().String(), ext)
 
-	if _, err := minioClient.PutObject(
+	if err := minioClient.PutObject(
 		ctx,
 		domain.Bucket,
 		imgName,
 		bytes.NewReader(data),
 		int64(len(data)),
-		minio.PutObjectOptions{
+		s3.PutObjectOptions{
 			ContentType: contentType,
 			UserMetadata: map[string]string{
 				"originalname": decodedName,
 			},
 		},
 	); err != nil {
-		return "", fmt.Errorf("failed to upload image to MinIO: %v", err)
+		return "", fmt.Errorf("failed to upload image: %v", err)
 	}
 	return fmt.Sprintf("/%s/%s", domain.Bucket, imgName), nil
 }
@@ -10,6 +10,7 @@ import { postApiV1Node, putApiV1NodeDetail } from '@/request/Node';
 import { ConstsNodeAccessPerm } from '@/request/types';
 import { useAppSelector } from '@/store';
 import { AppContext, updateTree } from '@/utils/drag';
+import { putNodeDetailWithLatestRevision } from '@/utils/node';
 import {
   handleMultiSelect,
   handleParentControlledSelect,
@@ -371,7 +372,7 @@ const TreeItem = React.forwardRef<
                     onClick={e => {
                       e.stopPropagation();
                       if (item.name) {
-                        putApiV1NodeDetail({
+                        putNodeDetailWithLatestRevision({
                           id: item.id,
                           kb_id: id,
                           nav_id:
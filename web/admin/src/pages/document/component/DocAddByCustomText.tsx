@@ -1,7 +1,8 @@
 import Emoji from '@/components/Emoji';
 import { DomainCreateNodeReq, V1NodeDetailResp } from '@/request';
-import { postApiV1Node, putApiV1NodeDetail } from '@/request/Node';
+import { postApiV1Node } from '@/request/Node';
 import { useAppSelector } from '@/store';
+import { putNodeDetailWithLatestRevision } from '@/utils/node';
 import { message, Modal } from '@ctzhian/ui';
 import {
   Box,
@@ -68,7 +69,7 @@ const DocAddByCustomText = ({
 
   const submit = (value: FormValues) => {
     if (data) {
-      putApiV1NodeDetail({
+      putNodeDetailWithLatestRevision({
         id: data.id || '',
         kb_id: id,
         nav_id: data.nav_id || nav_id || '',
@@ -1,7 +1,6 @@
 import Card from '@/components/Card';
 import DragTree from '@/components/Drag/DragTree';
 import { Form, FormItem } from '@/pages/setting/component/Common';
-import { putApiV1NodeDetail } from '@/request/Node';
 import {
   getApiV1NodePermission,
   patchApiV1NodePermissionEdit,
@@ -20,6 +19,7 @@ import {
 } from '@/request/types';
 import { useAppSelector } from '@/store';
 import { convertToTree } from '@/utils/drag';
+import { putNodeDetailWithLatestRevision } from '@/utils/node';
 import { filterEmptyFolders } from '@/utils/tree';
 import { Icon, Modal, message } from '@ctzhian/ui';
 import {
@@ -172,7 +172,7 @@ const DocPropertiesModal = ({
       }),
 
       !isBatch
-        ? putApiV1NodeDetail({
+        ? putNodeDetailWithLatestRevision({
             id: data[0].id!,
             name: values.name,
             summary: values.summary,
@@ -0,0 +1,96 @@
+import { V1NodeConflictResp } from '@/request/types';
+import { Modal } from '@ctzhian/ui';
+import ErrorOutlineIcon from '@mui/icons-material/ErrorOutline';
+import { Box, Button, Stack, alpha } from '@mui/material';
+import dayjs from 'dayjs';
+
+interface NodeConflictModalProps {
+  data: V1NodeConflictResp | null;
+  saving: boolean;
+  onClose: () => void;
+  onReload: () => void;
+  onOverwrite: () => void;
+  onSaveMerged: () => void;
+}
+
+const NodeConflictModal = ({
+  data,
+  saving,
+  onClose,
+  onReload,
+  onOverwrite,
+  onSaveMerged,
+}: NodeConflictModalProps) => {
+  if (!data) return null;
+  const conflicts = data.merged?.conflicts || 0;
+  return (
+    <Modal
+      title='文档已被其他人修改'
+      open={!!data}
+      onCancel={onClose}
+      footer={
+        <Stack direction='row' justifyContent='flex-end' gap={1} sx={{ p: 2 }}>
+          <Button onClick={onReload} disabled={saving}>
+            放弃我的修改
+          </Button>
+          <Button
+            variant='outlined'
+            color='warning'
+            onClick={onOverwrite}
+            disabled={saving}
+          >
+            覆盖保存
+          </Button>
+          <Button
+            variant='contained'
+            onClick={onSaveMerged}
+            disabled={saving || conflicts > 0}
+          >
+            保存合并结果
+          </Button>
+        </Stack>
+      }
+    >
+      <Stack
+        direction='row'
+        gap={1}
+        alignItems='center'
+        sx={{
+          py: 1,
+          px: 1.5,
+          borderRadius: 1,
+          mb: 2,
+          fontSize: 12,
+          color: 'warning.main',
+          bgcolor: theme => alpha(theme.palette.warning.main, 0.05),
+        }}
+      >
+        <ErrorOutlineIcon sx={{ color: 'warning.main', fontSize: 12 }} />
+        {conflicts > 0
+          ? `自动合并时有 ${conflicts} 处冲突，覆盖保存会丢失对方的修改`
+          : '双方的修改可以自动合并，保存后将加载合并结果'}
+      </Stack>
+      {data.editor_account && (
+        <Stack direction='row' spacing={2}>
+          <Box sx={{ fontSize: 14, width: 100, flexShrink: 0 }}>编辑人员</Box>
+          <Box sx={{ fontSize: 14 }}>{data.editor_account}</Box>
+        </Stack>
+      )}
+      {data.edit_time && (
+        <Stack direction='row' spacing={2} sx={{ mt: 2 }}>
+          <Box sx={{ fontSize: 14, width: 100, flexShrink: 0 }}>修改时间</Box>
+          <Box sx={{ fontSize: 14 }}>
+            {dayjs(data.edit_time).format('YYYY-MM-DD HH:mm:ss')}
+          </Box>
+        </Stack>
+      )}
+      {data.merged?.base_missing && (
+        <Box sx={{ fontSize: 12, color: 'text.tertiary', mt: 2 }}>
+          编辑时的版本已被清理，合并结果可能包含更多冲突
+        </Box>
+      )}
+    </Modal>
+  );
+};
+
+export default NodeConflictModal;
@@ -54,6 +54,7 @@ const Wrap = ({ detail: defaultDetail }: WrapProps) => {
     nodeDetail,
     setNodeDetail,
     onSave,
+    updateNodeDetail,
     catalogData,
     saveCurrentDocRef,
   } = useOutletContext<WrapContext>();
@@ -112,7 +113,7 @@ const Wrap = ({ detail: defaultDetail }: WrapProps) => {
 
   const debouncedUpdateTitle = useCallback(
     debounce((newTitle: string) => {
-      putApiV1NodeDetail({
+      updateNodeDetail({
         id: defaultDetail.id!,
         kb_id: defaultDetail.kb_id!,
         nav_id: defaultDetail.nav_id || '',
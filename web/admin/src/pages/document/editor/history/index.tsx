@@ -1,6 +1,6 @@
 import EmojiPicker from '@/components/Emoji';
 import { DocWidth } from '@/constant/enums';
-import { getApiV1NodeDetail, putApiV1NodeDetail } from '@/request';
+import { getApiV1NodeDetail } from '@/request';
 import {
   DomainGetNodeReleaseDetailResp,
   DomainNodeReleaseListItem,
@@ -9,6 +9,7 @@ import {
 } from '@/request/pro';
 import { DomainNodeStatus, V1NodeDetailResp } from '@/request/types';
 import { useAppSelector } from '@/store';
+import { putNodeDetailWithLatestRevision } from '@/utils/node';
 import { Editor, EditorDiff, useTiptap } from '@ctzhian/tiptap';
 import { Ellipsis } from '@ctzhian/ui';
 import {
@@ -593,7 +594,7 @@ const History = () => {
         open={confirmOpen}
         onClose={() => setConfirmOpen(false)}
         onOk={async () => {
-          await putApiV1NodeDetail({
+          await putNodeDetailWithLatestRevision({
             id: id,
             kb_id: kb_id,
             nav_id: nav_id || '',
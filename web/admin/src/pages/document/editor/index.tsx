@@ -3,7 +3,9 @@ import { getApiV1AppDetail } from '@/request';
 import { getApiV1KnowledgeBaseList } from '@/request/KnowledgeBase';
 import { getApiV1NodeListGroupNav, putApiV1NodeDetail } from '@/request/Node';
 import {
+  DomainUpdateNodeReq,
   GithubComChaitinPandaWikiApiNodeV1NodeListGroupNavResp,
+  V1NodeConflictResp,
   V1NodeDetailResp,
 } from '@/request/types';
 import { useAppDispatch, useAppSelector } from '@/store';
@@ -14,18 +16,24 @@ import {
   setNavId,
 } from '@/store/slices/config';
 import { convertToTree } from '@/utils/drag';
+import { getNodeConflict } from '@/utils/node';
 import { message } from '@ctzhian/ui';
 import { Box, Drawer, Stack, useMediaQuery } from '@mui/material';
 import { useEffect, useMemo, useRef, useState } from 'react';
 import { Outlet } from 'react-router-dom';
+import NodeConflictModal from '../component/NodeConflictModal';
 import Catalog from './Catalog';
 
+type UpdateNodeDetailReq = Omit<DomainUpdateNodeReq, 'revision'>;
+
 export interface WrapContext {
   catalogOpen: boolean;
   setCatalogOpen: (open: boolean) => void;
   nodeDetail: V1NodeDetailResp | null;
   setNodeDetail: (detail: V1NodeDetailResp) => void;
   onSave: (content: string) => void;
+  // 保存名称或内容，被其他人修改过时返回 false 并提示处理冲突
+  updateNodeDetail: (body: UpdateNodeDetailReq) => Promise<boolean>;
   docWidth: string;
   catalogData: ITreeItem[];
   groups: GithubComChaitinPandaWikiApiNodeV1NodeListGroupNavResp[];
@@ -51,6 +59,11 @@ const DocEditor = () => {
 
   const [docWidth, setDocWidth] = useState<string>('full');
   const saveCurrentDocRef = useRef<(() => Promise<void>) | null>(null);
+  // 编辑时的修订号，每次保存后更新
+  const revisionRef = useRef<number | undefined>(undefined);
+  const conflictBodyRef = useRef<UpdateNodeDetailReq | null>(null);
+  const [conflict, setConflict] = useState<V1NodeConflictResp | null>(null);
+  const [conflictSaving, setConflictSaving] = useState(false);
 
   const catalogData = useMemo(() => {
     const curGroup = groups.find(g => g.nav_id === nav_id);
@@ -103,22 +116,67 @@ const DocEditor = () => {
     }
   };
 
+  const updateNodeDetail = async (body: UpdateNodeDetailReq) => {
+    try {
+      const res = await putApiV1NodeDetail({
+        ...body,
+        revision: revisionRef.current,
+      });
+      if (res?.revision) revisionRef.current = res.revision;
+      return true;
+    } catch (error) {
+      const data = getNodeConflict(error);
+      if (!data) throw error;
+      conflictBodyRef.current = body;
+      setConflict(data);
+      return false;
+    }
+  };
+
+  const saveConflict = async (body: UpdateNodeDetailReq, reload: boolean) => {
+    if (!conflict) return;
+    setConflictSaving(true);
+    try {
+      const res = await putApiV1NodeDetail({
+        ...body,
+        revision: conflict.current_revision,
+      });
+      if (res?.revision) revisionRef.current = res.revision;
+      setConflict(null);
+      if (reload) {
+        window.location.reload();
+      } else {
+        message.success('保存成功');
+      }
+    } catch (error) {
+      // 处理冲突期间又被修改时更新冲突信息
+      const data = getNodeConflict(error);
+      if (data) setConflict(data);
+    } finally {
+      setConflictSaving(false);
+    }
+  };
+
   const onSave = async (content: string) => {
     if (!kb_id || !nodeDetail.id) return;
     try {
-      await putApiV1NodeDetail({
+      const saved = await updateNodeDetail({
         kb_id,
         id: nodeDetail.id,
         nav_id: nodeDetail.nav_id || '',
         content,
         name: nodeDetail.name || '',
       });
-      message.success('保存成功');
+      if (saved) message.success('保存成功');
     } catch (error) {
       console.error(error);
     }
   };
 
+  useEffect(() => {
+    revisionRef.current = nodeDetail.revision;
+  }, [nodeDetail.id, nodeDetail.revision]);
+
   useEffect(() => {
     setCatalogOpen(isWideScreen);
   }, [isWideScreen]);
@@ -184,6 +242,7 @@ const DocEditor = () => {
             nodeDetail,
             setNodeDetail,
             onSave,
+            updateNodeDetail,
             docWidth,
             catalogData,
             groups,
@@ -193,6 +252,32 @@ const DocEditor = () => {
           }}
         />
       </Box>
+      <NodeConflictModal
+        data={conflict}
+        saving={conflictSaving}
+        onClose={() => setConflict(null)}
+        onReload={() => window.location.reload()}
+        onOverwrite={() => {
+          if (conflictBodyRef.current) {
+            saveConflict(conflictBodyRef.current, false);
+          }
+        }}
+        onSaveMerged={() => {
+          const body = conflictBodyRef.current;
+          if (!body || !conflict?.merged) return;
+          saveConflict(
+            {
+              ...body,
+              name: body.name !== undefined ? conflict.merged.name : undefined,
+              content:
+                body.content !== undefined
+                  ? conflict.merged.content
+                  : undefined,
+            },
+            true,
+          );
+        }}
+      />
     </Stack>
   );
 };
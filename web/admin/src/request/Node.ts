@@ -33,6 +33,7 @@ import {
   V1NodeRestudyReq,
   V1NodeRestudyResp,
   V1NodeStatsResp,
+  V1UpdateNodeResp,
 } from "./types";
 
 /**
@@ -164,14 +165,21 @@ export const getApiV1NodeDetail = (
  * @summary Update Node Detail
  * @request PUT:/api/v1/node/detail
  * @secure
- * @response `200` `DomainResponse` OK
+ * @response `200` `(DomainPWResponse & {
+    data?: V1UpdateNodeResp,
+
+})` 修订号过期时 code 为 40009，data 为 v1.NodeConflictResp
  */
 
 export const putApiV1NodeDetail = (
   body: DomainUpdateNodeReq,
   params: RequestParams = {},
 ) =>
-  httpRequest<DomainResponse>({
+  httpRequest<
+    DomainPWResponse & {
+      data?: V1UpdateNodeResp;
+    }
+  >({
     path: `/api/v1/node/detail`,
     method: "PUT",
     body: body,
@@ -1365,6 +1365,8 @@ export interface DomainUpdateNodeReq {
   name?: string;
   nav_id?: string;
   position?: number;
+  /** 编辑时获取的修订号，修改名称或内容时必须传入 */
+  revision?: number;
   summary?: string;
 }
 
@@ -1742,6 +1744,31 @@ export interface V1NavUpdateReq {
   name: string;
 }
 
+export interface V1NodeConflictFields {
+  content?: string;
+  name?: string;
+}
+
+export interface V1NodeConflictMerged {
+  /** 共同祖先已被清理，合并结果可能包含更多冲突 */
+  base_missing?: boolean;
+  /** 冲突块数，为 0 时可直接以当前修订号保存 */
+  conflicts?: number;
+  content?: string;
+  name?: string;
+}
+
+export interface V1NodeConflictResp {
+  base_revision?: number;
+  current?: V1NodeConflictFields;
+  current_revision?: number;
+  edit_time?: string;
+  editor_account?: string;
+  editor_id?: string;
+  id?: string;
+  merged?: V1NodeConflictMerged;
+}
+
 export interface V1NodeDetailResp {
   content?: string;
   created_at?: string;
@@ -1759,6 +1786,7 @@ export interface V1NodeDetailResp {
   publisher_account?: string;
   publisher_id?: string;
   pv?: number;
+  revision?: number;
   status?: DomainNodeStatus;
   type?: DomainNodeType;
   updated_at?: string;
@@ -1862,6 +1890,10 @@ export interface V1StatCountResp {
   session_count?: number;
 }
 
+export interface V1UpdateNodeResp {
+  revision?: number;
+}
+
 export interface V1UserInfoResp {
   account?: string;
   created_at?: string;
@@ -0,0 +1,19 @@
+import { getApiV1NodeDetail, putApiV1NodeDetail } from '@/request/Node';
+import { DomainUpdateNodeReq, V1NodeConflictResp } from '@/request/types';
+
+// 修订号过期时接口返回的错误码
+export const NODE_CONFLICT_CODE = 40009;
+
+export const getNodeConflict = (error: unknown) => {
+  const res = error as { code?: number; data?: V1NodeConflictResp } | null;
+  if (res?.code !== NODE_CONFLICT_CODE || !res.data) return null;
+  return res.data;
+};
+
+// 编辑器之外修改名称、还原版本等操作以最新修订号保存，不检查冲突
+export const putNodeDetailWithLatestRevision = async (
+  body: Omit<DomainUpdateNodeReq, 'revision'>,
+) => {
+  const detail = await getApiV1NodeDetail({ id: body.id, kb_id: body.kb_id });
+  return putApiV1NodeDetail({ ...body, revision: detail.revision });
+};